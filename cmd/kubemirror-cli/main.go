@@ -0,0 +1,283 @@
+// Command kubemirror-cli shows how kubemirror mirrors resources: where a source
+// is mirrored and in what state, which namespaces an annotation change would
+// target, which mirrors were left behind, and which kubemirror annotations are
+// invalid. It only reads from the cluster.
+//
+// Installed on the PATH as kubectl-kubemirror it also runs as `kubectl kubemirror`.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/discovery"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/inspect"
+)
+
+const usage = `Inspect how kubemirror mirrors resources.
+
+Usage:
+  kubemirror-cli <command> [flags] [NAME]
+
+Commands:
+  status NAME      Show the namespaces a source resolves to and the state of each mirror
+  targets NAME     List the namespaces a source resolves to
+  simulate [NAME]  Resolve target namespaces with annotations changed, e.g.
+                   simulate --annotation target-namespaces=prod-* my-secret
+  orphans          List mirrors whose source is gone, was recreated or is now mirrored under another name
+  lint             Check the kubemirror labels and annotations of all sources; exits 1 on errors,
+                   e.g. as a pre-upgrade check in CI
+
+Run 'kubemirror-cli <command> -h' for the flags of a command.
+
+Targets are resolved like the controller resolves them when given the same
+--excluded-namespaces, --included-namespaces, --max-targets and
+--truncation-policy. Mirror policies and custom target resolvers are not consulted.
+`
+
+// options are the flags shared by all commands.
+type options struct {
+	kubeconfig    string
+	kubeContext   string
+	namespace     string
+	resourceType  string
+	resourceTypes string
+	excluded      string
+	included      string
+	maxTargets    int
+	truncation    string
+	output        string
+	annotations   []string
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes one command and returns the process exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		_, _ = fmt.Fprint(stderr, usage)
+		return 2
+	}
+	command, args := args[0], args[1:]
+	switch command {
+	case "status", "targets", "simulate", "orphans", "lint":
+	case "help", "-h", "--help":
+		_, _ = fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		_, _ = fmt.Fprintf(stderr, "unknown command %q\n\n%s", command, usage)
+		return 2
+	}
+
+	opts := &options{}
+	fs := newFlagSet(command, opts, stderr)
+	positional, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		return 2
+	}
+
+	if err := execute(ctx, command, opts, positional, stdout); err != nil {
+		_, _ = fmt.Fprintf(stderr, "error: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// newFlagSet declares the flags of command.
+func newFlagSet(command string, opts *options, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file (default: $KUBECONFIG or ~/.kube/config).")
+	fs.StringVar(&opts.kubeContext, "context", "", "Kubeconfig context to use (default: the current context).")
+	fs.StringVar(&opts.output, "o", inspect.FormatTable, "Output format: table or json.")
+
+	if command == "orphans" || command == "lint" {
+		fs.StringVar(&opts.resourceTypes, "resource-types", "",
+			"Comma-separated list of resource types to check (e.g., 'Secret.v1,ConfigMap.v1'). "+
+				"If empty, all mirrorable resources are auto-discovered.")
+		return fs
+	}
+
+	fs.StringVar(&opts.namespace, "n", "", "Namespace of the source (default: the kubeconfig context's namespace).")
+	fs.StringVar(&opts.resourceType, "type", "Secret.v1",
+		"Resource type of the source, as 'Kind.version[.group]' (e.g., 'ConfigMap.v1').")
+	fs.StringVar(&opts.excluded, "excluded-namespaces", "",
+		"Comma-separated namespaces the controller excludes, in addition to the built-in exclusions.")
+	fs.StringVar(&opts.included, "included-namespaces", "",
+		"Comma-separated namespace patterns the controller is limited to (empty = all).")
+	fs.IntVar(&opts.maxTargets, "max-targets", 100, "The controller's maximum number of target namespaces per resource.")
+	fs.StringVar(&opts.truncation, "truncation-policy", string(config.DefaultTruncationPolicy),
+		"The controller's truncation policy: 'alphabetical', 'oldest-first' or 'fail'.")
+	if command == "simulate" {
+		fs.Func("annotation", "Annotation to set before resolving, as key=value; keys without a prefix are "+
+			"kubemirror annotations and an empty value removes the annotation. Repeatable.", func(value string) error {
+			opts.annotations = append(opts.annotations, value)
+			return nil
+		})
+	}
+	return fs
+}
+
+// parseInterspersed parses flags given before and after positional arguments,
+// as kubectl does, and returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// execute runs command against the cluster.
+func execute(ctx context.Context, command string, opts *options, positional []string, stdout io.Writer) error {
+	if err := inspect.ValidateFormat(opts.output); err != nil {
+		return err
+	}
+	maxPositional := 1
+	if command == "orphans" || command == "lint" {
+		maxPositional = 0
+	}
+	if len(positional) > maxPositional {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(positional[maxPositional:], " "))
+	}
+	if len(positional) == 0 && (command == "status" || command == "targets") {
+		return fmt.Errorf("%s requires the name of a source", command)
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = opts.kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: opts.kubeContext})
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	c, err := client.New(restConfig, client.Options{Scheme: clientgoscheme.Scheme})
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	if command == "orphans" || command == "lint" {
+		var resourceTypes []config.ResourceType
+		if opts.resourceTypes != "" {
+			resourceTypes, err = config.ParseResourceTypes(opts.resourceTypes)
+		} else {
+			var rd *discovery.ResourceDiscovery
+			if rd, err = discovery.NewResourceDiscovery(restConfig); err == nil {
+				resourceTypes, err = rd.DiscoverMirrorableResources(ctx)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to determine resource types: %w", err)
+		}
+		if command == "lint" {
+			return lint(ctx, &inspect.Inspector{Client: c}, resourceTypes, opts.output, stdout)
+		}
+		orphans, err := (&inspect.Inspector{Client: c}).Orphans(ctx, resourceTypes)
+		if writeErr := inspect.WriteOrphans(stdout, orphans, opts.output); writeErr != nil {
+			return writeErr
+		}
+		return err
+	}
+
+	rt, err := config.ParseResourceType(opts.resourceType)
+	if err != nil {
+		return err
+	}
+	truncation, err := config.ParseTruncationPolicy(opts.truncation)
+	if err != nil {
+		return err
+	}
+	namespace := opts.namespace
+	if namespace == "" {
+		if namespace, _, err = clientConfig.Namespace(); err != nil {
+			return fmt.Errorf("failed to determine namespace: %w", err)
+		}
+	}
+	inspector := &inspect.Inspector{
+		Client: c,
+		Filter: filter.NewNamespaceFilter(
+			append(append([]string{}, constants.DefaultExcludedNamespaces...), filter.ParseTargetNamespaces(opts.excluded)...),
+			filter.ParseTargetNamespaces(opts.included)),
+		Config: &config.Config{MaxTargetsPerResource: opts.maxTargets, TruncationPolicy: truncation},
+	}
+
+	var source *unstructured.Unstructured
+	if len(positional) == 1 {
+		if source, err = inspector.Get(ctx, rt.GroupVersionKind(), types.NamespacedName{Namespace: namespace, Name: positional[0]}); err != nil {
+			return err
+		}
+	} else {
+		// Simulating a source that does not exist yet
+		source = &unstructured.Unstructured{}
+		source.SetGroupVersionKind(rt.GroupVersionKind())
+		source.SetNamespace(namespace)
+		source.SetName("simulated")
+		source.SetLabels(map[string]string{constants.LabelEnabled: "true"})
+		source.SetAnnotations(map[string]string{constants.AnnotationSync: "true"})
+	}
+
+	switch command {
+	case "status":
+		report, err := inspector.Status(ctx, source)
+		if err != nil {
+			return err
+		}
+		return inspect.WriteStatus(stdout, report, opts.output)
+	case "simulate":
+		annotations, err := inspect.ParseAnnotations(opts.annotations)
+		if err != nil {
+			return err
+		}
+		source = inspect.Simulate(source, annotations)
+	}
+	report, err := inspector.Targets(ctx, source)
+	if err != nil {
+		return err
+	}
+	return inspect.WriteTargets(stdout, report, opts.output)
+}
+
+// lint writes the findings of the lint command, and fails when any is an error.
+func lint(ctx context.Context, inspector *inspect.Inspector, resourceTypes []config.ResourceType, output string, stdout io.Writer) error {
+	findings, err := inspector.Lint(ctx, resourceTypes)
+	if writeErr := inspect.WriteLint(stdout, findings, output); writeErr != nil {
+		return writeErr
+	}
+	if err != nil {
+		return err
+	}
+	if inspect.HasErrors(findings) {
+		return errors.New("invalid kubemirror annotations found")
+	}
+	return nil
+}
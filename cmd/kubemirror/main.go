@@ -5,16 +5,26 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
+	"maps"
 	"net/http"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
@@ -22,11 +32,24 @@ import (
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	"github.com/lukaszraczylo/kubemirror/pkg/circuitbreaker"
+	"github.com/lukaszraczylo/kubemirror/pkg/compat"
 	"github.com/lukaszraczylo/kubemirror/pkg/config"
 	"github.com/lukaszraczylo/kubemirror/pkg/constants"
 	"github.com/lukaszraczylo/kubemirror/pkg/controller"
 	"github.com/lukaszraczylo/kubemirror/pkg/discovery"
 	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/health"
+	"github.com/lukaszraczylo/kubemirror/pkg/informer"
+	"github.com/lukaszraczylo/kubemirror/pkg/policy"
+	"github.com/lukaszraczylo/kubemirror/pkg/prune"
+	"github.com/lukaszraczylo/kubemirror/pkg/ratelimit"
+	"github.com/lukaszraczylo/kubemirror/pkg/sealing"
+	"github.com/lukaszraczylo/kubemirror/pkg/sharding"
+	"github.com/lukaszraczylo/kubemirror/pkg/status"
+	"github.com/lukaszraczylo/kubemirror/pkg/summary"
+	"github.com/lukaszraczylo/kubemirror/pkg/sweeper"
+	"github.com/lukaszraczylo/kubemirror/pkg/tracing"
+	"github.com/lukaszraczylo/kubemirror/pkg/transformer"
 )
 
 var (
@@ -57,16 +80,33 @@ func makeCacheSyncChecker(c cache.Cache, ctx context.Context, logger logr.Logger
 }
 
 func main() {
+	// Subcommands; without one, run the controller
+	if len(os.Args) > 1 && os.Args[1] == "prune" {
+		os.Exit(runPrune(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "sweep" {
+		os.Exit(runSweep(os.Args[2:]))
+	}
+
 	var (
+		configFile            string
 		metricsAddr           string
 		probeAddr             string
 		enableLeaderElection  bool
 		leaderElectionID      string
+		leaderElectionNs      string
+		leaderElectionLock    string
+		leaseDuration         time.Duration
+		renewDeadline         time.Duration
+		retryPeriod           time.Duration
 		excludedNamespaces    string
 		includedNamespaces    string
 		resourceTypes         string
 		discoveryInterval     time.Duration
+		discoveryIncludes     string
+		discoveryExcludes     string
 		maxTargets            int
+		truncationPolicy      string
 		workerThreads         int
 		rateLimitQPS          float64
 		rateLimitBurst        int
@@ -74,8 +114,56 @@ func main() {
 		verifySourceFreshness bool
 		lazyWatcherInit       bool
 		watcherScanInterval   time.Duration
+		watcherIdleScans      int
+		statusBackend         string
+		conflictPolicy        string
+		useOwnerReferences    bool
+		allowNsCreation       bool
+		mirrorLabels          string
+		mirrorAnnotations     string
+		sealedSecretsCert     string
+		gitopsIgnore          bool
+		createdNsLabels       string
+		createdNsAnnotations  string
+		dryRun                bool
+		previewEndpoint       bool
+		paused                bool
+		pausedResourceTypes   string
+		shardByResourceType   bool
+		namespaceShards       int
+		maxShardsPerReplica   int
+		listPageSize          int64
+		watchTimeout          time.Duration
+		watchBookmarks        bool
+		templateLookupAllow   string
+		templateContext       string
+		clusterName           string
+		templateWorkers       int
+		defaultTransformRules string
+		ruleLibraries         bool
+		serverDryRunTypes     string
+		summaryInterval       time.Duration
+		maxReconcileFailures  int
+		targetResolvers       string
+		sweepInterval         time.Duration
+		expiryInterval        time.Duration
+		mirrorPolicies        bool
+		annotationCompat      string
+		multiCluster          bool
+		remoteClusterQPS      float64
+		remoteClusterBurst    int
+		logFormat             string
+		watchNamespaces       string
+		otlpEndpoint          string
+		otlpInsecure          bool
+		traceSamplingRatio    float64
 	)
 
+	flag.StringVar(&configFile, "config", "",
+		"Path to a YAML config file (e.g. /etc/kubemirror/config.yaml) setting excluded/included namespaces, "+
+			"resource types, max targets, API rate limits, circuit breaker settings and default transform rules. "+
+			"Its settings take precedence over the matching flags. The file is watched and changes are applied "+
+			"without a restart, except resource types.")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -83,25 +171,65 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&leaderElectionID, "leader-election-id", constants.LeaderElectionID,
 		"The name of the leader election lease.")
+	flag.StringVar(&leaderElectionNs, "leader-election-namespace", "",
+		"Namespace holding the leader election leases. Empty detects the namespace the pod runs in, "+
+			"from the POD_NAMESPACE environment variable or the mounted service account.")
+	flag.DurationVar(&leaseDuration, "leader-election-lease-duration", 15*time.Second,
+		"How long standby replicas wait before taking over a lease that was not renewed.")
+	flag.DurationVar(&renewDeadline, "leader-election-renew-deadline", 10*time.Second,
+		"How long the leader keeps retrying to renew its lease before giving it up.")
+	flag.DurationVar(&retryPeriod, "leader-election-retry-period", 2*time.Second,
+		"How long replicas wait between attempts to acquire or renew a lease.")
+	flag.StringVar(&leaderElectionLock, "leader-election-resource-lock", sharding.ResourceLockLeases,
+		"Kind of object holding the leader election leases: 'leases' or 'configmaps' "+
+			"for older clusters without the coordination.k8s.io API.")
+	flag.BoolVar(&shardByResourceType, "shard-by-resource-type", false,
+		"Run a separate leader election per resource type so different replicas can own different types "+
+			"(e.g. Secret and ConfigMap fan-out on different pods). Replaces --leader-elect.")
+	flag.IntVar(&namespaceShards, "namespace-shards", 0,
+		"Split target namespaces into this many hash shards, each guarded by its own lease. "+
+			"Each replica only writes mirrors into namespaces of the shards it holds. 0 disables. Replaces --leader-elect.")
+	flag.IntVar(&maxShardsPerReplica, "max-namespace-shards-per-replica", 0,
+		"Maximum number of namespace shards a single replica holds, so shards spread across replicas "+
+			"(set to ceil(namespace-shards / replicas); 0 = unlimited).")
 	flag.StringVar(&excludedNamespaces, "excluded-namespaces", "",
 		"Comma-separated list of namespaces to exclude from mirroring (in addition to defaults).")
 	flag.StringVar(&includedNamespaces, "included-namespaces", "",
 		"Comma-separated list of namespace patterns to include (empty = all allowed).")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"Comma-separated list of namespaces to watch, for namespace-scoped RBAC. Caches, sources and targets "+
+			"are limited to them, and Namespace objects are not read, so namespace labels are ignored (empty = all).")
 	flag.StringVar(&resourceTypes, "resource-types", "",
 		"Comma-separated list of resource types to mirror (e.g., 'Secret.v1,ConfigMap.v1,Ingress.v1.networking.k8s.io'). "+
 			"If empty, all mirrorable resources will be auto-discovered.")
 	flag.DurationVar(&discoveryInterval, "discovery-interval", 5*time.Minute,
 		"Interval for rediscovering available resources (auto-discovery mode only).")
+	flag.StringVar(&discoveryIncludes, "discovery-include-groups", "",
+		"Comma-separated API group glob patterns auto-discovery is limited to, with '\"\"' or 'core' for the core group "+
+			"(e.g., '\"\",networking.k8s.io'; empty = all groups).")
+	flag.StringVar(&discoveryExcludes, "discovery-exclude-groups", "",
+		"Comma-separated API group glob patterns auto-discovery skips (e.g., 'metrics.k8s.io,*.cattle.io').")
 	flag.IntVar(&maxTargets, "max-targets", 100,
 		"Maximum number of target namespaces per resource.")
+	flag.StringVar(&truncationPolicy, "truncation-policy", string(config.DefaultTruncationPolicy),
+		"Which target namespaces a source over its max-targets limit keeps: 'alphabetical' (the first by name), "+
+			"'oldest-first' (the first created) or 'fail' (none; the source is not synced and its existing mirrors are left alone).")
 	flag.IntVar(&workerThreads, "worker-threads", 5,
-		"Number of concurrent reconciliation workers.")
+		"Number of concurrent reconciliation workers, and of target namespaces a source is mirrored to in parallel.")
 	flag.Float64Var(&rateLimitQPS, "rate-limit-qps", 50.0,
 		"QPS rate limit for API server requests.")
 	flag.IntVar(&rateLimitBurst, "rate-limit-burst", 100,
 		"Burst limit for API server requests.")
 	flag.DurationVar(&resyncPeriod, "resync-period", 10*time.Minute,
 		"Period for resyncing all resources (catches updates missed due to informer cache delays).")
+	flag.Int64Var(&listPageSize, "list-page-size", 0,
+		"Number of objects per paginated LIST request made by informers (0 = client-go default of 500). "+
+			"Smaller pages lower the memory spike of initial lists on clusters with many objects.")
+	flag.DurationVar(&watchTimeout, "watch-timeout", 0,
+		"How long each informer watch stays open before it is re-established (0 = client-go default of 5-10m). "+
+			"The API server sends a bookmark before closing a watch, so this also bounds the bookmark interval.")
+	flag.BoolVar(&watchBookmarks, "watch-bookmarks", true,
+		"Request BOOKMARK events on informer watches so reconnects resume from a recent resourceVersion instead of relisting.")
 	flag.BoolVar(&verifySourceFreshness, "verify-source-freshness", false,
 		"Verify source resource freshness by comparing cache with direct API read. "+
 			"Prevents mirroring stale data when cache lags behind watch events. "+
@@ -112,6 +240,118 @@ func main() {
 			"Recommended for production environments with many unused resource types.")
 	flag.DurationVar(&watcherScanInterval, "watcher-scan-interval", 5*time.Minute,
 		"Interval for scanning cluster to detect new resource types needing watchers (lazy-watcher-init mode only).")
+	flag.IntVar(&watcherIdleScans, "watcher-idle-scans", 3,
+		"Stop the controllers and informer of a resource type after this many consecutive scans found no enabled "+
+			"source of it, reclaiming their memory (lazy-watcher-init mode only). 0 keeps them running.")
+	flag.StringVar(&templateLookupAllow, "template-lookup-allow", "",
+		"Comma-separated list of 'namespace/name' glob patterns of ConfigMaps that transform templates may read "+
+			"with the lookup function (e.g. '*/mirror-settings'). Empty disables lookup.")
+	flag.StringVar(&templateContext, "template-context-configmap", "",
+		"Name of the ConfigMap in each target namespace that transform templates read with the contextValue "+
+			"function (e.g. 'kubemirror-context'). Empty disables contextValue.")
+	flag.StringVar(&clusterName, "cluster-name", "",
+		"Name of this cluster, read by transform templates as .ClusterName (e.g. 'prod-eu'). "+
+			"Mirrors in remote clusters get the name their cluster is registered with instead.")
+	flag.IntVar(&templateWorkers, "template-workers", 0,
+		"Maximum number of transform templates executing at once across all mirrors; executions beyond it wait "+
+			"for a worker within their template timeout. 0 uses one worker per CPU.")
+	flag.StringVar(&defaultTransformRules, "default-transform-rules", "",
+		"Path to a YAML file of transform rules applied to every mirror before the source's own rules, "+
+			"keyed by resource type (e.g. 'Secret.v1') or '*' for all types. Empty disables default rules.")
+	flag.BoolVar(&ruleLibraries, "transform-rule-libraries", false,
+		"Let sources reference shared transform rules with the "+constants.AnnotationTransformRef+" annotation. "+
+			"Rule libraries are ConfigMaps labeled "+constants.LabelTransformRules+"=true holding their rules "+
+			"under the '"+constants.TransformRulesKey+"' key; changing one re-syncs the mirrors of the sources referencing it.")
+	flag.StringVar(&serverDryRunTypes, "server-dry-run-types", "",
+		"Comma-separated list of resource types (e.g. 'Ingress.v1.networking.k8s.io') whose mirror writes are first "+
+			"sent as a server-side dry run. Admission rejections are then reported without writing anything. "+
+			"Doubles write requests for those types. Empty disables.")
+	flag.StringVar(&targetResolvers, "target-resolvers", controller.AnnotationResolverName,
+		"Comma-separated list of target resolvers deciding which namespaces receive mirrors; a source is mirrored "+
+			"to the union of their results. Available: "+strings.Join(controller.RegisteredTargetResolvers(), ", ")+".")
+	flag.DurationVar(&summaryInterval, "summary-interval", 0,
+		"How often to write the per-resource-type mirror summary (sources, mirrors, out-of-sync mirrors) "+
+			"to the kubemirror-summary ConfigMap and summary metrics, for dashboards. "+
+			"Each refresh lists the sources and mirrors of every mirrored type. 0 disables.")
+	flag.IntVar(&maxReconcileFailures, "max-consecutive-reconcile-failures", 10,
+		"Fail the readiness probe while a controller's last N reconciles all failed; "+
+			"it passes again after the next successful reconcile. 0 disables.")
+	flag.DurationVar(&sweepInterval, "sweep-interval", 0,
+		"How often to scan all mirrors and delete those whose source is gone or was recreated, "+
+			"as a safety net for events the mirror controllers missed. 0 disables.")
+	flag.DurationVar(&expiryInterval, "expiry-interval", time.Minute,
+		"How often to delete mirrors whose "+constants.AnnotationMirrorTTL+" expired. Without it, expired mirrors "+
+			"are removed at the next reconcile of their source. 0 disables.")
+	flag.BoolVar(&mirrorPolicies, "mirror-policies", false,
+		"Mirror sources selected by ClusterMirrorPolicy resources in addition to annotated ones. "+
+			"Requires the ClusterMirrorPolicy CRD.")
+	flag.StringVar(&annotationCompat, "annotation-compat", "",
+		"Comma-separated list of other mirroring controllers whose source annotations are honoured, to migrate "+
+			"without re-annotating every resource at once: "+compat.Reflector+" (emberstack/reflector auto-reflection), "+
+			compat.Replicator+" (mittwald/kubernetes-replicator replicate-to). Empty disables.")
+	flag.BoolVar(&multiCluster, "multi-cluster", false,
+		"Push mirrors to remote clusters registered through kubeconfig Secrets labeled "+
+			constants.LabelClusterSecret+"=true in the controller namespace. "+
+			"Sources select clusters with the "+constants.AnnotationTargetClusters+" annotation.")
+	flag.Float64Var(&remoteClusterQPS, "remote-cluster-qps", 20.0,
+		"QPS rate limit for API requests to each remote cluster.")
+	flag.IntVar(&remoteClusterBurst, "remote-cluster-burst", 30,
+		"Burst limit for API requests to each remote cluster.")
+	flag.StringVar(&statusBackend, "status-backend", status.DefaultBackend,
+		"Where to report per-source sync status: 'events' (Kubernetes Events, does not modify sources), "+
+			"'annotation' (sync-status annotation on the source, legacy behavior) or "+
+			"'resource' (companion MirrorStatus resource, requires the MirrorStatus CRD).")
+	flag.StringVar(&conflictPolicy, "conflict-policy", string(controller.DefaultConflictPolicy),
+		"What to do when a target namespace already holds an object of the mirror's name that kubemirror does not manage: "+
+			"'skip' (leave it, report the target as skipped), 'overwrite' (adopt it as a mirror), 'fail' (leave it, fail the target) "+
+			"or 'adopt-if-identical' (adopt it if its content is the mirror's, else skip it). "+
+			"Sources override it with the "+constants.AnnotationConflictPolicy+" annotation.")
+	flag.StringVar(&mirrorLabels, "mirror-labels", "",
+		"Comma-separated key=value labels stamped on every mirror.")
+	flag.StringVar(&mirrorAnnotations, "mirror-annotations", "",
+		"Comma-separated key=value annotations stamped on every mirror; they override --gitops-ignore-annotations.")
+	flag.StringVar(&sealedSecretsCert, "sealed-secrets-cert", "",
+		"PEM certificate of the sealed-secrets controller (as written by 'kubeseal --fetch-cert'). "+
+			"Secret sources with "+constants.AnnotationSealedMirror+"=true are mirrored as SealedSecrets sealed with it.")
+	flag.BoolVar(&gitopsIgnore, "gitops-ignore-annotations", false,
+		"Stamp argocd.argoproj.io/compare-options=IgnoreExtraneous and kustomize.toolkit.fluxcd.io/prune=disabled "+
+			"on every mirror, so Argo CD and Flux neither prune mirrors nor report them out of sync.")
+	flag.BoolVar(&allowNsCreation, "allow-namespace-creation", false,
+		"Let sources annotated with create-missing-namespaces create the target namespaces they list by name "+
+			"that do not exist yet. Created namespaces are kept when the source is deleted.")
+	flag.StringVar(&createdNsLabels, "created-namespace-labels", "",
+		"Comma-separated key=value labels set on namespaces created for sources (e.g. 'team=platform').")
+	flag.StringVar(&createdNsAnnotations, "created-namespace-annotations", "",
+		"Comma-separated key=value annotations set on namespaces created for sources.")
+	flag.BoolVar(&useOwnerReferences, "use-owner-references", false,
+		"Have a MirrorBinding in each target namespace own the mirrors there, so deleting a source removes "+
+			"its mirrors through Kubernetes garbage collection instead of a lookup in every namespace. "+
+			"Requires the MirrorBinding CRD.")
+	flag.BoolVar(&dryRun, "dry-run", false,
+		"Report the mirrors that would be created, updated and deleted (Events, logs, kubemirror_dry_run_changes_total) "+
+			"without writing them. Sources opt in individually with the "+constants.AnnotationDryRun+" annotation.")
+	flag.BoolVar(&previewEndpoint, "preview-endpoint", false,
+		"Serve POST "+controller.PreviewPath+" on the metrics server: it returns the mirror that would be written "+
+			"for a source manifest in a target namespace, with the live transform settings, without writing it.")
+	flag.BoolVar(&paused, "paused", false,
+		"Pause all mirroring: mirrors are kept as they are, without updates or cleanups, until resumed. "+
+			"Sources pause individually with the "+constants.AnnotationPaused+" annotation.")
+	flag.StringVar(&pausedResourceTypes, "paused-resource-types", "",
+		"Comma-separated list of resource types (e.g. 'Ingress.v1.networking.k8s.io') whose mirroring is paused. Empty pauses none.")
+
+	flag.StringVar(&logFormat, "log-format", "console",
+		"Log output format: 'console' (human-readable, debug level) or 'json' (one JSON object per line, "+
+			"info level, ISO 8601 timestamps). Every reconcile logs its reconcileID, which is also recorded "+
+			"on the mirrors it writes in the "+constants.AnnotationReconcileID+" annotation. "+
+			"--zap-encoder, --zap-log-level and --zap-time-encoding override individual settings.")
+
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", "",
+		"host:port of an OTLP gRPC receiver (e.g. an OpenTelemetry Collector) to export reconcile traces to. "+
+			"Empty disables tracing.")
+	flag.BoolVar(&otlpInsecure, "otlp-insecure", false,
+		"Export traces to --otlp-endpoint without TLS.")
+	flag.Float64Var(&traceSamplingRatio, "trace-sampling-ratio", 1.0,
+		"Fraction of reconciles traced, between 0 and 1.")
 
 	opts := zap.Options{
 		Development: true,
@@ -119,34 +359,150 @@ func main() {
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	logFormatErr := applyLogFormat(&opts, logFormat)
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	if logFormatErr != nil {
+		setupLog.Error(logFormatErr, "invalid log format")
+		os.Exit(1)
+	}
 
 	setupLog.Info("starting kubemirror controller",
 		"version", "dev",
 		"maxTargets", maxTargets,
 		"workers", workerThreads,
+		"dryRun", dryRun,
 	)
 
 	// Create controller configuration
 	cfg := &config.Config{
-		MaxTargetsPerResource: maxTargets,
-		DebounceDuration:      500 * time.Millisecond,
-		WorkerThreads:         workerThreads,
-		RateLimitQPS:          float32(rateLimitQPS),
-		RateLimitBurst:        rateLimitBurst,
-		EnableAllKeyword:      true,
-		RequireNamespaceOptIn: false,
-		VerifySourceFreshness: verifySourceFreshness,
+		MaxTargetsPerResource:    maxTargets,
+		DebounceDuration:         500 * time.Millisecond,
+		WorkerThreads:            workerThreads,
+		RateLimitQPS:             float32(rateLimitQPS),
+		RateLimitBurst:           rateLimitBurst,
+		EnableAllKeyword:         true,
+		RequireNamespaceOptIn:    false,
+		VerifySourceFreshness:    verifySourceFreshness,
+		StatusBackend:            statusBackend,
+		ConflictPolicy:           conflictPolicy,
+		UseOwnerReferences:       useOwnerReferences,
+		AllowNamespaceCreation:   allowNsCreation,
+		DryRun:                   dryRun,
+		ListPageSize:             listPageSize,
+		WatchTimeout:             watchTimeout,
+		WatchBookmarks:           watchBookmarks,
+		TemplateLookupAllow:      filter.ParseTargetNamespaces(templateLookupAllow),
+		TemplateContextConfigMap: templateContext,
+		ClusterName:              clusterName,
+		TemplatePool:             transformer.NewTemplatePool(templateWorkers),
+		TargetResolvers:          filter.ParseTargetNamespaces(targetResolvers),
 		LeaderElection: config.LeaderElectionConfig{
-			Enabled:           enableLeaderElection,
-			ResourceName:      leaderElectionID,
-			ResourceNamespace: "", // Will be auto-detected
-			LeaseDuration:     15 * time.Second,
-			RenewDeadline:     10 * time.Second,
-			RetryPeriod:       2 * time.Second,
+			Enabled:                      enableLeaderElection,
+			ResourceName:                 leaderElectionID,
+			ResourceNamespace:            leaderElectionNs, // Auto-detected below when empty
+			LeaseDuration:                leaseDuration,
+			RenewDeadline:                renewDeadline,
+			RetryPeriod:                  retryPeriod,
+			ResourceLock:                 leaderElectionLock,
+			ShardByResourceType:          shardByResourceType,
+			NamespaceShards:              namespaceShards,
+			MaxNamespaceShardsPerReplica: maxShardsPerReplica,
 		},
 	}
 
+	if _, err := controller.ParseConflictPolicy(conflictPolicy); err != nil {
+		setupLog.Error(err, "invalid conflict policy")
+		os.Exit(1)
+	}
+	parsedTruncation, err := config.ParseTruncationPolicy(truncationPolicy)
+	if err != nil {
+		setupLog.Error(err, "invalid truncation policy")
+		os.Exit(1)
+	}
+	cfg.TruncationPolicy = parsedTruncation
+
+	var parseErr error
+	if cfg.MirrorLabels, parseErr = parseMirrorMetadata(mirrorLabels, true, nil); parseErr != nil {
+		setupLog.Error(parseErr, "invalid mirror labels")
+		os.Exit(1)
+	}
+	var presetAnnotations map[string]string
+	if gitopsIgnore {
+		presetAnnotations = controller.GitOpsIgnoreAnnotations
+	}
+	if cfg.MirrorAnnotations, parseErr = parseMirrorMetadata(mirrorAnnotations, false, presetAnnotations); parseErr != nil {
+		setupLog.Error(parseErr, "invalid mirror annotations")
+		os.Exit(1)
+	}
+	if sealedSecretsCert != "" {
+		if cfg.SealingKey, parseErr = sealing.LoadCertificate(sealedSecretsCert); parseErr != nil {
+			setupLog.Error(parseErr, "invalid sealed-secrets certificate", "path", sealedSecretsCert)
+			os.Exit(1)
+		}
+	}
+	if cfg.CreatedNamespaceLabels, parseErr = parseKeyValues(createdNsLabels, true); parseErr != nil {
+		setupLog.Error(parseErr, "invalid created namespace labels")
+		os.Exit(1)
+	}
+	if cfg.CreatedNamespaceAnnotations, parseErr = parseKeyValues(createdNsAnnotations, false); parseErr != nil {
+		setupLog.Error(parseErr, "invalid created namespace annotations")
+		os.Exit(1)
+	}
+
+	if err := configureLeaderElection(&cfg.LeaderElection); err != nil {
+		setupLog.Error(err, "invalid leader election configuration")
+		os.Exit(1)
+	}
+	if cfg.LeaderElection.Active() {
+		setupLog.Info("leader election configured",
+			"namespace", cfg.LeaderElection.ResourceNamespace,
+			"resourceLock", cfg.LeaderElection.ResourceLock,
+			"leaseDuration", cfg.LeaderElection.LeaseDuration,
+			"renewDeadline", cfg.LeaderElection.RenewDeadline,
+			"retryPeriod", cfg.LeaderElection.RetryPeriod,
+		)
+	}
+
+	if defaultTransformRules != "" {
+		rules, err := transformer.LoadDefaultRules(defaultTransformRules)
+		if err != nil {
+			setupLog.Error(err, "failed to load default transform rules", "path", defaultTransformRules)
+			os.Exit(1)
+		}
+		cfg.DefaultTransformRules = rules
+		setupLog.Info("default transform rules loaded", "path", defaultTransformRules)
+	}
+
+	if serverDryRunTypes != "" {
+		dryRunTypes, err := config.ParseResourceTypes(serverDryRunTypes)
+		if err != nil {
+			setupLog.Error(err, "failed to parse server dry-run resource types")
+			os.Exit(1)
+		}
+		cfg.ServerDryRunTypes = dryRunTypes
+		setupLog.Info("server-side dry run enabled", "resourceTypes", serverDryRunTypes)
+	}
+
+	var pausedTypes []config.ResourceType
+	if pausedResourceTypes != "" {
+		var parseErr error
+		if pausedTypes, parseErr = config.ParseResourceTypes(pausedResourceTypes); parseErr != nil {
+			setupLog.Error(parseErr, "failed to parse paused resource types")
+			os.Exit(1)
+		}
+	}
+
+	includeGroups, groupsErr := config.ParseGroupPatterns(discoveryIncludes)
+	if groupsErr != nil {
+		setupLog.Error(groupsErr, "failed to parse discovery include groups")
+		os.Exit(1)
+	}
+	excludeGroups, groupsErr := config.ParseGroupPatterns(discoveryExcludes)
+	if groupsErr != nil {
+		setupLog.Error(groupsErr, "failed to parse discovery exclude groups")
+		os.Exit(1)
+	}
+
 	// Parse namespace filters
 	var excludedList, includedList []string
 	if excludedNamespaces != "" {
@@ -156,23 +512,114 @@ func main() {
 		includedList = filter.ParseTargetNamespaces(includedNamespaces)
 	}
 
+	watchedList, err := parseWatchNamespaces(watchNamespaces)
+	if err != nil {
+		setupLog.Error(err, "invalid watch namespaces")
+		os.Exit(1)
+	}
+	if len(watchedList) > 0 && lazyWatcherInit {
+		setupLog.Error(errors.New("lazy watcher initialization scans all namespaces"),
+			"--watch-namespaces cannot be combined with --lazy-watcher-init")
+		os.Exit(1)
+	}
+	cfg.WatchNamespaces = watchedList
+
 	// Combine with default exclusions
 	allExcluded := append(constants.DefaultExcludedNamespaces, excludedList...)
-	namespaceFilter := filter.NewNamespaceFilter(allExcluded, includedList)
 
+	// Settings the config file can change at runtime; flags supply the values it leaves out
+	flagTunables := config.Tunables{
+		ExcludedNamespaces:    allExcluded,
+		IncludedNamespaces:    includedList,
+		MaxTargets:            maxTargets,
+		TruncationPolicy:      parsedTruncation,
+		RateLimitQPS:          float32(rateLimitQPS),
+		RateLimitBurst:        rateLimitBurst,
+		CircuitBreaker:        circuitbreaker.DefaultConfig(),
+		DefaultTransformRules: cfg.DefaultTransformRules,
+		Paused:                paused,
+		PausedResourceTypes:   pausedTypes,
+	}
+	tunables := flagTunables
+
+	var (
+		configWatcher     *config.FileWatcher
+		fileResourceTypes string
+		fileGroups        [2][]string
+		fileKinds         [2][]schema.GroupKind
+	)
+	discoveryHeuristics := true
+	if configFile != "" {
+		configWatcher = &config.FileWatcher{Path: configFile, Log: ctrl.Log.WithName("config")}
+		file, loadErr := configWatcher.Load()
+		if loadErr != nil {
+			setupLog.Error(loadErr, "failed to load config file", "path", configFile)
+			os.Exit(1)
+		}
+		if tunables, loadErr = file.Apply(flagTunables, constants.DefaultExcludedNamespaces...); loadErr != nil {
+			setupLog.Error(loadErr, "invalid config file", "path", configFile)
+			os.Exit(1)
+		}
+		if len(file.ResourceTypes) > 0 {
+			fileResourceTypes = strings.Join(file.ResourceTypes, ",")
+			resourceTypes = fileResourceTypes
+		}
+		fileGroups[0], fileGroups[1], _ = file.DiscoveryGroups()
+		if file.DiscoveryIncludeGroups != nil {
+			includeGroups = fileGroups[0]
+		}
+		if file.DiscoveryExcludeGroups != nil {
+			excludeGroups = fileGroups[1]
+		}
+		fileKinds[0], fileKinds[1], _ = file.DiscoveryKinds()
+		if file.DiscoveryHeuristics != nil {
+			discoveryHeuristics = *file.DiscoveryHeuristics
+		}
+		if cfg.Queues, loadErr = file.QueueSettings(); loadErr != nil {
+			setupLog.Error(loadErr, "invalid config file", "path", configFile)
+			os.Exit(1)
+		}
+		for rt, settings := range cfg.Queues {
+			setupLog.Info("work queue tuned", "resourceType", rt.String(),
+				"priority", settings.Priority, "maxInFlight", settings.MaxInFlight)
+		}
+		setupLog.Info("config file loaded", "path", configFile)
+	}
+	cfg.ApplyTunables(tunables)
+	if tunables.Paused || len(tunables.PausedResourceTypes) > 0 {
+		setupLog.Info("mirroring paused, mirrors are kept but not updated",
+			"allResourceTypes", tunables.Paused, "resourceTypes", tunables.PausedResourceTypes)
+	}
+
+	namespaceFilter := filter.NewNamespaceFilter(tunables.ExcludedNamespaces, tunables.IncludedNamespaces)
+	namespaceFilter.Restrict(watchedList)
 	setupLog.Info("namespace filters configured",
-		"excluded", allExcluded,
-		"included", includedList,
+		"excluded", tunables.ExcludedNamespaces,
+		"included", tunables.IncludedNamespaces,
+		"watched", watchedList,
 	)
 
+	// Namespace-scoped mode: informers list and watch each namespace instead of the cluster
+	var cacheNamespaces map[string]cache.Config
+	if len(cfg.WatchNamespaces) > 0 {
+		cacheNamespaces = make(map[string]cache.Config, len(cfg.WatchNamespaces))
+		for _, ns := range cfg.WatchNamespaces {
+			cacheNamespaces[ns] = cache.Config{}
+		}
+	}
+
 	// Create circuit breaker for reconciliation failures
-	cb := circuitbreaker.NewWithDefaults()
+	cb := circuitbreaker.New(tunables.CircuitBreaker)
 	setupLog.Info("circuit breaker initialized",
-		"failureThreshold", 5,
-		"resetTimeout", "5m",
-		"halfOpenSuccessThreshold", 2,
+		"failureThreshold", tunables.CircuitBreaker.FailureThreshold,
+		"resetTimeout", tunables.CircuitBreaker.ResetTimeout,
+		"halfOpenSuccessThreshold", tunables.CircuitBreaker.HalfOpenSuccessThreshold,
 	)
 
+	// Client-side API rate limit, shared by all manager clients so reloads retune them together
+	apiRateLimiter := ratelimit.New(tunables.RateLimitQPS, tunables.RateLimitBurst)
+	setupLog.Info("API rate limit configured", "qps", tunables.RateLimitQPS, "burst", tunables.RateLimitBurst)
+
 	// Parse and configure resource types
 	var mirroredResources []config.ResourceType
 	if resourceTypes != "" {
@@ -186,7 +633,8 @@ func main() {
 		setupLog.Info("using user-specified resource types", "count", len(mirroredResources))
 	} else {
 		// Auto-discovery mode
-		setupLog.Info("enabling resource auto-discovery", "interval", discoveryInterval)
+		setupLog.Info("enabling resource auto-discovery", "interval", discoveryInterval,
+			"includeGroups", includeGroups, "excludeGroups", excludeGroups)
 	}
 
 	cfg.MirroredResourceTypes = mirroredResources
@@ -218,29 +666,99 @@ func main() {
 		return obj, nil
 	}
 
+	cacheTuning := informer.Tuning{
+		ListPageSize: cfg.ListPageSize,
+		WatchTimeout: cfg.WatchTimeout,
+	}
+	setupLog.Info("cache tuning configured",
+		"listPageSize", cacheTuning.ListPageSize,
+		"watchTimeout", cacheTuning.WatchTimeout,
+		"watchBookmarks", cfg.WatchBookmarks,
+		"resyncPeriod", resyncPeriod,
+	)
+
+	// Sharding leases replace the single manager-wide lease
+	managerLeaderElection := cfg.LeaderElection.Enabled &&
+		!cfg.LeaderElection.ShardByResourceType && cfg.LeaderElection.NamespaceShards == 0
+
+	managerRestConfig := ctrl.GetConfigOrDie()
+	managerRestConfig.RateLimiter = apiRateLimiter
+
+	// Leader election talks to the API server directly, outside the manager's rate limiter
+	var leaseClient *kubernetes.Clientset
+	if cfg.LeaderElection.Active() {
+		leaseClient, err = kubernetes.NewForConfig(ctrl.GetConfigOrDie())
+		if err != nil {
+			setupLog.Error(err, "unable to create lease client")
+			os.Exit(1)
+		}
+	}
+
+	// controller-runtime only creates Lease locks, so ConfigMap locks are handed in
+	var managerLock resourcelock.Interface
+	if managerLeaderElection && cfg.LeaderElection.ResourceLock == sharding.ResourceLockConfigMaps {
+		identity, idErr := sharding.NewIdentity()
+		if idErr != nil {
+			setupLog.Error(idErr, "unable to create leader election lock")
+			os.Exit(1)
+		}
+		managerLock, err = sharding.NewResourceLock(cfg.LeaderElection.ResourceLock, cfg.LeaderElection.ResourceNamespace,
+			cfg.LeaderElection.ResourceName, nil, leaseClient.CoreV1(), resourcelock.ResourceLockConfig{Identity: identity})
+		if err != nil {
+			setupLog.Error(err, "unable to create leader election lock")
+			os.Exit(1)
+		}
+	}
+
 	// Set up controller manager with cache configuration
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	mgr, err := ctrl.NewManager(managerRestConfig, ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
 			BindAddress: metricsAddr,
 		},
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         cfg.LeaderElection.Enabled,
-		LeaderElectionID:       cfg.LeaderElection.ResourceName,
-		LeaseDuration:          &cfg.LeaderElection.LeaseDuration,
-		RenewDeadline:          &cfg.LeaderElection.RenewDeadline,
-		RetryPeriod:            &cfg.LeaderElection.RetryPeriod,
+		HealthProbeBindAddress:              probeAddr,
+		LeaderElection:                      managerLeaderElection,
+		LeaderElectionID:                    cfg.LeaderElection.ResourceName,
+		LeaderElectionNamespace:             cfg.LeaderElection.ResourceNamespace,
+		LeaderElectionResourceLockInterface: managerLock,
+		LeaseDuration:                       &cfg.LeaderElection.LeaseDuration,
+		RenewDeadline:                       &cfg.LeaderElection.RenewDeadline,
+		RetryPeriod:                         &cfg.LeaderElection.RetryPeriod,
 		Cache: cache.Options{
 			// Use the transform function to reduce memory usage
 			DefaultTransform: transformFunc,
 			// Increase the resync period to reduce memory churn
 			SyncPeriod: &resyncPeriod,
+			// List/watch tuning for large clusters
+			DefaultEnableWatchBookmarks: &cfg.WatchBookmarks,
+			NewInformer:                 cacheTuning.NewInformerFunc(),
+			// Empty watches all namespaces
+			DefaultNamespaces: cacheNamespaces,
 		},
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to create manager")
 		os.Exit(1)
 	}
+	// Shared transform rules referenced by sources
+	var ruleLibrary *controller.RuleLibrary
+	if ruleLibraries {
+		ruleLibrary = controller.NewRuleLibrary(mgr.GetAPIReader())
+		if err = (&controller.RuleLibraryReconciler{Library: ruleLibrary}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create transform rule library controller")
+			os.Exit(1)
+		}
+		setupLog.Info("transform rule libraries enabled", "label", constants.LabelTransformRules)
+	}
+
+	if previewEndpoint {
+		if err = mgr.AddMetricsServerExtraHandler(controller.PreviewPath,
+			&controller.PreviewHandler{Client: mgr.GetClient(), Config: cfg, RuleLibrary: ruleLibrary}); err != nil {
+			setupLog.Error(err, "unable to add preview endpoint")
+			os.Exit(1)
+		}
+		setupLog.Info("serving mirror previews", "address", metricsAddr, "path", controller.PreviewPath)
+	}
 
 	// Note on Field Indexes:
 	// Field indexes in controller-runtime can improve performance for in-cache lookups.
@@ -257,9 +775,117 @@ func main() {
 	// If benchmarks show indexes would help, use:
 	//   mgr.GetFieldIndexer().IndexField(ctx, &unstructured.Unstructured{...}, indexPath, extractFunc)
 
+	// Create the sync status reporter shared by all source reconcilers
+	statusReporter, err := status.NewReporter(cfg.StatusBackend, mgr.GetClient(), mgr.GetEventRecorder(constants.ControllerName))
+	if err != nil {
+		setupLog.Error(err, "invalid status backend")
+		os.Exit(1)
+	}
+	setupLog.Info("status reporting configured", "backend", cfg.StatusBackend)
+
+	// Set up sharded leader election when enabled.
+	// Every replica runs all controllers, but only writes for the shards whose lease it holds.
+	var (
+		leadership         controller.ResourceTypeLeadership
+		namespaceOwnership controller.NamespaceOwnership
+	)
+
+	if cfg.LeaderElection.ShardByResourceType {
+		elector, electorErr := sharding.NewElector(sharding.ElectorConfig{
+			Client:        leaseClient.CoordinationV1(),
+			ConfigMaps:    leaseClient.CoreV1(),
+			ResourceLock:  cfg.LeaderElection.ResourceLock,
+			Namespace:     cfg.LeaderElection.ResourceNamespace,
+			LeaseDuration: cfg.LeaderElection.LeaseDuration,
+			RenewDeadline: cfg.LeaderElection.RenewDeadline,
+			RetryPeriod:   cfg.LeaderElection.RetryPeriod,
+		})
+		if electorErr != nil {
+			setupLog.Error(electorErr, "unable to create resource type elector")
+			os.Exit(1)
+		}
+
+		if err = mgr.Add(elector); err != nil {
+			setupLog.Error(err, "unable to add resource type elector to manager")
+			os.Exit(1)
+		}
+
+		leadership = &sharding.ResourceTypeSharder{
+			Elector:     elector,
+			LeasePrefix: cfg.LeaderElection.ResourceName,
+		}
+		setupLog.Info("sharding leadership by resource type", "identity", elector.Identity())
+	}
+
+	if cfg.LeaderElection.NamespaceShards > 0 {
+		// A separate elector so the per-replica shard cap doesn't limit resource type leases
+		elector, electorErr := sharding.NewElector(sharding.ElectorConfig{
+			Client:        leaseClient.CoordinationV1(),
+			ConfigMaps:    leaseClient.CoreV1(),
+			ResourceLock:  cfg.LeaderElection.ResourceLock,
+			Namespace:     cfg.LeaderElection.ResourceNamespace,
+			MaxLeases:     cfg.LeaderElection.MaxNamespaceShardsPerReplica,
+			LeaseDuration: cfg.LeaderElection.LeaseDuration,
+			RenewDeadline: cfg.LeaderElection.RenewDeadline,
+			RetryPeriod:   cfg.LeaderElection.RetryPeriod,
+		})
+		if electorErr != nil {
+			setupLog.Error(electorErr, "unable to create namespace shard elector")
+			os.Exit(1)
+		}
+
+		if err = mgr.Add(elector); err != nil {
+			setupLog.Error(err, "unable to add namespace shard elector to manager")
+			os.Exit(1)
+		}
+
+		namespaceOwnership = sharding.NewNamespaceSharder(elector, cfg.LeaderElection.ResourceName, cfg.LeaderElection.NamespaceShards)
+		setupLog.Info("sharding mirror writes by target namespace",
+			"shards", cfg.LeaderElection.NamespaceShards,
+			"maxShardsPerReplica", cfg.LeaderElection.MaxNamespaceShardsPerReplica,
+			"identity", elector.Identity(),
+		)
+	}
+
+	// Readiness gates: the pod only reports ready once controllers are registered,
+	// informer caches have synced and leadership has settled (when enabled)
+	readiness := health.NewReadiness()
+	registrationGate := readiness.Gate("controller-registration")
+	reconcileFailures := health.NewFailureTracker(maxReconcileFailures)
+	if err = mgr.Add(health.CacheSyncRunnable(mgr.GetCache(), readiness.Gate("cache-sync"))); err != nil {
+		setupLog.Error(err, "unable to add cache sync readiness gate")
+		os.Exit(1)
+	}
+	if managerLeaderElection {
+		// Standby replicas become ready once they see an active leader
+		leaseKey := types.NamespacedName{Namespace: cfg.LeaderElection.ResourceNamespace, Name: cfg.LeaderElection.ResourceName}
+		leaderGate := health.LeaderElectionRunnable(mgr.Elected(), mgr.GetAPIReader(), leaseKey,
+			cfg.LeaderElection.LeaseDuration, cfg.LeaderElection.RetryPeriod, readiness.Gate("leader-election"))
+		if err = mgr.Add(leaderGate); err != nil {
+			setupLog.Error(err, "unable to add leader election readiness gate")
+			os.Exit(1)
+		}
+	}
+
 	// Set up signal handler context for graceful shutdown
 	signalCtx := ctrl.SetupSignalHandler()
 
+	shutdownTracing, err := tracing.Setup(signalCtx, tracing.Options{
+		Endpoint:      otlpEndpoint,
+		Insecure:      otlpInsecure,
+		SamplingRatio: traceSamplingRatio,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+	if otlpEndpoint != "" {
+		setupLog.Info("exporting traces", "endpoint", otlpEndpoint, "samplingRatio", traceSamplingRatio)
+	}
+
+	// Resource types currently mirrored; follows rediscovery in auto-discovery mode
+	currentResourceTypes := func() []config.ResourceType { return cfg.MirroredResourceTypes }
+
 	// Set up resource discovery if auto-discovery is enabled
 	if resourceTypes == "" {
 		restConfig := ctrl.GetConfigOrDie()
@@ -269,9 +895,20 @@ func main() {
 			setupLog.Error(err, "unable to create discovery client")
 			os.Exit(1)
 		}
+		if len(includeGroups) > 0 || len(excludeGroups) > 0 {
+			discoveryClient.SetGroupFilter(discovery.NewGroupFilter(includeGroups, excludeGroups))
+		}
+		discoveryClient.SetKindFilter(discovery.NewKindFilter(fileKinds[0], fileKinds[1], discoveryHeuristics))
 
 		discoveryMgr := discovery.NewManager(discoveryClient, discoveryInterval)
 
+		// Best-effort cleanup of mirrors whose resource type disappears from the cluster
+		discoveryMgr.OnRemoved(discovery.NewRemovedTypeCleanup(&prune.Pruner{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("prune"),
+			DryRun: cfg.DryRun,
+		}))
+
 		// Start discovery manager with signal-aware context
 		err = discoveryMgr.Start(signalCtx)
 		if err != nil {
@@ -291,6 +928,7 @@ func main() {
 		// Get discovered resources and update config
 		mirroredResources = discoveryMgr.GetCurrentResources()
 		cfg.MirroredResourceTypes = mirroredResources
+		currentResourceTypes = discoveryMgr.GetCurrentResources
 
 		setupLog.Info("auto-discovered resources",
 			"count", len(mirroredResources),
@@ -298,13 +936,112 @@ func main() {
 		)
 	}
 
-	// Create namespace lister with API reader for fresh namespace lookups.
-	// This ensures label-based queries (allow-mirrors label) return fresh data
-	// and don't suffer from informer cache staleness after label changes.
-	namespaceLister := controller.NewKubernetesNamespaceListerWithAPIReader(
-		mgr.GetClient(),
-		mgr.GetAPIReader(),
-	)
+	// Namespaces are listed from memory, kept current by namespace watch events;
+	// until the informer has synced, lists go straight to the API server
+	var namespaceLister controller.NamespaceLister
+	if len(watchedList) > 0 {
+		// Namespace objects are cluster-scoped and out of reach of namespaced RBAC
+		namespaceLister = controller.NewStaticNamespaceLister(watchedList)
+	} else {
+		namespaceInformer, informerErr := mgr.GetCache().GetInformer(signalCtx, &corev1.Namespace{})
+		if informerErr != nil {
+			setupLog.Error(informerErr, "unable to get namespace informer")
+			os.Exit(1)
+		}
+		namespaceLister, err = controller.NewCachedNamespaceLister(namespaceInformer,
+			controller.NewKubernetesNamespaceListerWithAPIReader(mgr.GetClient(), mgr.GetAPIReader()))
+		if err != nil {
+			setupLog.Error(err, "unable to create namespace lister")
+			os.Exit(1)
+		}
+	}
+
+	// Resolvers deciding each source's target namespaces
+	targetResolver, err := controller.NewTargetResolver(cfg.TargetResolvers, controller.ResolverDeps{
+		Client:          mgr.GetClient(),
+		NamespaceLister: namespaceLister,
+		Filter:          namespaceFilter,
+		Config:          cfg,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to create target resolver", "available", controller.RegisteredTargetResolvers())
+		os.Exit(1)
+	}
+
+	// The controller's own objects are never mirrored, nor overwritten by mirrors
+	if ownNamespace, nsErr := sharding.DetectNamespace(); nsErr != nil {
+		setupLog.Info("controller namespace unknown, not protecting its own objects", "reason", nsErr.Error())
+	} else {
+		protected, protectErr := controller.DiscoverProtectedObjects(signalCtx, mgr.GetAPIReader(),
+			ownNamespace, os.Getenv(controller.PodNameEnv), cfg.LeaderElection.ResourceName)
+		if protectErr != nil {
+			setupLog.Error(protectErr, "unable to read the controller pod, protecting its leases only")
+		}
+		protected.Names = append(protected.Names, summary.DefaultConfigMapName)
+		cfg.Protected = protected
+		setupLog.Info("protecting the controller's own objects", "namespace", protected.Namespace, "names", protected.Names)
+	}
+
+	// Centrally declared mirroring via ClusterMirrorPolicy
+	var policies controller.MirrorPolicies
+	if mirrorPolicies {
+		policyStore := policy.NewStore()
+		// Load before source reconcilers start so policy-selected sources are not
+		// mistaken for disabled ones on the first reconcile
+		if err = policyStore.Load(signalCtx, mgr.GetAPIReader()); err != nil {
+			setupLog.Error(err, "unable to load mirror policies")
+			os.Exit(1)
+		}
+		if err = (&policy.Reconciler{
+			Client:   mgr.GetClient(),
+			Store:    policyStore,
+			Recorder: mgr.GetEventRecorder(constants.ControllerName),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create mirror policy controller")
+			os.Exit(1)
+		}
+		policies = policyStore
+		setupLog.Info("mirror policies enabled", "loaded", policyStore.Len())
+	}
+
+	// Sources annotated for other mirroring controllers
+	aliases, err := compat.NewAliases(strings.Split(annotationCompat, ","))
+	if err != nil {
+		setupLog.Error(err, "invalid --annotation-compat")
+		os.Exit(1)
+	}
+	if aliases.Enabled() {
+		policies = controller.JoinMirrorPolicies(policies, controller.AnnotationAliases{Aliases: aliases})
+		setupLog.Info("annotation compatibility enabled", "controllers", annotationCompat)
+	}
+
+	// Remote clusters mirrors can be pushed to
+	var clusterRegistry *controller.ClusterRegistry
+	if multiCluster {
+		clusterNamespace, nsErr := sharding.DetectNamespace()
+		if nsErr != nil {
+			setupLog.Error(nsErr, "unable to determine namespace for cluster secrets")
+			os.Exit(1)
+		}
+		clusterRegistry = &controller.ClusterRegistry{
+			Reader:    mgr.GetAPIReader(),
+			Namespace: clusterNamespace,
+			QPS:       float32(remoteClusterQPS),
+			Burst:     remoteClusterBurst,
+			Interval:  controller.DefaultClusterRefreshInterval,
+			Log:       ctrl.Log.WithName("clusters"),
+		}
+		// Register clusters before sources are reconciled; broken Secrets are only logged
+		if err = clusterRegistry.Refresh(signalCtx); err != nil {
+			setupLog.Error(err, "some remote clusters could not be registered")
+		}
+		if err = mgr.Add(clusterRegistry); err != nil {
+			setupLog.Error(err, "unable to add remote cluster registry")
+			os.Exit(1)
+		}
+		setupLog.Info("multi-cluster mirroring enabled", "namespace", clusterNamespace,
+			"clusters", len(clusterRegistry.Clusters()))
+	}
 
 	// Validate flag combinations and warn about conflicts
 	if lazyWatcherInit && resourceTypes != "" {
@@ -319,27 +1056,44 @@ func main() {
 		setupLog.Info("using lazy watcher initialization",
 			"availableResourceTypes", len(cfg.MirroredResourceTypes),
 			"scanInterval", watcherScanInterval,
+			"idleScans", watcherIdleScans,
 		)
 
 		// Factory functions for creating reconcilers
 		sourceFactory := func(gvk schema.GroupVersionKind) *controller.SourceReconciler {
 			return &controller.SourceReconciler{
-				Client:          mgr.GetClient(),
-				Scheme:          mgr.GetScheme(),
-				Config:          cfg,
-				Filter:          namespaceFilter,
-				NamespaceLister: namespaceLister,
-				GVK:             gvk,
-				APIReader:       mgr.GetAPIReader(),
-				CircuitBreaker:  cb,
+				Client:             mgr.GetClient(),
+				Scheme:             mgr.GetScheme(),
+				Config:             cfg,
+				Filter:             namespaceFilter,
+				NamespaceLister:    namespaceLister,
+				GVK:                gvk,
+				APIReader:          mgr.GetAPIReader(),
+				CircuitBreaker:     cb,
+				Recorder:           mgr.GetEventRecorder(constants.ControllerName),
+				StatusReporter:     statusReporter,
+				Leadership:         leadership,
+				NamespaceOwnership: namespaceOwnership,
+				TargetResolver:     targetResolver,
+				Policies:           policies,
+				RuleLibrary:        ruleLibrary,
+				Clusters:           clusterRegistry,
+				ResourceTypes:      currentResourceTypes,
+				Observer:           reconcileFailures,
 			}
 		}
 
 		mirrorFactory := func(gvk schema.GroupVersionKind) *controller.MirrorReconciler {
 			return &controller.MirrorReconciler{
-				Client: mgr.GetClient(),
-				Scheme: mgr.GetScheme(),
-				GVK:    gvk,
+				Client:             mgr.GetClient(),
+				Scheme:             mgr.GetScheme(),
+				GVK:                gvk,
+				Config:             cfg,
+				RuleLibrary:        ruleLibrary,
+				Leadership:         leadership,
+				NamespaceOwnership: namespaceOwnership,
+				Recorder:           mgr.GetEventRecorder(constants.ControllerName),
+				Observer:           reconcileFailures,
 			}
 		}
 
@@ -353,6 +1107,7 @@ func main() {
 			NamespaceLister:         namespaceLister,
 			AvailableResources:      cfg.MirroredResourceTypes,
 			ScanInterval:            watcherScanInterval,
+			IdleScans:               watcherIdleScans,
 			SourceReconcilerFactory: sourceFactory,
 			MirrorReconcilerFactory: mirrorFactory,
 		})
@@ -382,14 +1137,24 @@ func main() {
 
 			// Create a source reconciler instance for this specific resource type
 			sourceReconciler := &controller.SourceReconciler{
-				Client:          mgr.GetClient(),
-				Scheme:          mgr.GetScheme(),
-				Config:          cfg,
-				Filter:          namespaceFilter,
-				NamespaceLister: namespaceLister,
-				GVK:             gvk,
-				APIReader:       mgr.GetAPIReader(), // Direct API reader (bypasses cache)
-				CircuitBreaker:  cb,
+				Client:             mgr.GetClient(),
+				Scheme:             mgr.GetScheme(),
+				Config:             cfg,
+				Filter:             namespaceFilter,
+				NamespaceLister:    namespaceLister,
+				GVK:                gvk,
+				APIReader:          mgr.GetAPIReader(), // Direct API reader (bypasses cache)
+				CircuitBreaker:     cb,
+				Recorder:           mgr.GetEventRecorder(constants.ControllerName),
+				StatusReporter:     statusReporter,
+				Leadership:         leadership,
+				NamespaceOwnership: namespaceOwnership,
+				TargetResolver:     targetResolver,
+				Policies:           policies,
+				RuleLibrary:        ruleLibrary,
+				Clusters:           clusterRegistry,
+				ResourceTypes:      currentResourceTypes,
+				Observer:           reconcileFailures,
 			}
 
 			if err = sourceReconciler.SetupWithManagerForResourceType(mgr, gvk); err != nil {
@@ -402,9 +1167,15 @@ func main() {
 			// Create a mirror reconciler instance for orphan detection
 			// This watches mirrored resources (with managed-by label) and verifies their source still exists
 			mirrorReconciler := &controller.MirrorReconciler{
-				Client: mgr.GetClient(),
-				Scheme: mgr.GetScheme(),
-				GVK:    gvk,
+				Client:             mgr.GetClient(),
+				Scheme:             mgr.GetScheme(),
+				GVK:                gvk,
+				Config:             cfg,
+				RuleLibrary:        ruleLibrary,
+				Leadership:         leadership,
+				NamespaceOwnership: namespaceOwnership,
+				Recorder:           mgr.GetEventRecorder(constants.ControllerName),
+				Observer:           reconcileFailures,
 			}
 
 			if err = mirrorReconciler.SetupWithManager(mgr, gvk); err != nil {
@@ -418,23 +1189,138 @@ func main() {
 		setupLog.Info("registered source and mirror controllers", "count", len(cfg.MirroredResourceTypes))
 	}
 
-	// Register namespace reconciler to watch for new namespaces and label changes
-	namespaceReconciler := &controller.NamespaceReconciler{
-		Client:          mgr.GetClient(),
-		Scheme:          mgr.GetScheme(),
-		Config:          cfg,
-		Filter:          namespaceFilter,
-		NamespaceLister: namespaceLister,
-		ResourceTypes:   cfg.MirroredResourceTypes,
-		APIReader:       mgr.GetAPIReader(), // Direct API reader for fresh namespace lookups
+	// Register namespace reconciler to watch for new namespaces and label changes.
+	// Watched namespaces are fixed, and Namespace objects cannot be watched with namespaced RBAC.
+	if len(watchedList) > 0 {
+		setupLog.Info("namespace-scoped mode, namespace reconciler disabled", "namespaces", watchedList)
+	} else {
+		namespaceReconciler := &controller.NamespaceReconciler{
+			Client:             mgr.GetClient(),
+			Scheme:             mgr.GetScheme(),
+			Config:             cfg,
+			Filter:             namespaceFilter,
+			NamespaceLister:    namespaceLister,
+			ResourceTypes:      cfg.MirroredResourceTypes,
+			APIReader:          mgr.GetAPIReader(), // Direct API reader for fresh namespace lookups
+			Leadership:         leadership,
+			NamespaceOwnership: namespaceOwnership,
+			TargetResolver:     targetResolver,
+			Policies:           policies,
+			RuleLibrary:        ruleLibrary,
+			Recorder:           mgr.GetEventRecorder(constants.ControllerName),
+		}
+
+		if err = namespaceReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create namespace reconciler")
+			os.Exit(1)
+		}
+
+		setupLog.Info("registered namespace reconciler")
 	}
 
-	if err = namespaceReconciler.SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create namespace reconciler")
-		os.Exit(1)
+	// Discovery and the initial registration pass are complete
+	registrationGate.MarkReady()
+
+	// Apply config file changes without a restart
+	if configWatcher != nil {
+		configWatcher.OnChange = func(file *config.File) {
+			reloaded, applyErr := file.Apply(flagTunables, constants.DefaultExcludedNamespaces...)
+			if applyErr != nil {
+				setupLog.Error(applyErr, "invalid config file, keeping current settings", "path", configFile)
+				return
+			}
+			cfg.ApplyTunables(reloaded)
+			namespaceFilter.Update(reloaded.ExcludedNamespaces, reloaded.IncludedNamespaces)
+			cb.SetConfig(reloaded.CircuitBreaker)
+			apiRateLimiter.Set(reloaded.RateLimitQPS, reloaded.RateLimitBurst)
+			setupLog.Info("config file settings applied",
+				"excluded", reloaded.ExcludedNamespaces,
+				"included", reloaded.IncludedNamespaces,
+				"maxTargets", reloaded.MaxTargets,
+				"truncationPolicy", reloaded.TruncationPolicy,
+				"rateLimitQPS", reloaded.RateLimitQPS,
+				"rateLimitBurst", reloaded.RateLimitBurst,
+				"paused", reloaded.Paused,
+				"pausedResourceTypes", reloaded.PausedResourceTypes,
+			)
+
+			if changed := strings.Join(file.ResourceTypes, ","); changed != fileResourceTypes {
+				setupLog.Info("WARNING: resourceTypes changed in the config file, restart the controller to apply",
+					"running", fileResourceTypes, "configured", changed)
+			}
+			if include, exclude, _ := file.DiscoveryGroups(); !slices.Equal(include, fileGroups[0]) || !slices.Equal(exclude, fileGroups[1]) {
+				setupLog.Info("WARNING: discovery groups changed in the config file, restart the controller to apply")
+			}
+			deny, allow, _ := file.DiscoveryKinds()
+			heuristics := file.DiscoveryHeuristics == nil || *file.DiscoveryHeuristics
+			if !slices.Equal(deny, fileKinds[0]) || !slices.Equal(allow, fileKinds[1]) || heuristics != discoveryHeuristics {
+				setupLog.Info("WARNING: discovery kinds changed in the config file, restart the controller to apply")
+			}
+			if queues, _ := file.QueueSettings(); !maps.Equal(queues, cfg.Queues) {
+				setupLog.Info("WARNING: priorities or maxInFlight changed in the config file, restart the controller to apply")
+			}
+		}
+		if err = mgr.Add(configWatcher); err != nil {
+			setupLog.Error(err, "unable to add config file watcher")
+			os.Exit(1)
+		}
 	}
 
-	setupLog.Info("registered namespace reconciler")
+	// Periodic orphan sweep alongside the watch-based mirror reconcilers
+	if sweepInterval > 0 {
+		if err = mgr.Add(&sweeper.Sweeper{
+			Client:        mgr.GetClient(),
+			Log:           ctrl.Log.WithName("sweeper"),
+			ResourceTypes: currentResourceTypes,
+			Interval:      sweepInterval,
+			DryRun:        cfg.DryRun,
+			Paused: func(gvk schema.GroupVersionKind) bool {
+				paused, _ := cfg.PausedFor(gvk)
+				return paused
+			},
+		}); err != nil {
+			setupLog.Error(err, "unable to add mirror sweeper")
+			os.Exit(1)
+		}
+		setupLog.Info("mirror sweeper enabled", "interval", sweepInterval)
+	}
+
+	// Expired mirrors of sources with a mirror TTL are deleted on time
+	if expiryInterval > 0 {
+		if err = mgr.Add(&controller.ExpiryJanitor{
+			Client:        mgr.GetClient(),
+			Log:           ctrl.Log.WithName("expiry"),
+			Config:        cfg,
+			Recorder:      mgr.GetEventRecorder(constants.ControllerName),
+			ResourceTypes: currentResourceTypes,
+			Interval:      expiryInterval,
+		}); err != nil {
+			setupLog.Error(err, "unable to add expired mirror janitor")
+			os.Exit(1)
+		}
+	}
+
+	// Periodic per-type summary for dashboards
+	if summaryInterval > 0 {
+		summaryNamespace, nsErr := sharding.DetectNamespace()
+		if nsErr != nil {
+			setupLog.Error(nsErr, "unable to determine namespace for the summary ConfigMap")
+			os.Exit(1)
+		}
+		if err = mgr.Add(&summary.Summarizer{
+			Client:        mgr.GetClient(),
+			Log:           ctrl.Log.WithName("summary"),
+			ResourceTypes: currentResourceTypes,
+			Namespace:     summaryNamespace,
+			Name:          summary.DefaultConfigMapName,
+			Interval:      summaryInterval,
+		}); err != nil {
+			setupLog.Error(err, "unable to add mirror summary")
+			os.Exit(1)
+		}
+		setupLog.Info("mirror summary enabled", "interval", summaryInterval,
+			"configMap", summaryNamespace+"/"+summary.DefaultConfigMapName)
+	}
 
 	// Add health checks
 	// Liveness: basic ping to verify the controller process is alive
@@ -445,18 +1331,134 @@ func main() {
 
 	// Readiness: check that informer caches are synced before accepting traffic.
 	// This prevents reconciliation from running with incomplete/stale cache data.
-	// The cache sync check ensures all informers have received initial data from the API server.
-	// Note: The manager automatically waits for cache sync before starting controllers,
-	// but this check ensures the readiness probe reflects cache state for external monitoring.
+	// The cache sync check ensures all informers have received initial data from the API server,
+	// including informers added later by lazily registered controllers.
 	cacheReadyCheck := makeCacheSyncChecker(mgr.GetCache(), signalCtx, setupLog)
 	if err := mgr.AddReadyzCheck("readyz", cacheReadyCheck); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
 
+	// Startup gates: registration pass, first cache sync and leader election
+	if err := mgr.AddReadyzCheck("startup", readiness.Checker()); err != nil {
+		setupLog.Error(err, "unable to set up startup ready check")
+		os.Exit(1)
+	}
+
+	// Controllers whose reconciles keep failing, e.g. stuck on a broken API group
+	if err := mgr.AddReadyzCheck("reconciles", reconcileFailures.Checker()); err != nil {
+		setupLog.Error(err, "unable to set up reconcile failure ready check")
+		os.Exit(1)
+	}
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(signalCtx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+
+	// Flush the spans of the last reconciles
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		setupLog.Error(err, "failed to flush traces")
+	}
+}
+
+// applyLogFormat adjusts the logger options for --log-format. "json" selects the
+// production profile: JSON lines at info level and above with ISO 8601 timestamps,
+// unless --zap-time-encoding chose another encoding.
+func applyLogFormat(opts *zap.Options, format string) error {
+	switch format {
+	case "console":
+	case "json":
+		opts.Development = false
+		if opts.TimeEncoder == nil {
+			opts.TimeEncoder = zapcore.ISO8601TimeEncoder
+		}
+	default:
+		return fmt.Errorf("unknown log format %q: expected console or json", format)
+	}
+	return nil
+}
+
+// configureLeaderElection validates the leader election settings and, when leader
+// election is active without an explicit namespace, detects the pod's namespace
+// so the manager and the sharding electors keep their leases in the same place.
+func configureLeaderElection(le *config.LeaderElectionConfig) error {
+	lockType, err := sharding.ParseResourceLock(le.ResourceLock)
+	if err != nil {
+		return err
+	}
+	le.ResourceLock = lockType
+	if err := le.Validate(); err != nil {
+		return err
+	}
+	if !le.Active() || le.ResourceNamespace != "" {
+		return nil
+	}
+	if le.ResourceNamespace, err = sharding.DetectNamespace(); err != nil {
+		return fmt.Errorf("%w; set --leader-election-namespace when running outside the cluster", err)
+	}
+	return nil
+}
+
+// parseKeyValues parses comma-separated key=value pairs into labels (label values
+// validated) or annotations. An empty value returns nil.
+func parseKeyValues(value string, labels bool) (map[string]string, error) {
+	var pairs map[string]string
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, val, found := strings.Cut(pair, "=")
+		key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+		if !found {
+			return nil, fmt.Errorf("invalid pair %q: expected key=value", pair)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid key %q: %s", key, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(val); labels && len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label value %q: %s", val, strings.Join(errs, ", "))
+		}
+		if pairs == nil {
+			pairs = make(map[string]string)
+		}
+		pairs[key] = val
+	}
+	return pairs, nil
+}
+
+// parseMirrorMetadata parses --mirror-labels or --mirror-annotations on top of
+// preset. kubemirror's own keys are refused, as mirrors depend on them.
+func parseMirrorMetadata(value string, labels bool, preset map[string]string) (map[string]string, error) {
+	pairs, err := parseKeyValues(value, labels)
+	if err != nil {
+		return nil, err
+	}
+	for key := range pairs {
+		if strings.HasPrefix(key, constants.Domain+"/") {
+			return nil, fmt.Errorf("key %q is reserved for kubemirror", key)
+		}
+	}
+	if len(preset) == 0 {
+		return pairs, nil
+	}
+	merged := maps.Clone(preset)
+	maps.Copy(merged, pairs)
+	return merged, nil
+}
+
+// parseWatchNamespaces parses --watch-namespaces. Namespaces are listed by name:
+// patterns cannot be resolved without listing Namespace objects.
+func parseWatchNamespaces(value string) ([]string, error) {
+	namespaces := filter.ParseTargetNamespaces(value)
+	for _, ns := range namespaces {
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid namespace %q: %s", ns, strings.Join(errs, ", "))
+		}
+	}
+	return namespaces, nil
 }
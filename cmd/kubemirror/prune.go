@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/discovery"
+	"github.com/lukaszraczylo/kubemirror/pkg/prune"
+	"github.com/lukaszraczylo/kubemirror/pkg/status"
+)
+
+// runPrune implements `kubemirror prune`, which deletes every mirror and removes
+// kubemirror finalizers from sources. Run it after stopping the controller.
+func runPrune(args []string) int {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	var (
+		all           bool
+		dryRun        bool
+		resourceTypes string
+	)
+	fs.BoolVar(&all, "all", false,
+		"Required confirmation: delete all kubemirror-managed objects and remove kubemirror finalizers in every namespace.")
+	fs.BoolVar(&dryRun, "dry-run", false,
+		"Only log what would be deleted or patched.")
+	fs.StringVar(&resourceTypes, "resource-types", "",
+		"Comma-separated list of resource types to prune (e.g., 'Secret.v1,ConfigMap.v1'). "+
+			"If empty, all mirrorable resources are auto-discovered.")
+	opts := zap.Options{}
+	opts.BindFlags(fs)
+	_ = fs.Parse(args)
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	logger := ctrl.Log.WithName("prune")
+
+	if !all {
+		fmt.Fprintln(os.Stderr, "refusing to prune without --all (use --dry-run to preview)")
+		return 2
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	restConfig := ctrl.GetConfigOrDie()
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		logger.Error(err, "unable to create client")
+		return 1
+	}
+
+	types, err := subcommandResourceTypes(ctx, restConfig, resourceTypes)
+	if err != nil {
+		logger.Error(err, "unable to determine resource types")
+		return 1
+	}
+
+	gvks := make([]schema.GroupVersionKind, 0, len(types)+1)
+	for _, rt := range types {
+		gvks = append(gvks, rt.GroupVersionKind())
+	}
+	// Companion status resources of the "resource" status backend
+	if !slices.Contains(gvks, status.MirrorStatusGVK) {
+		gvks = append(gvks, status.MirrorStatusGVK)
+	}
+
+	logger.Info("pruning kubemirror objects", "resourceTypes", len(gvks), "dryRun", dryRun)
+	pruner := &prune.Pruner{Client: c, Log: logger, DryRun: dryRun}
+	result, err := pruner.Prune(ctx, gvks)
+	logger.Info("prune finished",
+		"mirrorsDeleted", result.MirrorsDeleted,
+		"finalizersRemoved", result.FinalizersRemoved,
+		"dryRun", dryRun,
+	)
+	if err != nil {
+		logger.Error(err, "prune completed with errors")
+		return 1
+	}
+	return 0
+}
+
+// subcommandResourceTypes parses a --resource-types flag value, or discovers
+// every mirrorable resource type when it is empty.
+func subcommandResourceTypes(ctx context.Context, restConfig *rest.Config, resourceTypes string) ([]config.ResourceType, error) {
+	if resourceTypes != "" {
+		return config.ParseResourceTypes(resourceTypes)
+	}
+	rd, err := discovery.NewResourceDiscovery(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return rd.DiscoverMirrorableResources(ctx)
+}
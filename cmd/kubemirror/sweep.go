@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/sweeper"
+)
+
+// runSweep implements `kubemirror sweep`, which deletes mirrors whose source is
+// gone or was recreated. It runs without the controller, once or on an interval.
+func runSweep(args []string) int {
+	fs := flag.NewFlagSet("sweep", flag.ExitOnError)
+	var (
+		interval      time.Duration
+		dryRun        bool
+		resourceTypes string
+	)
+	fs.DurationVar(&interval, "interval", 0,
+		"Time between sweeps; 0 sweeps once and exits.")
+	fs.BoolVar(&dryRun, "dry-run", false,
+		"Only log which mirrors would be deleted.")
+	fs.StringVar(&resourceTypes, "resource-types", "",
+		"Comma-separated list of resource types to sweep (e.g., 'Secret.v1,ConfigMap.v1'). "+
+			"If empty, all mirrorable resources are auto-discovered before each sweep.")
+	opts := zap.Options{}
+	opts.BindFlags(fs)
+	_ = fs.Parse(args)
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	logger := ctrl.Log.WithName("sweep")
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	restConfig := ctrl.GetConfigOrDie()
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		logger.Error(err, "unable to create client")
+		return 1
+	}
+
+	for {
+		var types []config.ResourceType
+		types, err = subcommandResourceTypes(ctx, restConfig, resourceTypes)
+		if err != nil {
+			logger.Error(err, "unable to determine resource types")
+		} else {
+			s := &sweeper.Sweeper{
+				Client:        c,
+				Log:           logger,
+				ResourceTypes: func() []config.ResourceType { return types },
+				DryRun:        dryRun,
+			}
+			if _, err = s.Sweep(ctx); err != nil {
+				logger.Error(err, "sweep completed with errors")
+			}
+		}
+
+		if interval <= 0 {
+			if err != nil {
+				return 1
+			}
+			return 0
+		}
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-time.After(interval):
+		}
+	}
+}
@@ -63,8 +63,9 @@ type resourceState struct {
 
 // CircuitBreaker tracks failures per resource and provides circuit breaker functionality
 type CircuitBreaker struct {
-	states sync.Map
-	config Config
+	states   sync.Map
+	config   Config
+	configMu sync.RWMutex
 }
 
 // New creates a new CircuitBreaker with the given configuration
@@ -79,6 +80,22 @@ func NewWithDefaults() *CircuitBreaker {
 	return New(DefaultConfig())
 }
 
+// SetConfig replaces the configuration (e.g. on a configuration reload).
+// Tracked resources keep their state; the new thresholds apply from their next
+// recorded result, and the new reset timeout to circuits already open.
+func (cb *CircuitBreaker) SetConfig(config Config) {
+	cb.configMu.Lock()
+	defer cb.configMu.Unlock()
+	cb.config = config
+}
+
+// Config returns the current configuration.
+func (cb *CircuitBreaker) Config() Config {
+	cb.configMu.RLock()
+	defer cb.configMu.RUnlock()
+	return cb.config
+}
+
 // resourceKey generates a unique key for a resource
 func resourceKey(namespace, name, kind string) string {
 	return namespace + "/" + name + "/" + kind
@@ -107,7 +124,7 @@ func (cb *CircuitBreaker) AllowRequest(namespace, name, kind string) bool {
 		return true
 	case StateOpen:
 		// Check if reset timeout has elapsed
-		if time.Since(state.lastFailure) >= cb.config.ResetTimeout {
+		if time.Since(state.lastFailure) >= cb.Config().ResetTimeout {
 			// Transition to half-open
 			state.state = StateHalfOpen
 			state.consecutiveSuccesses = 0
@@ -137,13 +154,13 @@ func (cb *CircuitBreaker) RecordSuccess(namespace, name, kind string) State {
 	switch state.state {
 	case StateHalfOpen:
 		state.consecutiveSuccesses++
-		if state.consecutiveSuccesses >= cb.config.HalfOpenSuccessThreshold {
+		if state.consecutiveSuccesses >= cb.Config().HalfOpenSuccessThreshold {
 			state.state = StateClosed
 			state.consecutiveSuccesses = 0
 		}
 	case StateOpen:
 		// If we got a success while open (after timeout), go to half-open
-		if time.Since(state.lastFailure) >= cb.config.ResetTimeout {
+		if time.Since(state.lastFailure) >= cb.Config().ResetTimeout {
 			state.state = StateHalfOpen
 			state.consecutiveSuccesses = 1
 		}
@@ -173,7 +190,7 @@ func (cb *CircuitBreaker) RecordFailure(namespace, name, kind string, err error)
 
 	switch state.state {
 	case StateClosed:
-		if state.consecutiveFailures >= cb.config.FailureThreshold {
+		if state.consecutiveFailures >= cb.Config().FailureThreshold {
 			state.state = StateOpen
 			justOpened = true
 		}
@@ -188,6 +205,25 @@ func (cb *CircuitBreaker) RecordFailure(namespace, name, kind string, err error)
 	return state.state, justOpened
 }
 
+// Trip opens the circuit for the resource immediately, for failures retrying
+// cannot fix. Returns whether the circuit just opened.
+func (cb *CircuitBreaker) Trip(namespace, name, kind string, err error) bool {
+	key := resourceKey(namespace, name, kind)
+	state := cb.getOrCreateState(key)
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.consecutiveFailures++
+	state.consecutiveSuccesses = 0
+	state.lastFailure = time.Now()
+	state.lastError = err
+
+	justOpened := state.state != StateOpen
+	state.state = StateOpen
+	return justOpened
+}
+
 // GetState returns the current state for a resource
 func (cb *CircuitBreaker) GetState(namespace, name, kind string) State {
 	key := resourceKey(namespace, name, kind)
@@ -197,7 +233,7 @@ func (cb *CircuitBreaker) GetState(namespace, name, kind string) State {
 	defer state.mu.RUnlock()
 
 	// Check if open circuit should transition to half-open
-	if state.state == StateOpen && time.Since(state.lastFailure) >= cb.config.ResetTimeout {
+	if state.state == StateOpen && time.Since(state.lastFailure) >= cb.Config().ResetTimeout {
 		return StateHalfOpen
 	}
 
@@ -264,7 +300,7 @@ func (cb *CircuitBreaker) GetStats() Stats {
 		state.mu.RLock()
 		s := state.state
 		// Check for timeout transition
-		if s == StateOpen && time.Since(state.lastFailure) >= cb.config.ResetTimeout {
+		if s == StateOpen && time.Since(state.lastFailure) >= cb.Config().ResetTimeout {
 			s = StateHalfOpen
 		}
 		state.mu.RUnlock()
@@ -165,6 +165,23 @@ func TestCircuitBreaker_Reset(t *testing.T) {
 	assert.True(t, cb.AllowRequest("ns", "name", "Secret"))
 }
 
+func TestCircuitBreaker_Trip(t *testing.T) {
+	cb := NewWithDefaults()
+
+	testErr := errors.New("admission denied")
+	assert.True(t, cb.Trip("ns", "name", "Secret", testErr))
+	assert.Equal(t, StateOpen, cb.GetState("ns", "name", "Secret"))
+	assert.False(t, cb.AllowRequest("ns", "name", "Secret"))
+	assert.Equal(t, testErr, cb.GetLastError("ns", "name", "Secret"))
+
+	// Tripping an open circuit keeps it open
+	assert.False(t, cb.Trip("ns", "name", "Secret", testErr))
+	assert.Equal(t, 2, cb.GetFailureCount("ns", "name", "Secret"))
+
+	cb.Reset("ns", "name", "Secret")
+	assert.True(t, cb.AllowRequest("ns", "name", "Secret"))
+}
+
 func TestCircuitBreaker_OpenCircuits(t *testing.T) {
 	cb := NewWithDefaults()
 
@@ -236,3 +253,24 @@ func TestState_String(t *testing.T) {
 	assert.Equal(t, "half-open", StateHalfOpen.String())
 	assert.Equal(t, "unknown", State(99).String())
 }
+
+func TestCircuitBreaker_SetConfig(t *testing.T) {
+	cb := NewWithDefaults()
+	err := errors.New("boom")
+
+	cb.RecordFailure("ns", "name", "Secret", err)
+	cb.RecordFailure("ns", "name", "Secret", err)
+	assert.Equal(t, StateClosed, cb.GetState("ns", "name", "Secret"))
+
+	// A lower threshold applies from the next failure
+	cb.SetConfig(Config{FailureThreshold: 3, ResetTimeout: time.Hour, HalfOpenSuccessThreshold: 1})
+	assert.Equal(t, 3, cb.Config().FailureThreshold)
+	state, opened := cb.RecordFailure("ns", "name", "Secret", err)
+	assert.Equal(t, StateOpen, state)
+	assert.True(t, opened)
+	assert.False(t, cb.AllowRequest("ns", "name", "Secret"))
+
+	// A shorter reset timeout applies to circuits already open
+	cb.SetConfig(Config{FailureThreshold: 3, ResetTimeout: time.Nanosecond, HalfOpenSuccessThreshold: 1})
+	assert.True(t, cb.AllowRequest("ns", "name", "Secret"))
+}
@@ -0,0 +1,157 @@
+// Package compat maps the annotations of other mirroring controllers,
+// emberstack/reflector and mittwald/kubernetes-replicator, onto kubemirror target
+// namespace patterns, so resources can be migrated without re-annotating them at once.
+//
+// Only push-style mirroring is mapped: reflector's auto-reflection and replicator's
+// replicate-to. Their pull-style annotations, set on copies that ask for a source's
+// content, have no kubemirror equivalent and are ignored.
+package compat
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// Names of the controllers whose annotations can be recognized.
+const (
+	Reflector  = "reflector"
+	Replicator = "replicator"
+)
+
+// Annotation prefixes of the recognized controllers.
+const (
+	ReflectorPrefix  = "reflector.v1.k8s.emberstack.com/"
+	ReplicatorPrefix = "replicator.v1.mittwald.de/"
+)
+
+// Source annotations of emberstack/reflector.
+const (
+	// ReflectorAllowed must be "true" for a source to be reflected at all
+	ReflectorAllowed = ReflectorPrefix + "reflection-allowed"
+	// ReflectorAllowedNamespaces lists the namespaces copies may exist in (empty = all)
+	ReflectorAllowedNamespaces = ReflectorPrefix + "reflection-allowed-namespaces"
+	// ReflectorAutoEnabled set to "true" creates copies without them being requested
+	ReflectorAutoEnabled = ReflectorPrefix + "reflection-auto-enabled"
+	// ReflectorAutoNamespaces lists the namespaces copies are created in (empty = the allowed ones)
+	ReflectorAutoNamespaces = ReflectorPrefix + "reflection-auto-namespaces"
+)
+
+// ReplicatorReplicateTo is mittwald/kubernetes-replicator's source annotation
+// listing the namespaces copies are pushed to.
+const ReplicatorReplicateTo = ReplicatorPrefix + "replicate-to"
+
+// Aliases recognizes the annotations of the configured controllers. The zero
+// value recognizes none. It is safe for concurrent use.
+type Aliases struct {
+	reflector  bool
+	replicator bool
+}
+
+// NewAliases returns Aliases for the named controllers (Reflector, Replicator).
+func NewAliases(names []string) (*Aliases, error) {
+	a := &Aliases{}
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case Reflector:
+			a.reflector = true
+		case Replicator:
+			a.replicator = true
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown annotation compatibility %q (valid: %s, %s)", name, Reflector, Replicator)
+		}
+	}
+	return a, nil
+}
+
+// Enabled reports whether any controller's annotations are recognized.
+func (a *Aliases) Enabled() bool {
+	return a != nil && (a.reflector || a.replicator)
+}
+
+// TargetPatterns returns the kubemirror target namespace patterns the recognized
+// annotations ask for (nil = none), and the namespace entries that could not be
+// mapped. Both controllers take regular expressions; only those equivalent to a
+// glob (names, ".", ".*" and ".+", optionally anchored) are mapped.
+func (a *Aliases) TargetPatterns(annotations map[string]string) (patterns, unsupported []string) {
+	if !a.Enabled() {
+		return nil, nil
+	}
+
+	var entries []string
+	if a.reflector && annotations[ReflectorAllowed] == "true" && annotations[ReflectorAutoEnabled] == "true" {
+		list := annotations[ReflectorAutoNamespaces]
+		if strings.TrimSpace(list) == "" {
+			list = annotations[ReflectorAllowedNamespaces]
+		}
+		if strings.TrimSpace(list) == "" {
+			list = ".*"
+		}
+		entries = append(entries, strings.Split(list, ",")...)
+	}
+	if a.replicator {
+		entries = append(entries, strings.Split(annotations[ReplicatorReplicateTo], ",")...)
+	}
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern, ok := globFromRegexp(entry)
+		if !ok {
+			unsupported = append(unsupported, entry)
+			continue
+		}
+		if !slices.Contains(patterns, pattern) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	if slices.Contains(patterns, constants.TargetNamespacesAll) {
+		return []string{constants.TargetNamespacesAll}, unsupported
+	}
+	return patterns, unsupported
+}
+
+// globFromRegexp converts a namespace regular expression, matched against the whole
+// name like both controllers do, into the equivalent glob. ".*" alone becomes "all".
+func globFromRegexp(expr string) (string, bool) {
+	expr = strings.TrimSuffix(strings.TrimPrefix(expr, "^"), "$")
+	if expr == ".*" {
+		return constants.TargetNamespacesAll, true
+	}
+
+	var glob strings.Builder
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case c == '.' && i+1 < len(expr) && expr[i+1] == '*':
+			glob.WriteByte('*')
+			i++
+		case c == '.' && i+1 < len(expr) && expr[i+1] == '+':
+			glob.WriteString("?*")
+			i++
+		case c == '.':
+			// Any single character
+			glob.WriteByte('?')
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-':
+			glob.WriteByte(c)
+		default:
+			return "", false
+		}
+	}
+	if glob.Len() == 0 {
+		return "", false
+	}
+	return glob.String(), true
+}
+
+// IsRecognizedAnnotation reports whether key belongs to one of the recognized
+// controllers. Such annotations are never copied to mirrors, so a controller
+// still running during the migration does not treat mirrors as sources.
+func IsRecognizedAnnotation(key string) bool {
+	return strings.HasPrefix(key, ReflectorPrefix) || strings.HasPrefix(key, ReplicatorPrefix)
+}
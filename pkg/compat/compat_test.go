@@ -0,0 +1,129 @@
+package compat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAliases(t *testing.T) {
+	a, err := NewAliases([]string{"reflector", " replicator", ""})
+	require.NoError(t, err)
+	assert.True(t, a.Enabled())
+
+	a, err = NewAliases(nil)
+	require.NoError(t, err)
+	assert.False(t, a.Enabled())
+
+	_, err = NewAliases([]string{"kubed"})
+	assert.ErrorContains(t, err, `unknown annotation compatibility "kubed"`)
+}
+
+func TestAliases_TargetPatterns(t *testing.T) {
+	tests := []struct {
+		annotations     map[string]string
+		name            string
+		names           []string
+		wantPatterns    []string
+		wantUnsupported []string
+	}{
+		{
+			name:  "reflector auto namespaces",
+			names: []string{Reflector},
+			annotations: map[string]string{
+				ReflectorAllowed:           "true",
+				ReflectorAllowedNamespaces: "team-.*",
+				ReflectorAutoEnabled:       "true",
+				ReflectorAutoNamespaces:    "team-a, team-b",
+			},
+			wantPatterns: []string{"team-a", "team-b"},
+		},
+		{
+			name:  "reflector falls back to allowed namespaces",
+			names: []string{Reflector},
+			annotations: map[string]string{
+				ReflectorAllowed:           "true",
+				ReflectorAllowedNamespaces: "^team-.*$",
+				ReflectorAutoEnabled:       "true",
+			},
+			wantPatterns: []string{"team-*"},
+		},
+		{
+			name:  "reflector without namespaces mirrors everywhere",
+			names: []string{Reflector},
+			annotations: map[string]string{
+				ReflectorAllowed:     "true",
+				ReflectorAutoEnabled: "true",
+			},
+			wantPatterns: []string{"all"},
+		},
+		{
+			name:  "reflector without auto-reflection creates no copies",
+			names: []string{Reflector},
+			annotations: map[string]string{
+				ReflectorAllowed:           "true",
+				ReflectorAllowedNamespaces: "team-a",
+			},
+		},
+		{
+			name:  "reflector annotations ignored unless enabled",
+			names: []string{Replicator},
+			annotations: map[string]string{
+				ReflectorAllowed:     "true",
+				ReflectorAutoEnabled: "true",
+			},
+		},
+		{
+			name:            "replicator replicate-to",
+			names:           []string{Replicator},
+			annotations:     map[string]string{ReplicatorReplicateTo: "prod-.+,stage-.,dev-[0-9]+"},
+			wantPatterns:    []string{"prod-?*", "stage-?"},
+			wantUnsupported: []string{"dev-[0-9]+"},
+		},
+		{
+			name:  "both controllers are merged",
+			names: []string{Reflector, Replicator},
+			annotations: map[string]string{
+				ReflectorAllowed:        "true",
+				ReflectorAutoEnabled:    "true",
+				ReflectorAutoNamespaces: "team-a",
+				ReplicatorReplicateTo:   "team-a,team-b",
+			},
+			wantPatterns: []string{"team-a", "team-b"},
+		},
+		{
+			name:  "all wins over other patterns",
+			names: []string{Replicator},
+			annotations: map[string]string{
+				ReplicatorReplicateTo: "team-a,.*",
+			},
+			wantPatterns: []string{"all"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewAliases(tt.names)
+			require.NoError(t, err)
+
+			patterns, unsupported := a.TargetPatterns(tt.annotations)
+			assert.Equal(t, tt.wantPatterns, patterns)
+			assert.Equal(t, tt.wantUnsupported, unsupported)
+		})
+	}
+}
+
+func TestAliases_TargetPatterns_Nil(t *testing.T) {
+	var a *Aliases
+	patterns, unsupported := a.TargetPatterns(map[string]string{ReplicatorReplicateTo: "team-a"})
+	assert.Nil(t, patterns)
+	assert.Nil(t, unsupported)
+}
+
+func TestIsRecognizedAnnotation(t *testing.T) {
+	assert.True(t, IsRecognizedAnnotation(ReflectorAutoEnabled))
+	assert.True(t, IsRecognizedAnnotation(ReplicatorReplicateTo))
+	assert.True(t, IsRecognizedAnnotation("replicator.v1.mittwald.de/replicated-at"))
+	assert.False(t, IsRecognizedAnnotation("kubemirror.raczylo.com/sync"))
+}
@@ -2,7 +2,15 @@
 package config
 
 import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/transformer"
 )
 
 // Config holds all configuration for the controller.
@@ -21,6 +29,48 @@ type Config struct {
 	MirroredResourceTypes []ResourceType
 	// DeniedResourceTypes is the deny-list of resource types (by name, for backward compatibility)
 	DeniedResourceTypes []string
+	// ServerDryRunTypes lists resource types whose mirror writes are first sent as a
+	// server-side dry run, so admission rejections never leave partial state (empty = none)
+	ServerDryRunTypes []ResourceType
+	// TemplateLookupAllow lists "namespace/name" glob patterns of ConfigMaps that transform
+	// templates may read with the lookup function (empty disables lookup)
+	TemplateLookupAllow []string
+	// TemplateContextConfigMap names the ConfigMap in each target namespace that transform
+	// templates read with the contextValue function (empty disables contextValue)
+	TemplateContextConfigMap string
+	// ClusterName names the local cluster in transform templates (.ClusterName)
+	ClusterName string
+	// TemplatePool bounds concurrent transform template executions across all
+	// mirrors (nil = no bound)
+	TemplatePool *transformer.TemplatePool
+	// TargetResolvers names the registered resolvers that decide target namespaces;
+	// a source is mirrored to the union of their results (empty = target-namespaces annotation)
+	TargetResolvers []string
+	// DefaultTransformRules are applied to every mirror of a resource type before
+	// the source's own transform rules (nil = none)
+	DefaultTransformRules *transformer.DefaultRules
+	// NamespaceRefFields are the namespace-bearing fields rewriteNamespaceRefs transform
+	// rules rewrite, by resource type (nil = the built-in fields)
+	NamespaceRefFields *transformer.NamespaceRefFields
+	// PruneFields are stripped from mirrors, by resource type (nil = none)
+	PruneFields *transformer.PruneFields
+	// Paused stops all mirror writes and cleanups; existing mirrors are kept as they are
+	Paused bool
+	// PausedResourceTypes stops mirror writes and cleanups of these resource types
+	PausedResourceTypes []ResourceType
+	// SecretTypes decides which Secret types are refused, need an opt-in or are
+	// audited (nil = DefaultSecretTypePolicy)
+	SecretTypes *SecretTypePolicy
+	// RetryBackoff are the retry schedules of failed mirror writes, by error class
+	// (nil = DefaultRetryBackoff)
+	RetryBackoff RetryBackoff
+
+	// ListPageSize is the page size for informer LIST calls (0 = client-go default)
+	ListPageSize int64
+	// WatchTimeout is how long informer watches stay open before being re-established (0 = client-go default)
+	WatchTimeout time.Duration
+	// WatchBookmarks requests BOOKMARK events on informer watches
+	WatchBookmarks bool
 
 	// LeaderElection configuration
 	LeaderElection LeaderElectionConfig
@@ -30,6 +80,8 @@ type Config struct {
 
 	// WorkerThreads is the number of concurrent reconciliation workers
 	WorkerThreads int
+	// Queues tune the work queues of individual resource types (see QueueSettingsFor)
+	Queues map[ResourceType]QueueSettings
 	// RateLimitBurst is the burst capacity for rate limiting
 	RateLimitBurst int
 	// MemoryLimitMB is the memory limit in megabytes
@@ -40,6 +92,8 @@ type Config struct {
 
 	// MaxTargetsPerResource is the maximum number of target namespaces per resource
 	MaxTargetsPerResource int
+	// TruncationPolicy decides which targets are kept over the limit (empty = DefaultTruncationPolicy)
+	TruncationPolicy TruncationPolicy
 
 	// RateLimitQPS is the maximum queries per second to the API server
 	RateLimitQPS float32
@@ -50,10 +104,40 @@ type Config struct {
 	EnableAllKeyword bool
 	// DryRun mode logs what would happen without actually making changes
 	DryRun bool
-	// VerifySourceFreshness checks cache staleness and re-fetches from API if needed
+	// StatusBackend selects where per-source sync status is reported:
+	// "events" (default, non-mutating), "annotation" (legacy) or "resource" (MirrorStatus CR)
+	StatusBackend string
+	// ConflictPolicy is the default for targets holding an unmanaged object of the
+	// mirror's name: "skip" (default), "overwrite" or "fail"
+	ConflictPolicy string
+	// UseOwnerReferences makes a MirrorBinding in each target namespace own the mirrors
+	// there, so deleting a source's bindings lets garbage collection remove its mirrors
+	UseOwnerReferences bool
+	// MirrorLabels and MirrorAnnotations are stamped on every mirror, e.g. so GitOps
+	// engines leave mirrors alone
+	MirrorLabels      map[string]string
+	MirrorAnnotations map[string]string
+	// SealingKey is the sealed-secrets controller's public key, which Secret sources
+	// with sealed-mirror are sealed with (nil = sealing unavailable)
+	SealingKey *rsa.PublicKey
+	// Protected are the controller's own objects, never mirrored nor overwritten
+	// by mirrors (nil = none)
+	Protected *ProtectedObjects
+	// AllowNamespaceCreation lets sources with create-missing-namespaces create the
+	// target namespaces they list that do not exist yet
+	AllowNamespaceCreation bool
+	// CreatedNamespaceLabels and CreatedNamespaceAnnotations are set on namespaces
+	// created for sources
+	CreatedNamespaceLabels      map[string]string
+	CreatedNamespaceAnnotations map[string]string
+	// VerifySourceFreshness checks cache staleness of sources and re-fetches from API if needed
 	// Prevents mirroring stale data when cache hasn't updated yet after watch event
 	// Trades some API load for guaranteed data freshness
 	VerifySourceFreshness bool
+
+	// mu guards the settings a configuration file reload replaces while
+	// reconcilers read them (see ApplyTunables)
+	mu sync.RWMutex
 }
 
 // LeaderElectionConfig holds leader election settings.
@@ -69,13 +153,160 @@ type LeaderElectionConfig struct {
 	RenewDeadline time.Duration
 	// RetryPeriod is the retry period
 	RetryPeriod time.Duration
+	// ResourceLock is the kind of object holding the leases: "leases" (default)
+	// or "configmaps" for clusters without the coordination.k8s.io API
+	ResourceLock string
 
 	// Enabled enables leader election
 	Enabled bool
+	// ShardByResourceType runs one lease per resource type instead of a single
+	// manager-wide lease, so different replicas can own different types
+	ShardByResourceType bool
+	// NamespaceShards splits target namespaces into this many hash shards, each with
+	// its own lease, so mirror writes scale across replicas (0 disables)
+	NamespaceShards int
+	// MaxNamespaceShardsPerReplica caps the shards a single replica holds (0 = unlimited)
+	MaxNamespaceShardsPerReplica int
+}
+
+// ServerDryRunEnabled reports whether mirror writes of gvk are validated with a
+// server-side dry run before the real write.
+func (c *Config) ServerDryRunEnabled(gvk schema.GroupVersionKind) bool {
+	for _, rt := range c.ServerDryRunTypes {
+		if rt.GroupVersionKind() == gvk {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxTargets returns MaxTargetsPerResource; safe to call during a reload.
+func (c *Config) MaxTargets() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.MaxTargetsPerResource
+}
+
+// TargetTruncation returns TruncationPolicy, or the default if unset; safe to
+// call during a reload.
+func (c *Config) TargetTruncation() TruncationPolicy {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.TruncationPolicy == "" {
+		return DefaultTruncationPolicy
+	}
+	return c.TruncationPolicy
+}
+
+// TransformDefaults returns DefaultTransformRules; safe to call during a reload.
+func (c *Config) TransformDefaults() *transformer.DefaultRules {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.DefaultTransformRules
+}
+
+// NamespaceRefs returns NamespaceRefFields; safe to call during a reload.
+func (c *Config) NamespaceRefs() *transformer.NamespaceRefFields {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.NamespaceRefFields
+}
+
+// SecretTypeRules returns SecretTypes, or the default policy if unset; safe to
+// call during a reload.
+func (c *Config) SecretTypeRules() *SecretTypePolicy {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.SecretTypes == nil {
+		return DefaultSecretTypePolicy()
+	}
+	return c.SecretTypes
+}
+
+// RetryBackoffFor returns the retry schedule of an error class, from RetryBackoff
+// or DefaultRetryBackoff if unset; safe to call during a reload.
+func (c *Config) RetryBackoffFor(class ErrorClass) (Backoff, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.RetryBackoff == nil {
+		backoff, ok := DefaultRetryBackoff()[class]
+		return backoff, ok
+	}
+	backoff, ok := c.RetryBackoff[class]
+	return backoff, ok
+}
+
+// PrunedFields returns PruneFields; safe to call during a reload.
+func (c *Config) PrunedFields() *transformer.PruneFields {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.PruneFields
+}
+
+// PausedFor reports whether mirroring of gvk is paused, and whether the pause is
+// controller-wide rather than for the resource type; safe to call during a reload.
+func (c *Config) PausedFor(gvk schema.GroupVersionKind) (paused, controllerWide bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.Paused {
+		return true, true
+	}
+	for _, rt := range c.PausedResourceTypes {
+		if rt.GroupVersionKind() == gvk {
+			return true, false
+		}
+	}
+	return false, false
+}
+
+// ApplyTunables stores the reloadable settings of t. The namespace filter,
+// circuit breaker and API rate limiter hold their own copies and are updated
+// by the caller.
+func (c *Config) ApplyTunables(t Tunables) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ExcludedNamespaces = t.ExcludedNamespaces
+	c.MaxTargetsPerResource = t.MaxTargets
+	c.TruncationPolicy = t.TruncationPolicy
+	c.RateLimitQPS = t.RateLimitQPS
+	c.RateLimitBurst = t.RateLimitBurst
+	c.DefaultTransformRules = t.DefaultTransformRules
+	c.NamespaceRefFields = t.NamespaceRefFields
+	c.PruneFields = t.PruneFields
+	c.Paused = t.Paused
+	c.PausedResourceTypes = t.PausedResourceTypes
+	c.SecretTypes = t.SecretTypes
+	c.RetryBackoff = t.RetryBackoff
 }
 
 // Validate checks if the configuration is valid.
 func (c *Config) Validate() error {
-	// Add validation logic if needed
+	return c.LeaderElection.Validate()
+}
+
+// Active reports whether any form of leader election is enabled.
+func (l LeaderElectionConfig) Active() bool {
+	return l.Enabled || l.ShardByResourceType || l.NamespaceShards > 0
+}
+
+// Validate checks the lease timings when leader election is active. The lease
+// must outlast the renew deadline, which must outlast a retry, or leaders would
+// lose their lease before they get a chance to renew it.
+func (l LeaderElectionConfig) Validate() error {
+	if !l.Active() {
+		return nil
+	}
+	if l.LeaseDuration <= 0 || l.RenewDeadline <= 0 || l.RetryPeriod <= 0 {
+		return errors.New("leader election lease duration, renew deadline and retry period must be positive")
+	}
+	if l.LeaseDuration <= l.RenewDeadline {
+		return fmt.Errorf("leader election lease duration (%s) must be greater than the renew deadline (%s)",
+			l.LeaseDuration, l.RenewDeadline)
+	}
+	// client-go jitters retries by up to 20%
+	if float64(l.RenewDeadline) <= 1.2*float64(l.RetryPeriod) {
+		return fmt.Errorf("leader election renew deadline (%s) must be greater than 1.2 times the retry period (%s)",
+			l.RenewDeadline, l.RetryPeriod)
+	}
 	return nil
 }
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaderElectionConfig_Validate(t *testing.T) {
+	valid := LeaderElectionConfig{
+		Enabled:       true,
+		LeaseDuration: 15 * time.Second,
+		RenewDeadline: 10 * time.Second,
+		RetryPeriod:   2 * time.Second,
+	}
+	tests := []struct {
+		name    string
+		mutate  func(*LeaderElectionConfig)
+		wantErr bool
+	}{
+		{name: "defaults", mutate: func(*LeaderElectionConfig) {}},
+		{name: "disabled ignores timings", mutate: func(l *LeaderElectionConfig) { *l = LeaderElectionConfig{} }},
+		{name: "zero retry period", mutate: func(l *LeaderElectionConfig) { l.RetryPeriod = 0 }, wantErr: true},
+		{name: "lease not above renew deadline", mutate: func(l *LeaderElectionConfig) { l.LeaseDuration = l.RenewDeadline }, wantErr: true},
+		{name: "renew deadline within retry jitter", mutate: func(l *LeaderElectionConfig) { l.RetryPeriod = 9 * time.Second }, wantErr: true},
+		{
+			name: "sharding validates timings",
+			mutate: func(l *LeaderElectionConfig) {
+				l.Enabled = false
+				l.NamespaceShards = 4
+				l.LeaseDuration = time.Second
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.mutate(&cfg)
+			err := (&Config{LeaderElection: cfg}).Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
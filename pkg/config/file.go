@@ -0,0 +1,383 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/circuitbreaker"
+	"github.com/lukaszraczylo/kubemirror/pkg/transformer"
+)
+
+// File is the YAML configuration file given with --config. Settings it sets take
+// precedence over the matching flags; settings it leaves out keep their flag
+// values, also when they are removed on a reload.
+//
+//	excludedNamespaces: [legacy, sandbox]
+//	includedNamespaces: ["app-*"]
+//	resourceTypes: [Secret.v1, ConfigMap.v1]
+//	discoveryExcludeGroups: [metrics.k8s.io, "*.cattle.io"]
+//	discoveryDenyKinds: [Backup.velero.io]
+//	discoveryAllowKinds: [Certificate.cert-manager.io]
+//	maxTargets: 200
+//	truncationPolicy: oldest-first
+//	rateLimit:
+//	  qps: 100
+//	  burst: 200
+//	circuitBreaker:
+//	  failureThreshold: 5
+//	  resetTimeout: 5m
+//	  halfOpenSuccessThreshold: 2
+//	defaultTransformRules:
+//	  "*":
+//	    - path: metadata.labels.mirrored-from
+//	      template: "{{.SourceNamespace}}"
+//	namespaceRefFields:
+//	  Certificate.v1.cert-manager.io: [spec.issuerRef.namespace]
+//	pruneFields:
+//	  Service.v1: [spec.clusterIP, spec.clusterIPs, "spec.ports[*].nodePort"]
+//	priorities:
+//	  Secret.v1: high
+//	  Certificate.v1.cert-manager.io: low
+//	maxInFlight:
+//	  Secret.v1: 4
+//	paused: false
+//	pausedResourceTypes: [Certificate.v1.cert-manager.io]
+//	secretTypes:
+//	  deny: [kubernetes.io/service-account-token]
+//	  requireOptIn: [bootstrap.kubernetes.io/token]
+//	  audit: [kubernetes.io/tls]
+//	retryBackoff:
+//	  quotaExceeded: {initial: 10m, max: 1h}
+//	  conflict: {initial: 100ms, max: 10s}
+type File struct {
+	// ExcludedNamespaces are never mirrored to, in addition to the built-in exclusions
+	ExcludedNamespaces []string `yaml:"excludedNamespaces"`
+	// IncludedNamespaces are the namespace patterns mirrors may be written to (empty = all)
+	IncludedNamespaces []string `yaml:"includedNamespaces"`
+	// ResourceTypes are the resource types to mirror; only read at startup
+	ResourceTypes []string `yaml:"resourceTypes"`
+	// DiscoveryIncludeGroups limits auto-discovery to API groups matching these
+	// patterns (empty = all); only read at startup
+	DiscoveryIncludeGroups []string `yaml:"discoveryIncludeGroups"`
+	// DiscoveryExcludeGroups are API group patterns auto-discovery skips; only read at startup
+	DiscoveryExcludeGroups []string `yaml:"discoveryExcludeGroups"`
+	// DiscoveryDenyKinds are kinds auto-discovery skips in addition to the built-in
+	// deny list, as Kind or Kind.group; only read at startup
+	DiscoveryDenyKinds []string `yaml:"discoveryDenyKinds"`
+	// DiscoveryAllowKinds are kinds auto-discovery keeps although the built-in deny
+	// list or the discovery heuristics would skip them; only read at startup
+	DiscoveryAllowKinds []string `yaml:"discoveryAllowKinds"`
+	// DiscoveryHeuristics skips kinds that look controller-owned, such as those
+	// serving a scale subresource (default true); only read at startup
+	DiscoveryHeuristics *bool `yaml:"discoveryHeuristics"`
+	// MaxTargets is the maximum number of target namespaces per resource
+	MaxTargets *int `yaml:"maxTargets"`
+	// TruncationPolicy decides which targets are kept over maxTargets:
+	// alphabetical, oldest-first or fail
+	TruncationPolicy string `yaml:"truncationPolicy"`
+	// RateLimit limits requests to the API server
+	RateLimit *RateLimitSettings `yaml:"rateLimit"`
+	// CircuitBreaker tunes when failing sources stop being retried
+	CircuitBreaker *CircuitBreakerSettings `yaml:"circuitBreaker"`
+	// DefaultTransformRules are applied to every mirror before the source's own
+	// rules, in the --default-transform-rules format
+	DefaultTransformRules map[string][]transformer.Rule `yaml:"defaultTransformRules"`
+	// NamespaceRefFields are the namespace-bearing fields rewriteNamespaceRefs transform
+	// rules rewrite, by resource type; they replace the built-in fields of the type
+	NamespaceRefFields map[string][]string `yaml:"namespaceRefFields"`
+	// PruneFields are stripped from mirrors, by resource type, e.g. cluster-assigned
+	// IPs and ports
+	PruneFields map[string][]string `yaml:"pruneFields"`
+	// Priorities rank resource types ("Kind.version[.group]") as high, normal or low;
+	// only read at startup
+	Priorities map[string]string `yaml:"priorities"`
+	// MaxInFlight limits how many sources of a resource type are reconciled at once;
+	// only read at startup
+	MaxInFlight map[string]int `yaml:"maxInFlight"`
+	// Paused stops all mirror writes and cleanups while true
+	Paused *bool `yaml:"paused"`
+	// PausedResourceTypes stops mirror writes and cleanups of these resource types
+	PausedResourceTypes []string `yaml:"pausedResourceTypes"`
+	// SecretTypes decides which Secret types are refused, need an opt-in or are
+	// audited; each list set replaces the default one
+	SecretTypes *SecretTypePolicy `yaml:"secretTypes"`
+	// RetryBackoff are the retry schedules of failed mirror writes, by error class;
+	// each class set replaces its default schedule
+	RetryBackoff map[string]Backoff `yaml:"retryBackoff"`
+}
+
+// RateLimitSettings limits requests to the API server.
+type RateLimitSettings struct {
+	// QPS is the sustained request rate (0 disables client-side rate limiting)
+	QPS *float32 `yaml:"qps"`
+	// Burst is the number of requests allowed above QPS for short periods
+	Burst *int `yaml:"burst"`
+}
+
+// CircuitBreakerSettings tune the circuit breaker; unset fields keep their defaults.
+type CircuitBreakerSettings struct {
+	// FailureThreshold is the number of consecutive failures that opens a source's circuit
+	FailureThreshold *int `yaml:"failureThreshold"`
+	// ResetTimeout is how long a circuit stays open before the source is retried
+	ResetTimeout *time.Duration `yaml:"resetTimeout"`
+	// HalfOpenSuccessThreshold is the number of successes that closes a retried circuit
+	HalfOpenSuccessThreshold *int `yaml:"halfOpenSuccessThreshold"`
+}
+
+// Tunables are the settings a configuration file reload applies to the running
+// controller, without a restart.
+type Tunables struct {
+	// ExcludedNamespaces are the namespaces never mirrored to, built-in exclusions included
+	ExcludedNamespaces []string
+	// IncludedNamespaces are the namespace patterns mirrors may be written to (empty = all)
+	IncludedNamespaces []string
+	// MaxTargets is the maximum number of target namespaces per resource
+	MaxTargets int
+	// TruncationPolicy decides which targets are kept over MaxTargets
+	TruncationPolicy TruncationPolicy
+	// RateLimitQPS is the maximum queries per second to the API server (0 = unlimited)
+	RateLimitQPS float32
+	// RateLimitBurst is the burst capacity for rate limiting
+	RateLimitBurst int
+	// CircuitBreaker is the circuit breaker configuration
+	CircuitBreaker circuitbreaker.Config
+	// DefaultTransformRules are applied to every mirror before the source's own rules
+	DefaultTransformRules *transformer.DefaultRules
+	// NamespaceRefFields are the namespace-bearing fields rewriteNamespaceRefs rules
+	// rewrite (nil = the built-in fields)
+	NamespaceRefFields *transformer.NamespaceRefFields
+	// PruneFields are stripped from mirrors, by resource type (nil = none)
+	PruneFields *transformer.PruneFields
+	// Paused stops all mirror writes and cleanups
+	Paused bool
+	// PausedResourceTypes stops mirror writes and cleanups of these resource types
+	PausedResourceTypes []ResourceType
+	// SecretTypes decides which Secret types are refused, need an opt-in or are
+	// audited (nil = DefaultSecretTypePolicy)
+	SecretTypes *SecretTypePolicy
+	// RetryBackoff are the retry schedules of failed mirror writes, by error class
+	// (nil = DefaultRetryBackoff)
+	RetryBackoff RetryBackoff
+}
+
+// LoadFile reads and validates a configuration file.
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return ParseFile(data)
+}
+
+// ParseFile parses and validates a configuration file. Unknown keys are
+// rejected, so a misspelt setting is reported instead of silently ignored.
+func ParseFile(data []byte) (*File, error) {
+	f := &File{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if _, err := f.MirroredResourceTypes(); err != nil {
+		return nil, err
+	}
+	if _, err := f.QueueSettings(); err != nil {
+		return nil, err
+	}
+	if _, _, err := f.DiscoveryGroups(); err != nil {
+		return nil, err
+	}
+	if _, _, err := f.DiscoveryKinds(); err != nil {
+		return nil, err
+	}
+	if _, err := f.Apply(Tunables{}); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// MirroredResourceTypes returns the parsed resourceTypes, or nil if unset.
+func (f *File) MirroredResourceTypes() ([]ResourceType, error) {
+	if len(f.ResourceTypes) == 0 {
+		return nil, nil
+	}
+	types, err := ParseResourceTypes(strings.Join(f.ResourceTypes, ","))
+	if err != nil {
+		return nil, fmt.Errorf("config file: resourceTypes: %w", err)
+	}
+	return types, nil
+}
+
+// DiscoveryGroups returns the normalized discoveryIncludeGroups and
+// discoveryExcludeGroups; each is nil if unset.
+func (f *File) DiscoveryGroups() (include, exclude []string, err error) {
+	if f.DiscoveryIncludeGroups != nil {
+		if include, err = NormalizeGroupPatterns(f.DiscoveryIncludeGroups); err != nil {
+			return nil, nil, fmt.Errorf("config file: discoveryIncludeGroups: %w", err)
+		}
+	}
+	if f.DiscoveryExcludeGroups != nil {
+		if exclude, err = NormalizeGroupPatterns(f.DiscoveryExcludeGroups); err != nil {
+			return nil, nil, fmt.Errorf("config file: discoveryExcludeGroups: %w", err)
+		}
+	}
+	return include, exclude, nil
+}
+
+// DiscoveryKinds returns the parsed discoveryDenyKinds and discoveryAllowKinds;
+// each is nil if unset.
+func (f *File) DiscoveryKinds() (deny, allow []schema.GroupKind, err error) {
+	if f.DiscoveryDenyKinds != nil {
+		if deny, err = NormalizeKindPatterns(f.DiscoveryDenyKinds); err != nil {
+			return nil, nil, fmt.Errorf("config file: discoveryDenyKinds: %w", err)
+		}
+	}
+	if f.DiscoveryAllowKinds != nil {
+		if allow, err = NormalizeKindPatterns(f.DiscoveryAllowKinds); err != nil {
+			return nil, nil, fmt.Errorf("config file: discoveryAllowKinds: %w", err)
+		}
+	}
+	return deny, allow, nil
+}
+
+// Apply returns base, which holds the flag values, with the settings of f
+// replacing the ones it sets. Excluded namespaces from the file replace the
+// flag's, and are added to defaultExcluded like the flag's are.
+func (f *File) Apply(base Tunables, defaultExcluded ...string) (Tunables, error) {
+	t := base
+	if f.ExcludedNamespaces != nil {
+		t.ExcludedNamespaces = append(append([]string{}, defaultExcluded...), f.ExcludedNamespaces...)
+	}
+	if f.IncludedNamespaces != nil {
+		t.IncludedNamespaces = f.IncludedNamespaces
+	}
+	if f.MaxTargets != nil {
+		if *f.MaxTargets < 0 {
+			return Tunables{}, fmt.Errorf("config file: maxTargets must not be negative")
+		}
+		t.MaxTargets = *f.MaxTargets
+	}
+	if f.TruncationPolicy != "" {
+		policy, err := ParseTruncationPolicy(f.TruncationPolicy)
+		if err != nil {
+			return Tunables{}, fmt.Errorf("config file: truncationPolicy: %w", err)
+		}
+		t.TruncationPolicy = policy
+	}
+
+	if rl := f.RateLimit; rl != nil {
+		if rl.QPS != nil {
+			if *rl.QPS < 0 {
+				return Tunables{}, fmt.Errorf("config file: rateLimit.qps must not be negative")
+			}
+			t.RateLimitQPS = *rl.QPS
+		}
+		if rl.Burst != nil {
+			if *rl.Burst < 0 {
+				return Tunables{}, fmt.Errorf("config file: rateLimit.burst must not be negative")
+			}
+			t.RateLimitBurst = *rl.Burst
+		}
+	}
+
+	if cb := f.CircuitBreaker; cb != nil {
+		if cb.FailureThreshold != nil {
+			if *cb.FailureThreshold < 1 {
+				return Tunables{}, fmt.Errorf("config file: circuitBreaker.failureThreshold must be at least 1")
+			}
+			t.CircuitBreaker.FailureThreshold = *cb.FailureThreshold
+		}
+		if cb.ResetTimeout != nil {
+			if *cb.ResetTimeout <= 0 {
+				return Tunables{}, fmt.Errorf("config file: circuitBreaker.resetTimeout must be positive")
+			}
+			t.CircuitBreaker.ResetTimeout = *cb.ResetTimeout
+		}
+		if cb.HalfOpenSuccessThreshold != nil {
+			if *cb.HalfOpenSuccessThreshold < 1 {
+				return Tunables{}, fmt.Errorf("config file: circuitBreaker.halfOpenSuccessThreshold must be at least 1")
+			}
+			t.CircuitBreaker.HalfOpenSuccessThreshold = *cb.HalfOpenSuccessThreshold
+		}
+	}
+
+	if f.DefaultTransformRules != nil {
+		rules, err := transformer.NewDefaultRules(f.DefaultTransformRules)
+		if err != nil {
+			return Tunables{}, fmt.Errorf("config file: defaultTransformRules: %w", err)
+		}
+		t.DefaultTransformRules = rules
+	}
+
+	if f.NamespaceRefFields != nil {
+		fields, err := transformer.NewNamespaceRefFields(f.NamespaceRefFields)
+		if err != nil {
+			return Tunables{}, fmt.Errorf("config file: namespaceRefFields: %w", err)
+		}
+		t.NamespaceRefFields = fields
+	}
+
+	if f.PruneFields != nil {
+		fields, err := transformer.NewPruneFields(f.PruneFields)
+		if err != nil {
+			return Tunables{}, fmt.Errorf("config file: pruneFields: %w", err)
+		}
+		t.PruneFields = fields
+	}
+
+	if f.Paused != nil {
+		t.Paused = *f.Paused
+	}
+	if f.PausedResourceTypes != nil {
+		t.PausedResourceTypes = nil
+	}
+	if len(f.PausedResourceTypes) > 0 {
+		types, err := ParseResourceTypes(strings.Join(f.PausedResourceTypes, ","))
+		if err != nil {
+			return Tunables{}, fmt.Errorf("config file: pausedResourceTypes: %w", err)
+		}
+		t.PausedResourceTypes = types
+	}
+
+	if st := f.SecretTypes; st != nil {
+		policy := *DefaultSecretTypePolicy()
+		if t.SecretTypes != nil {
+			policy = *t.SecretTypes
+		}
+		if st.Deny != nil {
+			policy.Deny = st.Deny
+		}
+		if st.RequireOptIn != nil {
+			policy.RequireOptIn = st.RequireOptIn
+		}
+		if st.Audit != nil {
+			policy.Audit = st.Audit
+		}
+		if err := policy.validate(); err != nil {
+			return Tunables{}, fmt.Errorf("config file: secretTypes: %w", err)
+		}
+		t.SecretTypes = &policy
+	}
+
+	if f.RetryBackoff != nil {
+		base := t.RetryBackoff
+		if base == nil {
+			base = DefaultRetryBackoff()
+		}
+		backoff, err := parseRetryBackoff(base, f.RetryBackoff)
+		if err != nil {
+			return Tunables{}, fmt.Errorf("config file: retryBackoff: %w", err)
+		}
+		t.RetryBackoff = backoff
+	}
+	return t, nil
+}
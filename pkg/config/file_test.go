@@ -0,0 +1,151 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/circuitbreaker"
+)
+
+func TestParseFile(t *testing.T) {
+	f, err := ParseFile([]byte(`
+excludedNamespaces: [legacy]
+includedNamespaces: ["app-*"]
+resourceTypes: [Secret.v1, Ingress.v1.networking.k8s.io]
+maxTargets: 200
+truncationPolicy: oldest-first
+rateLimit:
+  qps: 100
+circuitBreaker:
+  resetTimeout: 30s
+defaultTransformRules:
+  Secret.v1:
+    - path: data.DEBUG
+      delete: true
+namespaceRefFields:
+  Certificate.v1.cert-manager.io: [spec.issuerRef.namespace]
+pruneFields:
+  Service.v1: [spec.clusterIP]
+`))
+	require.NoError(t, err)
+
+	types, err := f.MirroredResourceTypes()
+	require.NoError(t, err)
+	assert.Len(t, types, 2)
+
+	base := Tunables{
+		ExcludedNamespaces: []string{"kube-system", "from-flag"},
+		MaxTargets:         100,
+		RateLimitQPS:       50,
+		RateLimitBurst:     100,
+		CircuitBreaker:     circuitbreaker.DefaultConfig(),
+	}
+	got, err := f.Apply(base, "kube-system")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kube-system", "legacy"}, got.ExcludedNamespaces, "replaces the flag's exclusions")
+	assert.Equal(t, []string{"app-*"}, got.IncludedNamespaces)
+	assert.Equal(t, 200, got.MaxTargets)
+	assert.Equal(t, TruncateOldestFirst, got.TruncationPolicy)
+	assert.Equal(t, float32(100), got.RateLimitQPS)
+	assert.Equal(t, 100, got.RateLimitBurst, "unset keeps the flag value")
+	assert.Equal(t, 30*time.Second, got.CircuitBreaker.ResetTimeout)
+	assert.Equal(t, 5, got.CircuitBreaker.FailureThreshold)
+	assert.Len(t, got.DefaultTransformRules.For(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}), 1)
+	assert.Equal(t, []string{"spec.issuerRef.namespace"},
+		got.NamespaceRefFields.For(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}))
+	assert.Equal(t, []string{"spec.clusterIP"}, got.PruneFields.For(schema.GroupVersionKind{Version: "v1", Kind: "Service"}))
+}
+
+func TestParseFile_Empty(t *testing.T) {
+	f, err := ParseFile(nil)
+	require.NoError(t, err)
+
+	base := Tunables{MaxTargets: 100, ExcludedNamespaces: []string{"kube-system"}}
+	got, err := f.Apply(base)
+	require.NoError(t, err)
+	assert.Equal(t, base, got)
+}
+
+func TestConfig_PausedFor(t *testing.T) {
+	secret := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+	f, err := ParseFile([]byte("pausedResourceTypes: [Secret.v1]"))
+	require.NoError(t, err)
+	tunables, err := f.Apply(Tunables{})
+	require.NoError(t, err)
+
+	cfg := &Config{}
+	cfg.ApplyTunables(tunables)
+	paused, controllerWide := cfg.PausedFor(secret)
+	assert.True(t, paused)
+	assert.False(t, controllerWide)
+	paused, _ = cfg.PausedFor(configMap)
+	assert.False(t, paused)
+
+	// An empty list resumes the types paused by the flags
+	f, err = ParseFile([]byte("paused: true\npausedResourceTypes: []"))
+	require.NoError(t, err)
+	tunables, err = f.Apply(tunables)
+	require.NoError(t, err)
+	assert.Empty(t, tunables.PausedResourceTypes)
+
+	cfg.ApplyTunables(tunables)
+	paused, controllerWide = cfg.PausedFor(configMap)
+	assert.True(t, paused)
+	assert.True(t, controllerWide)
+}
+
+func TestFile_DiscoveryGroups(t *testing.T) {
+	f, err := ParseFile([]byte(`discoveryIncludeGroups: ["", core, networking.k8s.io]`))
+	require.NoError(t, err)
+	include, exclude, err := f.DiscoveryGroups()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "", "networking.k8s.io"}, include)
+	assert.Nil(t, exclude, "unset keeps the flag value")
+}
+
+func TestFile_DiscoveryKinds(t *testing.T) {
+	f, err := ParseFile([]byte(`discoveryAllowKinds: [Job.batch]`))
+	require.NoError(t, err)
+	deny, allow, err := f.DiscoveryKinds()
+	require.NoError(t, err)
+	assert.Nil(t, deny)
+	assert.Equal(t, []schema.GroupKind{{Group: "batch", Kind: "Job"}}, allow)
+}
+
+func TestParseFile_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown key":          "maxTarget: 10",
+		"negative maxTargets":  "maxTargets: -1",
+		"negative qps":         "rateLimit: {qps: -1}",
+		"zero threshold":       "circuitBreaker: {failureThreshold: 0}",
+		"bad duration":         "circuitBreaker: {resetTimeout: soon}",
+		"bad resource type":    "resourceTypes: [Secret]",
+		"bad transform rule":   "defaultTransformRules: {Secret.v1: [{path: ''}]}",
+		"unknown priority":     "priorities: {Secret.v1: urgent}",
+		"bad priority type":    "priorities: {Secret: high}",
+		"zero maxInFlight":     "maxInFlight: {Secret.v1: 0}",
+		"bad namespace ref":    "namespaceRefFields: {Secret.v1: ['spec.refs[x].namespace']}",
+		"bad paused type":      "pausedResourceTypes: [Secret]",
+		"bad pruned field":     "pruneFields: {Service.v1: [metadata.annotations]}",
+		"bad discovery group":  "discoveryExcludeGroups: ['[metrics']",
+		"bad discovery kind":   "discoveryDenyKinds: [job]",
+		"empty secret type":    "secretTypes: {audit: ['']}",
+		"denied opt-in type":   "secretTypes: {requireOptIn: [kubernetes.io/service-account-token]}",
+		"unknown error class":  "retryBackoff: {notFound: {initial: 1s, max: 1m}}",
+		"zero initial backoff": "retryBackoff: {conflict: {initial: 0s, max: 1m}}",
+		"max below initial":    "retryBackoff: {quotaExceeded: {initial: 1h, max: 10m}}",
+		"unknown truncation":   "truncationPolicy: newest-first",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseFile([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
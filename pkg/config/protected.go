@@ -0,0 +1,27 @@
+package config
+
+import "path"
+
+// ProtectedObjects are the objects the controller itself depends on, e.g. its
+// leader election Leases and the ConfigMaps its pod mounts. They are never
+// mirrored, nor overwritten by mirrors, however they are labeled or annotated.
+type ProtectedObjects struct {
+	// Namespace is the controller's namespace
+	Namespace string
+	// Names are glob patterns of the protected object names in Namespace, of any kind
+	Names []string
+}
+
+// Protects reports whether the object namespace/name is protected. A nil
+// ProtectedObjects protects nothing.
+func (p *ProtectedObjects) Protects(namespace, name string) bool {
+	if p == nil || namespace == "" || namespace != p.Namespace {
+		return false
+	}
+	for _, pattern := range p.Names {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProtectedObjects_Protects(t *testing.T) {
+	p := &ProtectedObjects{
+		Namespace: "kubemirror-system",
+		Names:     []string{"kubemirror-config", "kubemirror-leader-*"},
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		object    string
+		want      bool
+	}{
+		{"exact name", "kubemirror-system", "kubemirror-config", true},
+		{"lease pattern", "kubemirror-system", "kubemirror-leader-ns-shard-0", true},
+		{"other name", "kubemirror-system", "app-config", false},
+		{"other namespace", "default", "kubemirror-config", false},
+		{"cluster scoped", "", "kubemirror-config", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, p.Protects(tt.namespace, tt.object))
+		})
+	}
+
+	var none *ProtectedObjects
+	assert.False(t, none.Protects("kubemirror-system", "kubemirror-config"))
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Priority ranks a resource type's reconciles against other resource types.
+type Priority string
+
+const (
+	// PriorityHigh retries and requeues quickly, for types whose mirrors must converge fast (e.g. Secrets)
+	PriorityHigh Priority = "high"
+	// PriorityNormal uses the controller-runtime work queue defaults
+	PriorityNormal Priority = "normal"
+	// PriorityLow paces retries and requeues, so a noisy type leaves API capacity to the others
+	PriorityLow Priority = "low"
+)
+
+// ParsePriority parses a priority name.
+func ParsePriority(s string) (Priority, error) {
+	switch p := Priority(s); p {
+	case PriorityHigh, PriorityNormal, PriorityLow:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown priority %q: expected %s, %s or %s", s, PriorityHigh, PriorityNormal, PriorityLow)
+	}
+}
+
+// QueueSettings tune the work queues of one resource type.
+type QueueSettings struct {
+	// Priority selects the work queue rate limiter
+	Priority Priority
+	// MaxInFlight is the number of sources of the type reconciled at once
+	// (0 = the controller-runtime default of one)
+	MaxInFlight int
+}
+
+// QueueSettingsFor returns the queue settings of a resource type, normal
+// priority unless configured otherwise.
+func (c *Config) QueueSettingsFor(gvk schema.GroupVersionKind) QueueSettings {
+	if settings, ok := c.Queues[ResourceType{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}]; ok {
+		return settings
+	}
+	return QueueSettings{Priority: PriorityNormal}
+}
+
+// QueueSettings returns the per-resource-type queue settings of the priorities
+// and maxInFlight sections, or nil when neither is set.
+func (f *File) QueueSettings() (map[ResourceType]QueueSettings, error) {
+	if len(f.Priorities) == 0 && len(f.MaxInFlight) == 0 {
+		return nil, nil
+	}
+	queues := make(map[ResourceType]QueueSettings, len(f.Priorities)+len(f.MaxInFlight))
+	settingsFor := func(section, key string) (ResourceType, QueueSettings, error) {
+		rt, err := ParseResourceType(key)
+		if err != nil {
+			return ResourceType{}, QueueSettings{}, fmt.Errorf("config file: %s: %w", section, err)
+		}
+		settings, ok := queues[rt]
+		if !ok {
+			settings.Priority = PriorityNormal
+		}
+		return rt, settings, nil
+	}
+
+	for key, value := range f.Priorities {
+		rt, settings, err := settingsFor("priorities", key)
+		if err != nil {
+			return nil, err
+		}
+		if settings.Priority, err = ParsePriority(value); err != nil {
+			return nil, fmt.Errorf("config file: priorities: %s: %w", key, err)
+		}
+		queues[rt] = settings
+	}
+	for key, value := range f.MaxInFlight {
+		rt, settings, err := settingsFor("maxInFlight", key)
+		if err != nil {
+			return nil, err
+		}
+		if value < 1 {
+			return nil, fmt.Errorf("config file: maxInFlight: %s must be at least 1", key)
+		}
+		settings.MaxInFlight = value
+		queues[rt] = settings
+	}
+	return queues, nil
+}
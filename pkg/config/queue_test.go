@@ -0,0 +1,55 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestParsePriority(t *testing.T) {
+	for _, name := range []string{"high", "normal", "low"} {
+		p, err := ParsePriority(name)
+		require.NoError(t, err)
+		assert.Equal(t, Priority(name), p)
+	}
+	_, err := ParsePriority("HIGH")
+	assert.Error(t, err)
+}
+
+func TestFile_QueueSettings(t *testing.T) {
+	f, err := ParseFile([]byte(`
+priorities:
+  Secret.v1: high
+  Certificate.v1.cert-manager.io: low
+maxInFlight:
+  Secret.v1: 4
+  ConfigMap.v1: 2
+`))
+	require.NoError(t, err)
+
+	queues, err := f.QueueSettings()
+	require.NoError(t, err)
+	assert.Equal(t, map[ResourceType]QueueSettings{
+		{Version: "v1", Kind: "Secret"}:                                {Priority: PriorityHigh, MaxInFlight: 4},
+		{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}: {Priority: PriorityLow},
+		{Version: "v1", Kind: "ConfigMap"}:                             {Priority: PriorityNormal, MaxInFlight: 2},
+	}, queues)
+
+	empty, err := (&File{}).QueueSettings()
+	require.NoError(t, err)
+	assert.Nil(t, empty)
+}
+
+func TestConfig_QueueSettingsFor(t *testing.T) {
+	cfg := &Config{Queues: map[ResourceType]QueueSettings{
+		{Version: "v1", Kind: "Secret"}: {Priority: PriorityHigh, MaxInFlight: 4},
+	}}
+	assert.Equal(t, QueueSettings{Priority: PriorityHigh, MaxInFlight: 4},
+		cfg.QueueSettingsFor(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}))
+	assert.Equal(t, QueueSettings{Priority: PriorityNormal},
+		cfg.QueueSettingsFor(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}))
+	assert.Equal(t, QueueSettings{Priority: PriorityNormal},
+		(&Config{}).QueueSettingsFor(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}))
+}
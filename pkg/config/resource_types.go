@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"path"
 	"strings"
+	"unicode"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
@@ -96,3 +98,67 @@ func ParseResourceTypes(s string) ([]ResourceType, error) {
 
 	return types, nil
 }
+
+// CoreGroupAlias names the core API group, whose name is empty, in API group patterns.
+const CoreGroupAlias = "core"
+
+// ParseGroupPatterns parses a comma-separated list of API group glob patterns
+// (e.g. `"",networking.k8s.io` or `*.k8s.io`). The core group is written as
+// `""` or core; empty entries are skipped.
+func ParseGroupPatterns(s string) ([]string, error) {
+	var patterns []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			patterns = append(patterns, part)
+		}
+	}
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	return NormalizeGroupPatterns(patterns)
+}
+
+// NormalizeGroupPatterns validates API group glob patterns, mapping `""` and core
+// to the empty name of the core group.
+func NormalizeGroupPatterns(patterns []string) ([]string, error) {
+	normalized := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == `""` || pattern == CoreGroupAlias {
+			pattern = ""
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid API group pattern %q: %w", pattern, err)
+		}
+		normalized = append(normalized, pattern)
+	}
+	return normalized, nil
+}
+
+// AnyGroup is the group of kind patterns naming no API group, which match the
+// kind in every group.
+const AnyGroup = "*"
+
+// NormalizeKindPatterns parses kind patterns: Kind matches the kind in every
+// API group, Kind.group only in that group, and Kind.core only in the core group.
+// Patterns without a group get AnyGroup.
+func NormalizeKindPatterns(patterns []string) ([]schema.GroupKind, error) {
+	kinds := make([]schema.GroupKind, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		kind, group, hasGroup := strings.Cut(pattern, ".")
+		if kind == "" || !unicode.IsUpper(rune(kind[0])) || strings.ContainsAny(pattern, " ,*") {
+			return nil, fmt.Errorf("invalid kind pattern %q: want Kind or Kind.group", pattern)
+		}
+		switch {
+		case !hasGroup:
+			group = AnyGroup
+		case group == CoreGroupAlias:
+			group = ""
+		case group == "":
+			return nil, fmt.Errorf("invalid kind pattern %q: want Kind or Kind.group", pattern)
+		}
+		kinds = append(kinds, schema.GroupKind{Group: group, Kind: kind})
+	}
+	return kinds, nil
+}
@@ -223,3 +223,31 @@ func TestDefaultResourceTypes(t *testing.T) {
 	assert.Contains(t, defaults, ResourceType{Kind: "Secret", Version: "v1", Group: ""})
 	assert.Contains(t, defaults, ResourceType{Kind: "ConfigMap", Version: "v1", Group: ""})
 }
+
+func TestParseGroupPatterns(t *testing.T) {
+	patterns, err := ParseGroupPatterns(`"", networking.k8s.io,core,*.cattle.io,`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "networking.k8s.io", "", "*.cattle.io"}, patterns)
+
+	patterns, err = ParseGroupPatterns("")
+	require.NoError(t, err)
+	assert.Nil(t, patterns)
+
+	_, err = ParseGroupPatterns("[metrics")
+	assert.Error(t, err)
+}
+
+func TestNormalizeKindPatterns(t *testing.T) {
+	kinds, err := NormalizeKindPatterns([]string{"Job", " Backup.velero.io", "Endpoints.core"})
+	require.NoError(t, err)
+	assert.Equal(t, []schema.GroupKind{
+		{Group: AnyGroup, Kind: "Job"},
+		{Group: "velero.io", Kind: "Backup"},
+		{Group: "", Kind: "Endpoints"},
+	}, kinds)
+
+	for _, invalid := range []string{"", "job", "Job.", ".batch", "Job,CronJob", "Cert*"} {
+		_, err := NormalizeKindPatterns([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ErrorClass groups mirror write errors that are retried on the same schedule.
+type ErrorClass string
+
+const (
+	// ErrorClassConflict is a write based on a stale resourceVersion; retrying soon succeeds
+	ErrorClassConflict ErrorClass = "conflict"
+	// ErrorClassForbidden is a write refused by RBAC, which takes a person to fix
+	ErrorClassForbidden ErrorClass = "forbidden"
+	// ErrorClassQuotaExceeded is a write over a ResourceQuota or a namespace's max-mirrors
+	ErrorClassQuotaExceeded ErrorClass = "quotaExceeded"
+	// ErrorClassWebhookDenied is a write rejected by an admission webhook or policy
+	ErrorClassWebhookDenied ErrorClass = "webhookDenied"
+	// ErrorClassTimeout is a write the API server did not answer in time
+	ErrorClassTimeout ErrorClass = "timeout"
+)
+
+// errorClasses are the known error classes.
+var errorClasses = []ErrorClass{
+	ErrorClassConflict, ErrorClassForbidden, ErrorClassQuotaExceeded, ErrorClassWebhookDenied, ErrorClassTimeout,
+}
+
+// Backoff is an exponential retry schedule: the first retry comes after Initial,
+// each next one after twice as long as the previous, up to Max.
+type Backoff struct {
+	Initial time.Duration `yaml:"initial"`
+	Max     time.Duration `yaml:"max"`
+}
+
+// Delay returns the wait before retry number attempt (1 = the first retry).
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := b.Initial
+	for i := 1; i < attempt && delay < b.Max; i++ {
+		delay *= 2
+	}
+	return min(delay, b.Max)
+}
+
+// validate rejects schedules that never wait or shrink.
+func (b Backoff) validate() error {
+	if b.Initial <= 0 {
+		return fmt.Errorf("initial must be positive")
+	}
+	if b.Max < b.Initial {
+		return fmt.Errorf("max must not be less than initial")
+	}
+	return nil
+}
+
+// RetryBackoff holds the retry schedules of sources whose mirror writes failed,
+// by error class. Failures of other kinds use the work queue's backoff.
+type RetryBackoff map[ErrorClass]Backoff
+
+// DefaultRetryBackoff retries conflicts and timeouts quickly and waits long for
+// failures that need a person or freed capacity to clear.
+func DefaultRetryBackoff() RetryBackoff {
+	return RetryBackoff{
+		ErrorClassConflict:      {Initial: 100 * time.Millisecond, Max: 10 * time.Second},
+		ErrorClassForbidden:     {Initial: time.Minute, Max: 30 * time.Minute},
+		ErrorClassQuotaExceeded: {Initial: 10 * time.Minute, Max: time.Hour},
+		ErrorClassWebhookDenied: {Initial: 5 * time.Minute, Max: time.Hour},
+		ErrorClassTimeout:       {Initial: time.Second, Max: 2 * time.Minute},
+	}
+}
+
+// parseRetryBackoff returns base with the schedules of settings replacing the
+// ones of their classes.
+func parseRetryBackoff(base RetryBackoff, settings map[string]Backoff) (RetryBackoff, error) {
+	merged := make(RetryBackoff, len(base)+len(settings))
+	for class, backoff := range base {
+		merged[class] = backoff
+	}
+	for key, backoff := range settings {
+		class := ErrorClass(key)
+		if !slices.Contains(errorClasses, class) {
+			return nil, fmt.Errorf("unknown error class %q (valid: %v)", key, errorClasses)
+		}
+		if err := backoff.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		merged[class] = backoff
+	}
+	return merged, nil
+}
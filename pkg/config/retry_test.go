@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 10 * time.Second}
+	assert.Equal(t, time.Second, b.Delay(1))
+	assert.Equal(t, 2*time.Second, b.Delay(2))
+	assert.Equal(t, 8*time.Second, b.Delay(4))
+	assert.Equal(t, 10*time.Second, b.Delay(5), "capped at max")
+	assert.Equal(t, 10*time.Second, b.Delay(100))
+}
+
+func TestFile_Apply_RetryBackoff(t *testing.T) {
+	f, err := ParseFile([]byte("retryBackoff:\n  quotaExceeded: {initial: 20m, max: 2h}\n"))
+	require.NoError(t, err)
+
+	tunables, err := f.Apply(Tunables{})
+	require.NoError(t, err)
+	assert.Equal(t, Backoff{Initial: 20 * time.Minute, Max: 2 * time.Hour}, tunables.RetryBackoff[ErrorClassQuotaExceeded])
+	assert.Equal(t, DefaultRetryBackoff()[ErrorClassConflict], tunables.RetryBackoff[ErrorClassConflict],
+		"classes left out keep their defaults")
+}
+
+func TestConfig_RetryBackoffFor(t *testing.T) {
+	cfg := &Config{}
+	backoff, ok := cfg.RetryBackoffFor(ErrorClassQuotaExceeded)
+	require.True(t, ok)
+	assert.Equal(t, 10*time.Minute, backoff.Initial)
+
+	cfg.ApplyTunables(Tunables{RetryBackoff: RetryBackoff{ErrorClassConflict: {Initial: time.Second, Max: time.Minute}}})
+	backoff, ok = cfg.RetryBackoffFor(ErrorClassConflict)
+	require.True(t, ok)
+	assert.Equal(t, time.Second, backoff.Initial)
+	_, ok = cfg.RetryBackoffFor(ErrorClassQuotaExceeded)
+	assert.False(t, ok)
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SecretTypePolicy decides how Secrets are mirrored by their type.
+type SecretTypePolicy struct {
+	// Deny lists the Secret types never mirrored
+	Deny []string `yaml:"deny"`
+	// RequireOptIn lists the Secret types mirrored only from sources annotated
+	// allow-sensitive: "true"
+	RequireOptIn []string `yaml:"requireOptIn"`
+	// Audit lists the Secret types whose every mirror write is logged and recorded
+	Audit []string `yaml:"audit"`
+}
+
+// DefaultSecretTypePolicy refuses service account tokens, requires an opt-in for
+// bootstrap tokens and audits TLS private keys.
+func DefaultSecretTypePolicy() *SecretTypePolicy {
+	return &SecretTypePolicy{
+		Deny:         []string{"kubernetes.io/service-account-token"},
+		RequireOptIn: []string{"bootstrap.kubernetes.io/token"},
+		Audit:        []string{"kubernetes.io/tls"},
+	}
+}
+
+// Denies reports whether Secrets of secretType are never mirrored.
+func (p *SecretTypePolicy) Denies(secretType string) bool {
+	return slices.Contains(p.Deny, secretType)
+}
+
+// RequiresOptIn reports whether Secrets of secretType are only mirrored with allow-sensitive.
+func (p *SecretTypePolicy) RequiresOptIn(secretType string) bool {
+	return slices.Contains(p.RequireOptIn, secretType)
+}
+
+// Audits reports whether mirror writes of Secrets of secretType are audited.
+func (p *SecretTypePolicy) Audits(secretType string) bool {
+	return slices.Contains(p.Audit, secretType)
+}
+
+// validate rejects empty types and types both denied and allowed on opt-in.
+func (p *SecretTypePolicy) validate() error {
+	for _, list := range [][]string{p.Deny, p.RequireOptIn, p.Audit} {
+		if slices.Contains(list, "") {
+			return fmt.Errorf("secret types must not be empty")
+		}
+	}
+	for _, secretType := range p.RequireOptIn {
+		if p.Denies(secretType) {
+			return fmt.Errorf("secret type %q is both denied and allowed on opt-in", secretType)
+		}
+	}
+	return nil
+}
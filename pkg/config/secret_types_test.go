@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultSecretTypePolicy(t *testing.T) {
+	policy := (&Config{}).SecretTypeRules()
+	assert.True(t, policy.Denies("kubernetes.io/service-account-token"))
+	assert.True(t, policy.RequiresOptIn("bootstrap.kubernetes.io/token"))
+	assert.True(t, policy.Audits("kubernetes.io/tls"))
+
+	assert.False(t, policy.Denies("Opaque"))
+	assert.False(t, policy.RequiresOptIn("Opaque"))
+	assert.False(t, policy.Audits("Opaque"))
+}
+
+func TestFile_SecretTypes(t *testing.T) {
+	f, err := ParseFile([]byte(`
+secretTypes:
+  requireOptIn: [bootstrap.kubernetes.io/token, example.com/signing-key]
+  audit: []
+`))
+	require.NoError(t, err)
+	tunables, err := f.Apply(Tunables{})
+	require.NoError(t, err)
+
+	cfg := &Config{}
+	cfg.ApplyTunables(tunables)
+	policy := cfg.SecretTypeRules()
+	assert.True(t, policy.Denies("kubernetes.io/service-account-token"), "unset lists keep the default")
+	assert.True(t, policy.RequiresOptIn("example.com/signing-key"))
+	assert.False(t, policy.Audits("kubernetes.io/tls"), "an empty list turns auditing off")
+
+	// Removing the setting on a reload restores the defaults
+	f, err = ParseFile(nil)
+	require.NoError(t, err)
+	tunables, err = f.Apply(Tunables{})
+	require.NoError(t, err)
+	cfg.ApplyTunables(tunables)
+	assert.True(t, cfg.SecretTypeRules().Audits("kubernetes.io/tls"))
+}
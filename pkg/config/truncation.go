@@ -0,0 +1,34 @@
+package config
+
+import "fmt"
+
+// TruncationPolicy decides which target namespaces a source keeps when it
+// resolves to more than its max-targets limit.
+type TruncationPolicy string
+
+const (
+	// TruncateAlphabetical keeps the first namespaces in alphabetical order
+	TruncateAlphabetical TruncationPolicy = "alphabetical"
+	// TruncateOldestFirst keeps the namespaces created first, so a new namespace
+	// never displaces the mirror of an existing one
+	TruncateOldestFirst TruncationPolicy = "oldest-first"
+	// TruncateFail syncs none of the source's mirrors and fails its reconcile,
+	// leaving existing mirrors as they are
+	TruncateFail TruncationPolicy = "fail"
+)
+
+// DefaultTruncationPolicy is used when neither the flag nor the config file sets one.
+const DefaultTruncationPolicy = TruncateAlphabetical
+
+// ParseTruncationPolicy validates a truncation policy; empty means the default.
+func ParseTruncationPolicy(value string) (TruncationPolicy, error) {
+	switch policy := TruncationPolicy(value); policy {
+	case "":
+		return DefaultTruncationPolicy, nil
+	case TruncateAlphabetical, TruncateOldestFirst, TruncateFail:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown truncation policy %q (valid: %s, %s, %s)",
+			value, TruncateAlphabetical, TruncateOldestFirst, TruncateFail)
+	}
+}
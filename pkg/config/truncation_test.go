@@ -0,0 +1,41 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTruncationPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    TruncationPolicy
+		wantErr bool
+	}{
+		{value: "", want: TruncateAlphabetical},
+		{value: "alphabetical", want: TruncateAlphabetical},
+		{value: "oldest-first", want: TruncateOldestFirst},
+		{value: "fail", want: TruncateFail},
+		{value: "random", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseTruncationPolicy(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfig_TargetTruncation(t *testing.T) {
+	cfg := &Config{}
+	assert.Equal(t, TruncateAlphabetical, cfg.TargetTruncation())
+
+	cfg.ApplyTunables(Tunables{TruncationPolicy: TruncateFail})
+	assert.Equal(t, TruncateFail, cfg.TargetTruncation())
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+)
+
+// FileWatcher reloads a configuration file when it changes on disk and hands
+// the new settings to OnChange. It watches the file's directory, so the symlink
+// swap Kubernetes uses to update mounted ConfigMaps is seen as well. A file that
+// fails to parse is logged and skipped, keeping the previous settings.
+type FileWatcher struct {
+	// Log receives reload results
+	Log logr.Logger
+	// OnChange is called with every successfully parsed change
+	OnChange func(*File)
+	// Path is the configuration file to watch
+	Path string
+
+	// last is the content most recently read, to ignore events that change nothing
+	last []byte
+}
+
+// Load reads and parses the file for startup. Start only reports changes made
+// after Load.
+func (w *FileWatcher) Load() (*File, error) {
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	f, err := ParseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	w.last = data
+	return f, nil
+}
+
+// Start watches the file until ctx is done. It implements manager.Runnable.
+func (w *FileWatcher) Start(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config file watcher: %w", err)
+	}
+	defer func() { _ = watcher.Close() }()
+
+	if err := watcher.Add(filepath.Dir(w.Path)); err != nil {
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+	w.Log.Info("watching config file for changes", "path", w.Path)
+
+	// Catch changes made between Load and the watch being set up
+	w.reload()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			w.reload()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			w.Log.Error(err, "config file watch error", "path", w.Path)
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable: every replica
+// applies its own configuration.
+func (w *FileWatcher) NeedLeaderElection() bool {
+	return false
+}
+
+// reload re-reads the file and calls OnChange if its content changed.
+func (w *FileWatcher) reload() {
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		// Mid-swap or deleted; the next event retries
+		w.Log.V(1).Info("config file not readable, keeping current settings", "path", w.Path, "error", err.Error())
+		return
+	}
+	if bytes.Equal(data, w.last) {
+		return
+	}
+	w.last = data
+
+	f, err := ParseFile(data)
+	if err != nil {
+		w.Log.Error(err, "invalid config file, keeping current settings", "path", w.Path)
+		return
+	}
+	w.Log.Info("config file changed, reloading", "path", w.Path)
+	if w.OnChange != nil {
+		w.OnChange(f)
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileWatcher(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("maxTargets: 10\n"), 0o600))
+
+	changes := make(chan *File, 10)
+	w := &FileWatcher{Path: path, Log: logr.Discard(), OnChange: func(f *File) { changes <- f }}
+	f, err := w.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 10, *f.MaxTargets)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Start(ctx) }()
+	defer func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}()
+
+	// An invalid file is skipped, so the first change reported is the valid one.
+	// Kubernetes replaces mounted ConfigMap files by renaming
+	require.NoError(t, os.WriteFile(path, []byte("maxTargets: -1\n"), 0o600))
+	tmp := filepath.Join(dir, "config.yaml.tmp")
+	require.NoError(t, os.WriteFile(tmp, []byte("maxTargets: 20\n"), 0o600))
+	require.NoError(t, os.Rename(tmp, path))
+
+	select {
+	case f := <-changes:
+		assert.Equal(t, 20, *f.MaxTargets)
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not reported")
+	}
+}
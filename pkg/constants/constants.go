@@ -52,6 +52,27 @@ const (
 	// Value: "true"
 	LabelAllowMirrors = Domain + "/allow-mirrors"
 
+	// LabelTenant groups namespaces into a tenant (or project) that sources can
+	// target as a whole with "tenant:<name>" in target-namespaces.
+	// Value: the tenant name, a valid label value
+	LabelTenant = Domain + "/tenant"
+
+	// LabelClusterSecret marks a Secret holding the kubeconfig of a remote cluster
+	// that mirrors can be pushed to (see AnnotationTargetClusters).
+	// Value: "true"
+	LabelClusterSecret = Domain + "/cluster"
+
+	// LabelSourceUID is set on mirrors in remote clusters to the UID of their source,
+	// so a source's remote mirrors can be found with a server-side label selector.
+	// A kubemirror running in the remote cluster leaves such mirrors alone, since
+	// their source lives in another cluster.
+	LabelSourceUID = Domain + "/source-uid"
+
+	// LabelTransformRules marks a ConfigMap as a transform rule library that sources
+	// can reference with AnnotationTransformRef.
+	// Value: "true"
+	LabelTransformRules = Domain + "/transform-rules"
+
 	// ====================
 	// ANNOTATIONS
 	// ====================
@@ -67,10 +88,16 @@ const (
 	AnnotationSync = Domain + "/sync"
 
 	// AnnotationTargetNamespaces specifies target namespaces.
-	// Values: "ns1,ns2", "app-*,prod-*" (glob), "all", or "all-labeled"
+	// Values: "ns1,ns2", "app-*,prod-*" (glob), "all", "all-labeled" or "descendants"
 	// Annotation because: values can be complex patterns exceeding label limits.
 	AnnotationTargetNamespaces = Domain + "/target-namespaces"
 
+	// AnnotationTargetNamespaceSelector selects target namespaces by label, in
+	// addition to target-namespaces.
+	// Values: a label selector, e.g. "team=payments,env in (prod,staging)"
+	// Annotation because: selector syntax is not a valid label value.
+	AnnotationTargetNamespaceSelector = Domain + "/target-namespace-selector"
+
 	// AnnotationExclude explicitly excludes a resource from mirroring when "true".
 	// Annotation because: used for configuration, not filtering.
 	AnnotationExclude = Domain + "/exclude"
@@ -84,15 +111,151 @@ const (
 	// Annotation because: configuration flag, not used for filtering.
 	AnnotationRecreateOnImmutableChange = Domain + "/recreate-on-immutable-change"
 
-	// AnnotationPaused on controller deployment pauses all reconciliation when "true".
+	// AnnotationForceApply lets kubemirror take ownership of mirror fields owned by other
+	// field managers when "true". Without it, server-side apply conflicts are reported
+	// via Events and the mirror is left untouched.
+	// Annotation because: configuration flag, not used for filtering.
+	AnnotationForceApply = Domain + "/force-apply"
+
+	// AnnotationSyncWhen holds a condition the source must satisfy before it is mirrored,
+	// e.g. `status.conditions[type=="Ready"].status == "True"`. While it does not hold,
+	// existing mirrors keep their last synced content.
+	// Annotation because: expression value that exceeds label limits.
+	AnnotationSyncWhen = Domain + "/sync-when"
+
+	// AnnotationMinSyncInterval limits how often a source fans out to its mirrors
+	// (Go duration, e.g. "1m"). Changes within the interval are coalesced and the
+	// latest content is synced once it elapses.
+	// Annotation because: duration configuration value.
+	AnnotationMinSyncInterval = Domain + "/min-sync-interval"
+
+	// AnnotationMirrorTTL limits how long mirrors of a source live in a target
+	// namespace (Go duration, e.g. "24h"), counted from the namespace's creation or
+	// its ttl-refreshed-at annotation. Expired mirrors are deleted and not recreated.
+	// Annotation because: duration configuration value.
+	AnnotationMirrorTTL = Domain + "/mirror-ttl"
+
+	// AnnotationTTLRefreshedAt on a target namespace restarts the mirror-ttl of the
+	// mirrors in it from the given time (RFC3339).
+	// Annotation because: timestamp value.
+	AnnotationTTLRefreshedAt = Domain + "/ttl-refreshed-at"
+
+	// AnnotationMaxMirrors on a target namespace limits how many mirrors, of all
+	// resource types, kubemirror keeps in it (e.g. "50"). Mirrors that would exceed
+	// it are not created; existing ones are still updated.
+	// Annotation because: numeric configuration value.
+	AnnotationMaxMirrors = Domain + "/max-mirrors"
+
+	// AnnotationSyncWave orders the mirrors of sources in the same namespace (integer,
+	// e.g. "1"). A source is mirrored into a target namespace only once the sources of
+	// lower waves targeting it have their mirrors there. Sources without it are unordered.
+	// Annotation because: ordering configuration value.
+	AnnotationSyncWave = Domain + "/sync-wave"
+
+	// AnnotationCreateMissingNamespaces makes kubemirror create the target namespaces
+	// listed by name in target-namespaces that do not exist yet, when "true" and the
+	// controller runs with --allow-namespace-creation. Created namespaces are kept
+	// when the source is deleted.
+	// Annotation because: configuration flag, not used for filtering.
+	AnnotationCreateMissingNamespaces = Domain + "/create-missing-namespaces"
+
+	// AnnotationMirrorImagePullSecrets on a ServiceAccount source carries its
+	// imagePullSecrets over to mirrors when "true". The referenced Secrets must exist
+	// in each target namespace (e.g. mirrored alongside the ServiceAccount).
+	// Annotation because: configuration flag, not used for filtering.
+	AnnotationMirrorImagePullSecrets = Domain + "/mirror-image-pull-secrets"
+
+	// AnnotationAllowSensitive set to "true" on a Secret source allows mirroring it
+	// when its type requires an explicit opt-in (e.g. bootstrap.kubernetes.io/token),
+	// see the secretTypes setting of the configuration file.
+	// Annotation because: configuration flag, not used for filtering.
+	AnnotationAllowSensitive = Domain + "/allow-sensitive"
+
+	// AnnotationIncludeKeys on a Secret or ConfigMap source limits mirrors to the data
+	// keys it lists (comma-separated, "*" and "?" globs allowed, e.g. "tls.crt,ca.crt").
+	// Keys left out are never written to mirrors and do not trigger syncs.
+	// Annotation because: list value that exceeds label limits.
+	AnnotationIncludeKeys = Domain + "/include-keys"
+
+	// AnnotationExcludeKeys on a Secret or ConfigMap source keeps the data keys it lists
+	// (comma-separated, globs allowed, e.g. "tls.key") out of mirrors. It applies after
+	// AnnotationIncludeKeys.
+	// Annotation because: list value that exceeds label limits.
+	AnnotationExcludeKeys = Domain + "/exclude-keys"
+
+	// AnnotationPropagateLabels on a source limits the labels copied to mirrors to
+	// the keys it lists (comma-separated, globs allowed, "*" for all, e.g.
+	// "app,team,app.kubernetes.io/*"). Without it every label is copied to
+	// unstructured mirrors; kubemirror's own labels never are.
+	// Annotation because: list value that exceeds label limits.
+	AnnotationPropagateLabels = Domain + "/propagate-labels"
+
+	// AnnotationPropagateAnnotations on a source limits the annotations copied to
+	// mirrors to the keys it lists, like AnnotationPropagateLabels (e.g. "prometheus.io/*").
+	// Annotation because: list value that exceeds label limits.
+	AnnotationPropagateAnnotations = Domain + "/propagate-annotations"
+
+	// AnnotationSealedMirror set to "true" on a Secret source writes its mirrors as
+	// Bitnami SealedSecrets, sealed for each target namespace with the certificate
+	// given by --sealed-secrets-cert, so the sealed-secrets controller unseals them.
+	// Annotation because: configuration flag, not used for filtering.
+	AnnotationSealedMirror = Domain + "/sealed-mirror"
+
+	// AnnotationAttachToServiceAccounts on a docker-registry Secret source appends each
+	// mirror to the imagePullSecrets of ServiceAccounts in its target namespace: "true"
+	// for the default ServiceAccount, or a comma-separated list of ServiceAccount names.
+	// Mirrors are detached again when they are removed.
+	// Annotation because: list value, not used for filtering.
+	AnnotationAttachToServiceAccounts = Domain + "/attach-to-service-accounts"
+
+	// AnnotationForceSync on a source forces every mirror to be rewritten whenever its
+	// value changes (e.g. set to the current timestamp), even if the source content did not.
+	// Annotation because: operational trigger, value is arbitrary.
+	AnnotationForceSync = Domain + "/force-sync"
+
+	// AnnotationTargetClusters lists the remote clusters (comma-separated names, or
+	// "all") a source is also mirrored to. Target namespaces in each cluster are
+	// resolved from the same target-namespaces patterns.
+	// Annotation because: list of cluster names, not used for filtering.
+	AnnotationTargetClusters = Domain + "/target-clusters"
+
+	// AnnotationConflictPolicy decides what happens when a target namespace already
+	// holds an unmanaged object of the mirror's name: "skip", "overwrite" (adopt it),
+	// "fail" or "adopt-if-identical". Overrides the --conflict-policy flag.
+	// Annotation because: configuration value, not used for filtering.
+	AnnotationConflictPolicy = Domain + "/conflict-policy"
+
+	// AnnotationProtectDrift set to "false" on a source stops kubemirror from
+	// restoring mirrors edited in their target namespace; drift is only reported.
+	// Annotation because: configuration flag, not used for filtering.
+	AnnotationProtectDrift = Domain + "/protect-drift"
+
+	// AnnotationDryRun set to "true" on a source makes kubemirror report the mirrors
+	// it would create, update and delete for it without writing them, like --dry-run.
+	// Annotation because: configuration flag, not used for filtering.
+	AnnotationDryRun = Domain + "/dry-run"
+
+	// AnnotationTargetName names mirrors differently from their source.
+	// Annotation because: configuration value, not used for filtering.
+	AnnotationTargetName = Domain + "/target-name"
+
+	// AnnotationTargetNamePrefix and AnnotationTargetNameSuffix are prepended and
+	// appended to the mirror name (the source name, or target-name when set).
+	// Annotations because: configuration values, not used for filtering.
+	AnnotationTargetNamePrefix = Domain + "/target-name-prefix"
+	AnnotationTargetNameSuffix = Domain + "/target-name-suffix"
+
+	// AnnotationPaused on a source pauses its mirroring when "true": its mirrors are
+	// kept as they are, without updates or cleanups, until the annotation is removed.
 	// Annotation because: operational control, not used for filtering.
 	AnnotationPaused = Domain + "/paused"
 
 	// --- Source Tracking Annotations ---
 	// These are set by kubemirror on source resources for change detection.
 
-	// AnnotationContentHash stores the SHA256 hash of the source resource content.
-	// Used for efficient change detection without deep comparison.
+	// AnnotationContentHash stores the SHA256 hash of the source resource content its
+	// mirrors were last synced at. Updates that leave the content and kubemirror's own
+	// labels and annotations unchanged are then dropped before they are reconciled.
 	// Annotation because: computed value (64 chars), may exceed label limits.
 	AnnotationContentHash = Domain + "/content-hash"
 
@@ -117,22 +280,56 @@ const (
 	// Compared against source's current hash to detect changes.
 	AnnotationSourceContentHash = Domain + "/source-content-hash"
 
+	// AnnotationMirrorContentHash stores the hash of the transformed mirror content
+	// when last synced. Set only when transformations apply; it detects output
+	// changes the source hash misses (default rules, looked-up values, render values).
+	AnnotationMirrorContentHash = Domain + "/mirror-content-hash"
+
+	// AnnotationTransformDiagnostics records on a transformed mirror which transform
+	// rules applied, were skipped or failed, as a JSON list, so a transform that did
+	// nothing in non-strict mode can be debugged from the mirror itself.
+	AnnotationTransformDiagnostics = Domain + "/transform-diagnostics"
+
+	// AnnotationAttachedServiceAccounts records on a mirror the ServiceAccounts it was
+	// attached to (see AnnotationAttachToServiceAccounts), so it can be detached from
+	// them when it is removed or the source's list changes.
+	AnnotationAttachedServiceAccounts = Domain + "/attached-service-accounts"
+
 	// AnnotationSourceResourceVersion stores the resourceVersion for debugging.
 	AnnotationSourceResourceVersion = Domain + "/source-resource-version"
 
 	// AnnotationLastSyncTime stores the timestamp of the last successful sync (RFC3339).
 	AnnotationLastSyncTime = Domain + "/last-sync-time"
 
+	// AnnotationReconcileID stores the ID of the reconcile that last wrote the mirror,
+	// logged as reconcileID by that reconcile.
+	AnnotationReconcileID = Domain + "/reconcile-id"
+
+	// AnnotationExpiresAt stores when a mirror of a source with mirror-ttl expires (RFC3339).
+	AnnotationExpiresAt = Domain + "/expires-at"
+
+	// AnnotationCreatedFor records on a namespace created for a source with
+	// create-missing-namespaces which source it was created for ("Kind namespace/name").
+	AnnotationCreatedFor = Domain + "/created-for"
+
 	// --- Status/Error Annotations ---
 	// These track sync status and errors for observability.
 
 	// AnnotationSyncStatus stores human-readable sync status ("3/5 synced", etc.).
+	// Only written when the "annotation" status backend is selected.
 	AnnotationSyncStatus = Domain + "/sync-status"
 
+	// AnnotationPropagationMs stores how many milliseconds the last content change of
+	// a source took from being observed to reaching every target namespace.
+	AnnotationPropagationMs = Domain + "/propagation-ms"
+
 	// AnnotationFailedTargets stores comma-separated list of failed target namespaces.
+	// Only written when the "annotation" status backend is selected.
 	AnnotationFailedTargets = Domain + "/failed-targets"
 
 	// AnnotationWebhookError stores webhook rejection error message for debugging.
+	// On mirrors it holds the rejection of their last update; on sources, with the
+	// "annotation" status backend, the rejections of each failed target.
 	AnnotationWebhookError = Domain + "/webhook-error"
 
 	// AnnotationTargetNamespaceUID tracks the UID of the target namespace.
@@ -153,6 +350,28 @@ const (
 	// In strict mode, transformation errors block mirroring instead of being logged.
 	AnnotationTransformStrict = Domain + "/transform-strict"
 
+	// AnnotationTransformRef references transform rule library ConfigMaps, as a
+	// comma-separated list of "namespace/name" (or "name" in the source's namespace).
+	// Their rules are applied before the source's own transform rules.
+	AnnotationTransformRef = Domain + "/transform-ref"
+
+	// AnnotationRenderValues renders every value of a source ConfigMap as a Go template
+	// against the transformation context of each target when "true".
+	AnnotationRenderValues = Domain + "/render-values"
+
+	// AnnotationInjectNamespaceKey names a data key of a Secret or ConfigMap that
+	// receives the target namespace name on each mirror.
+	AnnotationInjectNamespaceKey = Domain + "/inject-namespace-key"
+
+	// AnnotationSecretFormat lists built-in Secret format conversions applied to mirrors,
+	// comma-separated: "dockercfg", "dockerconfigjson", "pem-bundle".
+	AnnotationSecretFormat = Domain + "/secret-format"
+
+	// AnnotationHostTemplate enables the host-rewrite preset for Ingress and Gateway API
+	// HTTPRoute mirrors: hostnames are replaced by this template rendered per target
+	// (e.g. "{{.TargetNamespace}}.example.com") and backend namespaces are localized.
+	AnnotationHostTemplate = Domain + "/host-template"
+
 	// Finalizers
 
 	// FinalizerName is the finalizer added to source resources.
@@ -173,6 +392,32 @@ const (
 
 	// TargetNamespacesAllLabeled mirrors to namespaces with allow-mirrors label.
 	TargetNamespacesAllLabeled = "all-labeled"
+
+	// TargetNamespacesDescendants mirrors to the namespaces below the source namespace
+	// in its Hierarchical Namespace Controller (HNC) hierarchy.
+	TargetNamespacesDescendants = "descendants"
+
+	// TargetNamespacesExclusionPrefix marks a target-namespaces entry that removes
+	// the namespaces it matches from the targets, e.g. "app-*,!app-legacy".
+	TargetNamespacesExclusionPrefix = "!"
+
+	// NamespaceRegexPrefix marks a namespace pattern that is a regular expression
+	// rather than a glob, e.g. "re:^team-(a|b)-prod$".
+	NamespaceRegexPrefix = "re:"
+
+	// TargetNamespacesTenantPrefix marks a target-namespaces entry naming a tenant,
+	// e.g. "tenant:payments", which mirrors to every namespace labeled LabelTenant
+	// with that name.
+	TargetNamespacesTenantPrefix = "tenant:"
+
+	// HNCTreeDepthLabelSuffix completes the label HNC sets on a namespace for each of
+	// its ancestors, "<ancestor>.tree.hnc.x-k8s.io/depth", valued with the distance
+	// from that ancestor (0 for the namespace itself).
+	HNCTreeDepthLabelSuffix = ".tree.hnc.x-k8s.io/depth"
+
+	// TransformRulesKey is the data key of a rule library ConfigMap holding its
+	// rules, in the AnnotationTransform format.
+	TransformRulesKey = "rules"
 )
 
 // Default System Namespaces (excluded by default)
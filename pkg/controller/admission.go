@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/hash"
+)
+
+// AdmissionRejectedError reports a mirror write refused by admission in the target
+// namespace, e.g. by a validating webhook or policy. Writing the same mirror again
+// cannot succeed, so it is retried once the source changes rather than with backoff.
+type AdmissionRejectedError struct {
+	Namespace string
+	// DryRun is set when the server-side dry run was rejected, not the write itself
+	DryRun bool
+	Err    error
+}
+
+func (e *AdmissionRejectedError) Error() string {
+	by := "admission"
+	if e.DryRun {
+		by = "server-side dry run"
+	}
+	return fmt.Sprintf("mirror in namespace %s rejected by %s: %v", e.Namespace, by, e.Err)
+}
+
+func (e *AdmissionRejectedError) Unwrap() error {
+	return e.Err
+}
+
+// admissionRejection returns the AdmissionRejectedError in err's chain, or nil.
+func admissionRejection(err error) *AdmissionRejectedError {
+	var rejected *AdmissionRejectedError
+	if errors.As(err, &rejected) {
+		return rejected
+	}
+	return nil
+}
+
+// webhookErrorMessage returns err's message bounded for the webhook-error annotation.
+// It is cut on a rune boundary, so the annotation stays valid UTF-8.
+func webhookErrorMessage(err error) string {
+	message := err.Error()
+	if len(message) <= maxWebhookErrorLength {
+		return message
+	}
+	end := maxWebhookErrorLength
+	for end > 0 && !utf8.RuneStart(message[end]) {
+		end--
+	}
+	return message[:end]
+}
+
+// statusAnnotations are written onto sources by kubemirror itself and so do not
+// count as the source changing.
+var statusAnnotations = []string{
+	constants.AnnotationSyncStatus,
+	constants.AnnotationFailedTargets,
+	constants.AnnotationWebhookError,
+	constants.AnnotationContentHash,
+	constants.AnnotationPropagationMs,
+}
+
+// sourceFingerprint identifies what a source asks to be mirrored: its content plus
+// the labels and annotations set on it, apart from the status kubemirror writes.
+func sourceFingerprint(source *unstructured.Unstructured) (string, error) {
+	contentHash, err := hash.ComputeContentHash(source)
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{contentHash}
+	for k, v := range source.GetLabels() {
+		lines = append(lines, "label:"+k+"="+v)
+	}
+	for k, v := range source.GetAnnotations() {
+		if !slices.Contains(statusAnnotations, k) {
+			lines = append(lines, "annotation:"+k+"="+v)
+		}
+	}
+	slices.Sort(lines[1:])
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// admissionHolds remembers the sources whose circuit was opened because admission
+// rejected their mirrors, with the fingerprint they were rejected at, so the
+// circuit is closed again as soon as the source changes.
+type admissionHolds struct {
+	fingerprints map[types.NamespacedName]string
+	mu           sync.Mutex
+}
+
+// hold records that the source was rejected at fingerprint.
+func (h *admissionHolds) hold(key types.NamespacedName, fingerprint string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.fingerprints == nil {
+		h.fingerprints = make(map[types.NamespacedName]string)
+	}
+	h.fingerprints[key] = fingerprint
+}
+
+// release reports whether the source is held at a fingerprint other than the
+// given one, dropping the hold if so. An empty fingerprint always releases.
+func (h *admissionHolds) release(key types.NamespacedName, fingerprint string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	held, ok := h.fingerprints[key]
+	if !ok || (held == fingerprint && fingerprint != "") {
+		return false
+	}
+	delete(h.fingerprints, key)
+	return true
+}
+
+// forget drops the source's hold, e.g. once it is deleted.
+func (h *admissionHolds) forget(key types.NamespacedName) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.fingerprints, key)
+}
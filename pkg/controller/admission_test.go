@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/circuitbreaker"
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/status"
+)
+
+func TestAdmissionRejectedError(t *testing.T) {
+	rejected := &AdmissionRejectedError{Namespace: "team-a", Err: webhookDenied()}
+	assert.Contains(t, rejected.Error(), "mirror in namespace team-a rejected by admission")
+	assert.True(t, apierrors.IsForbidden(rejected))
+
+	dryRun := &AdmissionRejectedError{Namespace: "team-a", DryRun: true, Err: webhookDenied()}
+	assert.Contains(t, dryRun.Error(), "rejected by server-side dry run")
+
+	assert.Same(t, rejected, admissionRejection(rejected))
+	assert.Nil(t, admissionRejection(webhookDenied()))
+}
+
+func TestWebhookErrorMessage(t *testing.T) {
+	short := errors.New("denied")
+	assert.Equal(t, "denied", webhookErrorMessage(short))
+
+	// "é" is two bytes; the limit falls between them
+	long := errors.New(strings.Repeat("a", maxWebhookErrorLength-1) + "é and more")
+	message := webhookErrorMessage(long)
+	assert.True(t, utf8.ValidString(message), "cut on a rune boundary")
+	assert.Equal(t, strings.Repeat("a", maxWebhookErrorLength-1), message)
+
+	exact := errors.New(strings.Repeat("a", maxWebhookErrorLength-2) + "é")
+	assert.Equal(t, exact.Error(), webhookErrorMessage(exact), "a rune ending at the limit is kept")
+}
+
+func TestSourceFingerprint(t *testing.T) {
+	source := makeWaveSource("app-config", "", "team-a")
+	fingerprint, err := sourceFingerprint(source)
+	require.NoError(t, err)
+
+	// Status written by kubemirror is not a change
+	withStatus := source.DeepCopy()
+	withStatus.SetAnnotations(map[string]string{
+		constants.AnnotationSync:             "true",
+		constants.AnnotationTargetNamespaces: "team-a",
+		constants.AnnotationSyncStatus:       "reconciled:0,errors:1",
+		constants.AnnotationFailedTargets:    "team-a",
+	})
+	got, err := sourceFingerprint(withStatus)
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint, got)
+
+	changedData := source.DeepCopy()
+	changedData.Object["data"] = map[string]interface{}{"key": "b3RoZXI="}
+	got, err = sourceFingerprint(changedData)
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, got)
+
+	changedTargets := source.DeepCopy()
+	changedTargets.SetAnnotations(map[string]string{constants.AnnotationSync: "true", constants.AnnotationTargetNamespaces: "team-b"})
+	got, err = sourceFingerprint(changedTargets)
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, got)
+}
+
+func TestAdmissionHolds(t *testing.T) {
+	var holds admissionHolds
+	key := types.NamespacedName{Namespace: "default", Name: "app-config"}
+
+	assert.False(t, holds.release(key, "a"), "sources not held are not released")
+	holds.hold(key, "a")
+	assert.False(t, holds.release(key, "a"), "an unchanged source stays held")
+	assert.True(t, holds.release(key, "b"))
+	assert.False(t, holds.release(key, "c"), "the hold is dropped once released")
+
+	holds.hold(key, "a")
+	holds.forget(key)
+	assert.False(t, holds.release(key, "b"))
+}
+
+func TestSourceReconciler_Reconcile_AdmissionRejectionHoldsSource(t *testing.T) {
+	ctx := context.Background()
+	source := makeWaveSource("app-config", "", "team-a")
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	reject := true
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(source,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+				if reject {
+					return webhookDenied()
+				}
+				return c.Apply(ctx, obj, opts...)
+			},
+		}).Build()
+
+	recorder := events.NewFakeRecorder(10)
+	breaker := circuitbreaker.NewWithDefaults()
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		CircuitBreaker:  breaker,
+		Recorder:        recorder,
+		StatusReporter:  &status.AnnotationReporter{Client: c},
+		GVK:             secretGVK,
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)}
+	stored := makeUnstructuredSecret("", "", nil, nil)
+
+	// The rejection is recorded on the source and the source is held, not retried hot
+	result, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.Equal(t, circuitbreaker.StateOpen, breaker.GetState("default", "app-config", "Secret"))
+	assert.Contains(t, <-recorder.Events, ReasonAdmissionRejected)
+
+	require.NoError(t, c.Get(ctx, req.NamespacedName, stored))
+	assert.Equal(t, "team-a", stored.GetAnnotations()[constants.AnnotationFailedTargets])
+	assert.Contains(t, stored.GetAnnotations()[constants.AnnotationWebhookError], "team-a: ")
+	assert.Contains(t, stored.GetAnnotations()[constants.AnnotationWebhookError], "forbidden")
+
+	// Status written by kubemirror does not release the hold
+	reject = false
+	result, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.NotZero(t, result.RequeueAfter, "the open circuit defers the source")
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-config"}, makeUnstructuredSecret("", "", nil, nil))))
+
+	// Changing the source retries it at once
+	require.NoError(t, c.Get(ctx, req.NamespacedName, stored))
+	stored.Object["data"] = map[string]interface{}{"key": "Zml4ZWQ="}
+	require.NoError(t, c.Update(ctx, stored))
+	result, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.Equal(t, circuitbreaker.StateClosed, breaker.GetState("default", "app-config", "Secret"))
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-config"}, makeUnstructuredSecret("", "", nil, nil)))
+
+	require.NoError(t, c.Get(ctx, req.NamespacedName, stored))
+	assert.NotContains(t, stored.GetAnnotations(), constants.AnnotationFailedTargets)
+	assert.NotContains(t, stored.GetAnnotations(), constants.AnnotationWebhookError)
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// ReasonFieldManagerConflict is the Event reason used when a mirror apply
+// conflicts with fields owned by another field manager.
+const ReasonFieldManagerConflict = "FieldManagerConflict"
+
+// FieldConflict describes a single field of a mirror owned by another field manager.
+type FieldConflict struct {
+	// Field is the path of the conflicting field (e.g. ".data.password")
+	Field string
+	// Manager is the name of the field manager that owns the field
+	Manager string
+	// Message is the API server's description of the conflict
+	Message string
+}
+
+// FieldManagerConflictError is returned when a server-side apply of a mirror
+// conflicts with fields owned by another field manager (e.g. another operator
+// editing the same ConfigMap). kubemirror does not force ownership of such
+// fields unless the source opts in via the force-apply annotation.
+type FieldManagerConflictError struct {
+	Namespace string
+	Name      string
+	Conflicts []FieldConflict
+}
+
+// Error implements the error interface.
+func (e *FieldManagerConflictError) Error() string {
+	fields := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		fields = append(fields, c.Message)
+	}
+	if len(fields) == 0 {
+		return fmt.Sprintf("mirror %s/%s has fields owned by another field manager", e.Namespace, e.Name)
+	}
+	return fmt.Sprintf("mirror %s/%s has fields owned by another field manager: %s",
+		e.Namespace, e.Name, strings.Join(fields, "; "))
+}
+
+// IsFieldManagerConflict reports whether err is (or wraps) a FieldManagerConflictError.
+func IsFieldManagerConflict(err error) bool {
+	var conflictErr *FieldManagerConflictError
+	return errors.As(err, &conflictErr)
+}
+
+// shouldForceApply checks if the source opted in to taking over fields owned by other managers.
+func shouldForceApply(sourceObj metav1.Object) bool {
+	annotations := sourceObj.GetAnnotations()
+	if annotations == nil {
+		return false
+	}
+	return annotations[constants.AnnotationForceApply] == "true"
+}
+
+// applyMirror writes the desired mirror state using server-side apply with kubemirror
+// as the field manager. Conflicts with other field managers are converted into a
+// FieldManagerConflictError instead of being overwritten, unless force is set.
+// Extra options such as client.DryRunAll are passed to every apply request.
+func applyMirror(ctx context.Context, c client.Client, mirror *unstructured.Unstructured, force bool, extra ...client.ApplyOption) error {
+	applyObj := mirror.DeepCopy()
+	// Apply configurations must not carry server-populated metadata
+	applyObj.SetResourceVersion("")
+	applyObj.SetManagedFields(nil)
+	applyObj.SetUID("")
+	applyObj.SetCreationTimestamp(metav1.Time{})
+	stampReconcileID(applyObj, reconcileID(ctx))
+
+	opts := append([]client.ApplyOption{client.FieldOwner(constants.ControllerName)}, extra...)
+	if force {
+		opts = append(opts, client.ForceOwnership)
+	}
+
+	err := c.Apply(ctx, client.ApplyConfigurationFromUnstructured(applyObj), opts...)
+	if err == nil || !apierrors.IsConflict(err) {
+		return err
+	}
+
+	conflicts := extractFieldConflicts(err)
+	if len(conflicts) == 0 {
+		return err
+	}
+
+	// Mirrors written before the switch to server-side apply are owned by our own
+	// Update-operation manager entry. Taking those fields over is always safe.
+	if !force && ownedByUsOnly(conflicts) {
+		opts = append(opts, client.ForceOwnership)
+		return c.Apply(ctx, client.ApplyConfigurationFromUnstructured(applyObj), opts...)
+	}
+
+	return &FieldManagerConflictError{
+		Namespace: mirror.GetNamespace(),
+		Name:      mirror.GetName(),
+		Conflicts: conflicts,
+	}
+}
+
+// beforeFirstApplyManager is the placeholder manager the API server assigns to
+// fields of objects that were never server-side applied before.
+const beforeFirstApplyManager = "before-first-apply"
+
+// ownedByUsOnly reports whether every conflicting field is owned by a kubemirror manager entry.
+func ownedByUsOnly(conflicts []FieldConflict) bool {
+	for _, c := range conflicts {
+		if c.Manager != constants.ControllerName && c.Manager != beforeFirstApplyManager {
+			return false
+		}
+	}
+	return true
+}
+
+// extractFieldConflicts pulls field manager conflict causes out of an API error.
+func extractFieldConflicts(err error) []FieldConflict {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return nil
+	}
+
+	details := status.Status().Details
+	if details == nil {
+		return nil
+	}
+
+	var conflicts []FieldConflict
+	for _, cause := range details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		conflicts = append(conflicts, FieldConflict{
+			Field:   cause.Field,
+			Manager: conflictManager(cause.Message),
+			Message: cause.Message,
+		})
+	}
+
+	return conflicts
+}
+
+// conflictManager extracts the manager name from a conflict message.
+// The API server formats these as: conflict with "manager-name" using v1
+func conflictManager(message string) string {
+	start := strings.Index(message, `"`)
+	if start < 0 {
+		return ""
+	}
+	end := strings.Index(message[start+1:], `"`)
+	if end < 0 {
+		return ""
+	}
+	return message[start+1 : start+1+end]
+}
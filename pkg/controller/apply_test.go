@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func newApplyConflict(managers ...string) error {
+	causes := make([]metav1.StatusCause, 0, len(managers))
+	for _, m := range managers {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Field:   ".data.key",
+			Message: `conflict with "` + m + `" using v1`,
+		})
+	}
+	return apierrors.NewApplyConflict(causes, "Apply failed with conflicts")
+}
+
+func TestApplyMirror(t *testing.T) {
+	tests := []struct {
+		name          string
+		applyErrors   []error
+		force         bool
+		wantConflict  bool
+		wantErr       bool
+		wantForced    []bool
+		wantApplyCall int
+	}{
+		{
+			name:          "successful apply",
+			applyErrors:   []error{nil},
+			wantApplyCall: 1,
+			wantForced:    []bool{false},
+		},
+		{
+			name:          "conflict with another manager is surfaced",
+			applyErrors:   []error{newApplyConflict("other-operator")},
+			wantConflict:  true,
+			wantErr:       true,
+			wantApplyCall: 1,
+			wantForced:    []bool{false},
+		},
+		{
+			name:          "force option takes ownership",
+			applyErrors:   []error{nil},
+			force:         true,
+			wantApplyCall: 1,
+			wantForced:    []bool{true},
+		},
+		{
+			name:          "conflict with legacy kubemirror update manager is force-retried",
+			applyErrors:   []error{newApplyConflict(constants.ControllerName), nil},
+			wantApplyCall: 2,
+			wantForced:    []bool{false, true},
+		},
+		{
+			name:          "conflict with before-first-apply placeholder is force-retried",
+			applyErrors:   []error{newApplyConflict("before-first-apply"), nil},
+			wantApplyCall: 2,
+			wantForced:    []bool{false, true},
+		},
+		{
+			name:          "mixed conflicts are surfaced",
+			applyErrors:   []error{newApplyConflict(constants.ControllerName, "helm")},
+			wantConflict:  true,
+			wantErr:       true,
+			wantApplyCall: 1,
+			wantForced:    []bool{false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var forced []bool
+			calls := 0
+			c := fake.NewClientBuilder().
+				WithScheme(runtime.NewScheme()).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+						applyOpts := &client.ApplyOptions{}
+						applyOpts.ApplyOptions(opts)
+						forced = append(forced, applyOpts.Force != nil && *applyOpts.Force)
+						assert.Equal(t, constants.ControllerName, applyOpts.FieldManager)
+						err := tt.applyErrors[calls]
+						calls++
+						return err
+					},
+				}).
+				Build()
+
+			mirror := makeUnstructuredMirror("test-secret", "target-ns", "default", "test-secret")
+			mirror.SetResourceVersion("42")
+
+			err := applyMirror(context.Background(), c, mirror, tt.force)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantConflict, IsFieldManagerConflict(err))
+			assert.Equal(t, tt.wantApplyCall, calls)
+			assert.Equal(t, tt.wantForced, forced)
+			// The caller's object must not be mutated
+			assert.Equal(t, "42", mirror.GetResourceVersion())
+		})
+	}
+}
+
+func TestFieldManagerConflictError(t *testing.T) {
+	err := error(&FieldManagerConflictError{
+		Namespace: "target-ns",
+		Name:      "app-config",
+		Conflicts: []FieldConflict{{Field: ".data.key", Manager: "helm", Message: `conflict with "helm" using v1`}},
+	})
+	assert.Contains(t, err.Error(), "target-ns/app-config")
+	assert.Contains(t, err.Error(), `conflict with "helm"`)
+	assert.False(t, IsFieldManagerConflict(assert.AnError))
+}
+
+func TestConflictManager(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{message: `conflict with "helm" using v1`, want: "helm"},
+		{message: `conflict with "kube-controller-manager" with subresource "scale" using apps/v1`, want: "kube-controller-manager"},
+		{message: "conflict with unknown", want: ""},
+		{message: `conflict with "unterminated`, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			assert.Equal(t, tt.want, conflictManager(tt.message))
+		})
+	}
+}
+
+func TestShouldForceApply(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	assert.False(t, shouldForceApply(obj))
+
+	obj.SetAnnotations(map[string]string{constants.AnnotationForceApply: "true"})
+	assert.True(t, shouldForceApply(obj))
+
+	obj.SetAnnotations(map[string]string{constants.AnnotationForceApply: "false"})
+	assert.False(t, shouldForceApply(obj))
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/pullsecret"
+	"github.com/lukaszraczylo/kubemirror/pkg/sealing"
+)
+
+// ReasonServiceAccountAttached is the Event reason used when a registry Secret
+// mirror is added to the imagePullSecrets of a ServiceAccount.
+const ReasonServiceAccountAttached = "ServiceAccountAttached"
+
+// syncAttachments attaches the mirror named mirrorName in targetNs to the
+// ServiceAccounts the source lists, and detaches it from the previously
+// attached ones (recorded on the mirror) the source no longer lists.
+func (r *SourceReconciler) syncAttachments(ctx context.Context, source *unstructured.Unstructured, previous []string, targetNs, mirrorName string) error {
+	if r.dryRun(source) {
+		return nil
+	}
+	logger := log.FromContext(ctx).WithValues("targetNamespace", targetNs)
+
+	serviceAccounts := pullsecret.ServiceAccounts(source)
+	for _, serviceAccount := range previous {
+		if slices.Contains(serviceAccounts, serviceAccount) {
+			continue
+		}
+		if err := pullsecret.Detach(ctx, r.Client, targetNs, serviceAccount, mirrorName); err != nil {
+			return err
+		}
+		logger.V(1).Info("mirror detached from service account", "serviceAccount", serviceAccount)
+	}
+
+	for _, serviceAccount := range serviceAccounts {
+		attached, err := pullsecret.Attach(ctx, r.Client, targetNs, serviceAccount, mirrorName)
+		if err != nil {
+			return err
+		}
+		if attached {
+			logger.V(1).Info("mirror attached to service account", "serviceAccount", serviceAccount)
+			r.recordEvent(source, corev1.EventTypeNormal, ReasonServiceAccountAttached, "Attach",
+				"Attached mirror in namespace %s to service account %s", targetNs, serviceAccount)
+		}
+	}
+	return nil
+}
+
+// detachMirror detaches a registry Secret mirror that is about to be deleted
+// from the ServiceAccounts it was attached to. A bare reference (no annotations)
+// is read first; one that is gone or not managed by kubemirror needs nothing.
+// Sealed mirrors are detached like Secret ones: their unsealed Secret is attached.
+func detachMirror(ctx context.Context, c client.Client, mirror *unstructured.Unstructured) error {
+	if gvk := mirror.GroupVersionKind(); !isCoreSecret(gvk) && gvk != sealing.GVK {
+		return nil
+	}
+	if mirror.GetAnnotations() == nil {
+		if err := c.Get(ctx, client.ObjectKeyFromObject(mirror), mirror); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+	}
+	if !IsManagedByUs(mirror) {
+		return nil
+	}
+	return pullsecret.DetachMirror(ctx, c, mirror)
+}
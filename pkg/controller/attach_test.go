@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func registrySecret(attachTo string) *unstructured.Unstructured {
+	source := makeUnstructuredSecret("registry", "default", map[string]string{constants.LabelEnabled: "true"},
+		map[string]string{constants.AnnotationSync: "true", constants.AnnotationAttachToServiceAccounts: attachTo})
+	source.SetUID("test-uid")
+	source.Object["type"] = string(corev1.SecretTypeDockerConfigJson)
+	return source
+}
+
+func pullSecretNames(t *testing.T, c client.Client, ns, name string) []string {
+	t.Helper()
+	sa := &corev1.ServiceAccount{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: ns, Name: name}, sa))
+	var names []string
+	for _, ref := range sa.ImagePullSecrets {
+		names = append(names, ref.Name)
+	}
+	return names
+}
+
+func TestSourceReconciler_syncMirror_AttachesToServiceAccounts(t *testing.T) {
+	ctx := context.Background()
+	source := registrySecret("default,builder")
+	c := newFakeClient(t, source,
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "default"}},
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "builder"}},
+	)
+	recorder := events.NewFakeRecorder(10)
+	r := &SourceReconciler{Client: c, Config: &config.Config{}, Recorder: recorder, GVK: secretGVK}
+
+	_, err := r.syncMirror(ctx, source, source, "team-a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"registry"}, pullSecretNames(t, c, "team-a", "default"))
+	assert.Equal(t, []string{"registry"}, pullSecretNames(t, c, "team-a", "builder"))
+
+	mirror := &unstructured.Unstructured{}
+	mirror.SetGroupVersionKind(secretGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "registry"}, mirror))
+	assert.Equal(t, "default,builder", mirror.GetAnnotations()[constants.AnnotationAttachedServiceAccounts])
+
+	// Dropping a ServiceAccount from the list detaches the mirror from it
+	source = registrySecret("default")
+	_, err = r.syncMirror(ctx, source, source, "team-a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"registry"}, pullSecretNames(t, c, "team-a", "default"))
+	assert.Empty(t, pullSecretNames(t, c, "team-a", "builder"))
+
+	// Removing the mirror detaches it from the rest
+	deleted, err := r.deleteOwnMirror(ctx, source, "team-a", "registry", "namespace is no longer a target")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	assert.Empty(t, pullSecretNames(t, c, "team-a", "default"))
+}
+
+func TestSourceReconciler_syncMirror_WaitsForServiceAccount(t *testing.T) {
+	ctx := context.Background()
+	source := registrySecret("true")
+	c := newFakeClient(t, source)
+	r := &SourceReconciler{Client: c, Config: &config.Config{}, Recorder: events.NewFakeRecorder(10), GVK: secretGVK}
+
+	// The mirror is written, but the namespace's default ServiceAccount does not exist yet
+	_, err := r.syncMirror(ctx, source, source, "team-a")
+	assert.ErrorContains(t, err, "service account default not found in namespace team-a")
+
+	// Once it exists, the up-to-date mirror is attached on the retry
+	require.NoError(t, c.Create(ctx, &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "default"}}))
+	_, err = r.syncMirror(ctx, source, source, "team-a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"registry"}, pullSecretNames(t, c, "team-a", "default"))
+}
+
+func TestMirrorReconciler_DetachesOrphan(t *testing.T) {
+	ctx := context.Background()
+	orphan := makeUnstructuredMirror("registry", "team-a", "default", "gone")
+	orphan.SetAnnotations(map[string]string{
+		constants.AnnotationSourceNamespace:         "default",
+		constants.AnnotationSourceName:              "gone",
+		constants.AnnotationSourceUID:               "test-uid",
+		constants.AnnotationAttachedServiceAccounts: "default",
+	})
+	c := newFakeClient(t, orphan, &corev1.ServiceAccount{
+		ObjectMeta:       metav1.ObjectMeta{Namespace: "team-a", Name: "default"},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "other"}, {Name: "registry"}},
+	})
+
+	r := &MirrorReconciler{Client: c, Config: &config.Config{}, GVK: secretGVK, Recorder: events.NewFakeRecorder(10)}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(orphan)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other"}, pullSecretNames(t, c, "team-a", "default"))
+}
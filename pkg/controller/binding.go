@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/naming"
+)
+
+// MirrorBindingGVK is the GroupVersionKind of the namespaced objects that own the
+// mirrors of a source in each target namespace with --use-owner-references.
+// Owner references cannot cross namespaces, so the binding stands in for the
+// source: deleting it lets Kubernetes garbage collection remove the mirror.
+var MirrorBindingGVK = schema.GroupVersionKind{
+	Group:   constants.Domain,
+	Version: "v1alpha1",
+	Kind:    "MirrorBinding",
+}
+
+// maxBindingNameLength is the longest object name Kubernetes accepts.
+const maxBindingNameLength = 253
+
+// mirrorBindingName returns the name of the MirrorBinding of source in each of its
+// target namespaces. The kind and namespace keep sources of different types or
+// namespaces apart; names too long for Kubernetes are shortened with a hash.
+func mirrorBindingName(source *unstructured.Unstructured) string {
+	kind := strings.ToLower(source.GetKind())
+	name := kind + "." + source.GetNamespace() + "." + source.GetName()
+	if len(name) <= maxBindingNameLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(source.GetNamespace() + "/" + source.GetName()))
+	return kind + "." + hex.EncodeToString(sum[:])
+}
+
+// buildMirrorBinding builds the MirrorBinding of source in namespace.
+func buildMirrorBinding(source *unstructured.Unstructured, namespace string) *unstructured.Unstructured {
+	binding := &unstructured.Unstructured{}
+	binding.SetGroupVersionKind(MirrorBindingGVK)
+	binding.SetNamespace(namespace)
+	binding.SetName(mirrorBindingName(source))
+	setBindingSource(binding, source)
+	return binding
+}
+
+// setBindingSource points binding at source, labelled with its UID so all bindings
+// of a source are found with one label selector.
+func setBindingSource(binding, source *unstructured.Unstructured) {
+	binding.SetLabels(map[string]string{
+		constants.LabelManagedBy: constants.ControllerName,
+		constants.LabelSourceUID: string(source.GetUID()),
+	})
+	binding.Object["spec"] = map[string]interface{}{
+		"sourceRef": map[string]interface{}{
+			"apiVersion": source.GetAPIVersion(),
+			"kind":       source.GetKind(),
+			"namespace":  source.GetNamespace(),
+			"name":       source.GetName(),
+			"uid":        string(source.GetUID()),
+		},
+	}
+}
+
+// ensureMirrorBinding returns an owner reference to the MirrorBinding of source in
+// namespace, creating the binding first if needed. A binding left by a previous
+// source with the same name is taken over, keeping its mirror owned.
+func (r *SourceReconciler) ensureMirrorBinding(ctx context.Context, source *unstructured.Unstructured, namespace string) (metav1.OwnerReference, error) {
+	binding := &unstructured.Unstructured{}
+	binding.SetGroupVersionKind(MirrorBindingGVK)
+	key := client.ObjectKey{Namespace: namespace, Name: mirrorBindingName(source)}
+
+	err := r.Get(ctx, key, binding)
+	switch {
+	case apierrors.IsNotFound(err):
+		binding = buildMirrorBinding(source, namespace)
+		err = r.Create(ctx, binding)
+		if apierrors.IsAlreadyExists(err) {
+			// Created by a concurrent sync the cache has not seen yet
+			var reader client.Reader = r.Client
+			if r.APIReader != nil {
+				reader = r.APIReader
+			}
+			err = reader.Get(ctx, key, binding)
+		}
+		if err != nil {
+			return metav1.OwnerReference{}, fmt.Errorf("failed to create mirror binding: %w", err)
+		}
+	case err != nil:
+		return metav1.OwnerReference{}, fmt.Errorf("failed to get mirror binding: %w", err)
+	case binding.GetLabels()[constants.LabelSourceUID] != string(source.GetUID()):
+		setBindingSource(binding, source)
+		if err := r.Update(ctx, binding); err != nil {
+			return metav1.OwnerReference{}, fmt.Errorf("failed to update mirror binding: %w", err)
+		}
+	}
+
+	return metav1.OwnerReference{
+		APIVersion: MirrorBindingGVK.GroupVersion().String(),
+		Kind:       MirrorBindingGVK.Kind,
+		Name:       binding.GetName(),
+		UID:        binding.GetUID(),
+	}, nil
+}
+
+// ownedByBinding reports whether mirror is owned by a MirrorBinding.
+func ownedByBinding(mirror *unstructured.Unstructured) bool {
+	return slices.ContainsFunc(mirror.GetOwnerReferences(), isBindingReference)
+}
+
+// isBindingReference reports whether ref points to a MirrorBinding.
+func isBindingReference(ref metav1.OwnerReference) bool {
+	return ref.Kind == MirrorBindingGVK.Kind && ref.APIVersion == MirrorBindingGVK.GroupVersion().String()
+}
+
+// deleteMirrorBindings deletes the MirrorBindings of source, and so through garbage
+// collection its mirrors, with a single list instead of a lookup in every namespace.
+// Bindings in namespaces owned by other replicas are left to them and counted as pending.
+func (r *SourceReconciler) deleteMirrorBindings(ctx context.Context, source *unstructured.Unstructured) (deleted, pending int, err error) {
+	logger := log.FromContext(ctx)
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(MirrorBindingGVK.GroupVersion().WithKind(MirrorBindingGVK.Kind + "List"))
+	if err := r.List(ctx, list, client.MatchingLabels{
+		constants.LabelManagedBy: constants.ControllerName,
+		constants.LabelSourceUID: string(source.GetUID()),
+	}); err != nil {
+		return 0, 0, fmt.Errorf("failed to list mirror bindings: %w", err)
+	}
+
+	dryRun := r.dryRun(source)
+	for i := range list.Items {
+		binding := &list.Items[i]
+		ns := binding.GetNamespace()
+		if !ownsNamespace(r.NamespaceOwnership, ns) {
+			pending++
+			continue
+		}
+		if dryRun {
+			reportDryRun(ctx, r.Recorder, source, r.GVK, dryRunDelete, ns, "source is no longer mirrored")
+			deleted++
+			continue
+		}
+
+		// Registry Secret mirrors leave the ServiceAccounts they were attached to first
+		mirror := &unstructured.Unstructured{}
+		mirror.SetGroupVersionKind(source.GroupVersionKind())
+		mirror.SetNamespace(ns)
+		mirror.SetName(naming.MirrorNameOrDefault(source))
+		if err := detachMirror(ctx, r.Client, mirror); err != nil {
+			logger.Error(err, "failed to detach mirror from service accounts", "namespace", ns)
+			continue
+		}
+
+		if err := deleteMirrorBinding(ctx, r.Client, binding); err != nil {
+			logger.Error(err, "failed to delete mirror binding", "namespace", ns)
+			continue
+		}
+		deleted++
+		recordMirrorDeleted(r.Recorder, source, ns, "source is no longer mirrored")
+	}
+	return deleted, pending, nil
+}
+
+// deleteMirrorBinding deletes binding, letting garbage collection remove its mirror.
+func deleteMirrorBinding(ctx context.Context, c client.Client, binding *unstructured.Unstructured) error {
+	err := c.Delete(ctx, binding, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	return client.IgnoreNotFound(err)
+}
+
+// deleteBindingOwners deletes the MirrorBindings owning mirror once the mirror is
+// gone, so no binding outlives it.
+func deleteBindingOwners(ctx context.Context, c client.Client, mirror *unstructured.Unstructured) error {
+	for _, ref := range mirror.GetOwnerReferences() {
+		if !isBindingReference(ref) {
+			continue
+		}
+		binding := &unstructured.Unstructured{}
+		binding.SetGroupVersionKind(MirrorBindingGVK)
+		binding.SetNamespace(mirror.GetNamespace())
+		binding.SetName(ref.Name)
+		if err := deleteMirrorBinding(ctx, c, binding); err != nil {
+			return fmt.Errorf("failed to delete mirror binding %s: %w", ref.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+func getBinding(t *testing.T, c client.Client, namespace, name string) (*unstructured.Unstructured, error) {
+	t.Helper()
+	binding := &unstructured.Unstructured{}
+	binding.SetGroupVersionKind(MirrorBindingGVK)
+	err := c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, binding)
+	return binding, err
+}
+
+func TestMirrorBindingName(t *testing.T) {
+	source := makeUnstructuredSecret("app-secret", "default", nil, nil)
+	assert.Equal(t, "secret.default.app-secret", mirrorBindingName(source))
+
+	long := makeUnstructuredSecret(strings.Repeat("a", 253), "default", nil, nil)
+	name := mirrorBindingName(long)
+	assert.LessOrEqual(t, len(name), maxBindingNameLength)
+	assert.True(t, strings.HasPrefix(name, "secret."))
+	assert.Equal(t, name, mirrorBindingName(long), "the name is stable")
+}
+
+func TestSourceReconciler_Reconcile_OwnerReferences(t *testing.T) {
+	ctx := context.Background()
+	source := makeUnstructuredSecret("app-secret", "default", map[string]string{constants.LabelEnabled: "true"},
+		map[string]string{constants.AnnotationSync: "true", constants.AnnotationTargetNamespaces: "team-a"})
+	source.SetUID(types.UID("source-uid"))
+	source.SetFinalizers([]string{constants.FinalizerName})
+	source.Object["data"] = map[string]interface{}{"key": "dmFsdWU="}
+	c := newFakeClient(t, source)
+
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{UseOwnerReferences: true},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+	}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+	require.NoError(t, err)
+
+	binding, err := getBinding(t, c, "team-a", "secret.default.app-secret")
+	require.NoError(t, err)
+	assert.Equal(t, "source-uid", binding.GetLabels()[constants.LabelSourceUID])
+	name, _, _ := unstructured.NestedString(binding.Object, "spec", "sourceRef", "name")
+	assert.Equal(t, "app-secret", name)
+
+	mirror := makeUnstructuredSecret("", "", nil, nil)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, mirror))
+	require.Len(t, mirror.GetOwnerReferences(), 1)
+	owner := mirror.GetOwnerReferences()[0]
+	assert.Equal(t, MirrorBindingGVK.Kind, owner.Kind)
+	assert.Equal(t, binding.GetName(), owner.Name)
+	assert.Equal(t, binding.GetUID(), owner.UID)
+	assert.True(t, ownedByBinding(mirror))
+}
+
+func TestSourceReconciler_EnsureMirrorBindingTakesOver(t *testing.T) {
+	ctx := context.Background()
+	source := makeUnstructuredSecret("app-secret", "default", nil, nil)
+	source.SetUID(types.UID("previous"))
+	previous := buildMirrorBinding(source, "team-a")
+	c := newFakeClient(t, previous)
+
+	source.SetUID(types.UID("current"))
+	r := &SourceReconciler{Client: c, Config: &config.Config{UseOwnerReferences: true}, GVK: secretGVK}
+	owner, err := r.ensureMirrorBinding(ctx, source, "team-a")
+	require.NoError(t, err)
+
+	binding, err := getBinding(t, c, "team-a", owner.Name)
+	require.NoError(t, err)
+	assert.Equal(t, "current", binding.GetLabels()[constants.LabelSourceUID], "a recreated source takes over the binding")
+}
+
+func TestSourceReconciler_DeleteAllMirrorsOwnerReferences(t *testing.T) {
+	ctx := context.Background()
+	source := makeUnstructuredSecret("app-secret", "default", nil, nil)
+	source.SetUID(types.UID("source-uid"))
+	other := makeUnstructuredSecret("other", "default", nil, nil)
+	other.SetUID(types.UID("other-uid"))
+	c := newFakeClient(t,
+		buildMirrorBinding(source, "team-a"),
+		buildMirrorBinding(source, "team-b"),
+		buildMirrorBinding(other, "team-a"),
+	)
+
+	r := &SourceReconciler{
+		Client:             c,
+		Config:             &config.Config{UseOwnerReferences: true},
+		GVK:                secretGVK,
+		NamespaceOwnership: &fakeNamespaceOwnership{owned: map[string]bool{"default": true, "team-a": true}},
+	}
+	pending, err := r.deleteAllMirrors(ctx, source)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending, "team-b belongs to another shard")
+
+	_, err = getBinding(t, c, "team-a", mirrorBindingName(source))
+	assert.True(t, apierrors.IsNotFound(err))
+	_, err = getBinding(t, c, "team-b", mirrorBindingName(source))
+	assert.NoError(t, err)
+	_, err = getBinding(t, c, "team-a", mirrorBindingName(other))
+	assert.NoError(t, err, "other sources keep their bindings")
+}
+
+func TestDeleteBindingOwners(t *testing.T) {
+	ctx := context.Background()
+	source := makeUnstructuredSecret("app-secret", "default", nil, nil)
+	binding := buildMirrorBinding(source, "team-a")
+	c := newFakeClient(t, binding)
+
+	mirror := makeUnstructuredMirror("app-secret", "team-a", "default", "app-secret")
+	mirror.SetOwnerReferences([]metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "unrelated"},
+		{APIVersion: MirrorBindingGVK.GroupVersion().String(), Kind: MirrorBindingGVK.Kind, Name: binding.GetName()},
+	})
+	require.NoError(t, deleteBindingOwners(ctx, c, mirror))
+
+	_, err := getBinding(t, c, "team-a", binding.GetName())
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.NoError(t, deleteBindingOwners(ctx, c, mirror), "a binding already gone is fine")
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/compat"
+	"github.com/lukaszraczylo/kubemirror/pkg/policy"
+)
+
+var compatLog = ctrl.Log.WithName("annotation-compat")
+
+// AnnotationAliases mirrors sources carrying the annotations of emberstack/reflector
+// or mittwald/kubernetes-replicator as if they carried the sync annotation, to the
+// namespaces those annotations ask for.
+type AnnotationAliases struct {
+	Aliases *compat.Aliases
+}
+
+// TargetPatterns implements MirrorPolicies.
+func (a AnnotationAliases) TargetPatterns(_ schema.GroupVersionKind, source client.Object) []string {
+	patterns, unsupported := a.Aliases.TargetPatterns(source.GetAnnotations())
+	if len(unsupported) > 0 {
+		compatLog.V(1).Info("namespace patterns without a glob equivalent are skipped",
+			"namespace", source.GetNamespace(),
+			"name", source.GetName(),
+			"patterns", unsupported,
+		)
+	}
+	return patterns
+}
+
+// OnChange implements MirrorPolicies. Annotation changes reach the source
+// reconcilers as source events, which metadataOnlyUpdate never drops for the
+// annotations aliases read, so there is nothing to notify.
+func (a AnnotationAliases) OnChange(policy.ChangeFunc) {}
+
+// Resync implements MirrorPolicies.
+func (a AnnotationAliases) Resync(context.Context) {}
+
+// JoinMirrorPolicies combines policies, skipping nil ones: a source is selected
+// when any of them selects it, and mirrored to the union of their targets.
+func JoinMirrorPolicies(policies ...MirrorPolicies) MirrorPolicies {
+	var joined unionPolicies
+	for _, p := range policies {
+		if p != nil {
+			joined = append(joined, p)
+		}
+	}
+	switch len(joined) {
+	case 0:
+		return nil
+	case 1:
+		return joined[0]
+	}
+	return joined
+}
+
+// unionPolicies selects a source when any of its policies does.
+type unionPolicies []MirrorPolicies
+
+// TargetPatterns implements MirrorPolicies.
+func (u unionPolicies) TargetPatterns(gvk schema.GroupVersionKind, source client.Object) []string {
+	var patterns []string
+	for _, p := range u {
+		patterns = append(patterns, p.TargetPatterns(gvk, source)...)
+	}
+	return patterns
+}
+
+// OnChange implements MirrorPolicies.
+func (u unionPolicies) OnChange(fn policy.ChangeFunc) {
+	for _, p := range u {
+		p.OnChange(fn)
+	}
+}
+
+// Resync implements MirrorPolicies.
+func (u unionPolicies) Resync(ctx context.Context) {
+	for _, p := range u {
+		p.Resync(ctx)
+	}
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/compat"
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+// replicatorAliases recognizes mittwald/kubernetes-replicator annotations.
+func replicatorAliases(t *testing.T) AnnotationAliases {
+	t.Helper()
+	aliases, err := compat.NewAliases([]string{compat.Replicator})
+	require.NoError(t, err)
+	return AnnotationAliases{Aliases: aliases}
+}
+
+func TestSourceReconciler_Reconcile_AliasedSource(t *testing.T) {
+	// Annotated for kubernetes-replicator only
+	source := makeUnstructuredSecret("app-secret", "default", nil,
+		map[string]string{compat.ReplicatorReplicateTo: "team-.*"})
+	c := newFakeClient(t, source)
+
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+		Policies:        replicatorAliases(t),
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app-secret"}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	stored := makeUnstructuredSecret("", "", nil, nil)
+	require.NoError(t, c.Get(context.Background(), req.NamespacedName, stored))
+	assert.Contains(t, stored.GetFinalizers(), constants.FinalizerName)
+
+	targets, err := r.resolveTargetNamespaces(context.Background(), stored)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"team-a", "team-b"}, targets)
+}
+
+func TestJoinMirrorPolicies(t *testing.T) {
+	assert.Nil(t, JoinMirrorPolicies(nil, nil))
+
+	aliases := replicatorAliases(t)
+	assert.Equal(t, aliases, JoinMirrorPolicies(nil, aliases))
+
+	joined := JoinMirrorPolicies(secretPolicy(t, "team-a"), aliases)
+	source := makeUnstructuredSecret("app-secret", "default", map[string]string{"team": "a"},
+		map[string]string{compat.ReplicatorReplicateTo: "team-b"})
+	assert.Equal(t, []string{"team-a", "team-b"}, joined.TargetPatterns(secretGVK, source))
+}
+
+func TestCreateMirror_DropsAliasedAnnotations(t *testing.T) {
+	source := makeUnstructuredSecret("app-secret", "default", nil, map[string]string{
+		compat.ReplicatorReplicateTo: "team-a",
+		compat.ReflectorAutoEnabled:  "true",
+		"team":                       "payments",
+	})
+
+	mirror, err := CreateMirror(source, "team-a")
+	require.NoError(t, err)
+
+	annotations := mirror.(*unstructured.Unstructured).GetAnnotations()
+	assert.NotContains(t, annotations, compat.ReplicatorReplicateTo)
+	assert.NotContains(t, annotations, compat.ReflectorAutoEnabled)
+	assert.Equal(t, "payments", annotations["team"])
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/hash"
+)
+
+// ConflictPolicy decides what happens when a target namespace already holds an
+// object of the mirror's name that kubemirror does not manage.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip leaves the existing object alone and reports the target as skipped
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite adopts the existing object, turning it into a managed mirror
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictFail leaves the existing object alone and fails the target
+	ConflictFail ConflictPolicy = "fail"
+	// ConflictAdoptIfIdentical adopts the existing object when its content is the
+	// mirror's, and otherwise leaves it alone like ConflictSkip
+	ConflictAdoptIfIdentical ConflictPolicy = "adopt-if-identical"
+)
+
+// DefaultConflictPolicy is used when neither the flag nor the source sets one.
+const DefaultConflictPolicy = ConflictSkip
+
+// ParseConflictPolicy validates a conflict policy; empty means the default.
+func ParseConflictPolicy(value string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(value); policy {
+	case "":
+		return DefaultConflictPolicy, nil
+	case ConflictSkip, ConflictOverwrite, ConflictFail, ConflictAdoptIfIdentical:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid conflict policy %q (valid: skip, overwrite, fail, adopt-if-identical)", value)
+	}
+}
+
+// TargetConflictError reports a target held by an object kubemirror does not
+// manage, under the fail conflict policy.
+type TargetConflictError struct {
+	Namespace string
+	Name      string
+}
+
+func (e *TargetConflictError) Error() string {
+	return fmt.Sprintf("%s/%s exists and is not managed by kubemirror (conflict-policy=fail)", e.Namespace, e.Name)
+}
+
+// conflictPolicy returns the conflict policy for source: its conflict-policy
+// annotation, else the controller-wide setting.
+func (r *SourceReconciler) conflictPolicy(source metav1.Object) (ConflictPolicy, error) {
+	if value, ok := source.GetAnnotations()[constants.AnnotationConflictPolicy]; ok {
+		return ParseConflictPolicy(value)
+	}
+	if r.Config == nil {
+		return DefaultConflictPolicy, nil
+	}
+	return ParseConflictPolicy(r.Config.ConflictPolicy)
+}
+
+// identicalContent reports whether existing already holds the content of the
+// desired mirror. Metadata is left out: adopting the object adds kubemirror's.
+func identicalContent(desired runtime.Object, existing *unstructured.Unstructured) (bool, error) {
+	want, err := hash.ComputeContentHash(desired)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash mirror: %w", err)
+	}
+	got, err := hash.ComputeContentHash(existing)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash existing object: %w", err)
+	}
+	return want == got, nil
+}
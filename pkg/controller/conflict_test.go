@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func TestParseConflictPolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    ConflictPolicy
+		wantErr bool
+	}{
+		{value: "", want: ConflictSkip},
+		{value: "skip", want: ConflictSkip},
+		{value: "overwrite", want: ConflictOverwrite},
+		{value: "fail", want: ConflictFail},
+		{value: "adopt-if-identical", want: ConflictAdoptIfIdentical},
+		{value: "replace", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseConflictPolicy(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSourceReconciler_syncMirror_ConflictPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       string
+		annotation string
+		// theirs is the data of the unmanaged Secret (nil = different from the source's)
+		theirs map[string][]byte
+		check  func(t *testing.T, existing *corev1.Secret, skipped bool, err error)
+	}{
+		{
+			name: "skip by default",
+			check: func(t *testing.T, existing *corev1.Secret, skipped bool, err error) {
+				require.NoError(t, err)
+				assert.True(t, skipped)
+				assert.Equal(t, "theirs", string(existing.Data["key"]))
+			},
+		},
+		{
+			name: "fail",
+			flag: "fail",
+			check: func(t *testing.T, existing *corev1.Secret, skipped bool, err error) {
+				var conflict *TargetConflictError
+				require.True(t, errors.As(err, &conflict))
+				assert.Equal(t, "team-a", conflict.Namespace)
+				assert.Equal(t, "theirs", string(existing.Data["key"]))
+			},
+		},
+		{
+			name: "overwrite",
+			flag: "overwrite",
+			check: func(t *testing.T, existing *corev1.Secret, skipped bool, err error) {
+				require.NoError(t, err)
+				assert.False(t, skipped)
+				assert.Equal(t, "value", string(existing.Data["key"]))
+				assert.Equal(t, constants.ControllerName, existing.Labels[constants.LabelManagedBy])
+				assert.Equal(t, "theirs", string(existing.Data["extra"]), "fields kubemirror does not write are kept")
+			},
+		},
+		{
+			name:   "adopt if identical",
+			flag:   "adopt-if-identical",
+			theirs: map[string][]byte{"key": []byte("value")},
+			check: func(t *testing.T, existing *corev1.Secret, skipped bool, err error) {
+				require.NoError(t, err)
+				assert.False(t, skipped)
+				assert.Equal(t, constants.ControllerName, existing.Labels[constants.LabelManagedBy])
+			},
+		},
+		{
+			name: "different content is not adopted",
+			flag: "adopt-if-identical",
+			check: func(t *testing.T, existing *corev1.Secret, skipped bool, err error) {
+				require.NoError(t, err)
+				assert.True(t, skipped)
+				assert.Equal(t, "theirs", string(existing.Data["key"]))
+				assert.Empty(t, existing.Labels[constants.LabelManagedBy])
+			},
+		},
+		{
+			name:       "annotation overrides flag",
+			flag:       "fail",
+			annotation: "skip",
+			check: func(t *testing.T, existing *corev1.Secret, skipped bool, err error) {
+				require.NoError(t, err)
+				assert.True(t, skipped)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			var annotations map[string]string
+			if tt.annotation != "" {
+				annotations = map[string]string{constants.AnnotationConflictPolicy: tt.annotation}
+			}
+			source := makeUnstructuredSecret("app-secret", "default", nil, annotations)
+			source.SetUID("source-uid")
+			theirs := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "app-secret", Namespace: "team-a"},
+				Data:       map[string][]byte{"key": []byte("theirs"), "extra": []byte("theirs")},
+			}
+			if tt.theirs != nil {
+				theirs.Data = tt.theirs
+			}
+			c := newFakeClient(t, source, theirs)
+			require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(source), source))
+
+			r := &SourceReconciler{Client: c, Config: &config.Config{ConflictPolicy: tt.flag}, GVK: secretGVK}
+			skipped, err := r.syncMirror(ctx, source, source, "team-a")
+
+			existing := &corev1.Secret{}
+			require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "team-a", Name: "app-secret"}, existing))
+			tt.check(t, existing, skipped, err)
+		})
+	}
+}
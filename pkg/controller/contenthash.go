@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/compat"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/hash"
+)
+
+// recordSourceContentHash writes the content hash the source's mirrors are now in
+// sync with onto the source, along with the time the change took to propagate.
+// It is a no-op if the annotation already has that value.
+func (r *SourceReconciler) recordSourceContentHash(ctx context.Context, source *unstructured.Unstructured, contentHash string, propagation time.Duration) error {
+	annotations := source.GetAnnotations()
+	if contentHash == "" || annotations[constants.AnnotationContentHash] == contentHash {
+		return nil
+	}
+
+	patch := client.MergeFrom(source.DeepCopy())
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[constants.AnnotationContentHash] = contentHash
+	annotations[constants.AnnotationPropagationMs] = strconv.FormatInt(propagation.Milliseconds(), 10)
+	source.SetAnnotations(annotations)
+	return client.IgnoreNotFound(r.Patch(ctx, source, patch))
+}
+
+// metadataOnlyUpdate reports whether an update of a source leaves everything
+// kubemirror reads from it as it was last synced: the content matches the recorded
+// content hash, and labels, finalizers, deletion and the annotations kubemirror
+// reads are unchanged. Annotations set by other controllers and managedFields churn
+// are ignored. Sources with sync-when depend on their status and never qualify, and
+// periodic resyncs, which repeat the same object, are always reconciled.
+func metadataOnlyUpdate(oldObj, newObj client.Object) bool {
+	newU, ok := newObj.(*unstructured.Unstructured)
+	if !ok || oldObj.GetResourceVersion() == newObj.GetResourceVersion() {
+		return false
+	}
+	recorded := newU.GetAnnotations()[constants.AnnotationContentHash]
+	if recorded == "" || newU.GetAnnotations()[constants.AnnotationSyncWhen] != "" {
+		return false
+	}
+	if contentHash, err := hash.ComputeContentHash(newU); err != nil || contentHash != recorded {
+		return false
+	}
+
+	return maps.Equal(oldObj.GetLabels(), newObj.GetLabels()) &&
+		maps.Equal(mirroringAnnotations(oldObj), mirroringAnnotations(newObj)) &&
+		slices.Equal(oldObj.GetFinalizers(), newObj.GetFinalizers()) &&
+		oldObj.GetDeletionTimestamp().Equal(newObj.GetDeletionTimestamp())
+}
+
+// mirroringAnnotations returns the annotations of obj that decide how it is
+// mirrored: kubemirror's own, apart from the status it writes itself, and those of
+// reflector and replicator, which AnnotationAliases turns into targets.
+func mirroringAnnotations(obj client.Object) map[string]string {
+	annotations := make(map[string]string)
+	for k, v := range obj.GetAnnotations() {
+		own := strings.HasPrefix(k, constants.Domain+"/") && !slices.Contains(statusAnnotations, k)
+		if own || compat.IsRecognizedAnnotation(k) {
+			annotations[k] = v
+		}
+	}
+	return annotations
+}
+
+// skipMetadataOnlyUpdates drops source updates for which metadataOnlyUpdate holds.
+var skipMetadataOnlyUpdates = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return !metadataOnlyUpdate(e.ObjectOld, e.ObjectNew)
+	},
+}
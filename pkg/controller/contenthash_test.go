@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/compat"
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/hash"
+)
+
+func TestMetadataOnlyUpdate(t *testing.T) {
+	synced := makeWaveSource("app-config", "", "team-a")
+	synced.SetResourceVersion("1")
+	contentHash, err := hash.ComputeContentHash(synced)
+	require.NoError(t, err)
+	annotations := synced.GetAnnotations()
+	annotations[constants.AnnotationContentHash] = contentHash
+	synced.SetAnnotations(annotations)
+
+	setAnnotation := func(u *unstructured.Unstructured, key, value string) {
+		annotations := u.GetAnnotations()
+		annotations[key] = value
+		u.SetAnnotations(annotations)
+	}
+	tests := []struct {
+		name   string
+		update func(u *unstructured.Unstructured)
+		want   bool
+	}{
+		{name: "annotation by another controller", update: func(u *unstructured.Unstructured) {
+			setAnnotation(u, "argocd.argoproj.io/tracking-id", "app:/Secret:default/app-config")
+		}, want: true},
+		{name: "managed fields", update: func(u *unstructured.Unstructured) {
+			u.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl"}})
+		}, want: true},
+		{name: "status written by kubemirror", update: func(u *unstructured.Unstructured) {
+			setAnnotation(u, constants.AnnotationSyncStatus, "reconciled:1,errors:0")
+		}, want: true},
+		{name: "periodic resync", update: func(u *unstructured.Unstructured) { u.SetResourceVersion("1") }},
+		{name: "content", update: func(u *unstructured.Unstructured) {
+			u.Object["data"] = map[string]interface{}{"key": "b3RoZXI="}
+		}},
+		{name: "targets", update: func(u *unstructured.Unstructured) {
+			setAnnotation(u, constants.AnnotationTargetNamespaces, "team-b")
+		}},
+		{name: "replicator targets", update: func(u *unstructured.Unstructured) {
+			setAnnotation(u, compat.ReplicatorReplicateTo, "team-a,team-b")
+		}},
+		{name: "reflector targets", update: func(u *unstructured.Unstructured) {
+			setAnnotation(u, compat.ReflectorAutoNamespaces, "team-.*")
+		}},
+		{name: "labels", update: func(u *unstructured.Unstructured) {
+			u.SetLabels(map[string]string{constants.LabelEnabled: "true", "team": "payments"})
+		}},
+		{name: "finalizers", update: func(u *unstructured.Unstructured) { u.SetFinalizers(nil) }},
+		{name: "deletion", update: func(u *unstructured.Unstructured) {
+			now := metav1.Now()
+			u.SetDeletionTimestamp(&now)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := synced.DeepCopy()
+			updated.SetResourceVersion("2")
+			tt.update(updated)
+			assert.Equal(t, tt.want, metadataOnlyUpdate(synced, updated))
+		})
+	}
+
+	unsynced := synced.DeepCopy()
+	unsynced.SetAnnotations(map[string]string{constants.AnnotationSync: "true", constants.AnnotationTargetNamespaces: "team-a"})
+	updated := unsynced.DeepCopy()
+	updated.SetResourceVersion("2")
+	assert.False(t, metadataOnlyUpdate(unsynced, updated), "sources without a recorded hash are reconciled")
+
+	conditional := synced.DeepCopy()
+	setAnnotation(conditional, constants.AnnotationSyncWhen, "Ready")
+	updated = conditional.DeepCopy()
+	updated.SetResourceVersion("2")
+	assert.False(t, metadataOnlyUpdate(conditional, updated), "sync-when sources depend on their status")
+}
+
+func TestSkipMetadataOnlyUpdates_CompatRetarget(t *testing.T) {
+	aliased := makeWaveSource("app-config", "", "team-a")
+	aliased.SetResourceVersion("1")
+	contentHash, err := hash.ComputeContentHash(aliased)
+	require.NoError(t, err)
+	aliased.SetAnnotations(map[string]string{
+		compat.ReplicatorReplicateTo:    "team-a",
+		constants.AnnotationContentHash: contentHash,
+	})
+
+	retargeted := aliased.DeepCopy()
+	retargeted.SetResourceVersion("2")
+	annotations := retargeted.GetAnnotations()
+	annotations[compat.ReplicatorReplicateTo] = "team-a,team-b"
+	retargeted.SetAnnotations(annotations)
+
+	assert.True(t, skipMetadataOnlyUpdates.Update(event.UpdateEvent{ObjectOld: aliased, ObjectNew: retargeted}),
+		"a synced source retargeted through a compat annotation is reconciled")
+}
+
+func TestSourceReconciler_Reconcile_RecordsContentHash(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	tests := []struct {
+		name   string
+		dryRun bool
+		want   bool
+	}{
+		{name: "synced", want: true},
+		{name: "dry run", dryRun: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := makeWaveSource("app-config", "", "team-a")
+			c := fake.NewClientBuilder().WithScheme(scheme).
+				WithObjects(source,
+					&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+					&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}).
+				Build()
+			r := &SourceReconciler{
+				Client:          c,
+				Config:          &config.Config{DryRun: tt.dryRun},
+				Filter:          filter.NewNamespaceFilter(nil, nil),
+				NamespaceLister: NewKubernetesNamespaceLister(c),
+				GVK:             secretGVK,
+			}
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)}
+			_, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+
+			stored := makeUnstructuredSecret("", "", nil, nil)
+			require.NoError(t, c.Get(ctx, req.NamespacedName, stored))
+			recorded, ok := stored.GetAnnotations()[constants.AnnotationContentHash]
+			if !tt.want {
+				assert.False(t, ok)
+				return
+			}
+			contentHash, err := hash.ComputeContentHash(stored)
+			require.NoError(t, err)
+			assert.Equal(t, contentHash, recorded)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// reconcileID returns the ID controller-runtime assigned to the reconcile running
+// in ctx, or "" outside a reconcile. Every log line of the reconcile carries it as
+// reconcileID.
+func reconcileID(ctx context.Context) string {
+	return string(controller.ReconcileIDFromContext(ctx))
+}
+
+// stampReconcileID records id on mirror so the write can be traced back to the
+// reconcile's logs. An empty id leaves the mirror unchanged.
+func stampReconcileID(mirror *unstructured.Unstructured, id string) {
+	if id == "" {
+		return
+	}
+	annotations := mirror.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[constants.AnnotationReconcileID] = id
+	mirror.SetAnnotations(annotations)
+}
+
+// withReconcileID appends the reconcile ID in ctx to an Event note, so a failure
+// reported on a source can be matched with the reconcile's logs.
+func withReconcileID(ctx context.Context, note string) string {
+	if id := reconcileID(ctx); id != "" {
+		return note + " (reconcile " + id + ")"
+	}
+	return note
+}
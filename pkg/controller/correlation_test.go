@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func TestStampReconcileID(t *testing.T) {
+	t.Run("records the ID", func(t *testing.T) {
+		mirror := makeUnstructuredMirror("app", "team-a", "default", "app")
+		stampReconcileID(mirror, "6f1c0b9e-2d4a-4c1b-9f55-0e4b8a7d3c21")
+
+		annotations := mirror.GetAnnotations()
+		assert.Equal(t, "6f1c0b9e-2d4a-4c1b-9f55-0e4b8a7d3c21", annotations[constants.AnnotationReconcileID])
+		assert.Equal(t, "default", annotations[constants.AnnotationSourceNamespace], "existing annotations are kept")
+	})
+
+	t.Run("no annotations yet", func(t *testing.T) {
+		mirror := makeUnstructuredSecret("app", "team-a", nil, nil)
+		stampReconcileID(mirror, "abc")
+		assert.Equal(t, "abc", mirror.GetAnnotations()[constants.AnnotationReconcileID])
+	})
+
+	t.Run("empty ID leaves the mirror unchanged", func(t *testing.T) {
+		mirror := makeUnstructuredSecret("app", "team-a", nil, nil)
+		stampReconcileID(mirror, "")
+		assert.NotContains(t, mirror.GetAnnotations(), constants.AnnotationReconcileID)
+	})
+}
+
+func TestWithReconcileID_OutsideReconcile(t *testing.T) {
+	assert.Equal(t, "Failed to mirror", withReconcileID(context.Background(), "Failed to mirror"))
+	assert.Empty(t, reconcileID(context.Background()))
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/transformer"
+)
+
+// ReasonTransformRuleFailed is the Event reason used when a mirror is written
+// with transform rules that failed and were skipped in non-strict mode.
+const ReasonTransformRuleFailed = "TransformRuleFailed"
+
+// stampTransformDiagnostics records diag in a mirror's annotations. Mirrors
+// without rules to report don't carry the annotation.
+func stampTransformDiagnostics(annotations map[string]string, diag *transformer.Diagnostics) error {
+	if diag == nil || len(diag.Results) == 0 {
+		delete(annotations, constants.AnnotationTransformDiagnostics)
+		return nil
+	}
+	data, err := json.Marshal(diag.Results)
+	if err != nil {
+		return fmt.Errorf("failed to encode transform diagnostics: %w", err)
+	}
+	annotations[constants.AnnotationTransformDiagnostics] = string(data)
+	return nil
+}
+
+// transformFailures returns the failed steps recorded on a mirror built by
+// applyTransformations.
+func transformFailures(mirror *unstructured.Unstructured) []transformer.RuleResult {
+	data := mirror.GetAnnotations()[constants.AnnotationTransformDiagnostics]
+	if data == "" {
+		return nil
+	}
+	var results []transformer.RuleResult
+	if err := json.Unmarshal([]byte(data), &results); err != nil {
+		return nil
+	}
+	return (&transformer.Diagnostics{Results: results}).Failed()
+}
+
+// recordTransformFailures emits a Warning Event on source when the mirror just
+// written to targetNs skipped failing transform rules. It runs on writes only,
+// so a failing rule is reported when it starts failing rather than every resync.
+func (r *SourceReconciler) recordTransformFailures(source *unstructured.Unstructured, mirror *unstructured.Unstructured, targetNs string) {
+	failed := transformFailures(mirror)
+	if len(failed) == 0 {
+		return
+	}
+	reasons := make([]string, 0, len(failed))
+	for _, result := range failed {
+		step := result.Rule
+		if result.Path != "" {
+			step += " (" + result.Path + ")"
+		}
+		reasons = append(reasons, step+": "+result.Error)
+	}
+	r.recordEvent(source, corev1.EventTypeWarning, ReasonTransformRuleFailed, "Transform",
+		"Mirror in namespace %s was written without failed transform rules: %s (see the %s annotation on the mirror, or set %s=true to block the mirror instead)",
+		targetNs, strings.Join(reasons, "; "), constants.AnnotationTransformDiagnostics, constants.AnnotationTransformStrict)
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func TestSourceReconciler_syncMirror_TransformDiagnostics(t *testing.T) {
+	ctx := context.Background()
+	source := makeUnstructuredSecret("app-secret", "default", nil, map[string]string{
+		constants.AnnotationTransform: `rules:
+  - path: data.A
+    value: x
+  - path: data.key
+    merge:
+      nested: value
+  - path: data.PROD
+    value: y
+    namespacePattern: prod-*
+`,
+	})
+	c := newFakeClient(t, source)
+	recorder := events.NewFakeRecorder(10)
+	r := &SourceReconciler{Client: c, Config: &config.Config{}, GVK: secretGVK, Recorder: recorder}
+
+	_, err := r.syncMirror(ctx, source, source, "team-a")
+	require.NoError(t, err, "non-strict mode writes the mirror without the failed rule")
+
+	mirror := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, mirror))
+	assert.Equal(t, "x", string(mirror.Data["A"]))
+	assert.JSONEq(t, `[
+		{"rule": "rule 1", "path": "data.A", "outcome": "applied"},
+		{"rule": "rule 2", "path": "data.key", "outcome": "failed", "error": "failed to get existing value: .data.key accessor error: dmFsdWU= is of the type string, expected map[string]interface{}"},
+		{"rule": "rule 3", "path": "data.PROD", "outcome": "skipped", "reason": "namespacePattern \"prod-*\" does not match namespace team-a"}
+	]`, mirror.Annotations[constants.AnnotationTransformDiagnostics])
+
+	event := <-recorder.Events
+	assert.Contains(t, event, ReasonTransformRuleFailed)
+	assert.Contains(t, event, "rule 2 (data.key): failed to get existing value")
+	assert.Contains(t, <-recorder.Events, ReasonMirrorCreated)
+
+	// An up to date mirror is not written again, so the failure is not reported again
+	_, err = r.syncMirror(ctx, source, source, "team-a")
+	require.NoError(t, err)
+	assert.Empty(t, recorder.Events)
+}
+
+func TestStampTransformDiagnostics(t *testing.T) {
+	annotations := map[string]string{constants.AnnotationTransformDiagnostics: "[]"}
+	require.NoError(t, stampTransformDiagnostics(annotations, nil))
+	assert.NotContains(t, annotations, constants.AnnotationTransformDiagnostics, "mirrors without results drop stale diagnostics")
+
+	source := makeUnstructuredSecret("app-secret", "default", nil, map[string]string{constants.AnnotationTransformDiagnostics: "not json"})
+	assert.Empty(t, transformFailures(source))
+}
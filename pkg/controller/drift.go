@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/hash"
+	"github.com/lukaszraczylo/kubemirror/pkg/pullsecret"
+)
+
+// ReasonMirrorDrifted is the Event reason used when a mirror was edited in its
+// target namespace and no longer matches its source.
+const ReasonMirrorDrifted = "MirrorDrifted"
+
+// repairDrift compares a valid mirror with what its source would produce and,
+// unless the source sets protect-drift to "false", restores it. Only content is
+// compared; labels and annotations added by others are not drift.
+func (r *MirrorReconciler) repairDrift(ctx context.Context, source, mirror *unstructured.Unstructured) error {
+	logger := log.FromContext(ctx)
+
+	// A mirror behind its source is the source reconciler's job; it may be held
+	// back on purpose (sync-when, min-sync-interval), so never push it forward here
+	sourceHash, err := hash.ComputeContentHash(source)
+	if err != nil {
+		return fmt.Errorf("failed to compute source hash: %w", err)
+	}
+	if mirror.GetAnnotations()[constants.AnnotationSourceContentHash] != sourceHash {
+		return nil
+	}
+
+	// Build the mirror as the source reconciler would, transformations included
+	builder := &SourceReconciler{Client: r.Client, Config: r.Config, GVK: r.GVK, RuleLibrary: r.RuleLibrary}
+	desired, err := CreateMirrorWithOptions(source, mirror.GetNamespace(), builder.transformOptions())
+	if err != nil {
+		return fmt.Errorf("failed to build mirror: %w", err)
+	}
+	desiredU, ok := desired.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("failed to build mirror: unexpected mirror type %T", desired)
+	}
+	if desiredU.GetName() != mirror.GetName() {
+		return nil
+	}
+	pullsecret.Record(desiredU, pullsecret.ServiceAccounts(source))
+	stampMirrorMetadata(desiredU, r.Config)
+
+	drifted, err := contentDiffers(desiredU, mirror)
+	if err != nil || !drifted {
+		return err
+	}
+
+	if source.GetAnnotations()[constants.AnnotationProtectDrift] == "false" {
+		logger.Info("mirror drifted from its source, leaving it as is (protect-drift=false)")
+		emitEvent(r.Recorder, source, corev1.EventTypeWarning, ReasonMirrorDrifted, "Mirror",
+			"Mirror in namespace %s was modified and no longer matches the source", mirror.GetNamespace())
+		return nil
+	}
+
+	if isDryRun(r.Config, source) {
+		detail := "restoring it from the source after it was modified"
+		if changed := describeChangedFields(changedFields(mirror, desiredU)); changed != "" {
+			detail += ", " + changed
+		}
+		reportDryRun(ctx, r.Recorder, source, r.GVK, dryRunUpdate, mirror.GetNamespace(), detail)
+		return nil
+	}
+
+	// The edit made someone else the owner of the changed fields; take them back
+	if err := applyMirror(ctx, r.Client, desiredU, true); err != nil {
+		return fmt.Errorf("failed to restore drifted mirror: %w", err)
+	}
+	logger.Info("mirror drifted from its source, restored")
+	emitEvent(r.Recorder, source, corev1.EventTypeWarning, ReasonMirrorDrifted, "Mirror",
+		"Mirror in namespace %s was modified and has been restored from the source", mirror.GetNamespace())
+	return nil
+}
+
+// contentDiffers reports whether two mirrors hold different content.
+func contentDiffers(desired, actual *unstructured.Unstructured) (bool, error) {
+	desiredHash, err := hash.ComputeContentHash(desired)
+	if err != nil {
+		return false, fmt.Errorf("failed to compute mirror hash: %w", err)
+	}
+	actualHash, err := hash.ComputeContentHash(actual)
+	if err != nil {
+		return false, fmt.Errorf("failed to compute mirror hash: %w", err)
+	}
+	return desiredHash != actualHash, nil
+}
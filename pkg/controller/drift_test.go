@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func TestMirrorReconciler_RepairDrift(t *testing.T) {
+	ctx := context.Background()
+	source := makeUnstructuredSecret("app-secret", "default", nil, nil)
+	source.SetUID("source-uid")
+	c := newFakeClient(t, source)
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(source), source))
+
+	sourceReconciler := &SourceReconciler{Client: c, Config: &config.Config{}, GVK: secretGVK}
+	require.NoError(t, sourceReconciler.reconcileMirror(ctx, source, source, "team-a"))
+
+	recorder := events.NewFakeRecorder(10)
+	r := &MirrorReconciler{Client: c, Config: &config.Config{}, GVK: secretGVK, Recorder: recorder}
+	key := types.NamespacedName{Namespace: "team-a", Name: "app-secret"}
+	edit := func(value string) {
+		t.Helper()
+		mirror := &corev1.Secret{}
+		require.NoError(t, c.Get(ctx, key, mirror))
+		mirror.Data = map[string][]byte{"key": []byte(value)}
+		require.NoError(t, c.Update(ctx, mirror))
+	}
+	value := func() string {
+		t.Helper()
+		mirror := &corev1.Secret{}
+		require.NoError(t, c.Get(ctx, key, mirror))
+		return string(mirror.Data["key"])
+	}
+
+	// A mirror matching its source is left alone
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	assert.Empty(t, recorder.Events)
+
+	edit("edited")
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	assert.Equal(t, "value", value(), "restored from the source")
+	event := <-recorder.Events
+	assert.Contains(t, event, ReasonMirrorDrifted)
+	assert.Contains(t, event, "restored")
+
+	// With protect-drift=false drift is only reported
+	source.SetAnnotations(map[string]string{constants.AnnotationProtectDrift: "false"})
+	require.NoError(t, c.Update(ctx, source))
+	edit("edited")
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	assert.Equal(t, "edited", value())
+	assert.Contains(t, <-recorder.Events, "no longer matches")
+}
+
+func TestMirrorReconciler_RepairDrift_MirrorBehindSource(t *testing.T) {
+	ctx := context.Background()
+	source := makeUnstructuredSecret("app-secret", "default", nil, nil)
+	source.SetUID("source-uid")
+	c := newFakeClient(t, source)
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(source), source))
+
+	sourceReconciler := &SourceReconciler{Client: c, Config: &config.Config{}, GVK: secretGVK}
+	require.NoError(t, sourceReconciler.reconcileMirror(ctx, source, source, "team-a"))
+
+	// The source changed, but its mirror is held back (e.g. by sync-when)
+	source.Object["data"] = map[string]interface{}{"key": "bmV3"}
+	require.NoError(t, c.Update(ctx, source))
+
+	recorder := events.NewFakeRecorder(10)
+	r := &MirrorReconciler{Client: c, Config: &config.Config{}, GVK: secretGVK, Recorder: recorder}
+	key := types.NamespacedName{Namespace: "team-a", Name: "app-secret"}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	mirror := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, key, mirror))
+	assert.Equal(t, "value", string(mirror.Data["key"]), "left for the source reconciler")
+	assert.Empty(t, recorder.Events)
+}
+
+func TestMirrorReconciler_RepairDrift_KeepsAttachments(t *testing.T) {
+	ctx := context.Background()
+	source := registrySecret("true")
+	c := newFakeClient(t, source, &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "default"}})
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(source), source))
+
+	sourceReconciler := &SourceReconciler{Client: c, Config: &config.Config{}, GVK: secretGVK, Recorder: events.NewFakeRecorder(10)}
+	require.NoError(t, sourceReconciler.reconcileMirror(ctx, source, source, "team-a"))
+
+	key := types.NamespacedName{Namespace: "team-a", Name: "registry"}
+	mirror := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, key, mirror))
+	mirror.Data = map[string][]byte{"key": []byte("edited")}
+	require.NoError(t, c.Update(ctx, mirror))
+
+	r := &MirrorReconciler{Client: c, Config: &config.Config{}, GVK: secretGVK, Recorder: events.NewFakeRecorder(10)}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	require.NoError(t, c.Get(ctx, key, mirror))
+	assert.Equal(t, "value", string(mirror.Data["key"]), "restored from the source")
+	assert.Equal(t, "default", mirror.Annotations[constants.AnnotationAttachedServiceAccounts])
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// ReasonAdmissionRejected is the Event reason used when a mirror write, or its
+// server-side dry run, is rejected, e.g. by a validating webhook or policy in the
+// target namespace.
+const ReasonAdmissionRejected = "AdmissionRejected"
+
+// maxWebhookErrorLength bounds the rejection message stored on a mirror.
+const maxWebhookErrorLength = 1024
+
+// serverDryRun reports whether mirror writes of this reconciler's type are
+// validated with a server-side dry run first. It doubles write requests, so it
+// is enabled per resource type.
+func (r *SourceReconciler) serverDryRun() bool {
+	return r.Config != nil && r.Config.ServerDryRunEnabled(r.GVK)
+}
+
+// isAdmissionRejection reports whether err is the API server refusing the object
+// itself (admission webhooks, validation, policy) rather than a transient failure.
+func isAdmissionRejection(err error) bool {
+	return apierrors.IsForbidden(err) || apierrors.IsInvalid(err) || apierrors.IsBadRequest(err)
+}
+
+// recordAdmissionRejection reports a rejected mirror write with a Warning Event on
+// the source and, when the mirror already exists, the webhook-error annotation on the
+// mirror, so a mirror left at its previous state explains itself in the target namespace.
+func (r *SourceReconciler) recordAdmissionRejection(ctx context.Context, source, existing *unstructured.Unstructured, rejected *AdmissionRejectedError) {
+	r.recordEvent(source, corev1.EventTypeWarning, ReasonAdmissionRejected, "Mirror",
+		"%s, not written", rejected.Error())
+
+	if existing == nil || r.dryRun(source) {
+		return
+	}
+	if patchErr := r.setWebhookError(ctx, existing, webhookErrorMessage(rejected.Err)); patchErr != nil {
+		log.FromContext(ctx).Error(patchErr, "failed to record webhook error on mirror", "targetNamespace", rejected.Namespace)
+	}
+}
+
+// setWebhookError sets the webhook-error annotation on a mirror, or removes it
+// when message is empty. It is a no-op if the annotation already has that value.
+func (r *SourceReconciler) setWebhookError(ctx context.Context, mirror *unstructured.Unstructured, message string) error {
+	annotations := mirror.GetAnnotations()
+	if annotations[constants.AnnotationWebhookError] == message {
+		return nil
+	}
+
+	patch := client.MergeFrom(mirror.DeepCopy())
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if message == "" {
+		delete(annotations, constants.AnnotationWebhookError)
+	} else {
+		annotations[constants.AnnotationWebhookError] = message
+	}
+	mirror.SetAnnotations(annotations)
+	return client.IgnoreNotFound(r.Patch(ctx, mirror, patch))
+}
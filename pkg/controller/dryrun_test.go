@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func webhookDenied() error {
+	return apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "app-config",
+		assert.AnError)
+}
+
+func makeDryRunSource() *unstructured.Unstructured {
+	source := &unstructured.Unstructured{Object: map[string]interface{}{
+		"data": map[string]interface{}{"key": "dmFsdWU="},
+	}}
+	source.SetGroupVersionKind(secretGVK)
+	source.SetNamespace("default")
+	source.SetName("app-config")
+	source.SetUID("source-uid")
+	return source
+}
+
+func newDryRunReconciler(t *testing.T, rejectDryRun *bool, objs ...client.Object) (*SourceReconciler, client.Client, *events.FakeRecorder) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+				applyOpts := &client.ApplyOptions{}
+				applyOpts.ApplyOptions(opts)
+				if len(applyOpts.DryRun) > 0 {
+					if *rejectDryRun {
+						return webhookDenied()
+					}
+					return nil
+				}
+				return c.Apply(ctx, obj, opts...)
+			},
+		}).Build()
+
+	recorder := events.NewFakeRecorder(10)
+	return &SourceReconciler{
+		Client:   c,
+		Config:   &config.Config{ServerDryRunTypes: []config.ResourceType{{Version: "v1", Kind: "Secret"}}},
+		GVK:      secretGVK,
+		Recorder: recorder,
+	}, c, recorder
+}
+
+func TestReconcileMirror_ServerDryRunRejectsCreate(t *testing.T) {
+	reject := true
+	r, c, recorder := newDryRunReconciler(t, &reject)
+	source := makeDryRunSource()
+
+	err := r.reconcileMirror(context.Background(), source, source, "team-a")
+	require.Error(t, err)
+	assert.True(t, apierrors.IsForbidden(err))
+
+	// Nothing was written to the target namespace
+	mirror := &corev1.Secret{}
+	assert.True(t, apierrors.IsNotFound(c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "app-config"}, mirror)))
+
+	event := <-recorder.Events
+	assert.Contains(t, event, ReasonAdmissionRejected)
+	assert.Contains(t, event, "team-a")
+
+	// Once admission accepts it, the mirror is created
+	reject = false
+	require.NoError(t, r.reconcileMirror(context.Background(), source, source, "team-a"))
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "app-config"}, mirror))
+}
+
+func TestReconcileMirror_ServerDryRunRejectsUpdate(t *testing.T) {
+	existing := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name: "app-config", Namespace: "team-a",
+		Labels: map[string]string{constants.LabelManagedBy: constants.ControllerName, constants.LabelMirror: "true"},
+		Annotations: map[string]string{
+			constants.AnnotationSourceNamespace:   "default",
+			constants.AnnotationSourceName:        "app-config",
+			constants.AnnotationSourceContentHash: "stale",
+		},
+	}}
+	reject := true
+	r, c, recorder := newDryRunReconciler(t, &reject, existing)
+	source := makeDryRunSource()
+	key := client.ObjectKey{Namespace: "team-a", Name: "app-config"}
+
+	require.Error(t, r.reconcileMirror(context.Background(), source, source, "team-a"))
+	assert.Contains(t, <-recorder.Events, ReasonAdmissionRejected)
+
+	mirror := &corev1.Secret{}
+	require.NoError(t, c.Get(context.Background(), key, mirror))
+	assert.Contains(t, mirror.Annotations[constants.AnnotationWebhookError], "forbidden")
+
+	// A successful write clears the recorded rejection
+	reject = false
+	require.NoError(t, r.reconcileMirror(context.Background(), source, source, "team-a"))
+	require.NoError(t, c.Get(context.Background(), key, mirror))
+	assert.NotContains(t, mirror.Annotations, constants.AnnotationWebhookError)
+}
+
+func TestReconcileMirror_ServerDryRunDisabledForType(t *testing.T) {
+	reject := true
+	r, c, _ := newDryRunReconciler(t, &reject)
+	r.Config.ServerDryRunTypes = []config.ResourceType{{Version: "v1", Kind: "ConfigMap"}}
+	source := makeDryRunSource()
+
+	require.NoError(t, r.reconcileMirror(context.Background(), source, source, "team-a"))
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "app-config"}, &corev1.Secret{}))
+}
+
+func TestIsAdmissionRejection(t *testing.T) {
+	assert.True(t, isAdmissionRejection(webhookDenied()))
+	assert.True(t, isAdmissionRejection(apierrors.NewInvalid(schema.GroupKind{Kind: "Secret"}, "app-config", nil)))
+	assert.True(t, isAdmissionRejection(apierrors.NewBadRequest("bad")))
+	assert.False(t, isAdmissionRejection(apierrors.NewServiceUnavailable("down")))
+	assert.False(t, isAdmissionRejection(assert.AnError))
+}
@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// ReasonDryRun is the Event reason used for mirror changes a dry run reports
+// instead of making.
+const ReasonDryRun = "DryRun"
+
+// maxChangedFieldsInEvent bounds how many changed fields a dry-run Event lists by name.
+const maxChangedFieldsInEvent = 20
+
+// Mirror changes reported by dry runs, used as the action metric label.
+const (
+	dryRunCreate = "create"
+	dryRunUpdate = "update"
+	dryRunDelete = "delete"
+)
+
+// dryRunChangesTotal counts mirror changes dry runs reported instead of making.
+var dryRunChangesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubemirror_dry_run_changes_total",
+	Help: "Number of mirror creates, updates and deletes reported but not made in dry run mode.",
+}, []string{"resource_type", "action"})
+
+func init() {
+	metrics.Registry.MustRegister(dryRunChangesTotal)
+}
+
+// isDryRun reports whether mirror changes for source are only reported: with
+// --dry-run, or when the source sets the dry-run annotation to "true".
+func isDryRun(cfg *config.Config, source metav1.Object) bool {
+	return (cfg != nil && cfg.DryRun) || source.GetAnnotations()[constants.AnnotationDryRun] == "true"
+}
+
+// dryRun reports whether mirror changes for source are only reported.
+func (r *SourceReconciler) dryRun(source metav1.Object) bool {
+	return isDryRun(r.Config, source)
+}
+
+// reportDryRun logs, counts and records an Event regarding the source for a
+// mirror change a dry run did not make. detail completes the Event note.
+func reportDryRun(ctx context.Context, recorder events.EventRecorder, regarding runtime.Object,
+	gvk schema.GroupVersionKind, action, targetNs, detail string) {
+	log.FromContext(ctx).Info("dry run, not writing mirror", "action", action, "targetNamespace", targetNs, "detail", detail)
+	dryRunChangesTotal.WithLabelValues(resourceTypeLabel(gvk), action).Inc()
+
+	note := fmt.Sprintf("Dry run: would %s mirror in namespace %s", action, targetNs)
+	if detail != "" {
+		note += ": " + detail
+	}
+	emitEvent(recorder, regarding, corev1.EventTypeNormal, ReasonDryRun, "DryRun", "%s", note)
+}
+
+// reportDryRunSync reports the create or update a sync would have written.
+func (r *SourceReconciler) reportDryRunSync(ctx context.Context, source, existing, desired *unstructured.Unstructured, adopt bool) {
+	targetNs := desired.GetNamespace()
+	switch {
+	case existing == nil:
+		reportDryRun(ctx, r.Recorder, source, r.GVK, dryRunCreate, targetNs, "")
+	case adopt:
+		policy, _ := r.conflictPolicy(source)
+		reportDryRun(ctx, r.Recorder, source, r.GVK, dryRunUpdate, targetNs,
+			fmt.Sprintf("adopting existing %s (conflict-policy=%s)", desired.GetName(), policy))
+	default:
+		reportDryRun(ctx, r.Recorder, source, r.GVK, dryRunUpdate, targetNs, describeChangedFields(changedFields(existing, desired)))
+	}
+}
+
+// describeChangedFields formats changed field paths for an Event note.
+func describeChangedFields(fields []string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	more := ""
+	if len(fields) > maxChangedFieldsInEvent {
+		more = fmt.Sprintf(" and %d more", len(fields)-maxChangedFieldsInEvent)
+		fields = fields[:maxChangedFieldsInEvent]
+	}
+	return "changing " + strings.Join(fields, ", ") + more
+}
+
+// changedFields lists the fields an apply of desired would change on existing, as
+// dotted paths one level into maps (e.g. data.password, metadata.labels.team).
+// Values are left out, so the list is safe to show for Secrets. Labels and
+// annotations only count when desired sets them; others may have added their own.
+func changedFields(existing, desired *unstructured.Unstructured) []string {
+	var changed []string
+	for _, field := range []string{"labels", "annotations"} {
+		have, _, _ := unstructured.NestedFieldNoCopy(existing.Object, "metadata", field)
+		want, _, _ := unstructured.NestedFieldNoCopy(desired.Object, "metadata", field)
+		changed = append(changed, changedKeys("metadata."+field, have, want, false)...)
+	}
+	for key, want := range desired.Object {
+		switch key {
+		case "apiVersion", "kind", "metadata", "status":
+			continue
+		}
+		changed = append(changed, changedKeys(key, existing.Object[key], want, true)...)
+	}
+	// Content the source no longer has is removed from the mirror
+	for key, have := range existing.Object {
+		switch key {
+		case "apiVersion", "kind", "metadata", "status":
+			continue
+		}
+		if _, ok := desired.Object[key]; !ok && isContentField(have) {
+			changed = append(changed, key)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// changedKeys compares one field. Maps are compared key by key, reporting keys
+// only present in have as well when removals is set.
+func changedKeys(path string, have, want interface{}, removals bool) []string {
+	haveMap, haveIsMap := have.(map[string]interface{})
+	wantMap, wantIsMap := want.(map[string]interface{})
+	if !haveIsMap || !wantIsMap {
+		if want == nil || equality.Semantic.DeepEqual(have, want) {
+			return nil
+		}
+		return []string{path}
+	}
+
+	var changed []string
+	for key, value := range wantMap {
+		if current, ok := haveMap[key]; !ok || !equality.Semantic.DeepEqual(current, value) {
+			changed = append(changed, path+"."+key)
+		}
+	}
+	if removals {
+		for key := range haveMap {
+			if _, ok := wantMap[key]; !ok {
+				changed = append(changed, path+"."+key)
+			}
+		}
+	}
+	return changed
+}
+
+// isContentField reports whether a field left out of desired holds mirrored content
+// (e.g. a Secret's data once the source has none), rather than an empty default.
+func isContentField(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return len(v) > 0
+	case []interface{}:
+		return len(v) > 0
+	default:
+		return false
+	}
+}
+
+// resourceTypeLabel formats gvk as a resource type (e.g. Secret.v1) for metric labels.
+func resourceTypeLabel(gvk schema.GroupVersionKind) string {
+	return config.ResourceType{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}.String()
+}
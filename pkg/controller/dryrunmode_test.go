@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+func TestChangedFields(t *testing.T) {
+	existing := makeUnstructuredMirror("app-secret", "team-a", "default", "app-secret")
+	existing.SetLabels(map[string]string{constants.LabelManagedBy: "kubemirror", "theirs": "kept"})
+	existing.Object["data"] = map[string]interface{}{"same": "YQ==", "changed": "YQ==", "removed": "YQ=="}
+	existing.Object["type"] = "Opaque"
+
+	desired := makeUnstructuredMirror("app-secret", "team-a", "default", "app-secret")
+	desired.SetLabels(map[string]string{constants.LabelManagedBy: "kubemirror", "team": "a"})
+	desired.Object["data"] = map[string]interface{}{"same": "YQ==", "changed": "Yg==", "added": "Yg=="}
+	desired.Object["type"] = "kubernetes.io/tls"
+
+	assert.Equal(t, []string{"data.added", "data.changed", "data.removed", "metadata.labels.team", "type"},
+		changedFields(existing, desired))
+	assert.Empty(t, changedFields(existing, existing))
+
+	// Content dropped from the source as a whole is removed too
+	delete(desired.Object, "data")
+	assert.Contains(t, changedFields(existing, desired), "data")
+}
+
+func TestDescribeChangedFields(t *testing.T) {
+	assert.Empty(t, describeChangedFields(nil))
+	assert.Equal(t, "changing data.a, type", describeChangedFields([]string{"data.a", "type"}))
+
+	many := make([]string, maxChangedFieldsInEvent+3)
+	for i := range many {
+		many[i] = "data.key"
+	}
+	assert.Contains(t, describeChangedFields(many), " and 3 more")
+}
+
+func TestSourceReconciler_Reconcile_DryRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       bool
+		annotation bool
+	}{
+		{name: "dry-run flag", flag: true},
+		{name: "dry-run annotation", annotation: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			annotations := map[string]string{constants.AnnotationSync: "true", constants.AnnotationTargetNamespaces: "team-a"}
+			if tt.annotation {
+				annotations[constants.AnnotationDryRun] = "true"
+			}
+			source := makeUnstructuredSecret("app-secret", "default", map[string]string{constants.LabelEnabled: "true"}, annotations)
+
+			// team-a holds an outdated mirror, team-b one that is no longer a target
+			outdated := makeUnstructuredMirror("app-secret", "team-a", "default", "app-secret")
+			outdated.Object["data"] = map[string]interface{}{"key": "b2xk"}
+			c := newFakeClient(t, source, outdated, makeUnstructuredMirror("app-secret", "team-b", "default", "app-secret"))
+
+			recorder := events.NewFakeRecorder(10)
+			r := &SourceReconciler{
+				Client:          c,
+				Config:          &config.Config{DryRun: tt.flag},
+				Filter:          filter.NewNamespaceFilter(nil, nil),
+				NamespaceLister: NewKubernetesNamespaceLister(c),
+				StatusReporter:  &recordingReporter{},
+				Recorder:        recorder,
+				GVK:             secretGVK,
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app-secret"}}
+			result, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+			assert.Equal(t, ctrl.Result{}, result, "no requeue for a finalizer")
+
+			// Nothing was written
+			require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(source), source))
+			assert.Empty(t, source.GetFinalizers())
+			mirror := &corev1.Secret{}
+			require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "team-a", Name: "app-secret"}, mirror))
+			assert.Equal(t, "old", string(mirror.Data["key"]))
+			require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "team-b", Name: "app-secret"}, mirror))
+			assert.Empty(t, r.StatusReporter.(*recordingReporter).result.Targets)
+
+			// What would have been written is reported
+			require.Len(t, recorder.Events, 2)
+			update, deletion := <-recorder.Events, <-recorder.Events
+			assert.Contains(t, update, ReasonDryRun)
+			assert.Contains(t, update, "would update mirror in namespace team-a: changing data.key")
+			assert.Contains(t, deletion, "would delete mirror in namespace team-b")
+		})
+	}
+}
+
+func TestSourceReconciler_syncMirror_DryRunCreate(t *testing.T) {
+	ctx := context.Background()
+	source := makeUnstructuredSecret("app-secret", "default", nil, map[string]string{constants.AnnotationDryRun: "true"})
+	c := newFakeClient(t, source)
+	recorder := events.NewFakeRecorder(10)
+
+	r := &SourceReconciler{Client: c, Config: &config.Config{}, Recorder: recorder, GVK: secretGVK}
+	skipped, err := r.syncMirror(ctx, source, source, "team-a")
+	require.NoError(t, err)
+	assert.False(t, skipped)
+
+	mirror := &unstructured.Unstructured{}
+	mirror.SetGroupVersionKind(secretGVK)
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, types.NamespacedName{Namespace: "team-a", Name: "app-secret"}, mirror)))
+	assert.Contains(t, <-recorder.Events, "Dry run: would create mirror in namespace team-a")
+}
+
+func TestMirrorReconciler_DryRun(t *testing.T) {
+	ctx := context.Background()
+	orphan := makeUnstructuredMirror("app-secret", "team-a", "default", "gone")
+	c := newFakeClient(t, orphan)
+	recorder := events.NewFakeRecorder(10)
+
+	r := &MirrorReconciler{Client: c, Config: &config.Config{DryRun: true}, GVK: secretGVK, Recorder: recorder}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(orphan)})
+	require.NoError(t, err)
+
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(orphan), orphan), "orphan is kept")
+	assert.Contains(t, <-recorder.Events, "would delete mirror in namespace team-a: source was deleted")
+}
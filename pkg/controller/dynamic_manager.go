@@ -54,7 +54,7 @@ func (rs RegistrationState) String() string {
 // 1. Periodically scans cluster for resources with kubemirror.raczylo.com/enabled=true label
 // 2. Tracks which resource types have active source resources
 // 3. Dynamically registers controllers only for resource types in use
-// 4. Optionally unregisters controllers for resource types no longer in use
+// 4. Optionally unregisters controllers for resource types without sources for idleScans scans
 type DynamicControllerManager struct {
 	client                  client.Client
 	apiReader               client.Reader // Direct API reader (bypasses cache)
@@ -68,7 +68,10 @@ type DynamicControllerManager struct {
 	mirrorReconcilerFactory MirrorReconcilerFactory
 	availableResourceTypes  []config.ResourceType
 	scanInterval            time.Duration
-	managerStarted          bool // Flag to track if manager has started
+	idleScans               int                         // Scans without sources before unregistering (0 = never)
+	idle                    map[string]int              // Consecutive scans without sources, by GVK
+	groups                  map[string]*controllerGroup // Stops the controllers of a GVK
+	managerStarted          bool                        // Flag to track if manager has started
 	mu                      sync.RWMutex
 }
 
@@ -90,6 +93,10 @@ type DynamicManagerConfig struct {
 	MirrorReconcilerFactory MirrorReconcilerFactory
 	AvailableResources      []config.ResourceType
 	ScanInterval            time.Duration
+	// IdleScans unregisters the controllers of a resource type, and removes its
+	// informer, once this many consecutive scans found no enabled source of it
+	// (0 = controllers are never unregistered)
+	IdleScans int
 }
 
 // NewDynamicControllerManager creates a new dynamic controller manager
@@ -106,6 +113,9 @@ func NewDynamicControllerManager(cfg DynamicManagerConfig) *DynamicControllerMan
 		filter:                  cfg.Filter,
 		namespaceLister:         cfg.NamespaceLister,
 		scanInterval:            cfg.ScanInterval,
+		idleScans:               cfg.IdleScans,
+		idle:                    make(map[string]int),
+		groups:                  make(map[string]*controllerGroup),
 		registrationState:       make(map[string]RegistrationState),
 		activeResourceTypes:     make(map[string]schema.GroupVersionKind),
 		managerStarted:          false,
@@ -183,7 +193,7 @@ func (d *DynamicControllerManager) scanAndRegister(ctx context.Context) error {
 	defer d.mu.Unlock()
 
 	// Track changes
-	var newlyRegistered, alreadyRegistered, partialRetried int
+	var newlyRegistered, alreadyRegistered, partialRetried, unregistered int
 
 	// Register controllers for active resource types
 	for gvkStr, gvk := range activeTypes {
@@ -246,6 +256,20 @@ func (d *DynamicControllerManager) scanAndRegister(ctx context.Context) error {
 		}
 	}
 
+	// Unregister controllers of resource types that stayed without sources
+	for gvkStr, state := range d.registrationState {
+		if _, active := activeTypes[gvkStr]; active || state == StateNotRegistered {
+			delete(d.idle, gvkStr)
+			continue
+		}
+		d.idle[gvkStr]++
+		if d.idleScans == 0 || d.idle[gvkStr] < d.idleScans {
+			continue
+		}
+		d.unregisterController(ctx, gvkStr)
+		unregistered++
+	}
+
 	// Count fully registered controllers
 	fullyRegistered := 0
 	for _, state := range d.registrationState {
@@ -259,6 +283,7 @@ func (d *DynamicControllerManager) scanAndRegister(ctx context.Context) error {
 		"alreadyRegistered", alreadyRegistered,
 		"newlyRegistered", newlyRegistered,
 		"partialRetried", partialRetried,
+		"unregistered", unregistered,
 		"fullyRegistered", fullyRegistered,
 	)
 
@@ -336,13 +361,20 @@ func (d *DynamicControllerManager) findActiveResourceTypes(ctx context.Context)
 func (d *DynamicControllerManager) registerController(ctx context.Context, gvk schema.GroupVersionKind) (RegistrationState, error) {
 	logger := log.FromContext(ctx).WithName("dynamic-controller-manager")
 
+	// The controllers of a GVK run until it is unregistered
+	group := newControllerGroup()
+	gvkStr := config.ResourceType{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}.String()
+
 	// Create source reconciler using factory
 	sourceReconciler := d.sourceReconcilerFactory(gvk)
+	sourceReconciler.stopped = group.stop
 
 	// Register source controller
-	if err := sourceReconciler.SetupWithManagerForResourceType(d.mgr, gvk); err != nil {
+	if err := sourceReconciler.SetupWithManagerForResourceType(group.manager(d.mgr), gvk); err != nil {
+		group.Stop()
 		return StateNotRegistered, fmt.Errorf("failed to register source controller: %w", err)
 	}
+	d.groups[gvkStr] = group
 
 	// Source registered successfully, now try mirror
 	logger.V(1).Info("source controller registered",
@@ -355,7 +387,7 @@ func (d *DynamicControllerManager) registerController(ctx context.Context, gvk s
 	mirrorReconciler := d.mirrorReconcilerFactory(gvk)
 
 	// Register mirror controller
-	if err := mirrorReconciler.SetupWithManager(d.mgr, gvk); err != nil {
+	if err := mirrorReconciler.SetupWithManager(group.manager(d.mgr), gvk); err != nil {
 		// Source is registered but mirror failed - return partial state
 		return StateSourceOnly, fmt.Errorf("source registered but mirror failed: %w", err)
 	}
@@ -372,17 +404,55 @@ func (d *DynamicControllerManager) registerController(ctx context.Context, gvk s
 // registerMirrorControllerOnly registers only the mirror controller for a GVK.
 // Used to complete partial registrations where source was registered but mirror failed.
 func (d *DynamicControllerManager) registerMirrorControllerOnly(ctx context.Context, gvk schema.GroupVersionKind) error {
+	gvkStr := config.ResourceType{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}.String()
+	group := d.groups[gvkStr]
+	if group == nil {
+		group = newControllerGroup()
+		d.groups[gvkStr] = group
+	}
+
 	// Create mirror reconciler using factory
 	mirrorReconciler := d.mirrorReconcilerFactory(gvk)
 
 	// Register mirror controller
-	if err := mirrorReconciler.SetupWithManager(d.mgr, gvk); err != nil {
+	if err := mirrorReconciler.SetupWithManager(group.manager(d.mgr), gvk); err != nil {
 		return fmt.Errorf("failed to register mirror controller: %w", err)
 	}
 
 	return nil
 }
 
+// unregisterController stops the controllers of a GVK and removes its informer,
+// reclaiming the memory of its cached objects. A later scan registers them again
+// once the GVK has enabled sources. Callers must hold d.mu.
+func (d *DynamicControllerManager) unregisterController(ctx context.Context, gvkStr string) {
+	logger := log.FromContext(ctx).WithName("dynamic-controller-manager")
+	gvk := d.activeResourceTypes[gvkStr]
+
+	if group := d.groups[gvkStr]; group != nil {
+		group.Stop()
+	}
+	delete(d.groups, gvkStr)
+	delete(d.registrationState, gvkStr)
+	delete(d.activeResourceTypes, gvkStr)
+	delete(d.idle, gvkStr)
+
+	if d.mgr != nil && !gvk.Empty() {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		if err := d.mgr.GetCache().RemoveInformer(ctx, obj); err != nil {
+			logger.Error(err, "failed to remove informer of unregistered resource type", "gvk", gvkStr)
+		}
+	}
+
+	logger.Info("unregistered controllers of idle resource type",
+		"group", gvk.Group,
+		"version", gvk.Version,
+		"kind", gvk.Kind,
+		"idleScans", d.idleScans,
+	)
+}
+
 // GetRegisteredCount returns the number of fully registered controllers
 func (d *DynamicControllerManager) GetRegisteredCount() int {
 	d.mu.RLock()
@@ -527,3 +527,52 @@ func TestDynamicControllerManager_UnstructuredResourceHandling(t *testing.T) {
 	_, found := activeTypes["Middleware.v1alpha1.traefik.io"]
 	assert.True(t, found, "middleware type should be in active types")
 }
+
+func TestDynamicControllerManager_UnregistersIdleTypes(t *testing.T) {
+	ctx := context.Background()
+	gvk := schema.GroupVersionKind{Group: "traefik.io", Version: "v1alpha1", Kind: "Middleware"}
+	gvkStr := "Middleware.v1alpha1.traefik.io"
+
+	d := NewDynamicControllerManager(DynamicManagerConfig{
+		Client:             fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build(),
+		AvailableResources: []config.ResourceType{{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}},
+		IdleScans:          2,
+	})
+	group := newControllerGroup()
+	d.registrationState[gvkStr] = StateFullyRegistered
+	d.activeResourceTypes[gvkStr] = gvk
+	d.groups[gvkStr] = group
+
+	// The last source is gone, but a single scan is not enough
+	require.NoError(t, d.scanAndRegister(ctx))
+	assert.Equal(t, StateFullyRegistered, d.GetRegistrationState(gvkStr))
+	select {
+	case <-group.stop:
+		t.Fatal("controllers stopped after one idle scan")
+	default:
+	}
+
+	require.NoError(t, d.scanAndRegister(ctx))
+	assert.Equal(t, StateNotRegistered, d.GetRegistrationState(gvkStr))
+	assert.Empty(t, d.GetActiveResourceTypes())
+	assert.NotContains(t, d.groups, gvkStr)
+	select {
+	case <-group.stop:
+	default:
+		t.Fatal("controllers of the idle type are still running")
+	}
+}
+
+func TestDynamicControllerManager_KeepsIdleTypesByDefault(t *testing.T) {
+	gvkStr := "Middleware.v1alpha1.traefik.io"
+	d := NewDynamicControllerManager(DynamicManagerConfig{
+		Client:             fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build(),
+		AvailableResources: []config.ResourceType{{Group: "traefik.io", Version: "v1alpha1", Kind: "Middleware"}},
+	})
+	d.registrationState[gvkStr] = StateFullyRegistered
+
+	for range 5 {
+		require.NoError(t, d.scanAndRegister(context.Background()))
+	}
+	assert.Equal(t, StateFullyRegistered, d.GetRegistrationState(gvkStr), "IdleScans 0 never unregisters")
+}
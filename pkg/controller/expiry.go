@@ -0,0 +1,261 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/listing"
+)
+
+// ReasonInvalidMirrorTTL is the Event reason used when a source's mirror-ttl
+// annotation cannot be parsed.
+const ReasonInvalidMirrorTTL = "InvalidMirrorTTL"
+
+// mirrorTTL parses the source's mirror-ttl annotation (0 = mirrors never expire).
+func mirrorTTL(sourceObj metav1.Object) (time.Duration, error) {
+	value := sourceObj.GetAnnotations()[constants.AnnotationMirrorTTL]
+	if value == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", constants.AnnotationMirrorTTL, value, err)
+	}
+	if ttl <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be positive", constants.AnnotationMirrorTTL, value)
+	}
+	return ttl, nil
+}
+
+// namespaceExpiry returns when mirrors with ttl expire in namespace: ttl after the
+// namespace was created, or after its ttl-refreshed-at annotation when that is
+// later. An unparsable ttl-refreshed-at is ignored, so it never extends access.
+func namespaceExpiry(ctx context.Context, reader client.Reader, namespace string, ttl time.Duration) (time.Time, error) {
+	ns := &corev1.Namespace{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return time.Time{}, fmt.Errorf("failed to read namespace %s for %s: %w", namespace, constants.AnnotationMirrorTTL, err)
+	}
+
+	start := ns.CreationTimestamp.Time
+	if refreshed, err := time.Parse(time.RFC3339, ns.Annotations[constants.AnnotationTTLRefreshedAt]); err == nil && refreshed.After(start) {
+		start = refreshed
+	}
+	return start.Add(ttl), nil
+}
+
+// mirrorExpiry returns when the mirror of sourceObj in targetNs expires (zero = never).
+func mirrorExpiry(ctx context.Context, reader client.Reader, sourceObj metav1.Object, targetNs string) (time.Time, error) {
+	ttl, err := mirrorTTL(sourceObj)
+	if err != nil || ttl == 0 {
+		return time.Time{}, err
+	}
+	return namespaceExpiry(ctx, reader, targetNs, ttl)
+}
+
+// dropExpiredTargets removes the namespaces the mirrors of sourceObj have expired
+// in, so those mirrors are cleaned up like in any namespace that stopped being a
+// target, and never recreated.
+func dropExpiredTargets(ctx context.Context, reader client.Reader, sourceObj metav1.Object, targets []string, now time.Time) ([]string, error) {
+	ttl, err := mirrorTTL(sourceObj)
+	if err != nil || ttl == 0 {
+		return targets, err
+	}
+
+	kept := make([]string, 0, len(targets))
+	for _, ns := range targets {
+		expiresAt, err := namespaceExpiry(ctx, reader, ns, ttl)
+		if err != nil {
+			return nil, err
+		}
+		if now.Before(expiresAt) {
+			kept = append(kept, ns)
+		}
+	}
+	return kept, nil
+}
+
+// formatExpiry returns the expires-at annotation value for expiresAt ("" = never).
+func formatExpiry(expiresAt time.Time) string {
+	if expiresAt.IsZero() {
+		return ""
+	}
+	return expiresAt.UTC().Format(time.RFC3339)
+}
+
+// stampExpiry records expiresAt on mirror; a zero expiresAt leaves it without one.
+func stampExpiry(mirror *unstructured.Unstructured, expiresAt time.Time) {
+	if expiresAt.IsZero() {
+		return
+	}
+	annotations := mirror.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[constants.AnnotationExpiresAt] = formatExpiry(expiresAt)
+	mirror.SetAnnotations(annotations)
+}
+
+// ExpiryJanitor deletes mirrors past their expires-at time. Source reconciles stop
+// targeting namespaces a mirror expired in; the janitor removes the mirror on time
+// without waiting for the next reconcile of its source.
+type ExpiryJanitor struct {
+	Client client.Client
+	Log    logr.Logger
+	// Config supplies pauses and --dry-run (optional)
+	Config *config.Config
+	// Recorder emits MirrorDeleted Events on the sources of expired mirrors (optional)
+	Recorder events.EventRecorder
+	// ResourceTypes returns the resource types whose mirrors are checked
+	ResourceTypes func() []config.ResourceType
+	// Interval is the time between checks
+	Interval time.Duration
+
+	// now returns the current time (time.Now when nil)
+	now func() time.Time
+}
+
+// Start implements manager.Runnable, deleting expired mirrors every Interval.
+func (j *ExpiryJanitor) Start(ctx context.Context) error {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := j.DeleteExpired(ctx); err != nil {
+			j.Log.Error(err, "expired mirror cleanup completed with errors")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable: one replica cleans up.
+func (j *ExpiryJanitor) NeedLeaderElection() bool {
+	return true
+}
+
+// DeleteExpired deletes the expired mirrors of every resource type once and
+// returns how many it deleted. Errors on individual mirrors are collected so one
+// failure does not stop the others.
+func (j *ExpiryJanitor) DeleteExpired(ctx context.Context) (int, error) {
+	now := time.Now()
+	if j.now != nil {
+		now = j.now()
+	}
+
+	var (
+		deleted int
+		errs    []error
+	)
+	for _, rt := range j.ResourceTypes() {
+		gvk := rt.GroupVersionKind()
+		if pauseScope(j.Config, gvk, nil) != "" {
+			continue
+		}
+		err := j.forEachMirror(ctx, gvk, func(mirror *unstructured.Unstructured) {
+			expiresAt, err := time.Parse(time.RFC3339, mirror.GetAnnotations()[constants.AnnotationExpiresAt])
+			if err != nil || now.Before(expiresAt) {
+				return
+			}
+			ok, err := j.deleteExpired(ctx, gvk, mirror)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if ok {
+				deleted++
+			}
+		})
+		if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list %s: %w", rt, err))
+		}
+	}
+
+	if deleted > 0 {
+		j.Log.Info("expired mirrors deleted", "count", deleted)
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// deleteExpired deletes an expired mirror unless its source is paused, reporting
+// the deletion on the source (or on the mirror when the source is gone).
+func (j *ExpiryJanitor) deleteExpired(ctx context.Context, gvk schema.GroupVersionKind, mirror *unstructured.Unstructured) (bool, error) {
+	var regarding runtime.Object = mirror
+	dryRun := j.Config != nil && j.Config.DryRun
+
+	if sourceNs, sourceName, _, found := GetSourceReference(mirror); found {
+		source := &unstructured.Unstructured{}
+		source.SetGroupVersionKind(gvk)
+		err := j.Client.Get(ctx, client.ObjectKey{Namespace: sourceNs, Name: sourceName}, source)
+		switch {
+		case err == nil:
+			if pauseScope(nil, gvk, source) != "" {
+				return false, nil
+			}
+			regarding = source
+			dryRun = isDryRun(j.Config, source)
+		case !apierrors.IsNotFound(err):
+			return false, fmt.Errorf("failed to get source of expired mirror %s/%s: %w", mirror.GetNamespace(), mirror.GetName(), err)
+		}
+	}
+
+	const why = "mirror expired"
+	if dryRun {
+		reportDryRun(log.IntoContext(ctx, j.Log), j.Recorder, regarding, gvk, dryRunDelete, mirror.GetNamespace(), why)
+		return true, nil
+	}
+
+	if err := detachMirror(ctx, j.Client, mirror); err != nil {
+		return false, fmt.Errorf("failed to detach expired mirror %s/%s from service accounts: %w",
+			mirror.GetNamespace(), mirror.GetName(), err)
+	}
+	// A mirror rewritten since it was listed may have been given a new expiry
+	uid, resourceVersion := mirror.GetUID(), mirror.GetResourceVersion()
+	err := j.Client.Delete(ctx, mirror, client.Preconditions{UID: &uid, ResourceVersion: &resourceVersion})
+	if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete expired mirror %s/%s: %w", mirror.GetNamespace(), mirror.GetName(), err)
+	}
+
+	if err := deleteBindingOwners(ctx, j.Client, mirror); err != nil {
+		j.Log.Error(err, "failed to delete mirror binding of expired mirror", "namespace", mirror.GetNamespace())
+	}
+
+	j.Log.Info("expired mirror deleted", "kind", gvk.Kind, "namespace", mirror.GetNamespace(), "name", mirror.GetName())
+	recordMirrorDeleted(j.Recorder, regarding, mirror.GetNamespace(), why)
+	return true, nil
+}
+
+// forEachMirror lists the managed mirrors of gvk page by page.
+func (j *ExpiryJanitor) forEachMirror(ctx context.Context, gvk schema.GroupVersionKind, fn func(*unstructured.Unstructured)) error {
+	managed := client.MatchingLabels{
+		constants.LabelManagedBy: constants.ControllerName,
+		constants.LabelMirror:    "true",
+	}
+	return listing.ForEach(ctx, j.Client, gvk, []client.ListOption{managed}, func(mirror *unstructured.Unstructured) error {
+		fn(mirror)
+		return nil
+	})
+}
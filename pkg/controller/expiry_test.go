@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+// newExpiryFixture returns a fake client whose namespaces were created at the
+// given times; default was created long ago.
+func newExpiryFixture(t *testing.T, created map[string]time.Time, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	objs = append(objs, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:              "default",
+		CreationTimestamp: metav1.NewTime(time.Now().Add(-365 * 24 * time.Hour)),
+	}})
+	for name, at := range created {
+		objs = append(objs, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(at)}})
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func TestMirrorTTL(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "24h", want: 24 * time.Hour},
+		{value: "1h30m", want: 90 * time.Minute},
+		{value: "tomorrow", wantErr: true},
+		{value: "0s", wantErr: true},
+		{value: "-1h", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			source := makeUnstructuredSecret("app-secret", "default", nil, map[string]string{constants.AnnotationMirrorTTL: tt.value})
+			got, err := mirrorTTL(source)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDropExpiredTargets(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	refreshed := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:              "pr-3",
+		CreationTimestamp: metav1.NewTime(now.Add(-48 * time.Hour)),
+		Annotations:       map[string]string{constants.AnnotationTTLRefreshedAt: now.Add(-time.Hour).UTC().Format(time.RFC3339)},
+	}}
+	bogus := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:              "pr-4",
+		CreationTimestamp: metav1.NewTime(now.Add(-48 * time.Hour)),
+		Annotations:       map[string]string{constants.AnnotationTTLRefreshedAt: "soon"},
+	}}
+	c := newExpiryFixture(t, map[string]time.Time{
+		"pr-1": now.Add(-time.Hour),
+		"pr-2": now.Add(-48 * time.Hour),
+	}, refreshed, bogus)
+
+	source := makeUnstructuredSecret("app-secret", "default", nil, map[string]string{constants.AnnotationMirrorTTL: "24h"})
+	kept, err := dropExpiredTargets(ctx, c, source, []string{"pr-1", "pr-2", "pr-3", "pr-4"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pr-1", "pr-3"}, kept, "a refreshed namespace restarts its TTL, an invalid refresh is ignored")
+
+	noTTL := makeUnstructuredSecret("app-secret", "default", nil, nil)
+	kept, err = dropExpiredTargets(ctx, c, noTTL, []string{"pr-2", "missing"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pr-2", "missing"}, kept, "namespaces are not read without a TTL")
+
+	_, err = dropExpiredTargets(ctx, c, source, []string{"missing"}, now)
+	assert.Error(t, err)
+}
+
+func TestSourceReconciler_Reconcile_MirrorTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	source := makeUnstructuredSecret("app-secret", "default", map[string]string{constants.LabelEnabled: "true"}, map[string]string{
+		constants.AnnotationSync:             "true",
+		constants.AnnotationTargetNamespaces: "pr-1,pr-2",
+		constants.AnnotationMirrorTTL:        "24h",
+	})
+	source.SetFinalizers([]string{constants.FinalizerName})
+	source.Object["data"] = map[string]interface{}{"key": "dmFsdWU="}
+
+	c := newExpiryFixture(t, map[string]time.Time{
+		"pr-1": now.Add(-time.Hour),
+		"pr-2": now.Add(-48 * time.Hour),
+	}, source, makeUnstructuredMirror("app-secret", "pr-2", "default", "app-secret"))
+
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+	}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+	require.NoError(t, err)
+
+	mirror := makeUnstructuredSecret("", "", nil, nil)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "pr-1", Name: "app-secret"}, mirror))
+	ns := &corev1.Namespace{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "pr-1"}, ns))
+	assert.Equal(t, formatExpiry(ns.CreationTimestamp.Add(24*time.Hour)), mirror.GetAnnotations()[constants.AnnotationExpiresAt])
+
+	err = c.Get(ctx, client.ObjectKey{Namespace: "pr-2", Name: "app-secret"}, mirror)
+	assert.True(t, apierrors.IsNotFound(err), "expired mirror is deleted")
+}
+
+func TestExpiryJanitor_DeleteExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	expiring := func(namespace, sourceName string, expiresAt time.Time) *unstructured.Unstructured {
+		mirror := makeUnstructuredMirror("app-secret", namespace, "default", sourceName)
+		annotations := mirror.GetAnnotations()
+		annotations[constants.AnnotationExpiresAt] = formatExpiry(expiresAt)
+		mirror.SetAnnotations(annotations)
+		return mirror
+	}
+	newFixture := func(t *testing.T) client.Client {
+		pausedSource := makeUnstructuredSecret("paused", "default", nil, map[string]string{constants.AnnotationPaused: "true"})
+		pausedMirror := expiring("team-a", "paused", now.Add(-time.Minute))
+		pausedMirror.SetName("paused")
+		return newFakeClient(t,
+			makeUnstructuredSecret("app-secret", "default", nil, nil),
+			pausedSource,
+			expiring("team-a", "app-secret", now.Add(-time.Minute)),
+			expiring("team-b", "app-secret", now.Add(time.Hour)),
+			pausedMirror,
+		)
+	}
+	resourceTypes := func() []config.ResourceType { return []config.ResourceType{{Version: "v1", Kind: "Secret"}} }
+
+	t.Run("deletes expired mirrors", func(t *testing.T) {
+		c := newFixture(t)
+		j := &ExpiryJanitor{Client: c, Config: &config.Config{}, ResourceTypes: resourceTypes, now: func() time.Time { return now }}
+
+		deleted, err := j.DeleteExpired(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+
+		mirror := makeUnstructuredSecret("", "", nil, nil)
+		assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, mirror)))
+		assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-b", Name: "app-secret"}, mirror), "unexpired mirror is kept")
+		assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "paused"}, mirror), "paused source keeps its mirrors")
+	})
+
+	t.Run("paused resource type", func(t *testing.T) {
+		c := newFixture(t)
+		cfg := &config.Config{PausedResourceTypes: resourceTypes()}
+		j := &ExpiryJanitor{Client: c, Config: cfg, ResourceTypes: resourceTypes, now: func() time.Time { return now }}
+
+		deleted, err := j.DeleteExpired(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, deleted)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		c := newFixture(t)
+		j := &ExpiryJanitor{Client: c, Config: &config.Config{DryRun: true}, ResourceTypes: resourceTypes, now: func() time.Time { return now }}
+
+		_, err := j.DeleteExpired(ctx)
+		require.NoError(t, err)
+		assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, makeUnstructuredSecret("", "", nil, nil)))
+	})
+}
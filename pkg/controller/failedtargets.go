@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"slices"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/status"
+)
+
+// partialFailure is a reconcile of a source in which some mirror writes failed.
+type partialFailure struct {
+	// fingerprint of the source at the time, see sourceFingerprint
+	fingerprint string
+	// failed lists the target namespaces whose mirrors failed
+	failed []string
+	// statuses holds the status of every local target after the reconcile
+	statuses []status.TargetStatus
+}
+
+// retryTargets narrows targets down to the failed ones that are still targets.
+func (f *partialFailure) retryTargets(targets []string) []string {
+	retry := make([]string, 0, len(f.failed))
+	for _, ns := range targets {
+		if slices.Contains(f.failed, ns) {
+			retry = append(retry, ns)
+		}
+	}
+	return retry
+}
+
+// keptStatuses returns the statuses of the targets that are not retried, as of
+// the reconcile that failed, in the order of targets.
+func (f *partialFailure) keptStatuses(targets, retried []string) []status.TargetStatus {
+	kept := make([]status.TargetStatus, 0, len(targets))
+	for _, ns := range targets {
+		if slices.Contains(retried, ns) {
+			continue
+		}
+		i := slices.IndexFunc(f.statuses, func(s status.TargetStatus) bool { return s.Namespace == ns })
+		if i >= 0 {
+			kept = append(kept, f.statuses[i])
+		}
+	}
+	return kept
+}
+
+// failedTargets remembers which targets of each source failed to sync, so the
+// reconciles retrying them skip the targets already in sync. It is safe for
+// concurrent use.
+type failedTargets struct {
+	sources map[types.NamespacedName]*partialFailure
+	mu      sync.Mutex
+}
+
+// record stores the failed targets of key at fingerprint, replacing earlier ones.
+func (t *failedTargets) record(key types.NamespacedName, failure *partialFailure) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sources == nil {
+		t.sources = make(map[types.NamespacedName]*partialFailure)
+	}
+	t.sources[key] = failure
+}
+
+// pending returns the failed targets of key if the source is still at
+// fingerprint, or nil. Failures recorded at another fingerprint are dropped: a
+// changed source is synced to every target again.
+func (t *failedTargets) pending(key types.NamespacedName, fingerprint string) *partialFailure {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	failure, ok := t.sources[key]
+	if !ok {
+		return nil
+	}
+	if fingerprint == "" || failure.fingerprint != fingerprint {
+		delete(t.sources, key)
+		return nil
+	}
+	return failure
+}
+
+// forget drops the failed targets of key, e.g. once they all synced.
+func (t *failedTargets) forget(key types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.sources, key)
+}
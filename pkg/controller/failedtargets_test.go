@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/status"
+)
+
+func TestFailedTargets(t *testing.T) {
+	var tracker failedTargets
+	key := types.NamespacedName{Namespace: "default", Name: "app-secret"}
+	failure := &partialFailure{
+		fingerprint: "v1",
+		failed:      []string{"team-b"},
+		statuses: []status.TargetStatus{
+			{Namespace: "team-a", State: status.TargetSynced},
+			{Namespace: "team-b", State: status.TargetFailed},
+		},
+	}
+
+	assert.Nil(t, tracker.pending(key, "v1"))
+	tracker.record(key, failure)
+	require.Same(t, failure, tracker.pending(key, "v1"))
+
+	targets := []string{"team-a", "team-b", "team-c"}
+	retry := failure.retryTargets(targets)
+	assert.Equal(t, []string{"team-b"}, retry)
+	assert.Equal(t, []status.TargetStatus{{Namespace: "team-a", State: status.TargetSynced}},
+		failure.keptStatuses(targets, retry), "targets without a status are not made up")
+
+	assert.Nil(t, tracker.pending(key, "v2"), "a changed source is synced everywhere")
+	assert.Nil(t, tracker.pending(key, "v1"), "failures of the old fingerprint are dropped")
+
+	tracker.record(key, failure)
+	tracker.forget(key)
+	assert.Nil(t, tracker.pending(key, "v1"))
+}
+
+func TestSourceReconciler_Reconcile_RetriesFailedTargetsOnly(t *testing.T) {
+	ctx := context.Background()
+	source := makeWaveSource("app-secret", "", "team-a,team-b")
+	c := newFakeClient(t, source)
+	key := client.ObjectKeyFromObject(source)
+
+	// The mirror quota of team-a makes its mirror fail until it is lifted
+	setMaxMirrors := func(annotations map[string]string) {
+		namespace := &corev1.Namespace{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "team-a"}, namespace))
+		namespace.Annotations = annotations
+		require.NoError(t, c.Update(ctx, namespace))
+	}
+	mirrorExists := func(ns string) bool {
+		mirror := &unstructured.Unstructured{}
+		mirror.SetGroupVersionKind(secretGVK)
+		err := c.Get(ctx, client.ObjectKey{Namespace: ns, Name: "app-secret"}, mirror)
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+	deleteMirror := func(ns string) {
+		mirror := &unstructured.Unstructured{}
+		mirror.SetGroupVersionKind(secretGVK)
+		mirror.SetNamespace(ns)
+		mirror.SetName("app-secret")
+		require.NoError(t, c.Delete(ctx, mirror))
+	}
+	setMaxMirrors(map[string]string{constants.AnnotationMaxMirrors: "0"})
+
+	reporter := &recordingReporter{}
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		StatusReporter:  reporter,
+		GVK:             secretGVK,
+	}
+
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	assert.ErrorContains(t, err, "failed to reconcile 1/2 mirrors")
+	assert.False(t, mirrorExists("team-a"))
+	assert.True(t, mirrorExists("team-b"))
+
+	// A retry that fails again counts only the targets it attempted
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	assert.ErrorContains(t, err, "failed to reconcile 1/1 mirrors")
+
+	// The retry leaves team-b alone, though its mirror went missing meanwhile
+	deleteMirror("team-b")
+	setMaxMirrors(nil)
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	assert.True(t, mirrorExists("team-a"))
+	assert.False(t, mirrorExists("team-b"), "only the failed target is retried")
+
+	assert.Equal(t, 2, reporter.result.Reconciled)
+	require.Len(t, reporter.result.Targets, 2, "targets left alone keep their status")
+	assert.Equal(t, "team-a", reporter.result.Targets[0].Namespace)
+	assert.Equal(t, "team-b", reporter.result.Targets[1].Namespace)
+
+	// With every target synced, reconciles cover all targets again
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	assert.True(t, mirrorExists("team-b"))
+}
+
+func TestSourceReconciler_Reconcile_SourceChangeEndsFailedTargetRetries(t *testing.T) {
+	ctx := context.Background()
+	source := makeWaveSource("app-secret", "", "team-a,team-b")
+	c := newFakeClient(t, source)
+	key := client.ObjectKeyFromObject(source)
+
+	namespace := &corev1.Namespace{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "team-a"}, namespace))
+	namespace.Annotations = map[string]string{constants.AnnotationMaxMirrors: "0"}
+	require.NoError(t, c.Update(ctx, namespace))
+
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+	}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.Error(t, err)
+
+	// Change the source content
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(secretGVK)
+	require.NoError(t, c.Get(ctx, key, current))
+	current.Object["data"] = map[string]interface{}{"key": "Y2hhbmdlZA=="}
+	require.NoError(t, c.Update(ctx, current))
+
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.Error(t, err)
+
+	mirror := &unstructured.Unstructured{}
+	mirror.SetGroupVersionKind(secretGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-b", Name: "app-secret"}, mirror))
+	data, _, _ := unstructured.NestedString(mirror.Object, "data", "key")
+	assert.Equal(t, "Y2hhbmdlZA==", data, "every target gets the changed content")
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"context"
+	"sync"
+)
+
+// targetResult is the outcome of syncing one target namespace.
+type targetResult struct {
+	err     error
+	skipped bool
+}
+
+// mirrorWriteWorkers returns how many target namespaces of one source are synced
+// at once: WorkerThreads, or one at a time when unset.
+func (r *SourceReconciler) mirrorWriteWorkers() int {
+	if r.Config == nil || r.Config.WorkerThreads < 1 {
+		return 1
+	}
+	return r.Config.WorkerThreads
+}
+
+// fanOut calls syncTarget for every target with at most workers calls in flight and
+// returns the results in the order of targets. A failing target does not stop
+// the others; the caller aggregates the errors.
+func fanOut(ctx context.Context, targets []string, workers int,
+	syncTarget func(ctx context.Context, targetNs string) (bool, error)) []targetResult {
+	results := make([]targetResult, len(targets))
+	if workers <= 1 || len(targets) <= 1 {
+		for i, targetNs := range targets {
+			results[i].skipped, results[i].err = syncTarget(ctx, targetNs)
+		}
+		return results
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, workers)
+	for i, targetNs := range targets {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			results[i].skipped, results[i].err = syncTarget(ctx, targetNs)
+		}()
+	}
+	wg.Wait()
+	return results
+}
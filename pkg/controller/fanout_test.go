@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+)
+
+func TestFanOut(t *testing.T) {
+	targets := []string{"team-a", "team-b", "team-c", "team-d", "team-e", "team-f"}
+
+	tests := []struct {
+		name    string
+		workers int
+	}{
+		{name: "serial", workers: 1},
+		{name: "bounded", workers: 2},
+		{name: "more workers than targets", workers: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inFlight, peak atomic.Int32
+			results := fanOut(context.Background(), targets, tt.workers, func(_ context.Context, targetNs string) (bool, error) {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+
+				switch targetNs {
+				case "team-b":
+					return false, errors.New("write failed")
+				case "team-d":
+					return true, nil
+				}
+				return false, nil
+			})
+
+			require.Len(t, results, len(targets))
+			assert.LessOrEqual(t, int(peak.Load()), tt.workers)
+			for i, targetNs := range targets {
+				switch targetNs {
+				case "team-b":
+					assert.EqualError(t, results[i].err, "write failed", targetNs)
+				case "team-d":
+					assert.True(t, results[i].skipped, targetNs)
+					assert.NoError(t, results[i].err, targetNs)
+				default:
+					assert.False(t, results[i].skipped, targetNs)
+					assert.NoError(t, results[i].err, targetNs)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceReconciler_MirrorWriteWorkers(t *testing.T) {
+	assert.Equal(t, 1, (&SourceReconciler{}).mirrorWriteWorkers())
+	assert.Equal(t, 1, (&SourceReconciler{Config: &config.Config{}}).mirrorWriteWorkers())
+	assert.Equal(t, 8, (&SourceReconciler{Config: &config.Config{WorkerThreads: 8}}).mirrorWriteWorkers())
+}
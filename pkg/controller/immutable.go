@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// ReasonMirrorRecreated is the Event reason used when a mirror is deleted and
+// created again because an update changed immutable fields.
+const ReasonMirrorRecreated = "MirrorRecreated"
+
+// ImmutableFieldError is returned when the API server refuses a mirror update
+// because it changes immutable fields (e.g. a Secret's type, or the data of an
+// immutable ConfigMap) and the source has not opted in to recreating the mirror.
+type ImmutableFieldError struct {
+	// Err is the API server's rejection
+	Err       error
+	Namespace string
+	Name      string
+	// Fields are the immutable fields the update changed, when the API server names them
+	Fields []string
+}
+
+// Error implements the error interface.
+func (e *ImmutableFieldError) Error() string {
+	fields := ""
+	if len(e.Fields) > 0 {
+		fields = " (" + strings.Join(e.Fields, ", ") + ")"
+	}
+	return fmt.Sprintf("mirror %s/%s cannot be updated, immutable fields changed%s; set %s=true on the source to delete and recreate it",
+		e.Namespace, e.Name, fields, constants.AnnotationRecreateOnImmutableChange)
+}
+
+// Unwrap returns the API server's rejection.
+func (e *ImmutableFieldError) Unwrap() error {
+	return e.Err
+}
+
+// IsImmutableFieldChange reports whether err is (or wraps) an ImmutableFieldError.
+func IsImmutableFieldChange(err error) bool {
+	var immutableErr *ImmutableFieldError
+	return errors.As(err, &immutableErr)
+}
+
+// shouldRecreateOnImmutableChange checks if the source opted in to deleting and
+// recreating mirrors whose update changes immutable fields.
+func shouldRecreateOnImmutableChange(sourceObj metav1.Object) bool {
+	return sourceObj.GetAnnotations()[constants.AnnotationRecreateOnImmutableChange] == "true"
+}
+
+// immutableFields reports whether err is the API server refusing to change
+// immutable fields, and which fields it named.
+func immutableFields(err error) ([]string, bool) {
+	if err == nil || !apierrors.IsInvalid(err) {
+		return nil, false
+	}
+
+	var fields []string
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if strings.Contains(cause.Message, "immutable") {
+				fields = append(fields, cause.Field)
+			}
+		}
+	}
+	if len(fields) == 0 && !strings.Contains(err.Error(), "field is immutable") {
+		return nil, false
+	}
+	return fields, true
+}
+
+// applyOrRecreate applies desired over existing (nil when the mirror does not
+// exist yet). When the update is refused because immutable fields changed, the
+// mirror is deleted and created again if the source opted in, and an
+// ImmutableFieldError is returned otherwise. recreated reports the former.
+func applyOrRecreate(ctx context.Context, c client.Client, sourceObj metav1.Object, existing, desired *unstructured.Unstructured, force bool) (recreated bool, err error) {
+	err = applyMirror(ctx, c, desired, force)
+	fields, immutable := immutableFields(err)
+	if !immutable || existing == nil {
+		return false, err
+	}
+	if !shouldRecreateOnImmutableChange(sourceObj) {
+		return false, &ImmutableFieldError{Namespace: desired.GetNamespace(), Name: desired.GetName(), Fields: fields, Err: err}
+	}
+
+	// Only delete the object that was read; one replaced in the meantime is left alone
+	uid := existing.GetUID()
+	if err := c.Delete(ctx, existing, client.Preconditions{UID: &uid}); err != nil && !apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to delete mirror for recreation: %w", err)
+	}
+	if err := applyMirror(ctx, c, desired, force); err != nil {
+		return false, fmt.Errorf("failed to recreate mirror: %w", err)
+	}
+	return true, nil
+}
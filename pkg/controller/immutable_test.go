@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func immutableTypeChange() error {
+	return apierrors.NewInvalid(schema.GroupKind{Kind: "Secret"}, "app-secret", field.ErrorList{
+		field.Invalid(field.NewPath("type"), "kubernetes.io/tls", "field is immutable"),
+	})
+}
+
+func TestImmutableFields(t *testing.T) {
+	fields, ok := immutableFields(immutableTypeChange())
+	assert.True(t, ok)
+	assert.Equal(t, []string{"type"}, fields)
+
+	// Other validation failures and other errors are not immutable field changes
+	_, ok = immutableFields(apierrors.NewInvalid(schema.GroupKind{Kind: "Secret"}, "app-secret", field.ErrorList{
+		field.Required(field.NewPath("data"), ""),
+	}))
+	assert.False(t, ok)
+	_, ok = immutableFields(webhookDenied())
+	assert.False(t, ok)
+	_, ok = immutableFields(nil)
+	assert.False(t, ok)
+}
+
+func TestSourceReconciler_syncMirror_ImmutableFieldChange(t *testing.T) {
+	key := types.NamespacedName{Namespace: "team-a", Name: "app-secret"}
+
+	tests := []struct {
+		name         string
+		annotations  map[string]string
+		wantErr      bool
+		wantRecreate bool
+	}{
+		{name: "reported without opt-in", wantErr: true},
+		{
+			name:         "recreated with opt-in",
+			annotations:  map[string]string{constants.AnnotationRecreateOnImmutableChange: "true"},
+			wantRecreate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			source := makeUnstructuredSecret("app-secret", "default", nil, tt.annotations)
+			source.SetUID("source-uid")
+			existing := makeUnstructuredMirror("app-secret", "team-a", "default", "app-secret")
+			existing.Object["data"] = map[string]interface{}{"key": "b2xk"}
+
+			scheme := runtime.NewScheme()
+			require.NoError(t, corev1.AddToScheme(scheme))
+			// The API server refuses every update of the existing object
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(source, existing,
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+						if c.Get(ctx, key, &corev1.Secret{}) == nil {
+							return immutableTypeChange()
+						}
+						return c.Apply(ctx, obj, opts...)
+					},
+				}).Build()
+
+			recorder := events.NewFakeRecorder(10)
+			r := &SourceReconciler{Client: c, Config: &config.Config{}, GVK: secretGVK, Recorder: recorder}
+			_, err := r.syncMirror(ctx, source, source, "team-a")
+
+			mirror := &corev1.Secret{}
+			require.NoError(t, c.Get(ctx, key, mirror))
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, IsImmutableFieldChange(err))
+				assert.True(t, apierrors.IsInvalid(err), "wraps the API server's rejection")
+				assert.Contains(t, err.Error(), constants.AnnotationRecreateOnImmutableChange)
+				assert.Equal(t, "old", string(mirror.Data["key"]), "left as it was")
+				assert.Contains(t, <-recorder.Events, ReasonMirrorFailed)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "value", string(mirror.Data["key"]))
+			assert.Contains(t, <-recorder.Events, ReasonMirrorRecreated)
+		})
+	}
+}
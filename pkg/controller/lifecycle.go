@@ -0,0 +1,44 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+)
+
+// Event reasons for mirror lifecycle actions, emitted on the source so users can
+// see where it was mirrored without reading controller logs.
+const (
+	ReasonMirrorCreated = "MirrorCreated"
+	ReasonMirrorUpdated = "MirrorUpdated"
+	ReasonMirrorDeleted = "MirrorDeleted"
+	ReasonMirrorFailed  = "MirrorFailed"
+)
+
+// emitEvent emits an Event if a recorder is configured.
+func emitEvent(recorder events.EventRecorder, regarding runtime.Object, eventType, reason, action, note string, args ...interface{}) {
+	if recorder == nil {
+		return
+	}
+	recorder.Eventf(regarding, nil, eventType, reason, action, note, args...)
+}
+
+// recordMirrorDeleted emits a MirrorDeleted Event on source naming the target namespace and why.
+func recordMirrorDeleted(recorder events.EventRecorder, source runtime.Object, targetNs, why string) {
+	emitEvent(recorder, source, corev1.EventTypeNormal, ReasonMirrorDeleted, "Delete",
+		"Deleted mirror in namespace %s: %s", targetNs, why)
+}
+
+// sourceReference builds an object referring to a mirror's source, for Events
+// regarding a source that was not fetched.
+func sourceReference(gvk schema.GroupVersionKind, source types.NamespacedName, uid string) *unstructured.Unstructured {
+	ref := &unstructured.Unstructured{}
+	ref.SetGroupVersionKind(gvk)
+	ref.SetNamespace(source.Namespace)
+	ref.SetName(source.Name)
+	ref.SetUID(types.UID(uid))
+	return ref
+}
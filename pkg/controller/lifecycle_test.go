@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+)
+
+func TestSourceReconciler_LifecycleEvents(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if key.Namespace == "broken" {
+					return assert.AnError
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).Build()
+
+	recorder := events.NewFakeRecorder(10)
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+		Recorder:        recorder,
+	}
+	source := makeUnstructuredSecret("app-secret", "default", nil, nil)
+	source.SetUID("source-uid")
+
+	require.NoError(t, r.reconcileMirror(ctx, source, source, "team-a"))
+	event := <-recorder.Events
+	assert.Contains(t, event, ReasonMirrorCreated)
+	assert.Contains(t, event, "team-a")
+
+	// An unchanged source writes nothing and emits nothing
+	require.NoError(t, r.reconcileMirror(ctx, source, source, "team-a"))
+	assert.Empty(t, recorder.Events)
+
+	require.NoError(t, unstructured.SetNestedMap(source.Object, map[string]interface{}{"key": "bmV3"}, "data"))
+	require.NoError(t, r.reconcileMirror(ctx, source, source, "team-a"))
+	assert.Contains(t, <-recorder.Events, ReasonMirrorUpdated)
+
+	require.Error(t, r.reconcileMirror(ctx, source, source, "broken"))
+	event = <-recorder.Events
+	assert.Contains(t, event, "Warning "+ReasonMirrorFailed)
+	assert.Contains(t, event, "broken")
+
+	// team-a is no longer a target
+	deleted, err := r.cleanupOrphanedMirrors(ctx, source, []string{"team-b"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	event = <-recorder.Events
+	assert.Contains(t, event, ReasonMirrorDeleted)
+	assert.Contains(t, event, "team-a")
+}
+
+func TestMirrorReconciler_Reconcile_DeletedEvents(t *testing.T) {
+	ctx := context.Background()
+	recreated := makeUnstructuredSecret("recreated", "default", nil, nil)
+	recreated.SetUID("new-uid")
+	c := newFakeClient(t, recreated,
+		makeUnstructuredMirror("orphan", "team-a", "default", "orphan"),
+		makeUnstructuredMirror("recreated", "team-a", "default", "recreated"))
+
+	recorder := events.NewFakeRecorder(10)
+	r := &MirrorReconciler{Client: c, GVK: secretGVK, Recorder: recorder}
+
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "orphan"}})
+	require.NoError(t, err)
+	event := <-recorder.Events
+	assert.Contains(t, event, ReasonMirrorDeleted)
+	assert.Contains(t, event, "source was deleted")
+
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "recreated"}})
+	require.NoError(t, err)
+	event = <-recorder.Events
+	assert.Contains(t, event, ReasonMirrorDeleted)
+	assert.Contains(t, event, "previous source")
+}
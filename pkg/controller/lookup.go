@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/transformer"
+)
+
+// NewTemplateLookup returns a transformer.LookupFunc that reads resources through reader.
+// Objects are read as unstructured so they share the informers used for mirroring, and
+// access is still bounded by the controller's RBAC permissions.
+func NewTemplateLookup(reader client.Reader) transformer.LookupFunc {
+	return func(ctx context.Context, apiVersion, kind, namespace, name string) (map[string]string, error) {
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gv.WithKind(kind))
+		if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		data, _, err := unstructured.NestedStringMap(obj.Object, "data")
+		if err != nil {
+			return nil, fmt.Errorf("invalid data in %s/%s: %w", namespace, name, err)
+		}
+		return data, nil
+	}
+}
+
+// NewNamespaceMetadata returns a transformer.NamespaceFunc that reads namespaces
+// through reader.
+func NewNamespaceMetadata(reader client.Reader) transformer.NamespaceFunc {
+	return func(ctx context.Context, name string) (map[string]string, map[string]string, error) {
+		ns := &corev1.Namespace{}
+		if err := reader.Get(ctx, client.ObjectKey{Name: name}, ns); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil, nil
+			}
+			return nil, nil, err
+		}
+		return ns.Labels, ns.Annotations, nil
+	}
+}
+
+// transformOptions returns the transformation options for this reconciler's mirrors.
+// The lookup template function is only enabled when an allow-list is configured,
+// and contextValue when a context ConfigMap is. Target namespace metadata is not
+// read when watching namespaces, as Namespace objects are out of reach of
+// namespaced RBAC.
+func (r *SourceReconciler) transformOptions() transformer.TransformOptions {
+	opts := transformer.DefaultTransformOptions()
+	if r.Config == nil {
+		return opts
+	}
+
+	opts.DefaultRules = r.Config.TransformDefaults().For(r.GVK)
+	opts.NamespaceRefFields = r.Config.NamespaceRefs()
+	opts.PruneFields = r.Config.PrunedFields().For(r.GVK)
+	opts.LookupAllow = r.Config.TemplateLookupAllow
+	opts.ContextConfigMap = r.Config.TemplateContextConfigMap
+	if len(opts.LookupAllow) > 0 || opts.ContextConfigMap != "" {
+		opts.Lookup = NewTemplateLookup(r.Client)
+	}
+	opts.ClusterName = r.Config.ClusterName
+	opts.TemplatePool = r.Config.TemplatePool
+	if r.Client != nil && len(r.Config.WatchNamespaces) == 0 {
+		opts.Namespace = NewNamespaceMetadata(r.Client)
+	}
+	if r.RuleLibrary != nil {
+		opts.RuleLibrary = r.RuleLibrary.Rules
+	}
+	return opts
+}
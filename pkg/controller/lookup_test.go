@@ -0,0 +1,192 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/transformer"
+)
+
+func TestNewTemplateLookup(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "mirror-settings", Namespace: "prod"},
+		Data:       map[string]string{"domain": "prod.example.com"},
+	}).Build()
+
+	lookup := NewTemplateLookup(c)
+
+	data, err := lookup(context.Background(), "v1", "ConfigMap", "prod", "mirror-settings")
+	require.NoError(t, err)
+	assert.Equal(t, "prod.example.com", data["domain"])
+
+	data, err = lookup(context.Background(), "v1", "ConfigMap", "dev", "mirror-settings")
+	require.NoError(t, err, "missing resources are not an error")
+	assert.Nil(t, data)
+
+	_, err = lookup(context.Background(), "a/b/c", "ConfigMap", "prod", "mirror-settings")
+	assert.Error(t, err)
+}
+
+func TestSourceReconciler_TransformOptionsLookup(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "mirror-settings", Namespace: "prod"},
+		Data:       map[string]string{"domain": "prod.example.com"},
+	}).Build()
+
+	source := makeUnstructuredSecret("app-config", "default", nil, map[string]string{
+		constants.AnnotationTransform: `rules:
+  - path: metadata.labels.domain
+    template: '{{ lookup "v1" "ConfigMap" .TargetNamespace "mirror-settings" "domain" }}'
+`,
+	})
+
+	r := &SourceReconciler{Client: c, Config: &config.Config{}}
+	assert.Nil(t, r.transformOptions().Lookup, "lookup is disabled without an allow-list")
+
+	r.Config.TemplateLookupAllow = []string{"*/mirror-settings"}
+	mirror, err := CreateMirrorWithOptions(source, "prod", r.transformOptions())
+	require.NoError(t, err)
+	assert.Equal(t, "prod.example.com", mirror.(*unstructured.Unstructured).GetLabels()["domain"])
+}
+
+func TestSourceReconciler_TransformOptionsContextValue(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubemirror-context", Namespace: "prod"},
+		Data:       map[string]string{"domain": "prod.example.com"},
+	}).Build()
+
+	source := makeUnstructuredSecret("app-config", "default", nil, map[string]string{
+		constants.AnnotationTransform: `rules:
+  - path: metadata.labels.domain
+    template: '{{ contextValue "domain" }}'
+`,
+	})
+
+	r := &SourceReconciler{Client: c, Config: &config.Config{TemplateContextConfigMap: "kubemirror-context"}}
+	opts := r.transformOptions()
+	require.NotNil(t, opts.Lookup, "enabled by the context ConfigMap alone")
+	assert.Empty(t, opts.LookupAllow, "lookup itself stays disabled")
+
+	mirror, err := CreateMirrorWithOptions(source, "prod", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "prod.example.com", mirror.(*unstructured.Unstructured).GetLabels()["domain"])
+}
+
+func TestNewNamespaceMetadata(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "prod",
+			Labels:      map[string]string{"env": "production"},
+			Annotations: map[string]string{"owner": "platform"},
+		},
+	}).Build()
+
+	namespace := NewNamespaceMetadata(c)
+
+	labels, annotations, err := namespace(context.Background(), "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "production", labels["env"])
+	assert.Equal(t, "platform", annotations["owner"])
+
+	labels, annotations, err = namespace(context.Background(), "dev")
+	require.NoError(t, err, "missing namespaces are not an error")
+	assert.Nil(t, labels)
+	assert.Nil(t, annotations)
+}
+
+func TestSourceReconciler_TransformOptionsNamespaceContext(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"env": "production"}},
+	}).Build()
+
+	source := makeUnstructuredSecret("app-config", "default", map[string]string{"team": "payments"}, map[string]string{
+		constants.AnnotationTransform: `rules:
+  - path: metadata.labels.host
+    template: '{{ index .TargetNamespaceLabels "env" }}.{{ .ClusterName }}.{{ .SourceLabels.team }}'
+`,
+	})
+
+	r := &SourceReconciler{Client: c, Config: &config.Config{ClusterName: "eu"}}
+	mirror, err := CreateMirrorWithOptions(source, "prod", r.transformOptions())
+	require.NoError(t, err)
+	assert.Equal(t, "production.eu.payments", mirror.(*unstructured.Unstructured).GetLabels()["host"])
+
+	r.Config.WatchNamespaces = []string{"default", "prod"}
+	assert.Nil(t, r.transformOptions().Namespace, "namespaces are out of reach when watching namespaces")
+}
+
+func TestSourceReconciler_TransformOptionsDefaultRules(t *testing.T) {
+	defaults, err := transformer.ParseDefaultRules([]byte(`
+Secret.v1:
+  - path: metadata.labels.mirrored-from
+    template: "{{.SourceNamespace}}"
+`))
+	require.NoError(t, err)
+
+	r := &SourceReconciler{GVK: secretGVK, Config: &config.Config{DefaultTransformRules: defaults}}
+	source := makeUnstructuredSecret("app-secret", "default", nil, nil)
+
+	mirror, err := CreateMirrorWithOptions(source, "prod", r.transformOptions())
+	require.NoError(t, err)
+
+	labels := mirror.(*unstructured.Unstructured).GetLabels()
+	assert.Equal(t, "default", labels["mirrored-from"])
+	assert.Equal(t, constants.ControllerName, labels[constants.LabelManagedBy], "ownership labels are kept")
+	assert.NotContains(t, mirror.(*unstructured.Unstructured).GetAnnotations(), constants.AnnotationTransform)
+}
+
+func TestSourceReconciler_syncMirror_DefaultRulesChange(t *testing.T) {
+	rules := func(version string) *transformer.DefaultRules {
+		defaults, err := transformer.ParseDefaultRules([]byte(`
+Secret.v1:
+  - path: metadata.labels.rules
+    value: "` + version + `"
+`))
+		require.NoError(t, err)
+		return defaults
+	}
+
+	c := newFakeClient(t)
+	r := &SourceReconciler{Client: c, GVK: secretGVK, Config: &config.Config{DefaultTransformRules: rules("v1")}}
+	source := makeUnstructuredSecret("app-secret", "default", nil, nil)
+	key := client.ObjectKey{Namespace: "team-a", Name: "app-secret"}
+
+	require.NoError(t, r.reconcileMirror(context.Background(), source, source, "team-a"))
+	mirror := &corev1.Secret{}
+	require.NoError(t, c.Get(context.Background(), key, mirror))
+	assert.Equal(t, "v1", mirror.Labels["rules"])
+	assert.NotEmpty(t, mirror.Annotations[constants.AnnotationMirrorContentHash])
+	written := mirror.ResourceVersion
+
+	// Unchanged output is not rewritten
+	require.NoError(t, r.reconcileMirror(context.Background(), source, source, "team-a"))
+	require.NoError(t, c.Get(context.Background(), key, mirror))
+	assert.Equal(t, written, mirror.ResourceVersion)
+
+	// The source is unchanged, but its transformed output is not
+	r.Config.DefaultTransformRules = rules("v2")
+	require.NoError(t, r.reconcileMirror(context.Background(), source, source, "team-a"))
+	require.NoError(t, c.Get(context.Background(), key, mirror))
+	assert.Equal(t, "v2", mirror.Labels["rules"])
+}
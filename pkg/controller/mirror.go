@@ -2,17 +2,28 @@
 package controller
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/lukaszraczylo/kubemirror/pkg/compat"
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
 	"github.com/lukaszraczylo/kubemirror/pkg/constants"
 	"github.com/lukaszraczylo/kubemirror/pkg/hash"
+	"github.com/lukaszraczylo/kubemirror/pkg/keyfilter"
+	"github.com/lukaszraczylo/kubemirror/pkg/naming"
+	"github.com/lukaszraczylo/kubemirror/pkg/pullsecret"
 	"github.com/lukaszraczylo/kubemirror/pkg/transformer"
 )
 
@@ -20,6 +31,12 @@ import (
 // It copies the source resource's spec/data and adds ownership annotations.
 // If transformation rules are present, they are applied to the mirror.
 func CreateMirror(source runtime.Object, targetNamespace string) (runtime.Object, error) {
+	return CreateMirrorWithOptions(source, targetNamespace, transformer.DefaultTransformOptions())
+}
+
+// CreateMirrorWithOptions is CreateMirror with explicit transformation options,
+// e.g. to enable the lookup template function.
+func CreateMirrorWithOptions(source runtime.Object, targetNamespace string, opts transformer.TransformOptions) (runtime.Object, error) {
 	// Compute content hash of source
 	sourceHash, err := hash.ComputeContentHash(source)
 	if err != nil {
@@ -33,38 +50,120 @@ func CreateMirror(source runtime.Object, targetNamespace string) (runtime.Object
 		mirror, err = createSecretMirror(src, targetNamespace, sourceHash)
 	case *corev1.ConfigMap:
 		mirror, err = createConfigMapMirror(src, targetNamespace, sourceHash)
+	case *corev1.ServiceAccount:
+		mirror, err = createServiceAccountMirror(src, targetNamespace, sourceHash)
+	case *rbacv1.Role:
+		mirror, err = createRoleMirror(src, targetNamespace, sourceHash)
+	case *rbacv1.RoleBinding:
+		mirror, err = createRoleBindingMirror(src, targetNamespace, sourceHash)
+	case *networkingv1.NetworkPolicy:
+		mirror, err = createNetworkPolicyMirror(src, targetNamespace, sourceHash)
 	default:
 		// For unstructured/CRD resources
 		mirror, err = createUnstructuredMirror(source, targetNamespace, sourceHash)
+		if u, ok := mirror.(*unstructured.Unstructured); ok && err == nil {
+			err = transformer.Prune(u, opts.PruneFields)
+		}
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
+	// Mirrors may be named differently from their source
+	if sourceObj, ok := source.(metav1.Object); ok {
+		name, nameErr := naming.MirrorName(sourceObj)
+		if nameErr != nil {
+			return nil, nameErr
+		}
+		if mirrorObj, ok := mirror.(metav1.Object); ok {
+			mirrorObj.SetName(name)
+		}
+	}
+
 	// Apply transformations if rules are present
-	mirror, err = applyTransformations(source, mirror, targetNamespace)
+	mirror, err = applyTransformations(source, mirror, targetNamespace, opts)
 	if err != nil {
 		return nil, fmt.Errorf("transformation failed: %w", err)
 	}
 
+	// Record the transformed content, so output changes are detected on later syncs
+	if sourceObj, ok := source.(metav1.Object); ok && transformsApply(sourceObj, opts) {
+		if err := setMirrorContentHash(mirror); err != nil {
+			return nil, err
+		}
+	}
+
+	return mirror, nil
+}
+
+// setMirrorContentHash records the hash of the transformed mirror's content, labels
+// and annotations. kubemirror's own annotations are left out: they change on every sync.
+func setMirrorContentHash(mirror runtime.Object) error {
+	mirrorObj, ok := mirror.(metav1.Object)
+	if !ok {
+		return nil
+	}
+	content, err := hash.ComputeContentHash(mirror)
+	if err != nil {
+		return fmt.Errorf("failed to compute mirror hash: %w", err)
+	}
+
+	annotations := mirrorObj.GetAnnotations()
+	data, err := json.Marshal(map[string]interface{}{
+		"content":     content,
+		"labels":      mirrorObj.GetLabels(),
+		"annotations": filterKubeMirrorMetadata(annotations),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to compute mirror hash: %w", err)
+	}
+	sum := sha256.Sum256(data)
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[constants.AnnotationMirrorContentHash] = hex.EncodeToString(sum[:])
+	mirrorObj.SetAnnotations(annotations)
+	return nil
+}
+
+// transformedContentChanged reports whether the transformed content of desired
+// differs from what was last written to existing.
+func transformedContentChanged(desired runtime.Object, existing metav1.Object) bool {
+	desiredObj, ok := desired.(metav1.Object)
+	if !ok {
+		return true
+	}
+	// A rule failing without changing the output still updates the diagnostics
+	for _, key := range []string{constants.AnnotationMirrorContentHash, constants.AnnotationTransformDiagnostics} {
+		if desiredObj.GetAnnotations()[key] != existing.GetAnnotations()[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// completeMirror adds what a built mirror of source carries besides its content:
+// the ServiceAccounts its pull Secret is attached to, its expiry and the metadata
+// configured for all mirrors.
+func completeMirror(desired runtime.Object, source *unstructured.Unstructured, cfg *config.Config, expiresAt time.Time) (*unstructured.Unstructured, error) {
+	mirror, ok := desired.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("failed to build mirror: unexpected mirror type %T", desired)
+	}
+	pullsecret.Record(mirror, pullsecret.ServiceAccounts(source))
+	stampExpiry(mirror, expiresAt)
+	stampMirrorMetadata(mirror, cfg)
 	return mirror, nil
 }
 
 // createSecretMirror creates a mirror of a Secret.
 func createSecretMirror(source *corev1.Secret, targetNamespace, sourceHash string) (*corev1.Secret, error) {
 	mirror := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      source.Name,
-			Namespace: targetNamespace,
-			Labels: map[string]string{
-				constants.LabelManagedBy: constants.ControllerName,
-				constants.LabelMirror:    "true",
-			},
-			Annotations: buildMirrorAnnotations(source, sourceHash),
-		},
-		Type: source.Type,
-		Data: source.Data,
+		ObjectMeta: mirrorObjectMeta(source, source.Name, targetNamespace, sourceHash),
+		Type:       source.Type,
+		Data:       keyfilter.Keys(keyfilter.FromAnnotations(source.Annotations), source.Data),
 		// Note: Don't copy StringData as it's write-only and gets converted to Data
 	}
 
@@ -74,17 +173,107 @@ func createSecretMirror(source *corev1.Secret, targetNamespace, sourceHash strin
 // createConfigMapMirror creates a mirror of a ConfigMap.
 func createConfigMapMirror(source *corev1.ConfigMap, targetNamespace, sourceHash string) (*corev1.ConfigMap, error) {
 	mirror := &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      source.Name,
-			Namespace: targetNamespace,
-			Labels: map[string]string{
-				constants.LabelManagedBy: constants.ControllerName,
-				constants.LabelMirror:    "true",
-			},
-			Annotations: buildMirrorAnnotations(source, sourceHash),
-		},
-		Data:       source.Data,
-		BinaryData: source.BinaryData,
+		ObjectMeta: mirrorObjectMeta(source, source.Name, targetNamespace, sourceHash),
+	}
+	keys := keyfilter.FromAnnotations(source.Annotations)
+	mirror.Data = keyfilter.Keys(keys, source.Data)
+	mirror.BinaryData = keyfilter.Keys(keys, source.BinaryData)
+
+	return mirror, nil
+}
+
+// mirrorObjectMeta returns the metadata of a typed mirror of source. Of the
+// source's own labels and annotations, it only carries those the source
+// propagates.
+func mirrorObjectMeta(source runtime.Object, name, targetNamespace, sourceHash string) metav1.ObjectMeta {
+	labels := make(map[string]string)
+	annotations := make(map[string]string)
+	if sourceObj, ok := source.(metav1.Object); ok {
+		maps.Copy(labels, selectedMetadata(sourceObj, constants.AnnotationPropagateLabels, sourceObj.GetLabels()))
+		maps.Copy(annotations, selectedMetadata(sourceObj, constants.AnnotationPropagateAnnotations, sourceObj.GetAnnotations()))
+		maps.DeleteFunc(annotations, func(key, _ string) bool { return compat.IsRecognizedAnnotation(key) })
+	}
+	labels[constants.LabelManagedBy] = constants.ControllerName
+	labels[constants.LabelMirror] = "true"
+	maps.Copy(annotations, buildMirrorAnnotations(source, sourceHash))
+
+	return metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   targetNamespace,
+		Labels:      labels,
+		Annotations: annotations,
+	}
+}
+
+// selectedMetadata returns the entries of metadata, the labels or annotations of
+// source, selected by its propagate annotation key, or nil if it has none.
+func selectedMetadata(source metav1.Object, key string, metadata map[string]string) map[string]string {
+	patterns, ok := source.GetAnnotations()[key]
+	if !ok {
+		return nil
+	}
+	return keyfilter.Metadata(patterns, metadata)
+}
+
+// propagatedMetadata returns the labels or annotations of source copied to its
+// unstructured mirrors: those its propagate annotation key selects, or without
+// one all but kubemirror's own.
+func propagatedMetadata(source metav1.Object, key string, metadata map[string]string) map[string]string {
+	if _, ok := source.GetAnnotations()[key]; ok {
+		return selectedMetadata(source, key, metadata)
+	}
+	return filterKubeMirrorMetadata(metadata)
+}
+
+// createServiceAccountMirror creates a mirror of a ServiceAccount. secrets is never
+// copied; imagePullSecrets only when the source asks for it.
+func createServiceAccountMirror(source *corev1.ServiceAccount, targetNamespace, sourceHash string) (*corev1.ServiceAccount, error) {
+	mirror := &corev1.ServiceAccount{
+		ObjectMeta: mirrorObjectMeta(source, source.Name, targetNamespace, sourceHash),
+	}
+	copyServiceAccountContent(mirror, source)
+
+	return mirror, nil
+}
+
+// copyServiceAccountContent copies the ServiceAccount fields that make sense in
+// another namespace, see sanitizeServiceAccountMirror.
+func copyServiceAccountContent(mirror, source *corev1.ServiceAccount) {
+	mirror.AutomountServiceAccountToken = source.AutomountServiceAccountToken
+	mirror.Secrets = nil
+	mirror.ImagePullSecrets = nil
+	if source.Annotations[constants.AnnotationMirrorImagePullSecrets] == "true" {
+		mirror.ImagePullSecrets = append([]corev1.LocalObjectReference(nil), source.ImagePullSecrets...)
+	}
+}
+
+// createRoleMirror creates a mirror of a Role.
+func createRoleMirror(source *rbacv1.Role, targetNamespace, sourceHash string) (*rbacv1.Role, error) {
+	mirror := &rbacv1.Role{
+		ObjectMeta: mirrorObjectMeta(source, source.Name, targetNamespace, sourceHash),
+		Rules:      source.DeepCopy().Rules,
+	}
+
+	return mirror, nil
+}
+
+// createRoleBindingMirror creates a mirror of a RoleBinding.
+func createRoleBindingMirror(source *rbacv1.RoleBinding, targetNamespace, sourceHash string) (*rbacv1.RoleBinding, error) {
+	copied := source.DeepCopy()
+	mirror := &rbacv1.RoleBinding{
+		ObjectMeta: mirrorObjectMeta(source, source.Name, targetNamespace, sourceHash),
+		Subjects:   copied.Subjects,
+		RoleRef:    copied.RoleRef,
+	}
+
+	return mirror, nil
+}
+
+// createNetworkPolicyMirror creates a mirror of a NetworkPolicy.
+func createNetworkPolicyMirror(source *networkingv1.NetworkPolicy, targetNamespace, sourceHash string) (*networkingv1.NetworkPolicy, error) {
+	mirror := &networkingv1.NetworkPolicy{
+		ObjectMeta: mirrorObjectMeta(source, source.Name, targetNamespace, sourceHash),
+		Spec:       *source.Spec.DeepCopy(),
 	}
 
 	return mirror, nil
@@ -116,23 +305,18 @@ func createUnstructuredMirror(source runtime.Object, targetNamespace, sourceHash
 	// Create mirror
 	mirror := u.DeepCopy()
 	mirror.SetNamespace(targetNamespace)
+	keyfilter.Apply(mirror)
 
-	// Remove kubemirror labels from source (don't propagate to mirrors)
-	labels := mirror.GetLabels()
-	if labels == nil {
-		labels = make(map[string]string)
-	}
-	labels = filterKubeMirrorMetadata(labels)
+	// Copy the source labels it propagates, never kubemirror's own
+	labels := propagatedMetadata(u, constants.AnnotationPropagateLabels, mirror.GetLabels())
 	labels[constants.LabelManagedBy] = constants.ControllerName
 	labels[constants.LabelMirror] = "true"
 	mirror.SetLabels(labels)
 
-	// Remove kubemirror annotations from source (don't propagate to mirrors)
-	existingAnnotations := mirror.GetAnnotations()
-	if existingAnnotations == nil {
-		existingAnnotations = make(map[string]string)
-	}
-	existingAnnotations = filterKubeMirrorMetadata(existingAnnotations)
+	// Likewise the source annotations, never kubemirror's own
+	existingAnnotations := propagatedMetadata(u, constants.AnnotationPropagateAnnotations, mirror.GetAnnotations())
+	// Nor those of other mirroring controllers, which would treat mirrors as sources
+	maps.DeleteFunc(existingAnnotations, func(key, _ string) bool { return compat.IsRecognizedAnnotation(key) })
 
 	// Add mirror-specific annotations
 	annotations := buildMirrorAnnotations(source, sourceHash)
@@ -156,6 +340,8 @@ func createUnstructuredMirror(source runtime.Object, targetNamespace, sourceHash
 	// while KubeMirror independently manages the mirrors.
 	mirror.SetOwnerReferences(nil)
 
+	sanitizeServiceAccountMirror(u, mirror)
+
 	return mirror, nil
 }
 
@@ -209,7 +395,7 @@ func UpdateMirror(mirror, source runtime.Object) error {
 		if !ok {
 			return fmt.Errorf("mirror is Secret but source is %T", source)
 		}
-		m.Data = src.Data
+		m.Data = keyfilter.Keys(keyfilter.FromAnnotations(src.Annotations), src.Data)
 		m.Type = src.Type
 		updateMirrorAnnotations(m, source, sourceHash)
 	case *corev1.ConfigMap:
@@ -217,8 +403,39 @@ func UpdateMirror(mirror, source runtime.Object) error {
 		if !ok {
 			return fmt.Errorf("mirror is ConfigMap but source is %T", source)
 		}
-		m.Data = src.Data
-		m.BinaryData = src.BinaryData
+		keys := keyfilter.FromAnnotations(src.Annotations)
+		m.Data = keyfilter.Keys(keys, src.Data)
+		m.BinaryData = keyfilter.Keys(keys, src.BinaryData)
+		updateMirrorAnnotations(m, source, sourceHash)
+	case *corev1.ServiceAccount:
+		src, ok := source.(*corev1.ServiceAccount)
+		if !ok {
+			return fmt.Errorf("mirror is ServiceAccount but source is %T", source)
+		}
+		copyServiceAccountContent(m, src)
+		updateMirrorAnnotations(m, source, sourceHash)
+	case *rbacv1.Role:
+		src, ok := source.(*rbacv1.Role)
+		if !ok {
+			return fmt.Errorf("mirror is Role but source is %T", source)
+		}
+		m.Rules = src.DeepCopy().Rules
+		updateMirrorAnnotations(m, source, sourceHash)
+	case *rbacv1.RoleBinding:
+		src, ok := source.(*rbacv1.RoleBinding)
+		if !ok {
+			return fmt.Errorf("mirror is RoleBinding but source is %T", source)
+		}
+		copied := src.DeepCopy()
+		m.Subjects = copied.Subjects
+		m.RoleRef = copied.RoleRef
+		updateMirrorAnnotations(m, source, sourceHash)
+	case *networkingv1.NetworkPolicy:
+		src, ok := source.(*networkingv1.NetworkPolicy)
+		if !ok {
+			return fmt.Errorf("mirror is NetworkPolicy but source is %T", source)
+		}
+		m.Spec = *src.Spec.DeepCopy()
 		updateMirrorAnnotations(m, source, sourceHash)
 	default:
 		// Unstructured
@@ -234,7 +451,7 @@ func UpdateMirror(mirror, source runtime.Object) error {
 		return fmt.Errorf("mirror does not implement metav1.Object, got %T", mirror)
 	}
 	targetNamespace := mirrorObj.GetNamespace()
-	transformed, err := applyTransformations(source, mirror, targetNamespace)
+	transformed, err := applyTransformations(source, mirror, targetNamespace, transformer.DefaultTransformOptions())
 	if err != nil {
 		return fmt.Errorf("transformation failed: %w", err)
 	}
@@ -248,6 +465,10 @@ func UpdateMirror(mirror, source runtime.Object) error {
 			if data, found, _ := unstructured.NestedMap(transformedU.Object, "data"); found {
 				m.Data = convertToByteMap(data)
 			}
+			// Format conversions may change the Secret type
+			if secretType, found, _ := unstructured.NestedString(transformedU.Object, "type"); found {
+				m.Type = corev1.SecretType(secretType)
+			}
 			// Copy potentially transformed labels and annotations
 			m.SetLabels(transformedU.GetLabels())
 			m.SetAnnotations(transformedU.GetAnnotations())
@@ -261,6 +482,11 @@ func UpdateMirror(mirror, source runtime.Object) error {
 			// Copy potentially transformed labels and annotations
 			m.SetLabels(transformedU.GetLabels())
 			m.SetAnnotations(transformedU.GetAnnotations())
+		case *corev1.ServiceAccount, *rbacv1.Role, *rbacv1.RoleBinding, *networkingv1.NetworkPolicy:
+			// The transformed object is a full copy of the mirror
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(transformedU.Object, m); err != nil {
+				return fmt.Errorf("failed to convert transformed mirror: %w", err)
+			}
 		case *unstructured.Unstructured:
 			// For unstructured, the transformation is already applied in-place
 			m.Object = transformedU.Object
@@ -367,6 +593,8 @@ func updateUnstructuredMirror(mirror, source runtime.Object, sourceHash string)
 			m.Object[key] = value
 		}
 	}
+	keyfilter.ApplyFilter(m, keyfilter.FromAnnotations(s.GetAnnotations()))
+	sanitizeServiceAccountMirror(s, m)
 
 	// Update annotations
 	updateMirrorAnnotations(m, source, sourceHash)
@@ -417,24 +645,46 @@ func GetSourceReference(mirror metav1.Object) (namespace, name, uid string, foun
 	return namespace, name, uid, true
 }
 
+// transformAnnotations are the source annotations the transformer reads.
+// They are copied to the mirror for the transformation and never persisted on it.
+var transformAnnotations = []string{
+	constants.AnnotationTransform,
+	constants.AnnotationTransformStrict,
+	constants.AnnotationTransformRef,
+	constants.AnnotationRenderValues,
+	constants.AnnotationInjectNamespaceKey,
+	constants.AnnotationSecretFormat,
+	constants.AnnotationHostTemplate,
+}
+
+// transformsApply reports whether mirrors of source are transformed, by rules on
+// the source, by default rules or by pruned fields for its type.
+func transformsApply(sourceObj metav1.Object, opts transformer.TransformOptions) bool {
+	if len(opts.DefaultRules) > 0 || len(opts.PruneFields) > 0 {
+		return true
+	}
+	annotations := sourceObj.GetAnnotations()
+	for _, key := range transformAnnotations {
+		if key != constants.AnnotationTransformStrict && annotations[key] != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // applyTransformations applies transformation rules from the source to the mirror.
 // Returns the transformed mirror, or the original mirror if no rules are present.
-func applyTransformations(source, mirror runtime.Object, targetNamespace string) (runtime.Object, error) {
+func applyTransformations(source, mirror runtime.Object, targetNamespace string, opts transformer.TransformOptions) (runtime.Object, error) {
 	// Get source annotations to check for transform rules
 	sourceObj, ok := source.(metav1.Object)
 	if !ok {
 		return mirror, nil
 	}
 
-	sourceAnnotations := sourceObj.GetAnnotations()
-	if sourceAnnotations == nil {
-		return mirror, nil
-	}
-
-	transformRules, hasTransform := sourceAnnotations[constants.AnnotationTransform]
-	if !hasTransform || transformRules == "" {
+	if !transformsApply(sourceObj, opts) {
 		return mirror, nil // No transformation rules
 	}
+	sourceAnnotations := sourceObj.GetAnnotations()
 
 	// Temporarily copy transform annotations to mirror for Transform to read
 	// The Transform function reads rules from the object being transformed
@@ -459,20 +709,20 @@ func applyTransformations(source, mirror runtime.Object, targetNamespace string)
 	}
 
 	// Copy transform annotations from source
-	mirrorAnnotations[constants.AnnotationTransform] = transformRules
-	if strictMode, hasStrict := sourceAnnotations[constants.AnnotationTransformStrict]; hasStrict {
-		mirrorAnnotations[constants.AnnotationTransformStrict] = strictMode
+	for _, key := range transformAnnotations {
+		if value, exists := sourceAnnotations[key]; exists {
+			mirrorAnnotations[key] = value
+		}
 	}
 	mirrorObj.SetAnnotations(mirrorAnnotations)
 
 	// Build transformation context
 	ctx := buildTransformContext(source, mirror, targetNamespace)
 
-	// Create transformer with default options
-	t := transformer.NewDefaultTransformer()
+	t := transformer.NewTransformer(opts)
 
 	// Apply transformations (transformer reads rules from mirror's annotations now)
-	transformed, err := t.Transform(mirror, ctx)
+	transformed, diag, err := t.TransformWithDiagnostics(mirror, ctx)
 	if err != nil {
 		// Restore original annotations on failure to avoid leaving mirror in inconsistent state
 		mirrorObj.SetAnnotations(savedAnnotations)
@@ -482,8 +732,12 @@ func applyTransformations(source, mirror runtime.Object, targetNamespace string)
 	// Remove transform annotations from result (they shouldn't persist on mirrors)
 	if transformedObj, ok := transformed.(metav1.Object); ok {
 		annotations := transformedObj.GetAnnotations()
-		delete(annotations, constants.AnnotationTransform)
-		delete(annotations, constants.AnnotationTransformStrict)
+		for _, key := range transformAnnotations {
+			delete(annotations, key)
+		}
+		if err := stampTransformDiagnostics(annotations, diag); err != nil {
+			return nil, err
+		}
 		transformedObj.SetAnnotations(annotations)
 	}
 
@@ -508,6 +762,7 @@ func buildTransformContext(source, mirror runtime.Object, targetNamespace string
 		for k, v := range labels {
 			ctx.Labels[k] = v
 		}
+		ctx.SourceLabels = ctx.Labels
 	}
 
 	// Copy annotations (if any)
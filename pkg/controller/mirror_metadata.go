@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+)
+
+// GitOpsIgnoreAnnotations keep Argo CD and Flux from pruning mirrors or reporting
+// them out of sync, since mirrors exist in the cluster but not in Git.
+var GitOpsIgnoreAnnotations = map[string]string{
+	"argocd.argoproj.io/compare-options": "IgnoreExtraneous",
+	"kustomize.toolkit.fluxcd.io/prune":  "disabled",
+}
+
+// stampMirrorMetadata sets the labels and annotations configured for all mirrors
+// on mirror, over any it copied from its source.
+func stampMirrorMetadata(mirror *unstructured.Unstructured, cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	if len(cfg.MirrorLabels) > 0 {
+		labels := mirror.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string, len(cfg.MirrorLabels))
+		}
+		for key, value := range cfg.MirrorLabels {
+			labels[key] = value
+		}
+		mirror.SetLabels(labels)
+	}
+	if len(cfg.MirrorAnnotations) > 0 {
+		annotations := mirror.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string, len(cfg.MirrorAnnotations))
+		}
+		for key, value := range cfg.MirrorAnnotations {
+			annotations[key] = value
+		}
+		mirror.SetAnnotations(annotations)
+	}
+}
+
+// hasMirrorMetadata reports whether mirror carries the labels and annotations
+// configured for all mirrors, so mirrors written before they were configured are
+// updated without waiting for a source change.
+func hasMirrorMetadata(mirror *unstructured.Unstructured, cfg *config.Config) bool {
+	if cfg == nil {
+		return true
+	}
+	labels, annotations := mirror.GetLabels(), mirror.GetAnnotations()
+	for key, value := range cfg.MirrorLabels {
+		if current, ok := labels[key]; !ok || current != value {
+			return false
+		}
+	}
+	for key, value := range cfg.MirrorAnnotations {
+		if current, ok := annotations[key]; !ok || current != value {
+			return false
+		}
+	}
+	return true
+}
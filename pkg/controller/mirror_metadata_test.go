@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+func TestStampMirrorMetadata(t *testing.T) {
+	cfg := &config.Config{
+		MirrorLabels:      map[string]string{"team": "platform"},
+		MirrorAnnotations: GitOpsIgnoreAnnotations,
+	}
+	mirror := makeUnstructuredMirror("app-secret", "team-a", "default", "app-secret")
+	mirror.SetLabels(map[string]string{constants.LabelManagedBy: constants.ControllerName, "team": "copied"})
+	assert.False(t, hasMirrorMetadata(mirror, cfg))
+
+	stampMirrorMetadata(mirror, cfg)
+	assert.True(t, hasMirrorMetadata(mirror, cfg))
+	assert.Equal(t, "platform", mirror.GetLabels()["team"], "configured labels win over copied ones")
+	assert.Equal(t, constants.ControllerName, mirror.GetLabels()[constants.LabelManagedBy])
+	assert.Equal(t, "IgnoreExtraneous", mirror.GetAnnotations()["argocd.argoproj.io/compare-options"])
+	assert.Equal(t, "disabled", mirror.GetAnnotations()["kustomize.toolkit.fluxcd.io/prune"])
+
+	assert.True(t, hasMirrorMetadata(mirror, nil))
+	assert.True(t, hasMirrorMetadata(mirror, &config.Config{}))
+}
+
+func TestSourceReconciler_Reconcile_StampsMirrorMetadata(t *testing.T) {
+	ctx := context.Background()
+	source := makeUnstructuredSecret("app-secret", "default", map[string]string{constants.LabelEnabled: "true"},
+		map[string]string{constants.AnnotationSync: "true", constants.AnnotationTargetNamespaces: "team-a"})
+	source.SetFinalizers([]string{constants.FinalizerName})
+	source.Object["data"] = map[string]interface{}{"key": "dmFsdWU="}
+	c := newFakeClient(t, source)
+
+	cfg := &config.Config{}
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          cfg,
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)}
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	// Configured later, the annotations reach existing mirrors without a source change
+	cfg.MirrorAnnotations = GitOpsIgnoreAnnotations
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	mirror := makeUnstructuredSecret("", "", nil, nil)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, mirror))
+	assert.Equal(t, "IgnoreExtraneous", mirror.GetAnnotations()["argocd.argoproj.io/compare-options"])
+	assert.Equal(t, "disabled", mirror.GetAnnotations()["kustomize.toolkit.fluxcd.io/prune"])
+	assert.True(t, IsManagedByUs(mirror))
+}
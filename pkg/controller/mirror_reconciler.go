@@ -2,12 +2,15 @@ package controller
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
 	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/sweeper"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -16,10 +19,24 @@ import (
 
 // MirrorReconciler reconciles mirrored resources to detect and clean up orphans.
 // This reconciler watches resources with the managed-by label and verifies their source still exists.
+// The checks are shared with the periodic sweeper (see package sweeper).
+// Mirrors whose source is valid are checked for drift and restored when edited.
 type MirrorReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	GVK    schema.GroupVersionKind // The resource type this reconciler handles
+	// Config supplies the transformation settings drifted mirrors are rebuilt with
+	Config *config.Config
+	// RuleLibrary loads the transform rule libraries sources reference (optional)
+	RuleLibrary *RuleLibrary
+	// Leadership shards writes per resource type across replicas (optional)
+	Leadership ResourceTypeLeadership
+	// NamespaceOwnership shards writes by target namespace across replicas (optional)
+	NamespaceOwnership NamespaceOwnership
+	// Recorder emits mirror lifecycle Events (optional)
+	Recorder events.EventRecorder
+	// Observer is told the outcome of every reconcile (optional)
+	Observer ReconcileObserver
 }
 
 // Reconcile checks if a mirrored resource's source still exists, and deletes the mirror if orphaned.
@@ -32,6 +49,17 @@ func (r *MirrorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		"version", r.GVK.Version,
 	)
 
+	// Another replica owns orphan cleanup for this resource type or namespace
+	if !isLeaderFor(r.Leadership, r.GVK) || !ownsNamespace(r.NamespaceOwnership, req.Namespace) {
+		return ctrl.Result{}, nil
+	}
+
+	// Paused types keep their mirrors, orphans included, until resumed
+	if scope := pauseScope(r.Config, r.GVK, nil); scope != "" {
+		logger.V(1).Info("mirroring paused, skipping mirror check", "pausedBy", scope)
+		return ctrl.Result{}, nil
+	}
+
 	// Fetch the mirror resource
 	mirror := &unstructured.Unstructured{}
 	gv := schema.GroupVersion{Group: r.GVK.Group, Version: r.GVK.Version}
@@ -42,101 +70,118 @@ func (r *MirrorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	// Extract annotations using unstructured helper methods
-	annotations := mirror.GetAnnotations()
-	if annotations == nil {
-		// No annotations - not a valid mirror, skip
-		return ctrl.Result{}, nil
+	verdict, err := sweeper.Check(ctx, r.Client, mirror)
+	if err != nil {
+		logger.Error(err, "failed to fetch source resource for mirror",
+			"sourceNamespace", verdict.Source.Namespace, "sourceName", verdict.Source.Name)
+		return ctrl.Result{}, err
 	}
 
-	// Extract source reference from annotations
-	sourceNs, hasSourceNs := annotations[constants.AnnotationSourceNamespace]
-	sourceName, hasSourceName := annotations[constants.AnnotationSourceName]
-	sourceUID, hasSourceUID := annotations[constants.AnnotationSourceUID]
+	// A paused source's mirrors are neither repaired nor cleaned up
+	if verdict.Object != nil && pauseScope(nil, r.GVK, verdict.Object) != "" {
+		logger.V(1).Info("source paused, skipping mirror check", "pausedBy", pausedBySource)
+		return ctrl.Result{}, nil
+	}
 
-	if !hasSourceNs || !hasSourceName || !hasSourceUID {
+	switch verdict.Status {
+	case sweeper.StatusUnknown:
 		// Missing source reference annotations - not a valid mirror or corrupted
 		logger.V(1).Info("mirror missing source reference annotations, skipping",
 			"namespace", req.Namespace, "name", req.Name)
 		return ctrl.Result{}, nil
-	}
-
-	// Try to fetch the source resource
-	source := &unstructured.Unstructured{}
-	source.SetGroupVersionKind(gv.WithKind(r.GVK.Kind))
-	sourceKey := types.NamespacedName{
-		Namespace: sourceNs,
-		Name:      sourceName,
-	}
-
-	err := r.Get(ctx, sourceKey, source)
-	if err != nil {
-		if client.IgnoreNotFound(err) == nil {
-			// Source not found - this is an orphaned mirror, delete it
-			logger.Info("orphaned mirror detected (source deleted), cleaning up",
-				"mirror", req.NamespacedName,
-				"sourceNamespace", sourceNs,
-				"sourceName", sourceName,
-				"sourceUID", sourceUID)
-
-			deleteErr := r.Delete(ctx, mirror)
-			if deleteErr != nil {
-				logger.Error(deleteErr, "failed to delete orphaned mirror")
-				return ctrl.Result{}, deleteErr
-			}
-
-			logger.Info("orphaned mirror deleted successfully",
-				"mirror", req.NamespacedName,
-				"sourceNamespace", sourceNs,
-				"sourceName", sourceName)
-			return ctrl.Result{}, nil
-		}
-
-		// Some other error fetching source
-		logger.Error(err, "failed to fetch source resource for mirror",
-			"sourceNamespace", sourceNs, "sourceName", sourceName)
-		return ctrl.Result{}, err
-	}
 
-	// Check if source is being deleted - if so, let the SourceReconciler handle cleanup
-	// This prevents race conditions where both reconcilers try to delete mirrors
-	if !source.GetDeletionTimestamp().IsZero() {
+	case sweeper.StatusSourceDeleting:
+		// Let the SourceReconciler handle cleanup
+		// This prevents race conditions where both reconcilers try to delete mirrors
 		logger.V(1).Info("source is being deleted, skipping mirror check (SourceReconciler will handle cleanup)",
 			"mirror", req.NamespacedName,
-			"sourceNamespace", sourceNs,
-			"sourceName", sourceName)
+			"sourceNamespace", verdict.Source.Namespace,
+			"sourceName", verdict.Source.Name)
 		return ctrl.Result{}, nil
-	}
 
-	// Source exists - verify UID matches
-	actualUID := string(source.GetUID())
-	if actualUID != sourceUID {
-		// Source was recreated with different UID - this is a stale mirror
+	case sweeper.StatusOrphaned:
+		logger.Info("orphaned mirror detected (source deleted), cleaning up",
+			"mirror", req.NamespacedName,
+			"sourceNamespace", verdict.Source.Namespace,
+			"sourceName", verdict.Source.Name,
+			"sourceUID", verdict.ExpectedUID)
+
+	case sweeper.StatusStale:
 		logger.Info("stale mirror detected (source recreated with different UID), cleaning up",
 			"mirror", req.NamespacedName,
-			"sourceNamespace", sourceNs,
-			"sourceName", sourceName,
-			"expectedUID", sourceUID,
-			"actualUID", actualUID)
+			"sourceNamespace", verdict.Source.Namespace,
+			"sourceName", verdict.Source.Name,
+			"expectedUID", verdict.ExpectedUID,
+			"actualUID", verdict.ActualUID)
 
-		if err := r.Delete(ctx, mirror); err != nil {
-			logger.Error(err, "failed to delete stale mirror")
+	case sweeper.StatusRenamed:
+		logger.Info("mirror left under a previous name (source renamed its mirrors), cleaning up",
+			"mirror", req.NamespacedName,
+			"sourceNamespace", verdict.Source.Namespace,
+			"sourceName", verdict.Source.Name)
+
+	default:
+		// Source exists and UID matches - mirror is valid
+		logger.V(1).Info("mirror source verified",
+			"mirror", req.NamespacedName,
+			"sourceNamespace", verdict.Source.Namespace,
+			"sourceName", verdict.Source.Name)
+		if err := r.repairDrift(log.IntoContext(ctx, logger), verdict.Object, mirror); err != nil {
+			logger.Error(err, "failed to check mirror for drift")
 			return ctrl.Result{}, err
 		}
+		return ctrl.Result{}, nil
+	}
 
-		logger.Info("stale mirror deleted successfully",
-			"mirror", req.NamespacedName,
-			"sourceNamespace", sourceNs,
-			"sourceName", sourceName)
+	// An existing source hears about it; an orphan's source is gone, so the
+	// Event goes on the mirror in its own namespace instead
+	var regarding runtime.Object
+	var why string
+	switch verdict.Status {
+	case sweeper.StatusStale:
+		regarding = sourceReference(r.GVK, verdict.Source, verdict.ActualUID)
+		why = "mirror belonged to a previous source with the same name"
+	case sweeper.StatusRenamed:
+		regarding = sourceReference(r.GVK, verdict.Source, verdict.ActualUID)
+		why = fmt.Sprintf("mirror %s was left under a previous name", req.Name)
+	default:
+		regarding = mirror
+		why = "source was deleted"
+	}
+
+	// An orphan has no source left to opt in, so only --dry-run applies to it
+	dryRun := r.Config != nil && r.Config.DryRun
+	if verdict.Object != nil {
+		dryRun = isDryRun(r.Config, verdict.Object)
+	}
+	if dryRun {
+		reportDryRun(ctx, r.Recorder, regarding, r.GVK, dryRunDelete, req.Namespace, why)
 		return ctrl.Result{}, nil
 	}
 
-	// Source exists and UID matches - mirror is valid
-	logger.V(1).Info("mirror source verified",
-		"mirror", req.NamespacedName,
-		"sourceNamespace", sourceNs,
-		"sourceName", sourceName)
+	if err := detachMirror(ctx, r.Client, mirror); err != nil {
+		logger.Error(err, "failed to detach mirror from service accounts")
+		return ctrl.Result{}, err
+	}
+	if err := r.Delete(ctx, mirror); err != nil {
+		logger.Error(err, "failed to delete mirror", "status", verdict.Status.String())
+		return ctrl.Result{}, err
+	}
+	recordMirrorDeleted(r.Recorder, regarding, req.Namespace, why)
 
+	// An orphan's binding goes with it; the binding of a stale or renamed mirror
+	// belongs to the current source
+	if verdict.Status == sweeper.StatusOrphaned {
+		if err := deleteBindingOwners(ctx, r.Client, mirror); err != nil {
+			logger.Error(err, "failed to delete mirror binding of orphaned mirror")
+		}
+	}
+
+	logger.Info("mirror deleted successfully",
+		"mirror", req.NamespacedName,
+		"status", verdict.Status.String(),
+		"sourceNamespace", verdict.Source.Namespace,
+		"sourceName", verdict.Source.Name)
 	return ctrl.Result{}, nil
 }
 
@@ -164,6 +209,7 @@ func (r *MirrorReconciler) SetupWithManager(mgr ctrl.Manager, gvk schema.GroupVe
 	return ctrl.NewControllerManagedBy(mgr).
 		For(obj).
 		Named(controllerName).
+		WithOptions(controllerOptions(r.Config, gvk, r.Leadership != nil || r.NamespaceOwnership != nil)).
 		WithEventFilter(managedByPredicate).
-		Complete(r)
+		Complete(observeReconciler(r, controllerName, r.Observer))
 }
@@ -1,15 +1,20 @@
 package controller
 
 import (
+	"encoding/base64"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/transformer"
 )
 
 func TestCreateMirror_Secret(t *testing.T) {
@@ -697,6 +702,43 @@ func TestCreateMirror_NoSyncAnnotations(t *testing.T) {
 
 // Benchmarks for critical paths
 
+func TestCreateMirror_RenderValues(t *testing.T) {
+	source := &unstructured.Unstructured{}
+	source.SetAPIVersion("v1")
+	source.SetKind("ConfigMap")
+	source.SetName("app-config")
+	source.SetNamespace("default")
+	source.SetAnnotations(map[string]string{
+		constants.AnnotationSync:         "true",
+		constants.AnnotationRenderValues: "true",
+	})
+	require.NoError(t, unstructured.SetNestedStringMap(source.Object, map[string]string{
+		"API_URL": "https://{{ .TargetNamespace }}.api.example.com",
+	}, "data"))
+
+	mirror, err := CreateMirror(source, "prod")
+	require.NoError(t, err)
+
+	u := mirror.(*unstructured.Unstructured)
+	value, _, _ := unstructured.NestedString(u.Object, "data", "API_URL")
+	assert.Equal(t, "https://prod.api.example.com", value)
+	assert.NotContains(t, u.GetAnnotations(), constants.AnnotationRenderValues)
+}
+
+func TestCreateMirror_InjectNamespaceKey(t *testing.T) {
+	source := makeUnstructuredSecret("app-secret", "default", nil, map[string]string{
+		constants.AnnotationInjectNamespaceKey: "NAMESPACE",
+	})
+
+	mirror, err := CreateMirror(source, "team-a")
+	require.NoError(t, err)
+
+	u := mirror.(*unstructured.Unstructured)
+	value, _, _ := unstructured.NestedString(u.Object, "data", "NAMESPACE")
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("team-a")), value)
+	assert.NotContains(t, u.GetAnnotations(), constants.AnnotationInjectNamespaceKey)
+}
+
 func BenchmarkCreateMirror_Secret(b *testing.B) {
 	source := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -878,3 +920,314 @@ func BenchmarkGetSourceReference(b *testing.B) {
 		_, _, _, _ = GetSourceReference(obj)
 	}
 }
+
+func TestCreateMirror_TargetName(t *testing.T) {
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "shared-tls",
+			Namespace: "default",
+			Annotations: map[string]string{
+				constants.AnnotationTargetNamePrefix: "team-a-",
+			},
+		},
+	}
+
+	mirror, err := CreateMirror(source, "team-a")
+	require.NoError(t, err)
+	secretMirror := mirror.(*corev1.Secret)
+	assert.Equal(t, "team-a-shared-tls", secretMirror.Name)
+	// The source reference keeps the original name
+	assert.Equal(t, "shared-tls", secretMirror.Annotations[constants.AnnotationSourceName])
+
+	source.Annotations[constants.AnnotationTargetName] = "Invalid_Name"
+	_, err = CreateMirror(source, "team-a")
+	assert.ErrorContains(t, err, "invalid")
+}
+
+func TestCreateMirror_FilteredKeys(t *testing.T) {
+	annotations := map[string]string{
+		constants.AnnotationIncludeKeys: "tls.*,ca.crt",
+		constants.AnnotationExcludeKeys: "tls.key",
+	}
+
+	t.Run("typed", func(t *testing.T) {
+		source := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "default", Annotations: annotations},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{"tls.crt": []byte("crt"), "tls.key": []byte("key"), "ca.crt": []byte("ca"), "extra": []byte("x")},
+		}
+		mirror, err := CreateMirror(source, "app1")
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{"tls.crt": []byte("crt"), "ca.crt": []byte("ca")}, mirror.(*corev1.Secret).Data)
+		assert.Len(t, source.Data, 4, "source is not modified")
+	})
+
+	t.Run("unstructured", func(t *testing.T) {
+		source := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"type":       "kubernetes.io/tls",
+			"data":       map[string]interface{}{"tls.crt": "Y3J0", "tls.key": "a2V5", "ca.crt": "Y2E="},
+		}}
+		source.SetName("tls")
+		source.SetNamespace("default")
+		source.SetAnnotations(annotations)
+
+		mirror, err := CreateMirror(source, "app1")
+		require.NoError(t, err)
+		data, _, _ := unstructured.NestedMap(mirror.(*unstructured.Unstructured).Object, "data")
+		assert.Equal(t, map[string]interface{}{"tls.crt": "Y3J0", "ca.crt": "Y2E="}, data)
+
+		// Updating keeps the filter, and does not touch the source's data
+		existing := mirror.(*unstructured.Unstructured)
+		require.NoError(t, UpdateMirror(existing, source))
+		data, _, _ = unstructured.NestedMap(existing.Object, "data")
+		assert.Equal(t, map[string]interface{}{"tls.crt": "Y3J0", "ca.crt": "Y2E="}, data)
+		sourceData, _, _ := unstructured.NestedMap(source.Object, "data")
+		assert.Len(t, sourceData, 3)
+	})
+}
+
+func TestCreateMirror_PropagatedMetadata(t *testing.T) {
+	labels := map[string]string{
+		"app":                  "web",
+		"team":                 "payments",
+		"tier":                 "frontend",
+		constants.LabelEnabled: "true",
+	}
+	annotations := map[string]string{
+		constants.AnnotationSync:                 "true",
+		constants.AnnotationPropagateLabels:      "app,team",
+		constants.AnnotationPropagateAnnotations: "prometheus.io/*",
+		"prometheus.io/scrape":                   "true",
+		"owner":                                  "alice",
+	}
+
+	t.Run("typed", func(t *testing.T) {
+		source := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", Labels: labels, Annotations: annotations},
+		}
+		mirror, err := CreateMirror(source, "app1")
+		require.NoError(t, err)
+		cm := mirror.(*corev1.ConfigMap)
+		assert.Equal(t, map[string]string{
+			"app":                    "web",
+			"team":                   "payments",
+			constants.LabelManagedBy: constants.ControllerName,
+			constants.LabelMirror:    "true",
+		}, cm.Labels)
+		assert.Equal(t, "true", cm.Annotations["prometheus.io/scrape"])
+		assert.NotContains(t, cm.Annotations, "owner")
+		assert.NotContains(t, cm.Annotations, constants.AnnotationPropagateLabels)
+	})
+
+	t.Run("unstructured", func(t *testing.T) {
+		source := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"data":       map[string]interface{}{"key": "value"},
+		}}
+		source.SetName("app")
+		source.SetNamespace("default")
+		source.SetLabels(labels)
+		source.SetAnnotations(annotations)
+
+		mirror, err := CreateMirror(source, "app1")
+		require.NoError(t, err)
+		u := mirror.(*unstructured.Unstructured)
+		assert.Equal(t, map[string]string{
+			"app":                    "web",
+			"team":                   "payments",
+			constants.LabelManagedBy: constants.ControllerName,
+			constants.LabelMirror:    "true",
+		}, u.GetLabels())
+		assert.Equal(t, "true", u.GetAnnotations()["prometheus.io/scrape"])
+		assert.NotContains(t, u.GetAnnotations(), "owner")
+		assert.Equal(t, "web", source.GetLabels()["app"], "source is not modified")
+	})
+
+	t.Run("unstructured without selection copies everything", func(t *testing.T) {
+		source := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+		}}
+		source.SetName("app")
+		source.SetNamespace("default")
+		source.SetLabels(labels)
+		source.SetAnnotations(map[string]string{constants.AnnotationSync: "true", "owner": "alice"})
+
+		mirror, err := CreateMirror(source, "app1")
+		require.NoError(t, err)
+		u := mirror.(*unstructured.Unstructured)
+		assert.Equal(t, "frontend", u.GetLabels()["tier"])
+		assert.NotContains(t, u.GetLabels(), constants.LabelEnabled)
+		assert.Equal(t, "alice", u.GetAnnotations()["owner"])
+	})
+}
+
+func TestCreateMirror_PruneFields(t *testing.T) {
+	source := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"spec": map[string]interface{}{
+			"type":      "LoadBalancer",
+			"clusterIP": "10.0.0.1",
+			"ports":     []interface{}{map[string]interface{}{"port": int64(80), "nodePort": int64(30080)}},
+		},
+	}}
+	source.SetName("web")
+	source.SetNamespace("default")
+
+	opts := transformer.DefaultTransformOptions()
+	opts.PruneFields = []string{"spec.clusterIP", "spec.ports[*].nodePort"}
+	mirror, err := CreateMirrorWithOptions(source, "app1", opts)
+	require.NoError(t, err)
+
+	spec, _, _ := unstructured.NestedMap(mirror.(*unstructured.Unstructured).Object, "spec")
+	assert.Equal(t, map[string]interface{}{
+		"type":  "LoadBalancer",
+		"ports": []interface{}{map[string]interface{}{"port": int64(80)}},
+	}, spec)
+	assert.NotEmpty(t, mirror.(*unstructured.Unstructured).GetAnnotations()[constants.AnnotationMirrorContentHash],
+		"pruned mirrors are compared by their transformed content")
+	clusterIP, _, _ := unstructured.NestedString(source.Object, "spec", "clusterIP")
+	assert.Equal(t, "10.0.0.1", clusterIP, "source is not modified")
+}
+
+func TestCreateMirror_TypedFastPaths(t *testing.T) {
+	automount := false
+	meta := metav1.ObjectMeta{
+		Name:      "shared",
+		Namespace: "default",
+		UID:       "source-uid",
+		Labels:    map[string]string{"app": "web"},
+	}
+
+	tests := []struct {
+		source runtime.Object
+		check  func(t *testing.T, mirror runtime.Object)
+		name   string
+	}{
+		{
+			name: "ServiceAccount drops secrets",
+			source: &corev1.ServiceAccount{
+				ObjectMeta:                   meta,
+				Secrets:                      []corev1.ObjectReference{{Name: "shared-token"}},
+				ImagePullSecrets:             []corev1.LocalObjectReference{{Name: "registry"}},
+				AutomountServiceAccountToken: &automount,
+			},
+			check: func(t *testing.T, mirror runtime.Object) {
+				sa, ok := mirror.(*corev1.ServiceAccount)
+				require.True(t, ok, "mirror should be a ServiceAccount")
+				assert.Empty(t, sa.Secrets)
+				assert.Empty(t, sa.ImagePullSecrets)
+				assert.Equal(t, &automount, sa.AutomountServiceAccountToken)
+			},
+		},
+		{
+			name: "Role copies rules",
+			source: &rbacv1.Role{
+				ObjectMeta: meta,
+				Rules: []rbacv1.PolicyRule{{
+					APIGroups: []string{""},
+					Resources: []string{"configmaps"},
+					Verbs:     []string{"get"},
+				}},
+			},
+			check: func(t *testing.T, mirror runtime.Object) {
+				role, ok := mirror.(*rbacv1.Role)
+				require.True(t, ok, "mirror should be a Role")
+				assert.Equal(t, []string{"configmaps"}, role.Rules[0].Resources)
+			},
+		},
+		{
+			name: "RoleBinding copies subjects and roleRef",
+			source: &rbacv1.RoleBinding{
+				ObjectMeta: meta,
+				Subjects:   []rbacv1.Subject{{Kind: "Group", Name: "devs"}},
+				RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "view"},
+			},
+			check: func(t *testing.T, mirror runtime.Object) {
+				binding, ok := mirror.(*rbacv1.RoleBinding)
+				require.True(t, ok, "mirror should be a RoleBinding")
+				assert.Equal(t, "devs", binding.Subjects[0].Name)
+				assert.Equal(t, "view", binding.RoleRef.Name)
+			},
+		},
+		{
+			name: "NetworkPolicy copies spec",
+			source: &networkingv1.NetworkPolicy{
+				ObjectMeta: meta,
+				Spec: networkingv1.NetworkPolicySpec{
+					PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				},
+			},
+			check: func(t *testing.T, mirror runtime.Object) {
+				policy, ok := mirror.(*networkingv1.NetworkPolicy)
+				require.True(t, ok, "mirror should be a NetworkPolicy")
+				assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, policy.Spec.PolicyTypes)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mirror, err := CreateMirror(tt.source, "app1")
+			require.NoError(t, err)
+			tt.check(t, mirror)
+
+			mirrorObj, ok := mirror.(metav1.Object)
+			require.True(t, ok)
+			assert.Equal(t, "shared", mirrorObj.GetName())
+			assert.Equal(t, "app1", mirrorObj.GetNamespace())
+			assert.Equal(t, "true", mirrorObj.GetLabels()[constants.LabelMirror])
+			assert.Equal(t, "source-uid", mirrorObj.GetAnnotations()[constants.AnnotationSourceUID])
+			assert.NotEmpty(t, mirrorObj.GetAnnotations()[constants.AnnotationSourceContentHash])
+		})
+	}
+}
+
+func TestCreateMirror_TypedServiceAccountImagePullSecrets(t *testing.T) {
+	source := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "builder",
+			Namespace:   "default",
+			Annotations: map[string]string{constants.AnnotationMirrorImagePullSecrets: "true"},
+		},
+		Secrets:          []corev1.ObjectReference{{Name: "builder-token"}},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+	}
+
+	mirror, err := CreateMirror(source, "app1")
+	require.NoError(t, err)
+
+	sa, ok := mirror.(*corev1.ServiceAccount)
+	require.True(t, ok, "mirror should be a ServiceAccount")
+	assert.Empty(t, sa.Secrets)
+	assert.Equal(t, source.ImagePullSecrets, sa.ImagePullSecrets)
+}
+
+func TestUpdateMirror_TypedFastPaths(t *testing.T) {
+	source := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "view", Namespace: "default", Generation: 3},
+		Subjects:   []rbacv1.Subject{{Kind: "Group", Name: "ops"}},
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "view"},
+	}
+	mirror := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "view",
+			Namespace:   "app1",
+			Annotations: map[string]string{constants.AnnotationSourceContentHash: "oldhash"},
+		},
+		Subjects: []rbacv1.Subject{{Kind: "Group", Name: "devs"}},
+	}
+
+	require.NoError(t, UpdateMirror(mirror, source))
+	assert.Equal(t, source.Subjects, mirror.Subjects)
+	assert.Equal(t, source.RoleRef, mirror.RoleRef)
+	assert.NotEqual(t, "oldhash", mirror.Annotations[constants.AnnotationSourceContentHash])
+	assert.Equal(t, "3", mirror.Annotations[constants.AnnotationSourceGeneration])
+
+	err := UpdateMirror(mirror, &rbacv1.Role{})
+	assert.ErrorContains(t, err, "mirror is RoleBinding")
+}
@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"maps"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+// Event reasons used when creating missing target namespaces.
+const (
+	ReasonNamespaceCreated          = "NamespaceCreated"
+	ReasonNamespaceCreationFailed   = "NamespaceCreationFailed"
+	ReasonNamespaceCreationDisabled = "NamespaceCreationDisabled"
+)
+
+// createsMissingNamespaces reports whether source asks for its missing target
+// namespaces to be created.
+func createsMissingNamespaces(source *unstructured.Unstructured) bool {
+	return source.GetAnnotations()[constants.AnnotationCreateMissingNamespaces] == "true"
+}
+
+// listedNamespaces returns the namespaces named in source's target-namespaces
+// annotation, leaving out keywords and glob patterns, which only match namespaces
+// that already exist.
+func listedNamespaces(source *unstructured.Unstructured) map[string]bool {
+	listed := make(map[string]bool)
+	for _, pattern := range filter.ParseTargetNamespaces(source.GetAnnotations()[constants.AnnotationTargetNamespaces]) {
+		if pattern == constants.TargetNamespacesAll || pattern == constants.TargetNamespacesAllLabeled ||
+			pattern == constants.TargetNamespacesDescendants || strings.ContainsAny(pattern, "*?") || len(validation.IsDNS1123Label(pattern)) > 0 {
+			continue
+		}
+		listed[pattern] = true
+	}
+	return listed
+}
+
+// createMissingNamespaces creates the targets listed by name in source's
+// target-namespaces annotation that do not exist yet, when the source asks for it.
+// Failures are reported as Events; the mirror writes into those targets then fail
+// and are retried.
+func (r *SourceReconciler) createMissingNamespaces(ctx context.Context, source *unstructured.Unstructured, targets []string) {
+	if !createsMissingNamespaces(source) || len(targets) == 0 {
+		return
+	}
+	if r.Config == nil || !r.Config.AllowNamespaceCreation {
+		r.recordEvent(source, corev1.EventTypeWarning, ReasonNamespaceCreationDisabled, "CreateNamespace",
+			"Not creating missing target namespaces: the controller runs without --allow-namespace-creation")
+		return
+	}
+
+	listed := listedNamespaces(source)
+	if len(listed) == 0 {
+		return
+	}
+	logger := log.FromContext(ctx)
+	existing, err := r.NamespaceLister.ListNamespaces(ctx)
+	if err != nil {
+		logger.Error(err, "failed to list namespaces, not creating missing target namespaces")
+		return
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, ns := range existing {
+		exists[ns] = true
+	}
+
+	for _, ns := range targets {
+		if !listed[ns] || exists[ns] {
+			continue
+		}
+		if r.dryRun(source) {
+			logger.Info("dry run, not creating target namespace", "targetNamespace", ns)
+			r.recordEvent(source, corev1.EventTypeNormal, ReasonDryRun, "DryRun", "Dry run: would create namespace %s", ns)
+			continue
+		}
+
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        ns,
+			Labels:      maps.Clone(r.Config.CreatedNamespaceLabels),
+			Annotations: maps.Clone(r.Config.CreatedNamespaceAnnotations),
+		}}
+		if namespace.Annotations == nil {
+			namespace.Annotations = make(map[string]string, 1)
+		}
+		namespace.Annotations[constants.AnnotationCreatedFor] = source.GetKind() + " " + source.GetNamespace() + "/" + source.GetName()
+
+		err := r.Create(ctx, namespace)
+		switch {
+		case apierrors.IsAlreadyExists(err):
+			// Created meanwhile by someone else
+		case err != nil:
+			logger.Error(err, "failed to create target namespace", "targetNamespace", ns)
+			r.recordEvent(source, corev1.EventTypeWarning, ReasonNamespaceCreationFailed, "CreateNamespace",
+				"Failed to create namespace %s: %v", ns, err)
+		default:
+			logger.Info("created missing target namespace", "targetNamespace", ns)
+			r.recordEvent(source, corev1.EventTypeNormal, ReasonNamespaceCreated, "CreateNamespace", "Created namespace %s", ns)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+func makeBootstrapSource(targets string) *unstructured.Unstructured {
+	source := makeUnstructuredSecret("app-secret", "default", map[string]string{constants.LabelEnabled: "true"},
+		map[string]string{
+			constants.AnnotationSync:                    "true",
+			constants.AnnotationTargetNamespaces:        targets,
+			constants.AnnotationCreateMissingNamespaces: "true",
+		})
+	source.SetFinalizers([]string{constants.FinalizerName})
+	source.Object["data"] = map[string]interface{}{"key": "dmFsdWU="}
+	return source
+}
+
+func TestListedNamespaces(t *testing.T) {
+	source := makeBootstrapSource("team-a, app-*,Invalid_Name,team-?,new-team")
+	assert.Equal(t, map[string]bool{"team-a": true, "new-team": true}, listedNamespaces(source))
+
+	assert.Empty(t, listedNamespaces(makeBootstrapSource("all")))
+	assert.Empty(t, listedNamespaces(makeBootstrapSource("all-labeled")))
+}
+
+func TestSourceReconciler_Reconcile_CreateMissingNamespaces(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *config.Config
+		wantCreated bool
+	}{
+		{
+			name: "allowed",
+			config: &config.Config{
+				AllowNamespaceCreation:      true,
+				CreatedNamespaceLabels:      map[string]string{"team": "payments"},
+				CreatedNamespaceAnnotations: map[string]string{"owner": "platform"},
+			},
+			wantCreated: true,
+		},
+		{name: "not allowed by the controller", config: &config.Config{}},
+		{name: "dry run", config: &config.Config{AllowNamespaceCreation: true, DryRun: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			source := makeBootstrapSource("team-a,new-team,app-*")
+			c := newFakeClient(t, source)
+			r := &SourceReconciler{
+				Client:          c,
+				Config:          tt.config,
+				Filter:          filter.NewNamespaceFilter(nil, nil),
+				NamespaceLister: NewKubernetesNamespaceLister(c),
+				GVK:             secretGVK,
+			}
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+			require.NoError(t, err)
+
+			namespace := &corev1.Namespace{}
+			err = c.Get(ctx, client.ObjectKey{Name: "new-team"}, namespace)
+			if !tt.wantCreated {
+				assert.True(t, apierrors.IsNotFound(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "payments", namespace.Labels["team"])
+			assert.Equal(t, "platform", namespace.Annotations["owner"])
+			assert.Equal(t, "Secret default/app-secret", namespace.Annotations[constants.AnnotationCreatedFor])
+			assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "new-team", Name: "app-secret"}, makeUnstructuredSecret("", "", nil, nil)))
+			assert.NotContains(t, tt.config.CreatedNamespaceAnnotations, constants.AnnotationCreatedFor, "the configured annotations are not modified")
+		})
+	}
+}
+
+func TestSourceReconciler_CreateMissingNamespacesRespectsFilter(t *testing.T) {
+	ctx := context.Background()
+	source := makeBootstrapSource("kube-system-copy,new-team")
+	c := newFakeClient(t, source)
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{AllowNamespaceCreation: true},
+		Filter:          filter.NewNamespaceFilter([]string{"kube-system-copy"}, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+	}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+	require.NoError(t, err)
+
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Name: "kube-system-copy"}, &corev1.Namespace{})),
+		"excluded namespaces are never created")
+	assert.NoError(t, c.Get(ctx, client.ObjectKey{Name: "new-team"}, &corev1.Namespace{}))
+}
@@ -3,8 +3,15 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/lukaszraczylo/kubemirror/pkg/constants"
@@ -105,6 +112,23 @@ func (k *KubernetesNamespaceLister) ListOptOutNamespaces(ctx context.Context) ([
 	return names, nil
 }
 
+// ListTerminatingNamespaces returns the namespaces being deleted.
+func (k *KubernetesNamespaceLister) ListTerminatingNamespaces(ctx context.Context) ([]string, error) {
+	namespaceList := &corev1.NamespaceList{}
+	if err := k.getReader().List(ctx, namespaceList); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	for i := range namespaceList.Items {
+		if isTerminating(&namespaceList.Items[i]) {
+			names = append(names, namespaceList.Items[i].Name)
+		}
+	}
+
+	return names, nil
+}
+
 // NamespaceInfo contains categorized namespace information from a single API call.
 // This is more efficient than making 3 separate API calls.
 type NamespaceInfo struct {
@@ -114,10 +138,22 @@ type NamespaceInfo struct {
 	AllowMirrors []string
 	// OptOut contains namespaces with allow-mirrors="false" label
 	OptOut []string
+	// Labels contains the labels of every namespace, by name
+	Labels map[string]map[string]string
+	// Created contains the creation time of every namespace, by name (optional)
+	Created map[string]time.Time
+	// Tenants indexes namespace names, sorted, by their tenant label
+	Tenants map[string][]string
 }
 
-// ListNamespacesWithLabels returns all namespaces categorized by their allow-mirrors label
-// in a single API call. This is more efficient than calling ListNamespaces,
+// isTerminating reports whether a namespace is being deleted. The API server
+// rejects new objects in it until it is gone, and takes its mirrors with it.
+func isTerminating(ns *corev1.Namespace) bool {
+	return ns.DeletionTimestamp != nil || ns.Status.Phase == corev1.NamespaceTerminating
+}
+
+// ListNamespacesWithLabels returns all namespaces categorized by their allow-mirrors label,
+// along with their labels for target-namespace-selector, in a single API call. This is more efficient than calling ListNamespaces,
 // ListAllowMirrorsNamespaces, and ListOptOutNamespaces separately.
 // Uses direct API reads if apiReader is configured to ensure fresh data.
 func (k *KubernetesNamespaceLister) ListNamespacesWithLabels(ctx context.Context) (*NamespaceInfo, error) {
@@ -133,10 +169,18 @@ func (k *KubernetesNamespaceLister) ListNamespacesWithLabels(ctx context.Context
 		All:          make([]string, 0, len(namespaceList.Items)),
 		AllowMirrors: make([]string, 0),
 		OptOut:       make([]string, 0),
+		Labels:       make(map[string]map[string]string, len(namespaceList.Items)),
+		Created:      make(map[string]time.Time, len(namespaceList.Items)),
+		Tenants:      make(map[string][]string),
 	}
 
 	for _, ns := range namespaceList.Items {
 		info.All = append(info.All, ns.Name)
+		info.Labels[ns.Name] = ns.Labels
+		info.Created[ns.Name] = ns.CreationTimestamp.Time
+		if tenant := ns.Labels[constants.LabelTenant]; tenant != "" {
+			info.Tenants[tenant] = append(info.Tenants[tenant], ns.Name)
+		}
 
 		// Check allow-mirrors label value
 		if ns.Labels != nil {
@@ -152,3 +196,240 @@ func (k *KubernetesNamespaceLister) ListNamespacesWithLabels(ctx context.Context
 
 	return info, nil
 }
+
+// StaticNamespaceLister implements NamespaceLister over a fixed list of namespaces,
+// for a controller whose RBAC is limited to them and which cannot read Namespace
+// objects. No namespace carries labels, so none has opted in or out of mirrors.
+type StaticNamespaceLister struct {
+	namespaces []string
+}
+
+// NewStaticNamespaceLister creates a StaticNamespaceLister listing namespaces.
+func NewStaticNamespaceLister(namespaces []string) *StaticNamespaceLister {
+	return &StaticNamespaceLister{namespaces: append([]string(nil), namespaces...)}
+}
+
+// ListNamespaces returns the configured namespaces.
+func (s *StaticNamespaceLister) ListNamespaces(_ context.Context) ([]string, error) {
+	return append([]string(nil), s.namespaces...), nil
+}
+
+// ListAllowMirrorsNamespaces returns no namespaces, as labels cannot be read.
+func (s *StaticNamespaceLister) ListAllowMirrorsNamespaces(_ context.Context) ([]string, error) {
+	return []string{}, nil
+}
+
+// ListOptOutNamespaces returns no namespaces, as labels cannot be read.
+func (s *StaticNamespaceLister) ListOptOutNamespaces(_ context.Context) ([]string, error) {
+	return []string{}, nil
+}
+
+// ListTerminatingNamespaces returns no namespaces, as their status cannot be read.
+func (s *StaticNamespaceLister) ListTerminatingNamespaces(_ context.Context) ([]string, error) {
+	return []string{}, nil
+}
+
+// ListNamespacesWithLabels returns the configured namespaces, none of them labeled.
+func (s *StaticNamespaceLister) ListNamespacesWithLabels(_ context.Context) (*NamespaceInfo, error) {
+	info := &NamespaceInfo{
+		All:          append([]string(nil), s.namespaces...),
+		AllowMirrors: make([]string, 0),
+		OptOut:       make([]string, 0),
+		Labels:       make(map[string]map[string]string, len(s.namespaces)),
+		Tenants:      make(map[string][]string),
+	}
+	for _, ns := range s.namespaces {
+		info.Labels[ns] = map[string]string{}
+	}
+	return info, nil
+}
+
+// CachedNamespaceLister implements NamespaceLister from memory. It keeps the
+// labels and creation time of every namespace, the names that opted in to or out
+// of mirrors, the namespaces being deleted and an index of namespaces by tenant up
+// to date from the events of a Namespace informer, so listing namespaces costs no
+// API request however many sources are reconciled. Until the informer has synced
+// it answers from fallback.
+type CachedNamespaceLister struct {
+	fallback     NamespaceLister
+	registration toolscache.ResourceEventHandlerRegistration
+	labels       map[string]map[string]string
+	created      map[string]time.Time
+	allowMirrors map[string]bool
+	optOut       map[string]bool
+	terminating  map[string]bool
+	// tenants indexes namespace names by their tenant label
+	tenants map[string]map[string]bool
+	mu      sync.RWMutex
+}
+
+// NewCachedNamespaceLister creates a CachedNamespaceLister fed by informer, a
+// Namespace informer such as the manager cache's.
+func NewCachedNamespaceLister(informer cache.Informer, fallback NamespaceLister) (*CachedNamespaceLister, error) {
+	l := &CachedNamespaceLister{
+		fallback:     fallback,
+		labels:       make(map[string]map[string]string),
+		created:      make(map[string]time.Time),
+		allowMirrors: make(map[string]bool),
+		optOut:       make(map[string]bool),
+		terminating:  make(map[string]bool),
+		tenants:      make(map[string]map[string]bool),
+	}
+	registration, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    l.set,
+		UpdateFunc: func(_, obj interface{}) { l.set(obj) },
+		DeleteFunc: l.remove,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch namespaces: %w", err)
+	}
+	l.registration = registration
+	return l, nil
+}
+
+// set records the labels of a namespace that was added or updated.
+func (l *CachedNamespaceLister) set(obj interface{}) {
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.untenant(ns.Name)
+	if tenant := ns.Labels[constants.LabelTenant]; tenant != "" {
+		if l.tenants[tenant] == nil {
+			l.tenants[tenant] = make(map[string]bool)
+		}
+		l.tenants[tenant][ns.Name] = true
+	}
+	l.labels[ns.Name] = maps.Clone(ns.Labels)
+	l.created[ns.Name] = ns.CreationTimestamp.Time
+	delete(l.allowMirrors, ns.Name)
+	delete(l.optOut, ns.Name)
+	delete(l.terminating, ns.Name)
+	if isTerminating(ns) {
+		l.terminating[ns.Name] = true
+	}
+	switch ns.Labels[constants.LabelAllowMirrors] {
+	case "true":
+		l.allowMirrors[ns.Name] = true
+	case "false":
+		l.optOut[ns.Name] = true
+	}
+}
+
+// remove forgets a deleted namespace.
+func (l *CachedNamespaceLister) remove(obj interface{}) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.untenant(ns.Name)
+	delete(l.labels, ns.Name)
+	delete(l.created, ns.Name)
+	delete(l.allowMirrors, ns.Name)
+	delete(l.optOut, ns.Name)
+	delete(l.terminating, ns.Name)
+}
+
+// untenant drops a namespace from the tenant index, by the labels it was last
+// seen with. Callers hold the lock.
+func (l *CachedNamespaceLister) untenant(name string) {
+	tenant := l.labels[name][constants.LabelTenant]
+	if members := l.tenants[tenant]; members != nil {
+		delete(members, name)
+		if len(members) == 0 {
+			delete(l.tenants, tenant)
+		}
+	}
+}
+
+// synced reports whether the informer has delivered every existing namespace.
+func (l *CachedNamespaceLister) synced() bool {
+	return l.registration == nil || l.registration.HasSynced()
+}
+
+// sortedNames returns the names of set in order, like an API list returns them.
+func sortedNames[V any](set map[string]V) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ListNamespaces returns all namespace names in the cluster.
+func (l *CachedNamespaceLister) ListNamespaces(ctx context.Context) ([]string, error) {
+	if !l.synced() {
+		return l.fallback.ListNamespaces(ctx)
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return sortedNames(l.labels), nil
+}
+
+// ListAllowMirrorsNamespaces returns namespaces that have the allow-mirrors label.
+func (l *CachedNamespaceLister) ListAllowMirrorsNamespaces(ctx context.Context) ([]string, error) {
+	if !l.synced() {
+		return l.fallback.ListAllowMirrorsNamespaces(ctx)
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return sortedNames(l.allowMirrors), nil
+}
+
+// ListOptOutNamespaces returns namespaces that have explicitly opted out of mirrors.
+func (l *CachedNamespaceLister) ListOptOutNamespaces(ctx context.Context) ([]string, error) {
+	if !l.synced() {
+		return l.fallback.ListOptOutNamespaces(ctx)
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return sortedNames(l.optOut), nil
+}
+
+// ListTerminatingNamespaces returns the namespaces being deleted. Unlike
+// ListNamespacesWithLabels it copies nothing but their names.
+func (l *CachedNamespaceLister) ListTerminatingNamespaces(ctx context.Context) ([]string, error) {
+	if !l.synced() {
+		return l.fallback.ListTerminatingNamespaces(ctx)
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return sortedNames(l.terminating), nil
+}
+
+// ListNamespacesWithLabels returns all namespaces categorized by their allow-mirrors
+// label, along with their labels. Callers must not modify the returned labels.
+func (l *CachedNamespaceLister) ListNamespacesWithLabels(ctx context.Context) (*NamespaceInfo, error) {
+	if !l.synced() {
+		return l.fallback.ListNamespacesWithLabels(ctx)
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return &NamespaceInfo{
+		All:          sortedNames(l.labels),
+		AllowMirrors: sortedNames(l.allowMirrors),
+		OptOut:       sortedNames(l.optOut),
+		Labels:       maps.Clone(l.labels),
+		Created:      maps.Clone(l.created),
+		Tenants:      l.tenantIndex(),
+	}, nil
+}
+
+// tenantIndex returns the tenant index with sorted names. Callers hold the lock.
+func (l *CachedNamespaceLister) tenantIndex() map[string][]string {
+	index := make(map[string][]string, len(l.tenants))
+	for tenant, members := range l.tenants {
+		index[tenant] = sortedNames(members)
+	}
+	return index
+}
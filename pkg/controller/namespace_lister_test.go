@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllertest"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func makeNamespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestCachedNamespaceLister(t *testing.T) {
+	ctx := context.Background()
+	informer := &controllertest.FakeInformer{Synced: true}
+	lister, err := NewCachedNamespaceLister(informer, nil)
+	require.NoError(t, err)
+
+	informer.Add(makeNamespace("team-b", map[string]string{constants.LabelAllowMirrors: "true"}))
+	informer.Add(makeNamespace("team-a", map[string]string{constants.LabelAllowMirrors: "false", "tier": "gold"}))
+	informer.Add(makeNamespace("default", nil))
+
+	names, err := lister.ListNamespaces(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "team-a", "team-b"}, names)
+	names, err = lister.ListAllowMirrorsNamespaces(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team-b"}, names)
+	names, err = lister.ListOptOutNamespaces(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team-a"}, names)
+
+	// Label changes and deletions arrive as watch events
+	informer.Update(makeNamespace("team-a", nil), makeNamespace("team-a", map[string]string{constants.LabelAllowMirrors: "true"}))
+	informer.Delete(makeNamespace("team-b", nil))
+	info, err := lister.ListNamespacesWithLabels(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "team-a"}, info.All)
+	assert.Equal(t, []string{"team-a"}, info.AllowMirrors)
+	assert.Empty(t, info.OptOut)
+	assert.Equal(t, map[string]string{constants.LabelAllowMirrors: "true"}, info.Labels["team-a"])
+	assert.Contains(t, info.Created, "team-a")
+	assert.NotContains(t, info.Created, "team-b")
+	names, err = lister.ListTerminatingNamespaces(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	// Namespaces being deleted are listed until they are gone
+	terminating := makeNamespace("default", nil)
+	terminating.Status.Phase = corev1.NamespaceTerminating
+	informer.Update(makeNamespace("default", nil), terminating)
+	names, err = lister.ListTerminatingNamespaces(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default"}, names)
+	info, err = lister.ListNamespacesWithLabels(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, info.All, "default")
+	informer.Delete(terminating)
+	informer.Add(makeNamespace("default", nil))
+	names, err = lister.ListTerminatingNamespaces(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	// Deletions the informer missed arrive as tombstones
+	lister.remove(toolscache.DeletedFinalStateUnknown{Key: "team-a", Obj: makeNamespace("team-a", nil)})
+	names, err = lister.ListNamespaces(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default"}, names)
+}
+
+func TestCachedNamespaceLister_FallbackUntilSynced(t *testing.T) {
+	ctx := context.Background()
+	informer := &controllertest.FakeInformer{}
+	lister, err := NewCachedNamespaceLister(informer, NewStaticNamespaceLister([]string{"team-a"}))
+	require.NoError(t, err)
+	informer.Add(makeNamespace("default", nil))
+
+	names, err := lister.ListNamespaces(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team-a"}, names, "answered by the fallback until the informer syncs")
+
+	informer.Synced = true
+	names, err = lister.ListNamespaces(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default"}, names)
+}
+
+func TestNamespaceLister_Tenants(t *testing.T) {
+	ctx := context.Background()
+	namespaces := []*corev1.Namespace{
+		makeNamespace("payments-prod", map[string]string{constants.LabelTenant: "payments"}),
+		makeNamespace("payments-dev", map[string]string{constants.LabelTenant: "payments"}),
+		makeNamespace("shop-prod", map[string]string{constants.LabelTenant: "shop"}),
+		makeNamespace("default", nil),
+	}
+	want := map[string][]string{"payments": {"payments-dev", "payments-prod"}, "shop": {"shop-prod"}}
+
+	t.Run("kubernetes", func(t *testing.T) {
+		scheme := runtime.NewScheme()
+		require.NoError(t, corev1.AddToScheme(scheme))
+		builder := fake.NewClientBuilder().WithScheme(scheme)
+		for _, ns := range namespaces {
+			builder = builder.WithObjects(ns.DeepCopy())
+		}
+		info, err := NewKubernetesNamespaceLister(builder.Build()).ListNamespacesWithLabels(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, want, info.Tenants)
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		informer := &controllertest.FakeInformer{Synced: true}
+		lister, err := NewCachedNamespaceLister(informer, nil)
+		require.NoError(t, err)
+		for _, ns := range namespaces {
+			informer.Add(ns.DeepCopy())
+		}
+		info, err := lister.ListNamespacesWithLabels(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, want, info.Tenants)
+
+		// Moving a namespace to another tenant, or deleting the last one, updates the index
+		informer.Update(namespaces[1], makeNamespace("payments-dev", map[string]string{constants.LabelTenant: "shop"}))
+		informer.Delete(namespaces[0])
+		info, err = lister.ListNamespacesWithLabels(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{"shop": {"payments-dev", "shop-prod"}}, info.Tenants)
+	})
+}
@@ -4,6 +4,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"maps"
 	"slices"
 	"time"
 
@@ -11,8 +12,10 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -20,6 +23,7 @@ import (
 	"github.com/lukaszraczylo/kubemirror/pkg/config"
 	"github.com/lukaszraczylo/kubemirror/pkg/constants"
 	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/naming"
 )
 
 const (
@@ -39,6 +43,19 @@ type NamespaceReconciler struct {
 	Config          *config.Config
 	Filter          *filter.NamespaceFilter
 	ResourceTypes   []config.ResourceType
+	// Leadership shards writes per resource type across replicas (optional)
+	Leadership ResourceTypeLeadership
+	// NamespaceOwnership shards writes by target namespace across replicas (optional)
+	NamespaceOwnership NamespaceOwnership
+	// TargetResolver decides target namespaces (optional, nil = target-namespaces annotation)
+	TargetResolver TargetResolver
+	// Policies selects additional sources and targets from ClusterMirrorPolicies (optional)
+	Policies MirrorPolicies
+	// RuleLibrary loads the transform rule libraries sources reference (optional)
+	RuleLibrary *RuleLibrary
+	// Recorder emits mirror lifecycle Events on source resources, and Events on
+	// namespaces with an invalid tenant label (optional)
+	Recorder events.EventRecorder
 }
 
 // Reconcile processes namespace events and creates mirrors for matching sources.
@@ -55,12 +72,26 @@ func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	// A namespace being deleted rejects new mirrors, and its mirrors go with it
+	if isTerminating(namespace) {
+		logger.V(1).Info("namespace is terminating, skipping")
+		return ctrl.Result{}, nil
+	}
+
 	// Skip system namespaces
 	if r.Filter != nil && !r.Filter.IsAllowed(namespace.Name) {
 		logger.V(1).Info("namespace filtered out, skipping")
 		return ctrl.Result{}, nil
 	}
 
+	// Another replica writes mirrors into this namespace
+	if !ownsNamespace(r.NamespaceOwnership, namespace.Name) {
+		logger.V(2).Info("namespace owned by another shard, skipping")
+		return ctrl.Result{}, nil
+	}
+
+	r.checkTenantLabel(ctx, namespace)
+
 	logger.Info("namespace event detected, reconciling source resources")
 
 	// Query all source resources that have mirroring enabled
@@ -68,6 +99,15 @@ func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	var totalReconciled, totalErrors int
 
 	for _, rt := range r.ResourceTypes {
+		// Only the replica owning a resource type creates its mirrors
+		if !isLeaderFor(r.Leadership, rt.GroupVersionKind()) {
+			continue
+		}
+		if scope := pauseScope(r.Config, rt.GroupVersionKind(), nil); scope != "" {
+			logger.V(1).Info("mirroring paused, skipping resource type", "resourceType", rt.String(), "pausedBy", scope)
+			continue
+		}
+
 		reconciled, errors, err := r.reconcileResourceType(ctx, rt, namespace.Name)
 		if err != nil {
 			logger.Error(err, "failed to reconcile resource type",
@@ -79,6 +119,11 @@ func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		totalErrors += errors
 	}
 
+	// Sources selected by mirror policies are handed to their source reconcilers
+	if r.Policies != nil {
+		r.Policies.Resync(ctx)
+	}
+
 	logger.Info("namespace reconciliation complete",
 		"reconciled", totalReconciled,
 		"errors", totalErrors,
@@ -131,6 +176,11 @@ func (r *NamespaceReconciler) reconcileResourceType(ctx context.Context, rt conf
 			continue
 		}
 
+		// Paused sources keep their mirrors as they are
+		if pauseScope(nil, gvk, source) != "" {
+			continue
+		}
+
 		// Resolve target namespaces for this source
 		targetNamespaces, err := r.resolveTargetNamespaces(ctx, source)
 		if err != nil {
@@ -144,6 +194,23 @@ func (r *NamespaceReconciler) reconcileResourceType(ctx context.Context, rt conf
 		isTarget := slices.Contains(targetNamespaces, namespaceName)
 
 		if isTarget {
+			// Lower sync waves go first; the namespace is requeued shortly anyway
+			blocker, err := r.waveBlocker(ctx, source, namespaceName)
+			if err != nil {
+				logger.Error(err, "failed to check sync waves",
+					"source", source.GetName(), "namespace", source.GetNamespace())
+				errorCount++
+				continue
+			}
+			if blocker != "" {
+				logger.V(1).Info("mirror waiting for lower sync wave",
+					"source", source.GetName(),
+					"sourceNamespace", source.GetNamespace(),
+					"targetNamespace", namespaceName,
+					"waitingFor", blocker)
+				continue
+			}
+
 			// Create or update mirror in the namespace
 			if err := r.reconcileMirror(ctx, source, namespaceName); err != nil {
 				logger.Error(err, "failed to create mirror",
@@ -165,9 +232,9 @@ func (r *NamespaceReconciler) reconcileResourceType(ctx context.Context, rt conf
 			mirror := &unstructured.Unstructured{}
 			mirror.SetGroupVersionKind(source.GroupVersionKind())
 			mirror.SetNamespace(namespaceName)
-			mirror.SetName(source.GetName())
+			mirror.SetName(naming.MirrorNameOrDefault(source))
 
-			err := r.Get(ctx, client.ObjectKey{Namespace: namespaceName, Name: source.GetName()}, mirror)
+			err := r.Get(ctx, client.ObjectKeyFromObject(mirror), mirror)
 			if errors.IsNotFound(err) {
 				// No mirror exists, nothing to clean up
 				continue
@@ -191,7 +258,21 @@ func (r *NamespaceReconciler) reconcileResourceType(ctx context.Context, rt conf
 				continue
 			}
 
+			if isDryRun(r.Config, source) {
+				reportDryRun(ctx, r.Recorder, source, source.GroupVersionKind(), dryRunDelete, namespaceName,
+					"namespace is no longer a target")
+				reconciledCount++
+				continue
+			}
+
 			// This mirror should be deleted (namespace no longer a valid target)
+			if err := detachMirror(ctx, r.Client, mirror); err != nil {
+				logger.Error(err, "failed to detach orphaned mirror from service accounts",
+					"source", source.GetName(),
+					"targetNamespace", namespaceName)
+				errorCount++
+				continue
+			}
 			if err := r.Delete(ctx, mirror); err != nil {
 				logger.Error(err, "failed to delete orphaned mirror",
 					"source", source.GetName(),
@@ -201,7 +282,14 @@ func (r *NamespaceReconciler) reconcileResourceType(ctx context.Context, rt conf
 				continue
 			}
 
+			if err := deleteBindingOwners(ctx, r.Client, mirror); err != nil {
+				logger.Error(err, "failed to delete mirror binding",
+					"source", source.GetName(),
+					"targetNamespace", namespaceName)
+			}
+
 			reconciledCount++
+			recordMirrorDeleted(r.Recorder, source, namespaceName, "namespace is no longer a target")
 			logger.V(1).Info("deleted orphaned mirror due to namespace label change",
 				"source", source.GetName(),
 				"sourceNamespace", source.GetNamespace(),
@@ -216,70 +304,21 @@ func (r *NamespaceReconciler) reconcileResourceType(ctx context.Context, rt conf
 // resolveTargetNamespaces determines which namespaces should receive mirrors for a source.
 // Uses the same logic as SourceReconciler.resolveTargetNamespaces.
 func (r *NamespaceReconciler) resolveTargetNamespaces(ctx context.Context, source *unstructured.Unstructured) ([]string, error) {
-	annotations := source.GetAnnotations()
-	if annotations == nil {
-		return nil, nil
-	}
-
-	targetNsAnnotation := annotations[constants.AnnotationTargetNamespaces]
-	if targetNsAnnotation == "" {
-		return nil, nil
+	targetNamespaces, err := resolveTargets(ctx, r.TargetResolver, r.NamespaceLister, r.Filter, source,
+		policyTargetPatterns(r.Policies, source.GroupVersionKind(), source))
+	if err != nil || len(targetNamespaces) == 0 {
+		return nil, err
 	}
 
-	// Parse patterns
-	patterns := filter.ParseTargetNamespaces(targetNsAnnotation)
-	if len(patterns) == 0 {
-		return nil, nil
+	// Namespaces the mirrors expired in are no longer targets
+	if targetNamespaces, err = dropExpiredTargets(ctx, r.Client, source, targetNamespaces, time.Now()); err != nil {
+		return nil, err
 	}
 
-	// Validate patterns and log warnings for invalid ones
-	validationResults, allValid := filter.ValidatePatterns(patterns)
-	if !allValid {
-		logger := log.FromContext(ctx)
-		invalidPatterns := filter.InvalidPatterns(validationResults)
-		for _, invalid := range invalidPatterns {
-			logger.Info("invalid glob pattern in target-namespaces annotation, pattern will be skipped",
-				"pattern", invalid.Pattern,
-				"error", invalid.Error.Error(),
-				"source", source.GetName(),
-				"namespace", source.GetNamespace(),
-			)
-		}
-
-		// Filter to only valid patterns
-		var validPatterns []string
-		for _, result := range validationResults {
-			if result.Valid {
-				validPatterns = append(validPatterns, result.Pattern)
-			}
-		}
-		patterns = validPatterns
-
-		// If no valid patterns remain, return empty
-		if len(patterns) == 0 {
-			return nil, nil
-		}
-	}
-
-	// Get all namespace info in a single API call (more efficient than 3 separate calls)
-	nsInfo, err := r.NamespaceLister.ListNamespacesWithLabels(ctx)
+	// Enforce max targets limit; the source reconciler reports the truncation
+	targetNamespaces, _, err = truncateTargets(ctx, r.Config, r.NamespaceLister, source, targetNamespaces)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list namespaces: %w", err)
-	}
-
-	// Resolve target namespaces using the pre-categorized namespace info
-	targetNamespaces := filter.ResolveTargetNamespaces(
-		patterns,
-		nsInfo.All,
-		nsInfo.AllowMirrors,
-		nsInfo.OptOut,
-		source.GetNamespace(),
-		r.Filter,
-	)
-
-	// Enforce max targets limit
-	if r.Config != nil && r.Config.MaxTargetsPerResource > 0 && len(targetNamespaces) > r.Config.MaxTargetsPerResource {
-		targetNamespaces = targetNamespaces[:r.Config.MaxTargetsPerResource]
+		return nil, err
 	}
 
 	return targetNamespaces, nil
@@ -288,18 +327,32 @@ func (r *NamespaceReconciler) resolveTargetNamespaces(ctx context.Context, sourc
 // reconcileMirror creates or updates a mirror in the target namespace.
 // This calls the mirror creation logic from the SourceReconciler.
 func (r *NamespaceReconciler) reconcileMirror(ctx context.Context, source *unstructured.Unstructured, targetNamespace string) error {
-	// Create a temporary SourceReconciler to use its mirror creation logic
-	// This avoids code duplication
-	sourceReconciler := &SourceReconciler{
+	return r.sourceReconciler(source).reconcileMirror(ctx, source, source, targetNamespace)
+}
+
+// waveBlocker returns the lower-wave source whose mirror the namespace still
+// misses before source can be mirrored into it, or "" when there is none.
+func (r *NamespaceReconciler) waveBlocker(ctx context.Context, source *unstructured.Unstructured, targetNamespace string) (string, error) {
+	_, waiting, err := r.sourceReconciler(source).holdForWaves(ctx, source, []string{targetNamespace})
+	return waiting[targetNamespace], err
+}
+
+// sourceReconciler returns a temporary SourceReconciler for source, to reuse its
+// mirror creation logic and avoid code duplication.
+func (r *NamespaceReconciler) sourceReconciler(source *unstructured.Unstructured) *SourceReconciler {
+	return &SourceReconciler{
 		Client:          r.Client,
 		Scheme:          r.Scheme,
 		Config:          r.Config,
 		Filter:          r.Filter,
 		NamespaceLister: r.NamespaceLister,
 		GVK:             source.GroupVersionKind(),
+		Recorder:        r.Recorder,
+		TargetResolver:  r.TargetResolver,
+		Policies:        r.Policies,
+		RuleLibrary:     r.RuleLibrary,
+		ResourceTypes:   func() []config.ResourceType { return r.ResourceTypes },
 	}
-
-	return sourceReconciler.reconcileMirror(ctx, source, source, targetNamespace)
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -311,28 +364,15 @@ func (r *NamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			return true
 		},
 		UpdateFunc: func(e event.UpdateEvent) bool {
-			// Only reconcile if labels changed (specifically allow-mirrors label)
+			// Only reconcile if labels changed: allow-mirrors opts namespaces in and
+			// out, and any label may be matched by a target-namespace-selector
 			oldNs, okOld := e.ObjectOld.(*corev1.Namespace)
 			newNs, okNew := e.ObjectNew.(*corev1.Namespace)
 			if !okOld || !okNew {
 				return false
 			}
 
-			// Check if allow-mirrors label changed
-			// Use GetLabels() to safely handle nil labels map
-			oldLabels := oldNs.GetLabels()
-			newLabels := newNs.GetLabels()
-
-			// Get label values with nil-safe access
-			var oldLabel, newLabel string
-			if oldLabels != nil {
-				oldLabel = oldLabels[constants.LabelAllowMirrors]
-			}
-			if newLabels != nil {
-				newLabel = newLabels[constants.LabelAllowMirrors]
-			}
-
-			return oldLabel != newLabel
+			return !maps.Equal(oldNs.GetLabels(), newNs.GetLabels())
 		},
 		DeleteFunc: func(e event.DeleteEvent) bool {
 			// Don't reconcile on delete - source reconcilers will handle cleanup via finalizers
@@ -342,6 +382,7 @@ func (r *NamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Namespace{}).
+		WithOptions(controller.Options{NeedLeaderElection: needLeaderElection(r.Leadership != nil || r.NamespaceOwnership != nil)}).
 		WithEventFilter(namespacePredicate).
 		Complete(r)
 }
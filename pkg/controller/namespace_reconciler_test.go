@@ -311,6 +311,10 @@ func (m *mockNamespaceLister) ListOptOutNamespaces(ctx context.Context) ([]strin
 	return result, nil
 }
 
+func (m *mockNamespaceLister) ListTerminatingNamespaces(ctx context.Context) ([]string, error) {
+	return []string{}, nil
+}
+
 func (m *mockNamespaceLister) ListNamespacesWithLabels(ctx context.Context) (*NamespaceInfo, error) {
 	info := &NamespaceInfo{
 		All:          m.namespaces,
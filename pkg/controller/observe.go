@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ReconcileObserver is told the outcome of every reconcile, e.g. to fail the
+// readiness probe while a controller keeps failing.
+type ReconcileObserver interface {
+	ObserveReconcile(controller string, err error)
+}
+
+// observeReconciler reports the outcome of every reconcile of r to observer under
+// the controller's name. A nil observer returns r unchanged.
+func observeReconciler(r reconcile.Reconciler, name string, observer ReconcileObserver) reconcile.Reconciler {
+	if observer == nil {
+		return r
+	}
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		result, err := r.Reconcile(ctx, req)
+		observer.ObserveReconcile(name, err)
+		return result, err
+	})
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type recordingObserver struct {
+	controllers []string
+	errs        []error
+}
+
+func (o *recordingObserver) ObserveReconcile(controller string, err error) {
+	o.controllers = append(o.controllers, controller)
+	o.errs = append(o.errs, err)
+}
+
+func TestObserveReconciler(t *testing.T) {
+	failure := errors.New("boom")
+	calls := 0
+	inner := reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+		calls++
+		if calls == 1 {
+			return reconcile.Result{}, failure
+		}
+		return reconcile.Result{Requeue: true}, nil
+	})
+
+	assert.NotNil(t, observeReconciler(inner, "Secret.v1.", nil), "a nil observer keeps the reconciler")
+
+	observer := &recordingObserver{}
+	r := observeReconciler(inner, "Secret.v1.", observer)
+	_, err := r.Reconcile(context.Background(), reconcile.Request{})
+	assert.ErrorIs(t, err, failure)
+	result, err := r.Reconcile(context.Background(), reconcile.Request{})
+	assert.NoError(t, err)
+	assert.True(t, result.Requeue, "the result is passed through")
+
+	assert.Equal(t, []string{"Secret.v1.", "Secret.v1."}, observer.controllers)
+	assert.Equal(t, []error{failure, nil}, observer.errs)
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/status"
+)
+
+// Scopes mirroring can be paused at, reported in the sync status.
+const (
+	pausedByController   = "controller"
+	pausedByResourceType = "resource-type"
+	pausedBySource       = "source"
+)
+
+// pauseScope returns the scope mirroring of source is paused at, or "" when it
+// is not: the whole controller or the resource type (both from the config), or
+// the source itself with the paused annotation. A nil source checks only the config.
+func pauseScope(cfg *config.Config, gvk schema.GroupVersionKind, source *unstructured.Unstructured) string {
+	if cfg != nil {
+		if paused, controllerWide := cfg.PausedFor(gvk); controllerWide {
+			return pausedByController
+		} else if paused {
+			return pausedByResourceType
+		}
+	}
+	if source != nil && source.GetAnnotations()[constants.AnnotationPaused] == "true" {
+		return pausedBySource
+	}
+	return ""
+}
+
+// handlePaused leaves the mirrors of a paused source as they are. A paused source
+// can still be deleted: its finalizer is released without touching the mirrors,
+// which the mirror reconciler removes as orphans once their type is resumed.
+func (r *SourceReconciler) handlePaused(ctx context.Context, source *unstructured.Unstructured, scope string, ownsSource bool) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("pausedBy", scope)
+	hasFinalizer := slices.Contains(source.GetFinalizers(), constants.FinalizerName)
+
+	if !source.GetDeletionTimestamp().IsZero() {
+		if hasFinalizer && ownsSource {
+			logger.Info("paused source being deleted, removing finalizer and keeping mirrors")
+			source.SetFinalizers(removeString(source.GetFinalizers(), constants.FinalizerName))
+			if err := r.Update(ctx, source); err != nil {
+				logger.Error(err, "failed to remove finalizer")
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	logger.V(1).Info("mirroring paused, skipping")
+
+	// Only sources that were mirrored have a status to update
+	if r.StatusReporter != nil && hasFinalizer && ownsSource && !r.dryRun(source) {
+		if err := r.StatusReporter.Report(ctx, source, status.Result{Paused: scope}); err != nil {
+			logger.Error(err, "failed to report sync status")
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
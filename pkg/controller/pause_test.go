@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+func TestPauseScope(t *testing.T) {
+	paused := makeUnstructuredSecret("app-secret", "default", nil, map[string]string{constants.AnnotationPaused: "true"})
+	active := makeUnstructuredSecret("app-secret", "default", nil, map[string]string{constants.AnnotationPaused: "false"})
+
+	tests := []struct {
+		name   string
+		cfg    *config.Config
+		source *unstructured.Unstructured
+		want   string
+	}{
+		{name: "not paused", cfg: &config.Config{}, source: active, want: ""},
+		{name: "nil config", source: paused, want: pausedBySource},
+		{name: "source", cfg: &config.Config{}, source: paused, want: pausedBySource},
+		{name: "resource type", cfg: &config.Config{PausedResourceTypes: []config.ResourceType{{Version: "v1", Kind: "Secret"}}}, want: pausedByResourceType},
+		{name: "other resource type", cfg: &config.Config{PausedResourceTypes: []config.ResourceType{{Version: "v1", Kind: "ConfigMap"}}}, want: ""},
+		{name: "controller wins", cfg: &config.Config{Paused: true}, source: paused, want: pausedByController},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, pauseScope(tt.cfg, secretGVK, tt.source))
+		})
+	}
+}
+
+func TestSourceReconciler_Reconcile_Paused(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       *config.Config
+		annotated bool
+		want      string
+	}{
+		{name: "source annotation", cfg: &config.Config{}, annotated: true, want: pausedBySource},
+		{name: "resource type", cfg: &config.Config{PausedResourceTypes: []config.ResourceType{{Version: "v1", Kind: "Secret"}}}, want: pausedByResourceType},
+		{name: "controller", cfg: &config.Config{Paused: true}, want: pausedByController},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			annotations := map[string]string{constants.AnnotationSync: "true", constants.AnnotationTargetNamespaces: "team-a"}
+			if tt.annotated {
+				annotations[constants.AnnotationPaused] = "true"
+			}
+			source := makeUnstructuredSecret("app-secret", "default", map[string]string{constants.LabelEnabled: "true"}, annotations)
+			source.SetFinalizers([]string{constants.FinalizerName})
+
+			// team-a holds an outdated mirror, team-b one that is no longer a target
+			outdated := makeUnstructuredMirror("app-secret", "team-a", "default", "app-secret")
+			outdated.Object["data"] = map[string]interface{}{"key": "b2xk"}
+			c := newFakeClient(t, source, outdated, makeUnstructuredMirror("app-secret", "team-b", "default", "app-secret"))
+
+			reporter := &recordingReporter{}
+			r := &SourceReconciler{
+				Client:          c,
+				Config:          tt.cfg,
+				Filter:          filter.NewNamespaceFilter(nil, nil),
+				NamespaceLister: NewKubernetesNamespaceLister(c),
+				StatusReporter:  reporter,
+				GVK:             secretGVK,
+			}
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+			require.NoError(t, err)
+
+			require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(outdated), outdated))
+			value, _, _ := unstructured.NestedString(outdated.Object, "data", "key")
+			assert.Equal(t, "b2xk", value, "mirror is not updated")
+			assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-b", Name: "app-secret"}, makeUnstructuredSecret("", "", nil, nil)),
+				"mirror outside the targets is not cleaned up")
+			assert.Equal(t, tt.want, reporter.result.Paused)
+		})
+	}
+}
+
+func TestSourceReconciler_Reconcile_PausedDeletion(t *testing.T) {
+	ctx := context.Background()
+	source := makeUnstructuredSecret("app-secret", "default", map[string]string{constants.LabelEnabled: "true"},
+		map[string]string{constants.AnnotationSync: "true", constants.AnnotationTargetNamespaces: "team-a", constants.AnnotationPaused: "true"})
+	source.SetFinalizers([]string{constants.FinalizerName})
+	now := metav1.Now()
+	source.SetDeletionTimestamp(&now)
+	mirror := makeUnstructuredMirror("app-secret", "team-a", "default", "app-secret")
+	c := newFakeClient(t, source, mirror)
+
+	r := &SourceReconciler{Client: c, Config: &config.Config{}, GVK: secretGVK}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+	require.NoError(t, err)
+
+	err = c.Get(ctx, client.ObjectKeyFromObject(source), makeUnstructuredSecret("", "", nil, nil))
+	assert.True(t, apierrors.IsNotFound(err), "finalizer is released so the deletion completes")
+	assert.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(mirror), mirror), "mirror is kept")
+}
+
+func TestMirrorReconciler_Paused(t *testing.T) {
+	ctx := context.Background()
+	orphan := makeUnstructuredMirror("app-secret", "team-a", "default", "gone")
+	c := newFakeClient(t, orphan)
+
+	r := &MirrorReconciler{Client: c, Config: &config.Config{Paused: true}, GVK: secretGVK}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(orphan)})
+	require.NoError(t, err)
+	assert.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(orphan), orphan), "orphan is kept while paused")
+
+	r.Config = &config.Config{}
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(orphan)})
+	require.NoError(t, err)
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(orphan), orphan)), "orphan is removed once resumed")
+}
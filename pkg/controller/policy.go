@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/listing"
+	"github.com/lukaszraczylo/kubemirror/pkg/policy"
+)
+
+// MirrorPolicies supplies centrally declared mirroring rules (ClusterMirrorPolicy).
+// A source selected by a policy is mirrored as if it carried the sync annotation.
+type MirrorPolicies interface {
+	// TargetPatterns returns the target namespace patterns of every policy selecting source (nil = none)
+	TargetPatterns(gvk schema.GroupVersionKind, source client.Object) []string
+	// OnChange registers fn to run whenever the sources selected by a policy may have changed
+	OnChange(fn policy.ChangeFunc)
+	// Resync runs the OnChange callbacks for every policy
+	Resync(ctx context.Context)
+}
+
+// policyTargetPatterns returns the policy target patterns for source (nil without policies).
+func policyTargetPatterns(policies MirrorPolicies, gvk schema.GroupVersionKind, source client.Object) []string {
+	if policies == nil {
+		return nil
+	}
+	return policies.TargetPatterns(gvk, source)
+}
+
+// enqueuePolicySources requeues the sources of this reconciler's type in namespaces
+// ("" = all) affected by a policy change: those a policy now selects, so they are
+// mirrored, and those carrying our finalizer, so mirrors of deselected sources are removed.
+func (r *SourceReconciler) enqueuePolicySources(ctx context.Context, gvk schema.GroupVersionKind, namespaces []string) {
+	if gvk != r.GVK || r.resync == nil || r.isStopped() {
+		return
+	}
+	if slices.Contains(namespaces, "") {
+		namespaces = []string{""}
+	}
+
+	// Sending blocks until the controller runs; don't hold up the policy reconciler
+	go func() {
+		logger := log.FromContext(ctx).WithValues("kind", r.GVK.Kind, "group", r.GVK.Group, "version", r.GVK.Version)
+		for _, ns := range slices.Compact(slices.Sorted(slices.Values(namespaces))) {
+			if err := r.forEachSource(ctx, ns, func(source *unstructured.Unstructured) bool {
+				if !slices.Contains(source.GetFinalizers(), constants.FinalizerName) &&
+					len(policyTargetPatterns(r.Policies, r.GVK, source)) == 0 {
+					return true
+				}
+				select {
+				case r.resync <- event.GenericEvent{Object: source}:
+					return true
+				case <-ctx.Done():
+					return false
+				case <-r.stopped:
+					return false
+				}
+			}); err != nil {
+				logger.Error(err, "failed to list sources after mirror policy change", "namespace", ns)
+			}
+		}
+	}()
+}
+
+// forEachSource lists objects of this reconciler's type in namespace ("" = all)
+// page by page until fn returns false.
+func (r *SourceReconciler) forEachSource(ctx context.Context, namespace string, fn func(*unstructured.Unstructured) bool) error {
+	return listing.ForEach(ctx, r.Client, r.GVK, []client.ListOption{client.InNamespace(namespace)}, func(obj *unstructured.Unstructured) error {
+		if !fn(obj) {
+			return listing.ErrStop
+		}
+		return nil
+	})
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/policy"
+)
+
+// secretPolicy builds a store with one policy mirroring Secrets labeled team=a in default.
+func secretPolicy(t *testing.T, targets ...interface{}) *policy.Store {
+	t.Helper()
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"source": map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"namespace":  "default",
+				"selector":   map[string]interface{}{"matchLabels": map[string]interface{}{"team": "a"}},
+			},
+			"targetNamespaces": targets,
+		},
+	}}
+	obj.SetGroupVersionKind(policy.GVK)
+	obj.SetName("team-a")
+
+	p, err := policy.Parse(obj)
+	require.NoError(t, err)
+	store := policy.NewStore()
+	store.Set(context.Background(), p)
+	return store
+}
+
+func TestSourceReconciler_Reconcile_PolicySelectedSource(t *testing.T) {
+	// No enabled label or sync annotation: only the policy selects it
+	source := makeUnstructuredSecret("app-secret", "default", map[string]string{"team": "a"}, nil)
+	c := newFakeClient(t, source)
+
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+		Policies:        secretPolicy(t, "team-*"),
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app-secret"}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	stored := makeUnstructuredSecret("", "", nil, nil)
+	require.NoError(t, c.Get(context.Background(), req.NamespacedName, stored))
+	assert.Contains(t, stored.GetFinalizers(), constants.FinalizerName)
+
+	targets, err := r.resolveTargetNamespaces(context.Background(), stored)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"team-a", "team-b"}, targets)
+}
+
+func TestSourceReconciler_Reconcile_PolicyDeselectedSource(t *testing.T) {
+	source := makeUnstructuredSecret("app-secret", "default", map[string]string{"team": "b"}, nil)
+	source.SetFinalizers([]string{constants.FinalizerName})
+	c := newFakeClient(t, source, makeUnstructuredMirror("app-secret", "team-a", "default", "app-secret"))
+
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+		Policies:        secretPolicy(t, "team-a"),
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app-secret"}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	stored := makeUnstructuredSecret("", "", nil, nil)
+	require.NoError(t, c.Get(context.Background(), req.NamespacedName, stored))
+	assert.NotContains(t, stored.GetFinalizers(), constants.FinalizerName, "no longer selected, so treated as disabled")
+	mirror := &corev1.Secret{}
+	assert.Error(t, c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, mirror))
+}
+
+func TestSourceReconciler_resolveTargetNamespaces_PolicyAndAnnotation(t *testing.T) {
+	c := newFakeClient(t)
+	r := &SourceReconciler{
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+		Policies:        secretPolicy(t, "team-a"),
+	}
+	source := makeUnstructuredSecret("app-secret", "default", map[string]string{"team": "a"},
+		map[string]string{constants.AnnotationTargetNamespaces: "team-b"})
+
+	targets, err := r.resolveTargetNamespaces(context.Background(), source)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"team-a", "team-b"}, targets)
+}
+
+func TestSourceReconciler_EnqueuePolicySources(t *testing.T) {
+	selected := makeUnstructuredSecret("selected", "default", map[string]string{"team": "a"}, nil)
+	managed := makeUnstructuredSecret("managed", "default", nil, nil)
+	managed.SetFinalizers([]string{constants.FinalizerName})
+	other := makeUnstructuredSecret("other", "default", nil, nil)
+	elsewhere := makeUnstructuredSecret("elsewhere", "team-a", map[string]string{"team": "a"}, nil)
+	c := newFakeClient(t, selected, managed, other, elsewhere)
+
+	r := &SourceReconciler{
+		Client:   c,
+		GVK:      secretGVK,
+		Policies: secretPolicy(t, "team-a"),
+		resync:   make(chan event.GenericEvent, 10),
+	}
+	r.enqueuePolicySources(context.Background(), secretGVK, []string{"default"})
+
+	var names []string
+	for range 2 {
+		select {
+		case e := <-r.resync:
+			names = append(names, e.Object.GetName())
+		case <-time.After(time.Second):
+			t.Fatal("expected a resync event")
+		}
+	}
+	assert.ElementsMatch(t, []string{"selected", "managed"}, names)
+
+	// Other types are ignored
+	r.enqueuePolicySources(context.Background(), schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, []string{"default"})
+	select {
+	case e := <-r.resync:
+		t.Fatalf("unexpected resync of %s", e.Object.GetName())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+)
+
+// PreviewPath is the path the preview endpoint is served on.
+const PreviewPath = "/preview"
+
+// maxPreviewRequestBytes bounds the body of a preview request.
+const maxPreviewRequestBytes = 1 << 20
+
+// PreviewRequest is the body of a preview request, in JSON or YAML.
+type PreviewRequest struct {
+	// Source is the source manifest, as it would be applied
+	Source json.RawMessage `json:"source"`
+	// TargetNamespace is the namespace the mirror is previewed in
+	TargetNamespace string `json:"targetNamespace"`
+}
+
+// PreviewHandler answers POST requests with the mirror kubemirror would write for
+// a source manifest in a target namespace, built with the live transform settings,
+// so transform annotations can be checked before they are applied. Nothing is
+// written; template lookups read the cluster through Client.
+type PreviewHandler struct {
+	Client client.Client
+	Config *config.Config
+	// RuleLibrary loads the transform rule libraries sources reference (optional)
+	RuleLibrary *RuleLibrary
+}
+
+// ServeHTTP implements http.Handler.
+func (h *PreviewHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writePreviewError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed, use POST", req.Method))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxPreviewRequestBytes))
+	if err != nil {
+		writePreviewError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	source, targetNs, err := parsePreviewRequest(body)
+	if err != nil {
+		writePreviewError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	mirror, err := h.preview(req.Context(), source, targetNs)
+	if err != nil {
+		writePreviewError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(mirror.Object)
+}
+
+// preview builds the mirror of source in targetNs the way a source reconciler
+// of its type does.
+func (h *PreviewHandler) preview(ctx context.Context, source *unstructured.Unstructured, targetNs string) (*unstructured.Unstructured, error) {
+	r := &SourceReconciler{Client: h.Client, Config: h.Config, GVK: source.GroupVersionKind(), RuleLibrary: h.RuleLibrary}
+
+	expiresAt, err := mirrorExpiry(ctx, r.Client, source, targetNs)
+	if err != nil {
+		return nil, err
+	}
+	desired, err := buildMirror(ctx, source, source, targetNs, r.transformOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build mirror: %w", err)
+	}
+	mirror, err := completeMirror(desired, source, r.Config, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if sealsMirrors(source) {
+		return r.sealMirror(mirror)
+	}
+	return mirror, nil
+}
+
+// parsePreviewRequest decodes a preview request body and checks the source can be
+// mirrored to the target namespace.
+func parsePreviewRequest(body []byte) (*unstructured.Unstructured, string, error) {
+	data, err := yaml.YAMLToJSON(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid request: %w", err)
+	}
+	var request PreviewRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, "", fmt.Errorf("invalid request: %w", err)
+	}
+	if len(request.Source) == 0 || string(request.Source) == "null" {
+		return nil, "", fmt.Errorf("source is required")
+	}
+
+	source := &unstructured.Unstructured{}
+	if err := source.UnmarshalJSON(request.Source); err != nil {
+		return nil, "", fmt.Errorf("invalid source: %w", err)
+	}
+	switch {
+	case source.GetName() == "":
+		return nil, "", fmt.Errorf("source metadata.name is required")
+	case source.GetNamespace() == "":
+		return nil, "", fmt.Errorf("source metadata.namespace is required")
+	case request.TargetNamespace == "":
+		return nil, "", fmt.Errorf("targetNamespace is required")
+	case request.TargetNamespace == source.GetNamespace():
+		return nil, "", fmt.Errorf("targetNamespace must differ from the source namespace")
+	}
+	return source, request.TargetNamespace, nil
+}
+
+// writePreviewError answers a preview request with status and err as JSON.
+func writePreviewError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
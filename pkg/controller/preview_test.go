@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func TestPreviewHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	handler := &PreviewHandler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Config: &config.Config{MirrorLabels: map[string]string{"team": "platform"}},
+	}
+
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, PreviewPath, strings.NewReader(body)))
+		return recorder
+	}
+
+	t.Run("transformed mirror", func(t *testing.T) {
+		response := serve(http.MethodPost, `
+targetNamespace: team-a
+source:
+  apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: app-config
+    namespace: default
+    annotations:
+      kubemirror.raczylo.com/transform: |
+        rules:
+          - path: data.environment
+            template: "{{ .TargetNamespace }}"
+  data:
+    environment: default
+`)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+
+		var mirror struct {
+			Metadata struct {
+				Name        string            `json:"name"`
+				Namespace   string            `json:"namespace"`
+				Labels      map[string]string `json:"labels"`
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+			Data map[string]string `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &mirror))
+		assert.Equal(t, "app-config", mirror.Metadata.Name)
+		assert.Equal(t, "team-a", mirror.Metadata.Namespace)
+		assert.Equal(t, "platform", mirror.Metadata.Labels["team"])
+		assert.Equal(t, "default", mirror.Metadata.Annotations[constants.AnnotationSourceNamespace])
+		assert.Equal(t, "team-a", mirror.Data["environment"])
+	})
+
+	t.Run("failing transform", func(t *testing.T) {
+		response := serve(http.MethodPost, `{
+			"targetNamespace": "team-a",
+			"source": {
+				"apiVersion": "v1", "kind": "ConfigMap",
+				"metadata": {"name": "app-config", "namespace": "default",
+					"annotations": {
+						"kubemirror.raczylo.com/transform": "rules: [{path: data.x, template: '{{ .Missing'}]",
+						"kubemirror.raczylo.com/transform-strict": "true"
+					}},
+				"data": {"x": "y"}
+			}
+		}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+		assert.Contains(t, response.Body.String(), `"error"`)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		response := serve(http.MethodGet, "")
+		assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+		assert.Equal(t, http.MethodPost, response.Header().Get("Allow"))
+	})
+}
+
+func TestParsePreviewRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			body: `{"targetNamespace": "team-a", "source": {"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "s", "namespace": "default"}}}`,
+		},
+		{name: "not yaml", body: `{`, wantErr: "invalid request"},
+		{name: "no source", body: `targetNamespace: team-a`, wantErr: "source is required"},
+		{
+			name:    "no kind",
+			body:    `{"targetNamespace": "team-a", "source": {"apiVersion": "v1", "metadata": {"name": "s", "namespace": "default"}}}`,
+			wantErr: "invalid source",
+		},
+		{
+			name:    "no name",
+			body:    `{"targetNamespace": "team-a", "source": {"apiVersion": "v1", "kind": "Secret", "metadata": {"namespace": "default"}}}`,
+			wantErr: "metadata.name is required",
+		},
+		{
+			name:    "no namespace",
+			body:    `{"targetNamespace": "team-a", "source": {"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "s"}}}`,
+			wantErr: "metadata.namespace is required",
+		},
+		{
+			name:    "no target",
+			body:    `{"source": {"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "s", "namespace": "default"}}}`,
+			wantErr: "targetNamespace is required",
+		},
+		{
+			name:    "target is the source namespace",
+			body:    `{"targetNamespace": "default", "source": {"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "s", "namespace": "default"}}}`,
+			wantErr: "must differ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, targetNs, err := parsePreviewRequest([]byte(tt.body))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Secret", source.GetKind())
+			assert.Equal(t, "team-a", targetNs)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// propagationDuration observes how long source changes take to reach every target.
+var propagationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "kubemirror_propagation_duration_seconds",
+	Help:    "Time from observing a source content change to the last of its target namespaces being synced, by resource type.",
+	Buckets: []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60, 300},
+}, []string{"resource_type"})
+
+func init() {
+	metrics.Registry.MustRegister(propagationDuration)
+}
+
+// propagationClock remembers when a change of each source was first observed,
+// until the change has reached every target.
+type propagationClock struct {
+	observed map[types.NamespacedName]time.Time
+	mu       sync.Mutex
+}
+
+// observe records at as the observation time of key, unless an earlier one is pending.
+func (c *propagationClock) observe(key types.NamespacedName, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.observed == nil {
+		c.observed = make(map[types.NamespacedName]time.Time)
+	}
+	if _, ok := c.observed[key]; !ok {
+		c.observed[key] = at
+	}
+}
+
+// since returns the pending observation time of key, or fallback without one.
+func (c *propagationClock) since(key types.NamespacedName, fallback time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if at, ok := c.observed[key]; ok {
+		return at
+	}
+	return fallback
+}
+
+// forget drops the pending observation of key, e.g. once its change reached every target.
+func (c *propagationClock) forget(key types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.observed, key)
+}
+
+// observeSourceEvents records when created and updated sources are observed, so
+// propagation is measured from the watch event rather than from the reconcile.
+func (r *SourceReconciler) observeSourceEvents() predicate.Funcs {
+	observe := func(obj client.Object) bool {
+		if !IsMirrorResource(obj) {
+			r.propagation.observe(client.ObjectKeyFromObject(obj), time.Now())
+		}
+		return true
+	}
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool { return observe(e.Object) },
+		UpdateFunc: func(e event.UpdateEvent) bool { return observe(e.ObjectNew) },
+	}
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+// propagationSamples returns how many propagations of resourceType were observed.
+func propagationSamples(t *testing.T, resourceType string) uint64 {
+	metric := &dto.Metric{}
+	require.NoError(t, propagationDuration.WithLabelValues(resourceType).(prometheus.Histogram).Write(metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestPropagationClock(t *testing.T) {
+	var clock propagationClock
+	key := types.NamespacedName{Namespace: "default", Name: "app-config"}
+	first := time.Now().Add(-time.Second)
+	fallback := time.Now()
+
+	assert.Equal(t, fallback, clock.since(key, fallback))
+	clock.observe(key, first)
+	clock.observe(key, fallback)
+	assert.Equal(t, first, clock.since(key, fallback), "the earliest pending observation is kept")
+
+	clock.forget(key)
+	assert.Equal(t, fallback, clock.since(key, fallback))
+}
+
+func TestSourceReconciler_ObserveSourceEvents(t *testing.T) {
+	r := &SourceReconciler{}
+	predicate := r.observeSourceEvents()
+	source := makeWaveSource("app-config", "", "team-a")
+	mirror := makeUnstructuredMirror("app-config", "team-a", "default", "app-config")
+	fallback := time.Now().Add(time.Hour)
+
+	assert.True(t, predicate.Update(event.UpdateEvent{ObjectOld: source, ObjectNew: source}))
+	assert.True(t, r.propagation.since(client.ObjectKeyFromObject(source), fallback).Before(fallback))
+
+	assert.True(t, predicate.Create(event.CreateEvent{Object: mirror}))
+	assert.Equal(t, fallback, r.propagation.since(client.ObjectKeyFromObject(mirror), fallback), "mirrors are not tracked")
+}
+
+func TestSourceReconciler_Reconcile_RecordsPropagation(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	source := makeWaveSource("app-config", "", "team-a")
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(source,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}).
+		Build()
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)}
+	before := propagationSamples(t, "Secret.v1")
+
+	// The change was observed 250ms before it was reconciled
+	r.propagation.observe(req.NamespacedName, time.Now().Add(-250*time.Millisecond))
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	stored := makeUnstructuredSecret("", "", nil, nil)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, stored))
+	ms, err := strconv.Atoi(stored.GetAnnotations()[constants.AnnotationPropagationMs])
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, ms, 250)
+	assert.Equal(t, before+1, propagationSamples(t, "Secret.v1"))
+
+	// Reconciles without a content change observe nothing
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, before+1, propagationSamples(t, "Secret.v1"))
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+)
+
+// newRateLimiter returns the work queue rate limiter for a priority, or nil for
+// the controller-runtime default (5ms to 1000s per-item backoff, 10 qps overall).
+// It paces retries and requeues, which is where a failing or storming resource
+// type spends the API capacity it shares with the others.
+func newRateLimiter(priority config.Priority) workqueue.TypedRateLimiter[reconcile.Request] {
+	switch priority {
+	case config.PriorityHigh:
+		return workqueue.NewTypedMaxOfRateLimiter(
+			workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](5*time.Millisecond, 60*time.Second),
+			&workqueue.TypedBucketRateLimiter[reconcile.Request]{Limiter: rate.NewLimiter(rate.Limit(50), 500)},
+		)
+	case config.PriorityLow:
+		return workqueue.NewTypedMaxOfRateLimiter(
+			workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](time.Second, 1000*time.Second),
+			&workqueue.TypedBucketRateLimiter[reconcile.Request]{Limiter: rate.NewLimiter(rate.Limit(1), 10)},
+		)
+	default:
+		return nil
+	}
+}
+
+// controllerOptions returns the options of a controller reconciling gvk, with
+// the work queue rate limiter and concurrency configured for the type.
+func controllerOptions(cfg *config.Config, gvk schema.GroupVersionKind, sharded bool) controller.Options {
+	opts := controller.Options{NeedLeaderElection: needLeaderElection(sharded)}
+	if cfg == nil {
+		return opts
+	}
+	settings := cfg.QueueSettingsFor(gvk)
+	opts.RateLimiter = newRateLimiter(settings.Priority)
+	opts.MaxConcurrentReconciles = settings.MaxInFlight
+	return opts
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	item := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app"}}
+
+	assert.Nil(t, newRateLimiter(config.PriorityNormal), "normal uses the controller-runtime default")
+
+	high := newRateLimiter(config.PriorityHigh)
+	require.NotNil(t, high)
+	for i := 0; i < 30; i++ {
+		high.When(item)
+	}
+	assert.Equal(t, 60*time.Second, high.When(item), "high priority backoff is capped at a minute")
+
+	low := newRateLimiter(config.PriorityLow)
+	require.NotNil(t, low)
+	assert.GreaterOrEqual(t, low.When(item), time.Second, "low priority retries start after a second")
+}
+
+func TestControllerOptions(t *testing.T) {
+	secret := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	cfg := &config.Config{Queues: map[config.ResourceType]config.QueueSettings{
+		{Version: "v1", Kind: "Secret"}: {Priority: config.PriorityHigh, MaxInFlight: 4},
+	}}
+
+	opts := controllerOptions(cfg, secret, false)
+	assert.Equal(t, 4, opts.MaxConcurrentReconciles)
+	assert.NotNil(t, opts.RateLimiter)
+	require.NotNil(t, opts.NeedLeaderElection)
+	assert.True(t, *opts.NeedLeaderElection)
+
+	opts = controllerOptions(cfg, schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, true)
+	assert.Zero(t, opts.MaxConcurrentReconciles)
+	assert.Nil(t, opts.RateLimiter)
+	assert.False(t, *opts.NeedLeaderElection)
+
+	opts = controllerOptions(nil, secret, false)
+	assert.Nil(t, opts.RateLimiter)
+}
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// Event reasons of the per-namespace mirror quota.
+const (
+	// ReasonMirrorQuotaExceeded is used on the source and the target namespace when
+	// a mirror is not created because the namespace holds its max-mirrors already
+	ReasonMirrorQuotaExceeded = "MirrorQuotaExceeded"
+	// ReasonInvalidMirrorQuota is used on a namespace whose max-mirrors annotation
+	// cannot be parsed; the quota is not enforced
+	ReasonInvalidMirrorQuota = "InvalidMirrorQuota"
+)
+
+// mirrorQuotaExceededTotal counts mirrors not created over a namespace's quota.
+var mirrorQuotaExceededTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubemirror_mirror_quota_exceeded_total",
+	Help: "Number of mirrors not created because their target namespace reached its max-mirrors quota.",
+}, []string{"namespace"})
+
+func init() {
+	metrics.Registry.MustRegister(mirrorQuotaExceededTotal)
+}
+
+// MirrorQuotaExceededError reports a target namespace that already holds as many
+// mirrors as its max-mirrors annotation allows.
+type MirrorQuotaExceededError struct {
+	Namespace string
+	Limit     int
+}
+
+func (e *MirrorQuotaExceededError) Error() string {
+	return fmt.Sprintf("namespace %s already holds its quota of %d mirrors (%s)",
+		e.Namespace, e.Limit, constants.AnnotationMaxMirrors)
+}
+
+// isMirrorQuotaExceeded reports whether err is a MirrorQuotaExceededError.
+func isMirrorQuotaExceeded(err error) bool {
+	var quotaErr *MirrorQuotaExceededError
+	return errors.As(err, &quotaErr)
+}
+
+// mirrorQuota parses the namespace's max-mirrors annotation; ok is false without one.
+func mirrorQuota(namespace *corev1.Namespace) (limit int, ok bool, err error) {
+	value, ok := namespace.GetAnnotations()[constants.AnnotationMaxMirrors]
+	if !ok {
+		return 0, false, nil
+	}
+	limit, err = strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || limit < 0 {
+		return 0, false, fmt.Errorf("invalid %s %q: must be a non-negative integer", constants.AnnotationMaxMirrors, value)
+	}
+	return limit, true, nil
+}
+
+// checkMirrorQuota returns a MirrorQuotaExceededError when creating another mirror
+// in targetNs would exceed its quota, reported as Events on the source and the
+// namespace. Concurrent creates may overshoot the quota by the mirrors in flight.
+func (r *SourceReconciler) checkMirrorQuota(ctx context.Context, source *unstructured.Unstructured, targetNs string) error {
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, client.ObjectKey{Name: targetNs}, namespace); err != nil {
+		// A missing namespace fails the write itself
+		return client.IgnoreNotFound(err)
+	}
+	limit, ok, err := mirrorQuota(namespace)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "ignoring mirror quota", "targetNamespace", targetNs)
+		r.recordEvent(namespace, corev1.EventTypeWarning, ReasonInvalidMirrorQuota, "Mirror", "%s, not enforcing it", err.Error())
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+
+	count, err := r.countMirrors(ctx, source, targetNs)
+	if err != nil {
+		return err
+	}
+	if count < limit {
+		return nil
+	}
+
+	mirrorQuotaExceededTotal.WithLabelValues(targetNs).Inc()
+	quotaErr := &MirrorQuotaExceededError{Namespace: targetNs, Limit: limit}
+	r.recordEvent(source, corev1.EventTypeWarning, ReasonMirrorQuotaExceeded, "Mirror", "Not mirroring: %s", quotaErr.Error())
+	r.recordEvent(namespace, corev1.EventTypeWarning, ReasonMirrorQuotaExceeded, "Mirror",
+		"Not mirroring %s %s/%s: the namespace already holds its quota of %d mirrors",
+		source.GetKind(), source.GetNamespace(), source.GetName(), limit)
+	return quotaErr
+}
+
+// countMirrors counts the mirrors kubemirror keeps in namespace, across the
+// mirrored resource types.
+func (r *SourceReconciler) countMirrors(ctx context.Context, source *unstructured.Unstructured, namespace string) (int, error) {
+	gvk := source.GroupVersionKind()
+	resourceTypes := []config.ResourceType{{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}}
+	if r.ResourceTypes != nil {
+		resourceTypes = r.ResourceTypes()
+	}
+
+	var count int
+	for _, rt := range resourceTypes {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(rt.GroupVersionKind())
+		err := r.List(ctx, list, client.InNamespace(namespace), client.MatchingLabels{
+			constants.LabelManagedBy: constants.ControllerName,
+			constants.LabelMirror:    "true",
+		})
+		if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to count %s mirrors in %s: %w", rt, namespace, err)
+		}
+		count += len(list.Items)
+	}
+	return count, nil
+}
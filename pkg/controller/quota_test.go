@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+func TestMirrorQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantOK  bool
+		wantErr bool
+	}{
+		{name: "absent"},
+		{name: "zero", value: "0", want: 0, wantOK: true},
+		{name: "limit", value: " 50 ", want: 50, wantOK: true},
+		{name: "negative", value: "-1", wantErr: true},
+		{name: "invalid", value: "many", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}
+			if tt.name != "absent" {
+				namespace.Annotations = map[string]string{constants.AnnotationMaxMirrors: tt.value}
+			}
+			limit, ok, err := mirrorQuota(namespace)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, limit)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+func TestSourceReconciler_Reconcile_MirrorQuota(t *testing.T) {
+	ctx := context.Background()
+	existing := makeUnstructuredMirror("app-config", "team-a", "default", "app-config")
+	source := makeWaveSource("app-secret", "", "team-a,team-b")
+	c := newFakeClient(t, existing, source)
+
+	namespace := &corev1.Namespace{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "team-a"}, namespace))
+	namespace.Annotations = map[string]string{constants.AnnotationMaxMirrors: "1"}
+	require.NoError(t, c.Update(ctx, namespace))
+
+	recorder := events.NewFakeRecorder(10)
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		Recorder:        recorder,
+		GVK:             secretGVK,
+	}
+	before := testutil.ToFloat64(mirrorQuotaExceededTotal.WithLabelValues("team-a"))
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+	assert.Error(t, err, "the over-quota target fails")
+	assert.Equal(t, before+1, testutil.ToFloat64(mirrorQuotaExceededTotal.WithLabelValues("team-a")))
+
+	mirror := makeUnstructuredSecret("", "", nil, nil)
+	assert.Error(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, mirror))
+	assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-b", Name: "app-secret"}, mirror), "other targets are mirrored")
+
+	var quotaEvents int
+	for len(recorder.Events) > 0 {
+		event := <-recorder.Events
+		assert.NotContains(t, event, ReasonMirrorFailed)
+		if strings.Contains(event, ReasonMirrorQuotaExceeded) {
+			quotaEvents++
+		}
+	}
+	assert.Equal(t, 2, quotaEvents, "reported on the source and the namespace")
+
+	// Raising the quota lets the mirror through
+	namespace.Annotations[constants.AnnotationMaxMirrors] = "2"
+	require.NoError(t, c.Update(ctx, namespace))
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+	require.NoError(t, err)
+	assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, mirror))
+
+	// Existing mirrors are still updated over the quota
+	namespace.Annotations[constants.AnnotationMaxMirrors] = "1"
+	require.NoError(t, c.Update(ctx, namespace))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(source), source))
+	source.Object["data"] = map[string]interface{}{"key": "dXBkYXRlZA=="}
+	require.NoError(t, c.Update(ctx, source))
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, mirror))
+	assert.Equal(t, "dXBkYXRlZA==", mirror.Object["data"].(map[string]interface{})["key"])
+}
@@ -0,0 +1,418 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/circuitbreaker"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/hash"
+	"github.com/lukaszraczylo/kubemirror/pkg/naming"
+	"github.com/lukaszraczylo/kubemirror/pkg/status"
+)
+
+// Keys of a cluster Secret.
+const (
+	// ClusterSecretKubeconfigKey holds the kubeconfig of the remote cluster
+	ClusterSecretKubeconfigKey = "kubeconfig"
+	// ClusterSecretNameKey optionally overrides the cluster name (default: Secret name)
+	ClusterSecretNameKey = "name"
+)
+
+// allClustersKeyword selects every registered cluster in the target-clusters annotation.
+const allClustersKeyword = "all"
+
+// DefaultClusterRefreshInterval is how often cluster Secrets are rescanned.
+const DefaultClusterRefreshInterval = time.Minute
+
+// remoteRetryDelay is how soon a source is reconciled again after a remote cluster failed.
+const remoteRetryDelay = time.Minute
+
+// RemoteCluster is a registered remote cluster mirrors can be pushed to.
+type RemoteCluster struct {
+	Name   string
+	Client client.Client
+	// CircuitBreaker tracks failures of sources in this cluster only, so one
+	// unreachable cluster does not hold up the others
+	CircuitBreaker *circuitbreaker.CircuitBreaker
+
+	// configHash detects kubeconfig changes between refreshes
+	configHash string
+}
+
+// ClusterRegistry keeps clients for the remote clusters registered through
+// kubeconfig Secrets labeled kubemirror.raczylo.com/cluster=true.
+type ClusterRegistry struct {
+	// Reader reads the cluster Secrets (uncached, to avoid a cluster-wide Secret informer)
+	Reader client.Reader
+	// Namespace holds the cluster Secrets
+	Namespace string
+	// QPS and Burst rate limit each remote cluster independently (0 = client-go defaults)
+	QPS   float32
+	Burst int
+	// Interval is the time between Secret rescans when run as a manager runnable
+	Interval time.Duration
+	Log      logr.Logger
+
+	// newClient builds a client for a remote cluster (tests override it)
+	newClient func(*rest.Config) (client.Client, error)
+
+	mu       sync.RWMutex
+	clusters map[string]*RemoteCluster
+}
+
+// Start implements manager.Runnable, rescanning cluster Secrets every Interval.
+func (r *ClusterRegistry) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.Refresh(ctx); err != nil {
+				r.Log.Error(err, "failed to refresh remote clusters")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable: every replica
+// writing mirrors needs the remote clients.
+func (r *ClusterRegistry) NeedLeaderElection() bool {
+	return false
+}
+
+// Refresh reloads the cluster Secrets, building clients for new or changed
+// clusters and dropping removed ones. A Secret that cannot be loaded is skipped.
+func (r *ClusterRegistry) Refresh(ctx context.Context) error {
+	secrets := &corev1.SecretList{}
+	if err := r.Reader.List(ctx, secrets, client.InNamespace(r.Namespace),
+		client.MatchingLabels{constants.LabelClusterSecret: "true"}); err != nil {
+		return fmt.Errorf("failed to list cluster secrets: %w", err)
+	}
+
+	r.mu.RLock()
+	current := r.clusters
+	r.mu.RUnlock()
+
+	clusters := make(map[string]*RemoteCluster, len(secrets.Items))
+	var errs []error
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		name := secret.Name
+		if override := string(secret.Data[ClusterSecretNameKey]); override != "" {
+			name = override
+		}
+		if _, dup := clusters[name]; dup {
+			errs = append(errs, fmt.Errorf("cluster secret %s: duplicate cluster name %q", secret.Name, name))
+			continue
+		}
+
+		kubeconfig := secret.Data[ClusterSecretKubeconfigKey]
+		sum := sha256.Sum256(kubeconfig)
+		configHash := hex.EncodeToString(sum[:])
+		if existing, ok := current[name]; ok && existing.configHash == configHash {
+			clusters[name] = existing
+			continue
+		}
+
+		cluster, err := r.buildCluster(name, kubeconfig)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cluster secret %s: %w", secret.Name, err))
+			continue
+		}
+		cluster.configHash = configHash
+		clusters[name] = cluster
+		r.Log.Info("registered remote cluster", "cluster", name)
+	}
+
+	r.mu.Lock()
+	r.clusters = clusters
+	r.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// buildCluster creates a rate-limited client from a kubeconfig.
+func (r *ClusterRegistry) buildCluster(name string, kubeconfig []byte) (*RemoteCluster, error) {
+	if len(kubeconfig) == 0 {
+		return nil, fmt.Errorf("missing %q key", ClusterSecretKubeconfigKey)
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	if r.QPS > 0 {
+		restConfig.QPS = r.QPS
+	}
+	if r.Burst > 0 {
+		restConfig.Burst = r.Burst
+	}
+
+	newClient := r.newClient
+	if newClient == nil {
+		newClient = func(cfg *rest.Config) (client.Client, error) { return client.New(cfg, client.Options{}) }
+	}
+	c, err := newClient(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return &RemoteCluster{Name: name, Client: c, CircuitBreaker: circuitbreaker.NewWithDefaults()}, nil
+}
+
+// Clusters returns the registered clusters sorted by name.
+func (r *ClusterRegistry) Clusters() []*RemoteCluster {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clusters := make([]*RemoteCluster, 0, len(r.clusters))
+	for _, c := range r.clusters {
+		clusters = append(clusters, c)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters
+}
+
+// targetClusters parses the target-clusters annotation into a set of names (nil = none).
+func targetClusters(source client.Object) map[string]bool {
+	names := filter.ParseTargetNamespaces(source.GetAnnotations()[constants.AnnotationTargetClusters])
+	if len(names) == 0 {
+		return nil
+	}
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[name] = true
+	}
+	return selected
+}
+
+// syncRemoteClusters mirrors source into the registered clusters it selects and
+// removes its mirrors from the others. It returns the state of each remote target.
+func (r *SourceReconciler) syncRemoteClusters(ctx context.Context, source *unstructured.Unstructured) []status.TargetStatus {
+	if r.Clusters == nil {
+		return nil
+	}
+
+	selected := targetClusters(source)
+	var statuses []status.TargetStatus
+	for _, cluster := range r.Clusters.Clusters() {
+		logger := log.FromContext(ctx).WithValues("cluster", cluster.Name)
+		if !selected[cluster.Name] && !selected[allClustersKeyword] {
+			if err := r.cleanupRemoteMirrors(ctx, cluster, source, nil); err != nil {
+				logger.Error(err, "failed to remove mirrors from deselected cluster")
+			}
+			continue
+		}
+
+		if !cluster.CircuitBreaker.AllowRequest(source.GetNamespace(), source.GetName(), r.GVK.Kind) {
+			logger.V(1).Info("circuit breaker open for cluster, skipping")
+			statuses = append(statuses, status.TargetStatus{
+				Cluster: cluster.Name, State: status.TargetFailed, Error: "circuit breaker open",
+			})
+			continue
+		}
+
+		clusterStatuses, err := r.syncRemoteCluster(ctx, cluster, source)
+		statuses = append(statuses, clusterStatuses...)
+		failed := slices.ContainsFunc(clusterStatuses, func(s status.TargetStatus) bool { return s.State == status.TargetFailed })
+		switch {
+		case err != nil:
+			logger.Error(err, "failed to sync remote cluster")
+			cluster.CircuitBreaker.RecordFailure(source.GetNamespace(), source.GetName(), r.GVK.Kind, err)
+			statuses = append(statuses, status.TargetStatus{Cluster: cluster.Name, State: status.TargetFailed, Error: err.Error()})
+		case failed:
+			cluster.CircuitBreaker.RecordFailure(source.GetNamespace(), source.GetName(), r.GVK.Kind,
+				fmt.Errorf("failed to sync mirrors in cluster %s", cluster.Name))
+		default:
+			cluster.CircuitBreaker.RecordSuccess(source.GetNamespace(), source.GetName(), r.GVK.Kind)
+		}
+	}
+	return statuses
+}
+
+// syncRemoteCluster writes the mirrors of source into one remote cluster. Target
+// namespaces are resolved from the source's patterns against the remote cluster's
+// namespaces, along with its target-namespace-selector; unlike locally, the source's own namespace is a valid target there.
+func (r *SourceReconciler) syncRemoteCluster(ctx context.Context, cluster *RemoteCluster, source *unstructured.Unstructured) ([]status.TargetStatus, error) {
+	// Remote clusters unseal with keys of their own, which kubemirror does not have
+	if sealsMirrors(source) {
+		return nil, fmt.Errorf("%s mirrors cannot be written to remote clusters", constants.AnnotationSealedMirror)
+	}
+	patterns := append(filter.ParseTargetNamespaces(source.GetAnnotations()[constants.AnnotationTargetNamespaces]),
+		policyTargetPatterns(r.Policies, r.GVK, source)...)
+
+	nsInfo, err := NewKubernetesNamespaceLister(cluster.Client).ListNamespacesWithLabels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	targets := filter.ResolveTargetNamespaces(patterns, nsInfo.All, nsInfo.AllowMirrors, nsInfo.OptOut, "", r.Filter)
+	_, exclusions := filter.SplitExclusions(patterns)
+	if selector := targetNamespaceSelector(ctx, source); selector != nil {
+		targets = append(targets, filter.ExcludeNamespaces(filter.SelectNamespaces(selector, nsInfo.Labels, "", r.Filter), exclusions)...)
+	}
+	for _, pattern := range patterns {
+		if tenant, ok := filter.TenantPattern(pattern); ok {
+			members := filter.TenantNamespaces(tenant, nsInfo.Tenants, nsInfo.Labels, "", r.Filter)
+			targets = append(targets, filter.ExcludeNamespaces(members, exclusions)...)
+		}
+	}
+	slices.Sort(targets)
+	targets = slices.Compact(targets)
+	if targets, _, err = limitTargets(r.Config, source, targets, nsInfo.Created); err != nil {
+		return nil, err
+	}
+
+	contentHash, _ := hash.ComputeContentHash(source)
+	statuses := make([]status.TargetStatus, 0, len(targets))
+	for _, ns := range targets {
+		skipped, syncErr := r.syncRemoteMirror(ctx, cluster, source, ns)
+		switch {
+		case syncErr != nil:
+			log.FromContext(ctx).Error(syncErr, "failed to sync remote mirror", "cluster", cluster.Name, "targetNamespace", ns)
+			statuses = append(statuses, status.TargetStatus{
+				Cluster: cluster.Name, Namespace: ns, State: status.TargetFailed, Error: syncErr.Error(),
+			})
+		case skipped:
+			statuses = append(statuses, status.TargetStatus{
+				Cluster: cluster.Name, Namespace: ns, State: status.TargetSkipped, Error: "target exists and is not managed by kubemirror",
+			})
+		default:
+			statuses = append(statuses, status.TargetStatus{
+				Cluster: cluster.Name, Namespace: ns, State: status.TargetSynced, LastSyncTime: time.Now(), ContentHash: contentHash,
+			})
+		}
+	}
+
+	if err := r.cleanupRemoteMirrors(ctx, cluster, source, targets); err != nil {
+		return statuses, err
+	}
+	return statuses, nil
+}
+
+// syncRemoteMirror creates or updates the mirror of source in one namespace of a remote cluster.
+func (r *SourceReconciler) syncRemoteMirror(ctx context.Context, cluster *RemoteCluster, source *unstructured.Unstructured, targetNs string) (skipped bool, err error) {
+	mirrorName, err := naming.MirrorName(source)
+	if err != nil {
+		return false, err
+	}
+
+	opts := r.transformOptions()
+	opts.ClusterName = cluster.Name
+	opts.Namespace = NewNamespaceMetadata(cluster.Client)
+	desired, err := CreateMirrorWithOptions(source, targetNs, opts)
+	if err != nil {
+		return false, fmt.Errorf("failed to build mirror: %w", err)
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(source.GroupVersionKind())
+	err = cluster.Client.Get(ctx, client.ObjectKey{Namespace: targetNs, Name: mirrorName}, existing)
+	if apierrors.IsNotFound(err) {
+		existing = nil
+	}
+	force := shouldForceApply(source)
+	switch {
+	case err == nil && !IsManagedByUs(existing):
+		policy, policyErr := r.conflictPolicy(source)
+		if policyErr != nil {
+			return false, policyErr
+		}
+		switch policy {
+		case ConflictFail:
+			return false, &TargetConflictError{Namespace: targetNs, Name: mirrorName}
+		case ConflictOverwrite:
+			force = true
+		default:
+			return true, nil
+		}
+	case err == nil:
+		needsSync, syncErr := hash.NeedsSync(source, existing, existing.GetAnnotations())
+		if syncErr != nil {
+			return false, fmt.Errorf("failed to check if sync needed: %w", syncErr)
+		}
+		if !needsSync && !transformedContentChanged(desired, existing) && hasMirrorMetadata(existing, r.Config) {
+			return false, nil
+		}
+	case !apierrors.IsNotFound(err):
+		return false, fmt.Errorf("failed to get existing mirror: %w", err)
+	}
+
+	desiredU, ok := desired.(*unstructured.Unstructured)
+	if !ok {
+		return false, fmt.Errorf("failed to build mirror: unexpected mirror type %T", desired)
+	}
+	labels := desiredU.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[constants.LabelSourceUID] = string(source.GetUID())
+	desiredU.SetLabels(labels)
+	stampMirrorMetadata(desiredU, r.Config)
+
+	if _, err := applyOrRecreate(ctx, cluster.Client, source, existing, desiredU, force); err != nil {
+		return false, fmt.Errorf("failed to apply mirror: %w", err)
+	}
+	r.auditMirrorWrite(ctx, source, cluster.Name, targetNs)
+	return false, nil
+}
+
+// cleanupRemoteMirrors deletes the mirrors of source in a remote cluster outside
+// keep (nil = delete all of them), and those left under a previous name.
+func (r *SourceReconciler) cleanupRemoteMirrors(ctx context.Context, cluster *RemoteCluster, source client.Object, keep []string) error {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(r.GVK)
+	if err := cluster.Client.List(ctx, list, client.MatchingLabels{
+		constants.LabelManagedBy: constants.ControllerName,
+		constants.LabelSourceUID: string(source.GetUID()),
+	}); err != nil {
+		return fmt.Errorf("failed to list mirrors in cluster %s: %w", cluster.Name, err)
+	}
+
+	mirrorName := naming.MirrorNameOrDefault(source)
+	var errs []error
+	for i := range list.Items {
+		mirror := &list.Items[i]
+		if slices.Contains(keep, mirror.GetNamespace()) && mirror.GetName() == mirrorName {
+			continue
+		}
+		if err := cluster.Client.Delete(ctx, mirror); client.IgnoreNotFound(err) != nil {
+			errs = append(errs, fmt.Errorf("failed to delete mirror %s/%s in cluster %s: %w",
+				mirror.GetNamespace(), mirror.GetName(), cluster.Name, err))
+			continue
+		}
+		log.FromContext(ctx).V(1).Info("deleted remote mirror", "cluster", cluster.Name, "namespace", mirror.GetNamespace())
+	}
+	return errors.Join(errs...)
+}
+
+// deleteRemoteMirrors deletes the mirrors of source from every registered cluster.
+func (r *SourceReconciler) deleteRemoteMirrors(ctx context.Context, source client.Object) error {
+	if r.Clusters == nil {
+		return nil
+	}
+	var errs []error
+	for _, cluster := range r.Clusters.Clusters() {
+		if err := r.cleanupRemoteMirrors(ctx, cluster, source, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
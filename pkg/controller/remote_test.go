@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/circuitbreaker"
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/status"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: remote
+  cluster:
+    server: https://remote.example.com
+contexts:
+- name: remote
+  context:
+    cluster: remote
+    user: remote
+current-context: remote
+users:
+- name: remote
+  user:
+    token: secret-token
+`
+
+func clusterSecret(name string, data map[string]string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "kubemirror-system",
+			Labels:    map[string]string{constants.LabelClusterSecret: "true"},
+		},
+		StringData: data,
+	}
+}
+
+func TestClusterRegistry_Refresh(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	// The fake client does not convert StringData, so set Data directly
+	withData := func(s *corev1.Secret) *corev1.Secret {
+		s.Data = map[string][]byte{}
+		for k, v := range s.StringData {
+			s.Data[k] = []byte(v)
+		}
+		s.StringData = nil
+		return s
+	}
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		withData(clusterSecret("prod-eu", map[string]string{ClusterSecretKubeconfigKey: testKubeconfig})),
+		withData(clusterSecret("renamed", map[string]string{ClusterSecretKubeconfigKey: testKubeconfig, ClusterSecretNameKey: "prod-us"})),
+		withData(clusterSecret("broken", map[string]string{})),
+	).Build()
+
+	var configs []*rest.Config
+	registry := &ClusterRegistry{
+		Reader:    reader,
+		Namespace: "kubemirror-system",
+		QPS:       7,
+		Burst:     9,
+		newClient: func(cfg *rest.Config) (client.Client, error) {
+			configs = append(configs, cfg)
+			return fake.NewClientBuilder().Build(), nil
+		},
+	}
+
+	err := registry.Refresh(context.Background())
+	assert.ErrorContains(t, err, "cluster secret broken")
+
+	clusters := registry.Clusters()
+	require.Len(t, clusters, 2)
+	assert.Equal(t, "prod-eu", clusters[0].Name)
+	assert.Equal(t, "prod-us", clusters[1].Name)
+	require.Len(t, configs, 2)
+	assert.Equal(t, "https://remote.example.com", configs[0].Host)
+	assert.Equal(t, float32(7), configs[0].QPS)
+	assert.Equal(t, 9, configs[0].Burst)
+
+	// Unchanged kubeconfigs keep their client and circuit breaker
+	first := clusters[0]
+	_ = registry.Refresh(context.Background())
+	assert.Same(t, first, registry.Clusters()[0])
+	assert.Len(t, configs, 2)
+
+	// Removed Secrets drop the cluster
+	require.NoError(t, reader.Delete(context.Background(), clusterSecret("renamed", nil)))
+	_ = registry.Refresh(context.Background())
+	assert.Len(t, registry.Clusters(), 1)
+}
+
+// newRemoteFixture returns a reconciler with one registered remote cluster and its client.
+func newRemoteFixture(t *testing.T) (*SourceReconciler, client.Client) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	var objs []client.Object
+	for _, ns := range []string{"default", "app-1", "other"} {
+		objs = append(objs, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}})
+	}
+	remote := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+	registry := &ClusterRegistry{clusters: map[string]*RemoteCluster{
+		"prod": {Name: "prod", Client: remote, CircuitBreaker: circuitbreaker.NewWithDefaults()},
+	}}
+	return &SourceReconciler{
+		Config:   &config.Config{},
+		Filter:   filter.NewNamespaceFilter(nil, nil),
+		GVK:      secretGVK,
+		Clusters: registry,
+	}, remote
+}
+
+func TestSourceReconciler_SyncRemoteClusters(t *testing.T) {
+	r, remote := newRemoteFixture(t)
+	ctx := context.Background()
+
+	source := makeUnstructuredSecret("app-secret", "default", map[string]string{constants.LabelEnabled: "true"},
+		map[string]string{
+			constants.AnnotationSync:             "true",
+			constants.AnnotationTargetNamespaces: "app-*,default",
+			constants.AnnotationTargetClusters:   "prod",
+		})
+	source.SetUID("source-uid")
+
+	statuses := r.syncRemoteClusters(ctx, source)
+	require.Len(t, statuses, 2)
+	for _, s := range statuses {
+		assert.Equal(t, "prod", s.Cluster)
+		assert.Equal(t, status.TargetSynced, s.State, s.Error)
+	}
+
+	// The source namespace is a valid target in a remote cluster
+	for _, ns := range []string{"app-1", "default"} {
+		mirror := &unstructured.Unstructured{}
+		mirror.SetGroupVersionKind(secretGVK)
+		require.NoError(t, remote.Get(ctx, client.ObjectKey{Namespace: ns, Name: "app-secret"}, mirror))
+		assert.Equal(t, "source-uid", mirror.GetLabels()[constants.LabelSourceUID])
+	}
+
+	// Dropping the cluster from the annotation removes the remote mirrors
+	annotations := source.GetAnnotations()
+	delete(annotations, constants.AnnotationTargetClusters)
+	source.SetAnnotations(annotations)
+	assert.Empty(t, r.syncRemoteClusters(ctx, source))
+
+	mirror := &unstructured.Unstructured{}
+	mirror.SetGroupVersionKind(secretGVK)
+	err := remote.Get(ctx, client.ObjectKey{Namespace: "app-1", Name: "app-secret"}, mirror)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestSourceReconciler_SyncRemoteClusters_SkipsUnmanaged(t *testing.T) {
+	r, remote := newRemoteFixture(t)
+	ctx := context.Background()
+	require.NoError(t, remote.Create(ctx, makeUnstructuredSecret("app-secret", "app-1", nil, nil)))
+
+	source := makeUnstructuredSecret("app-secret", "default", nil, map[string]string{
+		constants.AnnotationTargetNamespaces: "app-1",
+		constants.AnnotationTargetClusters:   "all",
+	})
+
+	statuses := r.syncRemoteClusters(ctx, source)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, status.TargetSkipped, statuses[0].State)
+}
+
+func TestSourceReconciler_DeleteRemoteMirrors(t *testing.T) {
+	r, remote := newRemoteFixture(t)
+	ctx := context.Background()
+
+	mirror := makeUnstructuredMirror("app-secret", "app-1", "default", "app-secret")
+	labels := mirror.GetLabels()
+	labels[constants.LabelSourceUID] = "source-uid"
+	mirror.SetLabels(labels)
+	require.NoError(t, remote.Create(ctx, mirror))
+	// Mirrors of other sources are kept
+	require.NoError(t, remote.Create(ctx, makeUnstructuredMirror("other-secret", "app-1", "default", "other-secret")))
+
+	source := makeUnstructuredSecret("app-secret", "default", nil, nil)
+	source.SetUID("source-uid")
+	require.NoError(t, r.deleteRemoteMirrors(ctx, source))
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(secretGVK)
+	require.NoError(t, remote.List(ctx, list))
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "other-secret", list.Items[0].GetName())
+}
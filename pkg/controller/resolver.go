@@ -0,0 +1,320 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+// AnnotationResolverName is the name of the default resolver, which reads the
+// target-namespaces annotation.
+const AnnotationResolverName = "annotation"
+
+// TargetResolver decides which namespaces receive mirrors of a source.
+//
+// Resolvers only encode tenancy rules: the reconcilers drop the source namespace
+// and namespaces rejected by the namespace filter from the result, and enforce
+// MaxTargetsPerResource. A source without targets returns nil.
+type TargetResolver interface {
+	ResolveTargets(ctx context.Context, source client.Object) ([]string, error)
+}
+
+// TargetResolverFunc adapts a function to TargetResolver.
+type TargetResolverFunc func(ctx context.Context, source client.Object) ([]string, error)
+
+// ResolveTargets implements TargetResolver.
+func (f TargetResolverFunc) ResolveTargets(ctx context.Context, source client.Object) ([]string, error) {
+	return f(ctx, source)
+}
+
+// ResolverDeps are the shared dependencies handed to resolver factories.
+type ResolverDeps struct {
+	Client          client.Client
+	NamespaceLister NamespaceLister
+	Filter          *filter.NamespaceFilter
+	Config          *config.Config
+}
+
+// TargetResolverFactory builds a resolver from the controller's dependencies.
+type TargetResolverFactory func(deps ResolverDeps) (TargetResolver, error)
+
+var (
+	resolverFactoriesMu sync.RWMutex
+	resolverFactories   = map[string]TargetResolverFactory{
+		AnnotationResolverName: func(deps ResolverDeps) (TargetResolver, error) {
+			return &AnnotationResolver{NamespaceLister: deps.NamespaceLister, Filter: deps.Filter}, nil
+		},
+	}
+)
+
+// RegisterTargetResolver makes a resolver available by name (e.g. to --target-resolvers),
+// so custom builds can add tenancy rules from an init function without changing the
+// reconcilers. It panics if the name is empty or already registered.
+func RegisterTargetResolver(name string, factory TargetResolverFactory) {
+	resolverFactoriesMu.Lock()
+	defer resolverFactoriesMu.Unlock()
+
+	if name == "" || factory == nil {
+		panic("controller: RegisterTargetResolver requires a name and a factory")
+	}
+	if _, exists := resolverFactories[name]; exists {
+		panic(fmt.Sprintf("controller: target resolver %q already registered", name))
+	}
+	resolverFactories[name] = factory
+}
+
+// RegisteredTargetResolvers returns the names of all registered resolvers, sorted.
+func RegisteredTargetResolvers() []string {
+	resolverFactoriesMu.RLock()
+	defer resolverFactoriesMu.RUnlock()
+
+	names := make([]string, 0, len(resolverFactories))
+	for name := range resolverFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewTargetResolver builds the named resolvers and combines them: a source is
+// mirrored to the union of their targets. No names selects the annotation resolver.
+func NewTargetResolver(names []string, deps ResolverDeps) (TargetResolver, error) {
+	if len(names) == 0 {
+		names = []string{AnnotationResolverName}
+	}
+
+	resolverFactoriesMu.RLock()
+	defer resolverFactoriesMu.RUnlock()
+
+	resolvers := make(unionResolver, 0, len(names))
+	for _, name := range names {
+		factory, ok := resolverFactories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown target resolver %q", name)
+		}
+		resolver, err := factory(deps)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create target resolver %q: %w", name, err)
+		}
+		resolvers = append(resolvers, resolver)
+	}
+
+	if len(resolvers) == 1 {
+		return resolvers[0], nil
+	}
+	return resolvers, nil
+}
+
+// unionResolver mirrors a source to every namespace any of its resolvers returns.
+type unionResolver []TargetResolver
+
+// ResolveTargets implements TargetResolver.
+func (u unionResolver) ResolveTargets(ctx context.Context, source client.Object) ([]string, error) {
+	var targets []string
+	for _, resolver := range u {
+		resolved, err := resolver.ResolveTargets(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, resolved...)
+	}
+	return targets, nil
+}
+
+// AnnotationResolver resolves the patterns of the target-namespaces annotation
+// ("all", "all-labeled", "descendants", "tenant:" groups, globs and names) and the
+// label selector of the target-namespace-selector annotation. It is the default resolver.
+type AnnotationResolver struct {
+	NamespaceLister NamespaceLister
+	Filter          *filter.NamespaceFilter
+}
+
+// ResolveTargets implements TargetResolver.
+func (a *AnnotationResolver) ResolveTargets(ctx context.Context, source client.Object) ([]string, error) {
+	annotations := source.GetAnnotations()
+	if annotations == nil {
+		return nil, nil
+	}
+
+	patterns := filter.ParseTargetNamespaces(annotations[constants.AnnotationTargetNamespaces])
+	targets, err := a.resolvePatterns(ctx, source, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := targetNamespaceSelector(ctx, source)
+	if selector == nil {
+		return targets, nil
+	}
+	nsInfo, err := a.NamespaceLister.ListNamespacesWithLabels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	// Exclusions in target-namespaces carve namespaces out of the selector's too
+	_, exclusions := filter.SplitExclusions(patterns)
+	selected := filter.SelectNamespaces(selector, nsInfo.Labels, source.GetNamespace(), a.Filter)
+	return append(targets, filter.ExcludeNamespaces(selected, exclusions)...), nil
+}
+
+// targetNamespaceSelector returns the parsed target-namespace-selector annotation
+// of source, or nil if it has none. An invalid selector is logged and ignored,
+// like an invalid pattern in target-namespaces.
+func targetNamespaceSelector(ctx context.Context, source client.Object) labels.Selector {
+	value := source.GetAnnotations()[constants.AnnotationTargetNamespaceSelector]
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	selector, err := filter.ParseNamespaceSelector(value)
+	if err != nil {
+		log.FromContext(ctx).Info("invalid label selector in target-namespace-selector annotation, selector will be skipped",
+			"selector", value,
+			"error", err.Error(),
+			"source", source.GetName(),
+			"namespace", source.GetNamespace(),
+		)
+		return nil
+	}
+	return selector
+}
+
+// resolvePatterns resolves target namespace patterns against the cluster's namespaces.
+func (a *AnnotationResolver) resolvePatterns(ctx context.Context, source client.Object, patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	// Validate patterns and log warnings for invalid ones
+	validationResults, allValid := filter.ValidatePatterns(patterns)
+	if !allValid {
+		logger := log.FromContext(ctx)
+		invalidPatterns := filter.InvalidPatterns(validationResults)
+		for _, invalid := range invalidPatterns {
+			logger.Info("invalid glob pattern in target-namespaces annotation, pattern will be skipped",
+				"pattern", invalid.Pattern,
+				"error", invalid.Error.Error(),
+				"source", source.GetName(),
+				"namespace", source.GetNamespace(),
+			)
+		}
+
+		// Filter to only valid patterns
+		var validPatterns []string
+		for _, result := range validationResults {
+			if result.Valid {
+				validPatterns = append(validPatterns, result.Pattern)
+			}
+		}
+		patterns = validPatterns
+
+		// If no valid patterns remain, return empty
+		if len(patterns) == 0 {
+			return nil, nil
+		}
+	}
+
+	// Get all namespace info in a single API call (more efficient than 3 separate calls)
+	nsInfo, err := a.NamespaceLister.ListNamespacesWithLabels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	// Resolve target namespaces using the pre-categorized namespace info
+	targets := filter.ResolveTargetNamespaces(
+		patterns,
+		nsInfo.All,
+		nsInfo.AllowMirrors,
+		nsInfo.OptOut,
+		source.GetNamespace(),
+		a.Filter,
+	)
+	_, exclusions := filter.SplitExclusions(patterns)
+	// Descendants come from the HNC hierarchy labels of the namespaces
+	if slices.Contains(patterns, constants.TargetNamespacesDescendants) {
+		descendants := filter.DescendantNamespaces(nsInfo.Labels, source.GetNamespace(), a.Filter)
+		targets = append(targets, filter.ExcludeNamespaces(descendants, exclusions)...)
+	}
+	// Tenants come from the tenant label of the namespaces
+	for _, pattern := range patterns {
+		if tenant, ok := filter.TenantPattern(pattern); ok {
+			members := filter.TenantNamespaces(tenant, nsInfo.Tenants, nsInfo.Labels, source.GetNamespace(), a.Filter)
+			targets = append(targets, filter.ExcludeNamespaces(members, exclusions)...)
+		}
+	}
+	return targets, nil
+}
+
+// ResolveTargetNamespaces returns the namespaces source is mirrored to, resolved
+// as the source reconciler does apart from mirror policies, and the namespaces
+// dropped over the max-targets limit of the source or cfg, both sorted. Under the
+// fail truncation policy, a source over its limit has no targets and every
+// namespace is omitted. It lets tools explain target resolution without running
+// the controller.
+func ResolveTargetNamespaces(ctx context.Context, resolver TargetResolver, lister NamespaceLister,
+	nsFilter *filter.NamespaceFilter, cfg *config.Config, source client.Object) (targets, omitted []string, err error) {
+	targets, err = resolveTargets(ctx, resolver, lister, nsFilter, source, nil)
+	if err != nil || len(targets) == 0 {
+		return nil, nil, err
+	}
+	targets, omitted, err = truncateTargets(ctx, cfg, lister, source, targets)
+	if tooManyTargets(err) != nil {
+		return nil, omitted, nil
+	}
+	return targets, omitted, err
+}
+
+// resolveTargets runs resolver (nil = annotation resolver) for source, adds the
+// namespaces matching policyPatterns (from mirror policies selecting the source),
+// and applies the rules every result is subject to: no duplicates, never the
+// source namespace, and only namespaces allowed by nsFilter. The result is sorted,
+// so it does not depend on the order namespaces were listed in. The source,
+// namespace and wave reconcilers all resolve targets here, so allow-mirrors="false"
+// opt-outs apply the same way whichever of them creates a mirror: to keywords,
+// globs, regular expressions and selectors, but not to namespaces named explicitly.
+func resolveTargets(ctx context.Context, resolver TargetResolver, lister NamespaceLister,
+	nsFilter *filter.NamespaceFilter, source client.Object, policyPatterns []string) ([]string, error) {
+	annotationResolver := &AnnotationResolver{NamespaceLister: lister, Filter: nsFilter}
+	if resolver == nil {
+		resolver = annotationResolver
+	}
+
+	resolved, err := resolver.ResolveTargets(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	if len(policyPatterns) > 0 {
+		fromPolicies, policyErr := annotationResolver.resolvePatterns(ctx, source, policyPatterns)
+		if policyErr != nil {
+			return nil, policyErr
+		}
+		resolved = append(resolved, fromPolicies...)
+	}
+
+	seen := make(map[string]bool, len(resolved))
+	targets := make([]string, 0, len(resolved))
+	for _, ns := range resolved {
+		if ns == "" || ns == source.GetNamespace() || seen[ns] {
+			continue
+		}
+		seen[ns] = true
+		if nsFilter != nil && !nsFilter.IsAllowed(ns) {
+			continue
+		}
+		targets = append(targets, ns)
+	}
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	slices.Sort(targets)
+	return targets, nil
+}
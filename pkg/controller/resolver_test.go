@@ -0,0 +1,294 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+// staticResolver returns fixed targets for every source.
+func staticResolver(targets ...string) TargetResolver {
+	return TargetResolverFunc(func(context.Context, client.Object) ([]string, error) {
+		return targets, nil
+	})
+}
+
+// registerTestResolver registers a resolver for the duration of the test.
+func registerTestResolver(t *testing.T, name string, factory TargetResolverFactory) {
+	t.Helper()
+	RegisterTargetResolver(name, factory)
+	t.Cleanup(func() {
+		resolverFactoriesMu.Lock()
+		delete(resolverFactories, name)
+		resolverFactoriesMu.Unlock()
+	})
+}
+
+func TestNewTargetResolver(t *testing.T) {
+	registerTestResolver(t, "test-static", func(ResolverDeps) (TargetResolver, error) {
+		return staticResolver("tenant-a"), nil
+	})
+	registerTestResolver(t, "test-broken", func(ResolverDeps) (TargetResolver, error) {
+		return nil, errors.New("no policy CRD")
+	})
+
+	t.Run("defaults to the annotation resolver", func(t *testing.T) {
+		resolver, err := NewTargetResolver(nil, ResolverDeps{})
+		require.NoError(t, err)
+		assert.IsType(t, &AnnotationResolver{}, resolver)
+	})
+
+	t.Run("unknown resolver", func(t *testing.T) {
+		_, err := NewTargetResolver([]string{"nope"}, ResolverDeps{})
+		assert.ErrorContains(t, err, `unknown target resolver "nope"`)
+	})
+
+	t.Run("factory error", func(t *testing.T) {
+		_, err := NewTargetResolver([]string{"test-broken"}, ResolverDeps{})
+		assert.ErrorContains(t, err, "no policy CRD")
+	})
+
+	t.Run("union of resolvers", func(t *testing.T) {
+		lister := new(MockNamespaceLister)
+		lister.On("ListNamespacesWithLabels", mock.Anything).Return(&NamespaceInfo{All: []string{"app1", "app2"}}, nil)
+
+		resolver, err := NewTargetResolver([]string{AnnotationResolverName, "test-static"}, ResolverDeps{
+			NamespaceLister: lister,
+			Filter:          filter.NewNamespaceFilter(nil, nil),
+		})
+		require.NoError(t, err)
+
+		source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "creds",
+			Namespace:   "default",
+			Annotations: map[string]string{constants.AnnotationTargetNamespaces: "app1"},
+		}}
+		targets, err := resolver.ResolveTargets(context.Background(), source)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"app1", "tenant-a"}, targets)
+	})
+
+	assert.Contains(t, RegisteredTargetResolvers(), AnnotationResolverName)
+	assert.Contains(t, RegisteredTargetResolvers(), "test-static")
+}
+
+func TestRegisterTargetResolver_Duplicate(t *testing.T) {
+	assert.Panics(t, func() {
+		RegisterTargetResolver(AnnotationResolverName, func(ResolverDeps) (TargetResolver, error) { return nil, nil })
+	})
+}
+
+func TestSourceReconciler_resolveTargetNamespaces_CustomResolver(t *testing.T) {
+	r := &SourceReconciler{
+		Config: &config.Config{MaxTargetsPerResource: 2},
+		Filter: filter.NewNamespaceFilter([]string{"kube-system"}, nil),
+		// Duplicates, the source namespace and excluded namespaces are dropped
+		TargetResolver: staticResolver("tenant-b", "default", "kube-system", "tenant-a", "tenant-b", "tenant-c"),
+	}
+	source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default"}}
+
+	targets, err := r.resolveTargetNamespaces(context.Background(), source)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tenant-a", "tenant-b"}, targets)
+}
+
+func TestSourceReconciler_resolveTargetNamespaces_ResolverError(t *testing.T) {
+	r := &SourceReconciler{
+		Config: &config.Config{},
+		TargetResolver: TargetResolverFunc(func(context.Context, client.Object) ([]string, error) {
+			return nil, errors.New("webhook unavailable")
+		}),
+	}
+	source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default"}}
+
+	_, err := r.resolveTargetNamespaces(context.Background(), source)
+	assert.ErrorContains(t, err, "webhook unavailable")
+}
+
+func TestResolveTargetNamespaces(t *testing.T) {
+	source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default"}}
+	nsFilter := filter.NewNamespaceFilter([]string{"kube-system"}, nil)
+	resolver := staticResolver("tenant-c", "kube-system", "tenant-a", "tenant-b")
+
+	targets, omitted, err := ResolveTargetNamespaces(context.Background(), resolver, nil, nsFilter,
+		&config.Config{MaxTargetsPerResource: 2}, source)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tenant-a", "tenant-b"}, targets)
+	assert.Equal(t, []string{"tenant-c"}, omitted)
+
+	targets, omitted, err = ResolveTargetNamespaces(context.Background(), staticResolver(), nil, nsFilter, &config.Config{}, source)
+	require.NoError(t, err)
+	assert.Empty(t, targets)
+	assert.Empty(t, omitted)
+}
+
+func TestAnnotationResolver_NamespaceSelector(t *testing.T) {
+	lister := new(MockNamespaceLister)
+	lister.On("ListNamespacesWithLabels", mock.Anything).Return(&NamespaceInfo{
+		All: []string{"default", "payments-prod", "payments-dev", "shop-prod", "app1"},
+		Labels: map[string]map[string]string{
+			"default":       {"team": "payments", "env": "prod"},
+			"payments-prod": {"team": "payments", "env": "prod"},
+			"payments-dev":  {"team": "payments", "env": "dev"},
+			"shop-prod":     {"team": "shop", "env": "prod"},
+			"app1":          nil,
+		},
+	}, nil)
+	resolver := &AnnotationResolver{NamespaceLister: lister, Filter: filter.NewNamespaceFilter(nil, nil)}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        []string
+	}{
+		{
+			name:        "selector only",
+			annotations: map[string]string{constants.AnnotationTargetNamespaceSelector: "team=payments,env in (prod,staging)"},
+			want:        []string{"payments-prod"},
+		},
+		{
+			name: "union with target-namespaces",
+			annotations: map[string]string{
+				constants.AnnotationTargetNamespaces:        "app1",
+				constants.AnnotationTargetNamespaceSelector: "env=prod",
+			},
+			want: []string{"app1", "payments-prod", "shop-prod"},
+		},
+		{
+			name: "invalid selector is skipped",
+			annotations: map[string]string{
+				constants.AnnotationTargetNamespaces:        "app1",
+				constants.AnnotationTargetNamespaceSelector: "env in (prod",
+			},
+			want: []string{"app1"},
+		},
+		{
+			name: "exclusions apply to the selector",
+			annotations: map[string]string{
+				constants.AnnotationTargetNamespaces:        "app1,!shop-*",
+				constants.AnnotationTargetNamespaceSelector: "env=prod",
+			},
+			want: []string{"app1", "payments-prod"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default", Annotations: tt.annotations}}
+			targets, err := resolver.ResolveTargets(context.Background(), source)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, targets)
+		})
+	}
+}
+
+func TestAnnotationResolver_Descendants(t *testing.T) {
+	lister := new(MockNamespaceLister)
+	lister.On("ListNamespacesWithLabels", mock.Anything).Return(&NamespaceInfo{
+		All: []string{"org", "team-a", "team-a-dev", "shared"},
+		Labels: map[string]map[string]string{
+			"org":        {"org" + constants.HNCTreeDepthLabelSuffix: "0"},
+			"team-a":     {"org" + constants.HNCTreeDepthLabelSuffix: "1", "team-a" + constants.HNCTreeDepthLabelSuffix: "0"},
+			"team-a-dev": {"org" + constants.HNCTreeDepthLabelSuffix: "2", "team-a" + constants.HNCTreeDepthLabelSuffix: "1"},
+			"shared":     nil,
+		},
+	}, nil)
+	resolver := &AnnotationResolver{NamespaceLister: lister, Filter: filter.NewNamespaceFilter(nil, nil)}
+
+	tests := []struct {
+		name    string
+		targets string
+		want    []string
+	}{
+		{name: "descendants", targets: "descendants", want: []string{"team-a", "team-a-dev"}},
+		{name: "combined with names", targets: "descendants,shared", want: []string{"shared", "team-a", "team-a-dev"}},
+		{name: "with exclusions", targets: "descendants,!*-dev", want: []string{"team-a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "org",
+				Annotations: map[string]string{constants.AnnotationTargetNamespaces: tt.targets}}}
+			targets, err := resolver.ResolveTargets(context.Background(), source)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, targets)
+		})
+	}
+}
+
+func TestAnnotationResolver_Tenant(t *testing.T) {
+	lister := new(MockNamespaceLister)
+	lister.On("ListNamespacesWithLabels", mock.Anything).Return(&NamespaceInfo{
+		All: []string{"default", "payments-prod", "payments-dev", "shop-prod", "shared"},
+		Labels: map[string]map[string]string{
+			"default":       nil,
+			"payments-prod": {constants.LabelTenant: "payments"},
+			"payments-dev":  {constants.LabelTenant: "payments"},
+			"shop-prod":     {constants.LabelTenant: "shop"},
+			"shared":        nil,
+		},
+		Tenants: map[string][]string{"payments": {"payments-dev", "payments-prod"}, "shop": {"shop-prod"}},
+	}, nil)
+	resolver := &AnnotationResolver{NamespaceLister: lister, Filter: filter.NewNamespaceFilter(nil, nil)}
+
+	tests := []struct {
+		name    string
+		targets string
+		want    []string
+	}{
+		{name: "tenant", targets: "tenant:payments", want: []string{"payments-dev", "payments-prod"}},
+		{name: "several tenants and names", targets: "tenant:payments,tenant:shop,shared", want: []string{"payments-dev", "payments-prod", "shared", "shop-prod"}},
+		{name: "with exclusions", targets: "tenant:payments,!*-dev", want: []string{"payments-prod"}},
+		{name: "unknown tenant", targets: "tenant:unknown", want: nil},
+		{name: "invalid tenant is skipped", targets: "tenant:bad name,shared", want: []string{"shared"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default",
+				Annotations: map[string]string{constants.AnnotationTargetNamespaces: tt.targets}}}
+			targets, err := resolver.ResolveTargets(context.Background(), source)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, targets)
+		})
+	}
+}
+
+func TestResolveTargetNamespaces_WatchedNamespaces(t *testing.T) {
+	watched := []string{"default", "team-a", "team-b"}
+	nsFilter := filter.NewNamespaceFilter(nil, nil)
+	nsFilter.Restrict(watched)
+	lister := NewStaticNamespaceLister(watched)
+
+	tests := []struct {
+		name    string
+		targets string
+		want    []string
+	}{
+		{name: "all", targets: "all", want: []string{"team-a", "team-b"}},
+		{name: "pattern", targets: "team-*", want: []string{"team-a", "team-b"}},
+		{name: "unwatched names are dropped", targets: "team-a,team-c", want: []string{"team-a"}},
+		{name: "labels cannot be read", targets: "all-labeled", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name:        "creds",
+				Namespace:   "default",
+				Annotations: map[string]string{constants.AnnotationTargetNamespaces: tt.targets},
+			}}
+			targets, _, err := ResolveTargetNamespaces(context.Background(), nil, lister, nsFilter, &config.Config{}, source)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, targets)
+		})
+	}
+}
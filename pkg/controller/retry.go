@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+)
+
+// retryJitter is the largest fraction added to a retry delay, so sources failing
+// together are not all retried at the same moment.
+const retryJitter = 0.2
+
+// retriesScheduledTotal counts retries of failed mirror writes by error class.
+var retriesScheduledTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubemirror_retries_scheduled_total",
+	Help: "Number of sources requeued on the retry schedule of the error class their mirror writes failed with.",
+}, []string{"resource_type", "class"})
+
+func init() {
+	metrics.Registry.MustRegister(retriesScheduledTotal)
+}
+
+// RetryAfterError is returned by reconciles whose mirror writes all failed with
+// errors of a known class. The reconcile is retried after After rather than
+// with the work queue's backoff.
+type RetryAfterError struct {
+	Class config.ErrorClass
+	After time.Duration
+	Err   error
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// classifyError returns the error class of a failed mirror write, or "" if it
+// has none.
+func classifyError(err error) config.ErrorClass {
+	switch {
+	case isMirrorQuotaExceeded(err), apierrors.IsForbidden(err) && strings.Contains(err.Error(), "exceeded quota"):
+		return config.ErrorClassQuotaExceeded
+	case admissionRejection(err) != nil:
+		return config.ErrorClassWebhookDenied
+	case apierrors.IsConflict(err):
+		return config.ErrorClassConflict
+	case apierrors.IsForbidden(err):
+		return config.ErrorClassForbidden
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err), errors.Is(err, context.DeadlineExceeded):
+		return config.ErrorClassTimeout
+	default:
+		return ""
+	}
+}
+
+// retryAttempts counts the consecutive failed reconciles of each source by
+// error class, to back off exponentially. It is safe for concurrent use.
+type retryAttempts struct {
+	mu       sync.Mutex
+	attempts map[types.NamespacedName]map[config.ErrorClass]int
+}
+
+// next records another failure of key with class and returns its count.
+func (a *retryAttempts) next(key types.NamespacedName, class config.ErrorClass) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.attempts == nil {
+		a.attempts = make(map[types.NamespacedName]map[config.ErrorClass]int)
+	}
+	if a.attempts[key] == nil {
+		a.attempts[key] = make(map[config.ErrorClass]int)
+	}
+	a.attempts[key][class]++
+	return a.attempts[key][class]
+}
+
+// reset forgets the failures of key, e.g. once it reconciled successfully.
+func (a *retryAttempts) reset(key types.NamespacedName) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.attempts, key)
+}
+
+// retryError returns err, which failed the mirror writes with failures, as a
+// RetryAfterError when every failure has an error class with a retry schedule.
+// Of several classes, the soonest retry wins: it retries the other failures too.
+func (r *SourceReconciler) retryError(key types.NamespacedName, failures []error, err error) error {
+	classes := make(map[config.ErrorClass]bool, len(failures))
+	for _, failure := range failures {
+		class := classifyError(failure)
+		if class == "" {
+			return err
+		}
+		classes[class] = true
+	}
+
+	var retry *RetryAfterError
+	for class := range classes {
+		backoff, ok := retryBackoff(r.Config, class)
+		if !ok {
+			return err
+		}
+		after := wait.Jitter(backoff.Delay(r.retries.next(key, class)), retryJitter)
+		if retry == nil || after < retry.After {
+			retry = &RetryAfterError{Class: class, After: after, Err: err}
+		}
+	}
+	if retry == nil {
+		return err
+	}
+	retriesScheduledTotal.WithLabelValues(resourceTypeLabel(r.GVK), string(retry.Class)).Inc()
+	return retry
+}
+
+// retryBackoff returns the retry schedule of class, the default one without a config.
+func retryBackoff(cfg *config.Config, class config.ErrorClass) (config.Backoff, bool) {
+	if cfg == nil {
+		backoff, ok := config.DefaultRetryBackoff()[class]
+		return backoff, ok
+	}
+	return cfg.RetryBackoffFor(class)
+}
+
+// scheduleRetries requeues reconciles of r that returned a RetryAfterError after
+// its delay instead of with the work queue's backoff. It wraps the observed
+// reconciler, so those reconciles still count as failed.
+func scheduleRetries(r reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		result, err := r.Reconcile(ctx, req)
+		var retry *RetryAfterError
+		if errors.As(err, &retry) {
+			return reconcile.Result{RequeueAfter: retry.After}, nil
+		}
+		return result, err
+	})
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+func TestClassifyError(t *testing.T) {
+	secrets := schema.GroupResource{Resource: "secrets"}
+	tests := []struct {
+		err  error
+		name string
+		want config.ErrorClass
+	}{
+		{name: "conflict", err: apierrors.NewConflict(secrets, "app", errors.New("modified")), want: config.ErrorClassConflict},
+		{name: "forbidden", err: apierrors.NewForbidden(secrets, "app", errors.New("no RBAC")), want: config.ErrorClassForbidden},
+		{
+			name: "resource quota",
+			err:  apierrors.NewForbidden(secrets, "app", errors.New("exceeded quota: compute, requested: secrets=1")),
+			want: config.ErrorClassQuotaExceeded,
+		},
+		{name: "mirror quota", err: &MirrorQuotaExceededError{Namespace: "team-a", Limit: 1}, want: config.ErrorClassQuotaExceeded},
+		{
+			name: "webhook",
+			err:  &AdmissionRejectedError{Namespace: "team-a", Err: apierrors.NewForbidden(secrets, "app", errors.New("denied"))},
+			want: config.ErrorClassWebhookDenied,
+		},
+		{name: "timeout", err: apierrors.NewTimeoutError("slow", 1), want: config.ErrorClassTimeout},
+		{name: "deadline", err: fmt.Errorf("write: %w", context.DeadlineExceeded), want: config.ErrorClassTimeout},
+		{name: "other", err: errors.New("boom")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyError(tt.err))
+		})
+	}
+}
+
+func TestSourceReconciler_RetryError(t *testing.T) {
+	key := types.NamespacedName{Namespace: "default", Name: "app-secret"}
+	conflict := apierrors.NewConflict(schema.GroupResource{Resource: "secrets"}, "app", errors.New("modified"))
+	quota := &MirrorQuotaExceededError{Namespace: "team-a", Limit: 1}
+	failed := errors.New("failed to reconcile 2/2 mirrors")
+
+	r := &SourceReconciler{
+		Config: &config.Config{RetryBackoff: config.RetryBackoff{
+			config.ErrorClassConflict:      {Initial: time.Second, Max: 4 * time.Second},
+			config.ErrorClassQuotaExceeded: {Initial: time.Hour, Max: time.Hour},
+		}},
+		GVK: secretGVK,
+	}
+
+	var retry *RetryAfterError
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		err := r.retryError(key, []error{conflict}, failed)
+		require.ErrorAs(t, err, &retry)
+		assert.Equal(t, config.ErrorClassConflict, retry.Class)
+		assert.GreaterOrEqual(t, retry.After, want)
+		assert.LessOrEqual(t, retry.After, want+want/5, "at most 20% jitter")
+		assert.ErrorIs(t, err, failed)
+	}
+
+	// The soonest retry wins
+	r.retries.reset(key)
+	require.ErrorAs(t, r.retryError(key, []error{quota, conflict}, failed), &retry)
+	assert.Equal(t, config.ErrorClassConflict, retry.Class)
+
+	// Classes without a schedule, and errors of no class, use the work queue backoff
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "app", errors.New("no RBAC"))
+	assert.Same(t, failed, r.retryError(key, []error{forbidden}, failed))
+	assert.Same(t, failed, r.retryError(key, []error{conflict, errors.New("boom")}, failed))
+}
+
+func TestScheduleRetries(t *testing.T) {
+	failed := errors.New("failed")
+	inner := reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+		return reconcile.Result{}, &RetryAfterError{Class: config.ErrorClassConflict, After: time.Minute, Err: failed}
+	})
+	observer := &recordingObserver{}
+
+	result, err := scheduleRetries(observeReconciler(inner, "secret", observer)).Reconcile(context.Background(), reconcile.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, result.RequeueAfter)
+	require.Len(t, observer.errs, 1)
+	assert.ErrorIs(t, observer.errs[0], failed, "still observed as a failure")
+
+	plain := reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+		return reconcile.Result{}, failed
+	})
+	_, err = scheduleRetries(plain).Reconcile(context.Background(), reconcile.Request{})
+	assert.Same(t, failed, err)
+}
+
+func TestSourceReconciler_Reconcile_QuotaRetrySchedule(t *testing.T) {
+	ctx := context.Background()
+	source := makeWaveSource("app-secret", "", "team-a")
+	c := newFakeClient(t, source)
+
+	namespace := &corev1.Namespace{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "team-a"}, namespace))
+	namespace.Annotations = map[string]string{constants.AnnotationMaxMirrors: "0"}
+	require.NoError(t, c.Update(ctx, namespace))
+
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+	}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+
+	var retry *RetryAfterError
+	require.ErrorAs(t, err, &retry)
+	assert.Equal(t, config.ErrorClassQuotaExceeded, retry.Class)
+	assert.GreaterOrEqual(t, retry.After, 10*time.Minute)
+}
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/transformer"
+)
+
+// RuleLibraryChangeFunc is called with a rule library that was changed or removed.
+type RuleLibraryChangeFunc func(ctx context.Context, library transformer.RuleLibraryRef)
+
+// RuleLibrary loads transform rule libraries, ConfigMaps labeled
+// kubemirror.raczylo.com/transform-rules=true that sources reference with the
+// transform-ref annotation. Parsed rules are cached until the RuleLibraryReconciler
+// reports a change of their ConfigMap. It is safe for concurrent use.
+type RuleLibrary struct {
+	reader client.Reader
+
+	mu        sync.Mutex
+	cache     map[transformer.RuleLibraryRef]cachedRuleLibrary
+	versions  map[transformer.RuleLibraryRef]uint64
+	listeners []RuleLibraryChangeFunc
+}
+
+// cachedRuleLibrary is a loaded library: its rules, or why they could not be used.
+type cachedRuleLibrary struct {
+	rules *transformer.TransformRules
+	err   error
+}
+
+// NewRuleLibrary creates a RuleLibrary reading ConfigMaps through reader. Reads
+// happen on cache misses only, so reader can be an uncached API reader.
+func NewRuleLibrary(reader client.Reader) *RuleLibrary {
+	return &RuleLibrary{
+		reader:   reader,
+		cache:    make(map[transformer.RuleLibraryRef]cachedRuleLibrary),
+		versions: make(map[transformer.RuleLibraryRef]uint64),
+	}
+}
+
+// Rules returns the rules of a library. It implements transformer.RuleLibraryFunc.
+// Missing, unlabeled and invalid libraries are cached like valid ones, so sources
+// referencing them do not read them again until they change; read errors are not.
+func (l *RuleLibrary) Rules(ctx context.Context, namespace, name string) (*transformer.TransformRules, error) {
+	ref := transformer.RuleLibraryRef{Namespace: namespace, Name: name}
+
+	l.mu.Lock()
+	cached, found := l.cache[ref]
+	version := l.versions[ref]
+	l.mu.Unlock()
+	if found {
+		return cached.rules, cached.err
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := l.reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cm); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		cached = cachedRuleLibrary{err: fmt.Errorf("ConfigMap not found")}
+	} else {
+		cached = loadRuleLibrary(cm)
+	}
+
+	// A change reported while reading may have been missed by the read
+	l.mu.Lock()
+	if l.versions[ref] == version {
+		l.cache[ref] = cached
+	}
+	l.mu.Unlock()
+	return cached.rules, cached.err
+}
+
+// loadRuleLibrary parses and validates the rules of a library ConfigMap.
+func loadRuleLibrary(cm *corev1.ConfigMap) cachedRuleLibrary {
+	if cm.Labels[constants.LabelTransformRules] != "true" {
+		return cachedRuleLibrary{err: fmt.Errorf("ConfigMap is not labeled %s=true", constants.LabelTransformRules)}
+	}
+	data, found := cm.Data[constants.TransformRulesKey]
+	if !found {
+		return cachedRuleLibrary{err: fmt.Errorf("ConfigMap has no %q key", constants.TransformRulesKey)}
+	}
+
+	rules, err := transformer.ParseRules([]byte(data))
+	if err != nil {
+		return cachedRuleLibrary{err: err}
+	}
+	for i, rule := range rules.Rules {
+		if err := rule.Validate(); err != nil {
+			return cachedRuleLibrary{err: fmt.Errorf("rule %d: %w", i+1, err)}
+		}
+	}
+	return cachedRuleLibrary{rules: rules}
+}
+
+// OnChange registers fn to be called whenever a library is changed or removed.
+func (l *RuleLibrary) OnChange(fn RuleLibraryChangeFunc) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.listeners = append(l.listeners, fn)
+}
+
+// Invalidate drops a library from the cache and notifies listeners.
+func (l *RuleLibrary) Invalidate(ctx context.Context, library transformer.RuleLibraryRef) {
+	l.mu.Lock()
+	delete(l.cache, library)
+	l.versions[library]++
+	listeners := slices.Clone(l.listeners)
+	l.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(ctx, library)
+	}
+}
+
+// RuleLibraryReconciler invalidates the RuleLibrary cache whenever a labeled
+// ConfigMap changes. It only watches ConfigMap metadata, as the libraries
+// themselves are read on demand.
+type RuleLibraryReconciler struct {
+	Library *RuleLibrary
+}
+
+// Reconcile invalidates one library.
+func (r *RuleLibraryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log.FromContext(ctx).V(1).Info("transform rule library changed", "library", req.NamespacedName.String())
+	r.Library.Invalidate(ctx, transformer.RuleLibraryRef{Namespace: req.Namespace, Name: req.Name})
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager registers the reconciler. Every replica runs it, because every
+// replica reconciling sources reads the libraries.
+func (r *RuleLibraryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	needLeaderElection := false
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.ConfigMap{}, builder.OnlyMetadata, builder.WithPredicates(ruleLibraryPredicate)).
+		Named("transformrulelibrary").
+		WithOptions(controller.Options{NeedLeaderElection: &needLeaderElection}).
+		Complete(r)
+}
+
+// ruleLibraryPredicate passes events of ConfigMaps that are, or were, labeled as
+// rule libraries, so removing the label invalidates the library too.
+var ruleLibraryPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool { return isRuleLibrary(e.Object) },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return isRuleLibrary(e.ObjectOld) || isRuleLibrary(e.ObjectNew)
+	},
+	DeleteFunc:  func(e event.DeleteEvent) bool { return isRuleLibrary(e.Object) },
+	GenericFunc: func(e event.GenericEvent) bool { return isRuleLibrary(e.Object) },
+}
+
+func isRuleLibrary(obj client.Object) bool {
+	return obj.GetLabels()[constants.LabelTransformRules] == "true"
+}
+
+// enqueueRuleLibrarySources requeues the sources of this reconciler's type that
+// reference a changed rule library, so their mirrors pick up its rules.
+func (r *SourceReconciler) enqueueRuleLibrarySources(ctx context.Context, library transformer.RuleLibraryRef) {
+	if r.resync == nil || r.isStopped() {
+		return
+	}
+
+	// Sending blocks until the controller runs; don't hold up the library reconciler
+	go func() {
+		logger := log.FromContext(ctx).WithValues("kind", r.GVK.Kind, "group", r.GVK.Group, "version", r.GVK.Version)
+		if err := r.forEachSource(ctx, "", func(source *unstructured.Unstructured) bool {
+			if !referencesRuleLibrary(source, library) {
+				return true
+			}
+			select {
+			case r.resync <- event.GenericEvent{Object: source}:
+				return true
+			case <-ctx.Done():
+				return false
+			case <-r.stopped:
+				return false
+			}
+		}); err != nil {
+			logger.Error(err, "failed to list sources after transform rule library change", "library", library.String())
+		}
+	}()
+}
+
+// referencesRuleLibrary reports whether source's transform-ref annotation
+// references library.
+func referencesRuleLibrary(source client.Object, library transformer.RuleLibraryRef) bool {
+	annotation := source.GetAnnotations()[constants.AnnotationTransformRef]
+	if annotation == "" {
+		return false
+	}
+	refs, err := transformer.ParseRuleLibraryRefs(annotation, source.GetNamespace())
+	return err == nil && slices.Contains(refs, library)
+}
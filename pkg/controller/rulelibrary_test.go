@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/transformer"
+)
+
+func ruleLibraryConfigMap(name string, labeled bool, data map[string]string) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kubemirror-system"},
+		Data:       data,
+	}
+	if labeled {
+		cm.Labels = map[string]string{constants.LabelTransformRules: "true"}
+	}
+	return cm
+}
+
+func TestRuleLibrary_Rules(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		ruleLibraryConfigMap("standard-prod-rules", true, map[string]string{constants.TransformRulesKey: `rules:
+  - path: data.LOG_LEVEL
+    value: warn
+`}),
+		ruleLibraryConfigMap("unlabeled", false, map[string]string{constants.TransformRulesKey: "rules: []"}),
+		ruleLibraryConfigMap("no-rules", true, map[string]string{"other": "x"}),
+		ruleLibraryConfigMap("invalid", true, map[string]string{constants.TransformRulesKey: `rules:
+  - path: data.KEY
+`}),
+	).Build()
+	library := NewRuleLibrary(c)
+	ctx := context.Background()
+
+	rules, err := library.Rules(ctx, "kubemirror-system", "standard-prod-rules")
+	require.NoError(t, err)
+	require.Len(t, rules.Rules, 1)
+	assert.Equal(t, "data.LOG_LEVEL", rules.Rules[0].Path)
+
+	tests := map[string]string{
+		"missing":   "ConfigMap not found",
+		"unlabeled": "not labeled " + constants.LabelTransformRules + "=true",
+		"no-rules":  `no "rules" key`,
+		"invalid":   "rule 1: rule must specify one of",
+	}
+	for name, wantErr := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := library.Rules(ctx, "kubemirror-system", name)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), wantErr)
+		})
+	}
+}
+
+func TestRuleLibrary_Cache(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	reads := 0
+	failReads := false
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(ruleLibraryConfigMap("rules", true, map[string]string{constants.TransformRulesKey: `rules:
+  - path: data.A
+    value: "1"
+`})).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				reads++
+				if failReads {
+					return apierrors.NewServiceUnavailable("unavailable")
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).Build()
+	library := NewRuleLibrary(c)
+	ctx := context.Background()
+
+	var changed []transformer.RuleLibraryRef
+	library.OnChange(func(_ context.Context, ref transformer.RuleLibraryRef) { changed = append(changed, ref) })
+
+	_, err := library.Rules(ctx, "kubemirror-system", "rules")
+	require.NoError(t, err)
+	_, err = library.Rules(ctx, "kubemirror-system", "rules")
+	require.NoError(t, err)
+	assert.Equal(t, 1, reads, "loaded libraries are cached")
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "kubemirror-system", Name: "rules"}, cm))
+	cm.Data[constants.TransformRulesKey] = `rules:
+  - path: data.B
+    value: "2"
+`
+	require.NoError(t, c.Update(ctx, cm))
+	ref := transformer.RuleLibraryRef{Namespace: "kubemirror-system", Name: "rules"}
+	library.Invalidate(ctx, ref)
+	assert.Equal(t, []transformer.RuleLibraryRef{ref}, changed)
+
+	reads = 0
+	rules, err := library.Rules(ctx, "kubemirror-system", "rules")
+	require.NoError(t, err)
+	assert.Equal(t, "data.B", rules.Rules[0].Path, "invalidated libraries are read again")
+	assert.Equal(t, 1, reads)
+
+	// Read errors are not cached
+	library.Invalidate(ctx, ref)
+	failReads = true
+	_, err = library.Rules(ctx, "kubemirror-system", "rules")
+	require.Error(t, err)
+	failReads = false
+	_, err = library.Rules(ctx, "kubemirror-system", "rules")
+	require.NoError(t, err)
+}
+
+func TestRuleLibraryReconciler_Reconcile(t *testing.T) {
+	library := NewRuleLibrary(nil)
+	var changed []transformer.RuleLibraryRef
+	library.OnChange(func(_ context.Context, ref transformer.RuleLibraryRef) { changed = append(changed, ref) })
+
+	r := &RuleLibraryReconciler{Library: library}
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "kubemirror-system", Name: "rules"}})
+	require.NoError(t, err)
+	assert.Equal(t, []transformer.RuleLibraryRef{{Namespace: "kubemirror-system", Name: "rules"}}, changed)
+}
+
+func TestRuleLibraryPredicate(t *testing.T) {
+	labeled := ruleLibraryConfigMap("rules", true, nil)
+	unlabeled := ruleLibraryConfigMap("rules", false, nil)
+
+	assert.True(t, ruleLibraryPredicate.Create(event.CreateEvent{Object: labeled}))
+	assert.False(t, ruleLibraryPredicate.Create(event.CreateEvent{Object: unlabeled}))
+	assert.True(t, ruleLibraryPredicate.Update(event.UpdateEvent{ObjectOld: labeled, ObjectNew: unlabeled}), "removing the label invalidates")
+	assert.True(t, ruleLibraryPredicate.Update(event.UpdateEvent{ObjectOld: unlabeled, ObjectNew: labeled}))
+	assert.False(t, ruleLibraryPredicate.Update(event.UpdateEvent{ObjectOld: unlabeled, ObjectNew: unlabeled}))
+	assert.True(t, ruleLibraryPredicate.Delete(event.DeleteEvent{Object: labeled}))
+}
+
+func TestSourceReconciler_EnqueueRuleLibrarySources(t *testing.T) {
+	shared := makeUnstructuredSecret("shared", "default", nil,
+		map[string]string{constants.AnnotationTransformRef: "kubemirror-system/standard-prod-rules"})
+	listed := makeUnstructuredSecret("listed", "team-a", nil,
+		map[string]string{constants.AnnotationTransformRef: "team-rules, kubemirror-system/standard-prod-rules"})
+	local := makeUnstructuredSecret("local", "kubemirror-system", nil,
+		map[string]string{constants.AnnotationTransformRef: "standard-prod-rules"})
+	other := makeUnstructuredSecret("other", "default", nil,
+		map[string]string{constants.AnnotationTransformRef: "standard-prod-rules"})
+	plain := makeUnstructuredSecret("plain", "default", nil, nil)
+	c := newFakeClient(t, shared, listed, local, other, plain)
+
+	r := &SourceReconciler{
+		Client: c,
+		GVK:    secretGVK,
+		resync: make(chan event.GenericEvent, 10),
+	}
+	r.enqueueRuleLibrarySources(context.Background(), transformer.RuleLibraryRef{Namespace: "kubemirror-system", Name: "standard-prod-rules"})
+
+	var names []string
+	for range 3 {
+		select {
+		case e := <-r.resync:
+			names = append(names, e.Object.GetName())
+		case <-time.After(time.Second):
+			t.Fatal("expected a resync event")
+		}
+	}
+	assert.ElementsMatch(t, []string{"shared", "listed", "local"}, names)
+	select {
+	case e := <-r.resync:
+		t.Fatalf("unexpected resync of %s", e.Object.GetName())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSourceReconciler_TransformOptionsRuleLibrary(t *testing.T) {
+	r := &SourceReconciler{Config: &config.Config{}, GVK: schema.GroupVersionKind{Version: "v1", Kind: "Secret"}}
+	assert.Nil(t, r.transformOptions().RuleLibrary, "rule libraries are disabled without a library")
+
+	r.RuleLibrary = NewRuleLibrary(nil)
+	assert.NotNil(t, r.transformOptions().RuleLibrary)
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/sealing"
+)
+
+// sealsMirrors reports whether source asks for its mirrors to be SealedSecrets.
+func sealsMirrors(source metav1.Object) bool {
+	return source.GetAnnotations()[constants.AnnotationSealedMirror] == "true"
+}
+
+// isCoreSecret reports whether gvk is the core Secret kind.
+func isCoreSecret(gvk schema.GroupVersionKind) bool {
+	return gvk.Group == "" && gvk.Version == "v1" && gvk.Kind == "Secret"
+}
+
+// MirrorGVK returns the kind the mirrors of source are written as: SealedSecret
+// for Secrets with sealed-mirror, otherwise the kind of the source.
+func MirrorGVK(source *unstructured.Unstructured) schema.GroupVersionKind {
+	if gvk := source.GroupVersionKind(); !isCoreSecret(gvk) || !sealsMirrors(source) {
+		return gvk
+	}
+	return sealing.GVK
+}
+
+// sealMirror replaces the Secret mirror desired by a SealedSecret of the same
+// name, sealed for its target namespace.
+func (r *SourceReconciler) sealMirror(desired *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if !isCoreSecret(desired.GroupVersionKind()) {
+		return nil, fmt.Errorf("%s is only supported on Secrets, not %s", constants.AnnotationSealedMirror, desired.GetKind())
+	}
+	if r.Config == nil || r.Config.SealingKey == nil {
+		return nil, fmt.Errorf("%s is set but the controller has no sealing certificate (--sealed-secrets-cert)", constants.AnnotationSealedMirror)
+	}
+	sealed, err := sealing.Seal(r.Config.SealingKey, desired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal mirror: %w", err)
+	}
+	return sealed, nil
+}
+
+// dropUnsealedMirror deletes the plain Secret mirror source had in ns before its
+// mirrors were sealed, so the sealed-secrets controller can create the unsealed
+// Secret under that name.
+func (r *SourceReconciler) dropUnsealedMirror(ctx context.Context, source *unstructured.Unstructured, ns, name string) error {
+	_, err := r.deleteOwnMirrorOfKind(ctx, source, source.GroupVersionKind(), ns, name, "mirror is now sealed")
+	return err
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/sealing"
+)
+
+func makeSealedSource(name, targets string) *unstructured.Unstructured {
+	source := makeWaveSource(name, "", targets)
+	annotations := source.GetAnnotations()
+	annotations[constants.AnnotationSealedMirror] = "true"
+	source.SetAnnotations(annotations)
+	return source
+}
+
+func TestMirrorGVK(t *testing.T) {
+	assert.Equal(t, secretGVK, MirrorGVK(makeWaveSource("plain", "", "team-a")))
+	assert.Equal(t, sealing.GVK, MirrorGVK(makeSealedSource("sealed", "team-a")))
+
+	configMap := makeSealedSource("config", "team-a")
+	configMap.SetKind("ConfigMap")
+	assert.Equal(t, "ConfigMap", MirrorGVK(configMap).Kind, "only Secrets are sealed")
+}
+
+func TestSourceReconciler_Reconcile_SealedMirror(t *testing.T) {
+	ctx := context.Background()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	source := makeSealedSource("app-secret", "team-a")
+	// A plain mirror written before the source asked for sealing
+	c := newFakeClient(t, source, makeUnstructuredMirror("app-secret", "team-a", "default", "app-secret"))
+
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{SealingKey: &key.PublicKey},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+	}
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+	require.NoError(t, err)
+
+	sealed := &unstructured.Unstructured{}
+	sealed.SetGroupVersionKind(sealing.GVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, sealed))
+	assert.True(t, IsManagedByUs(sealed))
+	encrypted, _, _ := unstructured.NestedStringMap(sealed.Object, "spec", "encryptedData")
+	require.Contains(t, encrypted, "key")
+	assert.NotEqual(t, "dmFsdWU=", encrypted["key"])
+
+	plain := &unstructured.Unstructured{}
+	plain.SetGroupVersionKind(secretGVK)
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, plain)),
+		"the plain mirror makes way for the unsealed Secret")
+
+	// An unchanged source is not sealed again
+	resourceVersion := sealed.GetResourceVersion()
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, sealed))
+	assert.Equal(t, resourceVersion, sealed.GetResourceVersion())
+}
+
+func TestSourceReconciler_Reconcile_SealedMirrorWithoutCertificate(t *testing.T) {
+	ctx := context.Background()
+	source := makeSealedSource("app-secret", "team-a")
+	c := newFakeClient(t, source)
+
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+	}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+	require.Error(t, err)
+
+	plain := &unstructured.Unstructured{}
+	plain.SetGroupVersionKind(secretGVK)
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, plain)),
+		"no plaintext mirror is written in its place")
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// Event reasons of the Secret type policy.
+const (
+	// ReasonNotMirrorable is the Event reason used when a source is enabled for
+	// mirroring but its kind of object cannot, or may not, work in another namespace
+	ReasonNotMirrorable = "NotMirrorable"
+	// ReasonSensitiveSecretMirrored is used on the source for every write of a mirror
+	// of an audited Secret type
+	ReasonSensitiveSecretMirrored = "SensitiveSecretMirrored"
+)
+
+// secretType returns the type of a core Secret, or "" for other objects.
+func secretType(u *unstructured.Unstructured) string {
+	gvk := u.GroupVersionKind()
+	if gvk.Group != "" || gvk.Version != "v1" || gvk.Kind != "Secret" {
+		return ""
+	}
+	t, _, _ := unstructured.NestedString(u.Object, "type")
+	if t == "" {
+		return string(corev1.SecretTypeOpaque)
+	}
+	return t
+}
+
+// secretTypeRules returns the Secret type policy of cfg, or the default one.
+func secretTypeRules(cfg *config.Config) *config.SecretTypePolicy {
+	if cfg == nil {
+		return config.DefaultSecretTypePolicy()
+	}
+	return cfg.SecretTypeRules()
+}
+
+// unmirrorableReason explains why a source must not be mirrored, or returns ""
+// if it can be. A service account token Secret is bound to a ServiceAccount in
+// its own namespace: a copy elsewhere is either rejected by the token controller
+// or grants the source ServiceAccount's identity to every target namespace.
+func unmirrorableReason(source *unstructured.Unstructured, policy *config.SecretTypePolicy) string {
+	t := secretType(source)
+	switch {
+	case t == "":
+		return ""
+	case policy.Denies(t) && corev1.SecretType(t) == corev1.SecretTypeServiceAccountToken:
+		return "service account token Secrets are bound to their namespace's ServiceAccount"
+	case policy.Denies(t):
+		return fmt.Sprintf("Secrets of type %s are denied by the secret type policy", t)
+	case policy.RequiresOptIn(t) && source.GetAnnotations()[constants.AnnotationAllowSensitive] != "true":
+		return fmt.Sprintf("Secrets of type %s are only mirrored with %s=true", t, constants.AnnotationAllowSensitive)
+	}
+	return ""
+}
+
+// auditMirrorWrite logs and records the write of a mirror of an audited Secret
+// type, e.g. one holding a TLS private key. cluster is "" for the local cluster.
+func (r *SourceReconciler) auditMirrorWrite(ctx context.Context, source *unstructured.Unstructured, cluster, targetNs string) {
+	t := secretType(source)
+	if t == "" || !secretTypeRules(r.Config).Audits(t) {
+		return
+	}
+
+	target := targetNs
+	if cluster != "" {
+		target = cluster + "/" + targetNs
+	}
+	log.FromContext(ctx).Info("audit: sensitive Secret mirrored",
+		"sourceNamespace", source.GetNamespace(), "sourceName", source.GetName(),
+		"secretType", t, "target", target, "sourceUID", source.GetUID())
+	r.recordEvent(source, corev1.EventTypeNormal, ReasonSensitiveSecretMirrored, "Mirror",
+		"Mirrored Secret of type %s to %s", t, target)
+}
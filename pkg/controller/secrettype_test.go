@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+func TestUnmirrorableReason(t *testing.T) {
+	custom := &config.SecretTypePolicy{Deny: []string{"example.com/signing-key"}}
+	tests := []struct {
+		name        string
+		apiVersion  string
+		kind        string
+		secretType  string
+		annotations map[string]string
+		policy      *config.SecretTypePolicy
+		want        bool
+	}{
+		{name: "service account token secret", apiVersion: "v1", kind: "Secret", secretType: "kubernetes.io/service-account-token", want: true},
+		{name: "opaque secret", apiVersion: "v1", kind: "Secret", secretType: "Opaque"},
+		{name: "docker config secret", apiVersion: "v1", kind: "Secret", secretType: "kubernetes.io/dockerconfigjson"},
+		{name: "custom resource with token type", apiVersion: "example.com/v1", kind: "Secret", secretType: "kubernetes.io/service-account-token"},
+		{name: "service account", apiVersion: "v1", kind: "ServiceAccount"},
+		{name: "bootstrap token without opt-in", apiVersion: "v1", kind: "Secret", secretType: "bootstrap.kubernetes.io/token", want: true},
+		{
+			name: "bootstrap token with opt-in", apiVersion: "v1", kind: "Secret", secretType: "bootstrap.kubernetes.io/token",
+			annotations: map[string]string{constants.AnnotationAllowSensitive: "true"},
+		},
+		{
+			name: "opt-in does not override deny", apiVersion: "v1", kind: "Secret", secretType: "kubernetes.io/service-account-token",
+			annotations: map[string]string{constants.AnnotationAllowSensitive: "true"}, want: true,
+		},
+		{name: "custom denied type", apiVersion: "v1", kind: "Secret", secretType: "example.com/signing-key", policy: custom, want: true},
+		{name: "token allowed by custom policy", apiVersion: "v1", kind: "Secret", secretType: "kubernetes.io/service-account-token", policy: custom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": tt.apiVersion,
+				"kind":       tt.kind,
+			}}
+			if tt.secretType != "" {
+				u.Object["type"] = tt.secretType
+			}
+			u.SetAnnotations(tt.annotations)
+			policy := tt.policy
+			if policy == nil {
+				policy = config.DefaultSecretTypePolicy()
+			}
+			assert.Equal(t, tt.want, unmirrorableReason(u, policy) != "")
+		})
+	}
+}
+
+func TestSourceReconciler_Reconcile_SecretTypePolicy(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	reconcile := func(t *testing.T, source *unstructured.Unstructured) (*events.FakeRecorder, client.Client) {
+		c := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(source,
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}).
+			Build()
+		recorder := events.NewFakeRecorder(10)
+		r := &SourceReconciler{
+			Client:          c,
+			Config:          &config.Config{},
+			Filter:          filter.NewNamespaceFilter(nil, nil),
+			NamespaceLister: NewKubernetesNamespaceLister(c),
+			Recorder:        recorder,
+			GVK:             secretGVK,
+		}
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+		require.NoError(t, err)
+		return recorder, c
+	}
+	mirrorKey := client.ObjectKey{Namespace: "team-a", Name: "credentials"}
+
+	t.Run("bootstrap token needs opt-in", func(t *testing.T) {
+		source := makeWaveSource("credentials", "", "team-a")
+		source.Object["type"] = "bootstrap.kubernetes.io/token"
+		recorder, c := reconcile(t, source)
+
+		assert.Contains(t, <-recorder.Events, ReasonNotMirrorable)
+		err := c.Get(ctx, mirrorKey, makeUnstructuredSecret("", "", nil, nil))
+		assert.True(t, apierrors.IsNotFound(err))
+	})
+
+	t.Run("bootstrap token with opt-in", func(t *testing.T) {
+		source := makeWaveSource("credentials", "", "team-a")
+		source.Object["type"] = "bootstrap.kubernetes.io/token"
+		annotations := source.GetAnnotations()
+		annotations[constants.AnnotationAllowSensitive] = "true"
+		source.SetAnnotations(annotations)
+		_, c := reconcile(t, source)
+
+		require.NoError(t, c.Get(ctx, mirrorKey, makeUnstructuredSecret("", "", nil, nil)))
+	})
+
+	t.Run("TLS secrets are audited", func(t *testing.T) {
+		source := makeWaveSource("credentials", "", "team-a")
+		source.Object["type"] = "kubernetes.io/tls"
+		recorder, c := reconcile(t, source)
+
+		require.NoError(t, c.Get(ctx, mirrorKey, makeUnstructuredSecret("", "", nil, nil)))
+		assert.Contains(t, <-recorder.Events, ReasonSensitiveSecretMirrored)
+		assert.Contains(t, <-recorder.Events, ReasonMirrorCreated)
+	})
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+)
+
+const (
+	// ReasonProtectedTarget is the Event reason used on a source when one of its
+	// mirrors would overwrite an object the controller itself depends on
+	ReasonProtectedTarget = "ProtectedTarget"
+	// PodNameEnv names the environment variable the downward API fills with the
+	// name of the controller pod
+	PodNameEnv = "POD_NAME"
+)
+
+// isProtected reports whether namespace/name is one of the controller's own objects.
+func isProtected(cfg *config.Config, namespace, name string) bool {
+	return cfg != nil && cfg.Protected.Protects(namespace, name)
+}
+
+// DiscoverProtectedObjects collects the objects the controller depends on in its
+// namespace: the leader election Leases, named leaseName or prefixed with it,
+// and, when podName is set, the ConfigMaps and Secrets the pod mounts or reads
+// its environment from. The Leases are protected even when the pod cannot be
+// read, in which case the error is returned along with them.
+func DiscoverProtectedObjects(ctx context.Context, reader client.Reader, namespace, podName, leaseName string) (*config.ProtectedObjects, error) {
+	protected := &config.ProtectedObjects{Namespace: namespace}
+	if leaseName != "" {
+		protected.Names = append(protected.Names, leaseName, leaseName+"-*")
+	}
+	if podName == "" {
+		return protected, nil
+	}
+
+	pod := &corev1.Pod{}
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: podName}, pod); err != nil {
+		return protected, fmt.Errorf("failed to get controller pod %s/%s: %w", namespace, podName, err)
+	}
+	for _, name := range podReferences(&pod.Spec) {
+		if !slices.Contains(protected.Names, name) {
+			protected.Names = append(protected.Names, name)
+		}
+	}
+	return protected, nil
+}
+
+// podReferences returns the names of the ConfigMaps and Secrets spec mounts,
+// reads environment variables from or pulls images with.
+func podReferences(spec *corev1.PodSpec) []string {
+	var names []string
+	add := func(name string) {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	for _, volume := range spec.Volumes {
+		if volume.ConfigMap != nil {
+			add(volume.ConfigMap.Name)
+		}
+		if volume.Secret != nil {
+			add(volume.Secret.SecretName)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					add(source.ConfigMap.Name)
+				}
+				if source.Secret != nil {
+					add(source.Secret.Name)
+				}
+			}
+		}
+	}
+	for _, container := range slices.Concat(spec.InitContainers, spec.Containers) {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				add(ref.Name)
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				add(ref.Name)
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				add(envFrom.ConfigMapRef.Name)
+			}
+			if envFrom.SecretRef != nil {
+				add(envFrom.SecretRef.Name)
+			}
+		}
+	}
+	for _, pullSecret := range spec.ImagePullSecrets {
+		add(pullSecret.Name)
+	}
+	return names
+}
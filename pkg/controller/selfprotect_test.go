@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+func TestDiscoverProtectedObjects(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubemirror-abc", Namespace: "kubemirror-system"},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{
+				Name: "config",
+				VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{
+					Sources: []corev1.VolumeProjection{
+						{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "kubemirror-config"}}},
+						{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "kubemirror-sealed-secrets-cert"}}},
+					},
+				}},
+			}, {
+				Name:         "tls",
+				VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "kubemirror-tls"}},
+			}},
+			Containers: []corev1.Container{{
+				Name: "manager",
+				Env: []corev1.EnvVar{{
+					Name: "TOKEN",
+					ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "kubemirror-token"}, Key: "token",
+					}},
+				}, {
+					Name:      "POD_NAMESPACE",
+					ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"}},
+				}},
+				EnvFrom: []corev1.EnvFromSource{{
+					ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "kubemirror-config"}},
+				}},
+			}},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-credentials"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pod).Build()
+
+	t.Run("pod references", func(t *testing.T) {
+		protected, err := DiscoverProtectedObjects(ctx, c, "kubemirror-system", "kubemirror-abc", "kubemirror-leader")
+		require.NoError(t, err)
+		assert.Equal(t, "kubemirror-system", protected.Namespace)
+		assert.Equal(t, []string{
+			"kubemirror-leader", "kubemirror-leader-*",
+			"kubemirror-config", "kubemirror-sealed-secrets-cert", "kubemirror-tls",
+			"kubemirror-token", "registry-credentials",
+		}, protected.Names)
+	})
+
+	t.Run("pod unknown", func(t *testing.T) {
+		protected, err := DiscoverProtectedObjects(ctx, c, "kubemirror-system", "", "kubemirror-leader")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kubemirror-leader", "kubemirror-leader-*"}, protected.Names)
+	})
+
+	t.Run("pod not found keeps the leases", func(t *testing.T) {
+		protected, err := DiscoverProtectedObjects(ctx, c, "kubemirror-system", "gone", "kubemirror-leader")
+		require.Error(t, err)
+		assert.True(t, protected.Protects("kubemirror-system", "kubemirror-leader-ns-shard-0"))
+	})
+}
+
+func TestSourceReconciler_Reconcile_ProtectedObjects(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	reconcile := func(t *testing.T, protected *config.ProtectedObjects) (*events.FakeRecorder, client.Client) {
+		c := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(makeWaveSource("credentials", "", "team-a"),
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}).
+			Build()
+		recorder := events.NewFakeRecorder(10)
+		r := &SourceReconciler{
+			Client:          c,
+			Config:          &config.Config{Protected: protected},
+			Filter:          filter.NewNamespaceFilter(nil, nil),
+			NamespaceLister: NewKubernetesNamespaceLister(c),
+			Recorder:        recorder,
+			GVK:             secretGVK,
+		}
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "credentials"}})
+		require.NoError(t, err)
+		return recorder, c
+	}
+	mirrorKey := client.ObjectKey{Namespace: "team-a", Name: "credentials"}
+
+	t.Run("protected source", func(t *testing.T) {
+		recorder, c := reconcile(t, &config.ProtectedObjects{Namespace: "default", Names: []string{"credentials"}})
+
+		assert.Contains(t, <-recorder.Events, ReasonNotMirrorable)
+		err := c.Get(ctx, mirrorKey, makeUnstructuredSecret("", "", nil, nil))
+		assert.True(t, apierrors.IsNotFound(err))
+	})
+
+	t.Run("protected target", func(t *testing.T) {
+		recorder, c := reconcile(t, &config.ProtectedObjects{Namespace: "team-a", Names: []string{"cred*"}})
+
+		assert.Contains(t, <-recorder.Events, ReasonProtectedTarget)
+		err := c.Get(ctx, mirrorKey, makeUnstructuredSecret("", "", nil, nil))
+		assert.True(t, apierrors.IsNotFound(err))
+	})
+}
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// isServiceAccount reports whether u is a core ServiceAccount.
+func isServiceAccount(u *unstructured.Unstructured) bool {
+	gvk := u.GroupVersionKind()
+	return gvk.Group == "" && gvk.Version == "v1" && gvk.Kind == "ServiceAccount"
+}
+
+// sanitizeServiceAccountMirror keeps only the ServiceAccount fields that make sense
+// in another namespace. secrets references the source namespace's token Secrets,
+// so it is always dropped; imagePullSecrets is only carried over on request,
+// since the referenced Secrets must exist in the target namespace too.
+func sanitizeServiceAccountMirror(source, mirror *unstructured.Unstructured) {
+	if !isServiceAccount(source) {
+		return
+	}
+
+	unstructured.RemoveNestedField(mirror.Object, "secrets")
+	unstructured.RemoveNestedField(mirror.Object, "imagePullSecrets")
+
+	if source.GetAnnotations()[constants.AnnotationMirrorImagePullSecrets] != "true" {
+		return
+	}
+	if pullSecrets, found, _ := unstructured.NestedSlice(source.Object, "imagePullSecrets"); found {
+		mirror.Object["imagePullSecrets"] = pullSecrets
+	}
+}
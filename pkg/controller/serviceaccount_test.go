@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func makeServiceAccount(annotations map[string]string) *unstructured.Unstructured {
+	sa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion":                   "v1",
+		"kind":                         "ServiceAccount",
+		"automountServiceAccountToken": false,
+		"secrets":                      []interface{}{map[string]interface{}{"name": "builder-token-abcde"}},
+		"imagePullSecrets":             []interface{}{map[string]interface{}{"name": "registry"}},
+	}}
+	sa.SetNamespace("default")
+	sa.SetName("builder")
+	sa.SetUID("sa-uid")
+	sa.SetAnnotations(annotations)
+	return sa
+}
+
+func TestCreateMirror_ServiceAccount(t *testing.T) {
+	mirror, err := CreateMirror(makeServiceAccount(nil), "team-a")
+	require.NoError(t, err)
+
+	u := mirror.(*unstructured.Unstructured)
+	assert.Equal(t, "team-a", u.GetNamespace())
+	assert.Equal(t, false, u.Object["automountServiceAccountToken"])
+	assert.NotContains(t, u.Object, "secrets", "token references never leave the source namespace")
+	assert.NotContains(t, u.Object, "imagePullSecrets", "pull secrets are only carried on request")
+}
+
+func TestCreateMirror_ServiceAccountImagePullSecrets(t *testing.T) {
+	source := makeServiceAccount(map[string]string{constants.AnnotationMirrorImagePullSecrets: "true"})
+	mirror, err := CreateMirror(source, "team-a")
+	require.NoError(t, err)
+
+	u := mirror.(*unstructured.Unstructured)
+	assert.NotContains(t, u.Object, "secrets")
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "registry"}}, u.Object["imagePullSecrets"])
+	assert.NotContains(t, u.GetAnnotations(), constants.AnnotationMirrorImagePullSecrets)
+}
+
+func TestUpdateMirror_ServiceAccount(t *testing.T) {
+	source := makeServiceAccount(map[string]string{constants.AnnotationMirrorImagePullSecrets: "true"})
+	mirror, err := CreateMirror(source, "team-a")
+	require.NoError(t, err)
+
+	// Pull secrets mirroring turned off on the source
+	source.SetAnnotations(nil)
+	require.NoError(t, UpdateMirror(mirror, source))
+
+	u := mirror.(*unstructured.Unstructured)
+	assert.NotContains(t, u.Object, "secrets")
+	assert.NotContains(t, u.Object, "imagePullSecrets")
+}
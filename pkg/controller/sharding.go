@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// ResourceTypeLeadership decides which replica writes for a resource type when
+// leadership is sharded per GVK instead of held by a single manager-wide leader.
+type ResourceTypeLeadership interface {
+	// IsLeaderFor reports whether this replica currently owns writes for gvk
+	IsLeaderFor(gvk schema.GroupVersionKind) bool
+	// Campaign starts competing for gvk; onStartedLeading runs each time it is acquired
+	Campaign(gvk schema.GroupVersionKind, onStartedLeading func(context.Context))
+}
+
+// NamespaceOwnership decides which replica writes mirrors into a namespace when
+// the write path is sharded by target namespace hash.
+type NamespaceOwnership interface {
+	// OwnsNamespace reports whether this replica currently writes into namespace
+	OwnsNamespace(namespace string) bool
+	// OnShardAcquired registers fn to run each time this replica acquires a shard
+	OnShardAcquired(fn func(context.Context))
+}
+
+// shardHandoffDelay is how long the replica owning a source waits before re-checking
+// whether replicas owning other namespaces have deleted their mirrors.
+const shardHandoffDelay = 5 * time.Second
+
+// isLeaderFor reports whether writes for gvk should happen on this replica.
+// Without sharding the manager-wide leader election already gates controllers.
+func isLeaderFor(leadership ResourceTypeLeadership, gvk schema.GroupVersionKind) bool {
+	return leadership == nil || leadership.IsLeaderFor(gvk)
+}
+
+// ownsNamespace reports whether writes into namespace should happen on this replica.
+func ownsNamespace(ownership NamespaceOwnership, namespace string) bool {
+	return ownership == nil || ownership.OwnsNamespace(namespace)
+}
+
+// needLeaderElection returns the controller option for manager-wide leader election.
+// Sharded controllers run on every replica and gate writes on their own leases.
+func needLeaderElection(sharded bool) *bool {
+	need := !sharded
+	return &need
+}
+
+// enqueueAllSources requeues every enabled source of this reconciler's resource type.
+// It runs when this replica acquires a resource type or namespace shard lease, because
+// events that arrived while another replica (or nobody) held it were skipped here.
+func (r *SourceReconciler) enqueueAllSources(ctx context.Context) {
+	if r.isStopped() {
+		return
+	}
+	logger := log.FromContext(ctx).WithValues("kind", r.GVK.Kind, "group", r.GVK.Group, "version", r.GVK.Version)
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(r.GVK)
+	if err := r.List(ctx, list, client.HasLabels{constants.LabelEnabled}); err != nil {
+		logger.Error(err, "failed to list sources after acquiring lease")
+		return
+	}
+
+	logger.Info("acquired lease, resyncing sources", "count", len(list.Items))
+	for i := range list.Items {
+		select {
+		case r.resync <- event.GenericEvent{Object: &list.Items[i]}:
+		case <-ctx.Done():
+			return
+		case <-r.stopped:
+			return
+		}
+	}
+}
+
+// isStopped reports whether the controllers of this reconciler's type were
+// stopped, so lease and policy callbacks have nobody to requeue sources to.
+func (r *SourceReconciler) isStopped() bool {
+	select {
+	case <-r.stopped:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// fakeLeadership is a static ResourceTypeLeadership for tests.
+type fakeLeadership struct {
+	leading    map[schema.GroupVersionKind]bool
+	campaigned []schema.GroupVersionKind
+}
+
+func (f *fakeLeadership) IsLeaderFor(gvk schema.GroupVersionKind) bool {
+	return f.leading[gvk]
+}
+
+func (f *fakeLeadership) Campaign(gvk schema.GroupVersionKind, _ func(context.Context)) {
+	f.campaigned = append(f.campaigned, gvk)
+}
+
+var secretGVK = schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+
+func TestIsLeaderFor(t *testing.T) {
+	assert.True(t, isLeaderFor(nil, secretGVK), "no sharding means the manager leader owns everything")
+
+	leadership := &fakeLeadership{leading: map[schema.GroupVersionKind]bool{secretGVK: true}}
+	assert.True(t, isLeaderFor(leadership, secretGVK))
+	assert.False(t, isLeaderFor(leadership, schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}))
+
+	assert.True(t, *needLeaderElection(false))
+	assert.False(t, *needLeaderElection(true))
+}
+
+func TestSourceReconciler_SkipsWhenNotLeader(t *testing.T) {
+	var gets int
+	c := fake.NewClientBuilder().
+		WithScheme(runtime.NewScheme()).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				gets++
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+
+	r := &SourceReconciler{
+		Client:     c,
+		Config:     &config.Config{},
+		GVK:        secretGVK,
+		Leadership: &fakeLeadership{},
+	}
+
+	result, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: "default", Name: "app-secret"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.Zero(t, gets, "non-leader must not touch the source")
+}
+
+func TestMirrorReconciler_SkipsWhenNotLeader(t *testing.T) {
+	// Orphaned mirror: its source does not exist
+	mirror := makeUnstructuredMirror("app-secret", "target-ns", "default", "app-secret")
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(mirror).Build()
+
+	r := &MirrorReconciler{Client: c, GVK: secretGVK, Leadership: &fakeLeadership{}}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "target-ns", Name: "app-secret"}}
+
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.NoError(t, c.Get(context.Background(), req.NamespacedName, mirror.DeepCopy()), "non-leader must not delete orphans")
+
+	r.Leadership = &fakeLeadership{leading: map[schema.GroupVersionKind]bool{secretGVK: true}}
+	_, err = r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Error(t, c.Get(context.Background(), req.NamespacedName, mirror.DeepCopy()), "leader deletes orphans")
+}
+
+func TestSourceReconciler_EnqueueAllSources(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	enabled := makeUnstructuredSecret("enabled", "default", map[string]string{constants.LabelEnabled: "true"}, nil)
+	other := makeUnstructuredSecret("other", "default", nil, nil)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(enabled, other).Build()
+
+	r := &SourceReconciler{Client: c, GVK: secretGVK, resync: make(chan event.GenericEvent, 10)}
+	r.enqueueAllSources(context.Background())
+
+	require.Len(t, r.resync, 1)
+	select {
+	case e := <-r.resync:
+		assert.Equal(t, "enabled", e.Object.GetName())
+	case <-time.After(time.Second):
+		t.Fatal("expected a resync event")
+	}
+}
+
+// fakeNamespaceOwnership is a static NamespaceOwnership for tests.
+type fakeNamespaceOwnership struct {
+	owned     map[string]bool
+	callbacks int
+}
+
+func (f *fakeNamespaceOwnership) OwnsNamespace(namespace string) bool {
+	return f.owned[namespace]
+}
+
+func (f *fakeNamespaceOwnership) OnShardAcquired(func(context.Context)) {
+	f.callbacks++
+}
+
+func TestSourceReconciler_DeleteAllMirrorsNamespaceSharded(t *testing.T) {
+	source := makeUnstructuredSecret("app-secret", "default", nil, nil)
+	c := newFakeClient(t,
+		makeUnstructuredMirror("app-secret", "team-a", "default", "app-secret"),
+		makeUnstructuredMirror("app-secret", "team-b", "default", "app-secret"),
+	)
+
+	r := &SourceReconciler{
+		Client:             c,
+		NamespaceLister:    NewKubernetesNamespaceLister(c),
+		GVK:                secretGVK,
+		NamespaceOwnership: &fakeNamespaceOwnership{owned: map[string]bool{"default": true, "team-a": true}},
+	}
+
+	pending, err := r.deleteAllMirrors(context.Background(), source)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending, "mirror in team-b belongs to another shard")
+
+	mirror := makeUnstructuredMirror("app-secret", "team-a", "default", "app-secret")
+	assert.True(t, apierrors.IsNotFound(c.Get(context.Background(), client.ObjectKeyFromObject(mirror), mirror)))
+	mirror = makeUnstructuredMirror("app-secret", "team-b", "default", "app-secret")
+	assert.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(mirror), mirror))
+}
+
+func TestSourceReconciler_DeletionWaitsForOtherShards(t *testing.T) {
+	source := makeUnstructuredSecret("app-secret", "default", map[string]string{constants.LabelEnabled: "true"}, nil)
+	source.SetFinalizers([]string{constants.FinalizerName})
+	c := newFakeClient(t, source, makeUnstructuredMirror("app-secret", "team-b", "default", "app-secret"))
+	require.NoError(t, c.Delete(context.Background(), source.DeepCopy()))
+
+	r := &SourceReconciler{
+		Client:             c,
+		NamespaceLister:    NewKubernetesNamespaceLister(c),
+		Config:             &config.Config{},
+		GVK:                secretGVK,
+		NamespaceOwnership: &fakeNamespaceOwnership{owned: map[string]bool{"default": true}},
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app-secret"}}
+	result, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, shardHandoffDelay, result.RequeueAfter)
+
+	stored := makeUnstructuredSecret("app-secret", "default", nil, nil)
+	require.NoError(t, c.Get(context.Background(), req.NamespacedName, stored))
+	assert.Contains(t, stored.GetFinalizers(), constants.FinalizerName, "finalizer kept until other shards finish")
+}
+
+func TestNamespaceReconciler_SkipsUnownedNamespace(t *testing.T) {
+	var lists int
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				lists++
+				return c.List(ctx, list, opts...)
+			},
+		}).
+		Build()
+
+	r := &NamespaceReconciler{
+		Client:             c,
+		ResourceTypes:      []config.ResourceType{{Version: "v1", Kind: "Secret"}},
+		NamespaceOwnership: &fakeNamespaceOwnership{owned: map[string]bool{"team-a": true}},
+	}
+
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "team-b"}})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.Zero(t, lists, "namespace owned by another shard must not be reconciled")
+}
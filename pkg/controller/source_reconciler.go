@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -22,12 +23,17 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/lukaszraczylo/kubemirror/pkg/circuitbreaker"
 	"github.com/lukaszraczylo/kubemirror/pkg/config"
 	"github.com/lukaszraczylo/kubemirror/pkg/constants"
 	"github.com/lukaszraczylo/kubemirror/pkg/filter"
 	"github.com/lukaszraczylo/kubemirror/pkg/hash"
+	"github.com/lukaszraczylo/kubemirror/pkg/naming"
+	"github.com/lukaszraczylo/kubemirror/pkg/pullsecret"
+	"github.com/lukaszraczylo/kubemirror/pkg/status"
+	"github.com/lukaszraczylo/kubemirror/pkg/tracing"
 )
 
 // SourceReconciler reconciles source resources that need mirroring.
@@ -39,7 +45,43 @@ type SourceReconciler struct {
 	Config          *config.Config
 	Filter          *filter.NamespaceFilter
 	CircuitBreaker  *circuitbreaker.CircuitBreaker
-	GVK             schema.GroupVersionKind
+	// Recorder emits Kubernetes Events on source resources (optional)
+	Recorder events.EventRecorder
+	// StatusReporter publishes per-source sync results (optional, nil disables reporting)
+	StatusReporter status.Reporter
+	// Leadership shards writes per resource type across replicas (optional)
+	Leadership ResourceTypeLeadership
+	// NamespaceOwnership shards writes by target namespace across replicas (optional)
+	NamespaceOwnership NamespaceOwnership
+	// TargetResolver decides target namespaces (optional, nil = target-namespaces annotation)
+	TargetResolver TargetResolver
+	// Policies selects additional sources and targets from ClusterMirrorPolicies (optional)
+	Policies MirrorPolicies
+	// RuleLibrary loads the transform rule libraries sources reference (optional, nil = disabled)
+	RuleLibrary *RuleLibrary
+	// Clusters holds the remote clusters sources can be mirrored to (optional)
+	Clusters *ClusterRegistry
+	// ResourceTypes returns the mirrored resource types, searched for sources of lower
+	// sync waves (optional, nil = only this reconciler's type)
+	ResourceTypes func() []config.ResourceType
+	// Observer is told the outcome of every reconcile (optional)
+	Observer ReconcileObserver
+	GVK      schema.GroupVersionKind
+
+	// resync receives sources to requeue when this replica acquires the type's lease
+	resync chan event.GenericEvent
+	// stopped is closed when the controllers of the type are stopped (nil = never)
+	stopped <-chan struct{}
+	// throttle enforces per-source min-sync-interval annotations
+	throttle syncThrottle
+	// admissionHolds tracks sources held by the circuit breaker after admission rejections
+	admissionHolds admissionHolds
+	// propagation tracks when source changes were observed, until they reach every target
+	propagation propagationClock
+	// retries counts consecutive failures by error class, for their retry schedules
+	retries retryAttempts
+	// failedTargets tracks the targets that failed to sync, to retry only those
+	failedTargets failedTargets
 }
 
 // NamespaceLister provides a list of all namespaces in the cluster.
@@ -48,6 +90,8 @@ type NamespaceLister interface {
 	ListNamespaces(ctx context.Context) ([]string, error)
 	ListAllowMirrorsNamespaces(ctx context.Context) ([]string, error)
 	ListOptOutNamespaces(ctx context.Context) ([]string, error)
+	// ListTerminatingNamespaces returns the namespaces being deleted
+	ListTerminatingNamespaces(ctx context.Context) ([]string, error)
 	// ListNamespacesWithLabels returns all namespace info in a single API call (preferred)
 	ListNamespacesWithLabels(ctx context.Context) (*NamespaceInfo, error)
 }
@@ -121,6 +165,7 @@ func (r *SourceReconciler) getSourceWithFreshness(ctx context.Context, key clien
 
 // Reconcile processes a single source resource.
 func (r *SourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reconcileStart := time.Now()
 	logger := log.FromContext(ctx).WithValues(
 		"namespace", req.Namespace,
 		"name", req.Name,
@@ -129,11 +174,22 @@ func (r *SourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		"version", r.GVK.Version,
 	)
 
+	// Another replica owns writes for this resource type
+	if !isLeaderFor(r.Leadership, r.GVK) {
+		logger.V(2).Info("not the leader for this resource type, skipping")
+		return ctrl.Result{}, nil
+	}
+
 	// Fetch the source resource with optional freshness verification
 	source, err := r.getSourceWithFreshness(ctx, req.NamespacedName, r.GVK)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Resource deleted - nothing to do
+			r.throttle.forget(req.NamespacedName)
+			r.admissionHolds.forget(req.NamespacedName)
+			r.propagation.forget(req.NamespacedName)
+			r.retries.reset(req.NamespacedName)
+			r.failedTargets.forget(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		logger.Error(err, "failed to get resource")
@@ -148,8 +204,23 @@ func (r *SourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, nil
 	}
 
+	// With namespace sharding, writes to the source itself (finalizer, status) belong to
+	// the replica owning the source namespace; each mirror belongs to its target's owner.
+	ownsSource := ownsNamespace(r.NamespaceOwnership, req.Namespace)
+
+	// Paused sources keep their mirrors as they are: no updates and no cleanups
+	if scope := pauseScope(r.Config, r.GVK, sourceObj); scope != "" {
+		return r.handlePaused(ctx, sourceObj, scope, ownsSource)
+	}
+
 	// Check circuit breaker - skip if circuit is open (too many failures)
 	if r.CircuitBreaker != nil {
+		// Sources held after admission rejections are retried once they change
+		fingerprint, _ := sourceFingerprint(sourceObj)
+		if r.admissionHolds.release(req.NamespacedName, fingerprint) {
+			logger.Info("source changed since its mirrors were rejected by admission, retrying")
+			r.CircuitBreaker.Reset(req.Namespace, req.Name, r.GVK.Kind)
+		}
 		if !r.CircuitBreaker.AllowRequest(req.Namespace, req.Name, r.GVK.Kind) {
 			cbState := r.CircuitBreaker.GetState(req.Namespace, req.Name, r.GVK.Kind)
 			failCount := r.CircuitBreaker.GetFailureCount(req.Namespace, req.Name, r.GVK.Kind)
@@ -168,13 +239,22 @@ func (r *SourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		// Resource is being deleted - clean up mirrors and remove finalizer
 		if slices.Contains(sourceObj.GetFinalizers(), constants.FinalizerName) {
 			logger.Info("source being deleted, cleaning up all mirrors")
-			deleteErr := r.deleteAllMirrors(ctx, sourceObj)
+			pending, deleteErr := r.deleteAllMirrors(ctx, sourceObj)
 			if deleteErr != nil {
 				logger.Error(deleteErr, "failed to delete all mirrors during source deletion")
 				return ctrl.Result{}, deleteErr
 			}
 
-			// Remove finalizer to allow resource deletion
+			if !ownsSource {
+				return ctrl.Result{}, nil
+			}
+			if pending > 0 {
+				logger.Info("waiting for other shards to delete their mirrors", "pending", pending)
+				return ctrl.Result{RequeueAfter: shardHandoffDelay}, nil
+			}
+
+			// Remove finalizer to allow resource deletion. Dry runs remove it too,
+			// so a finalizer added before the dry run never blocks the deletion.
 			logger.Info("removing finalizer from source resource")
 			finalizers := removeString(sourceObj.GetFinalizers(), constants.FinalizerName)
 			sourceObj.SetFinalizers(finalizers)
@@ -188,17 +268,37 @@ func (r *SourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, nil
 	}
 
-	if !isEnabledForMirroring(sourceObj) {
+	if !isEnabledForMirroring(sourceObj) && len(policyTargetPatterns(r.Policies, r.GVK, sourceObj)) == 0 {
 		// Resource is disabled - remove finalizer if present and delete all mirrors
 		if slices.Contains(sourceObj.GetFinalizers(), constants.FinalizerName) {
 			return r.handleDisabled(ctx, sourceObj)
 		}
 		// No finalizer, just skip
+		r.propagation.forget(req.NamespacedName)
+		r.failedTargets.forget(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	// Refuse objects that cannot work outside their namespace, and the controller's own
+	reason := unmirrorableReason(source, secretTypeRules(r.Config))
+	if isProtected(r.Config, req.Namespace, req.Name) {
+		reason = "the object is used by the kubemirror controller itself"
+	}
+	if reason != "" {
+		logger.Info("source cannot be mirrored, skipping", "reason", reason)
+		r.recordEvent(source, corev1.EventTypeWarning, ReasonNotMirrorable, "Mirror", "Not mirroring: %s", reason)
+		if slices.Contains(sourceObj.GetFinalizers(), constants.FinalizerName) {
+			return r.handleDisabled(ctx, sourceObj)
+		}
 		return ctrl.Result{}, nil
 	}
 
-	// Add finalizer if not present
-	if !slices.Contains(sourceObj.GetFinalizers(), constants.FinalizerName) {
+	// Add finalizer if not present; a dry run creates no mirrors to clean up
+	if !slices.Contains(sourceObj.GetFinalizers(), constants.FinalizerName) && !r.dryRun(sourceObj) {
+		if !ownsSource {
+			// The owning replica adds it; the resulting update event requeues us
+			return ctrl.Result{}, nil
+		}
 		logger.Info("adding finalizer to source resource")
 		finalizers := append(sourceObj.GetFinalizers(), constants.FinalizerName)
 		sourceObj.SetFinalizers(finalizers)
@@ -212,8 +312,33 @@ func (r *SourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	// Hold off until the source reports it is ready; its status update requeues it
+	met, conditionErr := syncConditionMet(source)
+	if conditionErr != nil {
+		logger.Error(conditionErr, "invalid sync condition, not mirroring")
+		r.recordEvent(source, corev1.EventTypeWarning, ReasonInvalidSyncCondition, "Mirror", "%s", conditionErr.Error())
+		return ctrl.Result{}, nil
+	}
+	if !met {
+		logger.V(1).Info("sync condition not met, waiting", "condition", source.GetAnnotations()[constants.AnnotationSyncWhen])
+		return ctrl.Result{}, nil
+	}
+
+	// Coalesce bursts of changes on hot sources; the requeued reconcile syncs the latest content
+	interval, intervalErr := minSyncInterval(sourceObj)
+	if intervalErr != nil {
+		logger.Error(intervalErr, "ignoring min sync interval")
+	}
+	if wait := r.throttle.wait(req.NamespacedName, interval); wait > 0 {
+		logger.V(1).Info("source synced recently, deferring", "minSyncInterval", interval, "requeueAfter", wait)
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	// Invalid patterns are skipped; say so, as they may leave the source without mirrors
+	invalidPatterns := r.checkTargetPatterns(source)
+
 	// Get target namespaces
-	targetNamespaces, err := r.resolveTargetNamespaces(ctx, sourceObj)
+	targetNamespaces, omittedTargets, err := r.resolveLimitedTargets(ctx, sourceObj)
 	if err != nil {
 		logger.Error(err, "failed to resolve target namespaces")
 		if r.CircuitBreaker != nil {
@@ -224,49 +349,186 @@ func (r *SourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 	if len(targetNamespaces) == 0 {
 		logger.V(1).Info("no target namespaces resolved")
-		return ctrl.Result{}, nil
+		// Remote clusters resolve targets against their own namespaces
+		if r.Clusters == nil {
+			return ctrl.Result{}, r.reportInvalidPatterns(ctx, source, invalidPatterns, ownsSource)
+		}
+	}
+
+	// Only write into namespaces owned by this replica
+	ownedTargets := targetNamespaces
+	if r.NamespaceOwnership != nil {
+		ownedTargets = make([]string, 0, len(targetNamespaces))
+		for _, ns := range targetNamespaces {
+			if r.NamespaceOwnership.OwnsNamespace(ns) {
+				ownedTargets = append(ownedTargets, ns)
+			}
+		}
+	}
+	// Namespaces being deleted reject new mirrors, so they are dropped from the
+	// writes and the sync status; they stay in targetNamespaces only so orphan
+	// cleanup does not delete their mirrors
+	ownedTargets = r.dropTerminatingNamespaces(ctx, ownedTargets)
+
+	// Hold back targets still missing the mirrors of lower sync waves
+	readyTargets, waiting, err := r.holdForWaves(ctx, source, ownedTargets)
+	if err != nil {
+		logger.Error(err, "failed to check sync waves")
+		return ctrl.Result{}, err
+	}
+	if len(waiting) > 0 {
+		logger.V(1).Info("targets waiting for lower sync waves", "waiting", len(waiting))
 	}
 
-	logger.V(1).Info("reconciling mirrors", "targetCount", len(targetNamespaces))
+	// Bootstrap flows may mirror into namespaces that are not created yet
+	r.createMissingNamespaces(ctx, sourceObj, readyTargets)
+
+	// After a partial failure only the failed targets are retried, until the source changes
+	syncTargets := readyTargets
+	fingerprint, _ := sourceFingerprint(sourceObj)
+	retrying := r.failedTargets.pending(req.NamespacedName, fingerprint)
+	if retrying != nil {
+		syncTargets = retrying.retryTargets(readyTargets)
+		logger.V(1).Info("retrying failed targets only", "retrying", len(syncTargets), "targetCount", len(readyTargets))
+	} else {
+		logger.V(1).Info("reconciling mirrors", "targetCount", len(readyTargets))
+	}
 
-	// Reconcile each target namespace
-	var reconciledCount, errorCount int
-	for _, targetNs := range targetNamespaces {
-		reconcileErr := r.reconcileMirror(ctx, source, sourceObj, targetNs)
-		if reconcileErr != nil {
+	// Content hash recorded per synced target in the sync status
+	dryRun := r.dryRun(sourceObj)
+	reportStatus := r.StatusReporter != nil && ownsSource && !dryRun
+	var contentHash string
+	if reportStatus {
+		var hashErr error
+		if contentHash, hashErr = hash.ComputeContentHash(source); hashErr != nil {
+			logger.V(1).Info("failed to compute content hash for status", "error", hashErr.Error())
+		}
+	}
+
+	// Reconcile target namespaces, up to WorkerThreads at a time
+	results := fanOut(ctx, syncTargets, r.mirrorWriteWorkers(), func(ctx context.Context, targetNs string) (bool, error) {
+		return r.syncMirror(ctx, source, sourceObj, targetNs)
+	})
+
+	var reconciledCount, errorCount, rejectedCount int
+	var failures []error
+	var failedNamespaces []string
+	targetStatuses := make([]status.TargetStatus, 0, len(ownedTargets))
+	for i, targetNs := range syncTargets {
+		skipped, reconcileErr := results[i].skipped, results[i].err
+		switch {
+		case namespaceTerminating(reconcileErr):
+			logger.V(1).Info("target namespace is terminating, skipping", "targetNamespace", targetNs)
+		case reconcileErr != nil:
 			logger.Error(reconcileErr, "failed to reconcile mirror", "targetNamespace", targetNs)
 			errorCount++
-		} else {
+			failures = append(failures, reconcileErr)
+			failedNamespaces = append(failedNamespaces, targetNs)
+			targetStatus := status.TargetStatus{Namespace: targetNs, State: status.TargetFailed, Error: reconcileErr.Error()}
+			if rejected := admissionRejection(reconcileErr); rejected != nil {
+				rejectedCount++
+				targetStatus.WebhookError = webhookErrorMessage(rejected.Err)
+			}
+			targetStatuses = append(targetStatuses, targetStatus)
+		case skipped:
 			reconciledCount++
+			targetStatuses = append(targetStatuses, status.TargetStatus{
+				Namespace: targetNs, State: status.TargetSkipped, Error: "target exists and is not managed by kubemirror",
+			})
+		default:
+			reconciledCount++
+			targetStatuses = append(targetStatuses, status.TargetStatus{
+				Namespace: targetNs, State: status.TargetSynced, LastSyncTime: time.Now(), ContentHash: contentHash,
+			})
 		}
 	}
+	// Targets left alone keep the status they were synced with
+	if retrying != nil {
+		kept := retrying.keptStatuses(readyTargets, syncTargets)
+		reconciledCount += len(kept)
+		targetStatuses = append(targetStatuses, kept...)
+	}
 
-	// Clean up orphaned mirrors (namespaces that no longer match the target criteria)
-	orphanedCount, err := r.cleanupOrphanedMirrors(ctx, sourceObj, targetNamespaces)
-	if err != nil {
-		logger.Error(err, "failed to cleanup orphaned mirrors")
-		// Don't fail reconciliation for cleanup errors, just log them
-	} else if orphanedCount > 0 {
-		logger.Info("cleaned up orphaned mirrors", "count", orphanedCount)
+	// Remember the failed targets, so retries leave the others alone
+	if errorCount > 0 && fingerprint != "" {
+		r.failedTargets.record(req.NamespacedName, &partialFailure{
+			fingerprint: fingerprint, failed: failedNamespaces, statuses: slices.Clone(targetStatuses),
+		})
+	} else {
+		r.failedTargets.forget(req.NamespacedName)
 	}
 
-	// Update status annotation with last sync info
-	if err := r.updateLastSyncStatus(ctx, source, sourceObj, reconciledCount, errorCount); err != nil {
-		logger.Error(err, "failed to update sync status")
-		if r.CircuitBreaker != nil {
-			r.CircuitBreaker.RecordFailure(req.Namespace, req.Name, r.GVK.Kind, err)
+	for _, targetNs := range ownedTargets {
+		if blocker, ok := waiting[targetNs]; ok {
+			targetStatuses = append(targetStatuses, status.TargetStatus{
+				Namespace: targetNs, State: status.TargetWaiting, Error: "waiting for " + blocker,
+			})
+		}
+	}
+	for _, targetNs := range omittedTargets {
+		if ownsNamespace(r.NamespaceOwnership, targetNs) {
+			targetStatuses = append(targetStatuses, status.TargetStatus{
+				Namespace: targetNs, State: status.TargetSkipped,
+				Error: fmt.Sprintf("over the limit of %d target namespaces", len(targetNamespaces)),
+			})
+		}
+	}
+
+	// Clean up orphaned mirrors (namespaces that no longer match the target criteria);
+	// retries of failed targets leave that to the full reconcile before them
+	if len(targetNamespaces) > 0 && retrying == nil {
+		orphanedCount, err := r.cleanupOrphanedMirrors(ctx, sourceObj, targetNamespaces)
+		if err != nil {
+			logger.Error(err, "failed to cleanup orphaned mirrors")
+			// Don't fail reconciliation for cleanup errors, just log them
+		} else if orphanedCount > 0 {
+			logger.Info("cleaned up orphaned mirrors", "count", orphanedCount)
+		}
+	}
+
+	// Push mirrors to remote clusters. Their failures are retried on a timer and
+	// tracked by per-cluster circuit breakers, so they never block local mirrors.
+	var remoteFailed bool
+	if dryRun && r.Clusters != nil {
+		logger.V(1).Info("dry run, not syncing remote clusters")
+	} else if ownsSource {
+		remoteStatuses := r.syncRemoteClusters(ctx, source)
+		remoteFailed = slices.ContainsFunc(remoteStatuses, func(s status.TargetStatus) bool { return s.State == status.TargetFailed })
+		targetStatuses = append(targetStatuses, remoteStatuses...)
+	}
+
+	// Report sync status through the configured backend
+	if reportStatus {
+		result := status.Result{Reconciled: reconciledCount, Errors: errorCount, Targets: targetStatuses, InvalidPatterns: invalidPatterns}
+		if err := r.StatusReporter.Report(ctx, source, result); err != nil {
+			logger.Error(err, "failed to report sync status")
+			if r.CircuitBreaker != nil {
+				r.CircuitBreaker.RecordFailure(req.Namespace, req.Name, r.GVK.Kind, err)
+			}
+			return ctrl.Result{}, err
 		}
-		return ctrl.Result{}, err
 	}
 
 	logger.Info("reconciliation complete",
 		"reconciled", reconciledCount,
 		"errors", errorCount,
-		"total", len(targetNamespaces))
+		"waiting", len(waiting),
+		"total", len(ownedTargets),
+		"dryRun", dryRun)
 
 	// Return error if there were errors (controller-runtime will automatically requeue with exponential backoff)
 	if errorCount > 0 {
-		err := fmt.Errorf("failed to reconcile %d/%d mirrors", errorCount, len(targetNamespaces))
+		err := fmt.Errorf("failed to reconcile %d/%d mirrors", errorCount, len(syncTargets))
+		// Admission rejects the same mirrors again until the source changes, so
+		// rather than retrying with backoff the circuit opens until then
+		if r.CircuitBreaker != nil && rejectedCount == errorCount {
+			if fingerprint, fpErr := sourceFingerprint(sourceObj); fpErr == nil {
+				r.admissionHolds.hold(req.NamespacedName, fingerprint)
+				r.CircuitBreaker.Trip(req.Namespace, req.Name, r.GVK.Kind, err)
+				logger.Info("mirrors rejected by admission, holding source until it changes", "rejected", rejectedCount)
+				return ctrl.Result{}, nil
+			}
+		}
 		// Record failure with circuit breaker
 		if r.CircuitBreaker != nil {
 			state, justOpened := r.CircuitBreaker.RecordFailure(req.Namespace, req.Name, r.GVK.Kind, err)
@@ -276,14 +538,40 @@ func (r *SourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 					"consecutiveFailures", r.CircuitBreaker.GetFailureCount(req.Namespace, req.Name, r.GVK.Kind))
 			}
 		}
-		return ctrl.Result{}, err
+		return ctrl.Result{}, r.retryError(req.NamespacedName, failures, err)
 	}
 
 	// Record success with circuit breaker
 	if r.CircuitBreaker != nil {
 		r.CircuitBreaker.RecordSuccess(req.Namespace, req.Name, r.GVK.Kind)
 	}
+	r.retries.reset(req.NamespacedName)
+
+	// Every target is in sync with this content; later updates that leave it alone are
+	// skipped, and a content change that got here has fully propagated
+	if ownsSource && !dryRun && len(waiting) == 0 && !remoteFailed {
+		if contentHash == "" {
+			contentHash, _ = hash.ComputeContentHash(source)
+		}
+		var propagation time.Duration
+		if contentHash != "" && source.GetAnnotations()[constants.AnnotationContentHash] != contentHash {
+			propagation = time.Since(r.propagation.since(req.NamespacedName, reconcileStart))
+			propagationDuration.WithLabelValues(resourceTypeLabel(r.GVK)).Observe(propagation.Seconds())
+			logger.V(1).Info("source change propagated to every target", "propagation", propagation)
+		}
+		r.propagation.forget(req.NamespacedName)
+		if err := r.recordSourceContentHash(ctx, source, contentHash, propagation); err != nil {
+			logger.V(1).Info("failed to record source content hash", "error", err.Error())
+		}
+	}
 
+	// Recheck waiting targets until the lower sync waves reach them
+	if len(waiting) > 0 {
+		return ctrl.Result{RequeueAfter: waveRetryDelay}, nil
+	}
+	if remoteFailed {
+		return ctrl.Result{RequeueAfter: remoteRetryDelay}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -292,11 +580,20 @@ func (r *SourceReconciler) handleDisabled(ctx context.Context, sourceObj metav1.
 	logger := log.FromContext(ctx)
 
 	// Delete all mirrors for this disabled source
-	if err := r.deleteAllMirrors(ctx, sourceObj); err != nil {
+	pending, err := r.deleteAllMirrors(ctx, sourceObj)
+	if err != nil {
 		logger.Error(err, "failed to delete mirrors for disabled resource")
 		return ctrl.Result{}, err
 	}
 
+	if !ownsNamespace(r.NamespaceOwnership, sourceObj.GetNamespace()) || r.dryRun(sourceObj) {
+		return ctrl.Result{}, nil
+	}
+	if pending > 0 {
+		logger.Info("waiting for other shards to delete their mirrors", "pending", pending)
+		return ctrl.Result{RequeueAfter: shardHandoffDelay}, nil
+	}
+
 	// Remove finalizer if present
 	if slices.Contains(sourceObj.GetFinalizers(), constants.FinalizerName) {
 		logger.Info("removing finalizer from disabled resource")
@@ -318,106 +615,280 @@ func (r *SourceReconciler) handleDisabled(ctx context.Context, sourceObj metav1.
 
 // reconcileMirror creates or updates a mirror in the target namespace.
 func (r *SourceReconciler) reconcileMirror(ctx context.Context, source runtime.Object, sourceObj metav1.Object, targetNs string) error {
+	_, err := r.syncMirror(ctx, source, sourceObj, targetNs)
+	return err
+}
+
+// syncMirror creates or updates a mirror in the target namespace. It reports
+// skipped when the target holds an object kubemirror does not manage, or one of
+// the controller's own objects.
+func (r *SourceReconciler) syncMirror(ctx context.Context, source runtime.Object, sourceObj metav1.Object, targetNs string) (skipped bool, err error) {
+	ctx, span := tracing.Start(ctx, "SyncMirror", tracing.AttrTargetNamespace.String(targetNs))
 	logger := log.FromContext(ctx).WithValues("targetNamespace", targetNs)
+	defer func() {
+		span.SetAttributes(tracing.AttrSkipped.Bool(skipped))
+		tracing.End(span, err)
+
+		// Quota and admission rejections are reported with their own reasons
+		if err != nil && !isMirrorQuotaExceeded(err) && admissionRejection(err) == nil && !namespaceTerminating(err) {
+			r.recordEvent(source, corev1.EventTypeWarning, ReasonMirrorFailed, "Mirror",
+				withReconcileID(ctx, "Failed to mirror to namespace %s: %s"), targetNs, err.Error())
+		}
+	}()
 
-	// Try to get existing mirror as unstructured
+	mirrorName, err := naming.MirrorName(sourceObj)
+	if err != nil {
+		return false, err
+	}
+	if isProtected(r.Config, targetNs, mirrorName) {
+		logger.Info("target is one of the controller's own objects, skipping", "mirrorName", mirrorName)
+		r.recordEvent(source, corev1.EventTypeWarning, ReasonProtectedTarget, "Mirror",
+			"Not mirroring to %s/%s: the object is used by the kubemirror controller itself", targetNs, mirrorName)
+		return true, nil
+	}
+	expiresAt, err := mirrorExpiry(ctx, r.Client, sourceObj, targetNs)
+	if err != nil {
+		return false, err
+	}
+
+	// The apply below needs no prior read. The cached mirror is still read to detect
+	// objects kubemirror does not manage and to skip mirrors that are up to date. It
+	// is not re-read from the API server even with VerifySourceFreshness: the apply
+	// sends no resourceVersion, so a stale cache costs at most a redundant write, or
+	// a skipped one that drift repair corrects on the mirror's watch event, while a
+	// live read would add an API call per target on every reconcile.
 	sourceUnstructured := source.(*unstructured.Unstructured)
 	existing := &unstructured.Unstructured{}
-	existing.SetGroupVersionKind(sourceUnstructured.GroupVersionKind())
+	existing.SetGroupVersionKind(MirrorGVK(sourceUnstructured))
 
-	err := r.Get(ctx, client.ObjectKey{Namespace: targetNs, Name: sourceObj.GetName()}, existing)
+	err = r.Get(ctx, client.ObjectKey{Namespace: targetNs, Name: mirrorName}, existing)
 	if err != nil && !errors.IsNotFound(err) {
-		return fmt.Errorf("failed to get existing mirror: %w", err)
-	}
-
-	// If freshness verification is enabled and mirror exists, verify it's fresh too
-	if err == nil && r.Config.VerifySourceFreshness && r.APIReader != nil {
-		fresh := &unstructured.Unstructured{}
-		fresh.SetGroupVersionKind(sourceUnstructured.GroupVersionKind())
-		if apiErr := r.APIReader.Get(ctx, client.ObjectKey{Namespace: targetNs, Name: sourceObj.GetName()}, fresh); apiErr == nil {
-			if fresh.GetResourceVersion() != existing.GetResourceVersion() {
-				logger.V(2).Info("mirror cache stale, using fresh API version",
-					"cachedRV", existing.GetResourceVersion(),
-					"freshRV", fresh.GetResourceVersion())
-				existing = fresh
-			}
-		}
+		return false, fmt.Errorf("failed to get existing mirror: %w", err)
 	}
 
+	opts := r.transformOptions()
+	var desired runtime.Object
+	var adopt bool
+	var policy ConflictPolicy
 	if err == nil {
 		// Mirror exists - check if it's managed by us
 		if !IsManagedByUs(existing) {
-			logger.V(1).Info("target resource exists but not managed by kubemirror, skipping")
-			return nil
+			var policyErr error
+			if policy, policyErr = r.conflictPolicy(sourceObj); policyErr != nil {
+				return false, policyErr
+			}
+			switch policy {
+			case ConflictFail:
+				return false, &TargetConflictError{Namespace: targetNs, Name: mirrorName}
+			case ConflictOverwrite:
+				logger.Info("target resource exists but not managed by kubemirror, adopting it")
+				adopt = true
+			case ConflictAdoptIfIdentical:
+				if desired, err = buildMirror(ctx, source, sourceObj, targetNs, opts); err != nil {
+					return false, fmt.Errorf("failed to build mirror: %w", err)
+				}
+				identical, identicalErr := identicalContent(desired, existing)
+				if identicalErr != nil {
+					return false, identicalErr
+				}
+				if !identical {
+					logger.V(1).Info("target resource exists with different content and is not managed by kubemirror, skipping")
+					return true, nil
+				}
+				logger.Info("target resource exists with the mirror's content, adopting it")
+				adopt = true
+			default:
+				logger.V(1).Info("target resource exists but not managed by kubemirror, skipping")
+				return true, nil
+			}
 		}
 
-		// Check if update is needed
+		// Check if update is needed; an adopted object always is
 		needsSync, syncCheckErr := hash.NeedsSync(source, existing, existing.GetAnnotations())
 		if syncCheckErr != nil {
-			return fmt.Errorf("failed to check if sync needed: %w", syncCheckErr)
+			return false, fmt.Errorf("failed to check if sync needed: %w", syncCheckErr)
+		}
+		needsSync = needsSync || adopt ||
+			existing.GetAnnotations()[constants.AnnotationExpiresAt] != formatExpiry(expiresAt) ||
+			ownedByBinding(existing) != r.Config.UseOwnerReferences ||
+			!hasMirrorMetadata(existing, r.Config)
+
+		// Transformed output can change while the source does not, e.g. with
+		// default rules or looked-up values, so compare the transformed content too
+		if !needsSync && transformsApply(sourceObj, opts) {
+			if desired, err = buildMirror(ctx, source, sourceObj, targetNs, opts); err != nil {
+				return false, fmt.Errorf("failed to build mirror: %w", err)
+			}
+			needsSync = transformedContentChanged(desired, existing)
 		}
 
 		if !needsSync {
 			logger.V(2).Info("mirror is up to date")
-			return nil
+			// A ServiceAccount created after the mirror (e.g. a new namespace's default) still gets it
+			return false, r.syncAttachments(ctx, sourceUnstructured, pullsecret.Recorded(existing), targetNs, mirrorName)
 		}
+	} else {
+		existing = nil
+	}
 
-		// Update mirror
-		updateErr := UpdateMirror(existing, source)
-		if updateErr != nil {
-			return fmt.Errorf("failed to update mirror: %w", updateErr)
+	// Build the desired mirror and apply it server-side. A single apply creates or
+	// updates the mirror, and applying only the fields kubemirror manages leaves
+	// fields owned by other controllers untouched.
+	if desired == nil {
+		if desired, err = buildMirror(ctx, source, sourceObj, targetNs, opts); err != nil {
+			return false, fmt.Errorf("failed to build mirror: %w", err)
 		}
+	}
+	desiredU, err := completeMirror(desired, sourceUnstructured, r.Config, expiresAt)
+	if err != nil {
+		return false, err
+	}
 
-		clusterUpdateErr := r.Update(ctx, existing)
-		if clusterUpdateErr != nil {
-			return fmt.Errorf("failed to update mirror in cluster: %w", clusterUpdateErr)
+	// Sealed mirrors are written as SealedSecrets, taking the place of a plain mirror
+	if sealsMirrors(sourceObj) {
+		if desiredU, err = r.sealMirror(desiredU); err != nil {
+			return false, err
 		}
+		if existing == nil {
+			if err := r.dropUnsealedMirror(ctx, sourceUnstructured, targetNs, mirrorName); err != nil {
+				return false, fmt.Errorf("failed to delete unsealed mirror: %w", err)
+			}
+		}
+	}
 
-		logger.V(1).Info("mirror updated")
-		return nil
+	// A new mirror, adopted objects included, counts against the namespace's quota
+	if existing == nil || adopt {
+		if err := r.checkMirrorQuota(ctx, sourceUnstructured, targetNs); err != nil {
+			return false, err
+		}
 	}
 
-	// Create new mirror
-	mirror, err := CreateMirror(source, targetNs)
-	if err != nil {
-		return fmt.Errorf("failed to create mirror: %w", err)
+	// Garbage collection removes the mirror with the binding owning it
+	if r.Config.UseOwnerReferences && !r.dryRun(sourceObj) {
+		owner, bindingErr := r.ensureMirrorBinding(ctx, sourceUnstructured, targetNs)
+		if bindingErr != nil {
+			return false, bindingErr
+		}
+		desiredU.SetOwnerReferences([]metav1.OwnerReference{owner})
 	}
 
-	mirrorObj := mirror.(client.Object)
-	if err := r.Create(ctx, mirrorObj); err != nil {
-		return fmt.Errorf("failed to create mirror in cluster: %w", err)
+	// Adopting takes over the fields their previous owner set
+	force := shouldForceApply(sourceObj) || adopt
+
+	// Let admission judge the mirror first; a rejected mirror is not created and an
+	// existing one keeps its previous state. Immutable field changes are left to the
+	// real write, which recreates the mirror or reports them.
+	if r.serverDryRun() {
+		dryRunErr := applyMirror(ctx, r.Client, desiredU, force, client.DryRunAll)
+		_, immutable := immutableFields(dryRunErr)
+		if dryRunErr != nil && !IsFieldManagerConflict(dryRunErr) && !immutable {
+			if isAdmissionRejection(dryRunErr) {
+				rejected := &AdmissionRejectedError{Namespace: targetNs, DryRun: true, Err: dryRunErr}
+				r.recordAdmissionRejection(ctx, sourceUnstructured, existing, rejected)
+				return false, rejected
+			}
+			return false, fmt.Errorf("mirror failed server-side dry run: %w", dryRunErr)
+		}
 	}
 
-	// Verify mirror was actually created (catches webhook rejections, quota issues)
-	verifyMirror := &unstructured.Unstructured{}
-	verifyMirror.SetGroupVersionKind(sourceUnstructured.GroupVersionKind())
-	verifyKey := client.ObjectKey{Namespace: targetNs, Name: sourceObj.GetName()}
-	if verifyErr := r.Get(ctx, verifyKey, verifyMirror); verifyErr != nil {
-		logger.Error(verifyErr, "mirror creation verification failed - mirror may have been rejected")
-		return fmt.Errorf("mirror creation verification failed: %w", verifyErr)
+	if r.dryRun(sourceObj) {
+		r.reportDryRunSync(ctx, sourceUnstructured, existing, desiredU, adopt)
+		return false, nil
 	}
 
-	logger.V(1).Info("mirror created and verified")
-	return nil
+	recreated, applyErr := applyOrRecreate(ctx, r.Client, sourceObj, existing, desiredU, force)
+	if applyErr != nil {
+		if IsFieldManagerConflict(applyErr) {
+			logger.Info("mirror fields owned by another field manager, not overwriting", "error", applyErr.Error())
+			r.recordEvent(sourceUnstructured, corev1.EventTypeWarning, ReasonFieldManagerConflict, "Apply",
+				"%s (set %s=true on the source to take ownership)", applyErr.Error(), constants.AnnotationForceApply)
+		}
+		if IsImmutableFieldChange(applyErr) {
+			logger.Info("mirror update changes immutable fields, not recreating it", "error", applyErr.Error())
+		} else if !IsFieldManagerConflict(applyErr) && isAdmissionRejection(applyErr) {
+			rejected := &AdmissionRejectedError{Namespace: targetNs, Err: applyErr}
+			r.recordAdmissionRejection(ctx, sourceUnstructured, existing, rejected)
+			return false, rejected
+		}
+		return false, fmt.Errorf("failed to apply mirror: %w", applyErr)
+	}
+	r.auditMirrorWrite(ctx, sourceUnstructured, "", targetNs)
+	r.recordTransformFailures(sourceUnstructured, desiredU, targetNs)
+
+	var previous []string
+	if existing != nil {
+		previous = pullsecret.Recorded(existing)
+	}
+	if err := r.syncAttachments(ctx, sourceUnstructured, previous, targetNs, mirrorName); err != nil {
+		return false, err
+	}
+
+	if recreated {
+		logger.Info("mirror recreated after an immutable field change")
+		r.recordEvent(source, corev1.EventTypeNormal, ReasonMirrorRecreated, "Recreate",
+			"Recreated mirror in namespace %s: the update changed immutable fields", targetNs)
+		return false, nil
+	}
+
+	if existing == nil {
+		logger.V(1).Info("mirror created")
+		r.recordEvent(source, corev1.EventTypeNormal, ReasonMirrorCreated, "Create", "Created mirror in namespace %s", targetNs)
+		return false, nil
+	}
+
+	// The write went through, so any earlier rejection no longer applies
+	if clearErr := r.setWebhookError(ctx, existing, ""); clearErr != nil {
+		logger.Error(clearErr, "failed to clear webhook error from mirror")
+	}
+
+	if adopt {
+		logger.Info("existing resource adopted as mirror")
+		r.recordEvent(source, corev1.EventTypeNormal, ReasonMirrorUpdated, "Update",
+			"Adopted existing %s in namespace %s as mirror (conflict-policy=%s)", mirrorName, targetNs, policy)
+		return false, nil
+	}
+
+	logger.V(1).Info("mirror updated")
+	r.recordEvent(source, corev1.EventTypeNormal, ReasonMirrorUpdated, "Update", "Updated mirror in namespace %s", targetNs)
+	return false, nil
+}
+
+// recordEvent emits an Event on the source resource if a recorder is configured.
+func (r *SourceReconciler) recordEvent(regarding runtime.Object, eventType, reason, action, note string, args ...interface{}) {
+	emitEvent(r.Recorder, regarding, eventType, reason, action, note, args...)
 }
 
 // deleteAllMirrors deletes all mirrors for a source resource.
-func (r *SourceReconciler) deleteAllMirrors(ctx context.Context, sourceObj metav1.Object) error {
+// With namespace sharding only mirrors in owned namespaces are deleted; the returned
+// count is the number of mirrors still present in namespaces owned by other replicas.
+func (r *SourceReconciler) deleteAllMirrors(ctx context.Context, sourceObj metav1.Object) (int, error) {
 	logger := log.FromContext(ctx)
 
-	// List all namespaces
-	allNamespaces, err := r.NamespaceLister.ListNamespaces(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to list namespaces: %w", err)
-	}
-
 	// Get GVK from source object
 	sourceUnstructured, ok := sourceObj.(*unstructured.Unstructured)
 	if !ok {
-		return fmt.Errorf("source object is not unstructured")
+		return 0, fmt.Errorf("source object is not unstructured")
+	}
+
+	// Mirrors owned by bindings go with them, without visiting every namespace
+	if r.Config != nil && r.Config.UseOwnerReferences {
+		deleted, pending, err := r.deleteMirrorBindings(ctx, sourceUnstructured)
+		if err != nil {
+			return 0, err
+		}
+		logger.Info("deleted mirror bindings", "count", deleted, "dryRun", r.dryRun(sourceObj))
+		return pending, r.deleteRemoteMirrorsOfSource(ctx, sourceObj)
 	}
 
-	var deleteCount int
+	// List all namespaces
+	allNamespaces, err := r.NamespaceLister.ListNamespaces(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	mirrorName := naming.MirrorNameOrDefault(sourceObj)
+	dryRun := r.dryRun(sourceObj)
+	var deleteCount, pendingCount int
 	for _, ns := range allNamespaces {
 		// Skip source namespace
 		if ns == sourceObj.GetNamespace() {
@@ -426,19 +897,54 @@ func (r *SourceReconciler) deleteAllMirrors(ctx context.Context, sourceObj metav
 
 		// Create mirror reference for deletion
 		mirror := &unstructured.Unstructured{}
-		mirror.SetGroupVersionKind(sourceUnstructured.GroupVersionKind())
+		mirror.SetGroupVersionKind(MirrorGVK(sourceUnstructured))
 		mirror.SetNamespace(ns)
-		mirror.SetName(sourceObj.GetName())
+		mirror.SetName(mirrorName)
+
+		// Mirrors in other shards are deleted by their owners
+		if !ownsNamespace(r.NamespaceOwnership, ns) {
+			if err := r.Get(ctx, client.ObjectKeyFromObject(mirror), mirror); err == nil && IsManagedByUs(mirror) {
+				pendingCount++
+			}
+			continue
+		}
+
+		if dryRun {
+			if err := r.Get(ctx, client.ObjectKeyFromObject(mirror), mirror); err == nil && IsManagedByUs(mirror) {
+				reportDryRun(ctx, r.Recorder, sourceUnstructured, r.GVK, dryRunDelete, ns, "source is no longer mirrored")
+				deleteCount++
+			}
+			continue
+		}
+
+		// Registry Secret mirrors leave the ServiceAccounts they were attached to first
+		if err := detachMirror(ctx, r.Client, mirror); err != nil {
+			logger.Error(err, "failed to detach mirror from service accounts", "namespace", ns)
+			continue
+		}
 
 		err := r.Delete(ctx, mirror)
 		if err == nil {
 			deleteCount++
+			recordMirrorDeleted(r.Recorder, sourceUnstructured, ns, "source is no longer mirrored")
 		} else if !errors.IsNotFound(err) {
 			logger.Error(err, "failed to delete mirror", "namespace", ns)
 		}
 	}
 
-	logger.Info("deleted mirrors", "count", deleteCount)
+	logger.Info("deleted mirrors", "count", deleteCount, "dryRun", dryRun)
+	return pendingCount, r.deleteRemoteMirrorsOfSource(ctx, sourceObj)
+}
+
+// deleteRemoteMirrorsOfSource removes the remote mirrors of a source on the replica
+// owning the source.
+func (r *SourceReconciler) deleteRemoteMirrorsOfSource(ctx context.Context, sourceObj metav1.Object) error {
+	if !ownsNamespace(r.NamespaceOwnership, sourceObj.GetNamespace()) || r.dryRun(sourceObj) {
+		return nil
+	}
+	if source, ok := sourceObj.(client.Object); ok {
+		return r.deleteRemoteMirrors(ctx, source)
+	}
 	return nil
 }
 
@@ -466,6 +972,10 @@ func (r *SourceReconciler) cleanupOrphanedMirrors(ctx context.Context, sourceObj
 		targetSet[ns] = true
 	}
 
+	// A renamed source may still have mirrors under its own name, in any namespace
+	mirrorName := naming.MirrorNameOrDefault(sourceObj)
+	renamed := mirrorName != sourceObj.GetName()
+
 	var deletedCount int
 	for _, ns := range allNamespaces {
 		// Skip source namespace
@@ -473,134 +983,145 @@ func (r *SourceReconciler) cleanupOrphanedMirrors(ctx context.Context, sourceObj
 			continue
 		}
 
-		// Skip if this namespace IS in the current target list
-		if targetSet[ns] {
-			continue
-		}
-
-		// Check if a mirror exists in this namespace
-		mirror := &unstructured.Unstructured{}
-		mirror.SetGroupVersionKind(sourceUnstructured.GroupVersionKind())
-		mirror.SetNamespace(ns)
-		mirror.SetName(sourceObj.GetName())
-
-		err := r.Get(ctx, client.ObjectKey{Namespace: ns, Name: sourceObj.GetName()}, mirror)
-		if errors.IsNotFound(err) {
-			// No mirror exists, nothing to clean up
-			continue
-		}
-		if err != nil {
-			logger.Error(err, "failed to check for mirror", "namespace", ns)
-			continue
-		}
-
-		// Verify this is actually our mirror (not someone else's resource with the same name)
-		if !IsManagedByUs(mirror) {
+		// Mirrors in other shards are cleaned up by their owners
+		if !ownsNamespace(r.NamespaceOwnership, ns) {
 			continue
 		}
 
-		// Verify this mirror points to our source
-		srcNs, srcName, _, found := GetSourceReference(mirror)
-		if !found || srcNs != sourceObj.GetNamespace() || srcName != sourceObj.GetName() {
-			continue
+		// Mirrors under the current name are orphaned only outside the target list
+		if !targetSet[ns] {
+			deleted, err := r.deleteOwnMirror(ctx, sourceUnstructured, ns, mirrorName, "namespace is no longer a target")
+			if err != nil {
+				logger.Error(err, "failed to delete orphaned mirror", "namespace", ns)
+			} else if deleted {
+				deletedCount++
+				if r.Config != nil && r.Config.UseOwnerReferences && !r.dryRun(sourceObj) {
+					binding := buildMirrorBinding(sourceUnstructured, ns)
+					if err := deleteMirrorBinding(ctx, r.Client, binding); err != nil {
+						logger.Error(err, "failed to delete mirror binding", "namespace", ns)
+					}
+				}
+			}
 		}
 
-		// This is an orphaned mirror - delete it
-		if err := r.Delete(ctx, mirror); err != nil {
-			logger.Error(err, "failed to delete orphaned mirror", "namespace", ns)
-			continue
+		if renamed {
+			deleted, err := r.deleteOwnMirror(ctx, sourceUnstructured, ns, sourceObj.GetName(),
+				fmt.Sprintf("source is now mirrored as %s", mirrorName))
+			if err != nil {
+				logger.Error(err, "failed to delete mirror under previous name", "namespace", ns)
+			} else if deleted {
+				deletedCount++
+			}
 		}
-
-		deletedCount++
-		logger.V(1).Info("deleted orphaned mirror", "namespace", ns)
 	}
 
 	return deletedCount, nil
 }
 
-// resolveTargetNamespaces determines which namespaces should receive mirrors.
-func (r *SourceReconciler) resolveTargetNamespaces(ctx context.Context, sourceObj metav1.Object) ([]string, error) {
-	annotations := sourceObj.GetAnnotations()
-	if annotations == nil {
-		return nil, nil
-	}
-
-	targetNsAnnotation := annotations[constants.AnnotationTargetNamespaces]
-	if targetNsAnnotation == "" {
-		return nil, nil
-	}
-
-	// Parse patterns
-	patterns := filter.ParseTargetNamespaces(targetNsAnnotation)
-	if len(patterns) == 0 {
-		return nil, nil
-	}
-
-	// Validate patterns and log warnings for invalid ones
-	validationResults, allValid := filter.ValidatePatterns(patterns)
-	if !allValid {
-		logger := log.FromContext(ctx)
-		invalidPatterns := filter.InvalidPatterns(validationResults)
-		for _, invalid := range invalidPatterns {
-			logger.Info("invalid glob pattern in target-namespaces annotation, pattern will be skipped",
-				"pattern", invalid.Pattern,
-				"error", invalid.Error.Error(),
-				"source", sourceObj.GetName(),
-				"namespace", sourceObj.GetNamespace(),
-			)
-		}
-
-		// Filter to only valid patterns
-		var validPatterns []string
-		for _, result := range validationResults {
-			if result.Valid {
-				validPatterns = append(validPatterns, result.Pattern)
-			}
-		}
-		patterns = validPatterns
+// deleteOwnMirror deletes the mirror named name in namespace ns if it is managed
+// by kubemirror and points to source. It reports whether a mirror was deleted.
+func (r *SourceReconciler) deleteOwnMirror(ctx context.Context, source *unstructured.Unstructured, ns, name, why string) (bool, error) {
+	return r.deleteOwnMirrorOfKind(ctx, source, MirrorGVK(source), ns, name, why)
+}
 
-		// If no valid patterns remain, return empty
-		if len(patterns) == 0 {
-			return nil, nil
-		}
-	}
+// deleteOwnMirrorOfKind is deleteOwnMirror for a mirror of kind gvk.
+func (r *SourceReconciler) deleteOwnMirrorOfKind(ctx context.Context, source *unstructured.Unstructured, gvk schema.GroupVersionKind, ns, name, why string) (bool, error) {
+	mirror := &unstructured.Unstructured{}
+	mirror.SetGroupVersionKind(gvk)
 
-	// Get all namespace info in a single API call (more efficient than 3 separate calls)
-	nsInfo, err := r.NamespaceLister.ListNamespacesWithLabels(ctx)
+	err := r.Get(ctx, client.ObjectKey{Namespace: ns, Name: name}, mirror)
+	if errors.IsNotFound(err) {
+		// No mirror exists, nothing to clean up
+		return false, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+		return false, fmt.Errorf("failed to check for mirror: %w", err)
 	}
 
-	// Resolve target namespaces using the pre-categorized namespace info
-	targetNamespaces := filter.ResolveTargetNamespaces(
-		patterns,
-		nsInfo.All,
-		nsInfo.AllowMirrors,
-		nsInfo.OptOut,
-		sourceObj.GetNamespace(),
-		r.Filter,
-	)
+	// Verify this is actually our mirror (not someone else's resource with the same name)
+	if !IsManagedByUs(mirror) {
+		return false, nil
+	}
+
+	// Verify this mirror points to our source
+	srcNs, srcName, _, found := GetSourceReference(mirror)
+	if !found || srcNs != source.GetNamespace() || srcName != source.GetName() {
+		return false, nil
+	}
+
+	if r.dryRun(source) {
+		reportDryRun(ctx, r.Recorder, source, r.GVK, dryRunDelete, ns, why)
+		return true, nil
+	}
 
-	// Enforce max targets limit
-	if r.Config != nil && r.Config.MaxTargetsPerResource > 0 && len(targetNamespaces) > r.Config.MaxTargetsPerResource {
-		targetNamespaces = targetNamespaces[:r.Config.MaxTargetsPerResource]
+	if err := detachMirror(ctx, r.Client, mirror); err != nil {
+		return false, fmt.Errorf("failed to detach mirror from service accounts: %w", err)
+	}
+	if err := r.Delete(ctx, mirror); err != nil {
+		return false, err
 	}
 
-	return targetNamespaces, nil
+	log.FromContext(ctx).V(1).Info("deleted orphaned mirror", "namespace", ns, "name", name, "reason", why)
+	recordMirrorDeleted(r.Recorder, source, ns, why)
+	return true, nil
+}
+
+// resolveTargetNamespaces determines which namespaces should receive mirrors.
+func (r *SourceReconciler) resolveTargetNamespaces(ctx context.Context, sourceObj client.Object) ([]string, error) {
+	targets, _, err := r.resolveLimitedTargets(ctx, sourceObj)
+	return targets, err
 }
 
-// updateLastSyncStatus updates the source resource's annotations with sync status.
-func (r *SourceReconciler) updateLastSyncStatus(ctx context.Context, source runtime.Object, sourceObj metav1.Object, reconciledCount, errorCount int) error {
-	annotations := sourceObj.GetAnnotations()
-	if annotations == nil {
-		annotations = make(map[string]string)
+// resolveLimitedTargets determines which namespaces should receive mirrors, and
+// which were omitted over the source's max-targets limit.
+func (r *SourceReconciler) resolveLimitedTargets(ctx context.Context, sourceObj client.Object) (targets, omitted []string, err error) {
+	ctx, span := tracing.Start(ctx, "ResolveTargetNamespaces")
+	defer func() {
+		span.SetAttributes(tracing.AttrTargetCount.Int(len(targets)))
+		tracing.End(span, err)
+	}()
+
+	targetNamespaces, err := resolveTargets(ctx, r.TargetResolver, r.NamespaceLister, r.Filter, sourceObj,
+		policyTargetPatterns(r.Policies, r.GVK, sourceObj))
+	if err != nil || len(targetNamespaces) == 0 {
+		return nil, nil, err
 	}
 
-	annotations[constants.AnnotationSyncStatus] = fmt.Sprintf("reconciled:%d,errors:%d", reconciledCount, errorCount)
+	// Namespaces the mirrors expired in are no longer targets
+	targetNamespaces, err = dropExpiredTargets(ctx, r.Client, sourceObj, targetNamespaces, time.Now())
+	if err != nil {
+		if source, ok := sourceObj.(*unstructured.Unstructured); ok {
+			r.recordEvent(source, corev1.EventTypeWarning, ReasonInvalidMirrorTTL, "Mirror", "%s", err.Error())
+		}
+		return nil, nil, err
+	}
+
+	// Enforce max targets limit; an invalid override leaves the global one
+	if _, _, limitErr := maxTargetsOverride(sourceObj); limitErr != nil {
+		if source, ok := sourceObj.(*unstructured.Unstructured); ok {
+			r.recordEvent(source, corev1.EventTypeWarning, ReasonInvalidMaxTargets, "Mirror", "%s", limitErr.Error())
+		}
+	}
+	targetNamespaces, omitted, err = truncateTargets(ctx, r.Config, r.NamespaceLister, sourceObj, targetNamespaces)
+	if tooMany := tooManyTargets(err); tooMany != nil {
+		if source, ok := sourceObj.(*unstructured.Unstructured); ok {
+			r.reportTooManyTargets(source, tooMany)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(omitted) > 0 {
+		log.FromContext(ctx).Info("target namespaces truncated to max targets",
+			"limit", len(targetNamespaces),
+			"omitted", len(omitted),
+		)
+		if source, ok := sourceObj.(*unstructured.Unstructured); ok {
+			r.reportTruncatedTargets(source, targetNamespaces, omitted)
+		}
+	}
 
-	sourceObj.SetAnnotations(annotations)
-	// source (*unstructured.Unstructured) already implements client.Object
-	return r.Update(ctx, source.(*unstructured.Unstructured))
+	return targetNamespaces, omitted, nil
 }
 
 // isEnabledForMirroring checks if a resource has both the label and annotation for mirroring.
@@ -656,16 +1177,49 @@ func (r *SourceReconciler) SetupWithManagerForResourceType(
 		GenericFunc: func(e event.GenericEvent) bool { return false },
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(obj).
+	bldr := ctrl.NewControllerManagedBy(mgr).
+		// Updates leaving the synced content and kubemirror's metadata alone need no reconcile
+		For(obj, builder.WithPredicates(skipMetadataOnlyUpdates, r.observeSourceEvents())).
 		Named(controllerName).
+		WithOptions(controllerOptions(r.Config, gvk, r.sharded())).
 		// Watch mirror resources - when deleted, enqueue source for reconciliation
 		Watches(
 			mirrorObj,
 			handler.EnqueueRequestsFromMapFunc(r.mapMirrorToSource),
 			builder.WithPredicates(mirrorDeletePredicate),
-		).
-		Complete(r)
+		)
+
+	if r.sharded() || r.Policies != nil || r.RuleLibrary != nil {
+		// Sources are requeued through this channel whenever a lease is acquired, a
+		// policy changes or a rule library they reference changes
+		r.resync = make(chan event.GenericEvent)
+		bldr = bldr.WatchesRawSource(source.Channel(r.resync, &handler.EnqueueRequestForObject{}))
+	}
+
+	if err := bldr.Complete(scheduleRetries(traceReconciler(observeReconciler(r, controllerName, r.Observer), "SourceReconciler", r.GVK))); err != nil {
+		return err
+	}
+
+	if r.Leadership != nil {
+		r.Leadership.Campaign(gvk, r.enqueueAllSources)
+	}
+	if r.NamespaceOwnership != nil {
+		r.NamespaceOwnership.OnShardAcquired(r.enqueueAllSources)
+	}
+	if r.Policies != nil {
+		r.Policies.OnChange(r.enqueuePolicySources)
+	}
+	if r.RuleLibrary != nil {
+		r.RuleLibrary.OnChange(r.enqueueRuleLibrarySources)
+	}
+
+	return nil
+}
+
+// sharded reports whether writes are coordinated by kubemirror's own leases
+// instead of the manager-wide leader election.
+func (r *SourceReconciler) sharded() bool {
+	return r.Leadership != nil || r.NamespaceOwnership != nil
 }
 
 // mapMirrorToSource maps a mirror resource to its source for reconciliation.
@@ -16,12 +16,15 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/lukaszraczylo/kubemirror/pkg/config"
 	"github.com/lukaszraczylo/kubemirror/pkg/constants"
 	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/status"
 )
 
 // MockClient is a mock implementation of client.Client for testing.
@@ -134,6 +137,12 @@ func (m *MockNamespaceLister) ListOptOutNamespaces(ctx context.Context) ([]strin
 	return args.Get(0).([]string), args.Error(1)
 }
 
+// ListTerminatingNamespaces reports no terminating namespaces, so tests need not
+// expect the call.
+func (m *MockNamespaceLister) ListTerminatingNamespaces(ctx context.Context) ([]string, error) {
+	return []string{}, nil
+}
+
 func (m *MockNamespaceLister) ListNamespacesWithLabels(ctx context.Context) (*NamespaceInfo, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
@@ -142,6 +151,19 @@ func (m *MockNamespaceLister) ListNamespacesWithLabels(ctx context.Context) (*Na
 	return args.Get(0).(*NamespaceInfo), args.Error(1)
 }
 
+// newFakeClient returns a fake client holding objs and the default, team-a and
+// team-b namespaces.
+func newFakeClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	for _, ns := range []string{"default", "team-a", "team-b"} {
+		objs = append(objs, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}})
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
 func TestIsEnabledForMirroring(t *testing.T) {
 	tests := []struct {
 		obj  metav1.Object
@@ -389,6 +411,50 @@ func TestSourceReconciler_Reconcile_MirrorResource(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+func TestSourceReconciler_Reconcile_ServiceAccountTokenRefused(t *testing.T) {
+	mockClient := new(MockClient)
+	recorder := events.NewFakeRecorder(10)
+
+	r := &SourceReconciler{
+		Client:          mockClient,
+		Scheme:          runtime.NewScheme(),
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter([]string{}, []string{}),
+		NamespaceLister: new(MockNamespaceLister),
+		Recorder:        recorder,
+		GVK:             schema.GroupVersionKind{Version: "v1", Kind: "Secret"},
+	}
+
+	tokenSecret := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"type":       "kubernetes.io/service-account-token",
+			"metadata": map[string]interface{}{
+				"name":        "builder-token",
+				"namespace":   "app1",
+				"labels":      map[string]interface{}{constants.LabelEnabled: "true"},
+				"annotations": map[string]interface{}{constants.AnnotationSync: "true", constants.AnnotationTargetNamespaces: "all"},
+			},
+		},
+	}
+
+	// Only the source is read: no finalizer is added and no mirror is written
+	mockClient.On("Get", mock.Anything, mock.Anything, mock.AnythingOfType("*unstructured.Unstructured")).
+		Return(nil, tokenSecret)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "app1", Name: "builder-token"}}
+	result, err := r.Reconcile(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	mockClient.AssertExpectations(t)
+
+	event := <-recorder.Events
+	assert.Contains(t, event, corev1.EventTypeWarning)
+	assert.Contains(t, event, ReasonNotMirrorable)
+}
+
 func TestSourceReconciler_Reconcile_NotFound(t *testing.T) {
 	// Test that deleted resources are handled gracefully
 	mockClient := new(MockClient)
@@ -654,43 +720,20 @@ func TestSourceReconciler_Reconcile_AnnotationChange_AllToAllLabeled(t *testing.
 	mockClient.On("Get", mock.Anything, types.NamespacedName{Namespace: "default", Name: "test-secret"}, mock.Anything).
 		Return(nil, source)
 
-	// Helper to create a mock mirror for verification
-	createMirror := func(ns string) *unstructured.Unstructured {
-		return &unstructured.Unstructured{
-			Object: map[string]any{
-				"apiVersion": "v1",
-				"kind":       "Secret",
-				"metadata": map[string]any{
-					"name":      "test-secret",
-					"namespace": ns,
-					"labels": map[string]any{
-						constants.LabelManagedBy: constants.ControllerName,
-						constants.LabelMirror:    "true",
-					},
-				},
-			},
-		}
-	}
-
 	// Mock reconcileMirror calls for app-1 and app-2 (current targets)
 	notFoundErr := errors.NewNotFound(schema.GroupResource{Group: "", Resource: "secrets"}, "test-secret")
 	mockClient.On("Get", mock.Anything, types.NamespacedName{Namespace: "app-1", Name: "test-secret"}, mock.Anything).
 		Return(notFoundErr, nil).Once()
-	mockClient.On("Create", mock.Anything, mock.MatchedBy(func(obj client.Object) bool {
-		return obj.GetNamespace() == "app-1"
-	}), mock.Anything).Return(nil).Once()
-	// Verification Get after Create
-	mockClient.On("Get", mock.Anything, types.NamespacedName{Namespace: "app-1", Name: "test-secret"}, mock.Anything).
-		Return(nil, createMirror("app-1")).Once()
+	mockClient.On("Apply", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
 
 	mockClient.On("Get", mock.Anything, types.NamespacedName{Namespace: "app-2", Name: "test-secret"}, mock.Anything).
 		Return(notFoundErr, nil).Once()
-	mockClient.On("Create", mock.Anything, mock.MatchedBy(func(obj client.Object) bool {
-		return obj.GetNamespace() == "app-2"
-	}), mock.Anything).Return(nil).Once()
-	// Verification Get after Create
-	mockClient.On("Get", mock.Anything, types.NamespacedName{Namespace: "app-2", Name: "test-secret"}, mock.Anything).
-		Return(nil, createMirror("app-2")).Once()
+	mockClient.On("Apply", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	// New mirrors check the mirror quota of their namespace, none here
+	for _, ns := range []string{"app-1", "app-2"} {
+		mockClient.On("Get", mock.Anything, types.NamespacedName{Name: ns}, mock.Anything).Return(nil, nil).Once()
+	}
 
 	// Mock cleanup: check orphaned namespaces app-3, prod-1, prod-2
 	mockClient.On("Get", mock.Anything, types.NamespacedName{Namespace: "app-3", Name: "test-secret"}, mock.Anything).
@@ -713,6 +756,8 @@ func TestSourceReconciler_Reconcile_AnnotationChange_AllToAllLabeled(t *testing.
 
 	// Mock Update for status annotation
 	mockClient.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	// Mock Patch recording the source content hash
+	mockClient.On("Patch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
 
 	r := &SourceReconciler{
 		Client:          mockClient,
@@ -720,6 +765,7 @@ func TestSourceReconciler_Reconcile_AnnotationChange_AllToAllLabeled(t *testing.
 		Config:          &config.Config{},
 		Filter:          mockFilter,
 		NamespaceLister: mockLister,
+		StatusReporter:  &status.AnnotationReporter{Client: mockClient},
 		GVK:             schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Secret"},
 	}
 
@@ -810,42 +856,19 @@ func TestSourceReconciler_Reconcile_AnnotationChange_PatternChange(t *testing.T)
 
 	notFoundErr := errors.NewNotFound(schema.GroupResource{Group: "", Resource: "configmaps"}, "app-config")
 
-	// Helper to create a mock mirror for verification
-	createMirror := func(ns string) *unstructured.Unstructured {
-		return &unstructured.Unstructured{
-			Object: map[string]any{
-				"apiVersion": "v1",
-				"kind":       "ConfigMap",
-				"metadata": map[string]any{
-					"name":      "app-config",
-					"namespace": ns,
-					"labels": map[string]any{
-						constants.LabelManagedBy: constants.ControllerName,
-						constants.LabelMirror:    "true",
-					},
-				},
-			},
-		}
-	}
-
 	// Mock reconcileMirror for prod-1 and prod-2 (new targets)
 	mockClient.On("Get", mock.Anything, types.NamespacedName{Namespace: "prod-1", Name: "app-config"}, mock.Anything).
 		Return(notFoundErr, nil).Once()
-	mockClient.On("Create", mock.Anything, mock.MatchedBy(func(obj client.Object) bool {
-		return obj.GetNamespace() == "prod-1"
-	}), mock.Anything).Return(nil).Once()
-	// Verification Get after Create
-	mockClient.On("Get", mock.Anything, types.NamespacedName{Namespace: "prod-1", Name: "app-config"}, mock.Anything).
-		Return(nil, createMirror("prod-1")).Once()
+	mockClient.On("Apply", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
 
 	mockClient.On("Get", mock.Anything, types.NamespacedName{Namespace: "prod-2", Name: "app-config"}, mock.Anything).
 		Return(notFoundErr, nil).Once()
-	mockClient.On("Create", mock.Anything, mock.MatchedBy(func(obj client.Object) bool {
-		return obj.GetNamespace() == "prod-2"
-	}), mock.Anything).Return(nil).Once()
-	// Verification Get after Create
-	mockClient.On("Get", mock.Anything, types.NamespacedName{Namespace: "prod-2", Name: "app-config"}, mock.Anything).
-		Return(nil, createMirror("prod-2")).Once()
+	mockClient.On("Apply", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	// New mirrors check the mirror quota of their namespace, none here
+	for _, ns := range []string{"prod-1", "prod-2"} {
+		mockClient.On("Get", mock.Anything, types.NamespacedName{Name: ns}, mock.Anything).Return(nil, nil).Once()
+	}
 
 	// Mock cleanup: delete orphaned mirrors in app-1, app-2, app-3
 	for _, ns := range []string{"app-1", "app-2", "app-3"} {
@@ -858,6 +881,8 @@ func TestSourceReconciler_Reconcile_AnnotationChange_PatternChange(t *testing.T)
 
 	// Mock Update for status
 	mockClient.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	// Mock Patch recording the source content hash
+	mockClient.On("Patch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
 
 	r := &SourceReconciler{
 		Client:          mockClient,
@@ -865,6 +890,7 @@ func TestSourceReconciler_Reconcile_AnnotationChange_PatternChange(t *testing.T)
 		Config:          &config.Config{},
 		Filter:          mockFilter,
 		NamespaceLister: mockLister,
+		StatusReporter:  &status.AnnotationReporter{Client: mockClient},
 		GVK:             schema.GroupVersionKind{Group: "", Version: "v1", Kind: "ConfigMap"},
 	}
 
@@ -879,3 +905,117 @@ func TestSourceReconciler_Reconcile_AnnotationChange_PatternChange(t *testing.T)
 	mockClient.AssertExpectations(t)
 	mockLister.AssertExpectations(t)
 }
+
+// recordingReporter captures the last reported sync result.
+type recordingReporter struct {
+	result status.Result
+}
+
+func (r *recordingReporter) Report(_ context.Context, _ *unstructured.Unstructured, result status.Result) error {
+	r.result = result
+	return nil
+}
+
+func TestSourceReconciler_Reconcile_ReportsTargetStatus(t *testing.T) {
+	source := makeUnstructuredSecret("app-secret", "default",
+		map[string]string{constants.LabelEnabled: "true"},
+		map[string]string{constants.AnnotationSync: "true", constants.AnnotationTargetNamespaces: "team-a,team-b"})
+	source.SetFinalizers([]string{constants.FinalizerName})
+	unmanaged := makeUnstructuredSecret("app-secret", "team-b", nil, nil)
+	c := newFakeClient(t, source, unmanaged)
+
+	reporter := &recordingReporter{}
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		StatusReporter:  reporter,
+		GVK:             secretGVK,
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app-secret"}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	require.Len(t, reporter.result.Targets, 2)
+	synced, skipped := reporter.result.Targets[0], reporter.result.Targets[1]
+	assert.Equal(t, "team-a", synced.Namespace)
+	assert.Equal(t, status.TargetSynced, synced.State)
+	assert.NotEmpty(t, synced.ContentHash)
+	assert.False(t, synced.LastSyncTime.IsZero())
+	assert.Equal(t, "team-b", skipped.Namespace)
+	assert.Equal(t, status.TargetSkipped, skipped.State)
+	assert.Empty(t, reporter.result.FailedTargets())
+}
+
+func TestSourceReconciler_Reconcile_RenamedMirror(t *testing.T) {
+	source := makeUnstructuredSecret("shared-tls", "default", map[string]string{constants.LabelEnabled: "true"},
+		map[string]string{
+			constants.AnnotationSync:             "true",
+			constants.AnnotationTargetNamespaces: "team-a",
+			constants.AnnotationTargetNamePrefix: "team-a-",
+		})
+	source.SetFinalizers([]string{constants.FinalizerName})
+	// Written before the source was renamed
+	c := newFakeClient(t, source, makeUnstructuredMirror("shared-tls", "team-a", "default", "shared-tls"))
+
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "shared-tls"}}
+	_, err := r.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+
+	renamed := &corev1.Secret{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "team-a-shared-tls"}, renamed))
+	assert.Equal(t, "shared-tls", renamed.Annotations[constants.AnnotationSourceName])
+
+	previous := &corev1.Secret{}
+	assert.True(t, errors.IsNotFound(c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "shared-tls"}, previous)))
+}
+
+// recordingAPIReader records the keys of objects read past the cache.
+type recordingAPIReader struct {
+	client.Reader
+	keys []client.ObjectKey
+}
+
+func (r *recordingAPIReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	r.keys = append(r.keys, key)
+	return r.Reader.Get(ctx, key, obj, opts...)
+}
+
+func TestSourceReconciler_Reconcile_FreshnessReadsSourceOnly(t *testing.T) {
+	source := makeUnstructuredSecret("app-secret", "default",
+		map[string]string{constants.LabelEnabled: "true"},
+		map[string]string{constants.AnnotationSync: "true", constants.AnnotationTargetNamespaces: "team-a,team-b"})
+	source.SetFinalizers([]string{constants.FinalizerName})
+	c := newFakeClient(t, source)
+
+	apiReader := &recordingAPIReader{Reader: c}
+	r := &SourceReconciler{
+		Client:          c,
+		APIReader:       apiReader,
+		Config:          &config.Config{VerifySourceFreshness: true},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "app-secret"}}
+	// The second reconcile finds the mirrors written by the first
+	for range 2 {
+		_, err := r.Reconcile(context.Background(), req)
+		require.NoError(t, err)
+	}
+
+	sourceKey := client.ObjectKey{Namespace: "default", Name: "app-secret"}
+	assert.Equal(t, []client.ObjectKey{sourceKey, sourceKey}, apiReader.keys,
+		"only the source is read past the cache, never a mirror")
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// controllerGroup stops the controllers of one resource type together, without
+// stopping the manager they run in.
+type controllerGroup struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newControllerGroup() *controllerGroup {
+	return &controllerGroup{stop: make(chan struct{})}
+}
+
+// Stop stops the controllers of the group; it can be called more than once.
+func (g *controllerGroup) Stop() {
+	g.stopOnce.Do(func() { close(g.stop) })
+}
+
+// manager returns mgr adding the controllers built against it to the group.
+// Controller names are not checked for uniqueness, so a resource type can get
+// its controllers back after they were stopped.
+func (g *controllerGroup) manager(mgr ctrl.Manager) ctrl.Manager {
+	return &groupManager{Manager: mgr, group: g}
+}
+
+// groupManager is a manager whose runnables stop with their controller group.
+type groupManager struct {
+	ctrl.Manager
+	group *controllerGroup
+}
+
+// Add implements manager.Manager.
+func (m *groupManager) Add(r manager.Runnable) error {
+	return m.Manager.Add(&stoppableRunnable{Runnable: r, stop: m.group.stop})
+}
+
+// GetControllerOptions implements manager.Manager.
+func (m *groupManager) GetControllerOptions() config.Controller {
+	opts := m.Manager.GetControllerOptions()
+	skip := true
+	opts.SkipNameValidation = &skip
+	return opts
+}
+
+// stoppableRunnable runs a runnable until the manager stops or stop is closed.
+type stoppableRunnable struct {
+	manager.Runnable
+	stop <-chan struct{}
+}
+
+// Start implements manager.Runnable.
+func (r *stoppableRunnable) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-r.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return r.Runnable.Start(ctx)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, deferring to the
+// wrapped runnable.
+func (r *stoppableRunnable) NeedLeaderElection() bool {
+	if le, ok := r.Runnable.(manager.LeaderElectionRunnable); ok {
+		return le.NeedLeaderElection()
+	}
+	return true
+}
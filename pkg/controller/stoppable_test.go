@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// recordingManager keeps the runnables added to it instead of running them.
+type recordingManager struct {
+	ctrl.Manager
+	added []manager.Runnable
+}
+
+func (m *recordingManager) Add(r manager.Runnable) error {
+	m.added = append(m.added, r)
+	return nil
+}
+
+func (m *recordingManager) GetControllerOptions() config.Controller {
+	return config.Controller{}
+}
+
+// followerRunnable blocks until its context is done and never needs leader election.
+type followerRunnable struct{}
+
+func (followerRunnable) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (followerRunnable) NeedLeaderElection() bool { return false }
+
+func TestControllerGroup(t *testing.T) {
+	recorder := &recordingManager{}
+	group := newControllerGroup()
+	mgr := group.manager(recorder)
+
+	skip := mgr.GetControllerOptions().SkipNameValidation
+	require.NotNil(t, skip)
+	assert.True(t, *skip, "controllers can be registered again under the same name")
+
+	require.NoError(t, mgr.Add(followerRunnable{}))
+	require.Len(t, recorder.added, 1)
+	runnable := recorder.added[0]
+	assert.False(t, runnable.(manager.LeaderElectionRunnable).NeedLeaderElection())
+
+	done := make(chan error, 1)
+	go func() { done <- runnable.Start(context.Background()) }()
+
+	group.Stop()
+	group.Stop()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("runnable kept running after its group stopped")
+	}
+}
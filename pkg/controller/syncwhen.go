@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+// ReasonInvalidSyncCondition is the Event reason used when a source's sync-when
+// annotation cannot be parsed.
+const ReasonInvalidSyncCondition = "InvalidSyncCondition"
+
+// syncConditionMet evaluates the source's sync-when annotation, so sources still
+// being initialized by their operator are not propagated half-done. Sources
+// without the annotation always sync.
+func syncConditionMet(source *unstructured.Unstructured) (bool, error) {
+	expr := source.GetAnnotations()[constants.AnnotationSyncWhen]
+	if expr == "" {
+		return true, nil
+	}
+
+	condition, err := filter.ParseCondition(expr)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s annotation: %w", constants.AnnotationSyncWhen, err)
+	}
+	return condition.Matches(source.Object), nil
+}
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func TestSyncConditionMet(t *testing.T) {
+	makeSource := func(ready string, annotations map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Database",
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": ready},
+				},
+			},
+		}}
+		u.SetAnnotations(annotations)
+		return u
+	}
+	readyWhen := map[string]string{constants.AnnotationSyncWhen: `status.conditions[type=="Ready"].status == "True"`}
+
+	met, err := syncConditionMet(makeSource("False", nil))
+	require.NoError(t, err)
+	assert.True(t, met, "sources without a condition always sync")
+
+	met, err = syncConditionMet(makeSource("False", readyWhen))
+	require.NoError(t, err)
+	assert.False(t, met)
+
+	met, err = syncConditionMet(makeSource("True", readyWhen))
+	require.NoError(t, err)
+	assert.True(t, met)
+
+	_, err = syncConditionMet(makeSource("True", map[string]string{constants.AnnotationSyncWhen: `status.ready =`}))
+	assert.ErrorContains(t, err, constants.AnnotationSyncWhen)
+}
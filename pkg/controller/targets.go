@@ -0,0 +1,270 @@
+package controller
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/status"
+)
+
+// Event reasons of target resolution.
+const (
+	// ReasonTargetsTruncated is used when a source resolves to more target
+	// namespaces than its max-targets limit allows
+	ReasonTargetsTruncated = "TargetsTruncated"
+	// ReasonInvalidMaxTargets is used on a source whose max-targets annotation
+	// cannot be parsed; the global limit applies
+	ReasonInvalidMaxTargets = "InvalidMaxTargets"
+	// ReasonInvalidTargetPattern is used on a source whose target-namespaces
+	// annotation holds a pattern that is not a valid glob; it matches no namespace
+	ReasonInvalidTargetPattern = "InvalidTargetPattern"
+)
+
+// maxOmittedInEvent bounds how many omitted namespaces an Event lists by name.
+const maxOmittedInEvent = 20
+
+// targetsTruncatedTotal counts reconciles that dropped target namespaces over the limit.
+var targetsTruncatedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubemirror_targets_truncated_total",
+	Help: "Number of reconciles where a source's target namespaces were truncated to their max-targets limit.",
+}, []string{"source"})
+
+func init() {
+	metrics.Registry.MustRegister(targetsTruncatedTotal)
+}
+
+// invalidTargetPatterns returns the patterns of source's target-namespaces
+// annotation that are not valid globs.
+func invalidTargetPatterns(source metav1.Object) []filter.PatternValidationResult {
+	patterns := filter.ParseTargetNamespaces(source.GetAnnotations()[constants.AnnotationTargetNamespaces])
+	if results, valid := filter.ValidatePatterns(patterns); !valid {
+		return filter.InvalidPatterns(results)
+	}
+	return nil
+}
+
+// checkTargetPatterns emits a warning Event naming each invalid pattern of
+// source's target-namespaces annotation, which resolution skips, and returns them.
+func (r *SourceReconciler) checkTargetPatterns(source *unstructured.Unstructured) []string {
+	var patterns []string
+	for _, invalid := range invalidTargetPatterns(source) {
+		r.recordEvent(source, corev1.EventTypeWarning, ReasonInvalidTargetPattern, "Mirror",
+			"target-namespaces pattern %q is skipped and matches no namespace: %v", invalid.Pattern, invalid.Error)
+		patterns = append(patterns, invalid.Pattern)
+	}
+	return patterns
+}
+
+// reportInvalidPatterns reports the sync status of a source that resolved to no
+// target namespaces while some of its patterns are invalid, so the status says
+// why nothing was mirrored.
+func (r *SourceReconciler) reportInvalidPatterns(ctx context.Context, source *unstructured.Unstructured, patterns []string, ownsSource bool) error {
+	if len(patterns) == 0 || r.StatusReporter == nil || !ownsSource || r.dryRun(source) {
+		return nil
+	}
+	if err := r.StatusReporter.Report(ctx, source, status.Result{InvalidPatterns: patterns}); err != nil {
+		log.FromContext(ctx).Error(err, "failed to report sync status")
+		return err
+	}
+	return nil
+}
+
+// maxTargetsOverride returns the limit the source's max-targets annotation sets,
+// with ok false when it has none.
+func maxTargetsOverride(source metav1.Object) (limit int, ok bool, err error) {
+	value, ok := source.GetAnnotations()[constants.AnnotationMaxTargets]
+	if !ok {
+		return 0, false, nil
+	}
+	limit, err = strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || limit <= 0 {
+		return 0, false, fmt.Errorf("invalid %s %q: must be a positive integer", constants.AnnotationMaxTargets, value)
+	}
+	return limit, true, nil
+}
+
+// targetLimit returns how many target namespaces source may have: its
+// max-targets annotation, else MaxTargetsPerResource (0 = unlimited).
+func targetLimit(cfg *config.Config, source metav1.Object) int {
+	if limit, ok, _ := maxTargetsOverride(source); ok {
+		return limit
+	}
+	if cfg == nil {
+		return 0
+	}
+	return cfg.MaxTargets()
+}
+
+// TooManyTargetsError is returned under the fail truncation policy when a source
+// resolves to more target namespaces than its limit.
+type TooManyTargetsError struct {
+	Limit   int
+	Targets int
+}
+
+func (e *TooManyTargetsError) Error() string {
+	return fmt.Sprintf("%d target namespaces exceed the limit of %d; not syncing under the %s truncation policy",
+		e.Targets, e.Limit, config.TruncateFail)
+}
+
+// tooManyTargets returns the TooManyTargetsError in err's chain, or nil.
+func tooManyTargets(err error) *TooManyTargetsError {
+	var tooMany *TooManyTargetsError
+	if errors.As(err, &tooMany) {
+		return tooMany
+	}
+	return nil
+}
+
+// truncationPolicy returns the truncation policy of cfg, or the default.
+func truncationPolicy(cfg *config.Config) config.TruncationPolicy {
+	if cfg == nil {
+		return config.DefaultTruncationPolicy
+	}
+	return cfg.TargetTruncation()
+}
+
+// limitTargets enforces the max-targets limit of source under the configured
+// truncation policy. The choice depends only on the namespaces, never on the
+// order they were resolved in, so every reconciler keeps the same ones; otherwise
+// the kept set would change between reconciles and mirrors would flap. created
+// holds namespace creation times for the oldest-first policy; namespaces missing
+// from it count as the newest. Under the fail policy, every target is omitted
+// and a *TooManyTargetsError returned. kept and omitted are sorted; targets is
+// left as it was.
+func limitTargets(cfg *config.Config, source metav1.Object, targets []string, created map[string]time.Time) (kept, omitted []string, err error) {
+	limit := targetLimit(cfg, source)
+	if limit <= 0 || len(targets) <= limit {
+		return targets, nil, nil
+	}
+	// targets may be shared with the caller, so never sort it in place
+	targets = slices.Sorted(slices.Values(targets))
+
+	switch truncationPolicy(cfg) {
+	case config.TruncateFail:
+		return nil, targets, &TooManyTargetsError{Limit: limit, Targets: len(targets)}
+	case config.TruncateOldestFirst:
+		slices.SortStableFunc(targets, func(a, b string) int { return compareCreated(created, a, b) })
+		kept, omitted = targets[:limit], targets[limit:]
+		slices.Sort(kept)
+		slices.Sort(omitted)
+		return kept, omitted, nil
+	default:
+		return targets[:limit], targets[limit:], nil
+	}
+}
+
+// compareCreated orders namespaces a and b by creation time, unknown ones last.
+func compareCreated(created map[string]time.Time, a, b string) int {
+	ta, okA := created[a]
+	tb, okB := created[b]
+	switch {
+	case okA && okB:
+		return ta.Compare(tb)
+	case okA:
+		return -1
+	case okB:
+		return 1
+	default:
+		return cmp.Compare(a, b)
+	}
+}
+
+// truncateTargets runs limitTargets, listing namespace creation times from
+// lister only when the oldest-first policy has to drop targets.
+func truncateTargets(ctx context.Context, cfg *config.Config, lister NamespaceLister, source metav1.Object, targets []string) (kept, omitted []string, err error) {
+	var created map[string]time.Time
+	if limit := targetLimit(cfg, source); limit > 0 && len(targets) > limit &&
+		truncationPolicy(cfg) == config.TruncateOldestFirst && lister != nil {
+		info, listErr := lister.ListNamespacesWithLabels(ctx)
+		if listErr != nil {
+			return nil, nil, fmt.Errorf("failed to list namespaces: %w", listErr)
+		}
+		created = info.Created
+	}
+	return limitTargets(cfg, source, targets, created)
+}
+
+// reportTruncatedTargets records the truncation metric and a Warning Event naming
+// the omitted namespaces, so users learn their pattern exceeded the limit.
+func (r *SourceReconciler) reportTruncatedTargets(source *unstructured.Unstructured, kept, omitted []string) {
+	targetsTruncatedTotal.WithLabelValues(truncationSourceLabel(source)).Inc()
+
+	listed := omitted
+	more := ""
+	if len(listed) > maxOmittedInEvent {
+		listed = listed[:maxOmittedInEvent]
+		more = fmt.Sprintf(" and %d more", len(omitted)-maxOmittedInEvent)
+	}
+	r.recordEvent(source, corev1.EventTypeWarning, ReasonTargetsTruncated, "Mirror",
+		"Target namespaces exceed the limit of %d, omitting %d: %s%s",
+		len(kept), len(omitted), strings.Join(listed, ", "), more)
+}
+
+// reportTooManyTargets records the truncation metric and a Warning Event for a
+// source left unsynced under the fail truncation policy.
+func (r *SourceReconciler) reportTooManyTargets(source *unstructured.Unstructured, err *TooManyTargetsError) {
+	targetsTruncatedTotal.WithLabelValues(truncationSourceLabel(source)).Inc()
+	r.recordEvent(source, corev1.EventTypeWarning, ReasonTargetsTruncated, "Mirror", "%s", err.Error())
+}
+
+// truncationSourceLabel identifies a source in the truncation metric.
+func truncationSourceLabel(source *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s/%s", source.GetKind(), source.GetNamespace(), source.GetName())
+}
+
+// dropTerminatingNamespaces returns targets without the namespaces being deleted.
+// Writes into them fail until they are gone and their mirrors go with them, so
+// they are neither synced nor retried, and drop out of the sync status.
+func (r *SourceReconciler) dropTerminatingNamespaces(ctx context.Context, targets []string) []string {
+	if len(targets) == 0 || r.NamespaceLister == nil {
+		return targets
+	}
+	names, err := r.NamespaceLister.ListTerminatingNamespaces(ctx)
+	if err != nil {
+		log.FromContext(ctx).V(1).Info("failed to list terminating namespaces", "error", err.Error())
+		return targets
+	}
+	if len(names) == 0 {
+		return targets
+	}
+
+	terminating := make(map[string]bool, len(names))
+	for _, ns := range names {
+		terminating[ns] = true
+	}
+	// targets may be shared with the caller, so never filter it in place
+	kept := make([]string, 0, len(targets))
+	for _, ns := range targets {
+		if !terminating[ns] {
+			kept = append(kept, ns)
+		}
+	}
+	if dropped := len(targets) - len(kept); dropped > 0 {
+		log.FromContext(ctx).V(1).Info("skipping terminating target namespaces", "count", dropped)
+	}
+	return kept
+}
+
+// namespaceTerminating reports whether a write failed because its namespace
+// started terminating after targets were resolved.
+func namespaceTerminating(err error) bool {
+	return apierrors.HasStatusCause(err, corev1.NamespaceTerminatingCause)
+}
@@ -0,0 +1,459 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/status"
+)
+
+func TestLimitTargets(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         *config.Config
+		maxTargets  string
+		targets     []string
+		wantKept    []string
+		wantOmitted []string
+	}{
+		{
+			name:     "nil config",
+			targets:  []string{"b", "a"},
+			wantKept: []string{"b", "a"},
+		},
+		{
+			name:     "no limit",
+			cfg:      &config.Config{},
+			targets:  []string{"b", "a"},
+			wantKept: []string{"b", "a"},
+		},
+		{
+			name:     "within limit",
+			cfg:      &config.Config{MaxTargetsPerResource: 2},
+			targets:  []string{"b", "a"},
+			wantKept: []string{"b", "a"},
+		},
+		{
+			name:        "over limit keeps the first namespaces in sorted order",
+			cfg:         &config.Config{MaxTargetsPerResource: 2},
+			targets:     []string{"d", "b", "a", "c"},
+			wantKept:    []string{"a", "b"},
+			wantOmitted: []string{"c", "d"},
+		},
+		{
+			name:        "annotation lowers the limit",
+			cfg:         &config.Config{MaxTargetsPerResource: 3},
+			maxTargets:  "1",
+			targets:     []string{"b", "a", "c"},
+			wantKept:    []string{"a"},
+			wantOmitted: []string{"b", "c"},
+		},
+		{
+			name:       "annotation raises the limit",
+			cfg:        &config.Config{MaxTargetsPerResource: 1},
+			maxTargets: "3",
+			targets:    []string{"b", "a", "c"},
+			wantKept:   []string{"b", "a", "c"},
+		},
+		{
+			name:        "annotation applies without a config",
+			maxTargets:  "1",
+			targets:     []string{"b", "a"},
+			wantKept:    []string{"a"},
+			wantOmitted: []string{"b"},
+		},
+		{
+			name:        "invalid annotation keeps the global limit",
+			cfg:         &config.Config{MaxTargetsPerResource: 1},
+			maxTargets:  "0",
+			targets:     []string{"b", "a"},
+			wantKept:    []string{"a"},
+			wantOmitted: []string{"b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &unstructured.Unstructured{}
+			if tt.maxTargets != "" {
+				source.SetAnnotations(map[string]string{constants.AnnotationMaxTargets: tt.maxTargets})
+			}
+			kept, omitted, err := limitTargets(tt.cfg, source, tt.targets, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantKept, kept)
+			assert.Equal(t, tt.wantOmitted, omitted)
+		})
+	}
+}
+
+func TestLimitTargets_TruncationPolicy(t *testing.T) {
+	now := time.Now()
+	created := map[string]time.Time{
+		"a": now,
+		"b": now.Add(-time.Hour),
+		"c": now.Add(-2 * time.Hour),
+	}
+	source := &unstructured.Unstructured{}
+
+	cfg := &config.Config{MaxTargetsPerResource: 2, TruncationPolicy: config.TruncateOldestFirst}
+	targets := []string{"a", "d", "b", "c"}
+	kept, omitted, err := limitTargets(cfg, source, targets, created)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, kept, "the oldest namespaces are kept, in sorted order")
+	assert.Equal(t, []string{"a", "d"}, omitted, "namespaces of unknown age go last")
+	assert.Equal(t, []string{"a", "d", "b", "c"}, targets, "the caller's targets keep their order")
+
+	kept, omitted, err = limitTargets(cfg, source, []string{"c", "b", "a"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, kept, "without creation times the order is alphabetical")
+	assert.Equal(t, []string{"c"}, omitted)
+
+	cfg = &config.Config{MaxTargetsPerResource: 2, TruncationPolicy: config.TruncateFail}
+	kept, omitted, err = limitTargets(cfg, source, []string{"c", "b", "a"}, created)
+	var tooMany *TooManyTargetsError
+	require.ErrorAs(t, err, &tooMany)
+	assert.Equal(t, TooManyTargetsError{Limit: 2, Targets: 3}, *tooMany)
+	assert.Empty(t, kept)
+	assert.Equal(t, []string{"a", "b", "c"}, omitted)
+
+	kept, _, err = limitTargets(cfg, source, []string{"b", "a"}, nil)
+	require.NoError(t, err, "the fail policy only fails sources over their limit")
+	assert.Equal(t, []string{"b", "a"}, kept)
+}
+
+func TestReportTruncatedTargets(t *testing.T) {
+	recorder := events.NewFakeRecorder(10)
+	r := &SourceReconciler{Recorder: recorder}
+
+	source := &unstructured.Unstructured{}
+	source.SetAPIVersion("v1")
+	source.SetKind("Secret")
+	source.SetNamespace("default")
+	source.SetName("truncated")
+
+	omitted := make([]string, maxOmittedInEvent+3)
+	for i := range omitted {
+		omitted[i] = fmt.Sprintf("ns-%02d", i)
+	}
+
+	before := testutil.ToFloat64(targetsTruncatedTotal.WithLabelValues("Secret/default/truncated"))
+	r.reportTruncatedTargets(source, []string{"a", "b"}, omitted)
+	assert.Equal(t, before+1, testutil.ToFloat64(targetsTruncatedTotal.WithLabelValues("Secret/default/truncated")))
+
+	event := <-recorder.Events
+	assert.Contains(t, event, corev1.EventTypeWarning)
+	assert.Contains(t, event, ReasonTargetsTruncated)
+	assert.Contains(t, event, "limit of 2")
+	assert.Contains(t, event, "ns-00, ns-01")
+	assert.Contains(t, event, "and 3 more")
+	assert.NotContains(t, event, fmt.Sprintf("ns-%02d", maxOmittedInEvent))
+}
+
+func TestMaxTargetsOverride(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		wantLimit int
+		wantOK    bool
+		wantErr   bool
+	}{
+		{name: "absent"},
+		{name: "positive", value: "25", wantLimit: 25, wantOK: true},
+		{name: "surrounding spaces", value: " 5 ", wantLimit: 5, wantOK: true},
+		{name: "zero", value: "0", wantErr: true},
+		{name: "negative", value: "-1", wantErr: true},
+		{name: "not a number", value: "many", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &unstructured.Unstructured{}
+			if tt.value != "" {
+				source.SetAnnotations(map[string]string{constants.AnnotationMaxTargets: tt.value})
+			}
+			limit, ok, err := maxTargetsOverride(source)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantLimit, limit)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+func TestSourceReconciler_Reconcile_MaxTargetsOverride(t *testing.T) {
+	ctx := context.Background()
+	source := makeWaveSource("app-secret", "", "team-a,team-b")
+	annotations := source.GetAnnotations()
+	annotations[constants.AnnotationMaxTargets] = "1"
+	source.SetAnnotations(annotations)
+	c := newFakeClient(t, source)
+
+	recorder := events.NewFakeRecorder(10)
+	reporter := &recordingReporter{}
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{MaxTargetsPerResource: 100},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		Recorder:        recorder,
+		StatusReporter:  reporter,
+		GVK:             secretGVK,
+	}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+	require.NoError(t, err)
+
+	mirror := &unstructured.Unstructured{}
+	mirror.SetGroupVersionKind(secretGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, mirror))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Namespace: "team-b", Name: "app-secret"}, mirror)))
+
+	assert.Contains(t, reporter.result.Targets, status.TargetStatus{
+		Namespace: "team-b", State: status.TargetSkipped, Error: "over the limit of 1 target namespaces",
+	}, "the omitted namespace is recorded")
+	event := <-recorder.Events
+	assert.Contains(t, event, ReasonTargetsTruncated)
+	assert.Contains(t, event, "team-b")
+}
+
+func TestSourceReconciler_Reconcile_FailTruncationPolicy(t *testing.T) {
+	ctx := context.Background()
+	source := makeWaveSource("app-secret", "", "team-a,team-b")
+	c := newFakeClient(t, source, makeUnstructuredMirror("app-secret", "team-b", "default", "app-secret"))
+
+	recorder := events.NewFakeRecorder(10)
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{MaxTargetsPerResource: 1, TruncationPolicy: config.TruncateFail},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		Recorder:        recorder,
+		GVK:             secretGVK,
+	}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+	require.Error(t, err)
+
+	mirror := &unstructured.Unstructured{}
+	mirror.SetGroupVersionKind(secretGVK)
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, mirror)),
+		"no mirror is created")
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-b", Name: "app-secret"}, mirror),
+		"existing mirrors are left alone")
+
+	event := <-recorder.Events
+	assert.Contains(t, event, ReasonTargetsTruncated)
+	assert.Contains(t, event, "2 target namespaces exceed the limit of 1")
+}
+
+func TestSourceReconciler_Reconcile_InvalidTargetPattern(t *testing.T) {
+	tests := []struct {
+		name       string
+		targets    string
+		wantMirror bool
+		wantStatus status.Result
+	}{
+		{
+			name:       "only invalid patterns",
+			targets:    "team-[",
+			wantStatus: status.Result{InvalidPatterns: []string{"team-["}},
+		},
+		{
+			name:       "valid patterns still sync",
+			targets:    "team-a, [b",
+			wantMirror: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			source := makeWaveSource("app-secret", "", tt.targets)
+			c := newFakeClient(t, source)
+
+			recorder := events.NewFakeRecorder(10)
+			reporter := &recordingReporter{}
+			r := &SourceReconciler{
+				Client:          c,
+				Config:          &config.Config{MaxTargetsPerResource: 100},
+				Filter:          filter.NewNamespaceFilter(nil, nil),
+				NamespaceLister: NewKubernetesNamespaceLister(c),
+				Recorder:        recorder,
+				StatusReporter:  reporter,
+				GVK:             secretGVK,
+			}
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+			require.NoError(t, err)
+
+			event := <-recorder.Events
+			assert.Contains(t, event, ReasonInvalidTargetPattern)
+			assert.Contains(t, event, "is skipped and matches no namespace")
+
+			mirror := &unstructured.Unstructured{}
+			mirror.SetGroupVersionKind(secretGVK)
+			err = c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-secret"}, mirror)
+			if !tt.wantMirror {
+				assert.True(t, apierrors.IsNotFound(err))
+				assert.Equal(t, tt.wantStatus, reporter.result, "the status explains the missing mirrors")
+				assert.Equal(t, "invalid-pattern,reconciled:0,errors:0", reporter.result.String())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{"[b"}, reporter.result.InvalidPatterns)
+			assert.Equal(t, 1, reporter.result.Reconciled)
+		})
+	}
+}
+
+func TestSourceReconciler_Reconcile_OptOut(t *testing.T) {
+	tests := []struct {
+		targets string
+		want    []string
+	}{
+		{targets: "all", want: []string{"opted-in", "team-a", "team-b"}},
+		{targets: "all-labeled", want: []string{"opted-in"}},
+		{targets: "opted-*", want: []string{"opted-in"}},
+		{targets: "re:^opted-", want: []string{"opted-in"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.targets, func(t *testing.T) {
+			ctx := context.Background()
+			source := makeWaveSource("app-secret", "", tt.targets)
+			c := newFakeClient(t, source,
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "opted-out", Labels: map[string]string{constants.LabelAllowMirrors: "false"}}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "opted-in", Labels: map[string]string{constants.LabelAllowMirrors: "true"}}},
+			)
+			r := &SourceReconciler{
+				Client:          c,
+				Config:          &config.Config{MaxTargetsPerResource: 100},
+				Filter:          filter.NewNamespaceFilter(nil, nil),
+				NamespaceLister: NewKubernetesNamespaceLister(c),
+				GVK:             secretGVK,
+			}
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+			require.NoError(t, err)
+
+			var mirrored []string
+			for _, ns := range []string{"team-a", "team-b", "opted-in", "opted-out"} {
+				mirror := &unstructured.Unstructured{}
+				mirror.SetGroupVersionKind(secretGVK)
+				if err := c.Get(ctx, client.ObjectKey{Namespace: ns, Name: "app-secret"}, mirror); err == nil {
+					mirrored = append(mirrored, ns)
+				}
+			}
+			assert.ElementsMatch(t, tt.want, mirrored, "namespaces labelled allow-mirrors=false never get mirrors")
+		})
+	}
+}
+
+func TestSourceReconciler_Reconcile_TerminatingNamespace(t *testing.T) {
+	terminatingErr := &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status: metav1.StatusFailure, Code: http.StatusForbidden, Reason: metav1.StatusReasonForbidden,
+		Message: "unable to create new content in namespace team-b because it is being terminated",
+		Details: &metav1.StatusDetails{Causes: []metav1.StatusCause{{Type: corev1.NamespaceTerminatingCause}}},
+	}}
+
+	tests := []struct {
+		name string
+		// listed marks team-b Terminating before the reconcile; otherwise it
+		// starts terminating between target resolution and the write
+		listed bool
+	}{
+		{name: "listed as terminating", listed: true},
+		{name: "terminating during the write"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			source := makeWaveSource("app-secret", "", "team-a,team-b")
+			teamB := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}}
+			if tt.listed {
+				teamB.Status.Phase = corev1.NamespaceTerminating
+			}
+			scheme := runtime.NewScheme()
+			require.NoError(t, corev1.AddToScheme(scheme))
+			writes := map[string]int{}
+			c := fake.NewClientBuilder().WithScheme(scheme).
+				WithObjects(source, teamB,
+					&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+					&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+						ns := obj.(interface{ GetNamespace() string }).GetNamespace()
+						writes[ns]++
+						if ns == "team-b" {
+							return terminatingErr
+						}
+						return c.Apply(ctx, obj, opts...)
+					},
+				}).Build()
+
+			recorder := events.NewFakeRecorder(10)
+			reporter := &recordingReporter{}
+			r := &SourceReconciler{
+				Client:          c,
+				Config:          &config.Config{MaxTargetsPerResource: 100},
+				Filter:          filter.NewNamespaceFilter(nil, nil),
+				NamespaceLister: NewKubernetesNamespaceLister(c),
+				Recorder:        recorder,
+				StatusReporter:  reporter,
+				GVK:             secretGVK,
+			}
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+			require.NoError(t, err, "terminating namespaces are not retried")
+
+			if tt.listed {
+				assert.Zero(t, writes["team-b"], "no write is attempted")
+			}
+			assert.Equal(t, 1, reporter.result.Reconciled)
+			assert.Zero(t, reporter.result.Errors)
+			require.Len(t, reporter.result.Targets, 1, "terminating namespaces drop out of the status")
+			assert.Equal(t, "team-a", reporter.result.Targets[0].Namespace)
+			for len(recorder.Events) > 0 {
+				assert.NotContains(t, <-recorder.Events, ReasonMirrorFailed)
+			}
+		})
+	}
+}
+
+func TestNamespaceReconciler_SkipsTerminatingNamespace(t *testing.T) {
+	source := makeWaveSource("app-secret", "", "all")
+	terminating := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "closing"}}
+	terminating.Status.Phase = corev1.NamespaceTerminating
+	c := newFakeClient(t, source, terminating)
+
+	r := &NamespaceReconciler{
+		Client:          c,
+		Config:          &config.Config{MaxTargetsPerResource: 100},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		ResourceTypes:   []config.ResourceType{{Version: "v1", Kind: "Secret"}},
+	}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKey{Name: "closing"}})
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+
+	mirror := &unstructured.Unstructured{}
+	mirror.SetGroupVersionKind(secretGVK)
+	err = c.Get(context.Background(), client.ObjectKey{Namespace: "closing", Name: "app-secret"}, mirror)
+	assert.True(t, apierrors.IsNotFound(err))
+}
@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+// ReasonInvalidTenantLabel is the Event reason used when a namespace's tenant
+// label cannot name a tenant.
+const ReasonInvalidTenantLabel = "InvalidTenantLabel"
+
+// checkTenantLabel reports a namespace whose tenant label no "tenant:" target can
+// name, such as an empty one. The namespace stays out of every tenant index, so
+// without the report it would silently receive no tenant mirrors.
+func (r *NamespaceReconciler) checkTenantLabel(ctx context.Context, namespace *corev1.Namespace) {
+	tenant, ok := namespace.Labels[constants.LabelTenant]
+	if !ok {
+		return
+	}
+	if err := filter.ValidateTenant(tenant); err != nil {
+		log.FromContext(ctx).V(1).Info("namespace tenant label is invalid, namespace belongs to no tenant", "error", err.Error())
+		emitEvent(r.Recorder, namespace, corev1.EventTypeWarning, ReasonInvalidTenantLabel, "Validate",
+			"Namespace belongs to no tenant: %s label: %s", constants.LabelTenant, err.Error())
+	}
+}
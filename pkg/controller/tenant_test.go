@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func TestNamespaceReconciler_checkTenantLabel(t *testing.T) {
+	tests := []struct {
+		name      string
+		labels    map[string]string
+		wantEvent bool
+	}{
+		{name: "no tenant label", labels: map[string]string{"team": "a"}},
+		{name: "valid tenant", labels: map[string]string{constants.LabelTenant: "acme"}},
+		{name: "empty tenant", labels: map[string]string{constants.LabelTenant: ""}, wantEvent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := events.NewFakeRecorder(10)
+			r := &NamespaceReconciler{Recorder: recorder}
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: tt.labels}}
+
+			r.checkTenantLabel(context.Background(), namespace)
+
+			if !tt.wantEvent {
+				assert.Empty(t, recorder.Events)
+				return
+			}
+			event := <-recorder.Events
+			assert.Contains(t, event, corev1.EventTypeWarning)
+			assert.Contains(t, event, ReasonInvalidTenantLabel)
+			assert.Contains(t, event, "empty tenant name")
+		})
+	}
+}
+
+func TestNamespaceReconciler_Reconcile_InvalidTenantLabel(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "acme-prod", Labels: map[string]string{constants.LabelTenant: "acme"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "acme-dev", Labels: map[string]string{constants.LabelTenant: ""}}},
+	).Build()
+	recorder := events.NewFakeRecorder(10)
+	r := &NamespaceReconciler{Client: c, Recorder: recorder}
+
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "acme-prod"}})
+	require.NoError(t, err)
+	assert.Empty(t, recorder.Events, "a namespace of a valid tenant is not reported")
+
+	_, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "acme-dev"}})
+	require.NoError(t, err)
+	assert.Contains(t, <-recorder.Events, ReasonInvalidTenantLabel)
+}
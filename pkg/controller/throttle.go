@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// minSyncInterval returns the source's min-sync-interval annotation, or 0 if unset.
+func minSyncInterval(sourceObj metav1.Object) (time.Duration, error) {
+	value := sourceObj.GetAnnotations()[constants.AnnotationMinSyncInterval]
+	if value == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", constants.AnnotationMinSyncInterval, value, err)
+	}
+	if interval < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must not be negative", constants.AnnotationMinSyncInterval, value)
+	}
+	return interval, nil
+}
+
+// syncThrottle remembers when each source last fanned out to its mirrors, so hot
+// sources sync at most once per their min-sync-interval. Deferred syncs are
+// requeued rather than dropped: the requeued reconcile reads the source again,
+// so the latest content wins and intermediate changes are coalesced.
+type syncThrottle struct {
+	now      func() time.Time
+	lastSync map[types.NamespacedName]time.Time
+	mu       sync.Mutex
+}
+
+// wait returns how long the source must wait before its next sync, or 0 if it may
+// sync now, in which case the sync is recorded. Sources without an interval are
+// never tracked.
+func (t *syncThrottle) wait(key types.NamespacedName, interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if t.now != nil {
+		now = t.now()
+	}
+	if t.lastSync == nil {
+		t.lastSync = make(map[types.NamespacedName]time.Time)
+	}
+
+	if last, ok := t.lastSync[key]; ok {
+		if remaining := interval - now.Sub(last); remaining > 0 {
+			return remaining
+		}
+	}
+	t.lastSync[key] = now
+	return 0
+}
+
+// forget drops the source's sync history, e.g. once it is deleted.
+func (t *syncThrottle) forget(key types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.lastSync, key)
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func TestMinSyncInterval(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		want       time.Duration
+		wantErr    bool
+	}{
+		{name: "unset", want: 0},
+		{name: "one minute", annotation: "1m", want: time.Minute},
+		{name: "seconds", annotation: "30s", want: 30 * time.Second},
+		{name: "invalid", annotation: "often", wantErr: true},
+		{name: "negative", annotation: "-1m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &metav1.ObjectMeta{}
+			if tt.annotation != "" {
+				obj.Annotations = map[string]string{constants.AnnotationMinSyncInterval: tt.annotation}
+			}
+			got, err := minSyncInterval(obj)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSyncThrottle(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttle := &syncThrottle{now: func() time.Time { return now }}
+	hot := types.NamespacedName{Namespace: "default", Name: "hot"}
+	other := types.NamespacedName{Namespace: "default", Name: "other"}
+
+	assert.Zero(t, throttle.wait(hot, time.Minute), "first sync goes through")
+
+	now = now.Add(20 * time.Second)
+	assert.Equal(t, 40*time.Second, throttle.wait(hot, time.Minute), "changes within the interval are deferred")
+	assert.Zero(t, throttle.wait(other, time.Minute), "sources are throttled independently")
+
+	now = now.Add(40 * time.Second)
+	assert.Zero(t, throttle.wait(hot, time.Minute), "syncs again once the interval elapsed")
+	assert.Equal(t, time.Minute, throttle.wait(hot, time.Minute))
+
+	assert.Zero(t, throttle.wait(hot, 0), "no interval never throttles")
+
+	throttle.forget(hot)
+	assert.Zero(t, throttle.wait(hot, time.Minute), "forgotten sources sync immediately")
+}
+
+func TestSyncThrottle_UntrackedWithoutInterval(t *testing.T) {
+	throttle := &syncThrottle{}
+	throttle.wait(types.NamespacedName{Namespace: "default", Name: "cold"}, 0)
+	assert.Empty(t, throttle.lastSync)
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/tracing"
+	"github.com/lukaszraczylo/kubemirror/pkg/transformer"
+)
+
+// traceReconciler records a span of every reconcile of r, parenting the spans of
+// the target resolution and mirror writes it makes.
+func traceReconciler(r reconcile.Reconciler, name string, gvk schema.GroupVersionKind) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		ctx, span := tracing.Start(ctx, name+".Reconcile",
+			tracing.AttrResourceType.String(resourceTypeLabel(gvk)),
+			tracing.AttrSourceNamespace.String(req.Namespace),
+			tracing.AttrSourceName.String(req.Name))
+		result, err := r.Reconcile(ctx, req)
+		tracing.End(span, err)
+		return result, err
+	})
+}
+
+// buildMirror builds the mirror of source for targetNs, in a span of its own when
+// transformations apply.
+func buildMirror(ctx context.Context, source runtime.Object, sourceObj metav1.Object, targetNs string, opts transformer.TransformOptions) (runtime.Object, error) {
+	if !transformsApply(sourceObj, opts) {
+		return CreateMirrorWithOptions(source, targetNs, opts)
+	}
+	_, span := tracing.Start(ctx, "Transform", tracing.AttrTargetNamespace.String(targetNs))
+	mirror, err := CreateMirrorWithOptions(source, targetNs, opts)
+	tracing.End(span, err)
+	return mirror, err
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/tracing"
+)
+
+func TestTraceReconciler(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	source := makeWaveSource("app-secret", "", "team-a,team-b")
+	annotations := source.GetAnnotations()
+	annotations[constants.AnnotationTransform] = `rules:
+  - path: metadata.labels.env
+    value: prod
+`
+	source.SetAnnotations(annotations)
+	c := newFakeClient(t, source)
+
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+	}
+	_, err := traceReconciler(r, "SourceReconciler", secretGVK).
+		Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+	require.NoError(t, err)
+
+	counts := map[string]int{}
+	var root sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		counts[span.Name()]++
+		if span.Name() == "SourceReconciler.Reconcile" {
+			root = span
+		}
+	}
+	assert.Equal(t, map[string]int{
+		"SourceReconciler.Reconcile": 1,
+		"ResolveTargetNamespaces":    1,
+		"SyncMirror":                 2,
+		"Transform":                  2,
+	}, counts)
+
+	require.NotNil(t, root)
+	assert.Contains(t, root.Attributes(), tracing.AttrSourceName.String("app-secret"))
+	for _, span := range recorder.Ended() {
+		assert.Equal(t, root.SpanContext().TraceID(), span.SpanContext().TraceID(), "%s is part of the reconcile's trace", span.Name())
+	}
+}
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/naming"
+)
+
+// ReasonInvalidSyncWave is the Event reason used when a source's sync-wave
+// annotation cannot be parsed.
+const ReasonInvalidSyncWave = "InvalidSyncWave"
+
+// waveRetryDelay is how long a source waits before rechecking targets still
+// missing the mirrors of lower sync waves.
+const waveRetryDelay = 5 * time.Second
+
+// syncWave parses the source's sync-wave annotation; ok is false without one.
+func syncWave(source *unstructured.Unstructured) (wave int, ok bool, err error) {
+	value, ok := source.GetAnnotations()[constants.AnnotationSyncWave]
+	if !ok {
+		return 0, false, nil
+	}
+	wave, err = strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid %s %q: must be an integer", constants.AnnotationSyncWave, value)
+	}
+	return wave, true, nil
+}
+
+// waveSource is a source of a lower sync wave, with its target namespaces
+// resolved on first use.
+type waveSource struct {
+	obj      *unstructured.Unstructured
+	wave     int
+	targets  []string
+	resolved bool
+}
+
+// waveBarrier holds back the mirrors of a source until, per target namespace, the
+// sources of lower sync waves in its namespace have their mirrors there.
+type waveBarrier struct {
+	reader client.Reader
+	// resolve returns the target namespaces of a lower-wave source
+	resolve func(context.Context, *unstructured.Unstructured) ([]string, error)
+	// lower holds the lower-wave sources, lowest wave first
+	lower []*waveSource
+}
+
+// blockedBy returns the lower-wave source targeting namespace whose mirror is not
+// there yet, as "Kind/name (wave N)", or "" when the namespace can be synced.
+func (b *waveBarrier) blockedBy(ctx context.Context, namespace string) (string, error) {
+	for _, lower := range b.lower {
+		present, err := b.mirrorPresent(ctx, lower.obj, namespace)
+		if err != nil {
+			return "", err
+		}
+		if present {
+			continue
+		}
+
+		if !lower.resolved {
+			if lower.targets, err = b.resolve(ctx, lower.obj); err != nil {
+				return "", fmt.Errorf("failed to resolve targets of %s %s/%s: %w",
+					lower.obj.GetKind(), lower.obj.GetNamespace(), lower.obj.GetName(), err)
+			}
+			lower.resolved = true
+		}
+		if slices.Contains(lower.targets, namespace) {
+			return fmt.Sprintf("%s/%s (wave %d)", lower.obj.GetKind(), lower.obj.GetName(), lower.wave), nil
+		}
+	}
+	return "", nil
+}
+
+// mirrorPresent reports whether namespace holds kubemirror's mirror of source.
+func (b *waveBarrier) mirrorPresent(ctx context.Context, source *unstructured.Unstructured, namespace string) (bool, error) {
+	mirror := &unstructured.Unstructured{}
+	mirror.SetGroupVersionKind(source.GroupVersionKind())
+	err := b.reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: naming.MirrorNameOrDefault(source)}, mirror)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check mirror of %s %s/%s in %s: %w",
+			source.GetKind(), source.GetNamespace(), source.GetName(), namespace, err)
+	}
+
+	srcNs, srcName, _, found := GetSourceReference(mirror)
+	return IsManagedByUs(mirror) && found && srcNs == source.GetNamespace() && srcName == source.GetName(), nil
+}
+
+// newWaveBarrier returns the barrier for source, or nil when it has no sync wave
+// (or an invalid one, reported as an Event) or no lower-wave sources.
+func (r *SourceReconciler) newWaveBarrier(ctx context.Context, source *unstructured.Unstructured) (*waveBarrier, error) {
+	wave, ok, err := syncWave(source)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "ignoring sync wave")
+		r.recordEvent(source, corev1.EventTypeWarning, ReasonInvalidSyncWave, "Mirror", "%s, mirroring unordered", err.Error())
+		return nil, nil
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	resourceTypes := []config.ResourceType{{Group: r.GVK.Group, Version: r.GVK.Version, Kind: r.GVK.Kind}}
+	if r.ResourceTypes != nil {
+		resourceTypes = r.ResourceTypes()
+	}
+
+	barrier := &waveBarrier{reader: r.Client, resolve: r.waveTargets}
+	for _, rt := range resourceTypes {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(rt.GroupVersionKind())
+		err := r.List(ctx, list, client.InNamespace(source.GetNamespace()), client.HasLabels{constants.LabelEnabled})
+		if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s sources of lower sync waves: %w", rt, err)
+		}
+
+		for i := range list.Items {
+			lower := &list.Items[i]
+			if !isEnabledForMirroring(lower) || IsMirrorResource(lower) || !lower.GetDeletionTimestamp().IsZero() {
+				continue
+			}
+			// Sources with an invalid sync wave report it themselves
+			lowerWave, ok, err := syncWave(lower)
+			if err != nil || !ok || lowerWave >= wave {
+				continue
+			}
+			barrier.lower = append(barrier.lower, &waveSource{obj: lower, wave: lowerWave})
+		}
+	}
+	if len(barrier.lower) == 0 {
+		return nil, nil
+	}
+
+	slices.SortStableFunc(barrier.lower, func(a, b *waveSource) int { return cmp.Compare(a.wave, b.wave) })
+	return barrier, nil
+}
+
+// waveTargets resolves the target namespaces of a lower-wave source the way its
+// own reconciler does.
+func (r *SourceReconciler) waveTargets(ctx context.Context, source *unstructured.Unstructured) ([]string, error) {
+	targets, err := resolveTargets(ctx, r.TargetResolver, r.NamespaceLister, r.Filter, source,
+		policyTargetPatterns(r.Policies, source.GroupVersionKind(), source))
+	if err != nil || len(targets) == 0 {
+		return nil, err
+	}
+	if targets, err = dropExpiredTargets(ctx, r.Client, source, targets, time.Now()); err != nil {
+		return nil, err
+	}
+	targets, _, err = truncateTargets(ctx, r.Config, r.NamespaceLister, source, targets)
+	return targets, err
+}
+
+// holdForWaves splits targets into those ready to sync and those still missing
+// the mirrors of lower sync waves, mapped to the source they wait for.
+func (r *SourceReconciler) holdForWaves(ctx context.Context, source *unstructured.Unstructured, targets []string) ([]string, map[string]string, error) {
+	barrier, err := r.newWaveBarrier(ctx, source)
+	if err != nil || barrier == nil {
+		return targets, nil, err
+	}
+
+	ready := make([]string, 0, len(targets))
+	waiting := make(map[string]string)
+	for _, ns := range targets {
+		blocker, err := barrier.blockedBy(ctx, ns)
+		if err != nil {
+			return nil, nil, err
+		}
+		if blocker != "" {
+			waiting[ns] = blocker
+			continue
+		}
+		ready = append(ready, ns)
+	}
+	return ready, waiting, nil
+}
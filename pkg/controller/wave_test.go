@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/status"
+)
+
+func makeWaveSource(name, wave, targets string) *unstructured.Unstructured {
+	annotations := map[string]string{constants.AnnotationSync: "true", constants.AnnotationTargetNamespaces: targets}
+	if wave != "" {
+		annotations[constants.AnnotationSyncWave] = wave
+	}
+	source := makeUnstructuredSecret(name, "default", map[string]string{constants.LabelEnabled: "true"}, annotations)
+	source.SetFinalizers([]string{constants.FinalizerName})
+	source.Object["data"] = map[string]interface{}{"key": "dmFsdWU="}
+	return source
+}
+
+func TestSyncWave(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantOK  bool
+		wantErr bool
+	}{
+		{name: "absent"},
+		{name: "zero", value: "0", want: 0, wantOK: true},
+		{name: "positive", value: "2", want: 2, wantOK: true},
+		{name: "negative", value: "-1", want: -1, wantOK: true},
+		{name: "invalid", value: "first", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var annotations map[string]string
+			if tt.name != "absent" {
+				annotations = map[string]string{constants.AnnotationSyncWave: tt.value}
+			}
+			wave, ok, err := syncWave(makeUnstructuredSecret("app", "default", nil, annotations))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, wave)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+func TestSourceReconciler_Reconcile_SyncWaves(t *testing.T) {
+	ctx := context.Background()
+	config0 := makeWaveSource("app-config", "0", "team-a")
+	route1 := makeWaveSource("app-route", "1", "team-a,team-b")
+	c := newFakeClient(t, config0, route1)
+
+	newReconciler := func(reporter status.Reporter) *SourceReconciler {
+		return &SourceReconciler{
+			Client:          c,
+			Config:          &config.Config{},
+			Filter:          filter.NewNamespaceFilter(nil, nil),
+			NamespaceLister: NewKubernetesNamespaceLister(c),
+			StatusReporter:  reporter,
+			GVK:             secretGVK,
+		}
+	}
+	mirrorExists := func(namespace, name string) bool {
+		err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, makeUnstructuredSecret("", "", nil, nil))
+		if err != nil && !apierrors.IsNotFound(err) {
+			require.NoError(t, err)
+		}
+		return err == nil
+	}
+
+	// The higher wave goes first: team-a waits for app-config, team-b is not its target
+	reporter := &recordingReporter{}
+	result, err := newReconciler(reporter).Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(route1)})
+	require.NoError(t, err)
+	assert.Equal(t, waveRetryDelay, result.RequeueAfter)
+	assert.False(t, mirrorExists("team-a", "app-route"))
+	assert.True(t, mirrorExists("team-b", "app-route"))
+	require.Len(t, reporter.result.Targets, 2)
+	assert.Equal(t, status.TargetStatus{Namespace: "team-a", State: status.TargetWaiting, Error: "waiting for Secret/app-config (wave 0)"},
+		reporter.result.Targets[1])
+
+	_, err = newReconciler(nil).Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(config0)})
+	require.NoError(t, err)
+	require.True(t, mirrorExists("team-a", "app-config"))
+
+	result, err = newReconciler(nil).Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(route1)})
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.True(t, mirrorExists("team-a", "app-route"), "synced once the lower wave is present")
+}
+
+func TestSourceReconciler_Reconcile_SyncWaveIgnoresUnordered(t *testing.T) {
+	ctx := context.Background()
+	unordered := makeWaveSource("app-config", "", "team-a")
+	invalid := makeWaveSource("app-route", "later", "team-a")
+	c := newFakeClient(t, unordered, invalid)
+
+	r := &SourceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		GVK:             secretGVK,
+	}
+	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(invalid)})
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-route"}, makeUnstructuredSecret("", "", nil, nil)),
+		"an invalid sync wave mirrors unordered")
+}
+
+func TestNamespaceReconciler_SyncWaves(t *testing.T) {
+	ctx := context.Background()
+	c := newFakeClient(t, makeWaveSource("app-config", "0", "team-a"), makeWaveSource("app-route", "1", "team-a"))
+
+	r := &NamespaceReconciler{
+		Client:          c,
+		Config:          &config.Config{},
+		Filter:          filter.NewNamespaceFilter(nil, nil),
+		NamespaceLister: NewKubernetesNamespaceLister(c),
+		ResourceTypes:   []config.ResourceType{{Version: "v1", Kind: "Secret"}},
+	}
+	source := makeWaveSource("app-route", "1", "team-a")
+	blocker, err := r.waveBlocker(ctx, source, "team-a")
+	require.NoError(t, err)
+	assert.Equal(t, "Secret/app-config (wave 0)", blocker)
+
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: "team-a"}})
+	require.NoError(t, err)
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: "team-a"}})
+	require.NoError(t, err)
+
+	blocker, err = r.waveBlocker(ctx, source, "team-a")
+	require.NoError(t, err)
+	assert.Empty(t, blocker)
+	assert.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "app-route"}, makeUnstructuredSecret("", "", nil, nil)))
+}
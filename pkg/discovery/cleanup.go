@@ -0,0 +1,49 @@
+package discovery
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/prune"
+)
+
+// NewRemovedTypeCleanup returns a RemovedFunc that prunes the mirrors of removed
+// resource types and strips the kubemirror finalizer from their sources.
+//
+// Cleanup is best effort: a type that is still listable (e.g. it lost a verb
+// kubemirror needs) is pruned, while mirrors of a type the API server no longer
+// serves at all cannot be reached and are reported with a warning instead.
+func NewRemovedTypeCleanup(pruner *prune.Pruner) RemovedFunc {
+	return func(ctx context.Context, removed []config.ResourceType) {
+		logger := discoveryLog.WithName("cleanup")
+
+		gvks := make([]schema.GroupVersionKind, len(removed))
+		for i, rt := range removed {
+			gvks[i] = rt.GroupVersionKind()
+		}
+
+		result, err := pruner.Prune(ctx, gvks)
+		if err != nil {
+			logger.Error(err, "failed to clean up mirrors of removed resource types",
+				"resources", resourceTypesToStrings(removed))
+		}
+		if result.MirrorsDeleted > 0 || result.FinalizersRemoved > 0 {
+			logger.Info("cleaned up mirrors of removed resource types",
+				"resources", resourceTypesToStrings(removed),
+				"mirrorsDeleted", result.MirrorsDeleted,
+				"finalizersRemoved", result.FinalizersRemoved,
+				"dryRun", pruner.DryRun,
+			)
+		}
+		for _, gvk := range result.Unserved {
+			logger.Info("WARNING: resource type is no longer served; any remaining mirrors are abandoned",
+				"kind", gvk.Kind,
+				"group", gvk.Group,
+				"version", gvk.Version,
+				"recommendation", "if the API comes back, run `kubemirror prune --all --resource-types=<type>` to remove its mirrors",
+			)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/prune"
+)
+
+func TestNewRemovedTypeCleanup(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name: "config", Namespace: "team-a",
+			Labels: map[string]string{constants.LabelManagedBy: constants.ControllerName},
+		}},
+	).Build()
+
+	cleanup := NewRemovedTypeCleanup(&prune.Pruner{Client: c, Log: logr.Discard()})
+	cleanup(context.Background(), []config.ResourceType{
+		{Kind: "ConfigMap", Version: "v1"},
+		{Kind: "Widget", Version: "v1", Group: "example.com"}, // not served, only warned about
+	})
+
+	configMaps := &corev1.ConfigMapList{}
+	require.NoError(t, c.List(context.Background(), configMaps))
+	assert.Empty(t, configMaps.Items, "mirrors of a still-listable removed type are pruned")
+}
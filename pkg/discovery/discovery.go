@@ -3,6 +3,7 @@ package discovery
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -20,6 +21,8 @@ var discoveryLog = ctrl.Log.WithName("discovery")
 // ResourceDiscovery discovers all mirrorable resource types in a cluster.
 type ResourceDiscovery struct {
 	discoveryClient discovery.DiscoveryInterface
+	groups          *GroupFilter
+	kinds           *KindFilter
 }
 
 // NewResourceDiscovery creates a new resource discovery client.
@@ -34,25 +37,58 @@ func NewResourceDiscovery(cfg *rest.Config) (*ResourceDiscovery, error) {
 	}, nil
 }
 
+// SetGroupFilter limits discovery to the API groups allowed by groups; call it
+// before the first discovery.
+func (d *ResourceDiscovery) SetGroupFilter(groups *GroupFilter) {
+	d.groups = groups
+}
+
+// SetKindFilter adjusts the deny list and heuristics with kinds; call it before
+// the first discovery.
+func (d *ResourceDiscovery) SetKindFilter(kinds *KindFilter) {
+	d.kinds = kinds
+}
+
 // DiscoverMirrorableResources discovers all resource types that can be mirrored.
-// It filters out resources that shouldn't be mirrored based on a deny list.
+// It filters out resources that shouldn't be mirrored based on a deny list and
+// heuristics, adjusted by its kind filter, and the API groups its group filter
+// excludes.
 func (d *ResourceDiscovery) DiscoverMirrorableResources(ctx context.Context) ([]config.ResourceType, error) {
+	resources, _, err := d.discover(ctx)
+	return resources, err
+}
+
+// discover is DiscoverMirrorableResources that also returns the group versions
+// whose discovery failed, so callers can tell a removed type from an unavailable one.
+func (d *ResourceDiscovery) discover(_ context.Context) ([]config.ResourceType, map[schema.GroupVersion]error, error) {
 	logger := discoveryLog.WithName("discover")
 
 	// Get all API resources in the cluster
+	var failed map[schema.GroupVersion]error
 	_, apiResourceLists, err := d.discoveryClient.ServerGroupsAndResources()
 	if err != nil {
 		// Partial errors are common (some APIs might not be fully available)
 		// Continue with what we have
-		if !discovery.IsGroupDiscoveryFailedError(err) {
-			return nil, fmt.Errorf("failed to discover API resources: %w", err)
+		var groupErr *discovery.ErrGroupDiscoveryFailed
+		if !errors.As(err, &groupErr) {
+			return nil, nil, fmt.Errorf("failed to discover API resources: %w", err)
+		}
+		failed = make(map[schema.GroupVersion]error, len(groupErr.Groups))
+		for gv, gvErr := range groupErr.Groups {
+			// Errors of excluded groups, often flaky aggregated APIs, do not matter
+			if d.groups.Allows(gv.Group) {
+				failed[gv] = gvErr
+			}
+		}
+		if len(failed) > 0 {
+			logger.V(1).Info("some API groups had discovery errors, continuing with available resources")
 		}
-		logger.V(1).Info("some API groups had discovery errors, continuing with available resources")
 	}
 
 	var resources []config.ResourceType
 	seen := make(map[string]bool) // Deduplicate
-	var deniedCount int
+	excludedGroups := make(map[string]bool)
+	var deniedCount, scalableCount int
 
 	for _, apiResourceList := range apiResourceLists {
 		gv, err := schema.ParseGroupVersion(apiResourceList.GroupVersion)
@@ -60,6 +96,16 @@ func (d *ResourceDiscovery) DiscoverMirrorableResources(ctx context.Context) ([]
 			continue
 		}
 
+		// Skip API groups excluded by the group filter
+		if !d.groups.Allows(gv.Group) {
+			if !excludedGroups[gv.Group] {
+				excludedGroups[gv.Group] = true
+				logger.V(2).Info("skipping excluded API group", "group", gv.Group)
+			}
+			continue
+		}
+
+		scaled := scaledResources(apiResourceList.APIResources)
 		for _, apiResource := range apiResourceList.APIResources {
 			// Skip subresources (status, scale, etc.)
 			if strings.Contains(apiResource.Name, "/") {
@@ -76,8 +122,10 @@ func (d *ResourceDiscovery) DiscoverMirrorableResources(ctx context.Context) ([]
 				continue
 			}
 
-			// Skip denied resource types
-			if isDeniedResourceType(apiResource.Kind) {
+			// Skip denied resource types, unless explicitly allowed
+			gk := schema.GroupKind{Group: gv.Group, Kind: apiResource.Kind}
+			allowed := d.kinds.Allows(gk)
+			if !allowed && (isDeniedResourceType(apiResource.Kind) || d.kinds.Denies(gk)) {
 				deniedCount++
 				logger.V(2).Info("skipping denied resource type",
 					"kind", apiResource.Kind,
@@ -86,6 +134,16 @@ func (d *ResourceDiscovery) DiscoverMirrorableResources(ctx context.Context) ([]
 				continue
 			}
 
+			// Skip workloads: their replicas and pods belong to controllers
+			if !allowed && d.kinds.Heuristics() && scaled[apiResource.Name] {
+				scalableCount++
+				logger.V(2).Info("skipping resource type with a scale subresource",
+					"kind", apiResource.Kind,
+					"group", gv.Group,
+					"version", gv.Version)
+				continue
+			}
+
 			// Warn about potentially high-cardinality resource types that aren't in deny list
 			if isHighCardinalityResource(apiResource.Kind) {
 				logger.Info("WARNING: discovered potentially high-cardinality resource type",
@@ -114,9 +172,14 @@ func (d *ResourceDiscovery) DiscoverMirrorableResources(ctx context.Context) ([]
 
 	logger.Info("resource discovery complete",
 		"discovered", len(resources),
-		"denied", deniedCount)
+		"denied", deniedCount,
+		"scalable", scalableCount,
+		"excludedGroups", len(excludedGroups))
+	if len(resources) == 0 && len(excludedGroups) > 0 {
+		logger.Info("WARNING: no resource types discovered, check the discovery include and exclude groups")
+	}
 
-	return resources, nil
+	return resources, failed, nil
 }
 
 // supportsRequiredVerbs checks if a resource supports the verbs needed for mirroring.
@@ -157,6 +220,10 @@ var deniedKinds = map[string]bool{
 	// Lease resources (used for leader election)
 	"Lease": true,
 
+	// kubemirror's own status and binding resources
+	"MirrorStatus":  true,
+	"MirrorBinding": true,
+
 	// CSI and storage resources
 	"CSIDriver":          true,
 	"CSINode":            true,
@@ -0,0 +1,41 @@
+package discovery
+
+import (
+	"path"
+)
+
+// GroupFilter decides which API groups auto-discovery considers. Patterns are
+// globs matched against the group name, which is empty for the core group.
+type GroupFilter struct {
+	include []string
+	exclude []string
+}
+
+// NewGroupFilter returns a filter allowing the groups that match an include
+// pattern (all groups when include is empty) and no exclude pattern. Patterns
+// are expected to be normalized with config.NormalizeGroupPatterns.
+func NewGroupFilter(include, exclude []string) *GroupFilter {
+	return &GroupFilter{include: include, exclude: exclude}
+}
+
+// Allows reports whether resource types of group are discovered. A nil filter
+// allows every group.
+func (f *GroupFilter) Allows(group string) bool {
+	if f == nil {
+		return true
+	}
+	if matchesAnyGroup(group, f.exclude) {
+		return false
+	}
+	return len(f.include) == 0 || matchesAnyGroup(group, f.include)
+}
+
+// matchesAnyGroup reports whether group matches one of patterns.
+func matchesAnyGroup(group string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, group); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+)
+
+func TestGroupFilter_Allows(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		group   string
+		want    bool
+	}{
+		{name: "no patterns", group: "metrics.k8s.io", want: true},
+		{name: "excluded", exclude: []string{"metrics.k8s.io"}, group: "metrics.k8s.io", want: false},
+		{name: "excluded glob", exclude: []string{"*.cattle.io"}, group: "management.cattle.io", want: false},
+		{name: "not excluded", exclude: []string{"*.cattle.io"}, group: "", want: true},
+		{name: "included core", include: []string{"", "networking.k8s.io"}, group: "", want: true},
+		{name: "included group", include: []string{"", "networking.k8s.io"}, group: "networking.k8s.io", want: true},
+		{name: "not included", include: []string{"", "networking.k8s.io"}, group: "apps", want: false},
+		{name: "glob skips core", include: []string{"*.k8s.io"}, group: "", want: false},
+		{name: "exclude wins", include: []string{"*.k8s.io"}, exclude: []string{"metrics.k8s.io"}, group: "metrics.k8s.io", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewGroupFilter(tt.include, tt.exclude).Allows(tt.group))
+		})
+	}
+
+	var none *GroupFilter
+	assert.True(t, none.Allows("apps"), "a nil filter allows every group")
+}
+
+func TestResourceDiscovery_GroupFilter(t *testing.T) {
+	verbs := metav1.Verbs{"get", "list", "watch", "create", "update", "delete"}
+	fake := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	fake.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "secrets", Kind: "Secret", Namespaced: true, Verbs: verbs}}},
+		{GroupVersion: "networking.k8s.io/v1", APIResources: []metav1.APIResource{{Name: "networkpolicies", Kind: "NetworkPolicy", Namespaced: true, Verbs: verbs}}},
+		{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{{Name: "widgets", Kind: "Widget", Namespaced: true, Verbs: verbs}}},
+	}
+	client := &partialDiscovery{
+		FakeDiscovery: fake,
+		failed:        map[schema.GroupVersion]error{{Group: "metrics.k8s.io", Version: "v1beta1"}: errors.New("unavailable")},
+	}
+	d := &ResourceDiscovery{discoveryClient: client}
+
+	d.SetGroupFilter(NewGroupFilter([]string{"", "networking.k8s.io"}, nil))
+	resources, err := d.DiscoverMirrorableResources(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []config.ResourceType{
+		{Kind: "Secret", Version: "v1"},
+		{Kind: "NetworkPolicy", Version: "v1", Group: "networking.k8s.io"},
+	}, resources)
+
+	d.SetGroupFilter(NewGroupFilter(nil, []string{"metrics.k8s.io", "*.com"}))
+	resources, failed, err := d.discover(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, resources, 2)
+	assert.Empty(t, failed, "errors of excluded groups are dropped")
+}
@@ -0,0 +1,66 @@
+package discovery
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+)
+
+// KindFilter adjusts which kinds auto-discovery skips: deny adds kinds to the
+// built-in deny list, allow keeps kinds the deny list or the heuristics would
+// skip. Kinds with config.AnyGroup as group match in every API group.
+type KindFilter struct {
+	deny  []schema.GroupKind
+	allow []schema.GroupKind
+	// heuristics skips kinds that look controller-owned
+	heuristics bool
+}
+
+// NewKindFilter returns a filter denying deny and allowing allow, as parsed by
+// config.NormalizeKindPatterns. With heuristics, kinds serving a scale
+// subresource are skipped unless allowed.
+func NewKindFilter(deny, allow []schema.GroupKind, heuristics bool) *KindFilter {
+	return &KindFilter{deny: deny, allow: allow, heuristics: heuristics}
+}
+
+// Denies reports whether gk is on the configured deny list.
+func (f *KindFilter) Denies(gk schema.GroupKind) bool {
+	return f != nil && matchesAnyKind(gk, f.deny)
+}
+
+// Allows reports whether gk is on the configured allow list.
+func (f *KindFilter) Allows(gk schema.GroupKind) bool {
+	return f != nil && matchesAnyKind(gk, f.allow)
+}
+
+// Heuristics reports whether controller-owned looking kinds are skipped. A nil
+// filter applies them.
+func (f *KindFilter) Heuristics() bool {
+	return f == nil || f.heuristics
+}
+
+// matchesAnyKind reports whether gk matches one of kinds.
+func matchesAnyKind(gk schema.GroupKind, kinds []schema.GroupKind) bool {
+	for _, k := range kinds {
+		if k.Kind == gk.Kind && (k.Group == config.AnyGroup || k.Group == gk.Group) {
+			return true
+		}
+	}
+	return false
+}
+
+// scaledResources returns the resources of a group version serving a scale
+// subresource. Their replicas are driven by autoscalers and they own the pods
+// they spawn, so a mirror would run a second copy of the workload.
+func scaledResources(resources []metav1.APIResource) map[string]bool {
+	scaled := make(map[string]bool)
+	for _, r := range resources {
+		if resource, sub, ok := strings.Cut(r.Name, "/"); ok && sub == "scale" {
+			scaled[resource] = true
+		}
+	}
+	return scaled
+}
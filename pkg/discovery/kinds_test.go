@@ -0,0 +1,81 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+)
+
+func TestKindFilter(t *testing.T) {
+	f := NewKindFilter(
+		[]schema.GroupKind{{Group: "velero.io", Kind: "Backup"}, {Group: config.AnyGroup, Kind: "Snapshot"}},
+		[]schema.GroupKind{{Group: "", Kind: "Endpoints"}},
+		false,
+	)
+
+	assert.True(t, f.Denies(schema.GroupKind{Group: "velero.io", Kind: "Backup"}))
+	assert.False(t, f.Denies(schema.GroupKind{Group: "example.com", Kind: "Backup"}), "only in velero.io")
+	assert.True(t, f.Denies(schema.GroupKind{Group: "example.com", Kind: "Snapshot"}), "in every group")
+	assert.True(t, f.Allows(schema.GroupKind{Kind: "Endpoints"}))
+	assert.False(t, f.Allows(schema.GroupKind{Group: "example.com", Kind: "Endpoints"}), "only in the core group")
+	assert.False(t, f.Heuristics())
+
+	var unset *KindFilter
+	assert.False(t, unset.Denies(schema.GroupKind{Kind: "Backup"}))
+	assert.False(t, unset.Allows(schema.GroupKind{Kind: "Pod"}))
+	assert.True(t, unset.Heuristics(), "heuristics apply by default")
+}
+
+func TestResourceDiscovery_KindFilter(t *testing.T) {
+	verbs := metav1.Verbs{"get", "list", "watch", "create", "update", "delete"}
+	fake := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	fake.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "secrets", Kind: "Secret", Namespaced: true, Verbs: verbs},
+			{Name: "endpoints", Kind: "Endpoints", Namespaced: true, Verbs: verbs},
+		}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{
+			{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: verbs},
+			{Name: "deployments/scale", Kind: "Scale", Group: "autoscaling", Version: "v1", Namespaced: true, Verbs: metav1.Verbs{"get", "update"}},
+			{Name: "statefulsets", Kind: "StatefulSet", Namespaced: true, Verbs: verbs},
+			{Name: "statefulsets/scale", Kind: "Scale", Group: "autoscaling", Version: "v1", Namespaced: true, Verbs: metav1.Verbs{"get", "update"}},
+		}},
+		{GroupVersion: "velero.io/v1", APIResources: []metav1.APIResource{
+			{Name: "backups", Kind: "Backup", Namespaced: true, Verbs: verbs},
+		}},
+	}
+	d := &ResourceDiscovery{discoveryClient: fake}
+
+	resources, err := d.DiscoverMirrorableResources(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []config.ResourceType{
+		{Kind: "Secret", Version: "v1"},
+		{Kind: "Backup", Version: "v1", Group: "velero.io"},
+	}, resources, "Endpoints is denied, workloads with a scale subresource are skipped")
+
+	d.SetKindFilter(NewKindFilter(
+		[]schema.GroupKind{{Group: config.AnyGroup, Kind: "Backup"}},
+		[]schema.GroupKind{{Kind: "Endpoints"}, {Group: "apps", Kind: "StatefulSet"}},
+		true,
+	))
+	resources, err = d.DiscoverMirrorableResources(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []config.ResourceType{
+		{Kind: "Secret", Version: "v1"},
+		{Kind: "Endpoints", Version: "v1"},
+		{Kind: "StatefulSet", Version: "v1", Group: "apps"},
+	}, resources)
+
+	d.SetKindFilter(NewKindFilter(nil, nil, false))
+	resources, err = d.DiscoverMirrorableResources(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, resources, config.ResourceType{Kind: "Deployment", Version: "v1", Group: "apps"})
+}
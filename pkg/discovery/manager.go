@@ -7,15 +7,21 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/lukaszraczylo/kubemirror/pkg/config"
 )
 
+// RemovedFunc handles resource types that are no longer served, before the
+// manager forgets them.
+type RemovedFunc func(ctx context.Context, removed []config.ResourceType)
+
 // Manager handles periodic resource discovery and controller registration.
 type Manager struct {
 	discovery        *ResourceDiscovery
 	logger           logr.Logger
+	onRemoved        RemovedFunc
 	currentResources []config.ResourceType
 	interval         time.Duration
 	mu               sync.RWMutex
@@ -30,6 +36,13 @@ func NewManager(discovery *ResourceDiscovery, interval time.Duration) *Manager {
 	}
 }
 
+// OnRemoved registers fn to run for resource types that disappear from discovery.
+// Types whose group failed discovery, or whose kind is still served at another
+// version, are not considered removed.
+func (m *Manager) OnRemoved(fn RemovedFunc) {
+	m.onRemoved = fn
+}
+
 // Start begins periodic resource discovery.
 // It performs an initial discovery immediately, then rediscovers on the specified interval.
 func (m *Manager) Start(ctx context.Context) error {
@@ -83,16 +96,22 @@ func (m *Manager) discover(ctx context.Context) error {
 	logger := log.FromContext(ctx).WithName("discovery-manager")
 
 	// Discover current resources
-	discovered, err := m.discovery.DiscoverMirrorableResources(ctx)
+	discovered, failed, err := m.discovery.discover(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to discover resources: %w", err)
 	}
 
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	// Detect changes
-	added, removed := m.detectChanges(m.currentResources, discovered)
+	added, removed := m.detectChanges(m.GetCurrentResources(), discovered)
+
+	// A group that failed discovery is unavailable, not removed: keep its types
+	removed, unavailable := splitUnavailable(removed, failed)
+	if len(unavailable) > 0 {
+		logger.Info("keeping resource types whose API group failed discovery",
+			"resources", resourceTypesToStrings(unavailable),
+		)
+		discovered = append(discovered, unavailable...)
+	}
 
 	if len(added) > 0 {
 		logger.Info("new resource types discovered",
@@ -106,6 +125,12 @@ func (m *Manager) discover(ctx context.Context) error {
 			"count", len(removed),
 			"resources", resourceTypesToStrings(removed),
 		)
+
+		// Clean up before forgetting the types; a kind that moved to another
+		// version still has its mirrors, now served under the new version
+		if abandoned := abandonedTypes(removed, discovered); len(abandoned) > 0 && m.onRemoved != nil {
+			m.onRemoved(ctx, abandoned)
+		}
 	}
 
 	if len(added) == 0 && len(removed) == 0 {
@@ -115,7 +140,9 @@ func (m *Manager) discover(ctx context.Context) error {
 	}
 
 	// Update current resources
+	m.mu.Lock()
 	m.currentResources = discovered
+	m.mu.Unlock()
 
 	logger.Info("resource discovery completed",
 		"total", len(discovered),
@@ -156,6 +183,35 @@ func (m *Manager) detectChanges(old, new []config.ResourceType) (added, removed
 	return added, removed
 }
 
+// splitUnavailable separates removed types whose group version failed discovery.
+func splitUnavailable(removed []config.ResourceType, failed map[schema.GroupVersion]error) (gone, unavailable []config.ResourceType) {
+	for _, rt := range removed {
+		if _, ok := failed[schema.GroupVersion{Group: rt.Group, Version: rt.Version}]; ok {
+			unavailable = append(unavailable, rt)
+			continue
+		}
+		gone = append(gone, rt)
+	}
+	return gone, unavailable
+}
+
+// abandonedTypes returns the removed types whose group and kind are no longer
+// served at any version.
+func abandonedTypes(removed, discovered []config.ResourceType) []config.ResourceType {
+	served := make(map[schema.GroupKind]bool, len(discovered))
+	for _, rt := range discovered {
+		served[schema.GroupKind{Group: rt.Group, Kind: rt.Kind}] = true
+	}
+
+	var abandoned []config.ResourceType
+	for _, rt := range removed {
+		if !served[schema.GroupKind{Group: rt.Group, Kind: rt.Kind}] {
+			abandoned = append(abandoned, rt)
+		}
+	}
+	return abandoned
+}
+
 // resourceTypesToStrings converts a slice of ResourceType to strings for logging.
 func resourceTypesToStrings(resources []config.ResourceType) []string {
 	result := make([]string, len(resources))
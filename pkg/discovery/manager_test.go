@@ -1,9 +1,17 @@
 package discovery
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
 
 	"github.com/lukaszraczylo/kubemirror/pkg/config"
 )
@@ -152,3 +160,77 @@ func TestResourceTypesToStrings(t *testing.T) {
 	got := resourceTypesToStrings(resources)
 	assert.Equal(t, want, got)
 }
+
+func TestSplitUnavailable(t *testing.T) {
+	removed := []config.ResourceType{
+		{Kind: "ConfigMap", Version: "v1", Group: ""},
+		{Kind: "Middleware", Version: "v1alpha1", Group: "traefik.io"},
+	}
+	failed := map[schema.GroupVersion]error{
+		{Group: "traefik.io", Version: "v1alpha1"}: errors.New("service unavailable"),
+	}
+
+	gone, unavailable := splitUnavailable(removed, failed)
+	assert.Equal(t, []config.ResourceType{{Kind: "ConfigMap", Version: "v1", Group: ""}}, gone)
+	assert.Equal(t, []config.ResourceType{{Kind: "Middleware", Version: "v1alpha1", Group: "traefik.io"}}, unavailable)
+}
+
+func TestAbandonedTypes(t *testing.T) {
+	removed := []config.ResourceType{
+		{Kind: "Widget", Version: "v1beta1", Group: "example.com"},
+		{Kind: "Gadget", Version: "v1", Group: "example.com"},
+	}
+	discovered := []config.ResourceType{
+		{Kind: "Secret", Version: "v1", Group: ""},
+		{Kind: "Widget", Version: "v1", Group: "example.com"},
+	}
+
+	assert.Equal(t, []config.ResourceType{{Kind: "Gadget", Version: "v1", Group: "example.com"}},
+		abandonedTypes(removed, discovered), "a kind served at another version is not abandoned")
+}
+
+// partialDiscovery serves resources but reports some group versions as failed.
+type partialDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	failed map[schema.GroupVersion]error
+}
+
+func (d *partialDiscovery) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	groups, resources, _ := d.FakeDiscovery.ServerGroupsAndResources()
+	if len(d.failed) > 0 {
+		return groups, resources, &discovery.ErrGroupDiscoveryFailed{Groups: d.failed}
+	}
+	return groups, resources, nil
+}
+
+func TestManager_DiscoverRemoved(t *testing.T) {
+	verbs := metav1.Verbs{"get", "list", "watch", "create", "update", "delete"}
+	fake := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	client := &partialDiscovery{FakeDiscovery: fake}
+	m := NewManager(&ResourceDiscovery{discoveryClient: client}, 0)
+
+	var removed []config.ResourceType
+	m.OnRemoved(func(_ context.Context, rts []config.ResourceType) {
+		removed = append(removed, rts...)
+	})
+
+	fake.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "secrets", Kind: "Secret", Namespaced: true, Verbs: verbs}}},
+		{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{{Name: "widgets", Kind: "Widget", Namespaced: true, Verbs: verbs}}},
+		{GroupVersion: "traefik.io/v1alpha1", APIResources: []metav1.APIResource{{Name: "middlewares", Kind: "Middleware", Namespaced: true, Verbs: verbs}}},
+	}
+	require.NoError(t, m.discover(context.Background()))
+	assert.Len(t, m.GetCurrentResources(), 3)
+	assert.Empty(t, removed)
+
+	// The CRD is gone and traefik's aggregated API is temporarily unavailable
+	fake.Resources = fake.Resources[:1]
+	client.failed = map[schema.GroupVersion]error{{Group: "traefik.io", Version: "v1alpha1"}: errors.New("unavailable")}
+	require.NoError(t, m.discover(context.Background()))
+
+	assert.Equal(t, []config.ResourceType{{Kind: "Widget", Version: "v1", Group: "example.com"}}, removed)
+	assert.ElementsMatch(t, []config.ResourceType{
+		{Kind: "Secret", Version: "v1", Group: ""},
+		{Kind: "Middleware", Version: "v1alpha1", Group: "traefik.io"},
+	}, m.GetCurrentResources(), "types of unavailable groups are kept")
+}
@@ -0,0 +1,259 @@
+package filter
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// Condition is a parsed sync-when expression: one or more comparisons of object
+// fields joined with "&&", e.g.
+//
+//	status.conditions[type=="Ready"].status == "True" && status.phase != "Failed"
+//
+// Paths are dot-separated field names (a leading dot is optional); a list element
+// is selected with [field=="value"]. Values are quoted strings or bare words such
+// as true or 3. A missing field compares as the empty string.
+type Condition struct {
+	expr    string
+	clauses []conditionClause
+}
+
+type conditionClause struct {
+	path   []pathSegment
+	value  string
+	negate bool
+}
+
+type pathSegment struct {
+	field       string
+	selectKey   string
+	selectValue string
+	selector    bool
+}
+
+// ParseCondition parses a sync-when expression.
+func ParseCondition(expr string) (*Condition, error) {
+	tokens, err := tokenizeCondition(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", expr, err)
+	}
+
+	p := &conditionParser{tokens: tokens}
+	c := &Condition{expr: expr}
+	for {
+		clause, err := p.clause()
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition %q: %w", expr, err)
+		}
+		c.clauses = append(c.clauses, clause)
+		if p.done() {
+			return c, nil
+		}
+		if !p.accept("&&") {
+			return nil, fmt.Errorf("invalid condition %q: expected && but found %q", expr, p.peek().text)
+		}
+	}
+}
+
+// String returns the original expression.
+func (c *Condition) String() string {
+	return c.expr
+}
+
+// Matches reports whether every clause holds for the object.
+func (c *Condition) Matches(obj map[string]interface{}) bool {
+	for _, clause := range c.clauses {
+		actual := ""
+		if value, found := resolvePath(obj, clause.path); found && value != nil {
+			actual = fmt.Sprint(value)
+		}
+		if (actual == clause.value) == clause.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// resolvePath walks path through nested maps and selected list elements.
+func resolvePath(obj interface{}, path []pathSegment) (interface{}, bool) {
+	current := obj
+	for _, seg := range path {
+		fields, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = fields[seg.field]; !ok {
+			return nil, false
+		}
+		if !seg.selector {
+			continue
+		}
+
+		items, ok := current.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = nil
+		for _, item := range items {
+			if m, isMap := item.(map[string]interface{}); isMap && fmt.Sprint(m[seg.selectKey]) == seg.selectValue {
+				current = m
+				break
+			}
+		}
+		if current == nil {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+type tokenKind int
+
+const (
+	tokenIdent tokenKind = iota
+	tokenString
+	tokenSymbol
+	tokenEOF
+)
+
+type conditionToken struct {
+	text string
+	kind tokenKind
+}
+
+// tokenizeCondition splits an expression into identifiers, quoted strings and symbols.
+func tokenizeCondition(expr string) ([]conditionToken, error) {
+	var tokens []conditionToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, conditionToken{text: string(runes[i+1 : end]), kind: tokenString})
+			i = end + 1
+		case r == '.' || r == '[' || r == ']':
+			tokens = append(tokens, conditionToken{text: string(r), kind: tokenSymbol})
+			i++
+		case i+1 < len(runes) && isOperator(string(runes[i:i+2])):
+			tokens = append(tokens, conditionToken{text: string(runes[i : i+2]), kind: tokenSymbol})
+			i += 2
+		case isIdentRune(r):
+			end := i
+			for end < len(runes) && isIdentRune(runes[end]) {
+				end++
+			}
+			tokens = append(tokens, conditionToken{text: string(runes[i:end]), kind: tokenIdent})
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+func isOperator(s string) bool {
+	return s == "==" || s == "!=" || s == "&&"
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+}
+
+// conditionParser is a recursive-descent parser over condition tokens.
+type conditionParser struct {
+	tokens []conditionToken
+	pos    int
+}
+
+func (p *conditionParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *conditionParser) peek() conditionToken {
+	if p.done() {
+		return conditionToken{text: "end of expression", kind: tokenEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *conditionParser) accept(symbol string) bool {
+	if t := p.peek(); t.kind == tokenSymbol && t.text == symbol {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *conditionParser) ident() (string, error) {
+	t := p.peek()
+	if t.kind != tokenIdent {
+		return "", fmt.Errorf("expected field name but found %q", t.text)
+	}
+	p.pos++
+	return t.text, nil
+}
+
+// literal accepts a quoted string or a bare word such as true or 3.
+func (p *conditionParser) literal() (string, error) {
+	t := p.peek()
+	if t.kind != tokenString && t.kind != tokenIdent {
+		return "", fmt.Errorf("expected value but found %q", t.text)
+	}
+	p.pos++
+	return t.text, nil
+}
+
+func (p *conditionParser) clause() (conditionClause, error) {
+	var clause conditionClause
+	p.accept(".") // JSONPath-style leading dot is optional
+	for {
+		field, err := p.ident()
+		if err != nil {
+			return clause, err
+		}
+		seg := pathSegment{field: field}
+		if p.accept("[") {
+			if seg.selectKey, err = p.ident(); err != nil {
+				return clause, err
+			}
+			if !p.accept("==") {
+				return clause, fmt.Errorf("expected == in list selector but found %q", p.peek().text)
+			}
+			if seg.selectValue, err = p.literal(); err != nil {
+				return clause, err
+			}
+			if !p.accept("]") {
+				return clause, fmt.Errorf("expected ] but found %q", p.peek().text)
+			}
+			seg.selector = true
+		}
+		clause.path = append(clause.path, seg)
+		if !p.accept(".") {
+			break
+		}
+	}
+
+	switch {
+	case p.accept("=="):
+	case p.accept("!="):
+		clause.negate = true
+	default:
+		return clause, fmt.Errorf("expected == or != but found %q", p.peek().text)
+	}
+
+	value, err := p.literal()
+	if err != nil {
+		return clause, err
+	}
+	clause.value = value
+	return clause, nil
+}
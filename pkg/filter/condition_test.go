@@ -0,0 +1,75 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCondition_Matches(t *testing.T) {
+	obj := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"paused":   false,
+		},
+		"status": map[string]interface{}{
+			"phase": "Running",
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Progressing", "status": "False"},
+				map[string]interface{}{"type": "Ready", "status": "True"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		{name: "ready condition", expr: `status.conditions[type=="Ready"].status == "True"`, want: true},
+		{name: "other condition", expr: `status.conditions[type=="Progressing"].status == "True"`, want: false},
+		{name: "missing condition", expr: `status.conditions[type=="Synced"].status == "True"`, want: false},
+		{name: "missing condition compares as empty", expr: `status.conditions[type=="Synced"].status != "False"`, want: true},
+		{name: "plain field", expr: `status.phase == "Running"`, want: true},
+		{name: "leading dot", expr: `.status.phase == "Running"`, want: true},
+		{name: "not equal", expr: `status.phase != "Failed"`, want: true},
+		{name: "bare number", expr: `spec.replicas == 3`, want: true},
+		{name: "bare boolean", expr: `spec.paused == false`, want: true},
+		{name: "conjunction", expr: `status.phase == "Running" && status.conditions[type=="Ready"].status == "True"`, want: true},
+		{name: "conjunction with failing clause", expr: `status.phase == "Running" && spec.paused == true`, want: false},
+		{name: "missing status", expr: `status.observedGeneration == 1`, want: false},
+		{name: "path through scalar", expr: `status.phase.value == "x"`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCondition(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, c.Matches(obj))
+			assert.Equal(t, tt.expr, c.String())
+		})
+	}
+}
+
+func TestParseCondition_Invalid(t *testing.T) {
+	tests := []string{
+		``,
+		`status.phase`,
+		`status.phase = "Running"`,
+		`status.phase == "Running`,
+		`status.phase == "Running" &&`,
+		`status.phase == "Running" status.ready == true`,
+		`status.conditions[type].status == "True"`,
+		`status.conditions[type=="Ready".status == "True"`,
+		`status.phase == "Running" || status.phase == "Ready"`,
+		`== "True"`,
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			_, err := ParseCondition(expr)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package filter
+
+import "strings"
+
+// MatchGlob reports whether text matches pattern, where * matches any sequence of
+// characters and ? matches exactly one; every other character matches itself.
+// It runs in O(len(pattern)*len(text)) time at worst: on a mismatch only the
+// most recent * is retried, one character further along, since earlier stars
+// can never need to cover more.
+func MatchGlob(pattern, text string) bool {
+	if pattern == text || pattern == "*" {
+		return true
+	}
+
+	p, t := 0, 0
+	star, resume := -1, 0
+	for t < len(text) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			// Let the star match nothing for now; remember where to resume
+			star, resume = p, t
+			p++
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == text[t]):
+			p++
+			t++
+		case star >= 0:
+			// Let the last star swallow one more character
+			resume++
+			p, t = star+1, resume
+		default:
+			return false
+		}
+	}
+
+	// Trailing stars match the empty rest of text
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// isPlainGlob reports whether MatchGlob fully understands pattern, i.e. it has
+// no character classes or escapes that only filepath.Match supports.
+func isPlainGlob(pattern string) bool {
+	return !strings.ContainsAny(pattern, `[\`)
+}
@@ -0,0 +1,246 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		text     string
+		expected bool
+	}{
+		// Exact matches
+		{
+			name:     "exact match",
+			pattern:  "production",
+			text:     "production",
+			expected: true,
+		},
+		{
+			name:     "exact match - no match",
+			pattern:  "production",
+			text:     "staging",
+			expected: false,
+		},
+
+		// Wildcard * patterns
+		{
+			name:     "wildcard all",
+			pattern:  "*",
+			text:     "anything",
+			expected: true,
+		},
+		{
+			name:     "prefix wildcard",
+			pattern:  "prod-*",
+			text:     "prod-app-1",
+			expected: true,
+		},
+		{
+			name:     "prefix wildcard - no match",
+			pattern:  "prod-*",
+			text:     "staging-app-1",
+			expected: false,
+		},
+		{
+			name:     "suffix wildcard",
+			pattern:  "*-staging",
+			text:     "app-staging",
+			expected: true,
+		},
+		{
+			name:     "suffix wildcard - no match",
+			pattern:  "*-staging",
+			text:     "app-production",
+			expected: false,
+		},
+		{
+			name:     "middle wildcard",
+			pattern:  "app-*-db",
+			text:     "app-prod-db",
+			expected: true,
+		},
+		{
+			name:     "middle wildcard - no match",
+			pattern:  "app-*-db",
+			text:     "app-prod-cache",
+			expected: false,
+		},
+		{
+			name:     "multiple wildcards",
+			pattern:  "*-prod-*",
+			text:     "service-prod-v1",
+			expected: true,
+		},
+		{
+			name:     "wildcard matches empty",
+			pattern:  "app-*",
+			text:     "app-",
+			expected: true,
+		},
+
+		// Single character wildcard ?
+		{
+			name:     "single char wildcard",
+			pattern:  "app-?",
+			text:     "app-1",
+			expected: true,
+		},
+		{
+			name:     "single char wildcard - no match (too long)",
+			pattern:  "app-?",
+			text:     "app-12",
+			expected: false,
+		},
+		{
+			name:     "single char wildcard - no match (too short)",
+			pattern:  "app-?",
+			text:     "app-",
+			expected: false,
+		},
+		{
+			name:     "multiple single char wildcards",
+			pattern:  "app-??",
+			text:     "app-12",
+			expected: true,
+		},
+		{
+			name:     "mixed wildcards",
+			pattern:  "app-?-*",
+			text:     "app-1-prod",
+			expected: true,
+		},
+
+		// Edge cases
+		{
+			name:     "empty pattern and text",
+			pattern:  "",
+			text:     "",
+			expected: true,
+		},
+		{
+			name:     "empty pattern non-empty text",
+			pattern:  "",
+			text:     "text",
+			expected: false,
+		},
+		{
+			name:     "pattern longer than text",
+			pattern:  "production",
+			text:     "prod",
+			expected: false,
+		},
+		{
+			name:     "text longer than pattern",
+			pattern:  "prod",
+			text:     "production",
+			expected: false,
+		},
+
+		// Real-world examples
+		{
+			name:     "preprod namespaces",
+			pattern:  "preprod-*",
+			text:     "preprod-api",
+			expected: true,
+		},
+		{
+			name:     "staging environments",
+			pattern:  "*-staging",
+			text:     "app-staging",
+			expected: true,
+		},
+		{
+			name:     "numbered namespaces",
+			pattern:  "namespace-?",
+			text:     "namespace-1",
+			expected: true,
+		},
+		{
+			name:     "versioned services",
+			pattern:  "service-v*",
+			text:     "service-v1.2.3",
+			expected: true,
+		},
+		// Backtracking
+		{
+			name:     "star backtracks past a false match",
+			pattern:  "*-db",
+			text:     "app-dbx-db",
+			expected: true,
+		},
+		{
+			name:     "later star retried after earlier stars matched",
+			pattern:  "a*b*c",
+			text:     "abbbcbc",
+			expected: true,
+		},
+		{
+			name:     "no room left for ? after the star",
+			pattern:  "a*b?c",
+			text:     "abc",
+			expected: false,
+		},
+		{
+			name:     "literal star in text",
+			pattern:  "a*",
+			text:     "a*b",
+			expected: true,
+		},
+		{
+			name:     "consecutive stars",
+			pattern:  "app-**-prod",
+			text:     "app-eu-prod",
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := MatchGlob(tt.pattern, tt.text)
+			assert.Equal(t, tt.expected, result, "MatchGlob(%q, %q)", tt.pattern, tt.text)
+		})
+	}
+}
+
+// pathologicalGlob is a pattern with many stars that never matches its text; a
+// backtracking matcher retrying every star split takes exponential time on it.
+var pathologicalGlob = struct{ pattern, text string }{
+	pattern: strings.Repeat("a*", 20) + "b",
+	text:    strings.Repeat("a", 60),
+}
+
+func TestMatchGlob_ManyStars(t *testing.T) {
+	start := time.Now()
+	assert.False(t, MatchGlob(pathologicalGlob.pattern, pathologicalGlob.text))
+	assert.True(t, MatchGlob(pathologicalGlob.pattern, pathologicalGlob.text+"b"))
+	assert.Less(t, time.Since(start), time.Second, "matching must not backtrack exponentially")
+}
+
+func BenchmarkMatchGlob(b *testing.B) {
+	tests := []struct {
+		name    string
+		pattern string
+		text    string
+	}{
+		{name: "prefix", pattern: "app-*", text: "app-frontend"},
+		{name: "multiple wildcards", pattern: "*-app-*-db", text: "my-app-prod-db"},
+		{name: "no match", pattern: "app-*", text: "production-api"},
+		{name: "many stars", pattern: pathologicalGlob.pattern, text: pathologicalGlob.text},
+	}
+
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = MatchGlob(tt.pattern, tt.text)
+			}
+		})
+	}
+}
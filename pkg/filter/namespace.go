@@ -4,7 +4,12 @@ package filter
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/lukaszraczylo/kubemirror/pkg/constants"
 )
@@ -24,8 +29,33 @@ func ValidatePattern(pattern string) error {
 		return fmt.Errorf("empty pattern")
 	}
 
+	// Exclusions are names or globs; excluding a keyword means nothing
+	if excluded, ok := strings.CutPrefix(pattern, constants.TargetNamespacesExclusionPrefix); ok {
+		if excluded == "" {
+			return fmt.Errorf("empty exclusion")
+		}
+		if excluded == constants.TargetNamespacesAll || excluded == constants.TargetNamespacesAllLabeled ||
+			excluded == constants.TargetNamespacesDescendants {
+			return fmt.Errorf("keyword %q cannot be excluded", excluded)
+		}
+		if _, ok := TenantPattern(excluded); ok {
+			return fmt.Errorf("tenant %q cannot be excluded", excluded)
+		}
+		pattern = excluded
+	}
+
+	if tenant, ok := TenantPattern(pattern); ok {
+		return ValidateTenant(tenant)
+	}
+
+	if IsRegexPattern(pattern) {
+		_, err := CompileRegexPattern(pattern)
+		return err
+	}
+
 	// Special keywords are always valid
-	if pattern == constants.TargetNamespacesAll || pattern == constants.TargetNamespacesAllLabeled {
+	if pattern == constants.TargetNamespacesAll || pattern == constants.TargetNamespacesAllLabeled ||
+		pattern == constants.TargetNamespacesDescendants {
 		return nil
 	}
 
@@ -76,57 +106,98 @@ func InvalidPatterns(results []PatternValidationResult) []PatternValidationResul
 }
 
 // NamespaceFilter handles namespace filtering logic including patterns and exclusions.
+// It is safe for concurrent use, and Update changes it in place for all its users.
 type NamespaceFilter struct {
 	excludedNamespaces map[string]bool
-	includedPatterns   []string
+	// included is compiled once per Update, as IsAllowed runs for every namespace
+	// of every source
+	included *patternSet
+	// scope, when set, holds the only namespaces the controller can see
+	scope map[string]bool
+	mu    sync.RWMutex
 }
 
 // NewNamespaceFilter creates a new NamespaceFilter with the given exclusions and inclusions.
 func NewNamespaceFilter(excluded, included []string) *NamespaceFilter {
+	nf := &NamespaceFilter{}
+	nf.Update(excluded, included)
+	return nf
+}
+
+// Update replaces the filter's exclusions and inclusions (e.g. on a configuration reload).
+func (nf *NamespaceFilter) Update(excluded, included []string) {
 	excludedMap := make(map[string]bool)
 	for _, ns := range excluded {
 		excludedMap[ns] = true
 	}
+	includedSet := compilePatterns(included)
 
-	return &NamespaceFilter{
-		excludedNamespaces: excludedMap,
-		includedPatterns:   included,
+	nf.mu.Lock()
+	defer nf.mu.Unlock()
+	nf.excludedNamespaces = excludedMap
+	nf.included = includedSet
+}
+
+// Restrict limits the filter to the given namespaces, on top of its exclusions
+// and inclusions, for a controller whose watches are scoped to them. Unlike the
+// exclusions and inclusions it is not changed by Update. An empty list lifts the
+// restriction.
+func (nf *NamespaceFilter) Restrict(namespaces []string) {
+	var scope map[string]bool
+	if len(namespaces) > 0 {
+		scope = make(map[string]bool, len(namespaces))
+		for _, ns := range namespaces {
+			scope[ns] = true
+		}
 	}
+
+	nf.mu.Lock()
+	defer nf.mu.Unlock()
+	nf.scope = scope
 }
 
 // IsAllowed checks if a namespace is allowed based on filters.
 // Returns true if the namespace passes all filters.
 func (nf *NamespaceFilter) IsAllowed(namespace string) bool {
+	nf.mu.RLock()
+	defer nf.mu.RUnlock()
+
+	// Outside the watched namespaces the controller can neither read nor write
+	if nf.scope != nil && !nf.scope[namespace] {
+		return false
+	}
+
 	// Check if explicitly excluded
 	if nf.excludedNamespaces[namespace] {
 		return false
 	}
 
 	// If no include patterns specified, allow all (except excluded)
-	if len(nf.includedPatterns) == 0 {
+	if nf.included.empty() {
 		return true
 	}
 
-	// Check if matches any include pattern
-	for _, pattern := range nf.includedPatterns {
-		if matchesPattern(namespace, pattern) {
-			return true
-		}
-	}
-
-	return false
+	return nf.included.matches(namespace)
 }
 
 // MatchesPattern checks if a namespace name matches the given pattern.
-// Supports glob-style patterns: "app-*", "*-prod", "stage-*-db"
+// Supports glob-style patterns: "app-*", "*-prod", "stage-*-db", and
+// "re:"-prefixed regular expressions.
 func matchesPattern(namespace, pattern string) bool {
 	// Direct match
 	if namespace == pattern {
 		return true
 	}
 
-	// Use filepath.Match for glob-style matching
-	// filepath.Match supports * (any sequence) and ? (single char)
+	if IsRegexPattern(pattern) {
+		return matchesRegex(namespace, pattern)
+	}
+
+	if isPlainGlob(pattern) {
+		return MatchGlob(pattern, namespace)
+	}
+
+	// filepath.Match handles character classes and escapes
 	matched, err := filepath.Match(pattern, namespace)
 	if err != nil {
 		// Invalid pattern, no match
@@ -138,7 +209,8 @@ func matchesPattern(namespace, pattern string) bool {
 
 // ParseTargetNamespaces parses the target-namespaces annotation value.
 // Returns a list of namespace patterns or special keywords.
-// Input: "ns1,ns2,app-*" or "all" or "all-labeled"
+// Input: "ns1,ns2,app-*" or "all" or "all-labeled"; "descendants" may be combined
+// with names and patterns, and "!"-prefixed exclusions are kept as they are.
 func ParseTargetNamespaces(value string) []string {
 	if value == "" {
 		return nil
@@ -166,8 +238,38 @@ func ParseTargetNamespaces(value string) []string {
 	return result
 }
 
+// SplitExclusions separates "!"-prefixed exclusions from the other patterns,
+// returning the exclusions without their prefix.
+func SplitExclusions(patterns []string) (included, excluded []string) {
+	for _, pattern := range patterns {
+		if rest, ok := strings.CutPrefix(pattern, constants.TargetNamespacesExclusionPrefix); ok {
+			excluded = append(excluded, rest)
+			continue
+		}
+		included = append(included, pattern)
+	}
+	return included, excluded
+}
+
+// ExcludeNamespaces drops the namespaces matching any of the exclusions, names
+// or globs without their "!" prefix, keeping the order of the others. namespaces
+// is filtered in place.
+func ExcludeNamespaces(namespaces, exclusions []string) []string {
+	if len(exclusions) == 0 {
+		return namespaces
+	}
+	return slices.DeleteFunc(namespaces, func(ns string) bool {
+		return slices.ContainsFunc(exclusions, func(pattern string) bool { return matchesPattern(ns, pattern) })
+	})
+}
+
 // ResolveTargetNamespaces resolves namespace patterns to concrete namespace names.
-// Handles "all", "all-labeled", and glob patterns.
+// Handles "all", "all-labeled", and glob patterns; "descendants" and "tenant:"
+// entries need namespace labels and are resolved by DescendantNamespaces and
+// TenantNamespaces instead. Namespaces that opted out are skipped by every pattern
+// but a plain name, which asks for the namespace explicitly. Exclusions ("!"
+// prefixed) are applied after every other pattern is expanded, whatever their
+// position.
 // Parameters:
 //   - patterns: namespace patterns from annotation
 //   - allNamespaces: list of all namespaces in cluster
@@ -176,7 +278,7 @@ func ParseTargetNamespaces(value string) []string {
 //   - sourceNamespace: exclude this namespace to prevent self-copy
 //   - filter: namespace filter for exclusions
 //
-// Returns: list of concrete target namespace names
+// Returns: sorted list of concrete target namespace names
 func ResolveTargetNamespaces(
 	patterns []string,
 	allNamespaces []string,
@@ -185,6 +287,7 @@ func ResolveTargetNamespaces(
 	sourceNamespace string,
 	filter *NamespaceFilter,
 ) []string {
+	patterns, exclusions := SplitExclusions(patterns)
 	if len(patterns) == 0 {
 		return nil
 	}
@@ -210,6 +313,9 @@ func ResolveTargetNamespaces(
 				}
 			}
 
+		case constants.TargetNamespacesDescendants:
+			// Resolved from namespace labels by DescendantNamespaces
+
 		case constants.TargetNamespacesAllLabeled:
 			// Mirror only to namespaces with allow-mirrors="true" label
 			// This implements opt-IN model
@@ -220,16 +326,31 @@ func ResolveTargetNamespaces(
 			}
 
 		default:
+			// Tenants need namespace labels and are resolved by TenantNamespaces
+			if _, ok := TenantPattern(pattern); ok {
+				continue
+			}
 			// Check if it's a pattern or direct namespace name
-			if strings.Contains(pattern, "*") || strings.Contains(pattern, "?") {
-				// It's a glob pattern - match against all namespaces
+			if IsRegexPattern(pattern) {
+				// Compiled once for all namespaces; invalid expressions match nothing
+				re, err := CompileRegexPattern(pattern)
+				if err != nil {
+					continue
+				}
 				for _, ns := range allNamespaces {
-					if matchesPattern(ns, pattern) && ns != sourceNamespace && filter.IsAllowed(ns) {
+					if re.MatchString(ns) && ns != sourceNamespace && filter.IsAllowed(ns) && !optOutMap[ns] {
+						targetMap[ns] = true
+					}
+				}
+			} else if strings.Contains(pattern, "*") || strings.Contains(pattern, "?") {
+				// It's a glob pattern - match against all namespaces, skipping opt-outs like "all"
+				for _, ns := range allNamespaces {
+					if matchesPattern(ns, pattern) && ns != sourceNamespace && filter.IsAllowed(ns) && !optOutMap[ns] {
 						targetMap[ns] = true
 					}
 				}
 			} else {
-				// Direct namespace name
+				// Direct namespace name, mirrored to even if it opted out
 				if pattern != sourceNamespace && filter.IsAllowed(pattern) {
 					targetMap[pattern] = true
 				}
@@ -237,11 +358,86 @@ func ResolveTargetNamespaces(
 		}
 	}
 
-	// Convert map to slice
+	// Convert map to slice, sorted so every reconcile sees the same order
 	result := make([]string, 0, len(targetMap))
 	for ns := range targetMap {
 		result = append(result, ns)
 	}
+	slices.Sort(result)
+
+	return ExcludeNamespaces(result, exclusions)
+}
+
+// DescendantNamespaces returns the namespaces below sourceNamespace in its
+// Hierarchical Namespace Controller (HNC) hierarchy, at any depth. HNC labels every
+// namespace with "<ancestor>.tree.hnc.x-k8s.io/depth" for each of its ancestors, so
+// no HNC objects need to be read. Like "all", it skips namespaces that opted out with
+// allow-mirrors="false" and namespaces rejected by filter (nil allows all).
+func DescendantNamespaces(
+	namespaceLabels map[string]map[string]string,
+	sourceNamespace string,
+	filter *NamespaceFilter,
+) []string {
+	depthLabel := sourceNamespace + constants.HNCTreeDepthLabelSuffix
 
+	var result []string
+	for ns, nsLabels := range namespaceLabels {
+		depth, err := strconv.Atoi(nsLabels[depthLabel])
+		if err != nil || depth < 1 || nsLabels[constants.LabelAllowMirrors] == "false" {
+			continue
+		}
+		if filter != nil && !filter.IsAllowed(ns) {
+			continue
+		}
+		result = append(result, ns)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// ParseNamespaceSelector parses a target-namespace-selector annotation value, a
+// label selector in kubectl syntax (e.g. "team=payments,env in (prod,staging)").
+func ParseNamespaceSelector(value string) (labels.Selector, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("empty namespace selector")
+	}
+	selector, err := labels.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace selector %q: %w", value, err)
+	}
+	return selector, nil
+}
+
+// SelectNamespaces returns the namespaces whose labels match selector.
+// Like "all", it skips the source namespace, namespaces that opted out with
+// allow-mirrors="false" and namespaces rejected by filter (nil allows all).
+// Parameters:
+//   - selector: parsed target-namespace-selector
+//   - namespaceLabels: labels of every namespace in the cluster, by name
+//   - sourceNamespace: exclude this namespace to prevent self-copy
+//   - filter: namespace filter for exclusions
+func SelectNamespaces(
+	selector labels.Selector,
+	namespaceLabels map[string]map[string]string,
+	sourceNamespace string,
+	filter *NamespaceFilter,
+) []string {
+	if selector == nil {
+		return nil
+	}
+
+	var result []string
+	for ns, nsLabels := range namespaceLabels {
+		if ns == sourceNamespace || nsLabels[constants.LabelAllowMirrors] == "false" {
+			continue
+		}
+		if filter != nil && !filter.IsAllowed(ns) {
+			continue
+		}
+		if selector.Matches(labels.Set(nsLabels)) {
+			result = append(result, ns)
+		}
+	}
 	return result
 }
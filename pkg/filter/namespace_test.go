@@ -6,6 +6,8 @@ import (
 
 	"github.com/lukaszraczylo/kubemirror/pkg/constants"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 func TestNamespaceFilter_IsAllowed(t *testing.T) {
@@ -336,6 +338,92 @@ func TestResolveTargetNamespaces(t *testing.T) {
 	}
 }
 
+func TestResolveTargetNamespaces_Exclusions(t *testing.T) {
+	allNamespaces := []string{"app-a", "app-b", "app-legacy", "app-sandbox", "team-sandbox", "prod", "default"}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{
+			name:     "name and glob exclusions",
+			patterns: ParseTargetNamespaces("app-*,!app-legacy,!*-sandbox"),
+			want:     []string{"app-a", "app-b"},
+		},
+		{
+			name:     "exclusions apply wherever they are listed",
+			patterns: ParseTargetNamespaces("!app-legacy, app-*, prod"),
+			want:     []string{"app-a", "app-b", "app-sandbox", "prod"},
+		},
+		{
+			name:     "exclusions carve out of all",
+			patterns: ParseTargetNamespaces("all,!app-*"),
+			want:     []string{"prod", "team-sandbox"},
+		},
+		{
+			name:     "regular expressions",
+			patterns: ParseTargetNamespaces("re:^app-(a|b)$,prod"),
+			want:     []string{"app-a", "app-b", "prod"},
+		},
+		{
+			name:     "regular expression exclusions",
+			patterns: ParseTargetNamespaces("app-*,!re:-(legacy|sandbox)$"),
+			want:     []string{"app-a", "app-b"},
+		},
+		{
+			name:     "invalid regular expressions match nothing",
+			patterns: ParseTargetNamespaces("re:app-(,prod"),
+			want:     []string{"prod"},
+		},
+		{
+			name:     "exclusions alone target nothing",
+			patterns: ParseTargetNamespaces("!app-legacy"),
+			want:     nil,
+		},
+		{
+			name:     "tenants are left to TenantNamespaces",
+			patterns: ParseTargetNamespaces("tenant:payments,prod"),
+			want:     []string{"prod"},
+		},
+		{
+			name:     "excluding a listed name",
+			patterns: ParseTargetNamespaces("prod,!prod"),
+			want:     []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ResolveTargetNamespaces(tt.patterns, allNamespaces, nil, nil, "default", NewNamespaceFilter(nil, nil))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolveTargetNamespaces_OptOut(t *testing.T) {
+	allNamespaces := []string{"app-a", "app-b", "app-opted-out", "default"}
+	optOut := []string{"app-opted-out"}
+	resolve := func(patterns ...string) []string {
+		return ResolveTargetNamespaces(patterns, allNamespaces, []string{"app-b"}, optOut, "default", NewNamespaceFilter(nil, nil))
+	}
+
+	assert.Equal(t, []string{"app-a", "app-b"}, resolve(constants.TargetNamespacesAll), "all skips namespaces labelled allow-mirrors=false")
+	assert.Equal(t, []string{"app-b"}, resolve(constants.TargetNamespacesAllLabeled))
+	assert.Equal(t, []string{"app-a", "app-b"}, resolve("app-*"), "globs skip opted-out namespaces")
+	assert.Equal(t, []string{"app-a", "app-b"}, resolve("re:^app-"), "regular expressions skip opted-out namespaces")
+	assert.Equal(t, []string{"app-opted-out"}, resolve("app-opted-out"), "a namespace named explicitly is still a target")
+}
+
+func TestSplitExclusions(t *testing.T) {
+	included, excluded := SplitExclusions([]string{"app-*", "!app-legacy", "descendants", "!*-sandbox"})
+	assert.Equal(t, []string{"app-*", "descendants"}, included)
+	assert.Equal(t, []string{"app-legacy", "*-sandbox"}, excluded)
+
+	assert.Equal(t, []string{"a", "c"}, ExcludeNamespaces([]string{"a", "b-sandbox", "c"}, excluded))
+	assert.Equal(t, []string{"a"}, ExcludeNamespaces([]string{"a"}, nil))
+}
+
 // Edge case tests
 func TestResolveTargetNamespaces_EdgeCases(t *testing.T) {
 	t.Run("no namespaces in cluster", func(t *testing.T) {
@@ -636,6 +724,11 @@ func TestValidatePattern(t *testing.T) {
 			pattern: constants.TargetNamespacesAllLabeled,
 			wantErr: false,
 		},
+		{
+			name:    "valid 'descendants' keyword",
+			pattern: constants.TargetNamespacesDescendants,
+			wantErr: false,
+		},
 		{
 			name:    "invalid unclosed bracket",
 			pattern: "app-[",
@@ -651,6 +744,71 @@ func TestValidatePattern(t *testing.T) {
 			pattern: "",
 			wantErr: true,
 		},
+		{
+			name:    "valid exclusion",
+			pattern: "!app-legacy",
+			wantErr: false,
+		},
+		{
+			name:    "valid exclusion pattern",
+			pattern: "!*-sandbox",
+			wantErr: false,
+		},
+		{
+			name:    "invalid exclusion pattern",
+			pattern: "!app-[",
+			wantErr: true,
+		},
+		{
+			name:    "empty exclusion is invalid",
+			pattern: "!",
+			wantErr: true,
+		},
+		{
+			name:    "valid regular expression",
+			pattern: "re:^team-(a|b)-prod$",
+			wantErr: false,
+		},
+		{
+			name:    "invalid regular expression",
+			pattern: "re:^team-(a|b",
+			wantErr: true,
+		},
+		{
+			name:    "empty regular expression is invalid",
+			pattern: "re:",
+			wantErr: true,
+		},
+		{
+			name:    "valid regular expression exclusion",
+			pattern: "!re:-sandbox$",
+			wantErr: false,
+		},
+		{
+			name:    "excluded keyword is invalid",
+			pattern: "!" + constants.TargetNamespacesAll,
+			wantErr: true,
+		},
+		{
+			name:    "valid tenant",
+			pattern: "tenant:payments",
+			wantErr: false,
+		},
+		{
+			name:    "empty tenant is invalid",
+			pattern: "tenant:",
+			wantErr: true,
+		},
+		{
+			name:    "tenant that is not a label value is invalid",
+			pattern: "tenant:team a",
+			wantErr: true,
+		},
+		{
+			name:    "excluded tenant is invalid",
+			pattern: "!tenant:payments",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -756,3 +914,88 @@ func TestInvalidPatterns(t *testing.T) {
 		assert.Nil(t, invalid)
 	})
 }
+
+func TestParseNamespaceSelector(t *testing.T) {
+	selector, err := ParseNamespaceSelector(" team=payments,env in (prod,staging) ")
+	require.NoError(t, err)
+	assert.True(t, selector.Matches(labels.Set{"team": "payments", "env": "staging"}))
+	assert.False(t, selector.Matches(labels.Set{"team": "payments", "env": "dev"}))
+
+	_, err = ParseNamespaceSelector("")
+	assert.Error(t, err)
+	_, err = ParseNamespaceSelector("env in (prod")
+	assert.Error(t, err)
+}
+
+func TestSelectNamespaces(t *testing.T) {
+	selector, err := ParseNamespaceSelector("team=payments")
+	require.NoError(t, err)
+	namespaceLabels := map[string]map[string]string{
+		"source":      {"team": "payments"},
+		"payments":    {"team": "payments"},
+		"opted-out":   {"team": "payments", constants.LabelAllowMirrors: "false"},
+		"kube-system": {"team": "payments"},
+		"shop":        {"team": "shop"},
+		"unlabeled":   nil,
+	}
+
+	got := SelectNamespaces(selector, namespaceLabels, "source", NewNamespaceFilter([]string{"kube-system"}, nil))
+	assert.Equal(t, []string{"payments"}, got)
+	assert.Nil(t, SelectNamespaces(nil, namespaceLabels, "source", nil))
+}
+
+func TestDescendantNamespaces(t *testing.T) {
+	depth := func(ancestors map[string]string) map[string]string {
+		nsLabels := make(map[string]string, len(ancestors))
+		for ancestor, d := range ancestors {
+			nsLabels[ancestor+constants.HNCTreeDepthLabelSuffix] = d
+		}
+		return nsLabels
+	}
+	optedOut := depth(map[string]string{"org": "1", "opted-out": "0"})
+	optedOut[constants.LabelAllowMirrors] = "false"
+	namespaceLabels := map[string]map[string]string{
+		"org":        depth(map[string]string{"org": "0"}),
+		"team-a":     depth(map[string]string{"org": "1", "team-a": "0"}),
+		"team-a-dev": depth(map[string]string{"org": "2", "team-a": "1", "team-a-dev": "0"}),
+		"opted-out":  optedOut,
+		"excluded":   depth(map[string]string{"org": "1", "excluded": "0"}),
+		"other-org":  depth(map[string]string{"other-org": "0"}),
+		"invalid":    depth(map[string]string{"org": "deep"}),
+		"unlabeled":  nil,
+	}
+	nsFilter := NewNamespaceFilter([]string{"excluded"}, nil)
+
+	assert.Equal(t, []string{"team-a", "team-a-dev"}, DescendantNamespaces(namespaceLabels, "org", nsFilter),
+		"every level below the source namespace, not the namespace itself")
+	assert.Equal(t, []string{"team-a-dev"}, DescendantNamespaces(namespaceLabels, "team-a", nsFilter))
+	assert.Empty(t, DescendantNamespaces(namespaceLabels, "team-a-dev", nsFilter))
+	assert.Empty(t, DescendantNamespaces(namespaceLabels, "unlabeled", nil))
+}
+
+func TestNamespaceFilter_Update(t *testing.T) {
+	nf := NewNamespaceFilter([]string{"kube-system"}, nil)
+	assert.False(t, nf.IsAllowed("kube-system"))
+	assert.True(t, nf.IsAllowed("legacy"))
+
+	nf.Update([]string{"legacy"}, []string{"app-*", "legacy"})
+	assert.True(t, nf.IsAllowed("app-1"))
+	assert.False(t, nf.IsAllowed("kube-system"), "not included")
+	assert.False(t, nf.IsAllowed("legacy"), "exclusion wins over inclusion")
+}
+
+func TestNamespaceFilter_Restrict(t *testing.T) {
+	nf := NewNamespaceFilter([]string{"team-b"}, []string{"team-*"})
+	nf.Restrict([]string{"team-a", "team-b", "other"})
+	assert.True(t, nf.IsAllowed("team-a"))
+	assert.False(t, nf.IsAllowed("team-b"), "still excluded")
+	assert.False(t, nf.IsAllowed("other"), "still not included")
+	assert.False(t, nf.IsAllowed("team-c"), "not watched")
+
+	nf.Update(nil, nil)
+	assert.True(t, nf.IsAllowed("other"))
+	assert.False(t, nf.IsAllowed("team-c"), "restriction survives Update")
+
+	nf.Restrict(nil)
+	assert.True(t, nf.IsAllowed("team-c"))
+}
@@ -0,0 +1,77 @@
+package filter
+
+import "strings"
+
+// globMeta holds the characters that make a pattern more than a literal name.
+const globMeta = `*?[\`
+
+// patternSet is a list of namespace patterns compiled for repeated matching.
+// Names are looked up in a map and single-star prefix and suffix patterns
+// ("app-*", "*-prod") are checked with string operations; only the remaining
+// globs and regular expressions go through matchesPattern.
+type patternSet struct {
+	matchAll bool
+	exact    map[string]bool
+	prefixes []string
+	suffixes []string
+	complex  []string
+}
+
+// compilePatterns partitions patterns by how cheaply they can be matched.
+func compilePatterns(patterns []string) *patternSet {
+	s := &patternSet{exact: make(map[string]bool)}
+	for _, pattern := range patterns {
+		if pattern == "*" {
+			s.matchAll = true
+			continue
+		}
+		if IsRegexPattern(pattern) {
+			s.complex = append(s.complex, pattern)
+			continue
+		}
+		switch {
+		case isLiteral(pattern):
+			s.exact[pattern] = true
+		case isLiteral(strings.TrimSuffix(pattern, "*")):
+			s.prefixes = append(s.prefixes, strings.TrimSuffix(pattern, "*"))
+		case isLiteral(strings.TrimPrefix(pattern, "*")):
+			s.suffixes = append(s.suffixes, strings.TrimPrefix(pattern, "*"))
+		default:
+			s.complex = append(s.complex, pattern)
+		}
+	}
+	return s
+}
+
+// isLiteral reports whether s has no glob characters left.
+func isLiteral(s string) bool {
+	return !strings.ContainsAny(s, globMeta)
+}
+
+// empty reports whether the set has no patterns; a nil set is empty.
+func (s *patternSet) empty() bool {
+	return s == nil || !s.matchAll && len(s.exact) == 0 && len(s.prefixes) == 0 && len(s.suffixes) == 0 && len(s.complex) == 0
+}
+
+// matches reports whether namespace matches any pattern of the set.
+func (s *patternSet) matches(namespace string) bool {
+	if s.matchAll || s.exact[namespace] {
+		return true
+	}
+	for _, prefix := range s.prefixes {
+		if strings.HasPrefix(namespace, prefix) {
+			return true
+		}
+	}
+	for _, suffix := range s.suffixes {
+		if strings.HasSuffix(namespace, suffix) {
+			return true
+		}
+	}
+	for _, pattern := range s.complex {
+		if matchesPattern(namespace, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package filter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompilePatterns(t *testing.T) {
+	s := compilePatterns([]string{"legacy", "app-*", "*-prod", "stage-*-db", "team-?", "re:^ops-[0-9]+$", "qa-[ab]"})
+	assert.Equal(t, map[string]bool{"legacy": true}, s.exact)
+	assert.Equal(t, []string{"app-"}, s.prefixes)
+	assert.Equal(t, []string{"-prod"}, s.suffixes)
+	assert.Equal(t, []string{"stage-*-db", "team-?", "re:^ops-[0-9]+$", "qa-[ab]"}, s.complex)
+	assert.False(t, s.matchAll)
+
+	assert.True(t, compilePatterns([]string{"*"}).matchAll)
+	assert.True(t, compilePatterns(nil).empty())
+	assert.True(t, (*patternSet)(nil).empty())
+	assert.False(t, compilePatterns([]string{"*"}).empty())
+}
+
+func TestPatternSet_Matches(t *testing.T) {
+	patterns := []string{"legacy", "app-*", "*-prod", "stage-*-db", "team-?", "re:^ops-[0-9]+$", "qa-[ab]", "**"}
+	namespaces := []string{
+		"legacy", "legacy-2", "app-", "app-frontend", "my-app", "eu-prod", "prod",
+		"stage-eu-db", "stage-db", "team-a", "team-ab", "ops-12", "ops-x", "qa-a", "qa-c", "",
+	}
+
+	// Every namespace matches the compiled set exactly when it matches a pattern
+	for _, subset := range [][]string{patterns, patterns[:7], {"app-*"}, {"*-prod", "legacy"}} {
+		s := compilePatterns(subset)
+		for _, ns := range namespaces {
+			want := false
+			for _, pattern := range subset {
+				want = want || matchesPattern(ns, pattern)
+			}
+			assert.Equal(t, want, s.matches(ns), "patterns %v, namespace %q", subset, ns)
+		}
+	}
+}
+
+func BenchmarkNamespaceFilter_IsAllowed_LargeScale(b *testing.B) {
+	// Dozens of included patterns of every kind, matched against 1000+ namespaces
+	var included []string
+	for i := 0; i < 12; i++ {
+		included = append(included,
+			fmt.Sprintf("legacy-%d", i),
+			fmt.Sprintf("team-%d-*", i),
+			fmt.Sprintf("*-region-%d", i),
+			fmt.Sprintf("stage-%d-*-db", i),
+		)
+	}
+	namespaces := make([]string, 0, 1200)
+	for i := 0; i < 1200; i++ {
+		switch i % 4 {
+		case 0:
+			namespaces = append(namespaces, fmt.Sprintf("team-%d-app-%d", i%20, i))
+		case 1:
+			namespaces = append(namespaces, fmt.Sprintf("svc-%d-region-%d", i, i%20))
+		case 2:
+			namespaces = append(namespaces, fmt.Sprintf("stage-%d-api-%d-db", i%20, i))
+		default:
+			namespaces = append(namespaces, fmt.Sprintf("unrelated-%d", i))
+		}
+	}
+	filter := NewNamespaceFilter([]string{"kube-system", "kube-public"}, included)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, ns := range namespaces {
+			_ = filter.IsAllowed(ns)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// maxCachedRegexes bounds the compiled namespace regexes kept in memory; the
+// cache starts over once full.
+const maxCachedRegexes = 1024
+
+// regexCache holds compiled "re:" patterns, so resolving targets across many
+// namespaces and reconciles compiles each pattern once.
+var regexCache = struct {
+	sync.RWMutex
+	compiled map[string]*regexp.Regexp
+}{compiled: make(map[string]*regexp.Regexp)}
+
+// IsRegexPattern reports whether pattern is a "re:"-prefixed regular expression.
+func IsRegexPattern(pattern string) bool {
+	return strings.HasPrefix(pattern, constants.NamespaceRegexPrefix)
+}
+
+// CompileRegexPattern compiles a "re:"-prefixed pattern, from the cache when it
+// was compiled before. The expression is not anchored; use ^ and $ to match whole
+// namespace names.
+func CompileRegexPattern(pattern string) (*regexp.Regexp, error) {
+	regexCache.RLock()
+	re, found := regexCache.compiled[pattern]
+	regexCache.RUnlock()
+	if found {
+		return re, nil
+	}
+
+	expr := strings.TrimPrefix(pattern, constants.NamespaceRegexPrefix)
+	if expr == "" {
+		return nil, fmt.Errorf("empty regular expression")
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %w", expr, err)
+	}
+
+	regexCache.Lock()
+	if len(regexCache.compiled) >= maxCachedRegexes {
+		regexCache.compiled = make(map[string]*regexp.Regexp)
+	}
+	regexCache.compiled[pattern] = re
+	regexCache.Unlock()
+	return re, nil
+}
+
+// matchesRegex reports whether namespace matches a "re:" pattern; invalid
+// expressions match nothing.
+func matchesRegex(namespace, pattern string) bool {
+	re, err := CompileRegexPattern(pattern)
+	return err == nil && re.MatchString(namespace)
+}
@@ -0,0 +1,36 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileRegexPattern(t *testing.T) {
+	re, err := CompileRegexPattern("re:^team-(a|b)-prod$")
+	require.NoError(t, err)
+	assert.True(t, re.MatchString("team-a-prod"))
+	assert.False(t, re.MatchString("team-c-prod"))
+
+	again, err := CompileRegexPattern("re:^team-(a|b)-prod$")
+	require.NoError(t, err)
+	assert.Same(t, re, again, "compiled patterns are cached")
+
+	_, err = CompileRegexPattern("re:")
+	assert.ErrorContains(t, err, "empty regular expression")
+	_, err = CompileRegexPattern("re:team-(")
+	assert.ErrorContains(t, err, `invalid regular expression "team-("`)
+}
+
+func TestIsRegexPattern(t *testing.T) {
+	assert.True(t, IsRegexPattern("re:^prod$"))
+	assert.False(t, IsRegexPattern("prod-*"))
+	assert.False(t, IsRegexPattern("!re:^prod$"), "exclusions are split off first")
+}
+
+func TestMatchesPattern_Regex(t *testing.T) {
+	assert.True(t, matchesPattern("team-b-prod", "re:^team-(a|b)-prod$"))
+	assert.True(t, matchesPattern("my-team-a-prod-2", "re:team-a-prod"), "regular expressions are not anchored")
+	assert.False(t, matchesPattern("team-a", "re:team-("), "invalid expressions match nothing")
+}
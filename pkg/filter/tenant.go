@@ -0,0 +1,56 @@
+package filter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// TenantPattern returns the tenant a "tenant:"-prefixed target-namespaces entry
+// names, and whether pattern is one.
+func TenantPattern(pattern string) (tenant string, ok bool) {
+	return strings.CutPrefix(pattern, constants.TargetNamespacesTenantPrefix)
+}
+
+// ValidateTenant checks that a tenant name can be the value of the tenant label,
+// as otherwise no namespace can ever belong to it.
+func ValidateTenant(tenant string) error {
+	if tenant == "" {
+		return fmt.Errorf("empty tenant name")
+	}
+	if errs := validation.IsValidLabelValue(tenant); len(errs) > 0 {
+		return fmt.Errorf("invalid tenant name %q: %s", tenant, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// TenantNamespaces returns the namespaces of tenant, those labeled
+// kubemirror.raczylo.com/tenant=<tenant>, sorted. tenants indexes namespace names
+// by tenant, as namespace listers keep it, so the lookup does not scan every
+// namespace. Like "all", it skips the source namespace, namespaces that
+// opted out with allow-mirrors="false" and namespaces rejected by filter (nil
+// allows all).
+func TenantNamespaces(
+	tenant string,
+	tenants map[string][]string,
+	namespaceLabels map[string]map[string]string,
+	sourceNamespace string,
+	filter *NamespaceFilter,
+) []string {
+	var result []string
+	for _, ns := range tenants[tenant] {
+		if ns == sourceNamespace || namespaceLabels[ns][constants.LabelAllowMirrors] == "false" {
+			continue
+		}
+		if filter != nil && !filter.IsAllowed(ns) {
+			continue
+		}
+		result = append(result, ns)
+	}
+	slices.Sort(result)
+	return result
+}
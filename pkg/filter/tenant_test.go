@@ -0,0 +1,40 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func TestTenantPattern(t *testing.T) {
+	tenant, ok := TenantPattern("tenant:payments")
+	assert.True(t, ok)
+	assert.Equal(t, "payments", tenant)
+
+	_, ok = TenantPattern("payments")
+	assert.False(t, ok)
+}
+
+func TestTenantNamespaces(t *testing.T) {
+	tenants := map[string][]string{
+		"payments": {"payments-prod", "payments-dev", "payments-legacy", "kube-system", "payments-home"},
+		"shop":     {"shop-prod"},
+	}
+	namespaceLabels := map[string]map[string]string{
+		"payments-prod":   {constants.LabelTenant: "payments"},
+		"payments-dev":    {constants.LabelTenant: "payments"},
+		"payments-legacy": {constants.LabelTenant: "payments", constants.LabelAllowMirrors: "false"},
+		"kube-system":     {constants.LabelTenant: "payments"},
+		"payments-home":   {constants.LabelTenant: "payments"},
+		"shop-prod":       {constants.LabelTenant: "shop"},
+	}
+	nsFilter := NewNamespaceFilter([]string{"kube-system"}, nil)
+
+	got := TenantNamespaces("payments", tenants, namespaceLabels, "payments-home", nsFilter)
+	assert.Equal(t, []string{"payments-dev", "payments-prod"}, got,
+		"skips the source namespace, opted-out and filtered namespaces")
+	assert.Equal(t, []string{"shop-prod"}, TenantNamespaces("shop", tenants, namespaceLabels, "default", nil))
+	assert.Empty(t, TenantNamespaces("unknown", tenants, namespaceLabels, "default", nil))
+}
@@ -8,10 +8,14 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/keyfilter"
 )
 
 // ComputeContentHash computes a SHA256 hash of the resource's actual content.
@@ -37,12 +41,37 @@ func ComputeContentHash(obj runtime.Object) (string, error) {
 // extractContent extracts only the content fields from a resource.
 // Excludes all metadata except name, namespace, labels, and annotations we care about.
 func extractContent(obj runtime.Object) (interface{}, error) {
+	content, err := extractTypeContent(obj)
+	if err != nil {
+		return nil, err
+	}
+	if metaObj, ok := obj.(metav1.Object); ok {
+		if m, ok := content.(map[string]interface{}); ok {
+			withPropagation(m, metaObj.GetLabels(), metaObj.GetAnnotations())
+		}
+	}
+	return content, nil
+}
+
+// extractTypeContent extracts the content fields of obj by its type.
+func extractTypeContent(obj runtime.Object) (interface{}, error) {
 	// Try typed resources first
 	switch resource := obj.(type) {
 	case *corev1.Secret:
 		return extractSecretContent(resource), nil
 	case *corev1.ConfigMap:
 		return extractConfigMapContent(resource), nil
+	case *corev1.ServiceAccount:
+		return extractServiceAccountContent(resource), nil
+	case *rbacv1.Role:
+		return withTransform(map[string]interface{}{"rules": resource.Rules}, resource.Annotations), nil
+	case *rbacv1.RoleBinding:
+		return withTransform(map[string]interface{}{
+			"subjects": resource.Subjects,
+			"roleRef":  resource.RoleRef,
+		}, resource.Annotations), nil
+	case *networkingv1.NetworkPolicy:
+		return withTransform(map[string]interface{}{"spec": resource.Spec}, resource.Annotations), nil
 	default:
 		// Fall back to unstructured for CRDs and unknown types
 		return extractUnstructuredContent(obj)
@@ -51,10 +80,11 @@ func extractContent(obj runtime.Object) (interface{}, error) {
 
 // extractSecretContent extracts content from a Secret.
 func extractSecretContent(secret *corev1.Secret) map[string]interface{} {
+	keys := keyfilter.FromAnnotations(secret.Annotations)
 	content := map[string]interface{}{
 		"type":       string(secret.Type),
-		"data":       secret.Data,
-		"stringData": secret.StringData,
+		"data":       keyfilter.Keys(keys, secret.Data),
+		"stringData": keyfilter.Keys(keys, secret.StringData),
 	}
 
 	// Include transform annotation in hash so changes to transformation rules trigger updates
@@ -69,9 +99,10 @@ func extractSecretContent(secret *corev1.Secret) map[string]interface{} {
 
 // extractConfigMapContent extracts content from a ConfigMap.
 func extractConfigMapContent(cm *corev1.ConfigMap) map[string]interface{} {
+	keys := keyfilter.FromAnnotations(cm.Annotations)
 	content := map[string]interface{}{
-		"data":       cm.Data,
-		"binaryData": cm.BinaryData,
+		"data":       keyfilter.Keys(keys, cm.Data),
+		"binaryData": keyfilter.Keys(keys, cm.BinaryData),
 	}
 
 	// Include transform annotation in hash so changes to transformation rules trigger updates
@@ -84,6 +115,43 @@ func extractConfigMapContent(cm *corev1.ConfigMap) map[string]interface{} {
 	return content
 }
 
+// extractServiceAccountContent extracts content from a ServiceAccount. secrets is
+// left out: it is never mirrored and changes with token Secrets.
+func extractServiceAccountContent(sa *corev1.ServiceAccount) map[string]interface{} {
+	content := map[string]interface{}{
+		"automountServiceAccountToken": sa.AutomountServiceAccountToken,
+		"imagePullSecrets":             sa.ImagePullSecrets,
+	}
+
+	// Toggling imagePullSecrets mirroring changes ServiceAccount mirrors
+	if pullSecrets, exists := sa.Annotations[constants.AnnotationMirrorImagePullSecrets]; exists {
+		content["mirrorImagePullSecrets"] = pullSecrets
+	}
+
+	return withTransform(content, sa.Annotations)
+}
+
+// withTransform adds the transform annotation to content, so changes to
+// transformation rules trigger updates.
+func withTransform(content map[string]interface{}, annotations map[string]string) map[string]interface{} {
+	if transform, exists := annotations[constants.AnnotationTransform]; exists {
+		content["transform"] = transform
+	}
+	return content
+}
+
+// withPropagation adds the labels and annotations a source selects for its
+// mirrors, so changing them re-syncs the mirrors. Sources selecting none keep
+// their metadata out of the hash.
+func withPropagation(content map[string]interface{}, labels, annotations map[string]string) {
+	if patterns, exists := annotations[constants.AnnotationPropagateLabels]; exists {
+		content["propagateLabels"] = keyfilter.Metadata(patterns, labels)
+	}
+	if patterns, exists := annotations[constants.AnnotationPropagateAnnotations]; exists {
+		content["propagateAnnotations"] = keyfilter.Metadata(patterns, annotations)
+	}
+}
+
 // extractUnstructuredContent extracts content from an unstructured resource (CRDs, etc.).
 func extractUnstructuredContent(obj runtime.Object) (interface{}, error) {
 	// Convert to unstructured
@@ -98,6 +166,9 @@ func extractUnstructuredContent(obj runtime.Object) (interface{}, error) {
 	// NestedMap modifies the underlying map, so we need our own copy
 	uCopy := u.DeepCopy()
 
+	// Keys left out of mirrors must not trigger syncs
+	keyfilter.Apply(uCopy)
+
 	// Extract spec (most resources have spec)
 	spec, found, err := unstructured.NestedMap(uCopy.Object, "spec")
 	if err != nil {
@@ -124,6 +195,18 @@ func extractUnstructuredContent(obj runtime.Object) (interface{}, error) {
 		if transform, exists := annotations[constants.AnnotationTransform]; exists {
 			content["transform"] = transform
 		}
+		// Toggling imagePullSecrets mirroring changes ServiceAccount mirrors
+		if pullSecrets, exists := annotations[constants.AnnotationMirrorImagePullSecrets]; exists {
+			content["mirrorImagePullSecrets"] = pullSecrets
+		}
+		// Attaching mirrors to other ServiceAccounts is recorded on every mirror
+		if serviceAccounts, exists := annotations[constants.AnnotationAttachToServiceAccounts]; exists {
+			content["attachToServiceAccounts"] = serviceAccounts
+		}
+		// Bumping force-sync rewrites every mirror, repairing manual edits in targets
+		if forceSync, exists := annotations[constants.AnnotationForceSync]; exists {
+			content["forceSync"] = forceSync
+		}
 	}
 
 	return content, nil
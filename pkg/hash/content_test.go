@@ -1,12 +1,15 @@
 package hash
 
 import (
+	"maps"
 	"testing"
 
 	"github.com/lukaszraczylo/kubemirror/pkg/constants"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -275,6 +278,53 @@ func TestComputeContentHash_Unstructured(t *testing.T) {
 			wantSame:  false,
 			wantError: false,
 		},
+		{
+			name: "image pull secrets annotation included in hash",
+			obj1: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind":             "ServiceAccount",
+					"imagePullSecrets": []interface{}{map[string]interface{}{"name": "registry"}},
+				},
+			},
+			obj2: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": "ServiceAccount",
+					"metadata": map[string]interface{}{
+						"annotations": map[string]interface{}{
+							constants.AnnotationMirrorImagePullSecrets: "true",
+						},
+					},
+					"imagePullSecrets": []interface{}{map[string]interface{}{"name": "registry"}},
+				},
+			},
+			wantSame:  false,
+			wantError: false,
+		},
+		{
+			name: "force-sync value changes hash",
+			obj1: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"annotations": map[string]interface{}{
+							constants.AnnotationForceSync: "2026-01-01T00:00:00Z",
+						},
+					},
+					"data": map[string]interface{}{"key": "value"},
+				},
+			},
+			obj2: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"annotations": map[string]interface{}{
+							constants.AnnotationForceSync: "2026-01-02T00:00:00Z",
+						},
+					},
+					"data": map[string]interface{}{"key": "value"},
+				},
+			},
+			wantSame:  false,
+			wantError: false,
+		},
 		{
 			name: "metadata excluded from hash",
 			obj1: &unstructured.Unstructured{
@@ -641,3 +691,167 @@ func BenchmarkNeedsSync(b *testing.B) {
 		_, _ = NeedsSync(source, target, annotations)
 	}
 }
+
+func TestComputeContentHash_FilteredKeys(t *testing.T) {
+	secret := func(annotations map[string]string, key string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"type":       "kubernetes.io/tls",
+			"data":       map[string]interface{}{"tls.crt": "Y3J0", "tls.key": key},
+		}}
+		u.SetAnnotations(annotations)
+		return u
+	}
+	excluded := map[string]string{constants.AnnotationExcludeKeys: "tls.key"}
+
+	before, err := ComputeContentHash(secret(excluded, "a2V5"))
+	require.NoError(t, err)
+	after, err := ComputeContentHash(secret(excluded, "cm90YXRlZA=="))
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "excluded keys do not change the hash")
+
+	unfiltered, err := ComputeContentHash(secret(nil, "a2V5"))
+	require.NoError(t, err)
+	assert.NotEqual(t, before, unfiltered)
+
+	typed := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{constants.AnnotationIncludeKeys: "app.yaml"}},
+		Data:       map[string]string{"app.yaml": "a: 1", "debug.yaml": "b: 1"},
+	}
+	before, err = ComputeContentHash(typed)
+	require.NoError(t, err)
+	typed.Data["debug.yaml"] = "b: 2"
+	after, err = ComputeContentHash(typed)
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "keys outside include-keys do not change the hash")
+}
+
+func TestComputeContentHash_PropagatedMetadata(t *testing.T) {
+	configMap := func(labels, annotations map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"data":       map[string]interface{}{"key": "value"},
+		}}
+		u.SetLabels(labels)
+		u.SetAnnotations(annotations)
+		return u
+	}
+	hashOf := func(u *unstructured.Unstructured) string {
+		h, err := ComputeContentHash(u)
+		require.NoError(t, err)
+		return h
+	}
+
+	unselected := hashOf(configMap(map[string]string{"team": "a"}, nil))
+	assert.Equal(t, unselected, hashOf(configMap(map[string]string{"team": "b"}, nil)),
+		"labels are left out unless the source propagates them")
+
+	propagate := map[string]string{
+		constants.AnnotationPropagateLabels:      "team",
+		constants.AnnotationPropagateAnnotations: "prometheus.io/*",
+	}
+	selected := hashOf(configMap(map[string]string{"team": "a", "tier": "x"}, propagate))
+	assert.NotEqual(t, selected, hashOf(configMap(map[string]string{"team": "b", "tier": "x"}, propagate)),
+		"a propagated label changes the hash")
+	assert.Equal(t, selected, hashOf(configMap(map[string]string{"team": "a", "tier": "y"}, propagate)),
+		"labels outside the selection do not")
+
+	withPort := maps.Clone(propagate)
+	withPort["prometheus.io/port"] = "9090"
+	assert.NotEqual(t, selected, hashOf(configMap(map[string]string{"team": "a", "tier": "x"}, withPort)),
+		"a propagated annotation changes the hash")
+
+	withHash := map[string]string{
+		constants.AnnotationPropagateAnnotations: "*",
+		constants.AnnotationContentHash:          "recorded",
+	}
+	withOtherHash := maps.Clone(withHash)
+	withOtherHash[constants.AnnotationContentHash] = "rewritten"
+	assert.Equal(t, hashOf(configMap(nil, withHash)), hashOf(configMap(nil, withOtherHash)),
+		"kubemirror's own annotations never change the hash")
+}
+
+func TestComputeContentHash_TypedFastPaths(t *testing.T) {
+	automount := true
+	tests := []struct {
+		obj1     runtime.Object
+		obj2     runtime.Object
+		name     string
+		wantSame bool
+	}{
+		{
+			name: "ServiceAccount secrets don't affect hash",
+			obj1: &corev1.ServiceAccount{
+				Secrets: []corev1.ObjectReference{{Name: "token-a"}},
+			},
+			obj2: &corev1.ServiceAccount{
+				Secrets: []corev1.ObjectReference{{Name: "token-b"}},
+			},
+			wantSame: true,
+		},
+		{
+			name:     "ServiceAccount automount changes hash",
+			obj1:     &corev1.ServiceAccount{},
+			obj2:     &corev1.ServiceAccount{AutomountServiceAccountToken: &automount},
+			wantSame: false,
+		},
+		{
+			name: "ServiceAccount imagePullSecrets opt-in changes hash",
+			obj1: &corev1.ServiceAccount{},
+			obj2: &corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{constants.AnnotationMirrorImagePullSecrets: "true"},
+				},
+			},
+			wantSame: false,
+		},
+		{
+			name: "Role rules change hash",
+			obj1: &rbacv1.Role{Rules: []rbacv1.PolicyRule{{Verbs: []string{"get"}}}},
+			obj2: &rbacv1.Role{Rules: []rbacv1.PolicyRule{{Verbs: []string{"list"}}}},
+		},
+		{
+			name: "Role metadata doesn't affect hash",
+			obj1: &rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{ResourceVersion: "1"},
+				Rules:      []rbacv1.PolicyRule{{Verbs: []string{"get"}}},
+			},
+			obj2: &rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{ResourceVersion: "2"},
+				Rules:      []rbacv1.PolicyRule{{Verbs: []string{"get"}}},
+			},
+			wantSame: true,
+		},
+		{
+			name: "RoleBinding roleRef changes hash",
+			obj1: &rbacv1.RoleBinding{RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"}},
+			obj2: &rbacv1.RoleBinding{RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "edit"}},
+		},
+		{
+			name: "NetworkPolicy transform annotation changes hash",
+			obj1: &networkingv1.NetworkPolicy{},
+			obj2: &networkingv1.NetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{constants.AnnotationTransform: "rules: []"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash1, err := ComputeContentHash(tt.obj1)
+			require.NoError(t, err)
+			hash2, err := ComputeContentHash(tt.obj2)
+			require.NoError(t, err)
+
+			if tt.wantSame {
+				assert.Equal(t, hash1, hash2)
+			} else {
+				assert.NotEqual(t, hash1, hash2)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// FailureTracker counts consecutive reconcile failures per controller. Unlike the
+// startup gates it can turn unready again: a controller whose reconciles keep
+// failing fails the readiness check until one of them succeeds.
+type FailureTracker struct {
+	threshold int
+	failures  map[string]int
+	lastErr   map[string]string
+	mu        sync.Mutex
+}
+
+// NewFailureTracker creates a FailureTracker that fails readiness once a controller
+// reaches threshold consecutive failures (0 never fails).
+func NewFailureTracker(threshold int) *FailureTracker {
+	return &FailureTracker{
+		threshold: threshold,
+		failures:  make(map[string]int),
+		lastErr:   make(map[string]string),
+	}
+}
+
+// ObserveReconcile records the outcome of a reconcile of controller; a success
+// resets its count.
+func (t *FailureTracker) ObserveReconcile(controller string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err == nil {
+		delete(t.failures, controller)
+		delete(t.lastErr, controller)
+		return
+	}
+	t.failures[controller]++
+	t.lastErr[controller] = err.Error()
+}
+
+// Failing returns the controllers at or above the threshold, sorted by name.
+func (t *FailureTracker) Failing() []string {
+	if t.threshold <= 0 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var failing []string
+	for controller, count := range t.failures {
+		if count >= t.threshold {
+			failing = append(failing, controller)
+		}
+	}
+	slices.Sort(failing)
+	return failing
+}
+
+// Checker returns a healthz.Checker that fails while any controller is failing.
+func (t *FailureTracker) Checker() healthz.Checker {
+	return func(_ *http.Request) error {
+		failing := t.Failing()
+		if len(failing) == 0 {
+			return nil
+		}
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		details := make([]string, 0, len(failing))
+		for _, controller := range failing {
+			details = append(details, fmt.Sprintf("%s (%d consecutive failures, last: %s)",
+				controller, t.failures[controller], t.lastErr[controller]))
+		}
+		return fmt.Errorf("reconciles failing: %s", strings.Join(details, "; "))
+	}
+}
@@ -0,0 +1,42 @@
+package health
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailureTracker(t *testing.T) {
+	tracker := NewFailureTracker(3)
+	check := tracker.Checker()
+	failure := errors.New("conflict")
+
+	tracker.ObserveReconcile("Secret.v1.", failure)
+	tracker.ObserveReconcile("Secret.v1.", failure)
+	tracker.ObserveReconcile("ConfigMap.v1.", failure)
+	assert.NoError(t, check(&http.Request{}), "below the threshold")
+
+	tracker.ObserveReconcile("Secret.v1.", failure)
+	assert.Equal(t, []string{"Secret.v1."}, tracker.Failing())
+	err := check(&http.Request{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Secret.v1. (3 consecutive failures, last: conflict)")
+
+	tracker.ObserveReconcile("Secret.v1.", nil)
+	assert.NoError(t, check(&http.Request{}), "a success resets the count")
+
+	tracker.ObserveReconcile("Secret.v1.", failure)
+	assert.Empty(t, tracker.Failing(), "counting starts over")
+}
+
+func TestFailureTracker_Disabled(t *testing.T) {
+	tracker := NewFailureTracker(0)
+	for range 10 {
+		tracker.ObserveReconcile("Secret.v1.", errors.New("conflict"))
+	}
+	assert.Empty(t, tracker.Failing())
+	assert.NoError(t, tracker.Checker()(&http.Request{}))
+}
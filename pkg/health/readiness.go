@@ -0,0 +1,175 @@
+// Package health provides readiness gating for the kubemirror controller.
+//
+// A pod only reports ready once every startup condition has been met, so
+// rollouts don't cut over to a replica that hasn't synced its caches, settled
+// leader election or registered its controllers yet.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// Gate is a startup condition that becomes ready exactly once.
+type Gate struct {
+	name  string
+	ready atomic.Bool
+}
+
+// Name returns the gate name reported while it is pending.
+func (g *Gate) Name() string {
+	return g.name
+}
+
+// MarkReady marks the condition as met. Subsequent calls are no-ops.
+func (g *Gate) MarkReady() {
+	g.ready.Store(true)
+}
+
+// IsReady reports whether the condition has been met.
+func (g *Gate) IsReady() bool {
+	return g.ready.Load()
+}
+
+// Readiness aggregates startup gates into a single readiness check.
+type Readiness struct {
+	gates []*Gate
+	mu    sync.RWMutex
+}
+
+// NewReadiness creates an empty Readiness.
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// Gate registers a new pending gate.
+func (r *Readiness) Gate(name string) *Gate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g := &Gate{name: name}
+	r.gates = append(r.gates, g)
+	return g
+}
+
+// Pending returns the names of gates that are not ready yet.
+func (r *Readiness) Pending() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var pending []string
+	for _, g := range r.gates {
+		if !g.IsReady() {
+			pending = append(pending, g.name)
+		}
+	}
+	return pending
+}
+
+// Checker returns a healthz.Checker that fails until every gate is ready.
+func (r *Readiness) Checker() healthz.Checker {
+	return func(_ *http.Request) error {
+		if pending := r.Pending(); len(pending) > 0 {
+			return fmt.Errorf("waiting for: %s", strings.Join(pending, ", "))
+		}
+		return nil
+	}
+}
+
+// CacheSyncRunnable returns a manager runnable that marks gate ready once the
+// informer caches have synced. It runs on every replica, leader or not.
+func CacheSyncRunnable(c cache.Cache, gate *Gate) manager.Runnable {
+	return &gateRunnable{run: func(ctx context.Context) {
+		if c.WaitForCacheSync(ctx) {
+			gate.MarkReady()
+		}
+	}}
+}
+
+// LeaderElectionRunnable returns a manager runnable that marks gate ready once
+// leadership has settled: either this replica was elected, or the lease is held
+// and actively renewed by another replica. Standby replicas must still become
+// ready, otherwise a rolling update would wait forever for a pod that can only
+// lead after the old leader is gone. An empty lease name only waits for election.
+func LeaderElectionRunnable(elected <-chan struct{}, reader client.Reader, lease types.NamespacedName,
+	leaseDuration, interval time.Duration, gate *Gate) manager.Runnable {
+	return &gateRunnable{run: func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-elected:
+				gate.MarkReady()
+				return
+			case <-ticker.C:
+				if lease.Name != "" && leaseHeld(ctx, reader, lease, leaseDuration) {
+					gate.MarkReady()
+					return
+				}
+			}
+		}
+	}}
+}
+
+// leaseHeld reports whether the lease has a holder that renewed it recently. The
+// lease is either a Lease or, with ConfigMap locks, the leader election record
+// annotation of a ConfigMap.
+func leaseHeld(ctx context.Context, reader client.Reader, key types.NamespacedName, leaseDuration time.Duration) bool {
+	lease := &coordinationv1.Lease{}
+	if err := reader.Get(ctx, key, lease); err != nil {
+		return configMapLockHeld(ctx, reader, key, leaseDuration)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" || lease.Spec.RenewTime == nil {
+		return false
+	}
+	return time.Since(lease.Spec.RenewTime.Time) < leaseDuration
+}
+
+// configMapLockHeld reports whether the ConfigMap lock has a holder that renewed it recently.
+func configMapLockHeld(ctx context.Context, reader client.Reader, key types.NamespacedName, leaseDuration time.Duration) bool {
+	cm := &corev1.ConfigMap{}
+	if err := reader.Get(ctx, key, cm); err != nil {
+		return false
+	}
+	data, ok := cm.Annotations[resourcelock.LeaderElectionRecordAnnotationKey]
+	if !ok {
+		return false
+	}
+	var record resourcelock.LeaderElectionRecord
+	if err := json.Unmarshal([]byte(data), &record); err != nil || record.HolderIdentity == "" {
+		return false
+	}
+	return time.Since(record.RenewTime.Time) < leaseDuration
+}
+
+// gateRunnable runs a gate check on every replica, independent of leader election.
+type gateRunnable struct {
+	run func(ctx context.Context)
+}
+
+// Start implements manager.Runnable.
+func (g *gateRunnable) Start(ctx context.Context) error {
+	g.run(ctx)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (g *gateRunnable) NeedLeaderElection() bool {
+	return false
+}
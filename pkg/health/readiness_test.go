@@ -0,0 +1,129 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+func TestReadiness(t *testing.T) {
+	r := NewReadiness()
+	check := r.Checker()
+	req := &http.Request{}
+
+	// No gates means ready
+	assert.NoError(t, check(req))
+
+	registration := r.Gate("controller-registration")
+	cacheSync := r.Gate("cache-sync")
+	assert.Equal(t, "cache-sync", cacheSync.Name())
+
+	err := check(req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "controller-registration")
+	assert.Contains(t, err.Error(), "cache-sync")
+
+	registration.MarkReady()
+	assert.Equal(t, []string{"cache-sync"}, r.Pending())
+
+	cacheSync.MarkReady()
+	cacheSync.MarkReady()
+	assert.NoError(t, check(req))
+	assert.Empty(t, r.Pending())
+}
+
+func TestCacheSyncRunnable(t *testing.T) {
+	gate := NewReadiness().Gate("cache-sync")
+	runnable := CacheSyncRunnable(&informertest.FakeInformers{Synced: ptr(true)}, gate)
+	assert.False(t, runnable.(manager.LeaderElectionRunnable).NeedLeaderElection(), "must run on standby replicas too")
+
+	// Synced cache marks the gate ready
+	require.NoError(t, runnable.Start(context.Background()))
+	assert.True(t, gate.IsReady())
+
+	// Unsynced cache leaves the gate pending
+	pending := NewReadiness().Gate("cache-sync")
+	unsynced := &informertest.FakeInformers{Synced: ptr(false)}
+	require.NoError(t, CacheSyncRunnable(unsynced, pending).Start(context.Background()))
+	assert.False(t, pending.IsReady())
+}
+
+func makeLease(holder string, renewed time.Time) *coordinationv1.Lease {
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kubemirror-system", Name: "leader"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity: ptr(holder),
+			RenewTime:      &metav1.MicroTime{Time: renewed},
+		},
+	}
+}
+
+func TestLeaderElectionRunnable(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, coordinationv1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	key := types.NamespacedName{Namespace: "kubemirror-system", Name: "leader"}
+
+	t.Run("elected", func(t *testing.T) {
+		elected := make(chan struct{})
+		close(elected)
+		gate := NewReadiness().Gate("leader-election")
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+		require.NoError(t, LeaderElectionRunnable(elected, c, key, time.Minute, time.Hour, gate).Start(context.Background()))
+		assert.True(t, gate.IsReady())
+	})
+
+	t.Run("standby sees active leader", func(t *testing.T) {
+		gate := NewReadiness().Gate("leader-election")
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(makeLease("other-pod", time.Now())).Build()
+
+		require.NoError(t, LeaderElectionRunnable(make(chan struct{}), c, key, time.Minute, 10*time.Millisecond, gate).
+			Start(context.Background()))
+		assert.True(t, gate.IsReady())
+	})
+
+	t.Run("standby sees active configmap lock", func(t *testing.T) {
+		gate := NewReadiness().Gate("leader-election")
+		record := fmt.Sprintf(`{"holderIdentity":"other-pod","renewTime":%q}`, time.Now().UTC().Format(time.RFC3339))
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "kubemirror-system",
+			Name:        "leader",
+			Annotations: map[string]string{resourcelock.LeaderElectionRecordAnnotationKey: record},
+		}}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cm).Build()
+
+		require.NoError(t, LeaderElectionRunnable(make(chan struct{}), c, key, time.Minute, 10*time.Millisecond, gate).
+			Start(context.Background()))
+		assert.True(t, gate.IsReady())
+	})
+
+	t.Run("expired lease keeps waiting", func(t *testing.T) {
+		gate := NewReadiness().Gate("leader-election")
+		c := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(makeLease("other-pod", time.Now().Add(-time.Hour))).Build()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		require.NoError(t, LeaderElectionRunnable(make(chan struct{}), c, key, time.Minute, 10*time.Millisecond, gate).Start(ctx))
+		assert.False(t, gate.IsReady())
+	})
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
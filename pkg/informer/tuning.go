@@ -0,0 +1,72 @@
+// Package informer tunes how the manager cache lists and watches resources.
+//
+// On clusters with tens of thousands of objects the client-go defaults can make
+// initial lists take minutes and spike memory while the full response is held.
+// Tuning wraps the list/watch calls of every informer to apply page sizes and
+// watch timeouts that suit large clusters.
+package informer
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// Tuning holds list/watch settings applied to every informer.
+type Tuning struct {
+	// ListPageSize is the number of objects requested per paginated LIST call (0 = client-go default).
+	// It applies whenever the API server serves the initial list or a relist with pagination.
+	ListPageSize int64
+	// WatchTimeout is how long each watch request stays open before it is re-established
+	// (0 = client-go default of 5-10 minutes). The API server sends a bookmark before closing
+	// a watch, so shorter timeouts mean more frequent bookmarks and cheaper resumes.
+	WatchTimeout time.Duration
+}
+
+// NewInformerFunc returns a constructor for cache.Options.NewInformer that
+// applies the tuning to each informer's list/watch calls.
+func (t Tuning) NewInformerFunc() func(toolscache.ListerWatcher, runtime.Object, time.Duration, toolscache.Indexers) toolscache.SharedIndexInformer {
+	return func(lw toolscache.ListerWatcher, obj runtime.Object, resync time.Duration, indexers toolscache.Indexers) toolscache.SharedIndexInformer {
+		return toolscache.NewSharedIndexInformer(t.Wrap(lw), obj, resync, indexers)
+	}
+}
+
+// Wrap returns a ListerWatcher that applies the tuning before delegating to lw.
+func (t Tuning) Wrap(lw toolscache.ListerWatcher) toolscache.ListerWatcher {
+	inner := toolscache.ToListerWatcherWithContext(lw)
+
+	return &toolscache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+			t.applyToList(&opts)
+			return inner.ListWithContext(ctx, opts)
+		},
+		WatchFuncWithContext: func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+			t.applyToWatch(&opts)
+			return inner.WatchWithContext(ctx, opts)
+		},
+	}
+}
+
+// applyToList sets the page size on LIST requests.
+func (t Tuning) applyToList(opts *metav1.ListOptions) {
+	if t.ListPageSize > 0 {
+		opts.Limit = t.ListPageSize
+	}
+}
+
+// applyToWatch sets the server-side timeout on WATCH requests.
+// Streaming watch-list requests (initial list over a watch) keep their own timeout.
+func (t Tuning) applyToWatch(opts *metav1.ListOptions) {
+	if t.WatchTimeout <= 0 || (opts.SendInitialEvents != nil && *opts.SendInitialEvents) {
+		return
+	}
+	seconds := int64(t.WatchTimeout / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	opts.TimeoutSeconds = &seconds
+}
@@ -0,0 +1,81 @@
+package informer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// recordingListWatch captures the options passed to list and watch calls.
+func recordingListWatch(listOpts, watchOpts *metav1.ListOptions) *toolscache.ListWatch {
+	return &toolscache.ListWatch{
+		ListWithContextFunc: func(_ context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+			*listOpts = opts
+			return &corev1.SecretList{}, nil
+		},
+		WatchFuncWithContext: func(_ context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+			*watchOpts = opts
+			return watch.NewEmptyWatch(), nil
+		},
+	}
+}
+
+func TestTuningWrap(t *testing.T) {
+	var listOpts, watchOpts metav1.ListOptions
+	tuning := Tuning{ListPageSize: 250, WatchTimeout: 90 * time.Second}
+	lw := toolscache.ToListerWatcherWithContext(tuning.Wrap(recordingListWatch(&listOpts, &watchOpts)))
+
+	_, err := lw.ListWithContext(context.Background(), metav1.ListOptions{Limit: 500, LabelSelector: "a=b"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(250), listOpts.Limit)
+	assert.Equal(t, "a=b", listOpts.LabelSelector, "other options pass through")
+
+	_, err = lw.WatchWithContext(context.Background(), metav1.ListOptions{AllowWatchBookmarks: true})
+	require.NoError(t, err)
+	require.NotNil(t, watchOpts.TimeoutSeconds)
+	assert.Equal(t, int64(90), *watchOpts.TimeoutSeconds)
+	assert.True(t, watchOpts.AllowWatchBookmarks)
+
+	// Streaming watch-list requests keep their own timeout
+	sendInitial := true
+	original := int64(600)
+	_, err = lw.WatchWithContext(context.Background(), metav1.ListOptions{SendInitialEvents: &sendInitial, TimeoutSeconds: &original})
+	require.NoError(t, err)
+	assert.Equal(t, int64(600), *watchOpts.TimeoutSeconds)
+}
+
+func TestTuningDefaultsPassThrough(t *testing.T) {
+	var listOpts, watchOpts metav1.ListOptions
+	tuning := Tuning{}
+	lw := toolscache.ToListerWatcherWithContext(tuning.Wrap(recordingListWatch(&listOpts, &watchOpts)))
+
+	_, err := lw.ListWithContext(context.Background(), metav1.ListOptions{Limit: 500})
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), listOpts.Limit)
+
+	_, err = lw.WatchWithContext(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, watchOpts.TimeoutSeconds)
+}
+
+func TestTuningSubSecondWatchTimeout(t *testing.T) {
+	opts := metav1.ListOptions{}
+	Tuning{WatchTimeout: 100 * time.Millisecond}.applyToWatch(&opts)
+	require.NotNil(t, opts.TimeoutSeconds)
+	assert.Equal(t, int64(1), *opts.TimeoutSeconds)
+}
+
+func TestNewInformerFunc(t *testing.T) {
+	var listOpts, watchOpts metav1.ListOptions
+	newInformer := Tuning{ListPageSize: 100}.NewInformerFunc()
+	informer := newInformer(recordingListWatch(&listOpts, &watchOpts), &corev1.Secret{}, 0, toolscache.Indexers{})
+	assert.NotNil(t, informer)
+}
@@ -0,0 +1,294 @@
+// Package inspect explains how kubemirror mirrors sources: which namespaces a
+// source resolves to, the state of each of its mirrors, and which mirrors were
+// left behind. It only reads from the cluster, and backs the kubemirror-cli tool.
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/controller"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/hash"
+	"github.com/lukaszraczylo/kubemirror/pkg/listing"
+	"github.com/lukaszraczylo/kubemirror/pkg/naming"
+	"github.com/lukaszraczylo/kubemirror/pkg/sweeper"
+)
+
+// MirrorState describes one target namespace of a source.
+type MirrorState string
+
+const (
+	// StateSynced means the mirror holds the source's current content
+	StateSynced MirrorState = "synced"
+	// StateOutOfSync means the mirror was written from older source content
+	StateOutOfSync MirrorState = "out-of-sync"
+	// StateMissing means the namespace is a target but holds no mirror yet
+	StateMissing MirrorState = "missing"
+	// StateConflict means the namespace holds an object of the mirror's name that
+	// kubemirror does not manage (see --conflict-policy)
+	StateConflict MirrorState = "conflict"
+	// StateOrphaned means a mirror exists in a namespace that is no longer a target
+	StateOrphaned MirrorState = "orphaned"
+)
+
+// Mirror is the state of a source's mirror in one namespace.
+type Mirror struct {
+	Namespace string      `json:"namespace"`
+	State     MirrorState `json:"state"`
+	// LastSyncTime is the mirror's last-sync-time annotation, when it has one
+	LastSyncTime string `json:"lastSyncTime,omitempty"`
+}
+
+// SourceReport describes where a source is mirrored.
+type SourceReport struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Enabled reports whether the source has the enabled label and sync annotation
+	Enabled bool `json:"enabled"`
+	// MirrorName is the name mirrors are written under
+	MirrorName string `json:"mirrorName"`
+	// Targets are the namespaces the source resolves to, sorted
+	Targets []string `json:"targets"`
+	// Omitted are the namespaces dropped over the max-targets limit
+	Omitted []string `json:"omitted,omitempty"`
+	// Mirrors is the state of every target, and of mirrors outside the targets;
+	// only set by Status
+	Mirrors []Mirror `json:"mirrors,omitempty"`
+}
+
+// Orphan is a mirror whose source is gone, was recreated or now mirrors under another name.
+type Orphan struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Status is the sweeper's verdict: orphaned, stale or renamed
+	Status string `json:"status"`
+	// Source is the namespace/name the mirror refers to
+	Source string `json:"source"`
+}
+
+// Inspector answers questions about sources and mirrors. It resolves targets
+// like the controller does when given the same filter, limits and resolvers.
+type Inspector struct {
+	// Client reads sources, mirrors and namespaces; nothing is written
+	Client client.Client
+	// Resolver decides target namespaces (optional, nil = target-namespaces annotation)
+	Resolver controller.TargetResolver
+	// Filter holds the controller's excluded and included namespaces
+	Filter *filter.NamespaceFilter
+	// Config supplies the max-targets limit (optional)
+	Config *config.Config
+}
+
+// Get fetches a source.
+func (i *Inspector) Get(ctx context.Context, gvk schema.GroupVersionKind, key types.NamespacedName) (*unstructured.Unstructured, error) {
+	source := &unstructured.Unstructured{}
+	source.SetGroupVersionKind(gvk)
+	if err := i.Client.Get(ctx, key, source); err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %w", gvk.Kind, key, err)
+	}
+	return source, nil
+}
+
+// Targets resolves the namespaces source is mirrored to. Mirror policies are
+// not consulted.
+func (i *Inspector) Targets(ctx context.Context, source *unstructured.Unstructured) (*SourceReport, error) {
+	report := &SourceReport{
+		Kind:      source.GetKind(),
+		Namespace: source.GetNamespace(),
+		Name:      source.GetName(),
+		Enabled: source.GetLabels()[constants.LabelEnabled] == "true" &&
+			source.GetAnnotations()[constants.AnnotationSync] == "true",
+		MirrorName: naming.MirrorNameOrDefault(source),
+	}
+
+	lister := controller.NewKubernetesNamespaceLister(i.Client)
+	targets, omitted, err := controller.ResolveTargetNamespaces(ctx, i.Resolver, lister, i.Filter, i.Config, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve target namespaces: %w", err)
+	}
+	slices.Sort(targets)
+	report.Targets, report.Omitted = targets, omitted
+	return report, nil
+}
+
+// Status resolves the targets of source and reports the state of its mirror in
+// each of them, and of mirrors left in namespaces that are no longer targets.
+func (i *Inspector) Status(ctx context.Context, source *unstructured.Unstructured) (*SourceReport, error) {
+	report, err := i.Targets(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	sourceHash, err := hash.ComputeContentHash(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute source content hash: %w", err)
+	}
+
+	existing, err := i.mirrorsOf(ctx, source, report.MirrorName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ns := range report.Targets {
+		mirror := Mirror{Namespace: ns, State: StateMissing}
+		if obj, found := existing[ns]; found {
+			mirror = mirrorState(obj, sourceHash)
+			delete(existing, ns)
+		} else if conflict, err := i.unmanagedObject(ctx, controller.MirrorGVK(source), ns, report.MirrorName); err != nil {
+			return nil, err
+		} else if conflict {
+			mirror.State = StateConflict
+		}
+		report.Mirrors = append(report.Mirrors, mirror)
+	}
+
+	// Whatever is left is not a target any more; the controller removes it on its next sync
+	orphaned := make([]string, 0, len(existing))
+	for ns := range existing {
+		orphaned = append(orphaned, ns)
+	}
+	slices.Sort(orphaned)
+	for _, ns := range orphaned {
+		mirror := mirrorState(existing[ns], sourceHash)
+		mirror.State = StateOrphaned
+		report.Mirrors = append(report.Mirrors, mirror)
+	}
+	return report, nil
+}
+
+// Orphans lists the mirrors of the given resource types whose source is gone,
+// was recreated or now mirrors under another name. Resource types the cluster
+// does not serve are skipped.
+func (i *Inspector) Orphans(ctx context.Context, resourceTypes []config.ResourceType) ([]Orphan, error) {
+	var orphans []Orphan
+	for _, rt := range resourceTypes {
+		err := i.forEachMirror(ctx, rt.GroupVersionKind(), nil, func(mirror *unstructured.Unstructured) error {
+			verdict, err := sweeper.Check(ctx, i.Client, mirror)
+			if err != nil {
+				return fmt.Errorf("failed to check source of %s %s/%s: %w", mirror.GetKind(), mirror.GetNamespace(), mirror.GetName(), err)
+			}
+			if verdict.Status.Removable() {
+				orphans = append(orphans, Orphan{
+					Kind:      mirror.GetKind(),
+					Namespace: mirror.GetNamespace(),
+					Name:      mirror.GetName(),
+					Status:    verdict.Status.String(),
+					Source:    verdict.Source.String(),
+				})
+			}
+			return nil
+		})
+		if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return orphans, err
+		}
+	}
+	return orphans, nil
+}
+
+// Simulate returns a copy of source with annotations set, to resolve the targets
+// an annotation change would produce before making it. An empty value removes
+// the annotation.
+func Simulate(source *unstructured.Unstructured, annotations map[string]string) *unstructured.Unstructured {
+	simulated := source.DeepCopy()
+	merged := simulated.GetAnnotations()
+	if merged == nil {
+		merged = make(map[string]string, len(annotations))
+	}
+	for key, value := range annotations {
+		if value == "" {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	simulated.SetAnnotations(merged)
+	return simulated
+}
+
+// ParseAnnotations parses key=value pairs. Keys without a prefix are kubemirror
+// annotations, so "target-namespaces=prod-*" sets kubemirror.raczylo.com/target-namespaces.
+func ParseAnnotations(pairs []string) (map[string]string, error) {
+	annotations := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid annotation %q: expected key=value", pair)
+		}
+		if !strings.Contains(key, "/") {
+			key = constants.Domain + "/" + key
+		}
+		annotations[key] = value
+	}
+	return annotations, nil
+}
+
+// mirrorsOf returns the mirrors of source by namespace, under the given name.
+func (i *Inspector) mirrorsOf(ctx context.Context, source *unstructured.Unstructured, name string) (map[string]*unstructured.Unstructured, error) {
+	mirrors := make(map[string]*unstructured.Unstructured)
+	err := i.forEachMirror(ctx, controller.MirrorGVK(source), client.MatchingFields{"metadata.name": name}, func(mirror *unstructured.Unstructured) error {
+		srcNs, srcName, _, found := controller.GetSourceReference(mirror)
+		if found && srcNs == source.GetNamespace() && srcName == source.GetName() {
+			mirrors[mirror.GetNamespace()] = mirror.DeepCopy()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mirrors: %w", err)
+	}
+	return mirrors, nil
+}
+
+// unmanagedObject reports whether ns holds an object named name that kubemirror does not manage.
+func (i *Inspector) unmanagedObject(ctx context.Context, gvk schema.GroupVersionKind, ns, name string) (bool, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	err := i.Client.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, obj)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check namespace %s: %w", ns, err)
+	}
+	return !controller.IsManagedByUs(obj), nil
+}
+
+// forEachMirror lists the mirrors of gvk page by page, optionally narrowed by fields.
+func (i *Inspector) forEachMirror(ctx context.Context, gvk schema.GroupVersionKind, fields client.MatchingFields, fn func(*unstructured.Unstructured) error) error {
+	base := []client.ListOption{
+		client.MatchingLabels{constants.LabelManagedBy: constants.ControllerName, constants.LabelMirror: "true"},
+	}
+	if fields != nil {
+		base = append(base, fields)
+	}
+	return listing.ForEach(ctx, i.Client, gvk, base, fn)
+}
+
+// mirrorState compares a mirror with the current content hash of its source.
+func mirrorState(mirror *unstructured.Unstructured, sourceHash string) Mirror {
+	annotations := mirror.GetAnnotations()
+	state := StateOutOfSync
+	if annotations[constants.AnnotationSourceContentHash] == sourceHash {
+		state = StateSynced
+	}
+	return Mirror{
+		Namespace:    mirror.GetNamespace(),
+		State:        state,
+		LastSyncTime: annotations[constants.AnnotationLastSyncTime],
+	}
+}
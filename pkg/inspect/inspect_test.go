@@ -0,0 +1,168 @@
+package inspect
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/hash"
+)
+
+var secretGVK = schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+
+func namespace(name string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+}
+
+func source(annotations map[string]string) *corev1.Secret {
+	merged := map[string]string{constants.AnnotationSync: "true"}
+	for k, v := range annotations {
+		merged[k] = v
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default", Name: "app-secret", UID: "source-uid",
+			Labels:      map[string]string{constants.LabelEnabled: "true"},
+			Annotations: merged,
+		},
+		Data: map[string][]byte{"key": []byte("value")},
+	}
+}
+
+func mirror(ns, sourceName, contentHash string) *corev1.Secret {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Namespace: ns, Name: "app-secret",
+		Labels: map[string]string{constants.LabelManagedBy: constants.ControllerName, constants.LabelMirror: "true"},
+		Annotations: map[string]string{
+			constants.AnnotationSourceNamespace:   "default",
+			constants.AnnotationSourceName:        sourceName,
+			constants.AnnotationSourceUID:         "source-uid",
+			constants.AnnotationSourceContentHash: contentHash,
+			constants.AnnotationLastSyncTime:      "2026-01-02T03:04:05Z",
+		},
+	}}
+}
+
+func newInspector(t *testing.T, objs ...client.Object) *Inspector {
+	t.Helper()
+	for _, ns := range []string{"default", "kube-system", "prod-a", "prod-b", "prod-c", "staging"} {
+		objs = append(objs, namespace(ns))
+	}
+	// The API server serves metadata.name field selectors; the fake client needs an index
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objs...).
+		WithIndex(&corev1.Secret{}, "metadata.name", func(o client.Object) []string { return []string{o.GetName()} }).
+		Build()
+	return &Inspector{
+		Client: c,
+		Filter: filter.NewNamespaceFilter(constants.DefaultExcludedNamespaces, nil),
+		Config: &config.Config{},
+	}
+}
+
+func get(t *testing.T, i *Inspector) *unstructured.Unstructured {
+	t.Helper()
+	u, err := i.Get(context.Background(), secretGVK, types.NamespacedName{Namespace: "default", Name: "app-secret"})
+	require.NoError(t, err)
+	return u
+}
+
+func TestInspector_Targets(t *testing.T) {
+	i := newInspector(t, source(map[string]string{constants.AnnotationTargetNamespaces: "prod-*,kube-system"}))
+	i.Config.MaxTargetsPerResource = 2
+
+	report, err := i.Targets(context.Background(), get(t, i))
+	require.NoError(t, err)
+	assert.True(t, report.Enabled)
+	assert.Equal(t, "app-secret", report.MirrorName)
+	assert.Equal(t, []string{"prod-a", "prod-b"}, report.Targets, "excluded namespaces dropped, truncated to max-targets")
+	assert.Equal(t, []string{"prod-c"}, report.Omitted)
+}
+
+func TestInspector_Status(t *testing.T) {
+	src := source(map[string]string{constants.AnnotationTargetNamespaces: "prod-a,prod-b,prod-c,staging"})
+	i := newInspector(t, src,
+		mirror("prod-a", "app-secret", "current"),
+		mirror("prod-b", "app-secret", "outdated"),
+		// Unmanaged object of the mirror's name
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "prod-c", Name: "app-secret"}},
+	)
+	u := get(t, i)
+	current, err := hash.ComputeContentHash(u)
+	require.NoError(t, err)
+
+	// Re-point prod-a at the current content, and leave a mirror outside the targets
+	require.NoError(t, i.Client.Delete(context.Background(), mirror("prod-a", "app-secret", "")))
+	require.NoError(t, i.Client.Create(context.Background(), mirror("prod-a", "app-secret", current)))
+	orphaned := mirror("kube-system", "app-secret", current)
+	require.NoError(t, i.Client.Create(context.Background(), orphaned))
+
+	report, err := i.Status(context.Background(), u)
+	require.NoError(t, err)
+
+	states := make(map[string]MirrorState)
+	for _, m := range report.Mirrors {
+		states[m.Namespace] = m.State
+	}
+	assert.Equal(t, map[string]MirrorState{
+		"prod-a":      StateSynced,
+		"prod-b":      StateOutOfSync,
+		"prod-c":      StateConflict,
+		"staging":     StateMissing,
+		"kube-system": StateOrphaned,
+	}, states)
+	assert.Equal(t, "kube-system", report.Mirrors[len(report.Mirrors)-1].Namespace, "non-targets are listed last")
+}
+
+func TestInspector_Orphans(t *testing.T) {
+	i := newInspector(t, source(nil),
+		mirror("prod-a", "app-secret", ""),
+		mirror("prod-b", "gone", ""),
+	)
+	gone := mirror("prod-b", "gone", "")
+	gone.Name = "gone"
+	require.NoError(t, i.Client.Create(context.Background(), gone))
+
+	orphans, err := i.Orphans(context.Background(), []config.ResourceType{
+		{Version: "v1", Kind: "Secret"},
+		// Not served by the cluster, skipped
+		{Group: "example.com", Version: "v1", Kind: "Widget"},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []Orphan{
+		{Kind: "Secret", Namespace: "prod-b", Name: "app-secret", Status: "orphaned", Source: "default/gone"},
+		{Kind: "Secret", Namespace: "prod-b", Name: "gone", Status: "orphaned", Source: "default/gone"},
+	}, orphans)
+}
+
+func TestSimulate(t *testing.T) {
+	i := newInspector(t, source(map[string]string{constants.AnnotationTargetNamespaces: "staging"}))
+	u := get(t, i)
+
+	annotations, err := ParseAnnotations([]string{"target-namespaces=prod-*", "kubemirror.raczylo.com/target-name-prefix="})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		constants.AnnotationTargetNamespaces: "prod-*",
+		constants.AnnotationTargetNamePrefix: "",
+	}, annotations)
+
+	report, err := i.Targets(context.Background(), Simulate(u, annotations))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod-a", "prod-b", "prod-c"}, report.Targets)
+	assert.Equal(t, "staging", u.GetAnnotations()[constants.AnnotationTargetNamespaces], "source is not modified")
+
+	_, err = ParseAnnotations([]string{"target-namespaces"})
+	assert.Error(t, err)
+}
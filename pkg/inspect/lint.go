@@ -0,0 +1,152 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/controller"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+	"github.com/lukaszraczylo/kubemirror/pkg/listing"
+	"github.com/lukaszraczylo/kubemirror/pkg/transformer"
+)
+
+// Severity grades a lint finding.
+type Severity string
+
+const (
+	// SeverityError means the controller ignores the annotation, or part of it
+	SeverityError Severity = "error"
+	// SeverityWarning means the annotation is valid but likely not doing what was meant
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a problem with the kubemirror labels or annotations of one object.
+type Finding struct {
+	Kind      string   `json:"kind"`
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Severity  Severity `json:"severity"`
+	// Annotation is the annotation or label the finding is about
+	Annotation string `json:"annotation"`
+	Message    string `json:"message"`
+}
+
+// HasErrors reports whether any finding is an error.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks the kubemirror labels and annotations of every object of the given
+// resource types: target namespace patterns and selectors, sync conditions,
+// transform rules and the rule libraries they reference. Mirrors are skipped, as
+// are resource types the cluster does not serve.
+func (i *Inspector) Lint(ctx context.Context, resourceTypes []config.ResourceType) ([]Finding, error) {
+	l := &linter{
+		transformer: transformer.NewDefaultTransformer(),
+		libraries:   controller.NewRuleLibrary(i.Client),
+	}
+	for _, rt := range resourceTypes {
+		err := listing.ForEach(ctx, i.Client, rt.GroupVersionKind(), nil, func(obj *unstructured.Unstructured) error {
+			if obj.GetLabels()[constants.LabelMirror] != "true" {
+				l.lint(ctx, obj)
+			}
+			return nil
+		})
+		if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return l.findings, err
+		}
+	}
+	return l.findings, nil
+}
+
+// linter collects the findings of one Lint run.
+type linter struct {
+	transformer *transformer.Transformer
+	libraries   *controller.RuleLibrary
+	findings    []Finding
+}
+
+// lint checks one object. Objects without kubemirror labels or annotations are not sources.
+func (l *linter) lint(ctx context.Context, obj *unstructured.Unstructured) {
+	labeled := obj.GetLabels()[constants.LabelEnabled] == "true"
+	annotations := obj.GetAnnotations()
+	if !labeled && !hasKubemirrorAnnotation(annotations) {
+		return
+	}
+	report := func(severity Severity, annotation, format string, args ...interface{}) {
+		l.findings = append(l.findings, Finding{
+			Kind:       obj.GetKind(),
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+			Severity:   severity,
+			Annotation: annotation,
+			Message:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	// Mirror policies mirror sources without either, so this is not an error
+	switch synced := annotations[constants.AnnotationSync] == "true"; {
+	case labeled && !synced:
+		report(SeverityWarning, constants.AnnotationSync, "source has the enabled label but no sync annotation; only mirror policies would mirror it")
+	case synced && !labeled:
+		report(SeverityWarning, constants.LabelEnabled, "source has the sync annotation but no enabled label; only mirror policies would mirror it")
+	}
+
+	if value := annotations[constants.AnnotationTargetNamespaces]; value != "" {
+		results, _ := filter.ValidatePatterns(filter.ParseTargetNamespaces(value))
+		for _, invalid := range filter.InvalidPatterns(results) {
+			report(SeverityError, constants.AnnotationTargetNamespaces, "pattern %q is skipped: %v", invalid.Pattern, invalid.Error)
+		}
+	}
+	if value := annotations[constants.AnnotationTargetNamespaceSelector]; strings.TrimSpace(value) != "" {
+		if _, err := filter.ParseNamespaceSelector(value); err != nil {
+			report(SeverityError, constants.AnnotationTargetNamespaceSelector, "selector is skipped: %v", err)
+		}
+	}
+	if value := annotations[constants.AnnotationSyncWhen]; value != "" {
+		if _, err := filter.ParseCondition(value); err != nil {
+			report(SeverityError, constants.AnnotationSyncWhen, "the source is never synced: %v", err)
+		}
+	}
+	if value := annotations[constants.AnnotationTransform]; value != "" {
+		if err := l.transformer.ValidateRules(value); err != nil {
+			report(SeverityError, constants.AnnotationTransform, "rules are not applied: %v", err)
+		}
+	}
+	if value := annotations[constants.AnnotationTransformRef]; value != "" {
+		refs, err := transformer.ParseRuleLibraryRefs(value, obj.GetNamespace())
+		if err != nil {
+			report(SeverityError, constants.AnnotationTransformRef, "rules are not applied: %v", err)
+		}
+		for _, ref := range refs {
+			if _, err := l.libraries.Rules(ctx, ref.Namespace, ref.Name); err != nil {
+				report(SeverityError, constants.AnnotationTransformRef, "rule library %s: %v", ref, err)
+			}
+		}
+	}
+}
+
+// hasKubemirrorAnnotation reports whether annotations hold any kubemirror annotation.
+func hasKubemirrorAnnotation(annotations map[string]string) bool {
+	for key := range annotations {
+		if strings.HasPrefix(key, constants.Domain+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package inspect
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func TestInspector_Lint(t *testing.T) {
+	broken := source(map[string]string{
+		constants.AnnotationTargetNamespaces:        "prod-*, [prod",
+		constants.AnnotationTargetNamespaceSelector: "env in (prod",
+		constants.AnnotationSyncWhen:                "status.phase ==",
+		constants.AnnotationTransform:               "rules:\n  - path: data.key\n",
+		constants.AnnotationTransformRef:            "kubemirror-system/missing, shared",
+	})
+	valid := source(map[string]string{
+		constants.AnnotationTargetNamespaces: "prod-*",
+		constants.AnnotationTransform:        "rules:\n  - path: data.key\n    value: x\n",
+		constants.AnnotationTransformRef:     "shared",
+	})
+	valid.Name = "valid"
+	unlabeled := source(nil)
+	unlabeled.Name, unlabeled.Labels = "unlabeled", nil
+	// Mirrors carry no source annotations worth checking
+	copied := mirror("prod-a", "app-secret", "")
+	copied.Annotations[constants.AnnotationTransform] = "rules: ["
+	library := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "shared", Labels: map[string]string{constants.LabelTransformRules: "true"}},
+		Data:       map[string]string{constants.TransformRulesKey: "rules:\n  - path: data.key\n    value: x\n"},
+	}
+	plain := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "plain"}}
+
+	i := newInspector(t, broken, valid, unlabeled, copied, library, plain)
+	findings, err := i.Lint(context.Background(), []config.ResourceType{
+		{Version: "v1", Kind: "Secret"},
+		// Not served by the cluster, skipped
+		{Group: "example.com", Version: "v1", Kind: "Widget"},
+	})
+	require.NoError(t, err)
+	assert.True(t, HasErrors(findings))
+
+	got := make(map[string][]string)
+	for _, f := range findings {
+		got[f.Name] = append(got[f.Name], string(f.Severity)+" "+f.Annotation)
+		assert.NotEmpty(t, f.Message)
+	}
+	assert.Equal(t, map[string][]string{
+		"app-secret": {
+			"error " + constants.AnnotationTargetNamespaces,
+			"error " + constants.AnnotationTargetNamespaceSelector,
+			"error " + constants.AnnotationSyncWhen,
+			"error " + constants.AnnotationTransform,
+			"error " + constants.AnnotationTransformRef,
+		},
+		"unlabeled": {"warning " + constants.LabelEnabled},
+	}, got)
+}
+
+func TestHasErrors(t *testing.T) {
+	assert.False(t, HasErrors(nil))
+	assert.False(t, HasErrors([]Finding{{Severity: SeverityWarning}}))
+	assert.True(t, HasErrors([]Finding{{Severity: SeverityWarning}, {Severity: SeverityError}}))
+}
@@ -0,0 +1,135 @@
+package inspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats accepted by the Write functions.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// ValidateFormat checks an output format.
+func ValidateFormat(format string) error {
+	if format != FormatTable && format != FormatJSON {
+		return fmt.Errorf("unknown output format %q: expected %s or %s", format, FormatTable, FormatJSON)
+	}
+	return nil
+}
+
+// WriteTargets writes the target namespaces of a report, one per line.
+func WriteTargets(w io.Writer, report *SourceReport, format string) error {
+	if format == FormatJSON {
+		return writeJSON(w, report)
+	}
+	if err := writeHeader(w, report); err != nil {
+		return err
+	}
+	for _, ns := range report.Targets {
+		if _, err := fmt.Fprintln(w, ns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteStatus writes the state of every mirror of a report as a table.
+func WriteStatus(w io.Writer, report *SourceReport, format string) error {
+	if format == FormatJSON {
+		return writeJSON(w, report)
+	}
+	if err := writeHeader(w, report); err != nil {
+		return err
+	}
+	if len(report.Mirrors) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "NAMESPACE\tSTATE\tLAST SYNC")
+	for _, mirror := range report.Mirrors {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", mirror.Namespace, mirror.State, orDash(mirror.LastSyncTime))
+	}
+	return tw.Flush()
+}
+
+// WriteOrphans writes orphaned mirrors as a table.
+func WriteOrphans(w io.Writer, orphans []Orphan, format string) error {
+	if format == FormatJSON {
+		if orphans == nil {
+			orphans = []Orphan{}
+		}
+		return writeJSON(w, orphans)
+	}
+	if len(orphans) == 0 {
+		_, err := fmt.Fprintln(w, "No orphaned mirrors found.")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "KIND\tNAMESPACE\tNAME\tSTATUS\tSOURCE")
+	for _, orphan := range orphans {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", orphan.Kind, orphan.Namespace, orphan.Name, orphan.Status, orphan.Source)
+	}
+	return tw.Flush()
+}
+
+// WriteLint writes lint findings as a table.
+func WriteLint(w io.Writer, findings []Finding, format string) error {
+	if format == FormatJSON {
+		if findings == nil {
+			findings = []Finding{}
+		}
+		return writeJSON(w, findings)
+	}
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(w, "No problems found.")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "SEVERITY\tKIND\tNAMESPACE\tNAME\tANNOTATION\tMESSAGE")
+	for _, f := range findings {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", f.Severity, f.Kind, f.Namespace, f.Name, f.Annotation, f.Message)
+	}
+	return tw.Flush()
+}
+
+// writeHeader summarizes a source above its targets.
+func writeHeader(w io.Writer, report *SourceReport) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s/%s", report.Kind, report.Namespace, report.Name)
+	if report.MirrorName != report.Name {
+		fmt.Fprintf(&b, " (mirrored as %s)", report.MirrorName)
+	}
+	fmt.Fprintf(&b, ": %d target namespace(s)\n", len(report.Targets))
+	if !report.Enabled {
+		b.WriteString("Warning: source lacks the enabled label or sync annotation; only mirror policies would mirror it\n")
+	}
+	if len(report.Omitted) > 0 {
+		fmt.Fprintf(&b, "Warning: %d namespace(s) omitted over the max-targets limit: %s\n",
+			len(report.Omitted), strings.Join(report.Omitted, ", "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// orDash returns s, or "-" when it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,88 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFormat(t *testing.T) {
+	assert.NoError(t, ValidateFormat(FormatTable))
+	assert.NoError(t, ValidateFormat(FormatJSON))
+	assert.Error(t, ValidateFormat("yaml"))
+}
+
+func TestWriteTargets(t *testing.T) {
+	report := &SourceReport{
+		Kind: "Secret", Namespace: "default", Name: "app-secret", MirrorName: "shared-app-secret",
+		Targets: []string{"prod-a", "prod-b"}, Omitted: []string{"prod-c"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteTargets(&buf, report, FormatTable))
+	assert.Equal(t, "Secret default/app-secret (mirrored as shared-app-secret): 2 target namespace(s)\n"+
+		"Warning: source lacks the enabled label or sync annotation; only mirror policies would mirror it\n"+
+		"Warning: 1 namespace(s) omitted over the max-targets limit: prod-c\n"+
+		"prod-a\nprod-b\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteTargets(&buf, report, FormatJSON))
+	var decoded SourceReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, *report, decoded)
+}
+
+func TestWriteStatus(t *testing.T) {
+	report := &SourceReport{
+		Kind: "Secret", Namespace: "default", Name: "app-secret", MirrorName: "app-secret", Enabled: true,
+		Targets: []string{"prod-a", "staging"},
+		Mirrors: []Mirror{
+			{Namespace: "prod-a", State: StateSynced, LastSyncTime: "2026-01-02T03:04:05Z"},
+			{Namespace: "staging", State: StateMissing},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteStatus(&buf, report, FormatTable))
+	assert.Equal(t, "Secret default/app-secret: 2 target namespace(s)\n"+
+		"NAMESPACE  STATE    LAST SYNC\n"+
+		"prod-a     synced   2026-01-02T03:04:05Z\n"+
+		"staging    missing  -\n", buf.String())
+}
+
+func TestWriteOrphans(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteOrphans(&buf, nil, FormatTable))
+	assert.Equal(t, "No orphaned mirrors found.\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteOrphans(&buf, nil, FormatJSON))
+	assert.Equal(t, "[]\n", buf.String(), "no orphans is an empty list, not null")
+
+	buf.Reset()
+	require.NoError(t, WriteOrphans(&buf, []Orphan{
+		{Kind: "Secret", Namespace: "prod-a", Name: "app-secret", Status: "stale", Source: "default/app-secret"},
+	}, FormatTable))
+	assert.Equal(t, "KIND    NAMESPACE  NAME        STATUS  SOURCE\n"+
+		"Secret  prod-a     app-secret  stale   default/app-secret\n", buf.String())
+}
+
+func TestWriteLint(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteLint(&buf, nil, FormatTable))
+	assert.Equal(t, "No problems found.\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteLint(&buf, nil, FormatJSON))
+	assert.Equal(t, "[]\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteLint(&buf, []Finding{
+		{Kind: "Secret", Namespace: "default", Name: "app", Severity: SeverityError, Annotation: "kubemirror.raczylo.com/sync-when", Message: "invalid"},
+	}, FormatTable))
+	assert.Equal(t, "SEVERITY  KIND    NAMESPACE  NAME  ANNOTATION                        MESSAGE\n"+
+		"error     Secret  default    app   kubemirror.raczylo.com/sync-when  invalid\n", buf.String())
+}
@@ -0,0 +1,127 @@
+// Package keyfilter narrows the data keys of Secret and ConfigMap mirrors to the
+// ones a source's include-keys and exclude-keys annotations allow, and the labels
+// and annotations of mirrors to the ones its propagate-labels and
+// propagate-annotations annotations select. Both mirror building and content
+// hashing use it, so keys left out of mirrors never cause a sync.
+package keyfilter
+
+import (
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// dataFields are the fields of Secrets and ConfigMaps holding data keys.
+var dataFields = []string{"data", "binaryData", "stringData"}
+
+// Filter decides which data keys are mirrored. A nil Filter allows every key.
+type Filter struct {
+	include []string
+	exclude []string
+}
+
+// FromAnnotations returns the filter set by a source's annotations, or nil if
+// it sets neither include-keys nor exclude-keys.
+func FromAnnotations(annotations map[string]string) *Filter {
+	include := parsePatterns(annotations[constants.AnnotationIncludeKeys])
+	exclude := parsePatterns(annotations[constants.AnnotationExcludeKeys])
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil
+	}
+	return &Filter{include: include, exclude: exclude}
+}
+
+// Allows reports whether key is mirrored: it matches an include pattern (when
+// there are any) and no exclude pattern.
+func (f *Filter) Allows(key string) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.include) > 0 && !matchesAny(f.include, key) {
+		return false
+	}
+	return !matchesAny(f.exclude, key)
+}
+
+// Keys returns a copy of m holding only the keys f allows, or m itself when f is nil.
+func Keys[V any](f *Filter, m map[string]V) map[string]V {
+	if f == nil || m == nil {
+		return m
+	}
+	filtered := make(map[string]V, len(m))
+	for key, value := range m {
+		if f.Allows(key) {
+			filtered[key] = value
+		}
+	}
+	return filtered
+}
+
+// Apply narrows the data fields of a core Secret or ConfigMap to the keys its
+// own annotations allow; other objects are left alone. The fields are replaced
+// rather than edited, so maps shared with another object are not modified.
+func Apply(u *unstructured.Unstructured) {
+	ApplyFilter(u, FromAnnotations(u.GetAnnotations()))
+}
+
+// ApplyFilter is Apply with the filter of another object, e.g. of the source
+// when u is its mirror.
+func ApplyFilter(u *unstructured.Unstructured, f *Filter) {
+	if f == nil || !hasDataKeys(u) {
+		return
+	}
+	for _, field := range dataFields {
+		if data, ok := u.Object[field].(map[string]interface{}); ok {
+			u.Object[field] = Keys(f, data)
+		}
+	}
+}
+
+// Metadata returns the entries of metadata (labels or annotations of a source)
+// whose keys match the comma-separated patterns, as set by the propagate-labels
+// or propagate-annotations annotation. kubemirror's own keys are never selected.
+func Metadata(patterns string, metadata map[string]string) map[string]string {
+	include := parsePatterns(patterns)
+	selected := make(map[string]string)
+	for key, value := range metadata {
+		if !strings.HasPrefix(key, constants.Domain+"/") && matchesAny(include, key) {
+			selected[key] = value
+		}
+	}
+	return selected
+}
+
+// hasDataKeys reports whether u is a core Secret or ConfigMap.
+func hasDataKeys(u *unstructured.Unstructured) bool {
+	gvk := u.GroupVersionKind()
+	return gvk.Group == "" && gvk.Version == "v1" && (gvk.Kind == "Secret" || gvk.Kind == "ConfigMap")
+}
+
+// parsePatterns splits a comma-separated list of key patterns.
+func parsePatterns(value string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// matchesAny reports whether key matches one of patterns. "*" alone matches
+// every key, prefixed ones included; a malformed pattern only matches the
+// identical key.
+func matchesAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == key {
+			return true
+		}
+		if matched, err := path.Match(pattern, key); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package keyfilter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func TestFilter_Allows(t *testing.T) {
+	tests := []struct {
+		name    string
+		include string
+		exclude string
+		allowed []string
+		denied  []string
+	}{
+		{
+			name:    "include list",
+			include: "tls.crt, ca.crt",
+			allowed: []string{"tls.crt", "ca.crt"},
+			denied:  []string{"tls.key"},
+		},
+		{
+			name:    "exclude list",
+			exclude: "tls.key",
+			allowed: []string{"tls.crt", "ca.crt"},
+			denied:  []string{"tls.key"},
+		},
+		{
+			name:    "exclude applies after include",
+			include: "*.crt,*.key",
+			exclude: "tls.key",
+			allowed: []string{"tls.crt", "ca.key"},
+			denied:  []string{"tls.key", "config.yaml"},
+		},
+		{
+			name:    "globs",
+			include: "app-?.yaml",
+			allowed: []string{"app-1.yaml"},
+			denied:  []string{"app-10.yaml"},
+		},
+		{
+			name:    "malformed pattern only matches itself",
+			exclude: "[broken",
+			allowed: []string{"b"},
+			denied:  []string{"[broken"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := FromAnnotations(map[string]string{
+				constants.AnnotationIncludeKeys: tt.include,
+				constants.AnnotationExcludeKeys: tt.exclude,
+			})
+			for _, key := range tt.allowed {
+				assert.True(t, f.Allows(key), key)
+			}
+			for _, key := range tt.denied {
+				assert.False(t, f.Allows(key), key)
+			}
+		})
+	}
+}
+
+func TestFromAnnotations_None(t *testing.T) {
+	assert.Nil(t, FromAnnotations(nil))
+	assert.Nil(t, FromAnnotations(map[string]string{constants.AnnotationIncludeKeys: " , "}))
+
+	var f *Filter
+	assert.True(t, f.Allows("anything"))
+	data := map[string]string{"a": "1"}
+	assert.Equal(t, data, Keys(f, data))
+}
+
+func TestApply(t *testing.T) {
+	data := map[string]interface{}{"tls.crt": "Y3J0", "tls.key": "a2V5"}
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"data":       data,
+		"stringData": map[string]interface{}{"tls.key": "key"},
+	}}
+	secret.SetAnnotations(map[string]string{constants.AnnotationExcludeKeys: "tls.key"})
+
+	Apply(secret)
+	assert.Equal(t, map[string]interface{}{"tls.crt": "Y3J0"}, secret.Object["data"])
+	assert.Equal(t, map[string]interface{}{}, secret.Object["stringData"])
+	assert.Len(t, data, 2, "the original map is not modified")
+
+	// Only core Secrets and ConfigMaps have data keys
+	custom := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Secret",
+		"data":       map[string]interface{}{"tls.key": "a2V5"},
+	}}
+	custom.SetAnnotations(map[string]string{constants.AnnotationExcludeKeys: "tls.key"})
+	Apply(custom)
+	assert.Equal(t, map[string]interface{}{"tls.key": "a2V5"}, custom.Object["data"])
+}
+
+func TestMetadata(t *testing.T) {
+	labels := map[string]string{
+		"app":                       "web",
+		"team":                      "payments",
+		"app.kubernetes.io/name":    "web",
+		"tier":                      "frontend",
+		constants.LabelEnabled:      "true",
+		"prometheus.io/scrape":      "true",
+		"prometheus.io/port":        "9090",
+		"example.com/prometheus.io": "no",
+	}
+
+	assert.Equal(t, map[string]string{"app": "web", "team": "payments"}, Metadata("app, team", labels))
+	assert.Equal(t, map[string]string{"prometheus.io/scrape": "true", "prometheus.io/port": "9090"},
+		Metadata("prometheus.io/*", labels))
+
+	all := Metadata("*", labels)
+	assert.Len(t, all, len(labels)-1, `"*" selects prefixed keys too`)
+	assert.NotContains(t, all, constants.LabelEnabled, "kubemirror's own keys are never selected")
+
+	assert.Empty(t, Metadata("", labels))
+	assert.Empty(t, Metadata("app", nil))
+}
@@ -0,0 +1,51 @@
+// Package listing walks every object of a resource type through paginated LIST
+// calls.
+//
+// The janitors, the prune and inspect commands and the summary all scan whole
+// resource types; listing page by page keeps their memory bounded on clusters
+// with tens of thousands of objects.
+package listing
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PageSize is the number of objects requested per LIST call.
+const PageSize = 500
+
+// ErrStop can be returned by the function passed to ForEach to end the listing
+// early; ForEach then returns nil.
+var ErrStop = errors.New("stop listing")
+
+// ForEach lists the objects of gvk matching opts page by page and calls fn on
+// each. It stops at the first error of a LIST call or of fn and returns it.
+func ForEach(ctx context.Context, c client.Reader, gvk schema.GroupVersionKind, opts []client.ListOption, fn func(*unstructured.Unstructured) error) error {
+	base := append(slices.Clone(opts), client.Limit(PageSize))
+	page := base
+	for {
+		// A fresh list per page, so objects handed to fn stay valid after it returns
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk)
+		if err := c.List(ctx, list, page...); err != nil {
+			return err
+		}
+		for i := range list.Items {
+			if err := fn(&list.Items[i]); err != nil {
+				if errors.Is(err, ErrStop) {
+					return nil
+				}
+				return err
+			}
+		}
+		if list.GetContinue() == "" {
+			return nil
+		}
+		page = append(slices.Clone(base), client.Continue(list.GetContinue()))
+	}
+}
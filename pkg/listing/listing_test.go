@@ -0,0 +1,95 @@
+package listing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var configMapGVK = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+// pagedReader serves objects in pages of the requested limit and records the
+// options of every List call.
+type pagedReader struct {
+	client.Reader
+	objects int
+	calls   []*client.ListOptions
+}
+
+func (r *pagedReader) List(_ context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	o := &client.ListOptions{}
+	o.ApplyOptions(opts)
+	r.calls = append(r.calls, o)
+
+	start := 0
+	if o.Continue != "" {
+		start, _ = strconv.Atoi(o.Continue)
+	}
+	end := min(start+int(o.Limit), r.objects)
+	u := list.(*unstructured.UnstructuredList)
+	for i := start; i < end; i++ {
+		obj := unstructured.Unstructured{}
+		obj.SetName(fmt.Sprintf("cm-%d", i))
+		u.Items = append(u.Items, obj)
+	}
+	if end < r.objects {
+		u.SetContinue(strconv.Itoa(end))
+	}
+	return nil
+}
+
+func TestForEach(t *testing.T) {
+	reader := &pagedReader{objects: 2*PageSize + 1}
+	var names []string
+	err := ForEach(context.Background(), reader, configMapGVK, []client.ListOption{client.InNamespace("default")}, func(obj *unstructured.Unstructured) error {
+		names = append(names, obj.GetName())
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, names, 2*PageSize+1)
+	assert.Equal(t, "cm-0", names[0])
+	assert.Equal(t, fmt.Sprintf("cm-%d", 2*PageSize), names[len(names)-1])
+	require.Len(t, reader.calls, 3, "one LIST call per page")
+	for i, call := range reader.calls {
+		assert.Equal(t, "default", call.Namespace, "call %d keeps the caller's options", i)
+		assert.Equal(t, int64(PageSize), call.Limit, "call %d", i)
+	}
+	assert.Empty(t, reader.calls[0].Continue)
+	assert.Equal(t, strconv.Itoa(PageSize), reader.calls[1].Continue)
+}
+
+func TestForEach_Stop(t *testing.T) {
+	reader := &pagedReader{objects: 2 * PageSize}
+	visited := 0
+	err := ForEach(context.Background(), reader, configMapGVK, nil, func(*unstructured.Unstructured) error {
+		visited++
+		if visited == 3 {
+			return ErrStop
+		}
+		return nil
+	})
+	require.NoError(t, err, "ErrStop ends the listing without an error")
+	assert.Equal(t, 3, visited)
+	assert.Len(t, reader.calls, 1, "no further pages are listed")
+}
+
+func TestForEach_Error(t *testing.T) {
+	reader := &pagedReader{objects: 2 * PageSize}
+	boom := errors.New("boom")
+	visited := 0
+	err := ForEach(context.Background(), reader, configMapGVK, nil, func(*unstructured.Unstructured) error {
+		visited++
+		return boom
+	})
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, 1, visited)
+}
@@ -0,0 +1,44 @@
+// Package naming derives the name a source is mirrored under.
+package naming
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// MirrorName returns the name mirrors of source are created under: the
+// target-name annotation (or the source name), wrapped in the target-name-prefix
+// and target-name-suffix annotations.
+func MirrorName(source metav1.Object) (string, error) {
+	annotations := source.GetAnnotations()
+	name := source.GetName()
+	if target := strings.TrimSpace(annotations[constants.AnnotationTargetName]); target != "" {
+		name = target
+	}
+	name = annotations[constants.AnnotationTargetNamePrefix] + name + annotations[constants.AnnotationTargetNameSuffix]
+
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", fmt.Errorf("mirror name %q is invalid: %s", name, strings.Join(errs, "; "))
+	}
+	return name, nil
+}
+
+// MirrorNameOrDefault is MirrorName falling back to the source name when the
+// naming annotations are invalid; no mirror can exist under an invalid name.
+func MirrorNameOrDefault(source metav1.Object) string {
+	name, err := MirrorName(source)
+	if err != nil {
+		return source.GetName()
+	}
+	return name
+}
+
+// Renamed reports whether source is mirrored under a name other than its own.
+func Renamed(source metav1.Object) bool {
+	return MirrorNameOrDefault(source) != source.GetName()
+}
@@ -0,0 +1,60 @@
+package naming
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func TestMirrorName(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        string
+		wantErr     bool
+	}{
+		{name: "source name by default", want: "shared-tls"},
+		{
+			name:        "target name",
+			annotations: map[string]string{constants.AnnotationTargetName: "tls"},
+			want:        "tls",
+		},
+		{
+			name:        "prefix and suffix",
+			annotations: map[string]string{constants.AnnotationTargetNamePrefix: "team-a-", constants.AnnotationTargetNameSuffix: "-copy"},
+			want:        "team-a-shared-tls-copy",
+		},
+		{
+			name: "prefix wraps target name",
+			annotations: map[string]string{
+				constants.AnnotationTargetName:       "tls",
+				constants.AnnotationTargetNamePrefix: "team-a-",
+			},
+			want: "team-a-tls",
+		},
+		{
+			name:        "invalid name",
+			annotations: map[string]string{constants.AnnotationTargetNamePrefix: "Team_A-"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &metav1.ObjectMeta{Name: "shared-tls", Annotations: tt.annotations}
+			got, err := MirrorName(source)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, "shared-tls", MirrorNameOrDefault(source))
+				assert.False(t, Renamed(source))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want != "shared-tls", Renamed(source))
+		})
+	}
+}
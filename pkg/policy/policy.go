@@ -0,0 +1,255 @@
+// Package policy implements ClusterMirrorPolicy, a cluster-scoped resource that
+// declares which sources are mirrored to which namespaces.
+//
+// Annotations require write access to every source; policies let cluster admins
+// drive mirroring centrally instead. A source selected by a policy is mirrored
+// as if it carried the sync annotation, to the union of the policies' target
+// namespaces and its own target-namespaces annotation.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+// GVK is the GroupVersionKind of ClusterMirrorPolicy.
+var GVK = schema.GroupVersionKind{
+	Group:   constants.Domain,
+	Version: "v1alpha1",
+	Kind:    "ClusterMirrorPolicy",
+}
+
+// Spec is the spec of a ClusterMirrorPolicy.
+type Spec struct {
+	Source SourceSelector `json:"source"`
+	// TargetNamespaces uses the same patterns as the target-namespaces annotation
+	// ("all", "all-labeled", "descendants", "tenant:" groups, globs and names)
+	TargetNamespaces []string `json:"targetNamespaces"`
+}
+
+// SourceSelector selects the sources a policy mirrors.
+type SourceSelector struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Namespace restricts sources to one namespace (empty = all namespaces)
+	Namespace string `json:"namespace,omitempty"`
+	// Name selects a single source; requires Namespace
+	Name string `json:"name,omitempty"`
+	// Selector selects sources by label
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// Policy is a parsed, validated ClusterMirrorPolicy.
+type Policy struct {
+	// Name is the name of the ClusterMirrorPolicy object
+	Name             string
+	GVK              schema.GroupVersionKind
+	Namespace        string
+	SourceName       string
+	Selector         labels.Selector
+	TargetNamespaces []string
+}
+
+// Parse validates a ClusterMirrorPolicy object.
+func Parse(obj *unstructured.Unstructured) (*Policy, error) {
+	rawSpec, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+	var spec Spec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawSpec, &spec); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+
+	gv, err := schema.ParseGroupVersion(spec.Source.APIVersion)
+	if err != nil || spec.Source.APIVersion == "" {
+		return nil, fmt.Errorf("spec.source.apiVersion %q is invalid", spec.Source.APIVersion)
+	}
+	if spec.Source.Kind == "" {
+		return nil, fmt.Errorf("spec.source.kind is required")
+	}
+	if spec.Source.Name != "" && spec.Source.Namespace == "" {
+		return nil, fmt.Errorf("spec.source.name requires spec.source.namespace")
+	}
+	if spec.Source.Name == "" && spec.Source.Selector == nil {
+		return nil, fmt.Errorf("spec.source needs a name or a selector")
+	}
+
+	selector := labels.Everything()
+	if spec.Source.Selector != nil {
+		if selector, err = metav1.LabelSelectorAsSelector(spec.Source.Selector); err != nil {
+			return nil, fmt.Errorf("spec.source.selector is invalid: %w", err)
+		}
+	}
+
+	if len(spec.TargetNamespaces) == 0 {
+		return nil, fmt.Errorf("spec.targetNamespaces is required")
+	}
+	if results, ok := filter.ValidatePatterns(spec.TargetNamespaces); !ok {
+		invalid := filter.InvalidPatterns(results)[0]
+		return nil, fmt.Errorf("spec.targetNamespaces pattern %q is invalid: %w", invalid.Pattern, invalid.Error)
+	}
+
+	return &Policy{
+		Name:             obj.GetName(),
+		GVK:              gv.WithKind(spec.Source.Kind),
+		Namespace:        spec.Source.Namespace,
+		SourceName:       spec.Source.Name,
+		Selector:         selector,
+		TargetNamespaces: spec.TargetNamespaces,
+	}, nil
+}
+
+// Selects reports whether the policy selects source of type gvk.
+func (p *Policy) Selects(gvk schema.GroupVersionKind, source client.Object) bool {
+	if gvk != p.GVK {
+		return false
+	}
+	if p.Namespace != "" && source.GetNamespace() != p.Namespace {
+		return false
+	}
+	if p.SourceName != "" && source.GetName() != p.SourceName {
+		return false
+	}
+	return p.Selector.Matches(labels.Set(source.GetLabels()))
+}
+
+// ChangeFunc is called with the source type and namespaces ("" = all) whose
+// sources may be selected differently after a policy changed.
+type ChangeFunc func(ctx context.Context, gvk schema.GroupVersionKind, namespaces []string)
+
+// Store holds the current policies. It is safe for concurrent use.
+type Store struct {
+	mu        sync.RWMutex
+	policies  map[string]*Policy
+	listeners []ChangeFunc
+}
+
+// NewStore creates an empty Store.
+func NewStore() *Store {
+	return &Store{policies: make(map[string]*Policy)}
+}
+
+// Load reads every ClusterMirrorPolicy into the store without notifying listeners.
+// Call it before source reconcilers start, so their first reconcile of a
+// policy-selected source does not mistake it for a disabled one and remove its
+// mirrors. Invalid policies are skipped; the Reconciler reports them.
+func (s *Store) Load(ctx context.Context, reader client.Reader) error {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(GVK.GroupVersion().WithKind(GVK.Kind + "List"))
+	if err := reader.List(ctx, list); err != nil {
+		return fmt.Errorf("failed to list mirror policies: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range list.Items {
+		if p, err := Parse(&list.Items[i]); err == nil {
+			s.policies[p.Name] = p
+		}
+	}
+	return nil
+}
+
+// Len returns the number of policies in the store.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.policies)
+}
+
+// OnChange registers fn to be called whenever a policy is added, changed or removed.
+func (s *Store) OnChange(fn ChangeFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Set adds or replaces a policy and notifies listeners of the affected sources.
+func (s *Store) Set(ctx context.Context, p *Policy) {
+	s.mu.Lock()
+	previous := s.policies[p.Name]
+	s.policies[p.Name] = p
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	notify(ctx, listeners, previous, p)
+}
+
+// Delete removes a policy and notifies listeners of the sources it selected.
+func (s *Store) Delete(ctx context.Context, name string) {
+	s.mu.Lock()
+	previous, found := s.policies[name]
+	delete(s.policies, name)
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	if found {
+		notify(ctx, listeners, previous, nil)
+	}
+}
+
+// Resync notifies listeners of the sources of every policy, e.g. after a
+// namespace was created that "all" or glob targets now include.
+func (s *Store) Resync(ctx context.Context) {
+	s.mu.RLock()
+	policies := make([]*Policy, 0, len(s.policies))
+	for _, p := range s.policies {
+		policies = append(policies, p)
+	}
+	listeners := s.listeners
+	s.mu.RUnlock()
+
+	for _, p := range policies {
+		notify(ctx, listeners, nil, p)
+	}
+}
+
+// TargetPatterns returns the target namespace patterns of every policy that
+// selects source of type gvk, or nil when none does.
+func (s *Store) TargetPatterns(gvk schema.GroupVersionKind, source client.Object) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var names []string
+	for name, p := range s.policies {
+		if p.Selects(gvk, source) {
+			names = append(names, name)
+		}
+	}
+	// Stable order keeps logs and events deterministic
+	sort.Strings(names)
+
+	var patterns []string
+	for _, name := range names {
+		patterns = append(patterns, s.policies[name].TargetNamespaces...)
+	}
+	return patterns
+}
+
+// notify calls listeners for the source types and namespaces of the old and new policy.
+func notify(ctx context.Context, listeners []ChangeFunc, previous, current *Policy) {
+	affected := make(map[schema.GroupVersionKind][]string)
+	for _, p := range []*Policy{previous, current} {
+		if p != nil {
+			affected[p.GVK] = append(affected[p.GVK], p.Namespace)
+		}
+	}
+	for gvk, namespaces := range affected {
+		for _, fn := range listeners {
+			fn(ctx, gvk, namespaces)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var secretGVK = schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+
+func makePolicy(name string, source map[string]interface{}, targets ...interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"source":           source,
+			"targetNamespaces": targets,
+		},
+	}}
+	obj.SetGroupVersionKind(GVK)
+	obj.SetName(name)
+	return obj
+}
+
+func secretSource(fields map[string]interface{}) map[string]interface{} {
+	source := map[string]interface{}{"apiVersion": "v1", "kind": "Secret"}
+	for k, v := range fields {
+		source[k] = v
+	}
+	return source
+}
+
+func mustParse(t *testing.T, obj *unstructured.Unstructured) *Policy {
+	t.Helper()
+	p, err := Parse(obj)
+	require.NoError(t, err)
+	return p
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *unstructured.Unstructured
+		wantErr string
+	}{
+		{
+			name:   "named source",
+			policy: makePolicy("p", secretSource(map[string]interface{}{"namespace": "default", "name": "creds"}), "app-*"),
+		},
+		{
+			name: "selector in every namespace",
+			policy: makePolicy("p", secretSource(map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"team": "a"}},
+			}), "all"),
+		},
+		{
+			name:    "missing apiVersion",
+			policy:  makePolicy("p", map[string]interface{}{"kind": "Secret", "name": "creds", "namespace": "default"}, "app"),
+			wantErr: "spec.source.apiVersion",
+		},
+		{
+			name:    "missing kind",
+			policy:  makePolicy("p", map[string]interface{}{"apiVersion": "v1", "name": "creds", "namespace": "default"}, "app"),
+			wantErr: "spec.source.kind is required",
+		},
+		{
+			name:    "name without namespace",
+			policy:  makePolicy("p", secretSource(map[string]interface{}{"name": "creds"}), "app"),
+			wantErr: "requires spec.source.namespace",
+		},
+		{
+			name:    "neither name nor selector",
+			policy:  makePolicy("p", secretSource(map[string]interface{}{"namespace": "default"}), "app"),
+			wantErr: "needs a name or a selector",
+		},
+		{
+			name: "invalid selector",
+			policy: makePolicy("p", secretSource(map[string]interface{}{
+				"selector": map[string]interface{}{"matchExpressions": []interface{}{
+					map[string]interface{}{"key": "team", "operator": "Sometimes"},
+				}},
+			}), "app"),
+			wantErr: "spec.source.selector is invalid",
+		},
+		{
+			name:    "no targets",
+			policy:  makePolicy("p", secretSource(map[string]interface{}{"namespace": "default", "name": "creds"})),
+			wantErr: "spec.targetNamespaces is required",
+		},
+		{
+			name:    "invalid target pattern",
+			policy:  makePolicy("p", secretSource(map[string]interface{}{"namespace": "default", "name": "creds"}), "app-["),
+			wantErr: `pattern "app-["`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.policy)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "p", p.Name)
+			assert.Equal(t, secretGVK, p.GVK)
+		})
+	}
+}
+
+func TestPolicy_Selects(t *testing.T) {
+	secret := func(namespace, name string, labels map[string]string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}}
+	}
+
+	named := mustParse(t, makePolicy("named", secretSource(map[string]interface{}{"namespace": "default", "name": "creds"}), "app"))
+	assert.True(t, named.Selects(secretGVK, secret("default", "creds", nil)))
+	assert.False(t, named.Selects(secretGVK, secret("other", "creds", nil)))
+	assert.False(t, named.Selects(secretGVK, secret("default", "tls", nil)))
+	assert.False(t, named.Selects(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, secret("default", "creds", nil)))
+
+	labeled := mustParse(t, makePolicy("labeled", secretSource(map[string]interface{}{
+		"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"team": "a"}},
+	}), "app"))
+	assert.True(t, labeled.Selects(secretGVK, secret("any", "creds", map[string]string{"team": "a"})))
+	assert.False(t, labeled.Selects(secretGVK, secret("any", "creds", map[string]string{"team": "b"})))
+}
+
+func TestStore_TargetPatterns(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	store.Set(ctx, mustParse(t, makePolicy("b-policy", secretSource(map[string]interface{}{"namespace": "default", "name": "creds"}), "team-b")))
+	store.Set(ctx, mustParse(t, makePolicy("a-policy", secretSource(map[string]interface{}{
+		"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"team": "a"}},
+	}), "team-a", "shared")))
+
+	creds := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "creds", Labels: map[string]string{"team": "a"}}}
+	assert.Equal(t, []string{"team-a", "shared", "team-b"}, store.TargetPatterns(secretGVK, creds), "ordered by policy name")
+
+	other := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other"}}
+	assert.Nil(t, store.TargetPatterns(secretGVK, other))
+
+	store.Delete(ctx, "a-policy")
+	assert.Equal(t, []string{"team-b"}, store.TargetPatterns(secretGVK, creds))
+}
+
+func TestStore_Notify(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+
+	type change struct {
+		gvk        schema.GroupVersionKind
+		namespaces []string
+	}
+	var changes []change
+	store.OnChange(func(_ context.Context, gvk schema.GroupVersionKind, namespaces []string) {
+		changes = append(changes, change{gvk, namespaces})
+	})
+
+	store.Set(ctx, mustParse(t, makePolicy("p", secretSource(map[string]interface{}{"namespace": "team-a", "name": "creds"}), "app")))
+	assert.Equal(t, []change{{secretGVK, []string{"team-a"}}}, changes)
+
+	// Moving a policy affects both its old and its new sources
+	changes = nil
+	store.Set(ctx, mustParse(t, makePolicy("p", secretSource(map[string]interface{}{"namespace": "team-b", "name": "creds"}), "app")))
+	assert.Equal(t, []change{{secretGVK, []string{"team-a", "team-b"}}}, changes)
+
+	changes = nil
+	store.Resync(ctx)
+	assert.Equal(t, []change{{secretGVK, []string{"team-b"}}}, changes)
+
+	changes = nil
+	store.Delete(ctx, "p")
+	store.Delete(ctx, "missing")
+	assert.Equal(t, []change{{secretGVK, []string{"team-b"}}}, changes, "deleting an unknown policy notifies nobody")
+}
+
+func TestStore_Load(t *testing.T) {
+	valid := makePolicy("valid", secretSource(map[string]interface{}{"namespace": "default", "name": "creds"}), "app")
+	invalid := makePolicy("invalid", secretSource(map[string]interface{}{"name": "creds"}), "app")
+
+	scheme := runtime.NewScheme()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(valid, invalid).Build()
+
+	store := NewStore()
+	var notified bool
+	store.OnChange(func(context.Context, schema.GroupVersionKind, []string) { notified = true })
+
+	require.NoError(t, store.Load(context.Background(), c))
+	assert.Equal(t, 1, store.Len(), "invalid policies are skipped")
+	assert.False(t, notified)
+}
@@ -0,0 +1,74 @@
+package policy
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ReasonInvalidPolicy is the Event reason used when a ClusterMirrorPolicy cannot be parsed.
+const ReasonInvalidPolicy = "InvalidPolicy"
+
+// Reconciler keeps a Store in sync with the ClusterMirrorPolicy objects in the cluster.
+type Reconciler struct {
+	client.Client
+	Store *Store
+	// Recorder emits Events on invalid policies (optional)
+	Recorder events.EventRecorder
+}
+
+// Reconcile loads one policy into the store, or removes it when deleted or invalid.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("policy", req.Name)
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(GVK)
+	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			logger.Info("mirror policy removed")
+			r.Store.Delete(ctx, req.Name)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !obj.GetDeletionTimestamp().IsZero() {
+		r.Store.Delete(ctx, req.Name)
+		return ctrl.Result{}, nil
+	}
+
+	p, err := Parse(obj)
+	if err != nil {
+		// An invalid policy selects nothing, so its mirrors are cleaned up
+		logger.Error(err, "invalid mirror policy, ignoring")
+		if r.Recorder != nil {
+			r.Recorder.Eventf(obj, nil, corev1.EventTypeWarning, ReasonInvalidPolicy, "Validate", "%s", err.Error())
+		}
+		r.Store.Delete(ctx, req.Name)
+		return ctrl.Result{}, nil
+	}
+
+	logger.V(1).Info("mirror policy loaded", "source", p.GVK.String(), "targets", p.TargetNamespaces)
+	r.Store.Set(ctx, p)
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager registers the reconciler. Every replica runs it, because
+// every replica reconciling sources needs the current policies.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(GVK)
+
+	needLeaderElection := false
+	return ctrl.NewControllerManagedBy(mgr).
+		For(obj).
+		Named("clustermirrorpolicy").
+		WithOptions(controller.Options{NeedLeaderElection: &needLeaderElection}).
+		Complete(r)
+}
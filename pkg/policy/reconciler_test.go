@@ -0,0 +1,45 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconciler_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	valid := makePolicy("valid", secretSource(map[string]interface{}{"namespace": "default", "name": "creds"}), "app")
+	invalid := makePolicy("invalid", secretSource(map[string]interface{}{"name": "creds"}), "app")
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithObjects(valid, invalid).Build()
+
+	store := NewStore()
+	recorder := events.NewFakeRecorder(10)
+	r := &Reconciler{Client: c, Store: store, Recorder: recorder}
+	creds := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "creds"}}
+
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "valid"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app"}, store.TargetPatterns(secretGVK, creds))
+
+	// A policy that became invalid is reported and removed, so it selects nothing
+	store.Set(ctx, mustParse(t, makePolicy("invalid", secretSource(map[string]interface{}{"namespace": "default", "name": "creds"}), "old")))
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "invalid"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+	assert.Contains(t, <-recorder.Events, ReasonInvalidPolicy)
+
+	// A deleted policy is removed from the store
+	require.NoError(t, c.Delete(ctx, valid))
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "valid"}})
+	require.NoError(t, err)
+	assert.Nil(t, store.TargetPatterns(secretGVK, creds))
+}
@@ -0,0 +1,115 @@
+// Package prune removes everything kubemirror leaves in a cluster, for use when
+// uninstalling the controller.
+//
+// Without it, uninstalling strands every mirror (labeled managed-by=kubemirror)
+// and leaves the kubemirror finalizer on sources, so deleting a source hangs
+// forever once no controller is left to remove it.
+package prune
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/listing"
+)
+
+// Result summarizes a prune run.
+type Result struct {
+	// MirrorsDeleted counts deleted objects labeled as managed by kubemirror
+	MirrorsDeleted int
+	// FinalizersRemoved counts sources the kubemirror finalizer was removed from
+	FinalizersRemoved int
+	// Unserved lists the resource types that were skipped because the cluster no longer serves them
+	Unserved []schema.GroupVersionKind
+}
+
+// Pruner deletes kubemirror-managed objects and strips kubemirror finalizers.
+// It must run while the controller is stopped, otherwise the controller recreates
+// mirrors and re-adds finalizers as fast as they are removed.
+type Pruner struct {
+	Client client.Client
+	Log    logr.Logger
+	// DryRun reports what would be changed without changing anything
+	DryRun bool
+}
+
+// Prune scans every object of each resource type across all namespaces.
+// Resource types that are not served by the cluster are skipped. Errors on
+// individual objects are collected so one failure does not strand the rest.
+func (p *Pruner) Prune(ctx context.Context, gvks []schema.GroupVersionKind) (Result, error) {
+	var (
+		result Result
+		errs   []error
+	)
+
+	for _, gvk := range gvks {
+		logger := p.Log.WithValues("kind", gvk.Kind, "group", gvk.Group, "version", gvk.Version)
+
+		err := listing.ForEach(ctx, p.Client, gvk, nil, func(obj *unstructured.Unstructured) error {
+			if err := p.removeFinalizer(ctx, obj, &result); err != nil {
+				errs = append(errs, err)
+			}
+			if err := p.deleteMirror(ctx, obj, &result); err != nil {
+				errs = append(errs, err)
+			}
+			return nil
+		})
+		if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+			logger.V(1).Info("resource type not served, skipping")
+			result.Unserved = append(result.Unserved, gvk)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list %s: %w", gvk.Kind, err))
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// removeFinalizer strips the kubemirror finalizer from a source.
+func (p *Pruner) removeFinalizer(ctx context.Context, obj *unstructured.Unstructured, result *Result) error {
+	finalizers := obj.GetFinalizers()
+	if !slices.Contains(finalizers, constants.FinalizerName) {
+		return nil
+	}
+
+	p.Log.Info("removing finalizer", "kind", obj.GetKind(), "namespace", obj.GetNamespace(), "name", obj.GetName(), "dryRun", p.DryRun)
+	if !p.DryRun {
+		patch := client.MergeFromWithOptions(obj.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		obj.SetFinalizers(slices.DeleteFunc(slices.Clone(finalizers), func(f string) bool {
+			return f == constants.FinalizerName
+		}))
+		if err := p.Client.Patch(ctx, obj, patch); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to remove finalizer from %s %s/%s: %w", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+	result.FinalizersRemoved++
+	return nil
+}
+
+// deleteMirror deletes an object labeled as managed by kubemirror.
+func (p *Pruner) deleteMirror(ctx context.Context, obj *unstructured.Unstructured, result *Result) error {
+	if obj.GetLabels()[constants.LabelManagedBy] != constants.ControllerName {
+		return nil
+	}
+
+	p.Log.Info("deleting mirror", "kind", obj.GetKind(), "namespace", obj.GetNamespace(), "name", obj.GetName(), "dryRun", p.DryRun)
+	if !p.DryRun {
+		if err := p.Client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete %s %s/%s: %w", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+	result.MirrorsDeleted++
+	return nil
+}
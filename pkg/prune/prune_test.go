@@ -0,0 +1,95 @@
+package prune
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+var (
+	secretGVK    = schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	configMapGVK = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	missingGVK   = schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+)
+
+func newFixture(t *testing.T) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	mirrorLabels := map[string]string{
+		constants.LabelManagedBy: constants.ControllerName,
+		constants.LabelMirror:    "true",
+	}
+
+	// missingGVK is not served, so listing it fails with a no-match error
+	notServed := interceptor.Funcs{List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+		if gvk := list.GetObjectKind().GroupVersionKind(); gvk.GroupKind() == missingGVK.GroupKind() {
+			return &meta.NoKindMatchError{GroupKind: gvk.GroupKind(), SearchedVersions: []string{gvk.Version}}
+		}
+		return c.List(ctx, list, opts...)
+	}}
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(notServed).WithObjects(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name: "source", Namespace: "default",
+			Labels:     map[string]string{constants.LabelEnabled: "true"},
+			Finalizers: []string{constants.FinalizerName, "other.example.com/keep"},
+		}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "team-a", Labels: mirrorLabels}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "team-b", Labels: mirrorLabels}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "team-a"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "team-a", Labels: mirrorLabels}},
+	).Build()
+}
+
+func TestPruner_Prune(t *testing.T) {
+	c := newFixture(t)
+	p := &Pruner{Client: c, Log: logr.Discard()}
+
+	result, err := p.Prune(context.Background(), []schema.GroupVersionKind{secretGVK, configMapGVK, missingGVK})
+	require.NoError(t, err, "unserved resource types are skipped")
+	assert.Equal(t, Result{MirrorsDeleted: 3, FinalizersRemoved: 1, Unserved: []schema.GroupVersionKind{missingGVK}}, result)
+
+	secrets := &corev1.SecretList{}
+	require.NoError(t, c.List(context.Background(), secrets))
+	require.Len(t, secrets.Items, 2)
+
+	source := &corev1.Secret{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "source"}, source))
+	assert.Equal(t, []string{"other.example.com/keep"}, source.Finalizers, "other finalizers are kept")
+
+	configMaps := &corev1.ConfigMapList{}
+	require.NoError(t, c.List(context.Background(), configMaps))
+	assert.Empty(t, configMaps.Items)
+}
+
+func TestPruner_DryRun(t *testing.T) {
+	c := newFixture(t)
+	p := &Pruner{Client: c, Log: logr.Discard(), DryRun: true}
+
+	result, err := p.Prune(context.Background(), []schema.GroupVersionKind{secretGVK, configMapGVK})
+	require.NoError(t, err)
+	assert.Equal(t, Result{MirrorsDeleted: 3, FinalizersRemoved: 1}, result)
+
+	secrets := &corev1.SecretList{}
+	require.NoError(t, c.List(context.Background(), secrets))
+	assert.Len(t, secrets.Items, 4, "dry run changes nothing")
+
+	source := &corev1.Secret{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "source"}, source))
+	assert.Contains(t, source.Finalizers, constants.FinalizerName)
+}
@@ -0,0 +1,139 @@
+// Package pullsecret attaches mirrored registry credentials to ServiceAccounts in
+// their target namespaces, so pods there pull images with them without listing
+// imagePullSecrets themselves.
+package pullsecret
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// DefaultServiceAccount is attached to when the source annotation is "true".
+const DefaultServiceAccount = "default"
+
+// ServiceAccounts returns the ServiceAccounts mirrors of source are attached to,
+// from its attach-to-service-accounts annotation. Only docker-registry Secrets
+// (kubernetes.io/dockerconfigjson and kubernetes.io/dockercfg) are attached.
+func ServiceAccounts(source *unstructured.Unstructured) []string {
+	value := strings.TrimSpace(source.GetAnnotations()[constants.AnnotationAttachToServiceAccounts])
+	if value == "" || value == "false" || !isRegistrySecret(source) {
+		return nil
+	}
+	if value == "true" {
+		return []string{DefaultServiceAccount}
+	}
+	return parseList(value)
+}
+
+// Recorded returns the ServiceAccounts a mirror was attached to.
+func Recorded(mirror metav1.Object) []string {
+	return parseList(mirror.GetAnnotations()[constants.AnnotationAttachedServiceAccounts])
+}
+
+// Record notes on mirror the ServiceAccounts it is attached to.
+func Record(mirror metav1.Object, serviceAccounts []string) {
+	annotations := mirror.GetAnnotations()
+	if len(serviceAccounts) == 0 {
+		if _, found := annotations[constants.AnnotationAttachedServiceAccounts]; found {
+			delete(annotations, constants.AnnotationAttachedServiceAccounts)
+			mirror.SetAnnotations(annotations)
+		}
+		return
+	}
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[constants.AnnotationAttachedServiceAccounts] = strings.Join(serviceAccounts, ",")
+	mirror.SetAnnotations(annotations)
+}
+
+// Attach appends secret to the imagePullSecrets of a ServiceAccount. It reports
+// whether the ServiceAccount changed; one that already lists secret is left alone.
+// A ServiceAccount that does not exist (yet) is an error, so the caller retries:
+// a new namespace's default ServiceAccount is created shortly after the namespace.
+func Attach(ctx context.Context, c client.Client, namespace, serviceAccount, secret string) (bool, error) {
+	sa := &corev1.ServiceAccount{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: serviceAccount}, sa); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("service account %s not found in namespace %s", serviceAccount, namespace)
+		}
+		return false, fmt.Errorf("failed to get service account %s/%s: %w", namespace, serviceAccount, err)
+	}
+	if slices.Contains(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: secret}) {
+		return false, nil
+	}
+
+	// The optimistic lock keeps concurrent edits to the list from being overwritten
+	patch := client.MergeFromWithOptions(sa.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: secret})
+	if err := c.Patch(ctx, sa, patch); err != nil {
+		return false, fmt.Errorf("failed to attach %s to service account %s/%s: %w", secret, namespace, serviceAccount, err)
+	}
+	return true, nil
+}
+
+// Detach removes secret from the imagePullSecrets of a ServiceAccount. A
+// ServiceAccount that is gone or does not list secret needs nothing.
+func Detach(ctx context.Context, c client.Client, namespace, serviceAccount, secret string) error {
+	sa := &corev1.ServiceAccount{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: serviceAccount}, sa); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get service account %s/%s: %w", namespace, serviceAccount, err)
+	}
+	ref := corev1.LocalObjectReference{Name: secret}
+	if !slices.Contains(sa.ImagePullSecrets, ref) {
+		return nil
+	}
+
+	patch := client.MergeFromWithOptions(sa.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	sa.ImagePullSecrets = slices.DeleteFunc(sa.ImagePullSecrets, func(r corev1.LocalObjectReference) bool { return r == ref })
+	if err := c.Patch(ctx, sa, patch); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to detach %s from service account %s/%s: %w", secret, namespace, serviceAccount, err)
+	}
+	return nil
+}
+
+// DetachMirror detaches a mirror about to be removed from every ServiceAccount
+// it was attached to.
+func DetachMirror(ctx context.Context, c client.Client, mirror metav1.Object) error {
+	for _, serviceAccount := range Recorded(mirror) {
+		if err := Detach(ctx, c, mirror.GetNamespace(), serviceAccount, mirror.GetName()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isRegistrySecret reports whether u is a docker-registry Secret.
+func isRegistrySecret(u *unstructured.Unstructured) bool {
+	gvk := u.GroupVersionKind()
+	if gvk.Group != "" || gvk.Version != "v1" || gvk.Kind != "Secret" {
+		return false
+	}
+	secretType, _, _ := unstructured.NestedString(u.Object, "type")
+	return corev1.SecretType(secretType) == corev1.SecretTypeDockerConfigJson ||
+		corev1.SecretType(secretType) == corev1.SecretTypeDockercfg
+}
+
+// parseList splits a comma-separated list, dropping blanks and duplicates.
+func parseList(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,134 @@
+package pullsecret
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func secret(secretType corev1.SecretType, annotations map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"type":       string(secretType),
+	}}
+	u.SetNamespace("default")
+	u.SetName("registry")
+	u.SetAnnotations(annotations)
+	return u
+}
+
+func serviceAccount(ns, name string, pullSecrets ...string) *corev1.ServiceAccount {
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name}}
+	for _, s := range pullSecrets {
+		sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: s})
+	}
+	return sa
+}
+
+func newClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func pullSecretsOf(t *testing.T, c client.Client, ns, name string) []string {
+	t.Helper()
+	sa := &corev1.ServiceAccount{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: ns, Name: name}, sa))
+	var names []string
+	for _, ref := range sa.ImagePullSecrets {
+		names = append(names, ref.Name)
+	}
+	return names
+}
+
+func TestServiceAccounts(t *testing.T) {
+	tests := []struct {
+		name       string
+		secretType corev1.SecretType
+		value      string
+		want       []string
+	}{
+		{name: "not requested", secretType: corev1.SecretTypeDockerConfigJson},
+		{name: "disabled", secretType: corev1.SecretTypeDockerConfigJson, value: "false"},
+		{name: "default service account", secretType: corev1.SecretTypeDockerConfigJson, value: "true", want: []string{"default"}},
+		{name: "legacy dockercfg", secretType: corev1.SecretTypeDockercfg, value: "true", want: []string{"default"}},
+		{name: "named service accounts", secretType: corev1.SecretTypeDockerConfigJson, value: "builder, default,,builder", want: []string{"builder", "default"}},
+		{name: "not a registry secret", secretType: corev1.SecretTypeOpaque, value: "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var annotations map[string]string
+			if tt.value != "" {
+				annotations = map[string]string{constants.AnnotationAttachToServiceAccounts: tt.value}
+			}
+			assert.Equal(t, tt.want, ServiceAccounts(secret(tt.secretType, annotations)))
+		})
+	}
+
+	configMap := secret(corev1.SecretTypeDockerConfigJson, map[string]string{constants.AnnotationAttachToServiceAccounts: "true"})
+	configMap.SetKind("ConfigMap")
+	assert.Empty(t, ServiceAccounts(configMap))
+}
+
+func TestRecord(t *testing.T) {
+	mirror := secret(corev1.SecretTypeDockerConfigJson, nil)
+	Record(mirror, []string{"default", "builder"})
+	assert.Equal(t, "default,builder", mirror.GetAnnotations()[constants.AnnotationAttachedServiceAccounts])
+	assert.Equal(t, []string{"default", "builder"}, Recorded(mirror))
+
+	Record(mirror, nil)
+	assert.NotContains(t, mirror.GetAnnotations(), constants.AnnotationAttachedServiceAccounts)
+	assert.Empty(t, Recorded(mirror))
+}
+
+func TestAttach(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, serviceAccount("team-a", "default", "existing"))
+
+	attached, err := Attach(ctx, c, "team-a", "default", "registry")
+	require.NoError(t, err)
+	assert.True(t, attached)
+	assert.Equal(t, []string{"existing", "registry"}, pullSecretsOf(t, c, "team-a", "default"))
+
+	attached, err = Attach(ctx, c, "team-a", "default", "registry")
+	require.NoError(t, err)
+	assert.False(t, attached, "already attached")
+	assert.Equal(t, []string{"existing", "registry"}, pullSecretsOf(t, c, "team-a", "default"))
+
+	_, err = Attach(ctx, c, "team-b", "default", "registry")
+	assert.ErrorContains(t, err, "service account default not found in namespace team-b")
+}
+
+func TestDetachMirror(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t,
+		serviceAccount("team-a", "default", "existing", "registry"),
+		serviceAccount("team-a", "builder", "registry"),
+	)
+
+	mirror := secret(corev1.SecretTypeDockerConfigJson, nil)
+	mirror.SetNamespace("team-a")
+	// The builder ServiceAccount is detached, and the deleted one needs nothing
+	Record(mirror, []string{"default", "builder", "deleted"})
+
+	require.NoError(t, DetachMirror(ctx, c, mirror))
+	assert.Equal(t, []string{"existing"}, pullSecretsOf(t, c, "team-a", "default"))
+	assert.Empty(t, pullSecretsOf(t, c, "team-a", "builder"))
+
+	// Detaching again is a no-op
+	require.NoError(t, DetachMirror(ctx, c, mirror))
+}
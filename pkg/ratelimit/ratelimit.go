@@ -0,0 +1,83 @@
+// Package ratelimit provides a client-side API rate limiter whose limits can be
+// changed while clients use it.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// Limiter is a flowcontrol.RateLimiter for rest.Config.RateLimiter. Set swaps the
+// token bucket behind it, so a configuration reload retunes every client built
+// from that rest.Config. A QPS of 0 or less disables limiting.
+type Limiter struct {
+	bucket flowcontrol.RateLimiter
+	qps    float32
+	burst  int
+	mu     sync.RWMutex
+}
+
+// New returns a Limiter allowing qps requests per second with the given burst.
+func New(qps float32, burst int) *Limiter {
+	l := &Limiter{}
+	l.Set(qps, burst)
+	return l
+}
+
+// Set changes the limits. Requests already waiting finish under the old limits.
+func (l *Limiter) Set(qps float32, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.bucket != nil && qps == l.qps && burst == l.burst {
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	var bucket flowcontrol.RateLimiter
+	if qps > 0 {
+		bucket = flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+	} else {
+		bucket = flowcontrol.NewFakeAlwaysRateLimiter()
+	}
+	if l.bucket != nil {
+		l.bucket.Stop()
+	}
+	l.bucket, l.qps, l.burst = bucket, qps, burst
+}
+
+// current returns the token bucket in use; callers must not hold the lock while
+// waiting on it, or Set would block behind them.
+func (l *Limiter) current() flowcontrol.RateLimiter {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.bucket
+}
+
+// TryAccept implements flowcontrol.RateLimiter.
+func (l *Limiter) TryAccept() bool {
+	return l.current().TryAccept()
+}
+
+// Accept implements flowcontrol.RateLimiter.
+func (l *Limiter) Accept() {
+	l.current().Accept()
+}
+
+// Wait implements flowcontrol.RateLimiter.
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.current().Wait(ctx)
+}
+
+// Stop implements flowcontrol.RateLimiter. Clients stop their rate limiter when
+// they are discarded; the Limiter is shared, so this is a no-op.
+func (l *Limiter) Stop() {}
+
+// QPS implements flowcontrol.RateLimiter.
+func (l *Limiter) QPS() float32 {
+	return l.current().QPS()
+}
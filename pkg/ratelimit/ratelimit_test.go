@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	l := New(1, 2)
+	assert.Equal(t, float32(1), l.QPS())
+	assert.True(t, l.TryAccept())
+	assert.True(t, l.TryAccept())
+	assert.False(t, l.TryAccept(), "burst used up")
+
+	// Raising the limits takes effect immediately
+	l.Set(1, 3)
+	assert.True(t, l.TryAccept())
+	assert.True(t, l.TryAccept())
+	assert.True(t, l.TryAccept())
+	assert.False(t, l.TryAccept())
+
+	// Unchanged limits keep the current bucket
+	l.Set(1, 3)
+	assert.False(t, l.TryAccept())
+
+	// QPS 0 disables limiting
+	l.Set(0, 0)
+	for range 100 {
+		assert.True(t, l.TryAccept())
+	}
+	assert.NoError(t, l.Wait(context.Background()))
+
+	// Stop is a no-op; clients may stop a shared limiter
+	l.Stop()
+	assert.True(t, l.TryAccept())
+}
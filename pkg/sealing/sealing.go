@@ -0,0 +1,184 @@
+// Package sealing writes Secret mirrors as Bitnami SealedSecrets, encrypted with
+// the public certificate of the cluster's sealed-secrets controller, so mirrored
+// credentials are stored encrypted and only that controller can unseal them.
+//
+// Values are sealed with strict scope: each SealedSecret only unseals under its
+// own name and namespace, like `kubeseal --scope strict` does.
+package sealing
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// GVK is the kind sealed mirrors are written as.
+var GVK = schema.GroupVersionKind{Group: "bitnami.com", Version: "v1alpha1", Kind: "SealedSecret"}
+
+// sessionKeyBytes is the size of the AES-256 key each value is encrypted with.
+const sessionKeyBytes = 32
+
+// LoadCertificate reads the public key to seal with from a PEM file, as written
+// by `kubeseal --fetch-cert`.
+func LoadCertificate(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sealing certificate: %w", err)
+	}
+	return ParseCertificate(data)
+}
+
+// ParseCertificate returns the RSA public key of a PEM certificate, or of a PEM
+// public key.
+func ParseCertificate(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("sealing certificate is not PEM encoded")
+	}
+
+	var key any
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sealing certificate: %w", err)
+		}
+		key = cert.PublicKey
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sealing public key: %w", err)
+		}
+		key = parsed
+	default:
+		return nil, fmt.Errorf("unexpected PEM block %q in sealing certificate", block.Type)
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("sealing certificate holds a %T, not an RSA public key", key)
+	}
+	return rsaKey, nil
+}
+
+// Encrypt encrypts plaintext the way the sealed-secrets controller decrypts it:
+// a random AES-GCM session key encrypts the plaintext, and RSA-OAEP with label
+// encrypts the session key. The result is the length of the encrypted session
+// key (two bytes, big endian), the encrypted session key and the ciphertext.
+func Encrypt(rnd io.Reader, key *rsa.PublicKey, plaintext, label []byte) ([]byte, error) {
+	sessionKey := make([]byte, sessionKeyBytes)
+	if _, err := io.ReadFull(rnd, sessionKey); err != nil {
+		return nil, fmt.Errorf("failed to generate session key: %w", err)
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rnd, key, sessionKey, label)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt session key: %w", err)
+	}
+
+	out := binary.BigEndian.AppendUint16(nil, uint16(len(encryptedKey)))
+	out = append(out, encryptedKey...)
+	// The session key is never reused, so a fixed nonce is safe
+	return aead.Seal(out, make([]byte, aead.NonceSize()), plaintext, nil), nil
+}
+
+// Seal returns the SealedSecret standing in for the Secret mirror: same name,
+// namespace and metadata, with every data value encrypted for key. The unsealed
+// Secret gets the mirror's type and its labels and annotations other than
+// kubemirror's own, so kubemirror never mistakes it for a mirror.
+func Seal(key *rsa.PublicKey, mirror *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	label := []byte(mirror.GetNamespace() + "/" + mirror.GetName())
+	encrypted := make(map[string]interface{})
+
+	data, _, err := unstructured.NestedStringMap(mirror.Object, "data")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret data: %w", err)
+	}
+	for k, v := range data {
+		plaintext, decodeErr := base64.StdEncoding.DecodeString(v)
+		if decodeErr != nil {
+			return nil, fmt.Errorf("failed to decode secret key %q: %w", k, decodeErr)
+		}
+		if encrypted[k], err = sealValue(key, plaintext, label); err != nil {
+			return nil, err
+		}
+	}
+	stringData, _, err := unstructured.NestedStringMap(mirror.Object, "stringData")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret stringData: %w", err)
+	}
+	for k, v := range stringData {
+		if encrypted[k], err = sealValue(key, []byte(v), label); err != nil {
+			return nil, err
+		}
+	}
+
+	templateMeta := map[string]interface{}{}
+	if labels := withoutOwnKeys(mirror.GetLabels()); len(labels) > 0 {
+		templateMeta["labels"] = labels
+	}
+	if annotations := withoutOwnKeys(mirror.GetAnnotations()); len(annotations) > 0 {
+		templateMeta["annotations"] = annotations
+	}
+	template := map[string]interface{}{"metadata": templateMeta}
+	if secretType, _, _ := unstructured.NestedString(mirror.Object, "type"); secretType != "" {
+		template["type"] = secretType
+	}
+
+	sealed := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"encryptedData": encrypted,
+			"template":      template,
+		},
+	}}
+	sealed.SetGroupVersionKind(GVK)
+	sealed.SetName(mirror.GetName())
+	sealed.SetNamespace(mirror.GetNamespace())
+	sealed.SetLabels(mirror.GetLabels())
+	sealed.SetAnnotations(mirror.GetAnnotations())
+	sealed.SetOwnerReferences(mirror.GetOwnerReferences())
+	return sealed, nil
+}
+
+// sealValue encrypts one data value and encodes it for encryptedData.
+func sealValue(key *rsa.PublicKey, plaintext, label []byte) (string, error) {
+	ciphertext, err := Encrypt(rand.Reader, key, plaintext, label)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// withoutOwnKeys returns the entries of metadata outside kubemirror's domain.
+func withoutOwnKeys(metadata map[string]string) map[string]interface{} {
+	filtered := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		if !strings.HasPrefix(k, constants.Domain+"/") {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
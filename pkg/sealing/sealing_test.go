@@ -0,0 +1,149 @@
+package sealing
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// decrypt reverses Encrypt like the sealed-secrets controller does.
+func decrypt(t *testing.T, key *rsa.PrivateKey, ciphertext, label []byte) ([]byte, error) {
+	t.Helper()
+	require.Greater(t, len(ciphertext), 2)
+	keyLen := int(binary.BigEndian.Uint16(ciphertext))
+	sessionKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, ciphertext[2:2+keyLen], label)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(sessionKey)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	return aead.Open(nil, make([]byte, aead.NonceSize()), ciphertext[2+keyLen:], nil)
+}
+
+func newKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sealed-secret"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestParseCertificate(t *testing.T) {
+	key, certPEM := newKey(t)
+
+	parsed, err := ParseCertificate(certPEM)
+	require.NoError(t, err)
+	assert.True(t, key.PublicKey.Equal(parsed))
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	parsed, err = ParseCertificate(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.True(t, key.PublicKey.Equal(parsed))
+
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	require.NoError(t, os.WriteFile(path, certPEM, 0o600))
+	parsed, err = LoadCertificate(path)
+	require.NoError(t, err)
+	assert.True(t, key.PublicKey.Equal(parsed))
+
+	_, err = ParseCertificate([]byte("not pem"))
+	assert.Error(t, err)
+	_, err = ParseCertificate(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("x")}))
+	assert.Error(t, err)
+	_, err = LoadCertificate(filepath.Join(t.TempDir(), "missing.pem"))
+	assert.Error(t, err)
+}
+
+func TestEncrypt(t *testing.T) {
+	key, _ := newKey(t)
+	label := []byte("team-a/app-secret")
+
+	ciphertext, err := Encrypt(rand.Reader, &key.PublicKey, []byte("s3cret"), label)
+	require.NoError(t, err)
+	plaintext, err := decrypt(t, key, ciphertext, label)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", string(plaintext))
+
+	_, err = decrypt(t, key, ciphertext, []byte("team-b/app-secret"))
+	assert.Error(t, err, "values only unseal under the name and namespace they were sealed for")
+
+	again, err := Encrypt(rand.Reader, &key.PublicKey, []byte("s3cret"), label)
+	require.NoError(t, err)
+	assert.NotEqual(t, ciphertext, again, "every value gets its own session key")
+}
+
+func TestSeal(t *testing.T) {
+	key, certPEM := newKey(t)
+	publicKey, err := ParseCertificate(certPEM)
+	require.NoError(t, err)
+
+	mirror := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"type":       "kubernetes.io/basic-auth",
+		"data":       map[string]interface{}{"password": base64.StdEncoding.EncodeToString([]byte("hunter2"))},
+		"stringData": map[string]interface{}{"username": "admin"},
+	}}
+	mirror.SetName("credentials")
+	mirror.SetNamespace("team-a")
+	mirror.SetLabels(map[string]string{constants.LabelManagedBy: constants.ControllerName, "app": "web"})
+	mirror.SetAnnotations(map[string]string{constants.AnnotationSourceName: "credentials", "owner": "alice"})
+
+	sealed, err := Seal(publicKey, mirror)
+	require.NoError(t, err)
+	assert.Equal(t, GVK, sealed.GroupVersionKind())
+	assert.Equal(t, "credentials", sealed.GetName())
+	assert.Equal(t, "team-a", sealed.GetNamespace())
+	assert.Equal(t, mirror.GetLabels(), sealed.GetLabels(), "the SealedSecret is the mirror kubemirror manages")
+	assert.Equal(t, mirror.GetAnnotations(), sealed.GetAnnotations())
+
+	encrypted, _, err := unstructured.NestedStringMap(sealed.Object, "spec", "encryptedData")
+	require.NoError(t, err)
+	require.Len(t, encrypted, 2)
+	for name, want := range map[string]string{"password": "hunter2", "username": "admin"} {
+		ciphertext, decodeErr := base64.StdEncoding.DecodeString(encrypted[name])
+		require.NoError(t, decodeErr)
+		plaintext, decryptErr := decrypt(t, key, ciphertext, []byte("team-a/credentials"))
+		require.NoError(t, decryptErr)
+		assert.Equal(t, want, string(plaintext))
+	}
+
+	secretType, _, _ := unstructured.NestedString(sealed.Object, "spec", "template", "type")
+	assert.Equal(t, "kubernetes.io/basic-auth", secretType)
+	labels, _, _ := unstructured.NestedStringMap(sealed.Object, "spec", "template", "metadata", "labels")
+	assert.Equal(t, map[string]string{"app": "web"}, labels, "the unsealed Secret is not a mirror")
+	annotations, _, _ := unstructured.NestedStringMap(sealed.Object, "spec", "template", "metadata", "annotations")
+	assert.Equal(t, map[string]string{"owner": "alice"}, annotations)
+
+	mirror.Object["data"] = map[string]interface{}{"password": "not base64!"}
+	_, err = Seal(publicKey, mirror)
+	assert.Error(t, err)
+}
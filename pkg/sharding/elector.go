@@ -0,0 +1,315 @@
+// Package sharding distributes kubemirror's write path across controller replicas.
+//
+// Instead of a single manager-wide leader doing all the work, each replica
+// competes for a set of named leases and only writes for the shards whose
+// lease it currently holds.
+package sharding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// inClusterNamespacePath is where the service account namespace is mounted in pods.
+var inClusterNamespacePath = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// PodNamespaceEnv names the environment variable the downward API fills with the
+// namespace of the controller pod.
+const PodNamespaceEnv = "POD_NAMESPACE"
+
+// ElectorConfig configures an Elector.
+type ElectorConfig struct {
+	// Client is used to read and write Lease objects
+	Client coordinationv1client.LeasesGetter
+	// ConfigMaps is used to read and write ConfigMap locks with ResourceLockConfigMaps
+	ConfigMaps corev1client.ConfigMapsGetter
+	// ResourceLock is the kind of object holding each lease (defaults to ResourceLockLeases)
+	ResourceLock string
+	// Namespace holds the leases (auto-detected in-cluster when empty)
+	Namespace string
+	// Identity uniquely identifies this replica (defaults to hostname plus a random suffix)
+	Identity string
+	// MaxLeases caps how many leases this replica holds at once (0 = unlimited).
+	// Leases acquired beyond the cap are released so other replicas can take them.
+	MaxLeases int
+
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+// Elector runs one leader election per named lease and tracks which ones this
+// replica currently holds. It implements manager.Runnable and does not itself
+// require manager-wide leader election.
+type Elector struct {
+	cfg       ElectorConfig
+	leading   map[string]bool
+	callbacks map[string]func(context.Context)
+	ctx       context.Context // set once Start is called
+	mu        sync.RWMutex
+}
+
+// NewElector creates an Elector, filling in the namespace and identity when unset.
+func NewElector(cfg ElectorConfig) (*Elector, error) {
+	lockType, err := ParseResourceLock(cfg.ResourceLock)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ResourceLock = lockType
+	if lockType == ResourceLockLeases && cfg.Client == nil {
+		return nil, errors.New("lease client is required")
+	}
+	if lockType == ResourceLockConfigMaps && cfg.ConfigMaps == nil {
+		return nil, errors.New("configmap client is required")
+	}
+
+	if cfg.Namespace == "" {
+		ns, err := DetectNamespace()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Namespace = ns
+	}
+
+	if cfg.Identity == "" {
+		if cfg.Identity, err = NewIdentity(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Elector{
+		cfg:       cfg,
+		leading:   make(map[string]bool),
+		callbacks: make(map[string]func(context.Context)),
+	}, nil
+}
+
+// DetectNamespace returns the namespace the controller pod runs in, from the
+// POD_NAMESPACE environment variable set through the downward API, falling back
+// to the namespace of the mounted service account.
+func DetectNamespace() (string, error) {
+	if ns := strings.TrimSpace(os.Getenv(PodNamespaceEnv)); ns != "" {
+		return ns, nil
+	}
+	data, err := os.ReadFile(inClusterNamespacePath)
+	if err != nil {
+		return "", fmt.Errorf("unable to find lease namespace (not running in-cluster? set %s): %w", PodNamespaceEnv, err)
+	}
+	ns := strings.TrimSpace(string(data))
+	if ns == "" {
+		return "", fmt.Errorf("service account namespace file %s is empty", inClusterNamespacePath)
+	}
+	return ns, nil
+}
+
+// NewIdentity returns a unique identity for this replica: its hostname plus a random suffix.
+func NewIdentity() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine identity: %w", err)
+	}
+	return hostname + "_" + string(uuid.NewUUID()), nil
+}
+
+// Identity returns the identity this replica uses in lease records.
+func (e *Elector) Identity() string {
+	return e.cfg.Identity
+}
+
+// Campaign starts competing for the named lease. onStartedLeading (optional) is
+// invoked every time the lease is acquired. Campaigns registered before Start
+// are deferred until the elector starts; repeated calls for the same name are no-ops.
+func (e *Elector) Campaign(name string, onStartedLeading func(context.Context)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, exists := e.callbacks[name]; exists {
+		return
+	}
+	e.callbacks[name] = onStartedLeading
+
+	if e.ctx != nil {
+		go e.run(e.ctx, name, onStartedLeading)
+	}
+}
+
+// IsLeader reports whether this replica currently holds the named lease.
+func (e *Elector) IsLeader(name string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leading[name]
+}
+
+// Start launches all registered campaigns and blocks until ctx is cancelled.
+func (e *Elector) Start(ctx context.Context) error {
+	e.mu.Lock()
+	e.ctx = ctx
+	for name, cb := range e.callbacks {
+		go e.run(ctx, name, cb)
+	}
+	e.mu.Unlock()
+
+	<-ctx.Done()
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every replica
+// must campaign, so the elector runs regardless of manager-wide leadership.
+func (e *Elector) NeedLeaderElection() bool {
+	return false
+}
+
+// run keeps campaigning for a lease until ctx is cancelled. LeaderElector.Run
+// returns whenever leadership is lost, so it is restarted to rejoin the race.
+func (e *Elector) run(ctx context.Context, name string, onStartedLeading func(context.Context)) {
+	logger := log.FromContext(ctx).WithName("sharding").WithValues("lease", name)
+
+	lock, err := NewResourceLock(e.cfg.ResourceLock, e.cfg.Namespace, name, e.cfg.Client, e.cfg.ConfigMaps,
+		resourcelock.ResourceLockConfig{Identity: e.cfg.Identity})
+	if err != nil {
+		logger.Error(err, "invalid leader election lock")
+		return
+	}
+
+	for ctx.Err() == nil {
+		if !e.hasCapacity() {
+			wait(ctx, e.cfg.RetryPeriod)
+			continue
+		}
+
+		runCtx, cancel := context.WithCancel(ctx)
+		var overCapacity atomic.Bool
+		elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			Name:            name,
+			LeaseDuration:   e.cfg.LeaseDuration,
+			RenewDeadline:   e.cfg.RenewDeadline,
+			RetryPeriod:     e.cfg.RetryPeriod,
+			ReleaseOnCancel: true,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(leaderCtx context.Context) {
+					if !e.acquire(name) {
+						// Other leases were won while this one was pending
+						logger.V(1).Info("lease capacity reached, releasing lease")
+						overCapacity.Store(true)
+						cancel()
+						return
+					}
+					logger.Info("acquired lease")
+					if onStartedLeading != nil {
+						onStartedLeading(leaderCtx)
+					}
+				},
+				OnStoppedLeading: func() {
+					if e.release(name) {
+						logger.Info("lost lease")
+					}
+				},
+			},
+		})
+		if err != nil {
+			cancel()
+			logger.Error(err, "invalid leader election configuration")
+			return
+		}
+
+		elector.Run(runCtx)
+		cancel()
+
+		if overCapacity.Load() {
+			// Give replicas with spare capacity a chance to pick the lease up
+			wait(ctx, e.cfg.LeaseDuration)
+		}
+	}
+}
+
+// hasCapacity reports whether this replica may hold another lease.
+func (e *Elector) hasCapacity() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.cfg.MaxLeases <= 0 || e.heldLocked() < e.cfg.MaxLeases
+}
+
+// acquire marks a lease as held unless that would exceed MaxLeases.
+func (e *Elector) acquire(name string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.cfg.MaxLeases > 0 && e.heldLocked() >= e.cfg.MaxLeases {
+		return false
+	}
+	e.leading[name] = true
+	return true
+}
+
+// release marks a lease as no longer held, reporting whether it was held.
+func (e *Elector) release(name string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	held := e.leading[name]
+	e.leading[name] = false
+	return held
+}
+
+// heldLocked counts held leases; callers must hold e.mu.
+func (e *Elector) heldLocked() int {
+	held := 0
+	for _, leading := range e.leading {
+		if leading {
+			held++
+		}
+	}
+	return held
+}
+
+// wait sleeps for d or until ctx is cancelled.
+func wait(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// ResourceTypeLeaseName returns the lease name that guards writes for a resource type,
+// e.g. "kubemirror-controller-leader-secret.v1" or
+// "kubemirror-controller-leader-ingress.v1.networking.k8s.io".
+func ResourceTypeLeaseName(prefix string, gvk schema.GroupVersionKind) string {
+	name := strings.ToLower(gvk.Kind + "." + gvk.Version)
+	if gvk.Group != "" {
+		name += "." + strings.ToLower(gvk.Group)
+	}
+	return prefix + "-" + name
+}
+
+// ResourceTypeSharder shards work by resource type: each GVK has its own lease,
+// so different replicas can own Secret and ConfigMap fan-out at the same time.
+type ResourceTypeSharder struct {
+	Elector *Elector
+	// LeasePrefix is used to build per-GVK lease names
+	LeasePrefix string
+}
+
+// Campaign starts competing for the lease of a resource type.
+func (s *ResourceTypeSharder) Campaign(gvk schema.GroupVersionKind, onStartedLeading func(context.Context)) {
+	s.Elector.Campaign(ResourceTypeLeaseName(s.LeasePrefix, gvk), onStartedLeading)
+}
+
+// IsLeaderFor reports whether this replica currently owns writes for a resource type.
+func (s *ResourceTypeSharder) IsLeaderFor(gvk schema.GroupVersionKind) bool {
+	return s.Elector.IsLeader(ResourceTypeLeaseName(s.LeasePrefix, gvk))
+}
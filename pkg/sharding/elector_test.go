@@ -0,0 +1,162 @@
+package sharding
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func newTestElector(t *testing.T, clientset *fake.Clientset, identity string) *Elector {
+	t.Helper()
+	e, err := NewElector(ElectorConfig{
+		Client:        clientset.CoordinationV1(),
+		Namespace:     "kubemirror-system",
+		Identity:      identity,
+		LeaseDuration: 2 * time.Second,
+		RenewDeadline: 1 * time.Second,
+		RetryPeriod:   100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	return e
+}
+
+func TestResourceTypeLeaseName(t *testing.T) {
+	tests := []struct {
+		gvk  schema.GroupVersionKind
+		want string
+	}{
+		{gvk: schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, want: "leader-secret.v1"},
+		{gvk: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, want: "leader-configmap.v1"},
+		{
+			gvk:  schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+			want: "leader-ingress.v1.networking.k8s.io",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, ResourceTypeLeaseName("leader", tt.gvk))
+		})
+	}
+}
+
+func TestNewElector(t *testing.T) {
+	_, err := NewElector(ElectorConfig{Namespace: "ns"})
+	assert.Error(t, err, "client is required")
+
+	e, err := NewElector(ElectorConfig{Client: fake.NewClientset().CoordinationV1(), Namespace: "ns"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, e.Identity())
+	assert.False(t, e.NeedLeaderElection())
+}
+
+func TestElector_SingleLeaderPerLease(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clientset := fake.NewClientset()
+	first := newTestElector(t, clientset, "replica-a")
+	second := newTestElector(t, clientset, "replica-b")
+
+	acquired := make(chan string, 4)
+	first.Campaign("secrets", func(context.Context) { acquired <- "replica-a" })
+
+	go func() { _ = first.Start(ctx) }()
+	require.Eventually(t, func() bool { return first.IsLeader("secrets") }, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, "replica-a", <-acquired)
+
+	// Campaigns registered after Start begin immediately
+	go func() { _ = second.Start(ctx) }()
+	second.Campaign("secrets", nil)
+	second.Campaign("configmaps", nil)
+	require.Eventually(t, func() bool { return second.IsLeader("configmaps") }, 5*time.Second, 20*time.Millisecond)
+
+	// The first replica keeps its lease, so the second must not lead it
+	assert.False(t, second.IsLeader("secrets"))
+	assert.True(t, first.IsLeader("secrets"))
+	assert.False(t, first.IsLeader("configmaps"))
+}
+
+func TestResourceTypeSharder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := newTestElector(t, fake.NewClientset(), "replica-a")
+	sharder := &ResourceTypeSharder{Elector: e, LeasePrefix: "kubemirror"}
+	secret := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+	sharder.Campaign(secret, nil)
+	assert.False(t, sharder.IsLeaderFor(secret), "not leading before start")
+
+	go func() { _ = e.Start(ctx) }()
+	require.Eventually(t, func() bool { return sharder.IsLeaderFor(secret) }, 5*time.Second, 20*time.Millisecond)
+	assert.False(t, sharder.IsLeaderFor(configMap), "no campaign for ConfigMap")
+	assert.True(t, e.IsLeader("kubemirror-secret.v1"))
+}
+
+func TestDetectNamespace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "namespace")
+	require.NoError(t, os.WriteFile(path, []byte("kubemirror-system\n"), 0o600))
+	previous := inClusterNamespacePath
+	inClusterNamespacePath = path
+	t.Cleanup(func() { inClusterNamespacePath = previous })
+
+	t.Setenv(PodNamespaceEnv, "")
+	ns, err := DetectNamespace()
+	require.NoError(t, err)
+	assert.Equal(t, "kubemirror-system", ns, "falls back to the service account namespace")
+
+	t.Setenv(PodNamespaceEnv, "from-downward-api")
+	ns, err = DetectNamespace()
+	require.NoError(t, err)
+	assert.Equal(t, "from-downward-api", ns)
+
+	t.Setenv(PodNamespaceEnv, "")
+	inClusterNamespacePath = filepath.Join(dir, "missing")
+	_, err = DetectNamespace()
+	assert.Error(t, err)
+}
+
+func TestElector_ConfigMapLock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := NewElector(ElectorConfig{Namespace: "ns", ResourceLock: ResourceLockConfigMaps})
+	assert.Error(t, err, "configmap client is required")
+	_, err = NewElector(ElectorConfig{Namespace: "ns", ResourceLock: "endpoints"})
+	assert.Error(t, err)
+
+	clientset := fake.NewClientset()
+	e, err := NewElector(ElectorConfig{
+		ConfigMaps:    clientset.CoreV1(),
+		ResourceLock:  ResourceLockConfigMaps,
+		Namespace:     "kubemirror-system",
+		Identity:      "replica-a",
+		LeaseDuration: 2 * time.Second,
+		RenewDeadline: 1 * time.Second,
+		RetryPeriod:   100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	e.Campaign("leader", nil)
+
+	go func() { _ = e.Start(ctx) }()
+	require.Eventually(t, func() bool { return e.IsLeader("leader") }, 5*time.Second, 20*time.Millisecond)
+
+	cm, err := clientset.CoreV1().ConfigMaps("kubemirror-system").Get(ctx, "leader", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, cm.Annotations[resourcelock.LeaderElectionRecordAnnotationKey], `"holderIdentity":"replica-a"`)
+	leases, err := clientset.CoordinationV1().Leases("kubemirror-system").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, leases.Items)
+}
@@ -0,0 +1,148 @@
+package sharding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// Resource lock types a leader election can be kept in.
+const (
+	// ResourceLockLeases keeps each election in a coordination.k8s.io Lease (default)
+	ResourceLockLeases = "leases"
+	// ResourceLockConfigMaps keeps each election in an annotation of a ConfigMap,
+	// for clusters without the coordination.k8s.io API
+	ResourceLockConfigMaps = "configmaps"
+)
+
+// ParseResourceLock validates a resource lock type, mapping empty to ResourceLockLeases.
+func ParseResourceLock(name string) (string, error) {
+	switch strings.TrimSpace(name) {
+	case "", ResourceLockLeases:
+		return ResourceLockLeases, nil
+	case ResourceLockConfigMaps:
+		return ResourceLockConfigMaps, nil
+	default:
+		return "", fmt.Errorf("unknown leader election resource lock %q (expected %s or %s)",
+			name, ResourceLockLeases, ResourceLockConfigMaps)
+	}
+}
+
+// NewResourceLock returns the lock of lockType for the election name in namespace.
+// Leases need the leases client, ConfigMap locks the configMaps client.
+func NewResourceLock(lockType, namespace, name string, leases coordinationv1client.LeasesGetter,
+	configMaps corev1client.ConfigMapsGetter, lockConfig resourcelock.ResourceLockConfig) (resourcelock.Interface, error) {
+	meta := metav1.ObjectMeta{Namespace: namespace, Name: name}
+	switch lockType {
+	case "", ResourceLockLeases:
+		if leases == nil {
+			return nil, errors.New("lease client is required")
+		}
+		return &resourcelock.LeaseLock{LeaseMeta: meta, Client: leases, LockConfig: lockConfig}, nil
+	case ResourceLockConfigMaps:
+		if configMaps == nil {
+			return nil, errors.New("configmap client is required")
+		}
+		return &ConfigMapLock{ConfigMapMeta: meta, Client: configMaps, LockConfig: lockConfig}, nil
+	default:
+		return nil, fmt.Errorf("unknown leader election resource lock %q", lockType)
+	}
+}
+
+// ConfigMapLock implements resourcelock.Interface on the leader election record
+// annotation of a ConfigMap, the lock client-go used before Leases existed.
+// client-go no longer ships it; it remains for clusters without Leases.
+type ConfigMapLock struct {
+	// ConfigMapMeta names the ConfigMap holding the election
+	ConfigMapMeta metav1.ObjectMeta
+	Client        corev1client.ConfigMapsGetter
+	LockConfig    resourcelock.ResourceLockConfig
+	cm            *corev1.ConfigMap
+}
+
+// Get returns the election record from the ConfigMap annotation.
+func (l *ConfigMapLock) Get(ctx context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	cm, err := l.Client.ConfigMaps(l.ConfigMapMeta.Namespace).Get(ctx, l.ConfigMapMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	l.cm = cm
+
+	var record resourcelock.LeaderElectionRecord
+	recordBytes, found := cm.Annotations[resourcelock.LeaderElectionRecordAnnotationKey]
+	if found {
+		if err := json.Unmarshal([]byte(recordBytes), &record); err != nil {
+			return nil, nil, fmt.Errorf("invalid leader election record in configmap %s: %w", l.Describe(), err)
+		}
+	}
+	return &record, []byte(recordBytes), nil
+}
+
+// Create creates the ConfigMap holding the election record.
+func (l *ConfigMapLock) Create(ctx context.Context, record resourcelock.LeaderElectionRecord) error {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	l.cm, err = l.Client.ConfigMaps(l.ConfigMapMeta.Namespace).Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      l.ConfigMapMeta.Name,
+			Namespace: l.ConfigMapMeta.Namespace,
+			Annotations: map[string]string{
+				resourcelock.LeaderElectionRecordAnnotationKey: string(recordBytes),
+			},
+		},
+	}, metav1.CreateOptions{})
+	return err
+}
+
+// Update writes the election record to the ConfigMap read by Get or Create.
+func (l *ConfigMapLock) Update(ctx context.Context, record resourcelock.LeaderElectionRecord) error {
+	if l.cm == nil {
+		return errors.New("configmap not initialized, call get or create first")
+	}
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if l.cm.Annotations == nil {
+		l.cm.Annotations = make(map[string]string)
+	}
+	l.cm.Annotations[resourcelock.LeaderElectionRecordAnnotationKey] = string(recordBytes)
+
+	cm, err := l.Client.ConfigMaps(l.ConfigMapMeta.Namespace).Update(ctx, l.cm, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	l.cm = cm
+	return nil
+}
+
+// RecordEvent records a leader election Event on the ConfigMap.
+func (l *ConfigMapLock) RecordEvent(s string) {
+	if l.LockConfig.EventRecorder == nil || l.cm == nil {
+		return
+	}
+	subject := &corev1.ConfigMap{ObjectMeta: l.cm.ObjectMeta}
+	subject.Kind = "ConfigMap"
+	subject.APIVersion = corev1.SchemeGroupVersion.String()
+	l.LockConfig.EventRecorder.Eventf(subject, corev1.EventTypeNormal, "LeaderElection", "%v %v", l.LockConfig.Identity, s)
+}
+
+// Describe returns the namespace/name of the ConfigMap.
+func (l *ConfigMapLock) Describe() string {
+	return fmt.Sprintf("%v/%v", l.ConfigMapMeta.Namespace, l.ConfigMapMeta.Name)
+}
+
+// Identity returns the identity of this replica.
+func (l *ConfigMapLock) Identity() string {
+	return l.LockConfig.Identity
+}
@@ -0,0 +1,88 @@
+package sharding
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func TestParseResourceLock(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "", want: ResourceLockLeases},
+		{name: "leases", want: ResourceLockLeases},
+		{name: "configmaps", want: ResourceLockConfigMaps},
+		{name: "endpoints", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseResourceLock(tt.name)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewResourceLock(t *testing.T) {
+	clientset := fake.NewClientset()
+	lockConfig := resourcelock.ResourceLockConfig{Identity: "replica-a"}
+
+	lock, err := NewResourceLock(ResourceLockLeases, "ns", "leader", clientset.CoordinationV1(), nil, lockConfig)
+	require.NoError(t, err)
+	assert.IsType(t, &resourcelock.LeaseLock{}, lock)
+
+	lock, err = NewResourceLock(ResourceLockConfigMaps, "ns", "leader", nil, clientset.CoreV1(), lockConfig)
+	require.NoError(t, err)
+	assert.IsType(t, &ConfigMapLock{}, lock)
+	assert.Equal(t, "ns/leader", lock.Describe())
+	assert.Equal(t, "replica-a", lock.Identity())
+
+	_, err = NewResourceLock(ResourceLockConfigMaps, "ns", "leader", clientset.CoordinationV1(), nil, lockConfig)
+	assert.Error(t, err)
+	_, err = NewResourceLock("endpoints", "ns", "leader", clientset.CoordinationV1(), clientset.CoreV1(), lockConfig)
+	assert.Error(t, err)
+}
+
+func TestConfigMapLock(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewClientset()
+	lock := &ConfigMapLock{
+		ConfigMapMeta: metav1.ObjectMeta{Namespace: "ns", Name: "leader"},
+		Client:        clientset.CoreV1(),
+		LockConfig:    resourcelock.ResourceLockConfig{Identity: "replica-a"},
+	}
+
+	_, _, err := lock.Get(ctx)
+	require.Error(t, err, "no configmap yet")
+	assert.Error(t, lock.Update(ctx, resourcelock.LeaderElectionRecord{}), "update needs a get or create first")
+
+	now := metav1.NewTime(time.Now().Truncate(time.Second))
+	record := resourcelock.LeaderElectionRecord{HolderIdentity: "replica-a", LeaseDurationSeconds: 15, AcquireTime: now, RenewTime: now}
+	require.NoError(t, lock.Create(ctx, record))
+
+	got, raw, err := lock.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "replica-a", got.HolderIdentity)
+	assert.NotEmpty(t, raw)
+
+	record.HolderIdentity = "replica-b"
+	require.NoError(t, lock.Update(ctx, record))
+	got, _, err = lock.Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "replica-b", got.HolderIdentity)
+
+	lock.RecordEvent("became leader") // no recorder configured
+}
@@ -0,0 +1,81 @@
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// NamespaceShardLeaseName returns the lease name guarding one namespace shard,
+// e.g. "kubemirror-controller-leader-ns-shard-3".
+func NamespaceShardLeaseName(prefix string, shard int) string {
+	return fmt.Sprintf("%s-ns-shard-%d", prefix, shard)
+}
+
+// ShardForNamespace deterministically maps a namespace to one of shards buckets.
+// Every replica computes the same mapping, so no coordination is needed beyond the leases.
+func ShardForNamespace(namespace string, shards int) int {
+	if shards <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(namespace))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// NamespaceSharder shards the write path by target namespace: namespaces are hashed
+// into a fixed number of shards, each guarded by a lease, and a replica only writes
+// mirrors into namespaces whose shard lease it holds.
+type NamespaceSharder struct {
+	elector     *Elector
+	leasePrefix string
+	shards      int
+	callbacks   []func(context.Context)
+	mu          sync.RWMutex
+}
+
+// NewNamespaceSharder creates a sharder and campaigns for every shard lease.
+// Use ElectorConfig.MaxLeases to spread shards across replicas.
+func NewNamespaceSharder(elector *Elector, leasePrefix string, shards int) *NamespaceSharder {
+	s := &NamespaceSharder{
+		elector:     elector,
+		leasePrefix: leasePrefix,
+		shards:      shards,
+	}
+
+	for i := 0; i < shards; i++ {
+		elector.Campaign(NamespaceShardLeaseName(leasePrefix, i), s.shardAcquired)
+	}
+
+	return s
+}
+
+// Shards returns the total number of namespace shards.
+func (s *NamespaceSharder) Shards() int {
+	return s.shards
+}
+
+// OwnsNamespace reports whether this replica currently writes mirrors into namespace.
+func (s *NamespaceSharder) OwnsNamespace(namespace string) bool {
+	shard := ShardForNamespace(namespace, s.shards)
+	return s.elector.IsLeader(NamespaceShardLeaseName(s.leasePrefix, shard))
+}
+
+// OnShardAcquired registers fn to run every time this replica acquires a shard,
+// so work skipped while the shard was owned elsewhere can be caught up.
+func (s *NamespaceSharder) OnShardAcquired(fn func(context.Context)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.callbacks = append(s.callbacks, fn)
+}
+
+func (s *NamespaceSharder) shardAcquired(ctx context.Context) {
+	s.mu.RLock()
+	callbacks := append([]func(context.Context){}, s.callbacks...)
+	s.mu.RUnlock()
+
+	for _, fn := range callbacks {
+		fn(ctx)
+	}
+}
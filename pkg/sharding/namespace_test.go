@@ -0,0 +1,104 @@
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestShardForNamespace(t *testing.T) {
+	assert.Equal(t, 0, ShardForNamespace("anything", 0))
+	assert.Equal(t, 0, ShardForNamespace("anything", 1))
+
+	// Deterministic and within range
+	counts := make(map[int]int)
+	for i := 0; i < 200; i++ {
+		ns := fmt.Sprintf("team-%d", i)
+		shard := ShardForNamespace(ns, 4)
+		assert.Equal(t, shard, ShardForNamespace(ns, 4))
+		assert.GreaterOrEqual(t, shard, 0)
+		assert.Less(t, shard, 4)
+		counts[shard]++
+	}
+
+	// Every shard gets some namespaces
+	assert.Len(t, counts, 4)
+}
+
+func TestNamespaceShardLeaseName(t *testing.T) {
+	assert.Equal(t, "kubemirror-ns-shard-3", NamespaceShardLeaseName("kubemirror", 3))
+}
+
+func TestNamespaceSharder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := newTestElector(t, fake.NewClientset(), "replica-a")
+	sharder := NewNamespaceSharder(e, "kubemirror", 2)
+	assert.Equal(t, 2, sharder.Shards())
+
+	var acquired atomic.Int32
+	sharder.OnShardAcquired(func(context.Context) { acquired.Add(1) })
+
+	assert.False(t, sharder.OwnsNamespace("team-a"), "no shards held before start")
+
+	go func() { _ = e.Start(ctx) }()
+	require.Eventually(t, func() bool { return acquired.Load() == 2 }, 5*time.Second, 20*time.Millisecond)
+	assert.True(t, sharder.OwnsNamespace("team-a"))
+	assert.True(t, sharder.OwnsNamespace("team-b"))
+}
+
+func TestElector_MaxLeasesSpreadsShards(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clientset := fake.NewClientset()
+	newCapped := func(identity string) *Elector {
+		e, err := NewElector(ElectorConfig{
+			Client:        clientset.CoordinationV1(),
+			Namespace:     "kubemirror-system",
+			Identity:      identity,
+			MaxLeases:     2,
+			LeaseDuration: 2 * time.Second,
+			RenewDeadline: 1 * time.Second,
+			RetryPeriod:   100 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		return e
+	}
+
+	first := newCapped("replica-a")
+	second := newCapped("replica-b")
+	firstSharder := NewNamespaceSharder(first, "kubemirror", 4)
+	secondSharder := NewNamespaceSharder(second, "kubemirror", 4)
+
+	go func() { _ = first.Start(ctx) }()
+	require.Eventually(t, func() bool { return held(first, 4) == 2 }, 5*time.Second, 20*time.Millisecond)
+
+	go func() { _ = second.Start(ctx) }()
+	require.Eventually(t, func() bool { return held(second, 4) == 2 }, 15*time.Second, 50*time.Millisecond)
+
+	// Every shard is owned by exactly one replica
+	for shard := 0; shard < 4; shard++ {
+		name := NamespaceShardLeaseName("kubemirror", shard)
+		assert.NotEqual(t, first.IsLeader(name), second.IsLeader(name), "shard %d", shard)
+	}
+	assert.Equal(t, firstSharder.Shards(), secondSharder.Shards())
+}
+
+// held counts the namespace shard leases an elector holds.
+func held(e *Elector, shards int) int {
+	count := 0
+	for shard := 0; shard < shards; shard++ {
+		if e.IsLeader(NamespaceShardLeaseName("kubemirror", shard)) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,475 @@
+// Package status provides pluggable backends for reporting mirror sync status.
+//
+// Writing status onto the source object itself causes write amplification,
+// GitOps drift and extra reconcile loops, so the default backend only emits
+// Events. The legacy annotation backend and a companion MirrorStatus resource
+// are available as alternatives.
+package status
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// Supported status backends.
+const (
+	// BackendAnnotation writes the sync-status annotation onto the source (legacy behavior)
+	BackendAnnotation = "annotation"
+	// BackendEvents emits Kubernetes Events on the source without mutating it
+	BackendEvents = "events"
+	// BackendResource maintains a companion MirrorStatus resource next to the source
+	BackendResource = "resource"
+
+	// DefaultBackend is the non-mutating backend used when none is configured
+	DefaultBackend = BackendEvents
+)
+
+// Event reasons used by the events backend.
+const (
+	ReasonSynced     = "Synced"
+	ReasonSyncFailed = "SyncFailed"
+	ReasonPaused     = "Paused"
+	// ReasonInvalidPattern is the condition reason used when target-namespaces
+	// holds patterns that match no namespace
+	ReasonInvalidPattern = "InvalidPattern"
+)
+
+// Condition types of MirrorStatus, following the metav1.Condition conventions so
+// kstatus, Argo CD health checks and `kubectl wait --for=condition=Ready` work.
+const (
+	// ConditionReady is True when every target namespace holds a current mirror
+	ConditionReady = "Ready"
+	// ConditionDegraded is True when at least one mirror could not be written
+	ConditionDegraded = "Degraded"
+	// ConditionProgressing is True while targets wait for lower sync waves
+	ConditionProgressing = "Progressing"
+)
+
+// ReasonWaiting is the condition reason used while targets wait for lower sync waves.
+const ReasonWaiting = "Waiting"
+
+// MirrorStatusGVK is the GroupVersionKind of the companion status resource.
+var MirrorStatusGVK = schema.GroupVersionKind{
+	Group:   constants.Domain,
+	Version: "v1alpha1",
+	Kind:    "MirrorStatus",
+}
+
+// Target sync states.
+const (
+	// TargetSynced means the mirror matches the source
+	TargetSynced = "synced"
+	// TargetFailed means the mirror could not be written
+	TargetFailed = "failed"
+	// TargetSkipped means the target holds an object kubemirror does not manage,
+	// or is over the source's max-targets limit
+	TargetSkipped = "skipped"
+	// TargetWaiting means the target still misses the mirrors of lower sync waves
+	TargetWaiting = "waiting"
+)
+
+// maxEventTargets bounds the failed targets listed in a single Event.
+const maxEventTargets = 5
+
+// maxWebhookErrorLength bounds the webhook-error annotation written onto sources.
+const maxWebhookErrorLength = 1024
+
+// TargetStatus is the sync state of one target namespace.
+type TargetStatus struct {
+	// Cluster is the remote cluster of the target (empty = the local cluster)
+	Cluster   string
+	Namespace string
+	State     string
+	// Error is why the target failed, was skipped or is waiting
+	Error string
+	// LastSyncTime is when the mirror was last confirmed in sync (zero unless synced)
+	LastSyncTime time.Time
+	// ContentHash is the source content hash the mirror holds (synced only)
+	ContentHash string
+	// WebhookError is the message admission rejected the mirror write with (failed only)
+	WebhookError string
+}
+
+// Result summarizes the outcome of reconciling one source.
+type Result struct {
+	Reconciled int
+	Errors     int
+	// Targets holds the state of each target namespace (optional)
+	Targets []TargetStatus
+	// Paused names the scope mirroring is paused at: "controller", "resource-type"
+	// or "source" (empty = not paused). Paused sources have no targets reconciled.
+	Paused string
+	// InvalidPatterns are the target-namespaces patterns that are not valid globs
+	// and were skipped
+	InvalidPatterns []string
+}
+
+// FailedTargets returns the failed targets: namespaces, or "cluster/namespace"
+// for remote clusters ("cluster" alone when the whole cluster failed).
+func (r Result) FailedTargets() []string {
+	var failed []string
+	for _, t := range r.Targets {
+		if t.State != TargetFailed {
+			continue
+		}
+		switch {
+		case t.Cluster == "":
+			failed = append(failed, t.Namespace)
+		case t.Namespace == "":
+			failed = append(failed, t.Cluster)
+		default:
+			failed = append(failed, t.Cluster+"/"+t.Namespace)
+		}
+	}
+	return failed
+}
+
+// WebhookErrors returns the admission rejections of failed targets as
+// "namespace: message" entries separated by "; ", bounded in length.
+func (r Result) WebhookErrors() string {
+	var entries []string
+	for _, t := range r.Targets {
+		if t.State != TargetFailed || t.WebhookError == "" {
+			continue
+		}
+		target := t.Namespace
+		if t.Cluster != "" {
+			target = t.Cluster + "/" + t.Namespace
+		}
+		entries = append(entries, target+": "+t.WebhookError)
+	}
+	joined := strings.Join(entries, "; ")
+	if len(joined) > maxWebhookErrorLength {
+		joined = joined[:maxWebhookErrorLength]
+	}
+	return joined
+}
+
+// String returns the compact form stored in the sync-status annotation.
+func (r Result) String() string {
+	if r.Paused != "" {
+		return "paused:" + r.Paused
+	}
+	if len(r.InvalidPatterns) > 0 {
+		return fmt.Sprintf("invalid-pattern,reconciled:%d,errors:%d", r.Reconciled, r.Errors)
+	}
+	return fmt.Sprintf("reconciled:%d,errors:%d", r.Reconciled, r.Errors)
+}
+
+// Reporter publishes the sync result of a source somewhere observable.
+type Reporter interface {
+	Report(ctx context.Context, source *unstructured.Unstructured, result Result) error
+}
+
+// ParseBackend validates a backend name, mapping empty to DefaultBackend.
+func ParseBackend(name string) (string, error) {
+	switch strings.TrimSpace(name) {
+	case "":
+		return DefaultBackend, nil
+	case BackendAnnotation, BackendEvents, BackendResource:
+		return strings.TrimSpace(name), nil
+	default:
+		return "", fmt.Errorf("unknown status backend %q (expected %s, %s or %s)",
+			name, BackendAnnotation, BackendEvents, BackendResource)
+	}
+}
+
+// NewReporter creates a Reporter for the named backend.
+func NewReporter(backend string, c client.Client, recorder events.EventRecorder) (Reporter, error) {
+	backend, err := ParseBackend(backend)
+	if err != nil {
+		return nil, err
+	}
+
+	switch backend {
+	case BackendAnnotation:
+		return &AnnotationReporter{Client: c}, nil
+	case BackendResource:
+		return &ResourceReporter{Client: c}, nil
+	default:
+		return &EventReporter{Recorder: recorder}, nil
+	}
+}
+
+// AnnotationReporter writes the sync-status annotation onto the source object.
+type AnnotationReporter struct {
+	Client client.Client
+}
+
+// Report implements Reporter.
+func (a *AnnotationReporter) Report(ctx context.Context, source *unstructured.Unstructured, result Result) error {
+	annotations := source.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[constants.AnnotationSyncStatus] = result.String()
+	if failed := result.FailedTargets(); len(failed) > 0 {
+		annotations[constants.AnnotationFailedTargets] = strings.Join(failed, ",")
+	} else {
+		delete(annotations, constants.AnnotationFailedTargets)
+	}
+	if webhookErrors := result.WebhookErrors(); webhookErrors != "" {
+		annotations[constants.AnnotationWebhookError] = webhookErrors
+	} else {
+		delete(annotations, constants.AnnotationWebhookError)
+	}
+	source.SetAnnotations(annotations)
+
+	return a.Client.Update(ctx, source)
+}
+
+// EventReporter emits an Event on the source object for each sync.
+type EventReporter struct {
+	Recorder events.EventRecorder
+}
+
+// Report implements Reporter.
+func (e *EventReporter) Report(_ context.Context, source *unstructured.Unstructured, result Result) error {
+	if e.Recorder == nil {
+		return nil
+	}
+
+	if result.Paused != "" {
+		e.Recorder.Eventf(source, nil, corev1.EventTypeNormal, ReasonPaused, "Sync",
+			"mirroring paused (%s), mirrors are kept but not updated", result.Paused)
+		return nil
+	}
+
+	// The controller warns about invalid patterns itself; there is nothing else to say
+	if len(result.InvalidPatterns) > 0 && result.Reconciled == 0 && result.Errors == 0 {
+		return nil
+	}
+
+	if result.Errors > 0 {
+		note := fmt.Sprintf("failed to sync %d of %d mirrors", result.Errors, result.Reconciled+result.Errors)
+		if failed := result.FailedTargets(); len(failed) > maxEventTargets {
+			note += fmt.Sprintf(" (%s and %d more)", strings.Join(failed[:maxEventTargets], ", "), len(failed)-maxEventTargets)
+		} else if len(failed) > 0 {
+			note += fmt.Sprintf(" (%s)", strings.Join(failed, ", "))
+		}
+		e.Recorder.Eventf(source, nil, corev1.EventTypeWarning, ReasonSyncFailed, "Sync", "%s", note)
+		return nil
+	}
+
+	e.Recorder.Eventf(source, nil, corev1.EventTypeNormal, ReasonSynced, "Sync",
+		"synced %d mirrors", result.Reconciled)
+	return nil
+}
+
+// ResourceReporter maintains a MirrorStatus resource in the source namespace.
+// The resource is written with server-side apply so it never touches the source.
+type ResourceReporter struct {
+	Client client.Client
+}
+
+// Report implements Reporter.
+func (r *ResourceReporter) Report(ctx context.Context, source *unstructured.Unstructured, result Result) error {
+	obj := BuildMirrorStatus(source, result, time.Now())
+
+	// Conditions keep their transition time while their status holds
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(MirrorStatusGVK)
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	switch {
+	case err == nil:
+		keepTransitionTimes(obj, existing)
+	case !apierrors.IsNotFound(err):
+		return fmt.Errorf("failed to get MirrorStatus: %w", err)
+	}
+
+	return r.Client.Apply(ctx, client.ApplyConfigurationFromUnstructured(obj),
+		client.FieldOwner(constants.ControllerName), client.ForceOwnership)
+}
+
+// MirrorStatusName returns the name of the companion status resource for a source.
+// The kind is included so sources of different types with the same name don't collide.
+func MirrorStatusName(source *unstructured.Unstructured) string {
+	return source.GetName() + "." + strings.ToLower(source.GetKind())
+}
+
+// BuildMirrorStatus builds the desired MirrorStatus resource for a source.
+func BuildMirrorStatus(source *unstructured.Unstructured, result Result, now time.Time) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(MirrorStatusGVK)
+	obj.SetNamespace(source.GetNamespace())
+	obj.SetName(MirrorStatusName(source))
+	obj.SetLabels(map[string]string{
+		constants.LabelManagedBy: constants.ControllerName,
+	})
+
+	obj.Object["spec"] = map[string]interface{}{
+		"sourceRef": map[string]interface{}{
+			"apiVersion": source.GetAPIVersion(),
+			"kind":       source.GetKind(),
+			"name":       source.GetName(),
+			"uid":        string(source.GetUID()),
+		},
+	}
+	status := map[string]interface{}{
+		"reconciled":              int64(result.Reconciled),
+		"errors":                  int64(result.Errors),
+		"summary":                 result.String(),
+		"observedResourceVersion": source.GetResourceVersion(),
+	}
+	if result.Paused != "" {
+		status["paused"] = result.Paused
+	} else {
+		status["lastSyncTime"] = now.UTC().Format(time.RFC3339)
+	}
+	if len(result.Targets) > 0 {
+		status["targets"] = buildTargets(result.Targets)
+	}
+	if len(result.InvalidPatterns) > 0 {
+		patterns := make([]interface{}, 0, len(result.InvalidPatterns))
+		for _, p := range result.InvalidPatterns {
+			patterns = append(patterns, p)
+		}
+		status["invalidPatterns"] = patterns
+	}
+	status["conditions"] = buildConditions(result, now)
+	obj.Object["status"] = status
+
+	return obj
+}
+
+// buildTargets converts target states to status.targets entries, sorted by namespace.
+func buildTargets(targets []TargetStatus) []interface{} {
+	sorted := slices.Clone(targets)
+	slices.SortFunc(sorted, func(a, b TargetStatus) int {
+		if c := strings.Compare(a.Cluster, b.Cluster); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Namespace, b.Namespace)
+	})
+
+	entries := make([]interface{}, 0, len(sorted))
+	for _, t := range sorted {
+		entry := map[string]interface{}{"state": t.State}
+		if t.Cluster != "" {
+			entry["cluster"] = t.Cluster
+		}
+		if t.Namespace != "" {
+			entry["namespace"] = t.Namespace
+		}
+		if t.Error != "" {
+			entry["error"] = t.Error
+		}
+		if !t.LastSyncTime.IsZero() {
+			entry["lastSyncTime"] = t.LastSyncTime.UTC().Format(time.RFC3339)
+		}
+		if t.ContentHash != "" {
+			entry["contentHash"] = t.ContentHash
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// buildConditions derives the Ready, Degraded and Progressing conditions from a
+// result, all transitioning at now.
+func buildConditions(result Result, now time.Time) []interface{} {
+	ready := condition(ConditionReady, false, ReasonSyncFailed, "")
+	degraded := condition(ConditionDegraded, false, ReasonSynced, "")
+	progressing := condition(ConditionProgressing, false, ReasonSynced, "")
+
+	waiting := 0
+	for _, t := range result.Targets {
+		if t.State == TargetWaiting {
+			waiting++
+		}
+	}
+	if waiting > 0 {
+		progressing = condition(ConditionProgressing, true, ReasonWaiting,
+			fmt.Sprintf("%d target namespace(s) wait for lower sync waves", waiting))
+	}
+
+	switch {
+	case result.Paused != "":
+		message := fmt.Sprintf("mirroring is paused at %s scope", result.Paused)
+		ready = condition(ConditionReady, false, ReasonPaused, message)
+		degraded = condition(ConditionDegraded, false, ReasonPaused, message)
+		progressing = condition(ConditionProgressing, false, ReasonPaused, message)
+	case result.Errors > 0:
+		message := fmt.Sprintf("%d of %d mirror(s) failed", result.Errors, result.Reconciled+result.Errors)
+		if failed := result.FailedTargets(); len(failed) > 0 {
+			message += ": " + strings.Join(failed, ", ")
+		}
+		ready = condition(ConditionReady, false, ReasonSyncFailed, message)
+		degraded = condition(ConditionDegraded, true, ReasonSyncFailed, message)
+	case len(result.InvalidPatterns) > 0:
+		message := fmt.Sprintf("invalid target-namespaces pattern(s) skipped: %s", strings.Join(result.InvalidPatterns, ", "))
+		ready = condition(ConditionReady, false, ReasonInvalidPattern, message)
+		degraded = condition(ConditionDegraded, true, ReasonInvalidPattern, message)
+	case waiting > 0:
+		ready = condition(ConditionReady, false, ReasonWaiting, progressing["message"].(string))
+	default:
+		message := fmt.Sprintf("%d mirror(s) in sync", result.Reconciled)
+		ready = condition(ConditionReady, true, ReasonSynced, message)
+		degraded["message"] = message
+		progressing["message"] = message
+	}
+
+	conditions := []interface{}{ready, degraded, progressing}
+	for _, c := range conditions {
+		c.(map[string]interface{})["lastTransitionTime"] = now.UTC().Format(time.RFC3339)
+	}
+	return conditions
+}
+
+// condition returns a status.conditions entry.
+func condition(conditionType string, status bool, reason, message string) map[string]interface{} {
+	conditionStatus := metav1.ConditionFalse
+	if status {
+		conditionStatus = metav1.ConditionTrue
+	}
+	return map[string]interface{}{
+		"type":    conditionType,
+		"status":  string(conditionStatus),
+		"reason":  reason,
+		"message": message,
+	}
+}
+
+// keepTransitionTimes carries the lastTransitionTime of each condition in
+// existing over to desired when the condition status did not change.
+func keepTransitionTimes(desired, existing *unstructured.Unstructured) {
+	previous, _, _ := unstructured.NestedSlice(existing.Object, "status", "conditions")
+	conditions, _, _ := unstructured.NestedSlice(desired.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, p := range previous {
+			prev, ok := p.(map[string]interface{})
+			if !ok || prev["type"] != cond["type"] || prev["status"] != cond["status"] {
+				continue
+			}
+			if transition, ok := prev["lastTransitionTime"].(string); ok && transition != "" {
+				cond["lastTransitionTime"] = transition
+			}
+		}
+	}
+	_ = unstructured.SetNestedSlice(desired.Object, conditions, "status", "conditions")
+}
+
+// Ensure reporters implement the interface.
+var (
+	_ Reporter = &AnnotationReporter{}
+	_ Reporter = &EventReporter{}
+	_ Reporter = &ResourceReporter{}
+)
@@ -0,0 +1,341 @@
+package status
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func makeSource() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("ConfigMap")
+	u.SetNamespace("default")
+	u.SetName("app-config")
+	u.SetUID(types.UID("uid-123"))
+	u.SetAnnotations(map[string]string{constants.AnnotationSync: "true"})
+	return u
+}
+
+func TestParseBackend(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "", want: BackendEvents},
+		{input: "events", want: BackendEvents},
+		{input: "annotation", want: BackendAnnotation},
+		{input: " resource ", want: BackendResource},
+		{input: "crd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseBackend(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewReporter(t *testing.T) {
+	c := fake.NewClientBuilder().Build()
+
+	r, err := NewReporter("", c, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &EventReporter{}, r)
+
+	r, err = NewReporter(BackendAnnotation, c, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &AnnotationReporter{}, r)
+
+	r, err = NewReporter(BackendResource, c, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &ResourceReporter{}, r)
+
+	_, err = NewReporter("bogus", c, nil)
+	assert.Error(t, err)
+}
+
+func TestAnnotationReporter(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	source := makeSource()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(source.DeepCopy()).Build()
+
+	// Reporter updates the object it is given, so fetch the stored version first
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(source.GroupVersionKind())
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(source), current))
+
+	reporter := &AnnotationReporter{Client: c}
+	require.NoError(t, reporter.Report(context.Background(), current, Result{Reconciled: 3, Errors: 1}))
+
+	stored := &unstructured.Unstructured{}
+	stored.SetGroupVersionKind(source.GroupVersionKind())
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(source), stored))
+	assert.Equal(t, "reconciled:3,errors:1", stored.GetAnnotations()[constants.AnnotationSyncStatus])
+	assert.NotContains(t, stored.GetAnnotations(), constants.AnnotationFailedTargets)
+
+	// Failed targets are listed, and cleared once they recover
+	failed := Result{Reconciled: 1, Errors: 2, Targets: []TargetStatus{
+		{Namespace: "app1", State: TargetSynced},
+		{Namespace: "app2", State: TargetFailed, Error: "denied"},
+		{Namespace: "app3", State: TargetFailed, Error: "denied"},
+	}}
+	failed.Targets[1].WebhookError = "admission webhook denied the request"
+	require.NoError(t, reporter.Report(context.Background(), stored, failed))
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(source), stored))
+	assert.Equal(t, "app2,app3", stored.GetAnnotations()[constants.AnnotationFailedTargets])
+	assert.Equal(t, "app2: admission webhook denied the request", stored.GetAnnotations()[constants.AnnotationWebhookError])
+
+	require.NoError(t, reporter.Report(context.Background(), stored, Result{Reconciled: 3}))
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(source), stored))
+	assert.NotContains(t, stored.GetAnnotations(), constants.AnnotationFailedTargets)
+	assert.NotContains(t, stored.GetAnnotations(), constants.AnnotationWebhookError)
+}
+
+func TestEventReporter(t *testing.T) {
+	recorder := events.NewFakeRecorder(10)
+	reporter := &EventReporter{Recorder: recorder}
+	source := makeSource()
+
+	require.NoError(t, reporter.Report(context.Background(), source, Result{Reconciled: 2}))
+	event := <-recorder.Events
+	assert.Contains(t, event, corev1.EventTypeNormal)
+	assert.Contains(t, event, ReasonSynced)
+	assert.Contains(t, event, "synced 2 mirrors")
+
+	require.NoError(t, reporter.Report(context.Background(), source, Result{Reconciled: 2, Errors: 1}))
+	event = <-recorder.Events
+	assert.Contains(t, event, corev1.EventTypeWarning)
+	assert.Contains(t, event, ReasonSyncFailed)
+	assert.Contains(t, event, "failed to sync 1 of 3 mirrors")
+
+	var targets []TargetStatus
+	for _, ns := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		targets = append(targets, TargetStatus{Namespace: ns, State: TargetFailed})
+	}
+	require.NoError(t, reporter.Report(context.Background(), source, Result{Errors: 7, Targets: targets}))
+	event = <-recorder.Events
+	assert.Contains(t, event, "failed to sync 7 of 7 mirrors (a, b, c, d, e and 2 more)")
+
+	require.NoError(t, reporter.Report(context.Background(), source, Result{Paused: "source"}))
+	event = <-recorder.Events
+	assert.Contains(t, event, corev1.EventTypeNormal)
+	assert.Contains(t, event, ReasonPaused)
+	assert.Contains(t, event, "mirroring paused (source)")
+
+	// Sources without targets over invalid patterns are warned about by the controller
+	require.NoError(t, reporter.Report(context.Background(), source, Result{InvalidPatterns: []string{"[prod"}}))
+	assert.Empty(t, recorder.Events)
+
+	// Source must never be mutated
+	_, hasStatus := source.GetAnnotations()[constants.AnnotationSyncStatus]
+	assert.False(t, hasStatus)
+
+	// Nil recorder is a no-op
+	assert.NoError(t, (&EventReporter{}).Report(context.Background(), source, Result{}))
+}
+
+func TestBuildMirrorStatus(t *testing.T) {
+	source := makeSource()
+	source.SetResourceVersion("77")
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	obj := BuildMirrorStatus(source, Result{Reconciled: 4, Errors: 0}, now)
+
+	assert.Equal(t, MirrorStatusGVK, obj.GroupVersionKind())
+	assert.Equal(t, "default", obj.GetNamespace())
+	assert.Equal(t, "app-config.configmap", obj.GetName())
+	assert.Equal(t, constants.ControllerName, obj.GetLabels()[constants.LabelManagedBy])
+
+	kind, _, _ := unstructured.NestedString(obj.Object, "spec", "sourceRef", "kind")
+	assert.Equal(t, "ConfigMap", kind)
+	uid, _, _ := unstructured.NestedString(obj.Object, "spec", "sourceRef", "uid")
+	assert.Equal(t, "uid-123", uid)
+
+	reconciled, _, _ := unstructured.NestedInt64(obj.Object, "status", "reconciled")
+	assert.Equal(t, int64(4), reconciled)
+	lastSync, _, _ := unstructured.NestedString(obj.Object, "status", "lastSyncTime")
+	assert.Equal(t, "2025-01-02T03:04:05Z", lastSync)
+	observed, _, _ := unstructured.NestedString(obj.Object, "status", "observedResourceVersion")
+	assert.Equal(t, "77", observed)
+	_, hasTargets, _ := unstructured.NestedSlice(obj.Object, "status", "targets")
+	assert.False(t, hasTargets)
+}
+
+func TestBuildMirrorStatus_Paused(t *testing.T) {
+	obj := BuildMirrorStatus(makeSource(), Result{Paused: "resource-type"}, time.Now())
+
+	paused, _, _ := unstructured.NestedString(obj.Object, "status", "paused")
+	assert.Equal(t, "resource-type", paused)
+	summary, _, _ := unstructured.NestedString(obj.Object, "status", "summary")
+	assert.Equal(t, "paused:resource-type", summary)
+	_, hasLastSync, _ := unstructured.NestedString(obj.Object, "status", "lastSyncTime")
+	assert.False(t, hasLastSync, "a paused source was not synced")
+}
+
+func TestBuildMirrorStatus_InvalidPatterns(t *testing.T) {
+	obj := BuildMirrorStatus(makeSource(), Result{InvalidPatterns: []string{"[prod", "team-["}}, time.Now())
+
+	patterns, _, _ := unstructured.NestedStringSlice(obj.Object, "status", "invalidPatterns")
+	assert.Equal(t, []string{"[prod", "team-["}, patterns)
+	summary, _, _ := unstructured.NestedString(obj.Object, "status", "summary")
+	assert.Equal(t, "invalid-pattern,reconciled:0,errors:0", summary)
+	conditions := conditionsOf(t, obj)
+	assert.Equal(t, "invalid target-namespaces pattern(s) skipped: [prod, team-[", conditions[ConditionReady]["message"])
+}
+
+func TestBuildMirrorStatus_Targets(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	result := Result{Reconciled: 2, Errors: 1, Targets: []TargetStatus{
+		{Namespace: "app2", State: TargetFailed, Error: "webhook denied"},
+		{Namespace: "app1", State: TargetSynced, LastSyncTime: now, ContentHash: "abc"},
+		{Namespace: "legacy", State: TargetSkipped, Error: "not managed"},
+	}}
+
+	obj := BuildMirrorStatus(makeSource(), result, now)
+
+	targets, found, err := unstructured.NestedSlice(obj.Object, "status", "targets")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"namespace": "app1", "state": "synced", "lastSyncTime": "2025-01-02T03:04:05Z", "contentHash": "abc"},
+		map[string]interface{}{"namespace": "app2", "state": "failed", "error": "webhook denied"},
+		map[string]interface{}{"namespace": "legacy", "state": "skipped", "error": "not managed"},
+	}, targets, "sorted by namespace")
+}
+
+// conditionsOf returns the status.conditions of a MirrorStatus by type.
+func conditionsOf(t *testing.T, obj *unstructured.Unstructured) map[string]map[string]interface{} {
+	t.Helper()
+	conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	require.NoError(t, err)
+	require.True(t, found)
+	byType := make(map[string]map[string]interface{}, len(conditions))
+	for _, c := range conditions {
+		cond := c.(map[string]interface{})
+		byType[cond["type"].(string)] = cond
+	}
+	return byType
+}
+
+func TestBuildMirrorStatus_Conditions(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name   string
+		result Result
+		// want is the status of Ready, Degraded and Progressing
+		want        [3]string
+		readyReason string
+	}{
+		{
+			name:        "all synced",
+			result:      Result{Reconciled: 3},
+			want:        [3]string{"True", "False", "False"},
+			readyReason: ReasonSynced,
+		},
+		{
+			name: "failed target",
+			result: Result{Reconciled: 1, Errors: 1, Targets: []TargetStatus{
+				{Namespace: "app1", State: TargetSynced},
+				{Namespace: "app2", State: TargetFailed},
+			}},
+			want:        [3]string{"False", "True", "False"},
+			readyReason: ReasonSyncFailed,
+		},
+		{
+			name: "waiting for a lower wave",
+			result: Result{Reconciled: 1, Targets: []TargetStatus{
+				{Namespace: "app1", State: TargetSynced},
+				{Namespace: "app2", State: TargetWaiting},
+			}},
+			want:        [3]string{"False", "False", "True"},
+			readyReason: ReasonWaiting,
+		},
+		{
+			name:        "invalid pattern",
+			result:      Result{Reconciled: 1, InvalidPatterns: []string{"[prod"}},
+			want:        [3]string{"False", "True", "False"},
+			readyReason: ReasonInvalidPattern,
+		},
+		{
+			name:        "paused",
+			result:      Result{Paused: "source"},
+			want:        [3]string{"False", "False", "False"},
+			readyReason: ReasonPaused,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions := conditionsOf(t, BuildMirrorStatus(makeSource(), tt.result, now))
+			require.Len(t, conditions, 3)
+			assert.Equal(t, tt.want[0], conditions[ConditionReady]["status"])
+			assert.Equal(t, tt.readyReason, conditions[ConditionReady]["reason"])
+			assert.Equal(t, tt.want[1], conditions[ConditionDegraded]["status"])
+			assert.Equal(t, tt.want[2], conditions[ConditionProgressing]["status"])
+			for _, c := range conditions {
+				assert.Equal(t, "2025-01-02T03:04:05Z", c["lastTransitionTime"])
+			}
+		})
+	}
+
+	failed := conditionsOf(t, BuildMirrorStatus(makeSource(), tests[1].result, now))
+	assert.Equal(t, "1 of 2 mirror(s) failed: app2", failed[ConditionDegraded]["message"])
+}
+
+func TestKeepTransitionTimes(t *testing.T) {
+	earlier := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := earlier.Add(time.Hour)
+	existing := BuildMirrorStatus(makeSource(), Result{Reconciled: 2}, earlier)
+
+	desired := BuildMirrorStatus(makeSource(), Result{Reconciled: 1, Errors: 1}, now)
+	keepTransitionTimes(desired, existing)
+	conditions := conditionsOf(t, desired)
+	assert.Equal(t, "2025-01-01T01:00:00Z", conditions[ConditionReady]["lastTransitionTime"], "Ready went False")
+	assert.Equal(t, "2025-01-01T01:00:00Z", conditions[ConditionDegraded]["lastTransitionTime"], "Degraded went True")
+	assert.Equal(t, "2025-01-01T00:00:00Z", conditions[ConditionProgressing]["lastTransitionTime"], "Progressing stayed False")
+}
+
+func TestResult_FailedTargets(t *testing.T) {
+	result := Result{Targets: []TargetStatus{
+		{Namespace: "app1", State: TargetFailed},
+		{Namespace: "app2", State: TargetSynced},
+		{Cluster: "prod", Namespace: "app1", State: TargetFailed},
+		{Cluster: "edge", State: TargetFailed},
+	}}
+	assert.Equal(t, []string{"app1", "prod/app1", "edge"}, result.FailedTargets())
+}
+
+func TestResult_WebhookErrors(t *testing.T) {
+	result := Result{Targets: []TargetStatus{
+		{Namespace: "app1", State: TargetFailed, WebhookError: "denied by policy"},
+		{Namespace: "app2", State: TargetFailed, Error: "timeout"},
+		{Cluster: "prod", Namespace: "app1", State: TargetFailed, WebhookError: "denied"},
+	}}
+	assert.Equal(t, "app1: denied by policy; prod/app1: denied", result.WebhookErrors())
+	assert.Empty(t, Result{}.WebhookErrors())
+
+	long := Result{Targets: []TargetStatus{{Namespace: "app1", State: TargetFailed, WebhookError: strings.Repeat("x", 2000)}}}
+	assert.Len(t, long.WebhookErrors(), maxWebhookErrorLength)
+}
@@ -0,0 +1,217 @@
+// Package summary maintains a per-resource-type roll-up of mirroring state.
+//
+// Dashboards polling every source and mirror in a large cluster is expensive, so
+// the controller periodically aggregates counts into a single ConfigMap and
+// matching Prometheus gauges that are cheap to read and chart.
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/hash"
+	"github.com/lukaszraczylo/kubemirror/pkg/listing"
+)
+
+// DefaultConfigMapName is the name of the summary ConfigMap in the controller namespace.
+const DefaultConfigMapName = "kubemirror-summary"
+
+var (
+	sourcesGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubemirror_summary_sources",
+		Help: "Number of sources enabled for mirroring, by resource type.",
+	}, []string{"resource_type"})
+	mirrorsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubemirror_summary_mirrors",
+		Help: "Number of mirrors, by resource type.",
+	}, []string{"resource_type"})
+	outOfSyncGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubemirror_summary_mirrors_out_of_sync",
+		Help: "Number of mirrors whose content lags behind their source, by resource type.",
+	}, []string{"resource_type"})
+	orphanedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubemirror_summary_mirrors_orphaned",
+		Help: "Number of mirrors whose source no longer exists or is no longer enabled, by resource type.",
+	}, []string{"resource_type"})
+	oldestOutOfSyncGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubemirror_summary_oldest_out_of_sync_seconds",
+		Help: "Seconds since the longest out-of-sync mirror was last synced, by resource type (0 when all are in sync).",
+	}, []string{"resource_type"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(sourcesGauge, mirrorsGauge, outOfSyncGauge, orphanedGauge, oldestOutOfSyncGauge)
+}
+
+// TypeSummary is the roll-up for one resource type.
+type TypeSummary struct {
+	// Sources counts objects enabled for mirroring
+	Sources int `json:"sources"`
+	// Mirrors counts objects managed by kubemirror
+	Mirrors int `json:"mirrors"`
+	// OutOfSync counts mirrors whose recorded source hash differs from the source's current content
+	OutOfSync int `json:"outOfSync"`
+	// Orphaned counts mirrors whose source is gone or no longer enabled
+	Orphaned int `json:"orphaned"`
+	// OldestOutOfSyncSeconds is how long ago the stalest out-of-sync mirror was last synced
+	OldestOutOfSyncSeconds int64 `json:"oldestOutOfSyncSeconds"`
+}
+
+// Summarizer periodically writes a TypeSummary per resource type into a ConfigMap,
+// one data key per type (e.g. "Secret.v1"), and exports the same numbers as gauges.
+type Summarizer struct {
+	Client client.Client
+	Log    logr.Logger
+	// ResourceTypes returns the resource types to summarize
+	ResourceTypes func() []config.ResourceType
+	// Namespace and Name locate the summary ConfigMap
+	Namespace string
+	Name      string
+	Interval  time.Duration
+
+	now func() time.Time
+}
+
+// Start implements manager.Runnable, refreshing the summary every Interval.
+func (s *Summarizer) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Refresh(ctx); err != nil {
+			s.Log.Error(err, "failed to refresh mirror summary")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable: one replica writes the summary.
+func (s *Summarizer) NeedLeaderElection() bool {
+	return true
+}
+
+// Refresh recomputes the summary of every resource type and stores it.
+func (s *Summarizer) Refresh(ctx context.Context) error {
+	data := make(map[string]string)
+	var errs []error
+
+	for _, rt := range s.ResourceTypes() {
+		summary, err := s.summarize(ctx, rt.GroupVersionKind())
+		if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to summarize %s: %w", rt, err))
+			continue
+		}
+
+		encoded, err := json.Marshal(summary)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		data[rt.String()] = string(encoded)
+		exportGauges(rt.String(), summary)
+	}
+
+	if err := s.store(ctx, data); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// summarize computes the roll-up of one resource type.
+func (s *Summarizer) summarize(ctx context.Context, gvk schema.GroupVersionKind) (TypeSummary, error) {
+	var summary TypeSummary
+
+	// Current content hash of every enabled source, to compare mirrors against
+	sourceHashes := make(map[types.NamespacedName]string)
+	err := listing.ForEach(ctx, s.Client, gvk, []client.ListOption{client.MatchingLabels{constants.LabelEnabled: "true"}}, func(u *unstructured.Unstructured) error {
+		if u.GetLabels()[constants.LabelMirror] == "true" {
+			return nil
+		}
+		sourceHash, err := hash.ComputeContentHash(u)
+		if err != nil {
+			return err
+		}
+		sourceHashes[types.NamespacedName{Namespace: u.GetNamespace(), Name: u.GetName()}] = sourceHash
+		summary.Sources++
+		return nil
+	})
+	if err != nil {
+		return summary, err
+	}
+
+	now := time.Now()
+	if s.now != nil {
+		now = s.now()
+	}
+	err = listing.ForEach(ctx, s.Client, gvk, []client.ListOption{client.MatchingLabels{constants.LabelManagedBy: constants.ControllerName}}, func(u *unstructured.Unstructured) error {
+		summary.Mirrors++
+		annotations := u.GetAnnotations()
+		source := types.NamespacedName{
+			Namespace: annotations[constants.AnnotationSourceNamespace],
+			Name:      annotations[constants.AnnotationSourceName],
+		}
+
+		sourceHash, found := sourceHashes[source]
+		if !found {
+			summary.Orphaned++
+			return nil
+		}
+		if sourceHash == annotations[constants.AnnotationSourceContentHash] {
+			return nil
+		}
+
+		summary.OutOfSync++
+		if lastSync, parseErr := time.Parse(time.RFC3339, annotations[constants.AnnotationLastSyncTime]); parseErr == nil {
+			if age := int64(now.Sub(lastSync).Seconds()); age > summary.OldestOutOfSyncSeconds {
+				summary.OldestOutOfSyncSeconds = age
+			}
+		}
+		return nil
+	})
+	return summary, err
+}
+
+// store server-side applies the summary ConfigMap. Applying (rather than a cached
+// Get and Update) avoids starting a cluster-wide ConfigMap informer, and drops keys
+// of resource types that are no longer summarized.
+func (s *Summarizer) store(ctx context.Context, data map[string]string) error {
+	cm := corev1ac.ConfigMap(s.Name, s.Namespace).
+		WithLabels(map[string]string{"app.kubernetes.io/managed-by": constants.ControllerName}).
+		WithData(data)
+	if err := s.Client.Apply(ctx, cm, client.FieldOwner(constants.ControllerName), client.ForceOwnership); err != nil {
+		return fmt.Errorf("failed to apply summary ConfigMap %s/%s: %w", s.Namespace, s.Name, err)
+	}
+	return nil
+}
+
+// exportGauges publishes a resource type's summary as Prometheus gauges.
+func exportGauges(resourceType string, summary TypeSummary) {
+	sourcesGauge.WithLabelValues(resourceType).Set(float64(summary.Sources))
+	mirrorsGauge.WithLabelValues(resourceType).Set(float64(summary.Mirrors))
+	outOfSyncGauge.WithLabelValues(resourceType).Set(float64(summary.OutOfSync))
+	orphanedGauge.WithLabelValues(resourceType).Set(float64(summary.Orphaned))
+	oldestOutOfSyncGauge.WithLabelValues(resourceType).Set(float64(summary.OldestOutOfSyncSeconds))
+}
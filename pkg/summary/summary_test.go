@@ -0,0 +1,134 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/hash"
+)
+
+func source(name, value string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1TypeMeta(),
+		ObjectMeta: objectMeta("default", name,
+			map[string]string{constants.LabelEnabled: "true"}, nil),
+		Data: map[string]string{"key": value},
+	}
+}
+
+func mirror(namespace, name, sourceHash string, lastSync time.Time) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1TypeMeta(),
+		ObjectMeta: objectMeta(namespace, name,
+			map[string]string{
+				constants.LabelManagedBy: constants.ControllerName,
+				constants.LabelMirror:    "true",
+			},
+			map[string]string{
+				constants.AnnotationSourceNamespace:   "default",
+				constants.AnnotationSourceName:        name,
+				constants.AnnotationSourceContentHash: sourceHash,
+				constants.AnnotationLastSyncTime:      lastSync.Format(time.RFC3339),
+			}),
+	}
+}
+
+func TestSummarizer_Refresh(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	inSync := source("in-sync", "v1")
+	// The reconciler hashes the unstructured source, so do the same here
+	inSyncObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(inSync)
+	require.NoError(t, err)
+	inSyncHash, err := hash.ComputeContentHash(&unstructured.Unstructured{Object: inSyncObj})
+	require.NoError(t, err)
+	stale := source("stale", "v2")
+
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+		inSync,
+		stale,
+		mirror("team-a", "in-sync", inSyncHash, now.Add(-time.Hour)),
+		mirror("team-a", "stale", "old-hash", now.Add(-10*time.Minute)),
+		mirror("team-b", "stale", "old-hash", now.Add(-30*time.Minute)),
+		mirror("team-a", "gone", "whatever", now.Add(-time.Hour)),
+	).Build()
+
+	s := &Summarizer{
+		Client: c,
+		Log:    logr.Discard(),
+		ResourceTypes: func() []config.ResourceType {
+			return []config.ResourceType{{Version: "v1", Kind: "ConfigMap"}}
+		},
+		Namespace: "kubemirror-system",
+		Name:      DefaultConfigMapName,
+		Interval:  time.Minute,
+		now:       func() time.Time { return now },
+	}
+
+	require.NoError(t, s.Refresh(context.Background()))
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(context.Background(),
+		types.NamespacedName{Namespace: "kubemirror-system", Name: DefaultConfigMapName}, cm))
+
+	var got TypeSummary
+	require.NoError(t, json.Unmarshal([]byte(cm.Data["ConfigMap.v1"]), &got))
+	assert.Equal(t, TypeSummary{
+		Sources:                2,
+		Mirrors:                4,
+		OutOfSync:              2,
+		Orphaned:               1,
+		OldestOutOfSyncSeconds: int64((30 * time.Minute).Seconds()),
+	}, got)
+}
+
+func TestSummarizer_Refresh_DropsRemovedTypes(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	resourceTypes := []config.ResourceType{
+		{Version: "v1", Kind: "ConfigMap"},
+		{Version: "v1", Kind: "Secret"},
+	}
+	s := &Summarizer{
+		Client:        c,
+		Log:           logr.Discard(),
+		ResourceTypes: func() []config.ResourceType { return resourceTypes },
+		Namespace:     "kubemirror-system",
+		Name:          DefaultConfigMapName,
+	}
+	key := client.ObjectKey{Namespace: "kubemirror-system", Name: DefaultConfigMapName}
+
+	require.NoError(t, s.Refresh(context.Background()))
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(context.Background(), key, cm))
+	assert.Contains(t, cm.Data, "Secret.v1")
+
+	resourceTypes = resourceTypes[:1]
+	require.NoError(t, s.Refresh(context.Background()))
+	require.NoError(t, c.Get(context.Background(), key, cm))
+	assert.Contains(t, cm.Data, "ConfigMap.v1")
+	assert.NotContains(t, cm.Data, "Secret.v1")
+}
+
+func metav1TypeMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+}
+
+func objectMeta(namespace, name string, labels, annotations map[string]string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels, Annotations: annotations}
+}
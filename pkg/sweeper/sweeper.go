@@ -0,0 +1,283 @@
+// Package sweeper finds and deletes kubemirror mirrors whose source is gone.
+//
+// The MirrorReconciler does the same from watch events while the controller
+// runs. The sweeper instead scans every managed mirror periodically, so it can
+// run as its own lightweight deployment: on clusters where mirroring is disabled
+// but historical mirrors still need cleaning up, or next to the controller as a
+// safety net for mirrors whose events were missed.
+package sweeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/listing"
+	"github.com/lukaszraczylo/kubemirror/pkg/naming"
+	"github.com/lukaszraczylo/kubemirror/pkg/pullsecret"
+)
+
+// Status classifies a mirror against its source.
+type Status int
+
+const (
+	// StatusValid means the source exists with the UID the mirror recorded
+	StatusValid Status = iota
+	// StatusUnknown means the mirror lacks source references, or its source lives
+	// in another cluster, and is left alone
+	StatusUnknown
+	// StatusSourceDeleting means the source is being deleted; its finalizer handling removes the mirror
+	StatusSourceDeleting
+	// StatusOrphaned means the source no longer exists
+	StatusOrphaned
+	// StatusStale means the source was deleted and recreated with a different UID
+	StatusStale
+	// StatusRenamed means the source is now mirrored under a different name
+	StatusRenamed
+)
+
+// String returns the string representation of the status.
+func (s Status) String() string {
+	switch s {
+	case StatusValid:
+		return "valid"
+	case StatusSourceDeleting:
+		return "source-deleting"
+	case StatusOrphaned:
+		return "orphaned"
+	case StatusStale:
+		return "stale"
+	case StatusRenamed:
+		return "renamed"
+	default:
+		return "unknown"
+	}
+}
+
+// Removable reports whether a mirror with this status should be deleted.
+func (s Status) Removable() bool {
+	return s == StatusOrphaned || s == StatusStale || s == StatusRenamed
+}
+
+// Verdict is the result of checking a mirror against its source.
+type Verdict struct {
+	Status Status
+	// Source is the source the mirror refers to
+	Source types.NamespacedName
+	// ExpectedUID is the source UID recorded on the mirror
+	ExpectedUID string
+	// ActualUID is the UID of the existing source (empty if it does not exist)
+	ActualUID string
+	// Object is the existing source (nil if it does not exist)
+	Object *unstructured.Unstructured
+}
+
+// Check looks up the source of mirror and classifies the mirror.
+func Check(ctx context.Context, reader client.Reader, mirror *unstructured.Unstructured) (Verdict, error) {
+	annotations := mirror.GetAnnotations()
+	sourceNs, hasSourceNs := annotations[constants.AnnotationSourceNamespace]
+	sourceName, hasSourceName := annotations[constants.AnnotationSourceName]
+	sourceUID, hasSourceUID := annotations[constants.AnnotationSourceUID]
+
+	verdict := Verdict{
+		Source:      types.NamespacedName{Namespace: sourceNs, Name: sourceName},
+		ExpectedUID: sourceUID,
+	}
+	_, remote := mirror.GetLabels()[constants.LabelSourceUID]
+	if !hasSourceNs || !hasSourceName || !hasSourceUID || remote {
+		verdict.Status = StatusUnknown
+		return verdict, nil
+	}
+
+	source := &unstructured.Unstructured{}
+	source.SetGroupVersionKind(mirror.GroupVersionKind())
+	if err := reader.Get(ctx, verdict.Source, source); err != nil {
+		if apierrors.IsNotFound(err) {
+			verdict.Status = StatusOrphaned
+			return verdict, nil
+		}
+		return verdict, err
+	}
+
+	verdict.ActualUID = string(source.GetUID())
+	verdict.Object = source
+	switch {
+	case !source.GetDeletionTimestamp().IsZero():
+		verdict.Status = StatusSourceDeleting
+	case verdict.ActualUID != sourceUID:
+		verdict.Status = StatusStale
+	case renamed(source, mirror):
+		verdict.Status = StatusRenamed
+	default:
+		verdict.Status = StatusValid
+	}
+	return verdict, nil
+}
+
+// renamed reports whether source is now mirrored under a name other than the
+// mirror's. Invalid naming annotations never make a mirror removable.
+func renamed(source, mirror *unstructured.Unstructured) bool {
+	name, err := naming.MirrorName(source)
+	return err == nil && name != mirror.GetName()
+}
+
+// deletedTotal counts mirrors deleted by the sweeper.
+var deletedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubemirror_sweeper_deleted_total",
+	Help: "Number of orphaned or stale mirrors deleted by the sweeper.",
+}, []string{"resource_type", "status"})
+
+func init() {
+	metrics.Registry.MustRegister(deletedTotal)
+}
+
+// Result summarizes a sweep.
+type Result struct {
+	// Checked counts mirrors that were checked against their source
+	Checked int
+	// Deleted counts orphaned or stale mirrors that were deleted (or would be, in dry run)
+	Deleted int
+	// Unserved lists the resource types that were skipped because the cluster no longer serves them
+	Unserved []schema.GroupVersionKind
+}
+
+// Sweeper deletes mirrors whose source is gone or was recreated.
+type Sweeper struct {
+	Client client.Client
+	Log    logr.Logger
+	// ResourceTypes returns the resource types to sweep
+	ResourceTypes func() []config.ResourceType
+	// Interval is the time between sweeps when run as a manager runnable
+	Interval time.Duration
+	// DryRun reports what would be deleted without deleting anything
+	DryRun bool
+	// Paused reports resource types whose mirrors are left alone while mirroring
+	// of them is paused (optional, nil = none)
+	Paused func(schema.GroupVersionKind) bool
+}
+
+// Start implements manager.Runnable, sweeping every Interval.
+func (s *Sweeper) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Sweep(ctx); err != nil {
+			s.Log.Error(err, "mirror sweep completed with errors")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable: one replica sweeps.
+func (s *Sweeper) NeedLeaderElection() bool {
+	return true
+}
+
+// Sweep checks every managed mirror of each resource type once. Errors on
+// individual mirrors are collected so one failure does not stop the sweep.
+func (s *Sweeper) Sweep(ctx context.Context) (Result, error) {
+	var (
+		result Result
+		errs   []error
+	)
+
+	for _, rt := range s.ResourceTypes() {
+		gvk := rt.GroupVersionKind()
+		if s.Paused != nil && s.Paused(gvk) {
+			s.Log.V(1).Info("mirroring paused, skipping", "resourceType", rt.String())
+			continue
+		}
+		err := s.forEach(ctx, gvk, func(mirror *unstructured.Unstructured) {
+			result.Checked++
+			deleted, err := s.sweepMirror(ctx, rt.String(), mirror)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if deleted {
+				result.Deleted++
+			}
+		})
+		if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+			s.Log.V(1).Info("resource type not served, skipping", "resourceType", rt.String())
+			result.Unserved = append(result.Unserved, gvk)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list %s: %w", rt, err))
+		}
+	}
+
+	s.Log.Info("mirror sweep finished", "checked", result.Checked, "deleted", result.Deleted, "dryRun", s.DryRun)
+	return result, errors.Join(errs...)
+}
+
+// sweepMirror deletes mirror if its source is gone or was recreated.
+func (s *Sweeper) sweepMirror(ctx context.Context, resourceType string, mirror *unstructured.Unstructured) (bool, error) {
+	verdict, err := Check(ctx, s.Client, mirror)
+	if err != nil {
+		return false, fmt.Errorf("failed to check source of %s %s/%s: %w",
+			mirror.GetKind(), mirror.GetNamespace(), mirror.GetName(), err)
+	}
+	if !verdict.Status.Removable() {
+		return false, nil
+	}
+	// A paused source's mirrors stay as they are, even under a previous name
+	if verdict.Object != nil && verdict.Object.GetAnnotations()[constants.AnnotationPaused] == "true" {
+		return false, nil
+	}
+
+	s.Log.Info("deleting mirror", "kind", mirror.GetKind(), "namespace", mirror.GetNamespace(), "name", mirror.GetName(),
+		"status", verdict.Status.String(), "source", verdict.Source.String(), "dryRun", s.DryRun)
+	if s.DryRun {
+		return true, nil
+	}
+
+	if err := pullsecret.DetachMirror(ctx, s.Client, mirror); err != nil {
+		return false, fmt.Errorf("failed to detach %s %s/%s from service accounts: %w",
+			mirror.GetKind(), mirror.GetNamespace(), mirror.GetName(), err)
+	}
+
+	// Preconditions skip mirrors the controller rewrote since they were listed,
+	// e.g. after re-pointing them at a recreated source
+	uid, resourceVersion := mirror.GetUID(), mirror.GetResourceVersion()
+	err = s.Client.Delete(ctx, mirror, client.Preconditions{UID: &uid, ResourceVersion: &resourceVersion})
+	if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete %s %s/%s: %w", mirror.GetKind(), mirror.GetNamespace(), mirror.GetName(), err)
+	}
+	deletedTotal.WithLabelValues(resourceType, verdict.Status.String()).Inc()
+	return true, nil
+}
+
+// forEach lists all managed mirrors of gvk page by page. Other objects kubemirror
+// manages, such as MirrorStatus resources, lack the mirror label and are skipped.
+func (s *Sweeper) forEach(ctx context.Context, gvk schema.GroupVersionKind, fn func(*unstructured.Unstructured)) error {
+	managed := client.MatchingLabels{
+		constants.LabelManagedBy: constants.ControllerName,
+		constants.LabelMirror:    "true",
+	}
+	return listing.ForEach(ctx, s.Client, gvk, []client.ListOption{managed}, func(mirror *unstructured.Unstructured) error {
+		fn(mirror)
+		return nil
+	})
+}
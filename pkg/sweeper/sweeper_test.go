@@ -0,0 +1,227 @@
+package sweeper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func source(name string, uid types.UID) *corev1.Secret {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: uid}}
+}
+
+func mirror(namespace, name string, sourceUID types.UID) *corev1.Secret {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Namespace: namespace,
+		Name:      name,
+		Labels: map[string]string{
+			constants.LabelManagedBy: constants.ControllerName,
+			constants.LabelMirror:    "true",
+		},
+		Annotations: map[string]string{
+			constants.AnnotationSourceNamespace: "default",
+			constants.AnnotationSourceName:      name,
+			constants.AnnotationSourceUID:       string(sourceUID),
+		},
+	}}
+}
+
+func toUnstructured(t *testing.T, c client.Client, key types.NamespacedName) *unstructured.Unstructured {
+	t.Helper()
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("Secret")
+	require.NoError(t, c.Get(context.Background(), key, u))
+	return u
+}
+
+func TestCheck(t *testing.T) {
+	deleting := source("deleting", "uid-deleting")
+	deleting.Finalizers = []string{constants.FinalizerName}
+	deleting.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+
+	unreferenced := mirror("team-a", "unreferenced", "")
+	unreferenced.Annotations = nil
+
+	// Pushed from another cluster, so its source is not here
+	remote := mirror("team-a", "remote", "uid-remote")
+	remote.Labels[constants.LabelSourceUID] = "uid-remote"
+
+	// Now mirrored as team-a-renamed
+	renamed := source("renamed", "uid-renamed")
+	renamed.Annotations = map[string]string{constants.AnnotationTargetNamePrefix: "team-a-"}
+	// An invalid name never removes existing mirrors
+	misnamed := source("misnamed", "uid-misnamed")
+	misnamed.Annotations = map[string]string{constants.AnnotationTargetNamePrefix: "Team_A-"}
+
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+		source("valid", "uid-valid"),
+		source("recreated", "uid-new"),
+		deleting,
+		mirror("team-a", "valid", "uid-valid"),
+		mirror("team-a", "recreated", "uid-old"),
+		mirror("team-a", "deleting", "uid-deleting"),
+		mirror("team-a", "gone", "uid-gone"),
+		unreferenced,
+		remote,
+		renamed,
+		mirror("team-a", "renamed", "uid-renamed"),
+		misnamed,
+		mirror("team-a", "misnamed", "uid-misnamed"),
+	).Build()
+
+	tests := []struct {
+		name string
+		want Status
+	}{
+		{name: "valid", want: StatusValid},
+		{name: "recreated", want: StatusStale},
+		{name: "deleting", want: StatusSourceDeleting},
+		{name: "gone", want: StatusOrphaned},
+		{name: "unreferenced", want: StatusUnknown},
+		{name: "remote", want: StatusUnknown},
+		{name: "renamed", want: StatusRenamed},
+		{name: "misnamed", want: StatusValid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := toUnstructured(t, c, types.NamespacedName{Namespace: "team-a", Name: tt.name})
+			verdict, err := Check(context.Background(), c, m)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, verdict.Status)
+			assert.Equal(t, tt.want == StatusOrphaned || tt.want == StatusStale || tt.want == StatusRenamed, verdict.Status.Removable())
+		})
+	}
+}
+
+func TestSweeper_Sweep(t *testing.T) {
+	resourceTypes := func() []config.ResourceType {
+		return []config.ResourceType{{Version: "v1", Kind: "Secret"}}
+	}
+
+	tests := []struct {
+		name        string
+		dryRun      bool
+		wantDeleted []string
+	}{
+		{name: "deletes orphaned and stale mirrors", wantDeleted: []string{"gone", "recreated"}},
+		{name: "dry run keeps everything", dryRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+				source("valid", "uid-valid"),
+				source("recreated", "uid-new"),
+				mirror("team-a", "valid", "uid-valid"),
+				mirror("team-a", "recreated", "uid-old"),
+				mirror("team-b", "gone", "uid-gone"),
+			).Build()
+
+			s := &Sweeper{Client: c, Log: logr.Discard(), ResourceTypes: resourceTypes, DryRun: tt.dryRun}
+			result, err := s.Sweep(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, 3, result.Checked)
+			assert.Equal(t, 2, result.Deleted)
+
+			for _, key := range []types.NamespacedName{
+				{Namespace: "team-a", Name: "valid"},
+				{Namespace: "team-a", Name: "recreated"},
+				{Namespace: "team-b", Name: "gone"},
+			} {
+				err := c.Get(context.Background(), key, &corev1.Secret{})
+				if !tt.dryRun && (key.Name == "gone" || key.Name == "recreated") {
+					assert.True(t, apierrors.IsNotFound(err), "%s should be deleted", key)
+				} else {
+					assert.NoError(t, err, "%s should be kept", key)
+				}
+			}
+		})
+	}
+}
+
+func TestSweeper_Sweep_Paused(t *testing.T) {
+	resourceTypes := func() []config.ResourceType {
+		return []config.ResourceType{{Version: "v1", Kind: "Secret"}}
+	}
+	pausedSource := source("recreated", "uid-new")
+	pausedSource.Annotations = map[string]string{constants.AnnotationPaused: "true"}
+
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+		pausedSource,
+		mirror("team-a", "recreated", "uid-old"),
+		mirror("team-b", "gone", "uid-gone"),
+	).Build()
+
+	// A paused resource type is not swept at all
+	s := &Sweeper{Client: c, Log: logr.Discard(), ResourceTypes: resourceTypes,
+		Paused: func(schema.GroupVersionKind) bool { return true }}
+	result, err := s.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, result.Checked)
+
+	// A paused source keeps its stale mirror; orphans have no source left to pause them
+	s.Paused = nil
+	result, err = s.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Checked)
+	assert.Equal(t, 1, result.Deleted)
+	assert.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "team-a", Name: "recreated"}, &corev1.Secret{}))
+}
+
+func TestSweeper_Sweep_SkipsMirrorsChangedSinceListed(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+		mirror("team-a", "gone", "uid-gone"),
+	).Build()
+	s := &Sweeper{Client: c, Log: logr.Discard()}
+
+	m := toUnstructured(t, c, types.NamespacedName{Namespace: "team-a", Name: "gone"})
+	// The controller rewrites the mirror after it was listed
+	updated := m.DeepCopy()
+	updated.SetLabels(map[string]string{constants.LabelManagedBy: constants.ControllerName, "touched": "true"})
+	require.NoError(t, c.Update(context.Background(), updated))
+
+	deleted, err := s.sweepMirror(context.Background(), "Secret.v1", m)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	assert.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "team-a", Name: "gone"}, &corev1.Secret{}))
+}
+
+func TestSweeper_Sweep_OnlyMirrors(t *testing.T) {
+	// Managed by kubemirror but not marked as a mirror
+	unmarked := mirror("team-a", "unmarked", "uid-gone")
+	delete(unmarked.Labels, constants.LabelMirror)
+
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+		unmarked,
+		mirror("team-a", "gone", "uid-gone"),
+	).Build()
+	s := &Sweeper{
+		Client:        c,
+		Log:           logr.Discard(),
+		ResourceTypes: func() []config.ResourceType { return []config.ResourceType{{Version: "v1", Kind: "Secret"}} },
+	}
+
+	result, err := s.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Checked)
+	assert.Equal(t, 1, result.Deleted)
+	assert.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "team-a", Name: "unmarked"}, &corev1.Secret{}))
+}
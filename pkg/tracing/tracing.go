@@ -0,0 +1,97 @@
+// Package tracing records OpenTelemetry spans of reconciles and exports them over
+// OTLP, so operators can see where the time of a slow fan-out goes.
+//
+// Spans are recorded through the global tracer provider. Until Setup installs an
+// exporting one, they are no-ops and cost next to nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName is the service spans are exported as.
+const ServiceName = "kubemirror"
+
+// tracerName identifies the instrumentation library of kubemirror's spans.
+const tracerName = "github.com/lukaszraczylo/kubemirror"
+
+// Span attribute keys.
+const (
+	AttrResourceType    = attribute.Key("kubemirror.resource_type")
+	AttrSourceNamespace = attribute.Key("kubemirror.source.namespace")
+	AttrSourceName      = attribute.Key("kubemirror.source.name")
+	AttrTargetNamespace = attribute.Key("kubemirror.target.namespace")
+	AttrTargetCount     = attribute.Key("kubemirror.target.count")
+	AttrSkipped         = attribute.Key("kubemirror.skipped")
+)
+
+// Options configures span export.
+type Options struct {
+	// Endpoint is the host:port of the OTLP gRPC receiver (empty disables tracing)
+	Endpoint string
+	// Insecure sends spans without TLS
+	Insecure bool
+	// SamplingRatio is the fraction of reconciles traced, 0 to 1
+	SamplingRatio float64
+}
+
+// Setup installs a global tracer provider exporting spans to opts.Endpoint and
+// returns the function flushing and stopping it. Without an endpoint it does
+// nothing and spans stay no-ops.
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	if opts.SamplingRatio < 0 || opts.SamplingRatio > 1 {
+		return nil, fmt.Errorf("sampling ratio %v is not between 0 and 1", opts.SamplingRatio)
+	}
+
+	exporterOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(opts.Endpoint)}
+	if opts.Insecure {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewSchemaless(semconv.ServiceName(ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SamplingRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// Start starts a span named name as a child of the span in ctx, if any.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, marking it failed with err unless err is nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
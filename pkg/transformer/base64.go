@@ -0,0 +1,50 @@
+package transformer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// decodedField returns the base64-decoded string at path, for decodeBase64 rules.
+// A missing field decodes to "", so rules can add new keys.
+func decodedField(obj map[string]interface{}, path []string) (string, error) {
+	value, found, err := unstructured.NestedFieldNoCopy(obj, path...)
+	if err != nil || !found {
+		return "", nil
+	}
+	encoded, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s is a %T, not a base64 string", strings.Join(path, "."), value)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%s is not valid base64: %w", strings.Join(path, "."), err)
+	}
+	return string(decoded), nil
+}
+
+// setBase64Field sets the field at path to plain, base64-encoded. setNestedField
+// already encodes what it writes to Secret data.
+func setBase64Field(obj map[string]interface{}, path []string, plain string) error {
+	if !isSecretDataField(obj, path) {
+		plain = base64Encode(plain)
+	}
+	return setNestedField(obj, path, plain)
+}
+
+// encodeMergeValues base64-encodes the values of a decodeBase64 merge rule, which
+// must all be strings.
+func encodeMergeValues(merge map[string]interface{}) (map[string]interface{}, error) {
+	encoded := make(map[string]interface{}, len(merge))
+	for key, value := range merge {
+		plain, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("decodeBase64 merge value %s is a %T, not a string", key, value)
+		}
+		encoded[key] = base64Encode(plain)
+	}
+	return encoded, nil
+}
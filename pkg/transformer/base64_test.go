@@ -0,0 +1,107 @@
+package transformer
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func encode(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func TestTransformer_DecodeBase64Secret(t *testing.T) {
+	rules := `rules:
+  - path: data.DATABASE_URL
+    decodeBase64: true
+    template: '{{ replace .Value "db.default.svc" (printf "db.%s.svc" .TargetNamespace) }}'
+  - path: data
+    decodeBase64: true
+    merge:
+      ENVIRONMENT: production
+  - path: data.TOKEN
+    decodeBase64: true
+    regex:
+      pattern: '^dev-'
+      replacement: 'prod-'
+  - path: data.NEW
+    decodeBase64: true
+    template: '[{{ .Value }}]'
+`
+	binary := string([]byte{0xff, 0x00, 0xfe, 'd', 'e', 'v'})
+	source := newNamespacedObject("v1", "Secret", rules, map[string]interface{}{
+		"data": map[string]interface{}{
+			"DATABASE_URL": encode("postgres://app@db.default.svc/app"),
+			"TOKEN":        encode("dev-123"),
+			"BINARY":       encode(binary),
+		},
+	})
+	annotations := source.GetAnnotations()
+	annotations[constants.AnnotationTransformStrict] = "true"
+	source.SetAnnotations(annotations)
+
+	result, err := NewDefaultTransformer().Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "prod-eu"})
+	require.NoError(t, err)
+	data, _, _ := unstructured.NestedStringMap(result.(*unstructured.Unstructured).Object, "data")
+	assert.Equal(t, map[string]string{
+		"DATABASE_URL": encode("postgres://app@db.prod-eu.svc/app"),
+		"TOKEN":        encode("prod-123"),
+		"BINARY":       encode(binary),
+		"ENVIRONMENT":  encode("production"),
+		"NEW":          encode("[]"),
+	}, data)
+}
+
+func TestTransformer_DecodeBase64AnyField(t *testing.T) {
+	rules := `rules:
+  - path: binaryData.logo
+    decodeBase64: true
+    regex:
+      pattern: 'default'
+      replacement: '{{ .TargetNamespace }}'
+  - path: binaryData.motd
+    decodeBase64: true
+    value: hello
+`
+	binary := string([]byte{0x89, 'P', 'N', 'G', 0x00, 'd', 'e', 'f', 'a', 'u', 'l', 't', 0xff})
+	source := newNamespacedObject("v1", "ConfigMap", rules, map[string]interface{}{
+		"binaryData": map[string]interface{}{"logo": encode(binary)},
+	})
+
+	result, err := NewDefaultTransformer().Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "team-a"})
+	require.NoError(t, err)
+	binaryData, _, _ := unstructured.NestedStringMap(result.(*unstructured.Unstructured).Object, "binaryData")
+	assert.Equal(t, encode(string([]byte{0x89, 'P', 'N', 'G', 0x00, 't', 'e', 'a', 'm', '-', 'a', 0xff})), binaryData["logo"], "binary content survives")
+	assert.Equal(t, encode("hello"), binaryData["motd"])
+}
+
+func TestTransformer_DecodeBase64Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		wantErr string
+	}{
+		{name: "invalid base64", rule: "path: data.PLAIN\n    decodeBase64: true\n    template: '{{ .Value }}'", wantErr: "data.PLAIN is not valid base64"},
+		{name: "not a string", rule: "path: data\n    decodeBase64: true\n    template: '{{ .Value }}'", wantErr: "data is a map[string]interface {}, not a base64 string"},
+		{name: "merge of non-strings", rule: "path: data\n    decodeBase64: true\n    merge:\n      REPLICAS: 3", wantErr: "decodeBase64 merge value REPLICAS is a int, not a string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newNamespacedObject("v1", "ConfigMap", "rules:\n  - "+tt.rule+"\n", map[string]interface{}{
+				"data": map[string]interface{}{"PLAIN": "not base64!"},
+			})
+			annotations := source.GetAnnotations()
+			annotations[constants.AnnotationTransformStrict] = "true"
+			source.SetAnnotations(annotations)
+
+			_, err := NewDefaultTransformer().Transform(source, TransformContext{TargetNamespace: "team-a"})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
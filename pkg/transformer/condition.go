@@ -0,0 +1,313 @@
+package transformer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// condition is a compiled rule `when` expression.
+type condition func(ctx TransformContext) bool
+
+// operand is a compiled value within a `when` expression.
+type operand func(ctx TransformContext) string
+
+// conditionVariables are the plain variables of `when` expressions.
+var conditionVariables = map[string]operand{
+	"sourceName":      func(ctx TransformContext) string { return ctx.SourceName },
+	"sourceNamespace": func(ctx TransformContext) string { return ctx.SourceNamespace },
+	"targetName":      func(ctx TransformContext) string { return ctx.TargetName },
+	"targetNamespace": func(ctx TransformContext) string { return ctx.TargetNamespace },
+	"clusterName":     func(ctx TransformContext) string { return ctx.ClusterName },
+}
+
+// conditionMaps are the map variables of `when` expressions, read as "<map>.<key>".
+var conditionMaps = map[string]func(ctx TransformContext) map[string]string{
+	"labels":                     func(ctx TransformContext) map[string]string { return ctx.Labels },
+	"annotations":                func(ctx TransformContext) map[string]string { return ctx.Annotations },
+	"targetNamespaceLabels":      func(ctx TransformContext) map[string]string { return ctx.TargetNamespaceLabels },
+	"targetNamespaceAnnotations": func(ctx TransformContext) map[string]string { return ctx.TargetNamespaceAnnotations },
+}
+
+// parseCondition compiles a `when` expression such as
+//
+//	targetNamespace startsWith "prod-" && (labels.tier == "web" || !annotations.legacy)
+//
+// Comparisons are ==, !=, startsWith, endsWith, contains, matches (a regular
+// expression) and in (a list of strings); they combine with &&, || and ! and
+// group with parentheses. A value on its own is true when it is not empty.
+// Missing labels and annotations read as empty strings.
+func parseCondition(expr string) (condition, error) {
+	tokens, err := tokenizeCondition(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &conditionParser{tokens: tokens}
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("when: unexpected %q", tok.text)
+	}
+	return cond, nil
+}
+
+// conditionToken is a token of a `when` expression; literal tokens are quoted
+// strings, with text holding their unquoted value.
+type conditionToken struct {
+	text    string
+	literal bool
+}
+
+// conditionSymbols are the operator tokens, longest first.
+var conditionSymbols = []string{"&&", "||", "==", "!=", "!", "(", ")", "[", "]", ","}
+
+// tokenizeCondition splits a `when` expression into tokens.
+func tokenizeCondition(expr string) ([]conditionToken, error) {
+	var tokens []conditionToken
+	for i := 0; i < len(expr); {
+		ch := expr[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '"' || ch == '\'':
+			end := strings.IndexByte(expr[i+1:], ch)
+			if end < 0 {
+				return nil, fmt.Errorf("when: unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, conditionToken{text: expr[i+1 : i+1+end], literal: true})
+			i += end + 2
+		case isConditionWordChar(ch):
+			start := i
+			for i < len(expr) && isConditionWordChar(expr[i]) {
+				i++
+			}
+			tokens = append(tokens, conditionToken{text: expr[start:i]})
+		default:
+			symbol := ""
+			for _, s := range conditionSymbols {
+				if strings.HasPrefix(expr[i:], s) {
+					symbol = s
+					break
+				}
+			}
+			if symbol == "" {
+				return nil, fmt.Errorf("when: unexpected character %q at offset %d", ch, i)
+			}
+			tokens = append(tokens, conditionToken{text: symbol})
+			i += len(symbol)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("when: empty expression")
+	}
+	return tokens, nil
+}
+
+// isConditionWordChar reports whether ch can be part of a variable or operator
+// name; label and annotation keys may contain dots, dashes and slashes.
+func isConditionWordChar(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' ||
+		ch == '_' || ch == '.' || ch == '-' || ch == '/'
+}
+
+// conditionParser is a recursive descent parser over the tokens of a `when` expression.
+type conditionParser struct {
+	tokens []conditionToken
+	pos    int
+}
+
+func (p *conditionParser) peek() (conditionToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return conditionToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// accept consumes the next token if it is the symbol or operator text.
+func (p *conditionParser) accept(text string) bool {
+	if tok, ok := p.peek(); ok && !tok.literal && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *conditionParser) next() (conditionToken, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return conditionToken{}, fmt.Errorf("when: unexpected end of expression")
+	}
+	p.pos++
+	return tok, nil
+}
+
+func (p *conditionParser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(ctx TransformContext) bool { return l(ctx) || right(ctx) }
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAnd() (condition, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(ctx TransformContext) bool { return l(ctx) && right(ctx) }
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseUnary() (condition, error) {
+	if p.accept("!") {
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx TransformContext) bool { return !inner(ctx) }, nil
+	}
+	if p.accept("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("when: missing closing parenthesis")
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *conditionParser) parseComparison() (condition, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	tok, ok := p.peek()
+	if !ok || tok.literal {
+		return func(ctx TransformContext) bool { return left(ctx) != "" }, nil
+	}
+
+	var compare func(a, b string) bool
+	switch tok.text {
+	case "==":
+		compare = func(a, b string) bool { return a == b }
+	case "!=":
+		compare = func(a, b string) bool { return a != b }
+	case "startsWith":
+		compare = strings.HasPrefix
+	case "endsWith":
+		compare = strings.HasSuffix
+	case "contains":
+		compare = strings.Contains
+	case "matches":
+		p.pos++
+		pattern, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if !pattern.literal {
+			return nil, fmt.Errorf("when: matches needs a quoted pattern, got %q", pattern.text)
+		}
+		re, err := regexp.Compile(pattern.text)
+		if err != nil {
+			return nil, fmt.Errorf("when: invalid pattern %q: %w", pattern.text, err)
+		}
+		return func(ctx TransformContext) bool { return re.MatchString(left(ctx)) }, nil
+	case "in":
+		p.pos++
+		list, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx TransformContext) bool { return slices.Contains(list, left(ctx)) }, nil
+	default:
+		return func(ctx TransformContext) bool { return left(ctx) != "" }, nil
+	}
+
+	p.pos++
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx TransformContext) bool { return compare(left(ctx), right(ctx)) }, nil
+}
+
+// parseList parses a bracketed list of quoted strings.
+func (p *conditionParser) parseList() ([]string, error) {
+	if !p.accept("[") {
+		return nil, fmt.Errorf("when: in needs a list such as [\"a\", \"b\"]")
+	}
+	var list []string
+	for !p.accept("]") {
+		if len(list) > 0 && !p.accept(",") {
+			return nil, fmt.Errorf("when: expected , or ] in list")
+		}
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if !tok.literal {
+			return nil, fmt.Errorf("when: list entries must be quoted strings, got %q", tok.text)
+		}
+		list = append(list, tok.text)
+	}
+	return list, nil
+}
+
+// parseOperand parses a quoted string or a variable.
+func (p *conditionParser) parseOperand() (operand, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if tok.literal {
+		value := tok.text
+		return func(TransformContext) string { return value }, nil
+	}
+	if variable, ok := conditionVariables[tok.text]; ok {
+		return variable, nil
+	}
+	if name, key, ok := strings.Cut(tok.text, "."); ok && key != "" {
+		if values, ok := conditionMaps[name]; ok {
+			return func(ctx TransformContext) string { return values(ctx)[key] }, nil
+		}
+	}
+	return nil, fmt.Errorf("when: unknown variable %q", tok.text)
+}
+
+// appliesTo reports whether rule applies to the mirror ctx describes: its target
+// namespace matches the rule's namespacePattern and its `when` expression holds.
+func (r *Rule) appliesTo(ctx TransformContext) (bool, error) {
+	if !matchesNamespacePattern(*r, ctx.TargetNamespace) {
+		return false, nil
+	}
+	if r.When == "" {
+		return true, nil
+	}
+	cond, err := parseCondition(r.When)
+	if err != nil {
+		return false, err
+	}
+	return cond(ctx), nil
+}
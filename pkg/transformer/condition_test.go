@@ -0,0 +1,169 @@
+package transformer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseCondition(t *testing.T) {
+	ctx := TransformContext{
+		SourceName:      "app-config",
+		SourceNamespace: "default",
+		TargetName:      "app-config",
+		TargetNamespace: "prod-eu",
+		ClusterName:     "edge-1",
+		Labels:          map[string]string{"tier": "web", "app.kubernetes.io/name": "shop"},
+		Annotations:     map[string]string{"legacy": ""},
+		TargetNamespaceLabels: map[string]string{
+			"env": "production",
+		},
+		TargetNamespaceAnnotations: map[string]string{"owner": "team-a"},
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		{name: "equal", expr: `targetNamespace == "prod-eu"`, want: true},
+		{name: "not equal", expr: `sourceName != "legacy"`, want: true},
+		{name: "starts with", expr: `targetNamespace startsWith "prod-"`, want: true},
+		{name: "ends with", expr: `targetNamespace endsWith "-us"`, want: false},
+		{name: "contains", expr: `clusterName contains "edge"`, want: true},
+		{name: "matches", expr: `targetNamespace matches '^prod-(eu|us)$'`, want: true},
+		{name: "in", expr: `labels.tier in ["api", "web"]`, want: true},
+		{name: "not in", expr: `!(labels.tier in ["api"])`, want: true},
+		{name: "and", expr: `targetNamespace startsWith "prod-" && sourceName != "legacy"`, want: true},
+		{name: "and fails", expr: `targetNamespace startsWith "prod-" && sourceName == "legacy"`, want: false},
+		{name: "or", expr: `sourceName == "legacy" || targetNamespaceLabels.env == "production"`, want: true},
+		{name: "and binds tighter than or", expr: `sourceName == "x" && clusterName == "y" || sourceNamespace == "default"`, want: true},
+		{name: "parentheses", expr: `sourceName == "x" && (clusterName == "y" || sourceNamespace == "default")`, want: false},
+		{name: "label key with dots and slashes", expr: `labels.app.kubernetes.io/name == "shop"`, want: true},
+		{name: "set value", expr: `targetNamespaceAnnotations.owner`, want: true},
+		{name: "empty value", expr: `annotations.legacy`, want: false},
+		{name: "missing value", expr: `!labels.missing`, want: true},
+		{name: "target name", expr: `targetName == sourceName`, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, err := parseCondition(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cond(ctx))
+		})
+	}
+}
+
+func TestParseCondition_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr string
+	}{
+		{name: "empty", expr: "  ", wantErr: "empty expression"},
+		{name: "unknown variable", expr: `namespace == "a"`, wantErr: `unknown variable "namespace"`},
+		{name: "unknown map", expr: `spec.replicas == "1"`, wantErr: `unknown variable "spec.replicas"`},
+		{name: "unterminated string", expr: `sourceName == "a`, wantErr: "unterminated string"},
+		{name: "missing operand", expr: `sourceName ==`, wantErr: "unexpected end"},
+		{name: "unclosed parenthesis", expr: `(sourceName == "a"`, wantErr: "missing closing parenthesis"},
+		{name: "trailing token", expr: `sourceName == "a" "b"`, wantErr: `unexpected "b"`},
+		{name: "unknown operator", expr: `sourceName is "a"`, wantErr: `unexpected "is"`},
+		{name: "invalid character", expr: `sourceName == "a" & clusterName`, wantErr: "unexpected character"},
+		{name: "invalid pattern", expr: `sourceName matches "("`, wantErr: "invalid pattern"},
+		{name: "unquoted pattern", expr: `sourceName matches clusterName`, wantErr: "quoted pattern"},
+		{name: "in without list", expr: `sourceName in "a"`, wantErr: "needs a list"},
+		{name: "unquoted list entry", expr: `sourceName in [clusterName]`, wantErr: "quoted strings"},
+		{name: "list without commas", expr: `sourceName in ["a" "b"]`, wantErr: "expected , or ]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseCondition(tt.expr)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestTransformer_WhenRules(t *testing.T) {
+	rules := `rules:
+  - path: data.REPLICAS
+    value: "3"
+    when: 'targetNamespace startsWith "prod-" && sourceName != "legacy"'
+  - path: data.DEBUG
+    value: "true"
+    namespacePattern: "*"
+    when: '!(targetNamespace startsWith "prod-")'
+`
+
+	tests := []struct {
+		name            string
+		sourceName      string
+		targetNamespace string
+		want            map[string]interface{}
+	}{
+		{
+			name:            "production",
+			sourceName:      "app",
+			targetNamespace: "prod-eu",
+			want:            map[string]interface{}{"KEY": "v", "REPLICAS": "3"},
+		},
+		{
+			name:            "legacy source in production",
+			sourceName:      "legacy",
+			targetNamespace: "prod-eu",
+			want:            map[string]interface{}{"KEY": "v"},
+		},
+		{
+			name:            "staging",
+			sourceName:      "app",
+			targetNamespace: "staging",
+			want:            map[string]interface{}{"KEY": "v", "DEBUG": "true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newNamespacedObject("v1", "ConfigMap", rules, map[string]interface{}{
+				"data": map[string]interface{}{"KEY": "v"},
+			})
+			annotations := source.GetAnnotations()
+			annotations[constants.AnnotationTransformStrict] = "true"
+			source.SetAnnotations(annotations)
+
+			result, err := NewDefaultTransformer().Transform(source, TransformContext{
+				SourceName:      tt.sourceName,
+				SourceNamespace: "default",
+				TargetNamespace: tt.targetNamespace,
+			})
+			require.NoError(t, err)
+			data, _, _ := unstructured.NestedFieldNoCopy(result.(*unstructured.Unstructured).Object, "data")
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
+
+func TestTransformer_WhenTargetNamespaceLabels(t *testing.T) {
+	opts := DefaultTransformOptions()
+	opts.Strict = true
+	opts.DefaultRules = []Rule{{Path: "data.TIER", Value: stringPtr("gold"), When: `targetNamespaceLabels.tier == "gold"`}}
+	opts.Namespace = func(_ context.Context, name string) (map[string]string, map[string]string, error) {
+		if name == "team-a" {
+			return map[string]string{"tier": "gold"}, nil, nil
+		}
+		return nil, nil, nil
+	}
+
+	for namespace, want := range map[string]interface{}{"team-a": "gold", "team-b": nil} {
+		source := newNamespacedObject("v1", "ConfigMap", "", map[string]interface{}{"data": map[string]interface{}{}})
+		result, err := NewTransformer(opts).Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: namespace})
+		require.NoError(t, err)
+		data, _, _ := unstructured.NestedMap(result.(*unstructured.Unstructured).Object, "data")
+		assert.Equal(t, want, data["TIER"], namespace)
+	}
+}
@@ -0,0 +1,126 @@
+package transformer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// AllResourceTypes is the DefaultRules key whose rules apply to every resource type.
+const AllResourceTypes = "*"
+
+// AnyVersion stands for the version in a DefaultRules key whose rules apply to
+// every version of a kind, e.g. "Certificate.*.cert-manager.io".
+const AnyVersion = "*"
+
+// DefaultRules holds controller-level transformation rules applied to every mirror
+// of a resource type before the source's own rules. Rules are keyed by resource type
+// in the --resource-types format ("Secret.v1", "Ingress.v1.networking.k8s.io"),
+// with "*" as the version for every version of a kind, or by "*" for all types:
+//
+//	"*":
+//	  - path: metadata.labels.mirrored-from
+//	    template: "{{.SourceNamespace}}"
+//	Secret.v1:
+//	  - path: data.DEBUG
+//	    delete: true
+//	Certificate.*.cert-manager.io:
+//	  - path: spec.secretName
+//	    template: "{{.TargetNamespace}}-tls"
+type DefaultRules struct {
+	byType map[string][]Rule
+}
+
+// LoadDefaultRules reads default rules from a YAML file.
+func LoadDefaultRules(path string) (*DefaultRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read default transform rules: %w", err)
+	}
+	return ParseDefaultRules(data)
+}
+
+// ParseDefaultRules parses and validates default rules from YAML.
+func ParseDefaultRules(data []byte) (*DefaultRules, error) {
+	byType := map[string][]Rule{}
+	if err := yaml.Unmarshal(data, &byType); err != nil {
+		return nil, fmt.Errorf("failed to parse default transform rules: %w", err)
+	}
+	return NewDefaultRules(byType)
+}
+
+// NewDefaultRules validates default rules already decoded by resource type key.
+func NewDefaultRules(byType map[string][]Rule) (*DefaultRules, error) {
+	for key, rules := range byType {
+		if key != AllResourceTypes && len(strings.Split(key, ".")) < 2 {
+			return nil, fmt.Errorf("invalid resource type %q in default transform rules (expected kind.version, kind.version.group, kind.*.group or *)", key)
+		}
+		for i, rule := range rules {
+			if err := rule.Validate(); err != nil {
+				return nil, fmt.Errorf("default transform rules for %s: rule %d: %w", key, i+1, err)
+			}
+		}
+	}
+
+	return &DefaultRules{byType: byType}, nil
+}
+
+// For returns the default rules for gvk: rules for all types first, then those for
+// every version of its kind, then those for its version.
+func (d *DefaultRules) For(gvk schema.GroupVersionKind) []Rule {
+	if d == nil {
+		return nil
+	}
+
+	var rules []Rule
+	rules = append(rules, d.byType[AllResourceTypes]...)
+	rules = append(rules, d.byType[resourceTypeKey(gvk.Kind, AnyVersion, gvk.Group)]...)
+	rules = append(rules, d.byType[resourceTypeKey(gvk.Kind, gvk.Version, gvk.Group)]...)
+	return rules
+}
+
+// resourceTypeKey formats a resource type the way DefaultRules are keyed.
+func resourceTypeKey(kind, version, group string) string {
+	key := kind + "." + version
+	if group != "" {
+		key += "." + group
+	}
+	return key
+}
+
+// mergeRules combines default rules with a resource's own rules for one mirror.
+// An own rule on the same path as a default replaces that default wherever the
+// own rule applies, so a source can override a default (or escape one that would
+// fail) instead of both running; two merge rules on one path combine instead.
+// Kept defaults come first, followed by the own rules; defaults reports how many
+// of the result are defaults.
+func mergeRules(defaultRules, own []Rule, ctx TransformContext) (merged []Rule, defaults int) {
+	merged = make([]Rule, 0, len(defaultRules)+len(own))
+	for _, def := range defaultRules {
+		if !overridden(def, own, ctx) {
+			merged = append(merged, def)
+		}
+	}
+	defaults = len(merged)
+	return append(merged, own...), defaults
+}
+
+// overridden reports whether an own rule replaces def for the mirror ctx describes.
+func overridden(def Rule, own []Rule, ctx TransformContext) bool {
+	for _, rule := range own {
+		if rule.Path != def.Path {
+			continue
+		}
+		if applies, err := rule.appliesTo(ctx); err != nil || !applies {
+			continue
+		}
+		if rule.Type() == RuleTypeMerge && def.Type() == RuleTypeMerge {
+			continue
+		}
+		return true
+	}
+	return false
+}
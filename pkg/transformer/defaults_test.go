@@ -0,0 +1,223 @@
+package transformer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const testDefaultRules = `
+"*":
+  - path: metadata.labels.mirrored-from
+    template: "{{.SourceNamespace}}"
+ConfigMap.v1:
+  - path: data.DEBUG
+    delete: true
+  - path: data.LOG_LEVEL
+    value: "info"
+Ingress.v1.networking.k8s.io:
+  - path: metadata.annotations.team
+    value: "platform"
+Ingress.*.networking.k8s.io:
+  - path: spec.ingressClassName
+    delete: true
+`
+
+func TestParseDefaultRules(t *testing.T) {
+	rules, err := ParseDefaultRules([]byte(testDefaultRules))
+	require.NoError(t, err)
+
+	configMap := rules.For(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+	require.Len(t, configMap, 3)
+	assert.Equal(t, "metadata.labels.mirrored-from", configMap[0].Path, "rules for all types come first")
+	assert.Equal(t, "data.DEBUG", configMap[1].Path)
+
+	ingress := rules.For(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"})
+	require.Len(t, ingress, 3)
+	assert.Equal(t, "spec.ingressClassName", ingress[1].Path, "rules for every version come before version-specific ones")
+	assert.Equal(t, "metadata.annotations.team", ingress[2].Path)
+
+	ingressBeta := rules.For(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"})
+	require.Len(t, ingressBeta, 2)
+	assert.Equal(t, "spec.ingressClassName", ingressBeta[1].Path)
+
+	assert.Len(t, rules.For(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}), 1)
+
+	var none *DefaultRules
+	assert.Nil(t, none.For(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}))
+}
+
+func TestParseDefaultRules_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "malformed yaml", input: "not: [valid"},
+		{name: "invalid resource type", input: "Secret:\n  - path: data.x\n    delete: true\n"},
+		{name: "invalid rule", input: "Secret.v1:\n  - path: data.x\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDefaultRules([]byte(tt.input))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadDefaultRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testDefaultRules), 0o600))
+
+	rules, err := LoadDefaultRules(path)
+	require.NoError(t, err)
+	assert.Len(t, rules.For(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}), 3)
+
+	_, err = LoadDefaultRules(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestMergeRules(t *testing.T) {
+	value := func(s string) *string { return &s }
+	defaults := []Rule{
+		{Path: "metadata.labels", Merge: map[string]interface{}{"team": "platform"}},
+		{Path: "data.DEBUG", Delete: true},
+		{Path: "data.LOG_LEVEL", Value: value("info")},
+	}
+
+	tests := []struct {
+		name         string
+		own          []Rule
+		want         []Rule
+		wantDefaults int
+	}{
+		{
+			name:         "no own rules",
+			want:         defaults,
+			wantDefaults: 3,
+		},
+		{
+			name:         "own rule replaces default on the same path",
+			own:          []Rule{{Path: "data.DEBUG", Value: value("true")}},
+			want:         []Rule{defaults[0], defaults[2], {Path: "data.DEBUG", Value: value("true")}},
+			wantDefaults: 2,
+		},
+		{
+			name:         "merge rules combine",
+			own:          []Rule{{Path: "metadata.labels", Merge: map[string]interface{}{"app": "web"}}},
+			want:         append(append([]Rule{}, defaults...), Rule{Path: "metadata.labels", Merge: map[string]interface{}{"app": "web"}}),
+			wantDefaults: 3,
+		},
+		{
+			name:         "own rule for other namespaces keeps the default",
+			own:          []Rule{{Path: "data.LOG_LEVEL", Value: value("warn"), NamespacePattern: NamespacePattern{"staging-*"}}},
+			want:         append(append([]Rule{}, defaults...), Rule{Path: "data.LOG_LEVEL", Value: value("warn"), NamespacePattern: NamespacePattern{"staging-*"}}),
+			wantDefaults: 3,
+		},
+		{
+			name:         "own rule whose condition fails keeps the default",
+			own:          []Rule{{Path: "data.LOG_LEVEL", Value: value("warn"), When: `sourceName == "legacy"`}},
+			want:         append(append([]Rule{}, defaults...), Rule{Path: "data.LOG_LEVEL", Value: value("warn"), When: `sourceName == "legacy"`}),
+			wantDefaults: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, defaultCount := mergeRules(defaults, tt.own, TransformContext{TargetNamespace: "prod"})
+			assert.Equal(t, tt.want, merged)
+			assert.Equal(t, tt.wantDefaults, defaultCount)
+		})
+	}
+}
+
+func TestTransformer_DefaultRules(t *testing.T) {
+	defaults, err := ParseDefaultRules([]byte(testDefaultRules))
+	require.NoError(t, err)
+
+	opts := DefaultTransformOptions()
+	opts.DefaultRules = defaults.For(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+
+	t.Run("applies without source rules", func(t *testing.T) {
+		source := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Data:       map[string]string{"DEBUG": "true", "LOG_LEVEL": "debug"},
+		}
+
+		result, err := NewTransformer(opts).Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "prod"})
+		require.NoError(t, err)
+
+		u := result.(*unstructured.Unstructured)
+		assert.Equal(t, "default", u.GetLabels()["mirrored-from"])
+		data, _, _ := unstructured.NestedStringMap(u.Object, "data")
+		assert.Equal(t, map[string]string{"LOG_LEVEL": "info"}, data)
+	})
+
+	t.Run("source rules run after defaults", func(t *testing.T) {
+		source := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "app",
+				Namespace: "default",
+				Annotations: map[string]string{
+					constants.AnnotationTransform: "rules:\n  - path: data.LOG_LEVEL\n    value: \"warn\"\n",
+				},
+			},
+			Data: map[string]string{"LOG_LEVEL": "debug"},
+		}
+
+		result, err := NewTransformer(opts).Transform(source, TransformContext{TargetNamespace: "prod"})
+		require.NoError(t, err)
+
+		value, _, _ := unstructured.NestedString(result.(*unstructured.Unstructured).Object, "data", "LOG_LEVEL")
+		assert.Equal(t, "warn", value)
+	})
+
+	t.Run("source rule on a default's path replaces it", func(t *testing.T) {
+		source := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "app",
+				Namespace: "default",
+				Annotations: map[string]string{
+					constants.AnnotationTransform: "rules:\n  - path: data.DEBUG\n    value: \"false\"\n    namespacePattern: \"staging-*\"\n",
+				},
+			},
+			Data: map[string]string{"DEBUG": "true"},
+		}
+
+		result, err := NewTransformer(opts).Transform(source, TransformContext{TargetNamespace: "staging-eu"})
+		require.NoError(t, err)
+		value, found, _ := unstructured.NestedString(result.(*unstructured.Unstructured).Object, "data", "DEBUG")
+		assert.True(t, found, "default delete is replaced where the source rule applies")
+		assert.Equal(t, "false", value)
+
+		result, err = NewTransformer(opts).Transform(source, TransformContext{TargetNamespace: "prod"})
+		require.NoError(t, err)
+		_, found, _ = unstructured.NestedString(result.(*unstructured.Unstructured).Object, "data", "DEBUG")
+		assert.False(t, found, "default still applies elsewhere")
+	})
+
+	t.Run("invalid source rules keep defaults in non-strict mode", func(t *testing.T) {
+		source := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "app",
+				Namespace:   "default",
+				Annotations: map[string]string{constants.AnnotationTransform: "rules: [invalid"},
+			},
+			Data: map[string]string{"LOG_LEVEL": "debug"},
+		}
+
+		result, err := NewTransformer(opts).Transform(source, TransformContext{TargetNamespace: "prod"})
+		require.NoError(t, err)
+
+		value, _, _ := unstructured.NestedString(result.(*unstructured.Unstructured).Object, "data", "LOG_LEVEL")
+		assert.Equal(t, "info", value)
+	})
+}
@@ -0,0 +1,75 @@
+package transformer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RuleOutcome is what happened to a transformation rule.
+type RuleOutcome string
+
+const (
+	// RuleApplied means the rule changed, or was applied to, the resource
+	RuleApplied RuleOutcome = "applied"
+	// RuleSkipped means the rule does not apply to the mirror being built
+	RuleSkipped RuleOutcome = "skipped"
+	// RuleFailed means the rule returned an error and was skipped in non-strict mode
+	RuleFailed RuleOutcome = "failed"
+)
+
+// RuleResult reports the outcome of one step of a transformation.
+type RuleResult struct {
+	// Rule names the step: "rule N" for the resource's rules (library rules
+	// included), "default rule N" for controller defaults, "rules" for the rule
+	// set as a whole, or the annotation of an annotation-driven transform
+	Rule string `json:"rule"`
+	// Path is the rule's path
+	Path string `json:"path,omitempty"`
+	// Outcome is whether the step applied, was skipped or failed
+	Outcome RuleOutcome `json:"outcome"`
+	// Reason explains why a rule was skipped
+	Reason string `json:"reason,omitempty"`
+	// Error is why the step failed
+	Error string `json:"error,omitempty"`
+}
+
+// Diagnostics reports what a transformation did, rule by rule, so a transform
+// that "did nothing" can be debugged without the controller's logs.
+type Diagnostics struct {
+	Results []RuleResult
+}
+
+// Failed returns the results of the steps that failed.
+func (d *Diagnostics) Failed() []RuleResult {
+	var failed []RuleResult
+	for _, result := range d.Results {
+		if result.Outcome == RuleFailed {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// fail records a failed step.
+func (d *Diagnostics) fail(step string, err error) {
+	d.Results = append(d.Results, RuleResult{Rule: step, Outcome: RuleFailed, Error: err.Error()})
+}
+
+// ruleName names the i-th of the merged rules, the first defaultCount of which are
+// controller defaults, the way strict mode errors do.
+func ruleName(i, defaultCount int) string {
+	if i < defaultCount {
+		return "default rule " + strconv.Itoa(i+1)
+	}
+	return "rule " + strconv.Itoa(i-defaultCount+1)
+}
+
+// skipReason explains why rule does not apply to the mirror ctx describes.
+func skipReason(rule Rule, ctx TransformContext) string {
+	if !matchesNamespacePattern(rule, ctx.TargetNamespace) {
+		return fmt.Sprintf("namespacePattern %q does not match namespace %s",
+			strings.Join(rule.NamespacePattern, ", "), ctx.TargetNamespace)
+	}
+	return fmt.Sprintf("when %q is false", rule.When)
+}
@@ -0,0 +1,74 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformer_TransformWithDiagnostics(t *testing.T) {
+	rules := `rules:
+  - path: data.LOG_LEVEL
+    value: warn
+  - path: data.ENV
+    template: "{{ .Missing }}"
+  - path: data.REPLICAS
+    value: "3"
+    when: 'targetNamespace startsWith "prod-"'
+  - path: data.DEBUG
+    value: "true"
+    namespacePattern: [staging, dev-*]
+`
+	opts := DefaultTransformOptions()
+	opts.DefaultRules = []Rule{{Path: "data.TEAM", Value: stringPtr("payments")}}
+	source := newNamespacedObject("v1", "ConfigMap", rules, map[string]interface{}{
+		"data": map[string]interface{}{"LOG_LEVEL": "debug"},
+	})
+
+	_, diag, err := NewTransformer(opts).TransformWithDiagnostics(source, TransformContext{SourceNamespace: "default", TargetNamespace: "team-a"})
+	require.NoError(t, err)
+	require.Len(t, diag.Results, 5)
+	assert.Equal(t, RuleResult{Rule: "default rule 1", Path: "data.TEAM", Outcome: RuleApplied}, diag.Results[0])
+	assert.Equal(t, RuleResult{Rule: "rule 1", Path: "data.LOG_LEVEL", Outcome: RuleApplied}, diag.Results[1])
+	assert.Equal(t, "rule 2", diag.Results[2].Rule)
+	assert.Equal(t, RuleFailed, diag.Results[2].Outcome)
+	assert.Contains(t, diag.Results[2].Error, "can't evaluate field Missing")
+	assert.Equal(t, RuleResult{Rule: "rule 3", Path: "data.REPLICAS", Outcome: RuleSkipped,
+		Reason: `when "targetNamespace startsWith \"prod-\"" is false`}, diag.Results[3])
+	assert.Equal(t, RuleResult{Rule: "rule 4", Path: "data.DEBUG", Outcome: RuleSkipped,
+		Reason: `namespacePattern "staging, dev-*" does not match namespace team-a`}, diag.Results[4])
+	assert.Equal(t, []RuleResult{diag.Results[2]}, diag.Failed())
+}
+
+func TestTransformer_TransformWithDiagnosticsIgnoredRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr string
+	}{
+		{name: "unparseable", rules: "rules: [", wantErr: "failed to parse transformation rules"},
+		{name: "invalid", rules: "rules:\n  - path: data.KEY\n", wantErr: "invalid transformation rules: rule 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newNamespacedObject("v1", "ConfigMap", tt.rules, map[string]interface{}{"data": map[string]interface{}{}})
+			_, diag, err := NewDefaultTransformer().TransformWithDiagnostics(source, TransformContext{TargetNamespace: "team-a"})
+			require.NoError(t, err)
+			require.Len(t, diag.Results, 1, "the rules are reported although none ran")
+			assert.Equal(t, "rules", diag.Results[0].Rule)
+			assert.Equal(t, RuleFailed, diag.Results[0].Outcome)
+			assert.Contains(t, diag.Results[0].Error, tt.wantErr)
+
+			// Strict mode fails instead
+			annotations := source.GetAnnotations()
+			annotations[constants.AnnotationTransformStrict] = "true"
+			source.SetAnnotations(annotations)
+			_, diag, err = NewDefaultTransformer().TransformWithDiagnostics(source, TransformContext{TargetNamespace: "team-a"})
+			require.Error(t, err)
+			assert.Nil(t, diag)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package transformer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// alphaNum are the characters randAlphaNum draws from.
+const alphaNum = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// maxRandLength bounds randAlphaNum so a template cannot allocate without limit.
+const maxRandLength = 4096
+
+// indent prefixes every line of s with n spaces.
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// nindent is indent preceded by a newline, for values placed under a YAML key.
+func nindent(n int, s string) string {
+	return "\n" + indent(n, s)
+}
+
+// toYAML renders v as YAML without the trailing newline.
+func toYAML(v interface{}) (string, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// base64Decode decodes standard base64, e.g. a value read from a Secret's data.
+func base64Decode(s string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid base64: %w", err)
+	}
+	return string(decoded), nil
+}
+
+// sha256Sum returns the hex-encoded SHA-256 digest of s.
+func sha256Sum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// contentSeed derives the seed of randAlphaNum from the source's content (all
+// but its metadata and status) and the mirror's target, so generated values
+// differ per target, stay the same across syncs, and only change with the
+// source's content. Anyone able to read the source can derive them, just as
+// they could read the mirror.
+func contentSeed(u *unstructured.Unstructured, ctx TransformContext) []byte {
+	content := make(map[string]interface{}, len(u.Object))
+	for key, value := range u.Object {
+		if key != "metadata" && key != "status" {
+			content[key] = value
+		}
+	}
+	// Maps marshal with sorted keys, so the seed is stable
+	data, _ := json.Marshal(content)
+
+	h := sha256.New()
+	h.Write(data)
+	fmt.Fprintf(h, "\x00%s/%s", ctx.TargetNamespace, ctx.TargetName)
+	return h.Sum(nil)
+}
+
+// randAlphaNum returns the randAlphaNum template function for one template at
+// scope (e.g. the path it renders into). Each call returns a different string,
+// and rendering the same template for the same target returns the same strings.
+func randAlphaNum(seed []byte, scope, text string) func(n int) (string, error) {
+	calls := 0
+	return func(n int) (string, error) {
+		if n < 0 || n > maxRandLength {
+			return "", fmt.Errorf("randAlphaNum length must be between 0 and %d", maxRandLength)
+		}
+		calls++
+
+		h := sha256.New()
+		h.Write(seed)
+		fmt.Fprintf(h, "\x00%s\x00%s\x00%d\x00%d", scope, text, calls, n)
+		var key [32]byte
+		copy(key[:], h.Sum(nil))
+
+		rng := rand.New(rand.NewChaCha8(key))
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = alphaNum[rng.IntN(len(alphaNum))]
+		}
+		return string(b), nil
+	}
+}
+
+// contextValue returns the contextValue template function:
+//
+//	{{ contextValue "domain" }}
+//
+// It returns the value of key in the context ConfigMap (TransformOptions.ContextConfigMap)
+// of the target namespace, or an empty string when the ConfigMap or key does not exist.
+// The ConfigMap lives next to the mirror, so no allow-list is involved.
+func (t *Transformer) contextValue(ctx context.Context, targetNamespace string) func(key string) (string, error) {
+	return func(key string) (string, error) {
+		if t.options.Lookup == nil || t.options.ContextConfigMap == "" {
+			return "", fmt.Errorf("contextValue is not enabled")
+		}
+		data, err := t.options.Lookup(ctx, "v1", "ConfigMap", targetNamespace, t.options.ContextConfigMap)
+		if err != nil {
+			return "", fmt.Errorf("reading context ConfigMap %s/%s failed: %w", targetNamespace, t.options.ContextConfigMap, err)
+		}
+		return data[key], nil
+	}
+}
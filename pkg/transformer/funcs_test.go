@@ -0,0 +1,122 @@
+package transformer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// renderConfigMapRules transforms a ConfigMap with data and the given strict
+// transform rules, and returns the resulting data.
+func renderConfigMapRules(t *testing.T, opts TransformOptions, data map[string]string, rules string, ctx TransformContext) map[string]string {
+	t.Helper()
+	source := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app-config",
+			Namespace: "default",
+			Annotations: map[string]string{
+				constants.AnnotationTransform:       rules,
+				constants.AnnotationTransformStrict: "true",
+			},
+		},
+		Data: data,
+	}
+	result, err := NewTransformer(opts).Transform(source, ctx)
+	require.NoError(t, err)
+	got, _, err := unstructured.NestedStringMap(result.(*unstructured.Unstructured).Object, "data")
+	require.NoError(t, err)
+	return got
+}
+
+func TestTemplateFuncs_Encoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "base64enc", template: `{{ "hello" | base64enc }}`, want: "aGVsbG8="},
+		{name: "base64dec", template: `{{ "aGVsbG8=" | base64dec }}`, want: "hello"},
+		{name: "sha256sum", template: `{{ "hello" | sha256sum }}`, want: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{name: "indent", template: `{{ "a\nb" | indent 2 }}`, want: "  a\n  b"},
+		{name: "nindent", template: `key:{{ "a\nb" | nindent 2 }}`, want: "key:\n  a\n  b"},
+		{name: "toYaml", template: `{{ toYaml .Labels }}`, want: "app: web\ntier: frontend"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewDefaultTransformer().renderTemplate(tt.template, TransformContext{},
+				TransformContext{Labels: map[string]string{"tier": "frontend", "app": "web"}}, "data.VALUE")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+
+	_, err := NewDefaultTransformer().renderTemplate(`{{ "not base64!" | base64dec }}`, TransformContext{}, TransformContext{}, "")
+	assert.Error(t, err)
+}
+
+func TestTemplateFuncs_RandAlphaNum(t *testing.T) {
+	rules := `rules:
+  - path: data.PASSWORD
+    template: '{{ randAlphaNum 24 }}'
+  - path: data.TOKEN
+    template: '{{ randAlphaNum 24 }}'
+  - path: data.PAIR
+    template: '{{ randAlphaNum 8 }}-{{ randAlphaNum 8 }}'
+`
+	data := map[string]string{"user": "admin"}
+	render := func(targetNs string, data map[string]string) map[string]string {
+		return renderConfigMapRules(t, DefaultTransformOptions(), data, rules,
+			TransformContext{TargetNamespace: targetNs, TargetName: "app-config"})
+	}
+
+	first := render("team-a", data)
+	assert.Regexp(t, "^[a-zA-Z0-9]{24}$", first["PASSWORD"])
+	assert.NotEqual(t, first["PASSWORD"], first["TOKEN"], "each path gets its own value")
+	assert.Regexp(t, "^[a-zA-Z0-9]{8}-[a-zA-Z0-9]{8}$", first["PAIR"])
+	assert.NotEqual(t, first["PAIR"][:8], first["PAIR"][9:], "each call gets its own value")
+
+	assert.Equal(t, first, render("team-a", data), "stable across syncs")
+	assert.NotEqual(t, first["PASSWORD"], render("team-b", data)["PASSWORD"], "differs per target")
+	assert.NotEqual(t, first["PASSWORD"], render("team-a", map[string]string{"user": "root"})["PASSWORD"],
+		"changes with the source's content")
+
+	_, err := NewDefaultTransformer().renderTemplate(`{{ randAlphaNum 100000 }}`, TransformContext{}, TransformContext{}, "")
+	assert.Error(t, err)
+}
+
+func TestTemplateFuncs_ContextValue(t *testing.T) {
+	var read []string
+	lookup := func(_ context.Context, apiVersion, kind, namespace, name string) (map[string]string, error) {
+		read = append(read, apiVersion+"/"+kind+" "+namespace+"/"+name)
+		if namespace == "prod" {
+			return map[string]string{"domain": "prod.example.com"}, nil
+		}
+		return nil, nil
+	}
+	rules := `rules:
+  - path: data.DOMAIN
+    template: '{{ contextValue "domain" | default "example.com" }}'
+`
+	opts := DefaultTransformOptions()
+	opts.Lookup = lookup
+	opts.ContextConfigMap = "kubemirror-context"
+
+	got := renderConfigMapRules(t, opts, nil, rules, TransformContext{TargetNamespace: "prod"})
+	assert.Equal(t, "prod.example.com", got["DOMAIN"])
+	got = renderConfigMapRules(t, opts, nil, rules, TransformContext{TargetNamespace: "dev"})
+	assert.Equal(t, "example.com", got["DOMAIN"], "missing ConfigMap yields an empty string")
+	assert.Equal(t, []string{"v1/ConfigMap prod/kubemirror-context", "v1/ConfigMap dev/kubemirror-context"}, read,
+		"reads the context ConfigMap of the target namespace without an allow-list")
+
+	opts.ContextConfigMap = ""
+	_, err := NewTransformer(opts).renderTemplate(`{{ contextValue "domain" }}`, TransformContext{TargetNamespace: "prod"},
+		TransformContext{}, "")
+	assert.Error(t, err, "disabled without a context ConfigMap")
+}
@@ -0,0 +1,171 @@
+package transformer
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// HostContext is the template data for host rewriting: the transformation
+// context plus the original hostname being rewritten.
+type HostContext struct {
+	TransformContext
+	// Host is the hostname on the source resource
+	Host string
+}
+
+// hostRewriteTemplate returns the host template of an Ingress or HTTPRoute
+// (kubemirror.raczylo.com/host-template), or "" when the preset does not apply.
+func hostRewriteTemplate(u *unstructured.Unstructured) string {
+	gvk := u.GroupVersionKind()
+	if !isIngress(gvk) && !isHTTPRoute(gvk) {
+		return ""
+	}
+	return u.GetAnnotations()[constants.AnnotationHostTemplate]
+}
+
+func isIngress(gvk schema.GroupVersionKind) bool {
+	return gvk.Group == "networking.k8s.io" && gvk.Kind == "Ingress"
+}
+
+func isHTTPRoute(gvk schema.GroupVersionKind) bool {
+	return gvk.Group == "gateway.networking.k8s.io" && gvk.Kind == "HTTPRoute"
+}
+
+// rewriteHosts applies the host-rewrite preset so a mirrored route works in its
+// target namespace:
+//   - Ingress: spec.rules[].host and spec.tls[].hosts[] are rewritten. Ingress backends
+//     are always namespace-local, so they resolve to services in the target namespace.
+//   - HTTPRoute: spec.hostnames[] are rewritten, backendRefs pointing at the source
+//     namespace are made local to the target namespace, and parentRefs without a
+//     namespace are pinned to the source namespace so they keep attaching to the same Gateway.
+func (t *Transformer) rewriteHosts(u *unstructured.Unstructured, hostTemplate string, ctx TransformContext) error {
+	rewrite := func(host string) (string, error) {
+		return t.renderTemplate(hostTemplate, ctx, HostContext{TransformContext: ctx, Host: host}, host)
+	}
+
+	if isIngress(u.GroupVersionKind()) {
+		return rewriteIngressHosts(u, rewrite)
+	}
+	return rewriteHTTPRoute(u, rewrite, ctx)
+}
+
+func rewriteIngressHosts(u *unstructured.Unstructured, rewrite func(string) (string, error)) error {
+	rules, _, err := unstructured.NestedSlice(u.Object, "spec", "rules")
+	if err != nil {
+		return err
+	}
+	for i, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		host, ok := rule["host"].(string)
+		if !ok || host == "" {
+			continue
+		}
+		if rule["host"], err = rewrite(host); err != nil {
+			return fmt.Errorf("spec.rules[%d].host: %w", i, err)
+		}
+	}
+
+	tls, _, err := unstructured.NestedSlice(u.Object, "spec", "tls")
+	if err != nil {
+		return err
+	}
+	for i, entry := range tls {
+		tlsEntry, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		hosts, ok := tlsEntry["hosts"].([]interface{})
+		if !ok {
+			continue
+		}
+		if err := rewriteHostList(hosts, rewrite); err != nil {
+			return fmt.Errorf("spec.tls[%d].hosts: %w", i, err)
+		}
+	}
+
+	if err := setSliceIfPresent(u, rules, "spec", "rules"); err != nil {
+		return err
+	}
+	return setSliceIfPresent(u, tls, "spec", "tls")
+}
+
+func rewriteHTTPRoute(u *unstructured.Unstructured, rewrite func(string) (string, error), ctx TransformContext) error {
+	hostnames, _, err := unstructured.NestedSlice(u.Object, "spec", "hostnames")
+	if err != nil {
+		return err
+	}
+	if err := rewriteHostList(hostnames, rewrite); err != nil {
+		return fmt.Errorf("spec.hostnames: %w", err)
+	}
+
+	parentRefs, _, err := unstructured.NestedSlice(u.Object, "spec", "parentRefs")
+	if err != nil {
+		return err
+	}
+	for _, p := range parentRefs {
+		if ref, ok := p.(map[string]interface{}); ok {
+			if ns, _ := ref["namespace"].(string); ns == "" {
+				ref["namespace"] = ctx.SourceNamespace
+			}
+		}
+	}
+
+	rules, _, err := unstructured.NestedSlice(u.Object, "spec", "rules")
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		backendRefs, ok := rule["backendRefs"].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, b := range backendRefs {
+			if ref, ok := b.(map[string]interface{}); ok && ref["namespace"] == ctx.SourceNamespace {
+				delete(ref, "namespace")
+			}
+		}
+	}
+
+	if err := setSliceIfPresent(u, hostnames, "spec", "hostnames"); err != nil {
+		return err
+	}
+	if err := setSliceIfPresent(u, parentRefs, "spec", "parentRefs"); err != nil {
+		return err
+	}
+	return setSliceIfPresent(u, rules, "spec", "rules")
+}
+
+// rewriteHostList rewrites every string host in hosts in place.
+func rewriteHostList(hosts []interface{}, rewrite func(string) (string, error)) error {
+	for i, h := range hosts {
+		host, ok := h.(string)
+		if !ok || host == "" {
+			continue
+		}
+		rewritten, err := rewrite(host)
+		if err != nil {
+			return err
+		}
+		hosts[i] = rewritten
+	}
+	return nil
+}
+
+// setSliceIfPresent writes a modified copy of a slice back, leaving absent fields absent.
+func setSliceIfPresent(u *unstructured.Unstructured, value []interface{}, fields ...string) error {
+	if value == nil {
+		return nil
+	}
+	return unstructured.SetNestedSlice(u.Object, value, fields...)
+}
@@ -0,0 +1,106 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newRoute(apiVersion, kind, hostTemplate string, spec map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetName("app")
+	u.SetNamespace("default")
+	u.SetAnnotations(map[string]string{constants.AnnotationHostTemplate: hostTemplate})
+	return u
+}
+
+func TestTransformer_HostRewriteIngress(t *testing.T) {
+	source := newRoute("networking.k8s.io/v1", "Ingress", "{{.TargetNamespace}}.{{.Host}}", map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{"host": "app.example.com"},
+			map[string]interface{}{"http": map[string]interface{}{}},
+		},
+		"tls": []interface{}{
+			map[string]interface{}{"hosts": []interface{}{"app.example.com"}, "secretName": "app-tls"},
+		},
+	})
+
+	result, err := NewDefaultTransformer().Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "pr-42"})
+	require.NoError(t, err)
+
+	u := result.(*unstructured.Unstructured)
+	rules, _, _ := unstructured.NestedSlice(u.Object, "spec", "rules")
+	assert.Equal(t, "pr-42.app.example.com", rules[0].(map[string]interface{})["host"])
+	assert.NotContains(t, rules[1].(map[string]interface{}), "host", "rules without host stay catch-all")
+
+	tls, _, _ := unstructured.NestedSlice(u.Object, "spec", "tls")
+	assert.Equal(t, []interface{}{"pr-42.app.example.com"}, tls[0].(map[string]interface{})["hosts"])
+	assert.Equal(t, "app-tls", tls[0].(map[string]interface{})["secretName"])
+}
+
+func TestTransformer_HostRewriteHTTPRoute(t *testing.T) {
+	source := newRoute("gateway.networking.k8s.io/v1", "HTTPRoute", "{{.TargetNamespace}}.preview.example.com", map[string]interface{}{
+		"hostnames": []interface{}{"app.example.com"},
+		"parentRefs": []interface{}{
+			map[string]interface{}{"name": "local-gateway"},
+			map[string]interface{}{"name": "shared-gateway", "namespace": "gateway-system"},
+		},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"backendRefs": []interface{}{
+					map[string]interface{}{"name": "app", "port": int64(80)},
+					map[string]interface{}{"name": "app-canary", "namespace": "default", "port": int64(80)},
+					map[string]interface{}{"name": "auth", "namespace": "shared", "port": int64(80)},
+				},
+			},
+		},
+	})
+
+	result, err := NewDefaultTransformer().Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "pr-42"})
+	require.NoError(t, err)
+
+	u := result.(*unstructured.Unstructured)
+	hostnames, _, _ := unstructured.NestedStringSlice(u.Object, "spec", "hostnames")
+	assert.Equal(t, []string{"pr-42.preview.example.com"}, hostnames)
+
+	parentRefs, _, _ := unstructured.NestedSlice(u.Object, "spec", "parentRefs")
+	assert.Equal(t, "default", parentRefs[0].(map[string]interface{})["namespace"], "implicit parent is pinned to the source namespace")
+	assert.Equal(t, "gateway-system", parentRefs[1].(map[string]interface{})["namespace"])
+
+	rules, _, _ := unstructured.NestedSlice(u.Object, "spec", "rules")
+	backends := rules[0].(map[string]interface{})["backendRefs"].([]interface{})
+	assert.NotContains(t, backends[0].(map[string]interface{}), "namespace")
+	assert.NotContains(t, backends[1].(map[string]interface{}), "namespace", "source namespace backend becomes local")
+	assert.Equal(t, "shared", backends[2].(map[string]interface{})["namespace"], "other namespaces are kept")
+}
+
+func TestTransformer_HostRewriteIgnoredForOtherKinds(t *testing.T) {
+	source := newRoute("v1", "Service", "{{.TargetNamespace}}.example.com", map[string]interface{}{})
+
+	result, err := NewDefaultTransformer().Transform(source, TransformContext{TargetNamespace: "pr-42"})
+	require.NoError(t, err)
+	assert.Same(t, source, result)
+}
+
+func TestTransformer_HostRewriteInvalidTemplate(t *testing.T) {
+	source := newRoute("networking.k8s.io/v1", "Ingress", "{{.TargetNamespace", map[string]interface{}{
+		"rules": []interface{}{map[string]interface{}{"host": "app.example.com"}},
+	})
+
+	result, err := NewDefaultTransformer().Transform(source, TransformContext{TargetNamespace: "pr-42"})
+	require.NoError(t, err)
+	rules, _, _ := unstructured.NestedSlice(result.(*unstructured.Unstructured).Object, "spec", "rules")
+	assert.Equal(t, "app.example.com", rules[0].(map[string]interface{})["host"], "non-strict mode keeps the original host")
+
+	source.SetAnnotations(map[string]string{
+		constants.AnnotationHostTemplate:    "{{.TargetNamespace",
+		constants.AnnotationTransformStrict: "true",
+	})
+	_, err = NewDefaultTransformer().Transform(source, TransformContext{TargetNamespace: "pr-42"})
+	assert.Error(t, err)
+}
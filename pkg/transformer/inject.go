@@ -0,0 +1,30 @@
+package transformer
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// namespaceKey returns the data key the target namespace should be written to
+// (kubemirror.raczylo.com/inject-namespace-key), or "" when injection is not requested.
+// Only Secrets and ConfigMaps have data keys.
+func namespaceKey(u *unstructured.Unstructured) string {
+	if u.GetAPIVersion() != "v1" || (u.GetKind() != "ConfigMap" && u.GetKind() != "Secret") {
+		return ""
+	}
+	return strings.TrimSpace(u.GetAnnotations()[constants.AnnotationInjectNamespaceKey])
+}
+
+// injectNamespaceKey writes the target namespace into data[key].
+// Secret values are base64-encoded by setNestedField.
+func injectNamespaceKey(u *unstructured.Unstructured, key string, ctx TransformContext) error {
+	if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+		return fmt.Errorf("invalid data key %q: %s", key, strings.Join(errs, ", "))
+	}
+	return setNestedField(u.Object, []string{"data", key}, ctx.TargetNamespace)
+}
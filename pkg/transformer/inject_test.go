@@ -0,0 +1,110 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestTransformer_InjectNamespaceKey(t *testing.T) {
+	ctx := TransformContext{TargetNamespace: "team-a"}
+	meta := func(annotations map[string]string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: "app", Namespace: "default", Annotations: annotations}
+	}
+
+	tests := []struct {
+		name     string
+		source   runtime.Object
+		validate func(t *testing.T, result runtime.Object)
+		wantErr  bool
+	}{
+		{
+			name: "configmap",
+			source: &corev1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: meta(map[string]string{constants.AnnotationInjectNamespaceKey: "NAMESPACE"}),
+				Data:       map[string]string{"OTHER": "kept"},
+			},
+			validate: func(t *testing.T, result runtime.Object) {
+				data, _, _ := unstructured.NestedStringMap(result.(*unstructured.Unstructured).Object, "data")
+				assert.Equal(t, map[string]string{"NAMESPACE": "team-a", "OTHER": "kept"}, data)
+			},
+		},
+		{
+			name: "secret value is base64 encoded",
+			source: &corev1.Secret{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+				ObjectMeta: meta(map[string]string{constants.AnnotationInjectNamespaceKey: "NAMESPACE"}),
+			},
+			validate: func(t *testing.T, result runtime.Object) {
+				value, _, _ := unstructured.NestedString(result.(*unstructured.Unstructured).Object, "data", "NAMESPACE")
+				assert.Equal(t, base64Encode("team-a"), value)
+			},
+		},
+		{
+			name: "rules can override injected key",
+			source: &corev1.ConfigMap{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: meta(map[string]string{
+					constants.AnnotationInjectNamespaceKey: "NAMESPACE",
+					constants.AnnotationTransform:          "rules:\n  - path: data.NAMESPACE\n    template: '{{ upper .TargetNamespace }}'\n",
+				}),
+			},
+			validate: func(t *testing.T, result runtime.Object) {
+				value, _, _ := unstructured.NestedString(result.(*unstructured.Unstructured).Object, "data", "NAMESPACE")
+				assert.Equal(t, "TEAM-A", value)
+			},
+		},
+		{
+			name: "invalid key is skipped in non-strict mode",
+			source: &corev1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: meta(map[string]string{constants.AnnotationInjectNamespaceKey: "bad key"}),
+			},
+			validate: func(t *testing.T, result runtime.Object) {
+				_, found, _ := unstructured.NestedString(result.(*unstructured.Unstructured).Object, "data", "bad key")
+				assert.False(t, found)
+			},
+		},
+		{
+			name: "invalid key fails in strict mode",
+			source: &corev1.ConfigMap{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: meta(map[string]string{
+					constants.AnnotationInjectNamespaceKey: "bad key",
+					constants.AnnotationTransformStrict:    "true",
+				}),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewDefaultTransformer().Transform(tt.source, ctx)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tt.validate(t, result)
+		})
+	}
+}
+
+func TestTransformer_InjectNamespaceKeyIgnoredForOtherKinds(t *testing.T) {
+	source := &unstructured.Unstructured{}
+	source.SetAPIVersion("networking.k8s.io/v1")
+	source.SetKind("Ingress")
+	source.SetAnnotations(map[string]string{constants.AnnotationInjectNamespaceKey: "NAMESPACE"})
+
+	result, err := NewDefaultTransformer().Transform(source, TransformContext{TargetNamespace: "team-a"})
+	require.NoError(t, err)
+	assert.Same(t, source, result)
+}
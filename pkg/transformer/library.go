@@ -0,0 +1,66 @@
+package transformer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RuleLibraryRef names a rule library ConfigMap.
+type RuleLibraryRef struct {
+	Namespace string
+	Name      string
+}
+
+// String returns the reference as "namespace/name".
+func (r RuleLibraryRef) String() string {
+	return r.Namespace + "/" + r.Name
+}
+
+// ParseRuleLibraryRefs parses the transform-ref annotation: a comma-separated list
+// of "namespace/name" references, where a bare name refers to a library in
+// sourceNamespace.
+func ParseRuleLibraryRefs(annotation, sourceNamespace string) ([]RuleLibraryRef, error) {
+	var refs []RuleLibraryRef
+	for _, entry := range strings.Split(annotation, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		ref := RuleLibraryRef{Namespace: sourceNamespace, Name: entry}
+		if namespace, name, found := strings.Cut(entry, "/"); found {
+			ref = RuleLibraryRef{Namespace: namespace, Name: name}
+		}
+		if ref.Namespace == "" || ref.Name == "" || strings.Contains(ref.Name, "/") {
+			return nil, fmt.Errorf("invalid rule library reference %q (expected namespace/name or name)", entry)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// libraryRules returns the rules of the libraries the transform-ref annotation
+// references, in the order they are listed.
+func (t *Transformer) libraryRules(annotation, sourceNamespace string) (*TransformRules, error) {
+	refs, err := ParseRuleLibraryRefs(annotation, sourceNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &TransformRules{}
+	for _, ref := range refs {
+		if t.options.RuleLibrary == nil {
+			return nil, fmt.Errorf("rule library %s referenced, but rule libraries are not enabled", ref)
+		}
+
+		readCtx, cancel := context.WithTimeout(context.Background(), t.options.TemplateTimeout)
+		library, err := t.options.RuleLibrary(readCtx, ref.Namespace, ref.Name)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("rule library %s: %w", ref, err)
+		}
+		// Libraries are shared between sources, so never append to their rules in place
+		rules.Rules = append(rules.Rules, library.Rules...)
+	}
+	return rules, nil
+}
@@ -0,0 +1,137 @@
+package transformer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseRuleLibraryRefs(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		want       []RuleLibraryRef
+		wantErr    bool
+	}{
+		{name: "empty", annotation: "", want: nil},
+		{name: "namespaced", annotation: "kubemirror-system/standard-prod-rules", want: []RuleLibraryRef{{Namespace: "kubemirror-system", Name: "standard-prod-rules"}}},
+		{name: "bare name in the source namespace", annotation: "team-rules", want: []RuleLibraryRef{{Namespace: "default", Name: "team-rules"}}},
+		{
+			name:       "list",
+			annotation: " kubemirror-system/base , team-rules,",
+			want:       []RuleLibraryRef{{Namespace: "kubemirror-system", Name: "base"}, {Namespace: "default", Name: "team-rules"}},
+		},
+		{name: "missing namespace", annotation: "/rules", wantErr: true},
+		{name: "missing name", annotation: "kubemirror-system/", wantErr: true},
+		{name: "too many parts", annotation: "a/b/c", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs, err := ParseRuleLibraryRefs(tt.annotation, "default")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, refs)
+		})
+	}
+}
+
+func TestTransformer_RuleLibrary(t *testing.T) {
+	libraries := map[RuleLibraryRef]*TransformRules{
+		{Namespace: "kubemirror-system", Name: "standard-prod-rules"}: {Rules: []Rule{
+			{Path: "data.LOG_LEVEL", Value: stringPtr("warn")},
+			{Path: "data.ENV", Template: stringPtr("{{ .TargetNamespace }}")},
+		}},
+		{Namespace: "default", Name: "team-rules"}: {Rules: []Rule{
+			{Path: "data.TEAM", Value: stringPtr("payments")},
+		}},
+	}
+	opts := DefaultTransformOptions()
+	opts.RuleLibrary = func(_ context.Context, namespace, name string) (*TransformRules, error) {
+		rules, found := libraries[RuleLibraryRef{Namespace: namespace, Name: name}]
+		if !found {
+			return nil, fmt.Errorf("ConfigMap not found")
+		}
+		return rules, nil
+	}
+
+	transform := func(t *testing.T, annotations map[string]string) (*unstructured.Unstructured, error) {
+		source := newNamespacedObject("v1", "ConfigMap", "", map[string]interface{}{
+			"data": map[string]interface{}{"LOG_LEVEL": "debug"},
+		})
+		source.SetAnnotations(annotations)
+		result, err := NewTransformer(opts).Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "prod-eu"})
+		if err != nil {
+			return nil, err
+		}
+		return result.(*unstructured.Unstructured), nil
+	}
+
+	t.Run("library rules run before the source's own", func(t *testing.T) {
+		result, err := transform(t, map[string]string{
+			constants.AnnotationTransformRef: "kubemirror-system/standard-prod-rules, team-rules",
+			constants.AnnotationTransform: `rules:
+  - path: data.LOG_LEVEL
+    value: info
+`,
+		})
+		require.NoError(t, err)
+		data, _, _ := unstructured.NestedStringMap(result.Object, "data")
+		assert.Equal(t, map[string]string{"LOG_LEVEL": "info", "ENV": "prod-eu", "TEAM": "payments"}, data)
+	})
+
+	t.Run("library without own rules", func(t *testing.T) {
+		result, err := transform(t, map[string]string{constants.AnnotationTransformRef: "kubemirror-system/standard-prod-rules"})
+		require.NoError(t, err)
+		data, _, _ := unstructured.NestedStringMap(result.Object, "data")
+		assert.Equal(t, map[string]string{"LOG_LEVEL": "warn", "ENV": "prod-eu"}, data)
+	})
+
+	t.Run("missing library fails in strict mode", func(t *testing.T) {
+		_, err := transform(t, map[string]string{
+			constants.AnnotationTransformRef:    "kubemirror-system/missing",
+			constants.AnnotationTransformStrict: "true",
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "rule library kubemirror-system/missing: ConfigMap not found")
+	})
+
+	t.Run("missing library drops the rules otherwise", func(t *testing.T) {
+		result, err := transform(t, map[string]string{
+			constants.AnnotationTransformRef: "kubemirror-system/missing",
+			constants.AnnotationTransform: `rules:
+  - path: data.LOG_LEVEL
+    value: info
+`,
+		})
+		require.NoError(t, err)
+		data, _, _ := unstructured.NestedStringMap(result.Object, "data")
+		assert.Equal(t, map[string]string{"LOG_LEVEL": "debug"}, data)
+	})
+
+	t.Run("library rules are not modified", func(t *testing.T) {
+		_, err := transform(t, map[string]string{constants.AnnotationTransformRef: "team-rules, kubemirror-system/standard-prod-rules"})
+		require.NoError(t, err)
+		assert.Len(t, libraries[RuleLibraryRef{Namespace: "default", Name: "team-rules"}].Rules, 1)
+	})
+}
+
+func TestTransformer_RuleLibraryDisabled(t *testing.T) {
+	source := newNamespacedObject("v1", "ConfigMap", "", map[string]interface{}{"data": map[string]interface{}{}})
+	source.SetAnnotations(map[string]string{
+		constants.AnnotationTransformRef:    "kubemirror-system/standard-prod-rules",
+		constants.AnnotationTransformStrict: "true",
+	})
+
+	_, err := NewDefaultTransformer().Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "prod-eu"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rule libraries are not enabled")
+}
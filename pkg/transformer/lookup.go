@@ -0,0 +1,52 @@
+package transformer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+// lookupResources lists the resource types the lookup template function may read.
+// Only ConfigMaps are allowed so templates can never copy Secret data across namespaces.
+var lookupResources = map[string]bool{
+	"v1/ConfigMap": true,
+}
+
+// lookup returns the lookup template function:
+//
+//	{{ lookup "v1" "ConfigMap" .TargetNamespace "mirror-settings" "domain" }}
+//
+// It returns the value of key in the referenced resource, or an empty string when
+// the resource or key does not exist. Reads are read-only, limited to lookupResources
+// and to the namespace/name patterns in TransformOptions.LookupAllow.
+func (t *Transformer) lookup(ctx context.Context) func(apiVersion, kind, namespace, name, key string) (string, error) {
+	return func(apiVersion, kind, namespace, name, key string) (string, error) {
+		if t.options.Lookup == nil {
+			return "", fmt.Errorf("lookup is not enabled")
+		}
+		if !lookupResources[apiVersion+"/"+kind] {
+			return "", fmt.Errorf("lookup of %s %s is not supported", apiVersion, kind)
+		}
+		if !t.lookupAllowed(namespace, name) {
+			return "", fmt.Errorf("lookup of %s/%s is not allowed", namespace, name)
+		}
+
+		data, err := t.options.Lookup(ctx, apiVersion, kind, namespace, name)
+		if err != nil {
+			return "", fmt.Errorf("lookup of %s/%s failed: %w", namespace, name, err)
+		}
+		return data[key], nil
+	}
+}
+
+// lookupAllowed checks namespace/name against the LookupAllow patterns.
+func (t *Transformer) lookupAllowed(namespace, name string) bool {
+	ref := namespace + "/" + name
+	for _, pattern := range t.options.LookupAllow {
+		if filter.MatchGlob(pattern, ref) {
+			return true
+		}
+	}
+	return false
+}
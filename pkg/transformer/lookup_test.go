@@ -0,0 +1,124 @@
+package transformer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTransformer_Lookup(t *testing.T) {
+	settings := map[string]map[string]string{
+		"prod/mirror-settings": {"domain": "prod.example.com"},
+	}
+	lookup := func(_ context.Context, apiVersion, kind, namespace, name string) (map[string]string, error) {
+		if namespace == "broken" {
+			return nil, fmt.Errorf("connection refused")
+		}
+		return settings[namespace+"/"+name], nil
+	}
+
+	tests := []struct {
+		name      string
+		template  string
+		namespace string
+		want      string
+		allow     []string
+		lookup    LookupFunc
+		wantErr   bool
+	}{
+		{
+			name:      "reads key from allowed configmap",
+			template:  `{{ lookup "v1" "ConfigMap" .TargetNamespace "mirror-settings" "domain" }}`,
+			namespace: "prod",
+			allow:     []string{"*/mirror-settings"},
+			lookup:    lookup,
+			want:      "prod.example.com",
+		},
+		{
+			name:      "missing configmap yields empty string for default",
+			template:  `{{ lookup "v1" "ConfigMap" .TargetNamespace "mirror-settings" "domain" | default "fallback.example.com" }}`,
+			namespace: "dev",
+			allow:     []string{"*/mirror-settings"},
+			lookup:    lookup,
+			want:      "fallback.example.com",
+		},
+		{
+			name:      "missing key yields empty string",
+			template:  `x{{ lookup "v1" "ConfigMap" .TargetNamespace "mirror-settings" "missing" }}`,
+			namespace: "prod",
+			allow:     []string{"*/mirror-settings"},
+			lookup:    lookup,
+			want:      "x",
+		},
+		{
+			name:      "not in allow-list",
+			template:  `{{ lookup "v1" "ConfigMap" .TargetNamespace "other" "domain" }}`,
+			namespace: "prod",
+			allow:     []string{"*/mirror-settings"},
+			lookup:    lookup,
+			wantErr:   true,
+		},
+		{
+			name:      "secrets are never readable",
+			template:  `{{ lookup "v1" "Secret" .TargetNamespace "mirror-settings" "domain" }}`,
+			namespace: "prod",
+			allow:     []string{"*"},
+			lookup:    lookup,
+			wantErr:   true,
+		},
+		{
+			name:      "disabled without lookup func",
+			template:  `{{ lookup "v1" "ConfigMap" .TargetNamespace "mirror-settings" "domain" }}`,
+			namespace: "prod",
+			allow:     []string{"*/mirror-settings"},
+			wantErr:   true,
+		},
+		{
+			name:      "lookup error",
+			template:  `{{ lookup "v1" "ConfigMap" .TargetNamespace "mirror-settings" "domain" }}`,
+			namespace: "broken",
+			allow:     []string{"*/mirror-settings"},
+			lookup:    lookup,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultTransformOptions()
+			opts.Strict = true
+			opts.Lookup = tt.lookup
+			opts.LookupAllow = tt.allow
+
+			source := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "app-config",
+					Namespace: "default",
+					Annotations: map[string]string{
+						constants.AnnotationTransform:       "rules:\n  - path: data.DOMAIN\n    template: '" + tt.template + "'\n",
+						constants.AnnotationTransformStrict: "true",
+					},
+				},
+			}
+
+			result, err := NewTransformer(opts).Transform(source, TransformContext{TargetNamespace: tt.namespace})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			value, found, err := unstructured.NestedString(result.(*unstructured.Unstructured).Object, "data", "DOMAIN")
+			require.NoError(t, err)
+			assert.True(t, found)
+			assert.Equal(t, tt.want, value)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package transformer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// builtinNamespaceRefFields lists, by group and kind, the fields of well-known
+// resources that reference objects in another namespace. "[*]" walks every
+// element of a list.
+var builtinNamespaceRefFields = map[schema.GroupKind][]string{
+	{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}: {"subjects[*].namespace"},
+	{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute"}: {
+		"spec.parentRefs[*].namespace",
+		"spec.rules[*].backendRefs[*].namespace",
+	},
+	{Group: "gateway.networking.k8s.io", Kind: "GRPCRoute"}: {
+		"spec.parentRefs[*].namespace",
+		"spec.rules[*].backendRefs[*].namespace",
+	},
+	{Group: "gateway.networking.k8s.io", Kind: "Gateway"}:         {"spec.listeners[*].tls.certificateRefs[*].namespace"},
+	{Group: "traefik.io", Kind: "IngressRoute"}:                   traefikIngressRouteRefs,
+	{Group: "traefik.containo.us", Kind: "IngressRoute"}:          traefikIngressRouteRefs,
+	{Group: "traefik.io", Kind: "Middleware"}:                     traefikMiddlewareRefs,
+	{Group: "traefik.containo.us", Kind: "Middleware"}:            traefikMiddlewareRefs,
+	{Group: "kustomize.toolkit.fluxcd.io", Kind: "Kustomization"}: {"spec.sourceRef.namespace"},
+	{Group: "helm.toolkit.fluxcd.io", Kind: "HelmRelease"}:        {"spec.chart.spec.sourceRef.namespace"},
+}
+
+var traefikIngressRouteRefs = []string{
+	"spec.routes[*].services[*].namespace",
+	"spec.routes[*].middlewares[*].namespace",
+	"spec.tls.options.namespace",
+	"spec.tls.store.namespace",
+}
+
+var traefikMiddlewareRefs = []string{
+	"spec.chain.middlewares[*].namespace",
+	"spec.errors.service.namespace",
+}
+
+// NamespaceRefFields holds the namespace-bearing fields rewriteNamespaceRefs rules
+// rewrite, keyed by resource type in the --resource-types format. Configured
+// fields replace the built-in ones of the same type:
+//
+//	Certificate.v1.cert-manager.io:
+//	  - spec.issuerRef.namespace
+type NamespaceRefFields struct {
+	byType map[string][]string
+}
+
+// NewNamespaceRefFields validates namespace-bearing fields by resource type key.
+func NewNamespaceRefFields(byType map[string][]string) (*NamespaceRefFields, error) {
+	for key, fields := range byType {
+		if len(strings.Split(key, ".")) < 2 {
+			return nil, fmt.Errorf("invalid resource type %q in namespace reference fields (expected kind.version or kind.version.group)", key)
+		}
+		for _, field := range fields {
+			if _, err := parseFieldPath(field); err != nil {
+				return nil, fmt.Errorf("namespace reference fields for %s: %w", key, err)
+			}
+		}
+	}
+	return &NamespaceRefFields{byType: byType}, nil
+}
+
+// For returns the namespace-bearing fields of gvk: the configured ones, or the
+// built-in ones when none are configured.
+func (f *NamespaceRefFields) For(gvk schema.GroupVersionKind) []string {
+	if f != nil {
+		key := fmt.Sprintf("%s.%s", gvk.Kind, gvk.Version)
+		if gvk.Group != "" {
+			key += "." + gvk.Group
+		}
+		if fields, ok := f.byType[key]; ok {
+			return fields
+		}
+	}
+	return builtinNamespaceRefFields[gvk.GroupKind()]
+}
+
+// refSegment is one step of a field path: a map key, optionally
+// followed by a list index or "[*]" (index -1).
+type refSegment struct {
+	key   string
+	index int
+	list  bool
+}
+
+// parseFieldPath parses "spec.routes[*].services[*].namespace" into segments.
+func parseFieldPath(path string) ([]refSegment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty field path")
+	}
+	parts := strings.Split(path, ".")
+	segments := make([]refSegment, 0, len(parts))
+	for _, part := range parts {
+		segment := refSegment{key: part}
+		if open := strings.Index(part, "["); open >= 0 {
+			if !strings.HasSuffix(part, "]") {
+				return nil, fmt.Errorf("invalid field path %q", path)
+			}
+			segment.key, segment.list, segment.index = part[:open], true, -1
+			if index := part[open+1 : len(part)-1]; index != "*" {
+				n, err := strconv.Atoi(index)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid index in field path %q", path)
+				}
+				segment.index = n
+			}
+		}
+		if segment.key == "" {
+			return nil, fmt.Errorf("invalid field path %q", path)
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// applyRewriteNamespaceRefsRule rewrites namespace references to the source
+// namespace into references to the target namespace, in the rule's path or, when
+// it has none, in the known namespace-bearing fields of the resource type.
+// References to other namespaces are left alone.
+func (t *Transformer) applyRewriteNamespaceRefsRule(u *unstructured.Unstructured, rule Rule, ctx TransformContext) error {
+	fields := t.options.NamespaceRefFields.For(u.GroupVersionKind())
+	if rule.Path != "" {
+		fields = []string{rule.Path}
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("no known namespace reference fields for %s, set a path", u.GroupVersionKind().GroupKind())
+	}
+	if ctx.SourceNamespace == "" || ctx.SourceNamespace == ctx.TargetNamespace {
+		return nil
+	}
+
+	for _, field := range fields {
+		segments, err := parseFieldPath(field)
+		if err != nil {
+			return err
+		}
+		rewriteRefs(u.Object, segments, ctx.SourceNamespace, ctx.TargetNamespace)
+	}
+	return nil
+}
+
+// rewriteRefs replaces from with to in the string fields segments lead to.
+// Missing fields and values of other types are skipped.
+func rewriteRefs(obj map[string]interface{}, segments []refSegment, from, to string) {
+	segment := segments[0]
+	value, found := obj[segment.key]
+	if !found {
+		return
+	}
+
+	if !segment.list {
+		if len(segments) == 1 {
+			if s, ok := value.(string); ok && s == from {
+				obj[segment.key] = to
+			}
+			return
+		}
+		if child, ok := value.(map[string]interface{}); ok {
+			rewriteRefs(child, segments[1:], from, to)
+		}
+		return
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return
+	}
+	for i, item := range items {
+		if segment.index >= 0 && i != segment.index {
+			continue
+		}
+		if len(segments) == 1 {
+			if s, ok := item.(string); ok && s == from {
+				items[i] = to
+			}
+			continue
+		}
+		if child, ok := item.(map[string]interface{}); ok {
+			rewriteRefs(child, segments[1:], from, to)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const rewriteNamespaceRefsRules = "rules:\n  - rewriteNamespaceRefs: true\n"
+
+func newNamespacedObject(apiVersion, kind, rules string, fields map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: fields}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetName("app")
+	u.SetNamespace("default")
+	u.SetAnnotations(map[string]string{constants.AnnotationTransform: rules})
+	return u
+}
+
+func TestTransformer_RewriteNamespaceRefsRoleBinding(t *testing.T) {
+	source := newNamespacedObject("rbac.authorization.k8s.io/v1", "RoleBinding", rewriteNamespaceRefsRules, map[string]interface{}{
+		"subjects": []interface{}{
+			map[string]interface{}{"kind": "ServiceAccount", "name": "app", "namespace": "default"},
+			map[string]interface{}{"kind": "ServiceAccount", "name": "monitor", "namespace": "monitoring"},
+			map[string]interface{}{"kind": "Group", "name": "developers"},
+		},
+	})
+
+	result, err := NewDefaultTransformer().Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "team-a"})
+	require.NoError(t, err)
+
+	subjects, _, _ := unstructured.NestedSlice(result.(*unstructured.Unstructured).Object, "subjects")
+	assert.Equal(t, "team-a", subjects[0].(map[string]interface{})["namespace"])
+	assert.Equal(t, "monitoring", subjects[1].(map[string]interface{})["namespace"], "other namespaces are kept")
+	assert.NotContains(t, subjects[2].(map[string]interface{}), "namespace")
+}
+
+func TestTransformer_RewriteNamespaceRefsIngressRoute(t *testing.T) {
+	source := newNamespacedObject("traefik.io/v1alpha1", "IngressRoute", rewriteNamespaceRefsRules, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"routes": []interface{}{
+				map[string]interface{}{
+					"services": []interface{}{
+						map[string]interface{}{"name": "app", "namespace": "default"},
+					},
+					"middlewares": []interface{}{
+						map[string]interface{}{"name": "auth", "namespace": "default"},
+						map[string]interface{}{"name": "compress", "namespace": "traefik"},
+					},
+				},
+			},
+		},
+	})
+
+	result, err := NewDefaultTransformer().Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "team-a"})
+	require.NoError(t, err)
+
+	routes, _, _ := unstructured.NestedSlice(result.(*unstructured.Unstructured).Object, "spec", "routes")
+	route := routes[0].(map[string]interface{})
+	assert.Equal(t, "team-a", route["services"].([]interface{})[0].(map[string]interface{})["namespace"])
+	middlewares := route["middlewares"].([]interface{})
+	assert.Equal(t, "team-a", middlewares[0].(map[string]interface{})["namespace"])
+	assert.Equal(t, "traefik", middlewares[1].(map[string]interface{})["namespace"])
+}
+
+func TestTransformer_RewriteNamespaceRefsPath(t *testing.T) {
+	rules := "rules:\n  - path: spec.targets[1].namespace\n    rewriteNamespaceRefs: true\n"
+	source := newNamespacedObject("example.com/v1", "Widget", rules, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"targets": []interface{}{
+				map[string]interface{}{"namespace": "default"},
+				map[string]interface{}{"namespace": "default"},
+			},
+		},
+	})
+
+	result, err := NewDefaultTransformer().Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "team-a"})
+	require.NoError(t, err)
+
+	targets, _, _ := unstructured.NestedSlice(result.(*unstructured.Unstructured).Object, "spec", "targets")
+	assert.Equal(t, "default", targets[0].(map[string]interface{})["namespace"], "only the indexed element is rewritten")
+	assert.Equal(t, "team-a", targets[1].(map[string]interface{})["namespace"])
+}
+
+func TestTransformer_RewriteNamespaceRefsConfiguredFields(t *testing.T) {
+	fields, err := NewNamespaceRefFields(map[string][]string{
+		"Certificate.v1.cert-manager.io": {"spec.issuerRef.namespace"},
+	})
+	require.NoError(t, err)
+
+	source := newNamespacedObject("cert-manager.io/v1", "Certificate", rewriteNamespaceRefsRules, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"issuerRef": map[string]interface{}{"name": "ca", "namespace": "default"},
+		},
+	})
+
+	options := DefaultTransformOptions()
+	options.NamespaceRefFields = fields
+	result, err := NewTransformer(options).Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "team-a"})
+	require.NoError(t, err)
+
+	namespace, _, _ := unstructured.NestedString(result.(*unstructured.Unstructured).Object, "spec", "issuerRef", "namespace")
+	assert.Equal(t, "team-a", namespace)
+}
+
+func TestTransformer_RewriteNamespaceRefsUnknownType(t *testing.T) {
+	source := newNamespacedObject("example.com/v1", "Widget", rewriteNamespaceRefsRules, map[string]interface{}{
+		"spec": map[string]interface{}{"namespace": "default"},
+	})
+
+	options := DefaultTransformOptions()
+	options.Strict = true
+	_, err := NewTransformer(options).Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "team-a"})
+	assert.ErrorContains(t, err, "no known namespace reference fields")
+}
+
+func TestNamespaceRefFields_For(t *testing.T) {
+	fields, err := NewNamespaceRefFields(map[string][]string{"RoleBinding.v1.rbac.authorization.k8s.io": {"subjects[0].namespace"}})
+	require.NoError(t, err)
+
+	roleBinding := newNamespacedObject("rbac.authorization.k8s.io/v1", "RoleBinding", "", nil).GroupVersionKind()
+	assert.Equal(t, []string{"subjects[0].namespace"}, fields.For(roleBinding), "configured fields replace the built-in ones")
+
+	var builtin *NamespaceRefFields
+	assert.Equal(t, []string{"subjects[*].namespace"}, builtin.For(roleBinding))
+}
+
+func TestNewNamespaceRefFields_Invalid(t *testing.T) {
+	tests := map[string]map[string][]string{
+		"bad resource type": {"Certificate": {"spec.issuerRef.namespace"}},
+		"bad index":         {"Certificate.v1.cert-manager.io": {"spec.refs[x].namespace"}},
+		"unclosed index":    {"Certificate.v1.cert-manager.io": {"spec.refs[0.namespace"}},
+		"empty segment":     {"Certificate.v1.cert-manager.io": {"spec..namespace"}},
+	}
+	for name, byType := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewNamespaceRefFields(byType)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package transformer
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PruneFields holds the fields stripped from mirrors, keyed by resource type in
+// the --resource-types format, e.g. values assigned by the cluster that must not
+// be duplicated into another namespace:
+//
+//	Service.v1:
+//	  - spec.clusterIP
+//	  - spec.ports[*].nodePort
+type PruneFields struct {
+	byType map[string][]string
+}
+
+// NewPruneFields validates pruned fields by resource type key. Object identity
+// (apiVersion, kind, metadata) cannot be pruned, nor single list elements, whose
+// removal would shift the others.
+func NewPruneFields(byType map[string][]string) (*PruneFields, error) {
+	for key, fields := range byType {
+		if len(strings.Split(key, ".")) < 2 {
+			return nil, fmt.Errorf("invalid resource type %q in pruned fields (expected kind.version or kind.version.group)", key)
+		}
+		for _, field := range fields {
+			segments, err := parseFieldPath(field)
+			if err != nil {
+				return nil, fmt.Errorf("pruned fields for %s: %w", key, err)
+			}
+			switch segments[0].key {
+			case "apiVersion", "kind", "metadata":
+				return nil, fmt.Errorf("pruned fields for %s: %q cannot be pruned", key, field)
+			}
+			if last := segments[len(segments)-1]; last.list && last.index >= 0 {
+				return nil, fmt.Errorf("pruned fields for %s: %q cannot end in a list index", key, field)
+			}
+		}
+	}
+	return &PruneFields{byType: byType}, nil
+}
+
+// For returns the fields pruned from mirrors of gvk.
+func (f *PruneFields) For(gvk schema.GroupVersionKind) []string {
+	if f == nil {
+		return nil
+	}
+	key := fmt.Sprintf("%s.%s", gvk.Kind, gvk.Version)
+	if gvk.Group != "" {
+		key += "." + gvk.Group
+	}
+	return f.byType[key]
+}
+
+// Prune removes fields from u. Missing fields are skipped.
+func Prune(u *unstructured.Unstructured, fields []string) error {
+	for _, field := range fields {
+		segments, err := parseFieldPath(field)
+		if err != nil {
+			return err
+		}
+		pruneField(u.Object, segments)
+	}
+	return nil
+}
+
+// pruneField deletes the fields segments lead to.
+func pruneField(obj map[string]interface{}, segments []refSegment) {
+	segment := segments[0]
+	value, found := obj[segment.key]
+	if !found {
+		return
+	}
+
+	if len(segments) == 1 {
+		delete(obj, segment.key)
+		return
+	}
+	if !segment.list {
+		if child, ok := value.(map[string]interface{}); ok {
+			pruneField(child, segments[1:])
+		}
+		return
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return
+	}
+	for i, item := range items {
+		if segment.index >= 0 && i != segment.index {
+			continue
+		}
+		if child, ok := item.(map[string]interface{}); ok {
+			pruneField(child, segments[1:])
+		}
+	}
+}
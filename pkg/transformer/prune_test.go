@@ -0,0 +1,67 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestNewPruneFields(t *testing.T) {
+	fields, err := NewPruneFields(map[string][]string{
+		"Service.v1":                 {"spec.clusterIP", "spec.ports[*].nodePort"},
+		"Gateway.v1.example.com":     {"spec.addresses[*]"},
+		"LoadBalancer.v1.lb.example": {"spec.ports[0].assigned"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"spec.clusterIP", "spec.ports[*].nodePort"}, fields.For(schema.GroupVersionKind{Version: "v1", Kind: "Service"}))
+	assert.Equal(t, []string{"spec.addresses[*]"}, fields.For(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Gateway"}))
+	assert.Empty(t, fields.For(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}))
+	assert.Empty(t, (*PruneFields)(nil).For(schema.GroupVersionKind{Version: "v1", Kind: "Service"}))
+
+	invalid := map[string]map[string][]string{
+		"resource type":  {"Service": {"spec.clusterIP"}},
+		"empty path":     {"Service.v1": {""}},
+		"unclosed index": {"Service.v1": {"spec.ports[*.nodePort"}},
+		"metadata":       {"Service.v1": {"metadata.labels"}},
+		"kind":           {"Service.v1": {"kind"}},
+		"list element":   {"Service.v1": {"spec.ports[1]"}},
+	}
+	for name, byType := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewPruneFields(byType)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"spec": map[string]interface{}{
+			"clusterIP":  "10.0.0.1",
+			"clusterIPs": []interface{}{"10.0.0.1"},
+			"type":       "NodePort",
+			"ports": []interface{}{
+				map[string]interface{}{"port": int64(80), "nodePort": int64(30080)},
+				map[string]interface{}{"port": int64(443), "nodePort": int64(30443)},
+				"unexpected",
+			},
+		},
+	}}
+
+	require.NoError(t, Prune(u, []string{"spec.clusterIP", "spec.clusterIPs[*]", "spec.ports[*].nodePort", "spec.missing.field"}))
+	assert.Equal(t, map[string]interface{}{
+		"type": "NodePort",
+		"ports": []interface{}{
+			map[string]interface{}{"port": int64(80)},
+			map[string]interface{}{"port": int64(443)},
+			"unexpected",
+		},
+	}, u.Object["spec"])
+
+	assert.Error(t, Prune(u, []string{"spec..port"}))
+}
@@ -0,0 +1,138 @@
+package transformer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+// RegexRule rewrites the parts of string values matching a pattern, e.g.
+//
+//	path: data
+//	regex:
+//	  pattern: '\.default\.svc'
+//	  replacement: '.{{ .TargetNamespace }}.svc'
+type RegexRule struct {
+	// Pattern is an RE2 regular expression
+	Pattern string `yaml:"pattern"`
+	// Replacement replaces every match. It is rendered as a template first, then
+	// $1 or ${name} in it expand to the groups of the match
+	Replacement string `yaml:"replacement"`
+}
+
+// validate checks the pattern is a valid regular expression.
+func (r *RegexRule) validate() error {
+	if r.Pattern == "" {
+		return fmt.Errorf("regex rule pattern cannot be empty")
+	}
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return fmt.Errorf("invalid regex pattern %q: %w", r.Pattern, err)
+	}
+	return nil
+}
+
+// applyRegexRule replaces the matches of the rule's pattern in the string at its
+// path or, when the path leads to a map or list, in every string within it. Secret
+// data, and any field of a decodeBase64 rule, is matched decoded; strings that are
+// not valid base64 are skipped like missing fields and values of other types.
+func (t *Transformer) applyRegexRule(u *unstructured.Unstructured, rule Rule, ctx TransformContext) error {
+	if rule.Regex == nil {
+		return fmt.Errorf("regex rule has nil regex")
+	}
+	re, err := regexp.Compile(rule.Regex.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid regex pattern %q: %w", rule.Regex.Pattern, err)
+	}
+	segments, err := parseFieldPath(rule.Path)
+	if err != nil {
+		return err
+	}
+	replacement, err := t.renderTemplate(rule.Regex.Replacement, ctx, ctx, rule.Path)
+	if err != nil {
+		return err
+	}
+
+	replace := func(s string) string { return re.ReplaceAllString(s, replacement) }
+	if rule.DecodeBase64 || isSecretDataField(u.Object, []string{segments[0].key}) {
+		replace = func(s string) string {
+			decoded, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return s
+			}
+			return base64Encode(re.ReplaceAllString(string(decoded), replacement))
+		}
+	}
+	replaceAt(u.Object, segments, replace)
+	return nil
+}
+
+// replaceAt applies replace to the strings within the fields segments lead to.
+// A key with * or ? matches every map key it globs.
+func replaceAt(obj map[string]interface{}, segments []refSegment, replace func(string) string) {
+	segment := segments[0]
+	if isWildcardSegment(segment.key) {
+		for key := range obj {
+			if filter.MatchGlob(segment.key, key) {
+				matched := segment
+				matched.key = key
+				replaceAt(obj, append([]refSegment{matched}, segments[1:]...), replace)
+			}
+		}
+		return
+	}
+
+	value, found := obj[segment.key]
+	if !found {
+		return
+	}
+
+	if !segment.list {
+		if len(segments) == 1 {
+			obj[segment.key] = replaceStrings(value, replace)
+			return
+		}
+		if child, ok := value.(map[string]interface{}); ok {
+			replaceAt(child, segments[1:], replace)
+		}
+		return
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return
+	}
+	for i, item := range items {
+		if segment.index >= 0 && i != segment.index {
+			continue
+		}
+		if len(segments) == 1 {
+			items[i] = replaceStrings(item, replace)
+			continue
+		}
+		if child, ok := item.(map[string]interface{}); ok {
+			replaceAt(child, segments[1:], replace)
+		}
+	}
+}
+
+// replaceStrings applies replace to value if it is a string, or to every string
+// within it if it is a map or list.
+func replaceStrings(value interface{}, replace func(string) string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return replace(v)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = replaceStrings(item, replace)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = replaceStrings(item, replace)
+		}
+	}
+	return value
+}
@@ -0,0 +1,107 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTransformer_RegexRule(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		data  map[string]interface{}
+		want  map[string]interface{}
+	}{
+		{
+			name: "every value of a map",
+			rules: `rules:
+  - path: data
+    regex:
+      pattern: '\.default\.svc\b'
+      replacement: '.{{ .TargetNamespace }}.svc'
+`,
+			data: map[string]interface{}{
+				"API_URL":   "http://api.default.svc:8080/v1",
+				"CACHE_URL": "redis://cache.default.svc.cluster.local:6379",
+				"OTHER":     "http://api.monitoring.svc",
+			},
+			want: map[string]interface{}{
+				"API_URL":   "http://api.team-a.svc:8080/v1",
+				"CACHE_URL": "redis://cache.team-a.svc.cluster.local:6379",
+				"OTHER":     "http://api.monitoring.svc",
+			},
+		},
+		{
+			name: "groups in the replacement",
+			rules: `rules:
+  - path: data.DATABASE_URL
+    regex:
+      pattern: '^postgres://([^@]+)@[^/]+/'
+      replacement: 'postgres://${1}@db.{{ .TargetNamespace }}.svc/'
+`,
+			data: map[string]interface{}{"DATABASE_URL": "postgres://app@db.default.svc/app", "KEEP": "db.default.svc"},
+			want: map[string]interface{}{"DATABASE_URL": "postgres://app@db.team-a.svc/app", "KEEP": "db.default.svc"},
+		},
+		{
+			name: "list elements",
+			rules: `rules:
+  - path: data.hosts[*]
+    regex:
+      pattern: '^default-'
+      replacement: '{{ .TargetNamespace }}-'
+`,
+			data: map[string]interface{}{"hosts": []interface{}{"default-a", "other-b", int64(3)}},
+			want: map[string]interface{}{"hosts": []interface{}{"team-a-a", "other-b", int64(3)}},
+		},
+		{
+			name: "missing field",
+			rules: `rules:
+  - path: data.MISSING
+    regex:
+      pattern: 'x'
+      replacement: 'y'
+`,
+			data: map[string]interface{}{"KEY": "x"},
+			want: map[string]interface{}{"KEY": "x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newNamespacedObject("v1", "ConfigMap", tt.rules, map[string]interface{}{"data": tt.data})
+			annotations := source.GetAnnotations()
+			annotations[constants.AnnotationTransformStrict] = "true"
+			source.SetAnnotations(annotations)
+
+			result, err := NewDefaultTransformer().Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "team-a"})
+			require.NoError(t, err)
+			data, _, _ := unstructured.NestedFieldNoCopy(result.(*unstructured.Unstructured).Object, "data")
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
+
+func TestTransformer_RegexRuleSecretData(t *testing.T) {
+	rules := `rules:
+  - path: data
+    regex:
+      pattern: 'default'
+      replacement: '{{ .TargetNamespace }}'
+`
+	source := newNamespacedObject("v1", "Secret", rules, map[string]interface{}{
+		"data": map[string]interface{}{
+			"url":    base64Encode("https://vault.default.svc"),
+			"binary": "not base64!",
+		},
+	})
+
+	result, err := NewDefaultTransformer().Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "team-a"})
+	require.NoError(t, err)
+	data, _, _ := unstructured.NestedStringMap(result.(*unstructured.Unstructured).Object, "data")
+	assert.Equal(t, base64Encode("https://vault.team-a.svc"), data["url"], "matched decoded and re-encoded")
+	assert.Equal(t, "not base64!", data["binary"], "undecodable values are kept")
+}
@@ -0,0 +1,51 @@
+package transformer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// shouldRenderValues reports whether the resource is a ConfigMap whose values
+// should be rendered as templates (kubemirror.raczylo.com/render-values: "true").
+func (t *Transformer) shouldRenderValues(u *unstructured.Unstructured) bool {
+	if u.GetAPIVersion() != "v1" || u.GetKind() != "ConfigMap" {
+		return false
+	}
+	return u.GetAnnotations()[constants.AnnotationRenderValues] == "true"
+}
+
+// renderValues evaluates every ConfigMap data value as a Go template against ctx.
+// Values that fail to render are left unchanged and reported in the returned error.
+// binaryData is never rendered.
+func (t *Transformer) renderValues(u *unstructured.Unstructured, ctx TransformContext) error {
+	data, found, err := unstructured.NestedStringMap(u.Object, "data")
+	if err != nil || !found {
+		return err
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		rendered, err := t.renderTemplate(data[key], ctx, ctx, "data."+key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %s: %w", key, err))
+			continue
+		}
+		data[key] = rendered
+	}
+
+	if err := unstructured.SetNestedStringMap(u.Object, data, "data"); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,95 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func renderValuesConfigMap(annotations map[string]string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", Annotations: annotations},
+		Data:       data,
+		BinaryData: map[string][]byte{"raw": []byte("{{ .TargetNamespace }}")},
+	}
+}
+
+func TestTransformer_RenderValues(t *testing.T) {
+	ctx := TransformContext{TargetNamespace: "prod", SourceNamespace: "default", SourceName: "app"}
+
+	t.Run("renders every value per target", func(t *testing.T) {
+		source := renderValuesConfigMap(
+			map[string]string{constants.AnnotationRenderValues: "true"},
+			map[string]string{
+				"config.yaml": "namespace: {{ .TargetNamespace }}\nsource: {{ .SourceNamespace }}/{{ .SourceName }}\n",
+				"plain":       "no placeholders",
+				"upper":       "{{ upper .TargetNamespace }}",
+			},
+		)
+
+		result, err := NewDefaultTransformer().Transform(source, ctx)
+		require.NoError(t, err)
+
+		u := result.(*unstructured.Unstructured)
+		data, _, _ := unstructured.NestedStringMap(u.Object, "data")
+		assert.Equal(t, "namespace: prod\nsource: default/app\n", data["config.yaml"])
+		assert.Equal(t, "no placeholders", data["plain"])
+		assert.Equal(t, "PROD", data["upper"])
+
+		raw, _, _ := unstructured.NestedString(u.Object, "binaryData", "raw")
+		assert.Equal(t, base64Encode("{{ .TargetNamespace }}"), raw, "binaryData is never rendered")
+	})
+
+	t.Run("disabled without annotation", func(t *testing.T) {
+		source := renderValuesConfigMap(nil, map[string]string{"ns": "{{ .TargetNamespace }}"})
+
+		result, err := NewDefaultTransformer().Transform(source, ctx)
+		require.NoError(t, err)
+		assert.Same(t, source, result)
+	})
+
+	t.Run("rules run after rendering", func(t *testing.T) {
+		source := renderValuesConfigMap(map[string]string{
+			constants.AnnotationRenderValues: "true",
+			constants.AnnotationTransform:    "rules:\n  - path: data.ns\n    value: overridden\n",
+		}, map[string]string{"ns": "{{ .TargetNamespace }}", "other": "{{ .TargetNamespace }}"})
+
+		result, err := NewDefaultTransformer().Transform(source, ctx)
+		require.NoError(t, err)
+
+		data, _, _ := unstructured.NestedStringMap(result.(*unstructured.Unstructured).Object, "data")
+		assert.Equal(t, "overridden", data["ns"])
+		assert.Equal(t, "prod", data["other"])
+	})
+
+	t.Run("invalid template is kept in non-strict mode", func(t *testing.T) {
+		source := renderValuesConfigMap(
+			map[string]string{constants.AnnotationRenderValues: "true"},
+			map[string]string{"bad": "{{ .TargetNamespace", "good": "{{ .TargetNamespace }}"},
+		)
+
+		result, err := NewDefaultTransformer().Transform(source, ctx)
+		require.NoError(t, err)
+
+		data, _, _ := unstructured.NestedStringMap(result.(*unstructured.Unstructured).Object, "data")
+		assert.Equal(t, "{{ .TargetNamespace", data["bad"])
+		assert.Equal(t, "prod", data["good"])
+	})
+
+	t.Run("invalid template fails in strict mode", func(t *testing.T) {
+		source := renderValuesConfigMap(map[string]string{
+			constants.AnnotationRenderValues:    "true",
+			constants.AnnotationTransformStrict: "true",
+		}, map[string]string{"bad": "{{ .Missing.Field }}"})
+
+		_, err := NewDefaultTransformer().Transform(source, ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "key bad")
+	})
+}
@@ -0,0 +1,193 @@
+package transformer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// tickFunc is the function instrument calls at the start of every range
+// iteration and template call. Templates cannot call it themselves: it is only
+// defined after parsing.
+const tickFunc = "_tick"
+
+// TemplatePool bounds how many templates execute at once, so templates of many
+// mirrors rendered concurrently cannot exhaust the controller's CPU and memory.
+// It is safe for concurrent use; a nil pool does not bound executions.
+type TemplatePool struct {
+	slots chan struct{}
+}
+
+// NewTemplatePool creates a pool running at most workers templates at once, or
+// one per CPU when workers is not positive.
+func NewTemplatePool(workers int) *TemplatePool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &TemplatePool{slots: make(chan struct{}, workers)}
+}
+
+// acquire waits for a free worker until ctx is done, returning the function
+// that frees it again.
+func (p *TemplatePool) acquire(ctx context.Context) (func(), error) {
+	if p == nil {
+		return func() {}, nil
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return func() { <-p.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// templateSandbox enforces the limits of one template execution: its deadline,
+// the size of its output and of the strings its functions build, and the
+// number of range iterations and template calls. Exceeding any of them aborts
+// the execution, so a runaway template stops instead of running on in the
+// background after its timeout.
+type templateSandbox struct {
+	ctx           context.Context
+	maxOutput     int
+	maxIterations int
+	iterations    int
+}
+
+func newTemplateSandbox(ctx context.Context, opts TransformOptions) *templateSandbox {
+	return &templateSandbox{ctx: ctx, maxOutput: opts.MaxTemplateOutput, maxIterations: opts.MaxTemplateIterations}
+}
+
+// funcs returns bounded versions of the template functions whose result can be
+// much larger than their arguments.
+func (s *templateSandbox) funcs() template.FuncMap {
+	return template.FuncMap{
+		"replace": func(str, old, replacement string) (string, error) {
+			growth := strings.Count(str, old) * (len(replacement) - len(old))
+			if err := s.checkSize(len(str) + growth); err != nil {
+				return "", err
+			}
+			return strings.ReplaceAll(str, old, replacement), nil
+		},
+		"indent": func(n int, str string) (string, error) {
+			if err := s.checkIndent(n, str); err != nil {
+				return "", err
+			}
+			return indent(n, str), nil
+		},
+		"nindent": func(n int, str string) (string, error) {
+			if err := s.checkIndent(n, str); err != nil {
+				return "", err
+			}
+			return nindent(n, str), nil
+		},
+	}
+}
+
+// tick counts one iteration, failing once the limit or the deadline is reached.
+func (s *templateSandbox) tick() (string, error) {
+	if err := s.ctx.Err(); err != nil {
+		return "", fmt.Errorf("template execution timeout")
+	}
+	s.iterations++
+	if s.maxIterations > 0 && s.iterations > s.maxIterations {
+		return "", fmt.Errorf("template exceeds %d iterations", s.maxIterations)
+	}
+	return "", nil
+}
+
+// checkIndent checks the size of indenting every line of str by n spaces.
+func (s *templateSandbox) checkIndent(n int, str string) error {
+	if n < 0 {
+		return fmt.Errorf("indent must not be negative")
+	}
+	if s.maxOutput > 0 && n > s.maxOutput {
+		return fmt.Errorf("template output exceeds %d bytes", s.maxOutput)
+	}
+	return s.checkSize(len(str) + n*(strings.Count(str, "\n")+1))
+}
+
+// checkSize fails when a string of size bytes would exceed the output limit.
+func (s *templateSandbox) checkSize(size int) error {
+	if s.maxOutput > 0 && size > s.maxOutput {
+		return fmt.Errorf("template output exceeds %d bytes", s.maxOutput)
+	}
+	return nil
+}
+
+// writer returns a writer collecting template output, failing once the output
+// limit or the deadline is reached.
+func (s *templateSandbox) writer() *sandboxWriter {
+	return &sandboxWriter{sandbox: s}
+}
+
+// sandboxWriter collects a template's output within its sandbox's limits.
+type sandboxWriter struct {
+	sandbox *templateSandbox
+	buf     bytes.Buffer
+}
+
+// Write implements io.Writer.
+func (w *sandboxWriter) Write(p []byte) (int, error) {
+	if err := w.sandbox.ctx.Err(); err != nil {
+		return 0, fmt.Errorf("template execution timeout")
+	}
+	if err := w.sandbox.checkSize(w.buf.Len() + len(p)); err != nil {
+		return 0, err
+	}
+	return w.buf.Write(p)
+}
+
+// String returns the output written so far.
+func (w *sandboxWriter) String() string {
+	return w.buf.String()
+}
+
+// instrument makes every range iteration and template call of the parsed tmpl,
+// and of the templates it defines, count against the sandbox's limits first.
+// text/template offers no hook into range loops, so this is the only way to
+// stop a loop that writes nothing.
+func (s *templateSandbox) instrument(tmpl *template.Template) {
+	tmpl.Funcs(template.FuncMap{tickFunc: s.tick})
+	tick := &parse.ActionNode{
+		NodeType: parse.NodeAction,
+		Pipe: &parse.PipeNode{
+			NodeType: parse.NodePipe,
+			Cmds: []*parse.CommandNode{{
+				NodeType: parse.NodeCommand,
+				Args:     []parse.Node{parse.NewIdentifier(tickFunc)},
+			}},
+		},
+	}
+	for _, defined := range tmpl.Templates() {
+		if defined.Tree == nil || defined.Tree.Root == nil {
+			continue
+		}
+		instrumentList(defined.Tree.Root, tick)
+		defined.Tree.Root.Nodes = append([]parse.Node{tick}, defined.Tree.Root.Nodes...)
+	}
+}
+
+// instrumentList prepends tick to the body of every range in list.
+func instrumentList(list *parse.ListNode, tick parse.Node) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.RangeNode:
+			instrumentList(n.List, tick)
+			instrumentList(n.ElseList, tick)
+			n.List.Nodes = append([]parse.Node{tick}, n.List.Nodes...)
+		case *parse.IfNode:
+			instrumentList(n.List, tick)
+			instrumentList(n.ElseList, tick)
+		case *parse.WithNode:
+			instrumentList(n.List, tick)
+			instrumentList(n.ElseList, tick)
+		}
+	}
+}
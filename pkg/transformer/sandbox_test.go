@@ -0,0 +1,89 @@
+package transformer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplate_Sandbox(t *testing.T) {
+	ctx := TransformContext{TargetNamespace: "prod-eu", Labels: map[string]string{"app": "shop", "tier": "web"}}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  string
+	}{
+		{name: "ranges still render", template: `{{ range $k, $v := .Labels }}{{ $k }}={{ $v }};{{ else }}none{{ end }}`, want: "app=shop;tier=web;"},
+		{name: "defined templates still render", template: `{{ define "ns" }}{{ .TargetNamespace }}{{ end }}{{ template "ns" . }}`, want: "prod-eu"},
+		{name: "output limit", template: `{{ range 90 }}01234567890123456789{{ end }}`, wantErr: "template output exceeds 1000 bytes"},
+		{name: "iteration limit", template: `{{ range 1000000000 }}{{ end }}`, wantErr: "template exceeds 100 iterations"},
+		{name: "nested iteration limit", template: `{{ range 50 }}{{ range 50 }}{{ end }}{{ end }}`, wantErr: "template exceeds 100 iterations"},
+		{name: "recursion limit", template: `{{ define "loop" }}{{ template "loop" }}{{ end }}{{ template "loop" }}`, wantErr: "template exceeds 100 iterations"},
+		{name: "replace growth", template: `{{ replace (indent 200 "a") "" "0123456789" }}`, wantErr: "template output exceeds 1000 bytes"},
+		{name: "indent growth", template: `{{ indent 100000000 "a" }}`, wantErr: "template output exceeds 1000 bytes"},
+		{name: "negative indent", template: `{{ nindent -1 "a" }}`, wantErr: "indent must not be negative"},
+		{name: "tick is not callable", template: `{{ _tick }}`, wantErr: "function \"_tick\" not defined"},
+	}
+
+	opts := DefaultTransformOptions()
+	opts.MaxTemplateOutput = 1000
+	opts.MaxTemplateIterations = 100
+	tr := NewTransformer(opts)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tr.renderTemplate(tt.template, ctx, ctx, "data.KEY")
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRenderTemplate_Pool(t *testing.T) {
+	opts := DefaultTransformOptions()
+	opts.TemplateTimeout = 20 * time.Millisecond
+	opts.TemplatePool = NewTemplatePool(1)
+	tr := NewTransformer(opts)
+
+	release, err := opts.TemplatePool.acquire(context.Background())
+	require.NoError(t, err)
+	_, err = tr.renderTemplate(`{{ .TargetNamespace }}`, TransformContext{}, TransformContext{TargetNamespace: "prod-eu"}, "data.KEY")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "waiting for a worker")
+
+	release()
+	got, err := tr.renderTemplate(`{{ .TargetNamespace }}`, TransformContext{}, TransformContext{TargetNamespace: "prod-eu"}, "data.KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "prod-eu", got)
+}
+
+func TestRenderTemplate_TimeoutFreesWorker(t *testing.T) {
+	opts := DefaultTransformOptions()
+	opts.TemplateTimeout = 20 * time.Millisecond
+	opts.MaxTemplateIterations = 0
+	opts.TemplatePool = NewTemplatePool(1)
+	tr := NewTransformer(opts)
+
+	_, err := tr.renderTemplate(`{{ range 1000000000000 }}{{ end }}`, TransformContext{}, TransformContext{}, "data.KEY")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timeout")
+
+	// The runaway execution stops at its next iteration, freeing the only worker
+	require.Eventually(t, func() bool {
+		release, err := opts.TemplatePool.acquire(context.Background())
+		if err != nil {
+			return false
+		}
+		release()
+		return true
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,160 @@
+package transformer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+// Secret format conversions selectable with kubemirror.raczylo.com/secret-format.
+const (
+	// SecretFormatDockercfg converts a kubernetes.io/dockerconfigjson Secret into a
+	// legacy kubernetes.io/dockercfg Secret
+	SecretFormatDockercfg = "dockercfg"
+	// SecretFormatDockerConfigJSON converts a legacy kubernetes.io/dockercfg Secret into
+	// a kubernetes.io/dockerconfigjson Secret
+	SecretFormatDockerConfigJSON = "dockerconfigjson"
+	// SecretFormatPEMBundle adds a bundle.pem key concatenating tls.crt and ca.crt
+	SecretFormatPEMBundle = "pem-bundle"
+)
+
+const (
+	// PEMBundleKey is the data key written by the pem-bundle conversion
+	PEMBundleKey = "bundle.pem"
+	// caCertKey is the conventional CA certificate key of cert-manager style TLS Secrets
+	caCertKey = "ca.crt"
+)
+
+// secretConversions maps each format to its conversion of a Secret's decoded data.
+// Conversions return the new Secret type, or "" to keep the current one.
+var secretConversions = map[string]func(data map[string][]byte) (corev1.SecretType, error){
+	SecretFormatDockercfg:        toDockercfg,
+	SecretFormatDockerConfigJSON: toDockerConfigJSON,
+	SecretFormatPEMBundle:        toPEMBundle,
+}
+
+// secretFormats returns the conversions requested on a Secret, in annotation order.
+func secretFormats(u *unstructured.Unstructured) []string {
+	if u.GetAPIVersion() != "v1" || u.GetKind() != "Secret" {
+		return nil
+	}
+
+	var formats []string
+	for _, format := range strings.Split(u.GetAnnotations()[constants.AnnotationSecretFormat], ",") {
+		if format = strings.TrimSpace(format); format != "" {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+// convertSecretFormats applies the requested conversions to a Secret mirror.
+// The mirror is only modified when every conversion succeeds.
+func convertSecretFormats(u *unstructured.Unstructured, formats []string) error {
+	encoded, _, err := unstructured.NestedStringMap(u.Object, "data")
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string][]byte, len(encoded))
+	for key, value := range encoded {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return fmt.Errorf("invalid base64 in key %s: %w", key, err)
+		}
+		data[key] = decoded
+	}
+
+	var newType corev1.SecretType
+	for _, format := range formats {
+		convert, ok := secretConversions[format]
+		if !ok {
+			return fmt.Errorf("unknown secret format %q", format)
+		}
+		secretType, err := convert(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", format, err)
+		}
+		if secretType != "" {
+			newType = secretType
+		}
+	}
+
+	encoded = make(map[string]string, len(data))
+	for key, value := range data {
+		encoded[key] = base64.StdEncoding.EncodeToString(value)
+	}
+	if newType != "" {
+		u.Object["type"] = string(newType)
+	}
+	return unstructured.SetNestedStringMap(u.Object, encoded, "data")
+}
+
+// toDockercfg unwraps the "auths" map of .dockerconfigjson into .dockercfg.
+func toDockercfg(data map[string][]byte) (corev1.SecretType, error) {
+	raw, ok := data[corev1.DockerConfigJsonKey]
+	if !ok {
+		return "", fmt.Errorf("missing %s key", corev1.DockerConfigJsonKey)
+	}
+
+	var config struct {
+		Auths json.RawMessage `json:"auths"`
+	}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return "", fmt.Errorf("invalid %s: %w", corev1.DockerConfigJsonKey, err)
+	}
+	if len(config.Auths) == 0 {
+		return "", fmt.Errorf("%s has no auths", corev1.DockerConfigJsonKey)
+	}
+
+	delete(data, corev1.DockerConfigJsonKey)
+	data[corev1.DockerConfigKey] = config.Auths
+	return corev1.SecretTypeDockercfg, nil
+}
+
+// toDockerConfigJSON wraps the registry map of .dockercfg into .dockerconfigjson.
+func toDockerConfigJSON(data map[string][]byte) (corev1.SecretType, error) {
+	raw, ok := data[corev1.DockerConfigKey]
+	if !ok {
+		return "", fmt.Errorf("missing %s key", corev1.DockerConfigKey)
+	}
+
+	var auths map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &auths); err != nil {
+		return "", fmt.Errorf("invalid %s: %w", corev1.DockerConfigKey, err)
+	}
+
+	config, err := json.Marshal(map[string]interface{}{"auths": auths})
+	if err != nil {
+		return "", err
+	}
+
+	delete(data, corev1.DockerConfigKey)
+	data[corev1.DockerConfigJsonKey] = config
+	return corev1.SecretTypeDockerConfigJson, nil
+}
+
+// toPEMBundle concatenates tls.crt and ca.crt into bundle.pem, keeping the original keys.
+func toPEMBundle(data map[string][]byte) (corev1.SecretType, error) {
+	cert, ok := data[corev1.TLSCertKey]
+	if !ok {
+		return "", fmt.Errorf("missing %s key", corev1.TLSCertKey)
+	}
+
+	bundle := append([]byte{}, cert...)
+	if ca := data[caCertKey]; len(ca) > 0 {
+		if len(bundle) > 0 && bundle[len(bundle)-1] != '\n' {
+			bundle = append(bundle, '\n')
+		}
+		bundle = append(bundle, ca...)
+	}
+
+	data[PEMBundleKey] = bundle
+	return "", nil
+}
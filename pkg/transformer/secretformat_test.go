@@ -0,0 +1,147 @@
+package transformer
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func secretWithFormat(format string, secretType corev1.SecretType, data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "registry",
+			Namespace:   "default",
+			Annotations: map[string]string{constants.AnnotationSecretFormat: format},
+		},
+		Type: secretType,
+		Data: map[string][]byte{},
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+func decodedData(t *testing.T, u *unstructured.Unstructured) map[string]string {
+	t.Helper()
+	encoded, _, err := unstructured.NestedStringMap(u.Object, "data")
+	require.NoError(t, err)
+
+	decoded := make(map[string]string, len(encoded))
+	for k, v := range encoded {
+		raw, err := base64.StdEncoding.DecodeString(v)
+		require.NoError(t, err)
+		decoded[k] = string(raw)
+	}
+	return decoded
+}
+
+func TestTransformer_SecretFormat(t *testing.T) {
+	const auths = `{"registry.example.com":{"auth":"dXNlcjpwYXNz"}}`
+
+	tests := []struct {
+		name     string
+		source   *corev1.Secret
+		wantType string
+		wantData map[string]string
+		wantErr  bool
+	}{
+		{
+			name: "dockerconfigjson to dockercfg",
+			source: secretWithFormat(SecretFormatDockercfg, corev1.SecretTypeDockerConfigJson,
+				map[string]string{corev1.DockerConfigJsonKey: `{"auths":` + auths + `}`}),
+			wantType: string(corev1.SecretTypeDockercfg),
+			wantData: map[string]string{corev1.DockerConfigKey: auths},
+		},
+		{
+			name: "dockercfg to dockerconfigjson",
+			source: secretWithFormat(SecretFormatDockerConfigJSON, corev1.SecretTypeDockercfg,
+				map[string]string{corev1.DockerConfigKey: auths}),
+			wantType: string(corev1.SecretTypeDockerConfigJson),
+			wantData: map[string]string{corev1.DockerConfigJsonKey: `{"auths":` + auths + `}`},
+		},
+		{
+			name: "pem bundle adds separator newline",
+			source: secretWithFormat(SecretFormatPEMBundle, corev1.SecretTypeTLS, map[string]string{
+				corev1.TLSCertKey:       "-----CERT-----",
+				corev1.TLSPrivateKeyKey: "-----KEY-----",
+				"ca.crt":                "-----CA-----\n",
+			}),
+			wantType: string(corev1.SecretTypeTLS),
+			wantData: map[string]string{
+				corev1.TLSCertKey:       "-----CERT-----",
+				corev1.TLSPrivateKeyKey: "-----KEY-----",
+				"ca.crt":                "-----CA-----\n",
+				PEMBundleKey:            "-----CERT-----\n-----CA-----\n",
+			},
+		},
+		{
+			name: "pem bundle without ca",
+			source: secretWithFormat(SecretFormatPEMBundle, corev1.SecretTypeTLS, map[string]string{
+				corev1.TLSCertKey: "-----CERT-----\n",
+			}),
+			wantType: string(corev1.SecretTypeTLS),
+			wantData: map[string]string{
+				corev1.TLSCertKey: "-----CERT-----\n",
+				PEMBundleKey:      "-----CERT-----\n",
+			},
+		},
+		{
+			name:    "missing source key",
+			source:  secretWithFormat(SecretFormatDockercfg, corev1.SecretTypeOpaque, map[string]string{"other": "x"}),
+			wantErr: true,
+		},
+		{
+			name:    "unknown format",
+			source:  secretWithFormat("jks", corev1.SecretTypeOpaque, map[string]string{"other": "x"}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.source.Annotations[constants.AnnotationTransformStrict] = "true"
+
+			result, err := NewDefaultTransformer().Transform(tt.source, TransformContext{TargetNamespace: "prod"})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			u := result.(*unstructured.Unstructured)
+			secretType, _, _ := unstructured.NestedString(u.Object, "type")
+			assert.Equal(t, tt.wantType, secretType)
+			assert.Equal(t, tt.wantData, decodedData(t, u))
+		})
+	}
+}
+
+func TestTransformer_SecretFormatChained(t *testing.T) {
+	source := secretWithFormat("pem-bundle, dockercfg", corev1.SecretTypeOpaque, map[string]string{
+		corev1.TLSCertKey:          "CERT\n",
+		corev1.DockerConfigJsonKey: `{"auths":`,
+	})
+
+	// Invalid JSON fails the second conversion; non-strict mode keeps the mirror unconverted
+	result, err := NewDefaultTransformer().Transform(source, TransformContext{TargetNamespace: "prod"})
+	require.NoError(t, err)
+	u := result.(*unstructured.Unstructured)
+	assert.NotContains(t, decodedData(t, u), PEMBundleKey)
+	secretType, _, _ := unstructured.NestedString(u.Object, "type")
+	assert.Equal(t, string(corev1.SecretTypeOpaque), secretType)
+
+	source.Data[corev1.DockerConfigJsonKey] = []byte(`{"auths":{"r.example.com":{}}}`)
+	result, err = NewDefaultTransformer().Transform(source, TransformContext{TargetNamespace: "prod"})
+	require.NoError(t, err)
+	data := decodedData(t, result.(*unstructured.Unstructured))
+	assert.Equal(t, "CERT\n", data[PEMBundleKey])
+	assert.Equal(t, `{"r.example.com":{}}`, data[corev1.DockerConfigKey])
+}
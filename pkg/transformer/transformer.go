@@ -1,18 +1,17 @@
 package transformer
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"fmt"
 	"strings"
 	"text/template"
 
-	"gopkg.in/yaml.v3"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
 )
 
 // Transformer applies transformation rules to Kubernetes resources.
@@ -35,61 +34,169 @@ func NewDefaultTransformer() *Transformer {
 // Transform applies transformation rules to a resource.
 // It returns the transformed resource and any errors encountered.
 func (t *Transformer) Transform(source runtime.Object, ctx TransformContext) (runtime.Object, error) {
+	result, _, err := t.TransformWithDiagnostics(source, ctx)
+	return result, err
+}
+
+// TransformWithDiagnostics is Transform, also reporting which rules applied,
+// were skipped or failed. In non-strict mode failures are only reported there.
+func (t *Transformer) TransformWithDiagnostics(source runtime.Object, ctx TransformContext) (runtime.Object, *Diagnostics, error) {
+	diag := &Diagnostics{}
+
 	// Convert to unstructured for easier manipulation
 	unstructuredObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(source)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert to unstructured: %w", err)
+		return nil, nil, fmt.Errorf("failed to convert to unstructured: %w", err)
 	}
 
 	u := &unstructured.Unstructured{Object: unstructuredObj}
+	ctx.seed = contentSeed(u, ctx)
 
 	// Get transformation rules from annotations
-	rules, err := t.parseTransformRules(u)
+	rules, err := t.parseTransformRules(u, ctx.SourceNamespace)
 	if err != nil {
+		err = fmt.Errorf("failed to parse transformation rules: %w", err)
 		if t.isStrictMode(u) {
-			return nil, fmt.Errorf("failed to parse transformation rules: %w", err)
+			return nil, nil, err
 		}
-		// Non-strict mode: log warning and return original
-		return source, nil
-	}
-
-	if len(rules.Rules) == 0 {
-		// No transformation rules
-		return source, nil
+		// Non-strict mode: ignore the resource's rules, defaults still apply
+		diag.fail("rules", err)
+		rules = &TransformRules{}
 	}
 
 	// Validate rules
 	if err := t.validateRules(rules); err != nil {
+		err = fmt.Errorf("invalid transformation rules: %w", err)
 		if t.isStrictMode(u) {
-			return nil, fmt.Errorf("invalid transformation rules: %w", err)
+			return nil, nil, err
+		}
+		diag.fail("rules", err)
+		rules = &TransformRules{}
+	}
+
+	renderValues := t.shouldRenderValues(u)
+	formats := secretFormats(u)
+	injectKey := namespaceKey(u)
+	hostTemplate := hostRewriteTemplate(u)
+	if len(t.options.DefaultRules) == 0 && len(rules.Rules) == 0 &&
+		!renderValues && len(formats) == 0 && injectKey == "" && hostTemplate == "" {
+		// No transformation rules
+		return source, diag, nil
+	}
+
+	if err := t.resolveContext(&ctx); err != nil {
+		return nil, nil, err
+	}
+
+	// Controller-level defaults run first; per-resource rules on the same path replace them
+	allRules, defaultCount := mergeRules(t.options.DefaultRules, rules.Rules, ctx)
+
+	// Render templated values before rules, so rules can still override individual keys
+	if renderValues {
+		if err := t.renderValues(u, ctx); err != nil {
+			if t.isStrictMode(u) {
+				return nil, nil, fmt.Errorf("failed to render values: %w", err)
+			}
+			diag.fail("render-values", err)
+		}
+	}
+
+	if len(formats) > 0 {
+		if err := convertSecretFormats(u, formats); err != nil {
+			if t.isStrictMode(u) {
+				return nil, nil, fmt.Errorf("failed to convert secret format: %w", err)
+			}
+			diag.fail("secret-format", err)
+		}
+	}
+
+	if hostTemplate != "" {
+		if err := t.rewriteHosts(u, hostTemplate, ctx); err != nil {
+			if t.isStrictMode(u) {
+				return nil, nil, fmt.Errorf("failed to rewrite hosts: %w", err)
+			}
+			diag.fail("host-template", err)
+		}
+	}
+
+	if injectKey != "" {
+		if err := injectNamespaceKey(u, injectKey, ctx); err != nil {
+			if t.isStrictMode(u) {
+				return nil, nil, fmt.Errorf("failed to inject namespace key: %w", err)
+			}
+			diag.fail("inject-namespace-key", err)
 		}
-		return source, nil
 	}
 
 	// Apply each rule
-	for i, rule := range rules.Rules {
-		if err := t.applyRule(u, rule, ctx); err != nil {
+	for i, rule := range allRules {
+		result := RuleResult{Rule: ruleName(i, defaultCount), Path: rule.Path, Outcome: RuleApplied}
+		applies, err := rule.appliesTo(ctx)
+		if err == nil && !applies {
+			// Rule doesn't apply to this mirror
+			result.Outcome, result.Reason = RuleSkipped, skipReason(rule, ctx)
+			diag.Results = append(diag.Results, result)
+			continue
+		}
+		if err == nil {
+			err = t.applyRule(u, rule, ctx)
+		}
+		if err != nil {
 			if t.isStrictMode(u) {
-				return nil, fmt.Errorf("failed to apply rule %d (%s): %w", i+1, rule.Path, err)
+				if i < defaultCount {
+					return nil, nil, fmt.Errorf("failed to apply default rule (%s): %w", rule.Path, err)
+				}
+				return nil, nil, fmt.Errorf("failed to apply rule %d (%s): %w", i-defaultCount+1, rule.Path, err)
 			}
 			// Non-strict mode: continue with next rule
-			continue
+			result.Outcome, result.Error = RuleFailed, err.Error()
 		}
+		diag.Results = append(diag.Results, result)
+	}
+
+	return u, diag, nil
+}
+
+// resolveContext fills in what templates read from outside the source: the
+// cluster name and the target namespace's labels and annotations. Failing to
+// read the namespace fails the transformation even in non-strict mode, as its
+// templates would silently render without the namespace's metadata.
+func (t *Transformer) resolveContext(ctx *TransformContext) error {
+	if ctx.ClusterName == "" {
+		ctx.ClusterName = t.options.ClusterName
+	}
+	if t.options.Namespace == nil || ctx.TargetNamespace == "" ||
+		ctx.TargetNamespaceLabels != nil || ctx.TargetNamespaceAnnotations != nil {
+		return nil
 	}
 
-	return u, nil
+	readCtx, cancel := context.WithTimeout(context.Background(), t.options.TemplateTimeout)
+	defer cancel()
+	labels, annotations, err := t.options.Namespace(readCtx, ctx.TargetNamespace)
+	if err != nil {
+		return fmt.Errorf("reading target namespace %s failed: %w", ctx.TargetNamespace, err)
+	}
+	ctx.TargetNamespaceLabels, ctx.TargetNamespaceAnnotations = labels, annotations
+	return nil
 }
 
-// parseTransformRules extracts and parses transformation rules from resource annotations.
-func (t *Transformer) parseTransformRules(u *unstructured.Unstructured) (*TransformRules, error) {
+// parseTransformRules extracts and parses transformation rules from resource
+// annotations: the rules of the referenced rule libraries, followed by the
+// resource's own rules.
+func (t *Transformer) parseTransformRules(u *unstructured.Unstructured, sourceNamespace string) (*TransformRules, error) {
 	annotations := u.GetAnnotations()
 	if annotations == nil {
 		return &TransformRules{}, nil
 	}
 
+	rules, err := t.libraryRules(annotations[constants.AnnotationTransformRef], sourceNamespace)
+	if err != nil {
+		return nil, err
+	}
+
 	rulesYAML, exists := annotations[constants.AnnotationTransform]
 	if !exists || rulesYAML == "" {
-		return &TransformRules{}, nil
+		return rules, nil
 	}
 
 	// Check size limit
@@ -97,12 +204,25 @@ func (t *Transformer) parseTransformRules(u *unstructured.Unstructured) (*Transf
 		return nil, fmt.Errorf("transformation rules exceed maximum size of %d bytes", t.options.MaxRuleSize)
 	}
 
-	var rules TransformRules
-	if err := yaml.Unmarshal([]byte(rulesYAML), &rules); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	own, err := ParseRules([]byte(rulesYAML))
+	if err != nil {
+		return nil, err
 	}
+	own.Rules = append(rules.Rules, own.Rules...)
+	return own, nil
+}
 
-	return &rules, nil
+// ValidateRules checks a transform annotation like Transform does before applying
+// it: its size, its YAML, the number of rules and every rule.
+func (t *Transformer) ValidateRules(rulesYAML string) error {
+	if len(rulesYAML) > t.options.MaxRuleSize {
+		return fmt.Errorf("transformation rules exceed maximum size of %d bytes", t.options.MaxRuleSize)
+	}
+	rules, err := ParseRules([]byte(rulesYAML))
+	if err != nil {
+		return err
+	}
+	return t.validateRules(rules)
 }
 
 // validateRules validates all transformation rules.
@@ -120,88 +240,139 @@ func (t *Transformer) validateRules(rules *TransformRules) error {
 	return nil
 }
 
-// applyRule applies a single transformation rule to the resource.
+// applyRule applies a single transformation rule to the resource. The caller
+// checks that the rule applies to the mirror being built.
 func (t *Transformer) applyRule(u *unstructured.Unstructured, rule Rule, ctx TransformContext) error {
-	// Check if rule should apply to this target namespace
-	if !matchesNamespacePattern(rule, ctx.TargetNamespace) {
-		// Rule doesn't apply to this namespace - skip silently
-		return nil
-	}
-
+	var apply func(path []string) error
 	switch rule.Type() {
 	case RuleTypeValue:
-		return t.applyValueRule(u, rule, ctx)
+		apply = func(path []string) error { return t.applyValueRule(u, rule, path) }
 	case RuleTypeTemplate:
-		return t.applyTemplateRule(u, rule, ctx)
+		apply = func(path []string) error { return t.applyTemplateRule(u, rule, path, ctx) }
 	case RuleTypeMerge:
-		return t.applyMergeRule(u, rule, ctx)
+		apply = func(path []string) error { return t.applyMergeRule(u, rule, path) }
 	case RuleTypeDelete:
-		return t.applyDeleteRule(u, rule, ctx)
+		apply = func(path []string) error { return t.applyDeleteRule(u, path) }
+	case RuleTypeRewriteNamespaceRefs:
+		return t.applyRewriteNamespaceRefsRule(u, rule, ctx)
+	case RuleTypeRegex:
+		return t.applyRegexRule(u, rule, ctx)
 	default:
 		return fmt.Errorf("unknown rule type: %s", rule.Type())
 	}
+
+	// A path with wildcards applies the rule to every field it matches
+	for _, path := range expandPath(u.Object, parsePath(rule.Path)) {
+		if err := apply(path); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // applyValueRule sets a field to a static value.
-func (t *Transformer) applyValueRule(u *unstructured.Unstructured, rule Rule, ctx TransformContext) error {
+func (t *Transformer) applyValueRule(u *unstructured.Unstructured, rule Rule, path []string) error {
 	if rule.Value == nil {
 		return fmt.Errorf("value rule has nil value")
 	}
 
-	pathParts := parsePath(rule.Path)
-	if len(pathParts) == 0 {
+	if len(path) == 0 {
 		return fmt.Errorf("empty path")
 	}
 
-	return setNestedField(u.Object, pathParts, *rule.Value)
+	if rule.DecodeBase64 {
+		return setBase64Field(u.Object, path, *rule.Value)
+	}
+	return setNestedField(u.Object, path, *rule.Value)
 }
 
 // applyTemplateRule uses Go templates to generate the value.
-func (t *Transformer) applyTemplateRule(u *unstructured.Unstructured, rule Rule, ctx TransformContext) error {
+func (t *Transformer) applyTemplateRule(u *unstructured.Unstructured, rule Rule, path []string, ctx TransformContext) error {
 	if rule.Template == nil {
 		return fmt.Errorf("template rule has nil template")
 	}
 
-	// Create template with timeout
-	tmpl, err := template.New("transform").Funcs(templateFuncs()).Parse(*rule.Template)
+	// Each field a wildcard path matches gets its own random values
+	scope := rule.Path
+	if hasWildcard(parsePath(rule.Path)) {
+		scope = strings.Join(path, ".")
+	}
+	if rule.DecodeBase64 {
+		value, err := decodedField(u.Object, path)
+		if err != nil {
+			return err
+		}
+		ctx.Value = value
+	}
+	result, err := t.renderTemplate(*rule.Template, ctx, ctx, scope)
 	if err != nil {
-		return fmt.Errorf("failed to parse template: %w", err)
+		return err
 	}
 
+	if rule.DecodeBase64 {
+		return setBase64Field(u.Object, path, result)
+	}
+	return setNestedField(u.Object, path, result)
+}
+
+// renderTemplate evaluates text as a Go template against data (usually the
+// TransformContext) with the configured timeout. scope names what the result
+// is rendered into, so randAlphaNum generates different values for each.
+func (t *Transformer) renderTemplate(text string, ctx TransformContext, data interface{}, scope string) (string, error) {
 	// Execute template with timeout
 	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), t.options.TemplateTimeout)
 	defer cancel()
 
+	sandbox := newTemplateSandbox(ctxWithTimeout, t.options)
+	tmpl, err := template.New("transform").
+		Funcs(templateFuncs()).
+		Funcs(sandbox.funcs()).
+		Funcs(template.FuncMap{
+			"lookup":       t.lookup(ctxWithTimeout),
+			"contextValue": t.contextValue(ctxWithTimeout, ctx.TargetNamespace),
+			"randAlphaNum": randAlphaNum(ctx.seed, scope, text),
+		}).
+		Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+	sandbox.instrument(tmpl)
+
+	release, err := t.options.TemplatePool.acquire(ctxWithTimeout)
+	if err != nil {
+		return "", fmt.Errorf("template execution timeout waiting for a worker")
+	}
+
 	resultChan := make(chan string, 1)
 	errChan := make(chan error, 1)
 
+	// The sandbox stops the execution soon after the deadline, freeing its worker
 	go func() {
-		var buf bytes.Buffer
-		if err := tmpl.Execute(&buf, ctx); err != nil {
+		defer release()
+		out := sandbox.writer()
+		if err := tmpl.Execute(out, data); err != nil {
 			errChan <- err
 			return
 		}
-		resultChan <- buf.String()
+		resultChan <- out.String()
 	}()
 
 	select {
 	case <-ctxWithTimeout.Done():
-		return fmt.Errorf("template execution timeout")
+		return "", fmt.Errorf("template execution timeout")
 	case err := <-errChan:
-		return fmt.Errorf("template execution failed: %w", err)
+		return "", fmt.Errorf("template execution failed: %w", err)
 	case result := <-resultChan:
-		pathParts := parsePath(rule.Path)
-		return setNestedField(u.Object, pathParts, result)
+		return result, nil
 	}
 }
 
 // applyMergeRule merges a map into the target field.
-func (t *Transformer) applyMergeRule(u *unstructured.Unstructured, rule Rule, ctx TransformContext) error {
+func (t *Transformer) applyMergeRule(u *unstructured.Unstructured, rule Rule, pathParts []string) error {
 	if rule.Merge == nil {
 		return fmt.Errorf("merge rule has nil merge map")
 	}
 
-	pathParts := parsePath(rule.Path)
 	if len(pathParts) == 0 {
 		return fmt.Errorf("empty path")
 	}
@@ -221,7 +392,13 @@ func (t *Transformer) applyMergeRule(u *unstructured.Unstructured, rule Rule, ct
 	}
 
 	// Merge new values
-	for k, v := range rule.Merge {
+	values := rule.Merge
+	if rule.DecodeBase64 {
+		if values, err = encodeMergeValues(rule.Merge); err != nil {
+			return err
+		}
+	}
+	for k, v := range values {
 		merged[k] = v
 	}
 
@@ -229,8 +406,7 @@ func (t *Transformer) applyMergeRule(u *unstructured.Unstructured, rule Rule, ct
 }
 
 // applyDeleteRule removes a field from the resource.
-func (t *Transformer) applyDeleteRule(u *unstructured.Unstructured, rule Rule, ctx TransformContext) error {
-	pathParts := parsePath(rule.Path)
+func (t *Transformer) applyDeleteRule(u *unstructured.Unstructured, pathParts []string) error {
 	if len(pathParts) == 0 {
 		return fmt.Errorf("empty path")
 	}
@@ -443,63 +619,42 @@ func parseArrayIndex(segment string) (int, error) {
 	return index, nil
 }
 
-// matchesNamespacePattern checks if a target namespace matches the rule's namespace pattern.
+// matchesNamespacePattern checks if a target namespace matches the rule's namespace patterns.
 // If no pattern is specified, the rule applies to all namespaces.
-// Supports glob patterns with * (matches any characters) and ? (matches single character).
+// Supports glob patterns with * (matches any characters) and ? (matches single character),
+// and "re:"-prefixed regular expressions. Patterns prefixed with ! exclude matching namespaces and take precedence over inclusions;
+// a list containing only exclusions applies to every other namespace.
 func matchesNamespacePattern(rule Rule, targetNamespace string) bool {
-	// If no pattern is specified, rule applies to all namespaces
-	if rule.NamespacePattern == nil || *rule.NamespacePattern == "" {
-		return true
-	}
-
-	pattern := *rule.NamespacePattern
-	return matchGlob(pattern, targetNamespace)
-}
-
-// matchGlob performs simple glob pattern matching with support for * and ?.
-// * matches zero or more characters
-// ? matches exactly one character
-func matchGlob(pattern, text string) bool {
-	// Fast path for exact match or wildcard-only pattern
-	if pattern == text {
-		return true
-	}
-	if pattern == "*" {
-		return true
-	}
+	included := false
+	hasInclude := false
 
-	return matchGlobRecursive(pattern, text, 0, 0)
-}
-
-// matchGlobRecursive implements recursive glob matching.
-func matchGlobRecursive(pattern, text string, pIdx, tIdx int) bool {
-	pLen := len(pattern)
-	tLen := len(text)
-
-	// Base cases
-	if pIdx == pLen {
-		return tIdx == tLen
-	}
-
-	// Check for wildcard
-	if pattern[pIdx] == '*' {
-		// Try matching zero characters (skip *)
-		if matchGlobRecursive(pattern, text, pIdx+1, tIdx) {
-			return true
+	for _, pattern := range rule.NamespacePattern {
+		if pattern == "" {
+			continue
 		}
-		// Try matching one or more characters
-		if tIdx < tLen && matchGlobRecursive(pattern, text, pIdx, tIdx+1) {
-			return true
+		if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+			if matchNamespace(negated, targetNamespace) {
+				return false
+			}
+			continue
+		}
+		hasInclude = true
+		if matchNamespace(pattern, targetNamespace) {
+			included = true
 		}
-		return false
 	}
 
-	// Check for single character wildcard or exact match
-	if tIdx < tLen && (pattern[pIdx] == '?' || pattern[pIdx] == text[tIdx]) {
-		return matchGlobRecursive(pattern, text, pIdx+1, tIdx+1)
-	}
+	// If no inclusion pattern is specified, rule applies to all remaining namespaces
+	return included || !hasInclude
+}
 
-	return false
+// matchNamespace matches a namespace against a glob or a "re:" regular expression.
+func matchNamespace(pattern, namespace string) bool {
+	if filter.IsRegexPattern(pattern) {
+		re, err := filter.CompileRegexPattern(pattern)
+		return err == nil && re.MatchString(namespace)
+	}
+	return filter.MatchGlob(pattern, namespace)
 }
 
 // templateFuncs returns custom template functions.
@@ -512,6 +667,12 @@ func templateFuncs() template.FuncMap {
 		"replace":    strings.ReplaceAll,
 		"hasPrefix":  strings.HasPrefix,
 		"hasSuffix":  strings.HasSuffix,
+		"base64enc":  base64Encode,
+		"base64dec":  base64Decode,
+		"sha256sum":  sha256Sum,
+		"indent":     indent,
+		"nindent":    nindent,
+		"toYaml":     toYAML,
 		"default": func(defaultValue interface{}, value interface{}) interface{} {
 			if value == nil || value == "" {
 				return defaultValue
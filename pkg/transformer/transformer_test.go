@@ -1,7 +1,9 @@
 package transformer
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/lukaszraczylo/kubemirror/pkg/constants"
@@ -734,172 +736,29 @@ func TestTransformer_TemplateTimeout(t *testing.T) {
 	t.Skip("Template timeout testing is unreliable in unit tests - covered by integration tests")
 }
 
-func TestMatchGlob(t *testing.T) {
-	tests := []struct {
-		name     string
-		pattern  string
-		text     string
-		expected bool
-	}{
-		// Exact matches
-		{
-			name:     "exact match",
-			pattern:  "production",
-			text:     "production",
-			expected: true,
-		},
-		{
-			name:     "exact match - no match",
-			pattern:  "production",
-			text:     "staging",
-			expected: false,
-		},
+func TestTransformer_ValidateRules(t *testing.T) {
+	opts := DefaultTransformOptions()
+	opts.MaxRules = 1
+	opts.MaxRuleSize = 100
+	tr := NewTransformer(opts)
 
-		// Wildcard * patterns
-		{
-			name:     "wildcard all",
-			pattern:  "*",
-			text:     "anything",
-			expected: true,
-		},
-		{
-			name:     "prefix wildcard",
-			pattern:  "prod-*",
-			text:     "prod-app-1",
-			expected: true,
-		},
-		{
-			name:     "prefix wildcard - no match",
-			pattern:  "prod-*",
-			text:     "staging-app-1",
-			expected: false,
-		},
-		{
-			name:     "suffix wildcard",
-			pattern:  "*-staging",
-			text:     "app-staging",
-			expected: true,
-		},
-		{
-			name:     "suffix wildcard - no match",
-			pattern:  "*-staging",
-			text:     "app-production",
-			expected: false,
-		},
-		{
-			name:     "middle wildcard",
-			pattern:  "app-*-db",
-			text:     "app-prod-db",
-			expected: true,
-		},
-		{
-			name:     "middle wildcard - no match",
-			pattern:  "app-*-db",
-			text:     "app-prod-cache",
-			expected: false,
-		},
-		{
-			name:     "multiple wildcards",
-			pattern:  "*-prod-*",
-			text:     "service-prod-v1",
-			expected: true,
-		},
-		{
-			name:     "wildcard matches empty",
-			pattern:  "app-*",
-			text:     "app-",
-			expected: true,
-		},
+	assert.NoError(t, tr.ValidateRules("rules:\n  - path: data.A\n    value: x\n"))
 
-		// Single character wildcard ?
-		{
-			name:     "single char wildcard",
-			pattern:  "app-?",
-			text:     "app-1",
-			expected: true,
-		},
-		{
-			name:     "single char wildcard - no match (too long)",
-			pattern:  "app-?",
-			text:     "app-12",
-			expected: false,
-		},
-		{
-			name:     "single char wildcard - no match (too short)",
-			pattern:  "app-?",
-			text:     "app-",
-			expected: false,
-		},
-		{
-			name:     "multiple single char wildcards",
-			pattern:  "app-??",
-			text:     "app-12",
-			expected: true,
-		},
-		{
-			name:     "mixed wildcards",
-			pattern:  "app-?-*",
-			text:     "app-1-prod",
-			expected: true,
-		},
-
-		// Edge cases
-		{
-			name:     "empty pattern and text",
-			pattern:  "",
-			text:     "",
-			expected: true,
-		},
-		{
-			name:     "empty pattern non-empty text",
-			pattern:  "",
-			text:     "text",
-			expected: false,
-		},
-		{
-			name:     "pattern longer than text",
-			pattern:  "production",
-			text:     "prod",
-			expected: false,
-		},
-		{
-			name:     "text longer than pattern",
-			pattern:  "prod",
-			text:     "production",
-			expected: false,
-		},
-
-		// Real-world examples
-		{
-			name:     "preprod namespaces",
-			pattern:  "preprod-*",
-			text:     "preprod-api",
-			expected: true,
-		},
-		{
-			name:     "staging environments",
-			pattern:  "*-staging",
-			text:     "app-staging",
-			expected: true,
-		},
-		{
-			name:     "numbered namespaces",
-			pattern:  "namespace-?",
-			text:     "namespace-1",
-			expected: true,
-		},
-		{
-			name:     "versioned services",
-			pattern:  "service-v*",
-			text:     "service-v1.2.3",
-			expected: true,
-		},
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr string
+	}{
+		{name: "invalid YAML", rules: "rules: [", wantErr: "failed to parse YAML"},
+		{name: "invalid rule", rules: "rules:\n  - path: data.A\n", wantErr: "rule 1:"},
+		{name: "too many rules", rules: "rules:\n  - path: data.A\n    value: x\n  - path: data.B\n    value: y\n", wantErr: "too many rules (2)"},
+		{name: "too large", rules: "rules:\n" + strings.Repeat("#", 100), wantErr: "exceed maximum size"},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := matchGlob(tt.pattern, tt.text)
-			assert.Equal(t, tt.expected, result, "matchGlob(%q, %q)", tt.pattern, tt.text)
+			err := tr.ValidateRules(tt.rules)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
 		})
 	}
 }
@@ -907,7 +766,7 @@ func TestMatchGlob(t *testing.T) {
 func TestMatchesNamespacePattern(t *testing.T) {
 	tests := []struct {
 		name            string
-		pattern         *string
+		pattern         NamespacePattern
 		targetNamespace string
 		expected        bool
 	}{
@@ -919,46 +778,106 @@ func TestMatchesNamespacePattern(t *testing.T) {
 		},
 		{
 			name:            "empty pattern - matches all",
-			pattern:         stringPtr(""),
+			pattern:         NamespacePattern{""},
 			targetNamespace: "any-namespace",
 			expected:        true,
 		},
 		{
 			name:            "exact match",
-			pattern:         stringPtr("production"),
+			pattern:         NamespacePattern{"production"},
 			targetNamespace: "production",
 			expected:        true,
 		},
+		{
+			name:            "regular expression",
+			pattern:         NamespacePattern{"re:^team-(a|b)-prod$"},
+			targetNamespace: "team-b-prod",
+			expected:        true,
+		},
+		{
+			name:            "regular expression - no match",
+			pattern:         NamespacePattern{"re:^team-(a|b)-prod$"},
+			targetNamespace: "team-c-prod",
+			expected:        false,
+		},
+		{
+			name:            "regular expression exclusion",
+			pattern:         NamespacePattern{"team-*", "!re:-(dev|test)$"},
+			targetNamespace: "team-a-dev",
+			expected:        false,
+		},
 		{
 			name:            "exact match - no match",
-			pattern:         stringPtr("production"),
+			pattern:         NamespacePattern{"production"},
 			targetNamespace: "staging",
 			expected:        false,
 		},
 		{
 			name:            "prefix pattern match",
-			pattern:         stringPtr("preprod-*"),
+			pattern:         NamespacePattern{"preprod-*"},
 			targetNamespace: "preprod-api",
 			expected:        true,
 		},
 		{
 			name:            "prefix pattern no match",
-			pattern:         stringPtr("preprod-*"),
+			pattern:         NamespacePattern{"preprod-*"},
 			targetNamespace: "prod-api",
 			expected:        false,
 		},
 		{
 			name:            "suffix pattern match",
-			pattern:         stringPtr("*-staging"),
+			pattern:         NamespacePattern{"*-staging"},
 			targetNamespace: "app-staging",
 			expected:        true,
 		},
 		{
 			name:            "suffix pattern no match",
-			pattern:         stringPtr("*-staging"),
+			pattern:         NamespacePattern{"*-staging"},
 			targetNamespace: "app-prod",
 			expected:        false,
 		},
+		{
+			name:            "list - matches any pattern",
+			pattern:         NamespacePattern{"prod-*", "staging-*"},
+			targetNamespace: "staging-api",
+			expected:        true,
+		},
+		{
+			name:            "list - matches no pattern",
+			pattern:         NamespacePattern{"prod-*", "staging-*"},
+			targetNamespace: "dev-api",
+			expected:        false,
+		},
+		{
+			name:            "negation excludes included namespace",
+			pattern:         NamespacePattern{"prod-*", "!prod-eu-*"},
+			targetNamespace: "prod-eu-west",
+			expected:        false,
+		},
+		{
+			name:            "negation keeps other included namespaces",
+			pattern:         NamespacePattern{"prod-*", "!prod-eu-*"},
+			targetNamespace: "prod-us-east",
+			expected:        true,
+		},
+		{
+			name:            "negation wins regardless of order",
+			pattern:         NamespacePattern{"!prod-eu-*", "prod-*"},
+			targetNamespace: "prod-eu-west",
+			expected:        false,
+		},
+		{
+			name:            "negation only - matches everything else",
+			pattern:         NamespacePattern{"!kube-*"},
+			targetNamespace: "team-a",
+			expected:        true,
+		},
+		{
+			name:            "negation only - excluded",
+			pattern:         NamespacePattern{"!kube-*"},
+			targetNamespace: "kube-system",
+			expected:        false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1079,6 +998,33 @@ rules:
 			},
 			description: "Rule without pattern should apply, rule with non-matching pattern should not",
 		},
+		{
+			name: "pattern list with negation",
+			sourceData: map[string]interface{}{
+				"data": map[string]interface{}{
+					"HOST":   "default.example.com",
+					"REGION": "unset",
+				},
+			},
+			rules: `
+rules:
+  - path: data.HOST
+    value: "prod.example.com"
+    namespacePattern: ["prod-*", "!prod-eu-*"]
+  - path: data.REGION
+    value: "eu"
+    namespacePattern:
+      - "prod-eu-*"
+`,
+			targetNamespace: "prod-eu-west",
+			expectedData: map[string]interface{}{
+				"data": map[string]interface{}{
+					"HOST":   "default.example.com",
+					"REGION": "eu",
+				},
+			},
+			description: "Negated pattern should exclude 'prod-eu-west' from the 'prod-*' rule",
+		},
 		{
 			name: "template rule with namespace pattern",
 			sourceData: map[string]interface{}{
@@ -1401,3 +1347,61 @@ rules:
 		})
 	}
 }
+
+func TestTransformer_ResolveContext(t *testing.T) {
+	var read []string
+	namespace := func(_ context.Context, name string) (map[string]string, map[string]string, error) {
+		read = append(read, name)
+		switch name {
+		case "prod":
+			return map[string]string{"env": "production"}, map[string]string{"owner": "platform"}, nil
+		case "broken":
+			return nil, nil, fmt.Errorf("connection refused")
+		}
+		return nil, nil, nil
+	}
+	rules := `rules:
+  - path: data.API_URL
+    template: '{{ index .TargetNamespaceLabels "env" | default "dev" }}.api.example.com'
+  - path: data.OWNER
+    template: '{{ index .TargetNamespaceAnnotations "owner" }}'
+  - path: data.CLUSTER
+    template: '{{ .ClusterName }}'
+  - path: data.TEAM
+    template: '{{ .SourceLabels.team }}'
+`
+	opts := DefaultTransformOptions()
+	opts.Namespace = namespace
+	opts.ClusterName = "prod-eu"
+	sourceLabels := map[string]string{"team": "payments"}
+
+	got := renderConfigMapRules(t, opts, nil, rules, TransformContext{TargetNamespace: "prod", SourceLabels: sourceLabels})
+	assert.Equal(t, map[string]string{
+		"API_URL": "production.api.example.com",
+		"OWNER":   "platform",
+		"CLUSTER": "prod-eu",
+		"TEAM":    "payments",
+	}, got)
+
+	got = renderConfigMapRules(t, opts, nil, rules, TransformContext{TargetNamespace: "dev", ClusterName: "staging"})
+	assert.Equal(t, "dev.api.example.com", got["API_URL"], "missing namespace yields empty maps")
+	assert.Equal(t, "staging", got["CLUSTER"], "the context's cluster name wins")
+	assert.Equal(t, []string{"prod", "dev"}, read)
+
+	source := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "app-config",
+			Namespace:   "default",
+			Annotations: map[string]string{constants.AnnotationTransform: rules},
+		},
+	}
+	_, err := NewTransformer(opts).Transform(source, TransformContext{TargetNamespace: "broken"})
+	require.Error(t, err, "unreadable namespaces fail even in non-strict mode")
+	assert.Contains(t, err.Error(), "reading target namespace broken failed")
+
+	plain := source.DeepCopy()
+	plain.Annotations = nil
+	_, err = NewTransformer(opts).Transform(plain, TransformContext{TargetNamespace: "broken"})
+	assert.NoError(t, err, "namespaces are only read for mirrors with transformations")
+}
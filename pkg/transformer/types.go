@@ -2,13 +2,21 @@
 package transformer
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
 )
 
 // TransformRules represents a collection of transformation rules.
 type TransformRules struct {
-	Rules []Rule `yaml:"rules"`
+	// APIVersion selects the rule schema version (empty = RulesAPIVersionV1)
+	APIVersion string `yaml:"apiVersion,omitempty"`
+	Rules      []Rule `yaml:"rules"`
 }
 
 // Rule represents a single transformation rule.
@@ -16,9 +24,48 @@ type Rule struct {
 	Value            *string                `yaml:"value,omitempty"`
 	Template         *string                `yaml:"template,omitempty"`
 	Merge            map[string]interface{} `yaml:"merge,omitempty"`
-	NamespacePattern *string                `yaml:"namespacePattern,omitempty"`
+	NamespacePattern NamespacePattern       `yaml:"namespacePattern,omitempty"`
 	Path             string                 `yaml:"path"`
 	Delete           bool                   `yaml:"delete,omitempty"`
+	// RewriteNamespaceRefs rewrites references to the source namespace into the
+	// target namespace, at Path or, without one, in the resource type's known
+	// namespace-bearing fields (see NamespaceRefFields)
+	RewriteNamespaceRefs bool `yaml:"rewriteNamespaceRefs,omitempty"`
+	// Regex rewrites the parts of the string values at Path matching a pattern
+	Regex *RegexRule `yaml:"regex,omitempty"`
+	// When is an expression the mirror must satisfy for the rule to apply, on top
+	// of NamespacePattern (see parseCondition)
+	When string `yaml:"when,omitempty"`
+	// DecodeBase64 treats the fields at Path as base64: value, template, merge and
+	// regex rules work on their decoded content and the result is encoded again
+	DecodeBase64 bool `yaml:"decodeBase64,omitempty"`
+}
+
+// NamespacePattern holds the target namespace globs a rule applies to.
+// In YAML it is either a single glob or a list; entries prefixed with "!" exclude
+// matching namespaces, e.g. ["prod-*", "!prod-eu-*"].
+type NamespacePattern []string
+
+// UnmarshalYAML accepts a single pattern string or a list of patterns.
+func (p *NamespacePattern) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		var pattern string
+		if err := node.Decode(&pattern); err != nil {
+			return err
+		}
+		*p = NamespacePattern{pattern}
+		return nil
+	case yaml.SequenceNode:
+		var patterns []string
+		if err := node.Decode(&patterns); err != nil {
+			return err
+		}
+		*p = patterns
+		return nil
+	default:
+		return fmt.Errorf("namespacePattern must be a string or a list of strings")
+	}
 }
 
 // TransformContext provides context variables for template evaluation.
@@ -29,6 +76,21 @@ type TransformContext struct {
 	SourceNamespace string
 	SourceName      string
 	TargetName      string
+
+	// SourceLabels are the source's labels, the same as Labels
+	SourceLabels map[string]string
+	// TargetNamespaceLabels and TargetNamespaceAnnotations are the metadata of the
+	// target namespace, read when the mirror is built (see TransformOptions.Namespace)
+	TargetNamespaceLabels      map[string]string
+	TargetNamespaceAnnotations map[string]string
+	// ClusterName names the cluster the mirror is written to (see TransformOptions.ClusterName)
+	ClusterName string
+	// Value is the decoded current value of the field a decodeBase64 template
+	// rule renders ("" for other rules, or a missing field)
+	Value string
+
+	// seed makes randAlphaNum stable for a source's content and target (set by Transform)
+	seed []byte
 }
 
 // TransformOptions configures the transformation behavior.
@@ -42,10 +104,65 @@ type TransformOptions struct {
 	// MaxRuleSize limits the size of each rule in bytes
 	MaxRuleSize int
 
-	// TemplateTimeout limits template execution time
+	// TemplateTimeout limits template execution time, including waiting for a
+	// TemplatePool worker
 	TemplateTimeout time.Duration
+
+	// MaxTemplateOutput limits the bytes one template execution may output (0 = no limit)
+	MaxTemplateOutput int
+
+	// MaxTemplateIterations limits the range iterations and template calls of one
+	// template execution (0 = no limit)
+	MaxTemplateIterations int
+
+	// TemplatePool bounds concurrent template executions (nil = no bound)
+	TemplatePool *TemplatePool
+
+	// DefaultRules are applied before the resource's own rules (see DefaultRules.For)
+	DefaultRules []Rule
+
+	// NamespaceRefFields are the namespace-bearing fields rewriteNamespaceRefs rules
+	// without a path rewrite (nil = the built-in fields)
+	NamespaceRefFields *NamespaceRefFields
+
+	// PruneFields are stripped from mirrors before the rules are applied (see PruneFields.For)
+	PruneFields []string
+
+	// Lookup fetches resources for the lookup template function (nil disables lookup)
+	Lookup LookupFunc
+
+	// LookupAllow lists "namespace/name" glob patterns the lookup function may read.
+	// Resources not matching any pattern are rejected, so an empty list disables lookup.
+	LookupAllow []string
+
+	// ContextConfigMap names the ConfigMap in each target namespace the contextValue
+	// function reads through Lookup (empty disables contextValue)
+	ContextConfigMap string
+
+	// Namespace reads the target namespace's labels and annotations into the
+	// template context (nil leaves them empty)
+	Namespace NamespaceFunc
+
+	// ClusterName is the template context's ClusterName, unless the context sets one
+	ClusterName string
+
+	// RuleLibrary reads the rule libraries sources reference with the transform-ref
+	// annotation (nil rejects such references)
+	RuleLibrary RuleLibraryFunc
 }
 
+// RuleLibraryFunc returns the rules of a rule library. It returns an error when the
+// library does not exist or its rules cannot be parsed.
+type RuleLibraryFunc func(ctx context.Context, namespace, name string) (*TransformRules, error)
+
+// NamespaceFunc returns the labels and annotations of a namespace. It returns nil
+// maps and no error when the namespace does not exist.
+type NamespaceFunc func(ctx context.Context, name string) (labels, annotations map[string]string, err error)
+
+// LookupFunc fetches the data of a resource referenced by the lookup template function.
+// It returns nil data and no error when the resource does not exist.
+type LookupFunc func(ctx context.Context, apiVersion, kind, namespace, name string) (map[string]string, error)
+
 // DefaultTransformOptions returns default transformation options.
 func DefaultTransformOptions() TransformOptions {
 	return TransformOptions{
@@ -53,12 +170,15 @@ func DefaultTransformOptions() TransformOptions {
 		MaxRules:        50,
 		MaxRuleSize:     10 * 1024, // 10KB
 		TemplateTimeout: 100 * time.Millisecond,
+		// A ConfigMap holds at most 1MiB, so no useful template outputs more
+		MaxTemplateOutput:     1024 * 1024,
+		MaxTemplateIterations: 10000,
 	}
 }
 
 // Validate checks if the rule is valid.
 func (r *Rule) Validate() error {
-	if r.Path == "" {
+	if r.Path == "" && !r.RewriteNamespaceRefs {
 		return fmt.Errorf("rule path cannot be empty")
 	}
 
@@ -76,13 +196,52 @@ func (r *Rule) Validate() error {
 	if r.Delete {
 		actionCount++
 	}
+	if r.RewriteNamespaceRefs {
+		actionCount++
+	}
+	if r.Regex != nil {
+		actionCount++
+	}
 
 	if actionCount == 0 {
-		return fmt.Errorf("rule must specify one of: value, template, merge, or delete")
+		return fmt.Errorf("rule must specify one of: value, template, merge, delete, rewriteNamespaceRefs, or regex")
 	}
 
 	if actionCount > 1 {
-		return fmt.Errorf("rule cannot specify multiple actions (value, template, merge, delete are mutually exclusive)")
+		return fmt.Errorf("rule cannot specify multiple actions (value, template, merge, delete, rewriteNamespaceRefs, regex are mutually exclusive)")
+	}
+
+	if r.DecodeBase64 && (r.Delete || r.RewriteNamespaceRefs) {
+		return fmt.Errorf("decodeBase64 applies to value, template, merge and regex rules only")
+	}
+
+	if r.RewriteNamespaceRefs && r.Path != "" {
+		if _, err := parseFieldPath(r.Path); err != nil {
+			return err
+		}
+	}
+
+	if r.Regex != nil {
+		if _, err := parseFieldPath(r.Path); err != nil {
+			return err
+		}
+		if err := r.Regex.validate(); err != nil {
+			return err
+		}
+	}
+
+	if r.When != "" {
+		if _, err := parseCondition(r.When); err != nil {
+			return err
+		}
+	}
+
+	for _, pattern := range r.NamespacePattern {
+		if pattern = strings.TrimPrefix(pattern, "!"); filter.IsRegexPattern(pattern) {
+			if _, err := filter.CompileRegexPattern(pattern); err != nil {
+				return fmt.Errorf("invalid namespacePattern: %w", err)
+			}
+		}
 	}
 
 	return nil
@@ -99,6 +258,10 @@ func (r *Rule) Type() RuleType {
 		return RuleTypeMerge
 	case r.Delete:
 		return RuleTypeDelete
+	case r.RewriteNamespaceRefs:
+		return RuleTypeRewriteNamespaceRefs
+	case r.Regex != nil:
+		return RuleTypeRegex
 	default:
 		return RuleTypeUnknown
 	}
@@ -122,6 +285,12 @@ const (
 
 	// RuleTypeDelete removes a field
 	RuleTypeDelete
+
+	// RuleTypeRewriteNamespaceRefs points namespace references at the target namespace
+	RuleTypeRewriteNamespaceRefs
+
+	// RuleTypeRegex rewrites the parts of string values matching a pattern
+	RuleTypeRegex
 )
 
 // String returns the string representation of the rule type.
@@ -135,6 +304,10 @@ func (rt RuleType) String() string {
 		return "merge"
 	case RuleTypeDelete:
 		return "delete"
+	case RuleTypeRewriteNamespaceRefs:
+		return "rewriteNamespaceRefs"
+	case RuleTypeRegex:
+		return "regex"
 	default:
 		return "unknown"
 	}
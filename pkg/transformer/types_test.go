@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestRule_Validate(t *testing.T) {
@@ -115,6 +117,77 @@ func TestRule_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:    "rewriteNamespaceRefs without path",
+			rule:    Rule{RewriteNamespaceRefs: true},
+			wantErr: false,
+		},
+		{
+			name: "rewriteNamespaceRefs with invalid path",
+			rule: Rule{
+				Path:                 "spec.refs[x].namespace",
+				RewriteNamespaceRefs: true,
+			},
+			wantErr: true,
+			errMsg:  "invalid index",
+		},
+		{
+			name:    "valid regex rule",
+			rule:    Rule{Path: "data", Regex: &RegexRule{Pattern: `default\.svc`, Replacement: "team-a.svc"}},
+			wantErr: false,
+		},
+		{
+			name:    "regex without pattern",
+			rule:    Rule{Path: "data", Regex: &RegexRule{Replacement: "x"}},
+			wantErr: true,
+			errMsg:  "pattern cannot be empty",
+		},
+		{
+			name:    "regex with invalid pattern",
+			rule:    Rule{Path: "data", Regex: &RegexRule{Pattern: "(unclosed"}},
+			wantErr: true,
+			errMsg:  "invalid regex pattern",
+		},
+		{
+			name:    "regex without path",
+			rule:    Rule{Regex: &RegexRule{Pattern: "x"}},
+			wantErr: true,
+			errMsg:  "path cannot be empty",
+		},
+		{
+			name:    "regex with another action",
+			rule:    Rule{Path: "data.KEY", Value: stringPtr("v"), Regex: &RegexRule{Pattern: "x"}},
+			wantErr: true,
+			errMsg:  "cannot specify multiple actions",
+		},
+		{
+			name:    "valid when condition",
+			rule:    Rule{Path: "data.KEY", Value: stringPtr("v"), When: `targetNamespace startsWith "prod-"`},
+			wantErr: false,
+		},
+		{
+			name:    "invalid when condition",
+			rule:    Rule{Path: "data.KEY", Value: stringPtr("v"), When: `namespace == "prod"`},
+			wantErr: true,
+			errMsg:  "unknown variable",
+		},
+		{
+			name:    "decodeBase64 on a delete rule",
+			rule:    Rule{Path: "data.KEY", Delete: true, DecodeBase64: true},
+			wantErr: true,
+			errMsg:  "decodeBase64 applies to value, template, merge and regex rules only",
+		},
+		{
+			name:    "valid regex namespacePattern",
+			rule:    Rule{Path: "data.KEY", Value: stringPtr("v"), NamespacePattern: NamespacePattern{"re:^team-(a|b)-prod$", "!re:-eu$"}},
+			wantErr: false,
+		},
+		{
+			name:    "invalid regex namespacePattern",
+			rule:    Rule{Path: "data.KEY", Value: stringPtr("v"), NamespacePattern: NamespacePattern{"!re:team-("}},
+			wantErr: true,
+			errMsg:  "invalid namespacePattern: invalid regular expression",
+		},
 	}
 
 	for _, tt := range tests {
@@ -171,6 +244,16 @@ func TestRule_Type(t *testing.T) {
 			},
 			wantType: RuleTypeDelete,
 		},
+		{
+			name:     "rewriteNamespaceRefs rule",
+			rule:     Rule{RewriteNamespaceRefs: true},
+			wantType: RuleTypeRewriteNamespaceRefs,
+		},
+		{
+			name:     "regex rule",
+			rule:     Rule{Path: "data", Regex: &RegexRule{Pattern: "x"}},
+			wantType: RuleTypeRegex,
+		},
 		{
 			name: "unknown rule (no action)",
 			rule: Rule{
@@ -198,6 +281,8 @@ func TestRuleType_String(t *testing.T) {
 		{name: "template", ruleType: RuleTypeTemplate, want: "template"},
 		{name: "merge", ruleType: RuleTypeMerge, want: "merge"},
 		{name: "delete", ruleType: RuleTypeDelete, want: "delete"},
+		{name: "rewriteNamespaceRefs", ruleType: RuleTypeRewriteNamespaceRefs, want: "rewriteNamespaceRefs"},
+		{name: "regex", ruleType: RuleTypeRegex, want: "regex"},
 		{name: "unknown", ruleType: RuleTypeUnknown, want: "unknown"},
 	}
 
@@ -218,6 +303,34 @@ func TestDefaultTransformOptions(t *testing.T) {
 	assert.Equal(t, 100*time.Millisecond, opts.TemplateTimeout, "default timeout should be 100ms")
 }
 
+func TestNamespacePattern_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    NamespacePattern
+		wantErr bool
+	}{
+		{name: "single string", input: `namespacePattern: "prod-*"`, want: NamespacePattern{"prod-*"}},
+		{name: "flow list", input: `namespacePattern: ["prod-*", "!prod-eu-*"]`, want: NamespacePattern{"prod-*", "!prod-eu-*"}},
+		{name: "block list", input: "namespacePattern:\n  - prod-*\n  - '!prod-eu-*'", want: NamespacePattern{"prod-*", "!prod-eu-*"}},
+		{name: "omitted", input: `path: data.x`, want: nil},
+		{name: "map is invalid", input: "namespacePattern:\n  include: prod-*", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rule Rule
+			err := yaml.Unmarshal([]byte(tt.input), &rule)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rule.NamespacePattern)
+		})
+	}
+}
+
 // stringPtr is a helper to create string pointers
 func stringPtr(s string) *string {
 	return &s
@@ -0,0 +1,68 @@
+package transformer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// RulesAPIVersionV1 is the current transform rules schema version.
+	// Rules without an apiVersion are parsed as v1.
+	RulesAPIVersionV1 = "kubemirror.raczylo.com/v1"
+)
+
+// rulesParsers maps each supported apiVersion to the parser for its syntax.
+// New rule syntax gets a new version and parser, so existing annotations keep working.
+var rulesParsers = map[string]func(data []byte) (*TransformRules, error){
+	RulesAPIVersionV1: parseRulesV1,
+}
+
+// ParseRules parses transformation rules YAML, dispatching on its apiVersion.
+func ParseRules(data []byte) (*TransformRules, error) {
+	var header struct {
+		APIVersion string `yaml:"apiVersion"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	version := header.APIVersion
+	if version == "" {
+		version = RulesAPIVersionV1
+	}
+
+	parse, ok := rulesParsers[version]
+	if !ok {
+		return nil, fmt.Errorf("unsupported transform rules apiVersion %q (supported: %s)",
+			header.APIVersion, strings.Join(SupportedRulesAPIVersions(), ", "))
+	}
+
+	rules, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	rules.APIVersion = version
+	return rules, nil
+}
+
+// SupportedRulesAPIVersions returns the rule schema versions this build understands.
+func SupportedRulesAPIVersions() []string {
+	versions := make([]string, 0, len(rulesParsers))
+	for v := range rulesParsers {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// parseRulesV1 parses the v1 rule syntax.
+func parseRulesV1(data []byte) (*TransformRules, error) {
+	var rules TransformRules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	return &rules, nil
+}
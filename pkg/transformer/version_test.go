@@ -0,0 +1,84 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantVersion string
+		wantRules   int
+		errMsg      string
+	}{
+		{
+			name:        "no apiVersion defaults to v1",
+			input:       "rules:\n  - path: data.x\n    value: y\n",
+			wantVersion: RulesAPIVersionV1,
+			wantRules:   1,
+		},
+		{
+			name:        "explicit v1",
+			input:       "apiVersion: kubemirror.raczylo.com/v1\nrules:\n  - path: data.x\n    delete: true\n",
+			wantVersion: RulesAPIVersionV1,
+			wantRules:   1,
+		},
+		{
+			name:   "unknown version",
+			input:  "apiVersion: kubemirror.raczylo.com/v9\nrules: []\n",
+			errMsg: `unsupported transform rules apiVersion "kubemirror.raczylo.com/v9"`,
+		},
+		{
+			name:   "malformed yaml",
+			input:  "rules: [",
+			errMsg: "failed to parse YAML",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseRules([]byte(tt.input))
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantVersion, rules.APIVersion)
+			assert.Len(t, rules.Rules, tt.wantRules)
+		})
+	}
+}
+
+func TestSupportedRulesAPIVersions(t *testing.T) {
+	assert.Equal(t, []string{RulesAPIVersionV1}, SupportedRulesAPIVersions())
+}
+
+func TestTransformer_UnknownRulesVersion(t *testing.T) {
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+			Annotations: map[string]string{
+				constants.AnnotationTransform: "apiVersion: kubemirror.raczylo.com/v2\nrules:\n  - path: data.x\n    value: y\n",
+			},
+		},
+		Data: map[string]string{"x": "original"},
+	}
+
+	result, err := NewDefaultTransformer().Transform(source, TransformContext{TargetNamespace: "prod"})
+	require.NoError(t, err, "non-strict mode ignores rules it cannot parse")
+	assert.Same(t, source, result)
+
+	source.Annotations[constants.AnnotationTransformStrict] = "true"
+	_, err = NewDefaultTransformer().Transform(source, TransformContext{TargetNamespace: "prod"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported transform rules apiVersion")
+}
@@ -0,0 +1,90 @@
+package transformer
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+// wildcardIndex is the array segment matching every item of a list.
+const wildcardIndex = "[*]"
+
+// isWildcardSegment reports whether a path segment matches more than one field:
+// "[*]" matches every list item and a key with * or ? matches map keys as a glob.
+func isWildcardSegment(segment string) bool {
+	if isArrayIndex(segment) {
+		return segment == wildcardIndex
+	}
+	return strings.ContainsAny(segment, "*?")
+}
+
+// hasWildcard reports whether any segment of path is a wildcard.
+func hasWildcard(path []string) bool {
+	return slices.ContainsFunc(path, isWildcardSegment)
+}
+
+// expandPath returns the concrete paths in obj that path matches. A path without
+// wildcards is returned as is, so rules can still create the field it names.
+// Wildcards only match fields that exist; the segments after the last wildcard
+// may name missing fields, which the rule then creates under every match.
+// Paths are returned in key and index order.
+func expandPath(obj map[string]interface{}, path []string) [][]string {
+	if !hasWildcard(path) {
+		return [][]string{path}
+	}
+
+	var paths [][]string
+	var walk func(value interface{}, i int, prefix []string)
+	walk = func(value interface{}, i int, prefix []string) {
+		if !hasWildcard(path[i:]) {
+			paths = append(paths, append(slices.Clone(prefix), path[i:]...))
+			return
+		}
+
+		segment := path[i]
+		if isArrayIndex(segment) {
+			items, ok := value.([]interface{})
+			if !ok {
+				return
+			}
+			if segment == wildcardIndex {
+				for index, item := range items {
+					walk(item, i+1, append(prefix, fmt.Sprintf("[%d]", index)))
+				}
+				return
+			}
+			index, err := parseArrayIndex(segment)
+			if err != nil || index < 0 || index >= len(items) {
+				return
+			}
+			walk(items[index], i+1, append(prefix, segment))
+			return
+		}
+
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		if !isWildcardSegment(segment) {
+			if child, found := fields[segment]; found {
+				walk(child, i+1, append(prefix, segment))
+			}
+			return
+		}
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			if filter.MatchGlob(segment, key) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walk(fields[key], i+1, append(prefix, key))
+		}
+	}
+	walk(obj, 0, nil)
+	return paths
+}
@@ -0,0 +1,242 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestExpandPath(t *testing.T) {
+	obj := map[string]interface{}{
+		"data": map[string]interface{}{
+			"API_URL":   "a",
+			"CACHE_URL": "b",
+			"TIMEOUT":   "c",
+		},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "app:1"},
+				map[string]interface{}{"name": "sidecar", "image": "proxy:1"},
+			},
+			"replicas": int64(1),
+		},
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want [][]string
+	}{
+		{
+			name: "no wildcard",
+			path: "data.MISSING",
+			want: [][]string{{"data", "MISSING"}},
+		},
+		{
+			name: "key glob",
+			path: "data.*_URL",
+			want: [][]string{{"data", "API_URL"}, {"data", "CACHE_URL"}},
+		},
+		{
+			name: "single character glob",
+			path: "data.?PI_URL",
+			want: [][]string{{"data", "API_URL"}},
+		},
+		{
+			name: "every list item",
+			path: "spec.containers[*].image",
+			want: [][]string{{"spec", "containers", "[0]", "image"}, {"spec", "containers", "[1]", "image"}},
+		},
+		{
+			name: "missing field after the last wildcard",
+			path: "spec.containers[*].imagePullPolicy",
+			want: [][]string{{"spec", "containers", "[0]", "imagePullPolicy"}, {"spec", "containers", "[1]", "imagePullPolicy"}},
+		},
+		{
+			name: "index before a wildcard",
+			path: "spec.containers[1].*",
+			want: [][]string{{"spec", "containers", "[1]", "image"}, {"spec", "containers", "[1]", "name"}},
+		},
+		{
+			name: "no match",
+			path: "data.*_HOST",
+			want: nil,
+		},
+		{
+			name: "missing field before a wildcard",
+			path: "spec.initContainers[*].image",
+			want: nil,
+		},
+		{
+			name: "list wildcard on a non-list",
+			path: "spec.replicas[*]",
+			want: nil,
+		},
+		{
+			name: "index out of range",
+			path: "spec.containers[5].*",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, expandPath(obj, parsePath(tt.path)))
+		})
+	}
+}
+
+func TestTransformer_WildcardPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  string
+		fields map[string]interface{}
+		want   map[string]interface{}
+	}{
+		{
+			name: "value rule on matching keys",
+			rules: `rules:
+  - path: data.*_URL
+    value: "http://example.com"
+`,
+			fields: map[string]interface{}{"data": map[string]interface{}{"API_URL": "a", "CACHE_URL": "b", "TIMEOUT": "30s"}},
+			want:   map[string]interface{}{"data": map[string]interface{}{"API_URL": "http://example.com", "CACHE_URL": "http://example.com", "TIMEOUT": "30s"}},
+		},
+		{
+			name: "template rule on every list item",
+			rules: `rules:
+  - path: spec.containers[*].image
+    template: "registry.{{ .TargetNamespace }}.local/app"
+`,
+			fields: map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "app:1"},
+				map[string]interface{}{"name": "sidecar", "image": "proxy:1"},
+			}}},
+			want: map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "registry.team-a.local/app"},
+				map[string]interface{}{"name": "sidecar", "image": "registry.team-a.local/app"},
+			}}},
+		},
+		{
+			name: "field created under every match",
+			rules: `rules:
+  - path: spec.containers[*].imagePullPolicy
+    value: Always
+`,
+			fields: map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+				map[string]interface{}{"name": "app"},
+				map[string]interface{}{"name": "sidecar"},
+			}}},
+			want: map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+				map[string]interface{}{"name": "app", "imagePullPolicy": "Always"},
+				map[string]interface{}{"name": "sidecar", "imagePullPolicy": "Always"},
+			}}},
+		},
+		{
+			name: "delete rule on matching keys",
+			rules: `rules:
+  - path: data.DEBUG_*
+    delete: true
+`,
+			fields: map[string]interface{}{"data": map[string]interface{}{"DEBUG_LEVEL": "trace", "DEBUG_PORT": "9000", "PORT": "8080"}},
+			want:   map[string]interface{}{"data": map[string]interface{}{"PORT": "8080"}},
+		},
+		{
+			name: "merge rule on matching maps",
+			rules: `rules:
+  - path: spec.*Selector
+    merge:
+      mirrored: "true"
+`,
+			fields: map[string]interface{}{"spec": map[string]interface{}{
+				"podSelector":  map[string]interface{}{"app": "web"},
+				"nodeSelector": map[string]interface{}{"zone": "a"},
+			}},
+			want: map[string]interface{}{"spec": map[string]interface{}{
+				"podSelector":  map[string]interface{}{"app": "web", "mirrored": "true"},
+				"nodeSelector": map[string]interface{}{"zone": "a", "mirrored": "true"},
+			}},
+		},
+		{
+			name: "no match leaves the object alone",
+			rules: `rules:
+  - path: data.*_HOST
+    value: "example.com"
+`,
+			fields: map[string]interface{}{"data": map[string]interface{}{"API_URL": "a"}},
+			want:   map[string]interface{}{"data": map[string]interface{}{"API_URL": "a"}},
+		},
+		{
+			name: "regex rule on matching keys",
+			rules: `rules:
+  - path: data.*_URL
+    regex:
+      pattern: 'default'
+      replacement: '{{ .TargetNamespace }}'
+`,
+			fields: map[string]interface{}{"data": map[string]interface{}{"API_URL": "api.default.svc", "NAMESPACE": "default"}},
+			want:   map[string]interface{}{"data": map[string]interface{}{"API_URL": "api.team-a.svc", "NAMESPACE": "default"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newNamespacedObject("v1", "ConfigMap", tt.rules, tt.fields)
+			annotations := source.GetAnnotations()
+			annotations[constants.AnnotationTransformStrict] = "true"
+			source.SetAnnotations(annotations)
+
+			result, err := NewDefaultTransformer().Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "team-a"})
+			require.NoError(t, err)
+			got := result.(*unstructured.Unstructured).Object
+			for field, want := range tt.want {
+				assert.Equal(t, want, got[field])
+			}
+		})
+	}
+}
+
+func TestTransformer_WildcardSecretData(t *testing.T) {
+	rules := `rules:
+  - path: data.*_PASSWORD
+    value: "redacted"
+`
+	source := newNamespacedObject("v1", "Secret", rules, map[string]interface{}{
+		"data": map[string]interface{}{
+			"DB_PASSWORD":    base64Encode("hunter2"),
+			"CACHE_PASSWORD": base64Encode("swordfish"),
+			"USER":           base64Encode("app"),
+		},
+	})
+
+	result, err := NewDefaultTransformer().Transform(source, TransformContext{SourceNamespace: "default", TargetNamespace: "team-a"})
+	require.NoError(t, err)
+	data, _, _ := unstructured.NestedStringMap(result.(*unstructured.Unstructured).Object, "data")
+	assert.Equal(t, base64Encode("redacted"), data["DB_PASSWORD"])
+	assert.Equal(t, base64Encode("redacted"), data["CACHE_PASSWORD"])
+	assert.Equal(t, base64Encode("app"), data["USER"])
+}
+
+func TestTransformer_WildcardTemplateScope(t *testing.T) {
+	rules := `rules:
+  - path: data.*_TOKEN
+    template: "{{ randAlphaNum 16 }}"
+`
+	source := newNamespacedObject("v1", "ConfigMap", rules, map[string]interface{}{
+		"data": map[string]interface{}{"A_TOKEN": "", "B_TOKEN": ""},
+	})
+
+	ctx := TransformContext{SourceNamespace: "default", TargetNamespace: "team-a"}
+	first, err := NewDefaultTransformer().Transform(source.DeepCopy(), ctx)
+	require.NoError(t, err)
+	second, err := NewDefaultTransformer().Transform(source.DeepCopy(), ctx)
+	require.NoError(t, err)
+
+	data, _, _ := unstructured.NestedStringMap(first.(*unstructured.Unstructured).Object, "data")
+	assert.NotEqual(t, data["A_TOKEN"], data["B_TOKEN"], "each match gets its own value")
+	again, _, _ := unstructured.NestedStringMap(second.(*unstructured.Unstructured).Object, "data")
+	assert.Equal(t, data, again, "values are stable across reconciles")
+}
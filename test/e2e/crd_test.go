@@ -0,0 +1,51 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+func TestAutoDiscovery(t *testing.T) {
+	if profile != profileKind {
+		t.Skip("auto-discovery runs with E2E_PROFILE=kind")
+	}
+
+	assert.Contains(t, resourceTypes, config.ResourceType{Version: "v1", Kind: "Secret"})
+	assert.Contains(t, resourceTypes, config.ResourceType{Group: widgetGVK.Group, Version: widgetGVK.Version, Kind: widgetGVK.Kind},
+		"custom resources are discovered")
+	assert.NotContains(t, resourceTypes, config.ResourceType{Version: "v1", Kind: "Pod"}, "denied types are not discovered")
+}
+
+func TestCustomResourceMirroring(t *testing.T) {
+	ctx := context.Background()
+	sourceNs := createNamespace(t, "source", nil)
+	teamA := createNamespace(t, "team-a", nil)
+
+	source := makeSource(widgetGVK, sourceNs, "blue-widget", teamA)
+	source.Object["spec"] = map[string]interface{}{"size": int64(3), "color": "blue"}
+	require.NoError(t, k8sClient.Create(ctx, source))
+
+	eventually(t, func(c *assert.CollectT) {
+		mirror, err := getObject(widgetGVK, teamA, "blue-widget")
+		if !assert.NoError(c, err) {
+			return
+		}
+		assert.Equal(c, constants.ControllerName, mirror.GetLabels()[constants.LabelManagedBy])
+		spec, _, _ := unstructured.NestedMap(mirror.Object, "spec")
+		assert.Equal(c, map[string]interface{}{"size": int64(3), "color": "blue"}, spec)
+	}, "custom resources are mirrored")
+
+	require.NoError(t, k8sClient.Delete(ctx, source))
+	eventually(t, func(c *assert.CollectT) {
+		assertGone(c, widgetGVK, teamA, "blue-widget")
+	}, "deleting the custom resource source removes its mirror")
+}
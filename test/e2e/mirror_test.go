@@ -0,0 +1,155 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/rand"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+)
+
+var secretGVK = schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+
+// eventually retries check until it passes or the controllers had waitTimeout to converge.
+func eventually(t *testing.T, check func(c *assert.CollectT), msgAndArgs ...interface{}) {
+	t.Helper()
+	require.EventuallyWithT(t, check, waitTimeout, pollInterval, msgAndArgs...)
+}
+
+// mirrorData returns the data of the mirror of a Secret in namespace.
+func mirrorData(c *assert.CollectT, namespace, name string) map[string]string {
+	mirror, err := getObject(secretGVK, namespace, name)
+	if !assert.NoError(c, err) {
+		return nil
+	}
+	assert.Equal(c, constants.ControllerName, mirror.GetLabels()[constants.LabelManagedBy])
+	data, _, _ := unstructured.NestedStringMap(mirror.Object, "data")
+	return data
+}
+
+// assertGone checks the object of gvk no longer exists.
+func assertGone(c *assert.CollectT, gvk schema.GroupVersionKind, namespace, name string) {
+	_, err := getObject(gvk, namespace, name)
+	assert.True(c, apierrors.IsNotFound(err), "expected %s %s/%s to be gone, got %v", gvk.Kind, namespace, name, err)
+}
+
+func TestMirrorCreateAndUpdate(t *testing.T) {
+	ctx := context.Background()
+	sourceNs := createNamespace(t, "source", nil)
+	teamA := createNamespace(t, "team-a", nil)
+	teamB := createNamespace(t, "team-b", nil)
+
+	source := makeSource(secretGVK, sourceNs, "credentials", teamA+","+teamB)
+	source.Object["data"] = map[string]interface{}{"password": "djE="}
+	require.NoError(t, k8sClient.Create(ctx, source))
+
+	eventually(t, func(c *assert.CollectT) {
+		for _, ns := range []string{teamA, teamB} {
+			assert.Equal(c, map[string]string{"password": "djE="}, mirrorData(c, ns, "credentials"))
+		}
+	}, "mirrors are created in every target")
+
+	eventually(t, func(c *assert.CollectT) {
+		current, err := getObject(secretGVK, sourceNs, "credentials")
+		if !assert.NoError(c, err) {
+			return
+		}
+		current.Object["data"] = map[string]interface{}{"password": "djI="}
+		assert.NoError(c, k8sClient.Update(ctx, current))
+	})
+	eventually(t, func(c *assert.CollectT) {
+		for _, ns := range []string{teamA, teamB} {
+			assert.Equal(c, map[string]string{"password": "djI="}, mirrorData(c, ns, "credentials"))
+		}
+	}, "mirrors follow source updates")
+}
+
+func TestOrphanedMirrorsRemoved(t *testing.T) {
+	ctx := context.Background()
+	sourceNs := createNamespace(t, "source", nil)
+	teamA := createNamespace(t, "team-a", nil)
+	teamB := createNamespace(t, "team-b", nil)
+
+	source := makeSource(secretGVK, sourceNs, "credentials", teamA+","+teamB)
+	source.Object["data"] = map[string]interface{}{"password": "djE="}
+	require.NoError(t, k8sClient.Create(ctx, source))
+	eventually(t, func(c *assert.CollectT) {
+		mirrorData(c, teamA, "credentials")
+		mirrorData(c, teamB, "credentials")
+	})
+
+	// Dropping a target removes its mirror
+	eventually(t, func(c *assert.CollectT) {
+		current, err := getObject(secretGVK, sourceNs, "credentials")
+		if !assert.NoError(c, err) {
+			return
+		}
+		annotations := current.GetAnnotations()
+		annotations[constants.AnnotationTargetNamespaces] = teamA
+		current.SetAnnotations(annotations)
+		assert.NoError(c, k8sClient.Update(ctx, current))
+	})
+	eventually(t, func(c *assert.CollectT) {
+		assertGone(c, secretGVK, teamB, "credentials")
+		mirrorData(c, teamA, "credentials")
+	}, "the mirror in the dropped target is removed")
+
+	// A mirror whose source is gone is removed by the mirror reconciler
+	orphan := makeSource(secretGVK, teamB, "stray", "")
+	orphan.SetLabels(map[string]string{constants.LabelManagedBy: constants.ControllerName, constants.LabelMirror: "true"})
+	orphan.SetAnnotations(map[string]string{
+		constants.AnnotationSourceNamespace: sourceNs,
+		constants.AnnotationSourceName:      "missing",
+	})
+	require.NoError(t, k8sClient.Create(ctx, orphan))
+	eventually(t, func(c *assert.CollectT) {
+		assertGone(c, secretGVK, teamB, "stray")
+	}, "orphaned mirrors are deleted")
+}
+
+func TestFinalizerRemovesMirrors(t *testing.T) {
+	ctx := context.Background()
+	sourceNs := createNamespace(t, "source", nil)
+	teamA := createNamespace(t, "team-a", nil)
+
+	source := makeSource(secretGVK, sourceNs, "credentials", teamA)
+	source.Object["data"] = map[string]interface{}{"password": "djE="}
+	require.NoError(t, k8sClient.Create(ctx, source))
+
+	eventually(t, func(c *assert.CollectT) {
+		current, err := getObject(secretGVK, sourceNs, "credentials")
+		if assert.NoError(c, err) {
+			assert.Contains(c, current.GetFinalizers(), constants.FinalizerName)
+		}
+		mirrorData(c, teamA, "credentials")
+	}, "the source gets the finalizer once mirrored")
+
+	require.NoError(t, k8sClient.Delete(ctx, source))
+	eventually(t, func(c *assert.CollectT) {
+		assertGone(c, secretGVK, teamA, "credentials")
+		assertGone(c, secretGVK, sourceNs, "credentials")
+	}, "deleting the source removes its mirrors, then the finalizer")
+}
+
+func TestNewNamespaceReceivesMirrors(t *testing.T) {
+	ctx := context.Background()
+	sourceNs := createNamespace(t, "source", nil)
+	prefix := "late-" + rand.String(4)
+
+	source := makeSource(secretGVK, sourceNs, "credentials", prefix+"-*")
+	source.Object["data"] = map[string]interface{}{"password": "djE="}
+	require.NoError(t, k8sClient.Create(ctx, source))
+
+	late := createNamespace(t, prefix, nil)
+	eventually(t, func(c *assert.CollectT) {
+		assert.Equal(c, map[string]string{"password": "djE="}, mirrorData(c, late, "credentials"))
+	}, "a namespace created after the source matching its pattern gets the mirror")
+}
@@ -0,0 +1,263 @@
+//go:build e2e
+
+// Package e2e runs kubemirror's reconcilers against a real API server: the
+// kube-apiserver and etcd of envtest by default, or an existing cluster such as
+// kind with E2E_PROFILE=kind. Run it with make test-e2e or make test-e2e-kind.
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"github.com/lukaszraczylo/kubemirror/pkg/circuitbreaker"
+	"github.com/lukaszraczylo/kubemirror/pkg/config"
+	"github.com/lukaszraczylo/kubemirror/pkg/constants"
+	"github.com/lukaszraczylo/kubemirror/pkg/controller"
+	"github.com/lukaszraczylo/kubemirror/pkg/discovery"
+	"github.com/lukaszraczylo/kubemirror/pkg/filter"
+)
+
+// Test profiles, selected with E2E_PROFILE.
+const (
+	// profileEnvtest starts a local kube-apiserver and etcd from KUBEBUILDER_ASSETS (default)
+	profileEnvtest = "envtest"
+	// profileKind uses the cluster of the current kubeconfig, e.g. a kind cluster,
+	// and auto-discovers the resource types to mirror
+	profileKind = "kind"
+)
+
+// Timeouts for the controllers to converge.
+const (
+	waitTimeout  = 30 * time.Second
+	pollInterval = 250 * time.Millisecond
+)
+
+// widgetGVK is the custom resource installed from testdata to exercise CRD mirroring.
+var widgetGVK = schema.GroupVersionKind{Group: "e2e.kubemirror.raczylo.com", Version: "v1", Kind: "Widget"}
+
+var (
+	profile       string
+	k8sClient     client.Client
+	resourceTypes []config.ResourceType
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	profile = os.Getenv("E2E_PROFILE")
+	if profile == "" {
+		profile = profileEnvtest
+	}
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "..", "charts", "kubemirror", "crds"),
+			filepath.Join("testdata", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+	switch profile {
+	case profileEnvtest:
+		if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+			fmt.Fprintln(os.Stderr, "skipping e2e tests: KUBEBUILDER_ASSETS is not set (use make test-e2e)")
+			return 0
+		}
+	case profileKind:
+		existing := true
+		testEnv.UseExistingCluster = &existing
+	default:
+		fmt.Fprintf(os.Stderr, "unknown E2E_PROFILE %q (expected %s or %s)\n", profile, profileEnvtest, profileKind)
+		return 1
+	}
+
+	ctrl.SetLogger(zap.New(zap.UseDevMode(true), zap.WriteTo(os.Stderr)))
+
+	restConfig, err := testEnv.Start()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start test environment: %v\n", err)
+		return 1
+	}
+	defer func() {
+		if err := testEnv.Stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to stop test environment: %v\n", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := startManager(ctx, restConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start manager: %v\n", err)
+		return 1
+	}
+	return m.Run()
+}
+
+// startManager registers the source, mirror and namespace reconcilers the way
+// eager watcher initialization does and starts them in the background.
+func startManager(ctx context.Context, restConfig *rest.Config) error {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		return err
+	}
+
+	resourceTypes = []config.ResourceType{
+		{Version: "v1", Kind: "Secret"},
+		{Version: "v1", Kind: "ConfigMap"},
+		{Group: widgetGVK.Group, Version: widgetGVK.Version, Kind: widgetGVK.Kind},
+	}
+	if profile == profileKind {
+		discovered, err := discoverResourceTypes(ctx, restConfig)
+		if err != nil {
+			return err
+		}
+		resourceTypes = discovered
+	}
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
+		Scheme:                 scheme,
+		Metrics:                metricsserver.Options{BindAddress: "0"},
+		HealthProbeBindAddress: "0",
+	})
+	if err != nil {
+		return err
+	}
+	k8sClient = mgr.GetClient()
+
+	cfg := &config.Config{
+		MaxTargetsPerResource: 100,
+		WorkerThreads:         2,
+		EnableAllKeyword:      true,
+		MirroredResourceTypes: resourceTypes,
+	}
+	namespaceFilter := filter.NewNamespaceFilter([]string{"kube-system", "kube-public", "kube-node-lease"}, nil)
+	namespaceLister := controller.NewKubernetesNamespaceListerWithAPIReader(mgr.GetClient(), mgr.GetAPIReader())
+	cb := circuitbreaker.NewWithDefaults()
+	recorder := mgr.GetEventRecorder(constants.ControllerName)
+
+	for _, rt := range resourceTypes {
+		gvk := rt.GroupVersionKind()
+		source := &controller.SourceReconciler{
+			Client:          mgr.GetClient(),
+			Scheme:          mgr.GetScheme(),
+			Config:          cfg,
+			Filter:          namespaceFilter,
+			NamespaceLister: namespaceLister,
+			GVK:             gvk,
+			APIReader:       mgr.GetAPIReader(),
+			CircuitBreaker:  cb,
+			Recorder:        recorder,
+			ResourceTypes:   func() []config.ResourceType { return resourceTypes },
+		}
+		if err := source.SetupWithManagerForResourceType(mgr, gvk); err != nil {
+			return fmt.Errorf("failed to set up source controller for %s: %w", rt, err)
+		}
+		mirror := &controller.MirrorReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			GVK:      gvk,
+			Config:   cfg,
+			Recorder: recorder,
+		}
+		if err := mirror.SetupWithManager(mgr, gvk); err != nil {
+			return fmt.Errorf("failed to set up mirror controller for %s: %w", rt, err)
+		}
+	}
+
+	namespaces := &controller.NamespaceReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Config:          cfg,
+		Filter:          namespaceFilter,
+		NamespaceLister: namespaceLister,
+		ResourceTypes:   resourceTypes,
+		APIReader:       mgr.GetAPIReader(),
+		Recorder:        recorder,
+	}
+	if err := namespaces.SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("failed to set up namespace controller: %w", err)
+	}
+
+	go func() {
+		if err := mgr.Start(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "manager stopped: %v\n", err)
+		}
+	}()
+	if !mgr.GetCache().WaitForCacheSync(ctx) {
+		return fmt.Errorf("cache did not sync")
+	}
+	return nil
+}
+
+// discoverResourceTypes runs auto-discovery against the cluster, as kubemirror
+// does without --resource-types.
+func discoverResourceTypes(ctx context.Context, restConfig *rest.Config) ([]config.ResourceType, error) {
+	discoveryClient, err := discovery.NewResourceDiscovery(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	discoveryMgr := discovery.NewManager(discoveryClient, time.Hour)
+	if err := discoveryMgr.Start(ctx); err != nil {
+		return nil, err
+	}
+	if err := discoveryMgr.WaitForInitialDiscovery(ctx, waitTimeout); err != nil {
+		return nil, err
+	}
+	return discoveryMgr.GetCurrentResources(), nil
+}
+
+// createNamespace creates a namespace with a random suffix, deleted after the test.
+func createNamespace(t *testing.T, prefix string, labels map[string]string) string {
+	t.Helper()
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   prefix + "-" + rand.String(5),
+		Labels: labels,
+	}}
+	if err := k8sClient.Create(context.Background(), ns); err != nil {
+		t.Fatalf("failed to create namespace: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = k8sClient.Delete(context.Background(), ns)
+	})
+	return ns.Name
+}
+
+// makeSource returns a source of gvk mirrored to targets.
+func makeSource(gvk schema.GroupVersionKind, namespace, name, targets string) *unstructured.Unstructured {
+	source := &unstructured.Unstructured{}
+	source.SetGroupVersionKind(gvk)
+	source.SetNamespace(namespace)
+	source.SetName(name)
+	source.SetLabels(map[string]string{constants.LabelEnabled: "true"})
+	source.SetAnnotations(map[string]string{
+		constants.AnnotationSync:             "true",
+		constants.AnnotationTargetNamespaces: targets,
+	})
+	return source
+}
+
+// getObject reads the object of gvk, returning it and the error of the read.
+func getObject(gvk schema.GroupVersionKind, namespace, name string) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	err := k8sClient.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, obj)
+	return obj, err
+}